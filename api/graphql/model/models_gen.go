@@ -6,6 +6,11 @@ import (
 	"seta-training/internal/models"
 )
 
+type ColumnMappingInput struct {
+	Column string `json:"column"`
+	Field  string `json:"field"`
+}
+
 type CreateUserInput struct {
 	Username string          `json:"username"`
 	Email    string          `json:"email"`
@@ -13,6 +18,35 @@ type CreateUserInput struct {
 	Role     models.UserRole `json:"role"`
 }
 
+type ImportConfigInput struct {
+	WorkerCount    *int                  `json:"workerCount,omitempty"`
+	BatchSize      *int                  `json:"batchSize,omitempty"`
+	MaxRecords     *int                  `json:"maxRecords,omitempty"`
+	TimeoutSeconds *int                  `json:"timeoutSeconds,omitempty"`
+	SkipDuplicates *bool                 `json:"skipDuplicates,omitempty"`
+	ColumnMapping  []*ColumnMappingInput `json:"columnMapping,omitempty"`
+	DefaultRole    *models.UserRole      `json:"defaultRole,omitempty"`
+	Delimiter      *string               `json:"delimiter,omitempty"`
+}
+
+type ImportResult struct {
+	Line     int     `json:"line"`
+	Username string  `json:"username"`
+	Email    string  `json:"email"`
+	Success  bool    `json:"success"`
+	Error    *string `json:"error,omitempty"`
+	UserID   *string `json:"userId,omitempty"`
+}
+
+type ImportSummary struct {
+	TotalRecords   int             `json:"totalRecords"`
+	SuccessCount   int             `json:"successCount"`
+	FailureCount   int             `json:"failureCount"`
+	ProcessingTime string          `json:"processingTime"`
+	Results        []*ImportResult `json:"results"`
+	Errors         []string        `json:"errors"`
+}
+
 type LoginInput struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
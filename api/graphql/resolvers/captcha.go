@@ -0,0 +1,12 @@
+package resolvers
+
+import "context"
+
+// checkCaptcha asks the configured guard, if any, whether the request may
+// log in or sign up
+func (r *Resolver) checkCaptcha(ctx context.Context) error {
+	if r.Captcha == nil {
+		return nil
+	}
+	return r.Captcha.Check(ctx)
+}
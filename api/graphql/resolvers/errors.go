@@ -0,0 +1,37 @@
+package resolvers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"seta-training/internal/apperrors"
+	"seta-training/pkg/i18n"
+)
+
+// ErrorPresenter adds the REST error code to typed errors as
+// extensions.code, so GraphQL clients can branch on the same codes, and
+// translates their messages like the REST error envelope
+func ErrorPresenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		return gqlErr
+	}
+	if gqlErr.Extensions == nil {
+		gqlErr.Extensions = make(map[string]interface{})
+	}
+	if localizer := i18n.FromContext(ctx); localizer != nil {
+		untranslated := appErr.Message
+		appErr = appErr.Localize(localizer.T)
+		if gqlErr.Message == untranslated {
+			gqlErr.Message = appErr.Message
+		}
+	}
+	gqlErr.Extensions["code"] = appErr.Code
+	if len(appErr.Fields) > 0 {
+		gqlErr.Extensions["details"] = appErr.Fields
+	}
+	return gqlErr
+}
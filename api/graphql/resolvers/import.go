@@ -0,0 +1,120 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"seta-training/api/graphql/model"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/services"
+	"seta-training/pkg/upload"
+)
+
+// csvUploads accepts import files like the REST import does
+var csvUploads = upload.NewValidator(services.MaxImportFileSize, upload.TypeCSV)
+
+// validateImportFile checks an uploaded file by its content and rewinds it
+func validateImportFile(file graphql.Upload) error {
+	_, err := csvUploads.Validate(file.File, file.Size)
+	return err
+}
+
+// importConfigFromInput applies input to the default import config. Unlike
+// the REST form, whose out-of-range values fall back to the defaults, invalid
+// fields are reported.
+func importConfigFromInput(input *model.ImportConfigInput) (services.ImportConfig, error) {
+	config := services.DefaultImportConfig()
+	if input == nil {
+		return config, nil
+	}
+
+	fields := make(map[string]string)
+	setBounded := func(name string, value *int, upper int, set func(int)) {
+		if value == nil {
+			return
+		}
+		if *value < 1 || *value > upper {
+			fields[name] = fmt.Sprintf("must be between 1 and %d", upper)
+			return
+		}
+		set(*value)
+	}
+	setBounded("workerCount", input.WorkerCount, 20, func(v int) { config.WorkerCount = v })
+	setBounded("batchSize", input.BatchSize, 1000, func(v int) { config.BatchSize = v })
+	setBounded("maxRecords", input.MaxRecords, 10000, func(v int) { config.MaxRecords = v })
+	setBounded("timeoutSeconds", input.TimeoutSeconds, 300, func(v int) { config.Timeout = time.Duration(v) * time.Second })
+
+	if input.SkipDuplicates != nil {
+		config.SkipDuplicates = *input.SkipDuplicates
+	}
+	if input.DefaultRole != nil {
+		config.DefaultRole = string(*input.DefaultRole)
+	}
+	if input.Delimiter != nil {
+		delimiter, err := services.ParseImportDelimiter(*input.Delimiter)
+		if err != nil {
+			fields["delimiter"] = apperrors.From(err).Message
+		} else {
+			config.Delimiter = delimiter
+		}
+	}
+
+	if len(input.ColumnMapping) > 0 {
+		config.ColumnMapping = make(map[string]string, len(input.ColumnMapping))
+		for _, mapping := range input.ColumnMapping {
+			if !slices.Contains(services.ImportFields, strings.ToLower(strings.TrimSpace(mapping.Field))) {
+				fields["columnMapping"] = fmt.Sprintf("column %q is mapped to unknown field %q. Allowed fields: %v", mapping.Column, mapping.Field, services.ImportFields)
+				continue
+			}
+			config.ColumnMapping[mapping.Column] = mapping.Field
+		}
+	}
+
+	if len(fields) > 0 {
+		return config, apperrors.ValidationFields("Invalid import config", fields)
+	}
+	return config, nil
+}
+
+// importSummary converts the service summary to its GraphQL type
+func importSummary(summary *services.ImportSummary) *model.ImportSummary {
+	result := &model.ImportSummary{
+		TotalRecords:   summary.TotalRecords,
+		SuccessCount:   summary.SuccessCount,
+		FailureCount:   summary.FailureCount,
+		ProcessingTime: summary.ProcessingTime,
+		Results:        make([]*model.ImportResult, len(summary.Results)),
+		Errors:         summary.Errors,
+	}
+	if result.Errors == nil {
+		result.Errors = []string{}
+	}
+	for i, r := range summary.Results {
+		result.Results[i] = &model.ImportResult{
+			Line:     r.Record.LineNum,
+			Username: r.Record.Username,
+			Email:    r.Record.Email,
+			Success:  r.Success,
+		}
+		if r.Error != "" {
+			result.Results[i].Error = &r.Error
+		}
+		if r.UserID != "" {
+			result.Results[i].UserID = &r.UserID
+		}
+	}
+	return result
+}
+
+// notifyImport reports a finished import to the configured notifier, if any
+func (r *Resolver) notifyImport(ctx context.Context, event services.ImportEvent) {
+	if r.ImportNotifier == nil {
+		return
+	}
+	event.FinishedAt = time.Now().UTC()
+	r.ImportNotifier.ImportFinished(ctx, event)
+}
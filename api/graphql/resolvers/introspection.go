@@ -0,0 +1,35 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"seta-training/pkg/auth"
+)
+
+// IntrospectionPolicy is a gqlgen extension serving schema introspection
+// only to authenticated callers, and to nobody unless Enabled. It must be
+// used after extension.Introspection, which enables it for everyone.
+type IntrospectionPolicy struct {
+	Enabled bool
+}
+
+var _ interface {
+	graphql.OperationContextMutator
+	graphql.HandlerExtension
+} = IntrospectionPolicy{}
+
+func (IntrospectionPolicy) ExtensionName() string {
+	return "IntrospectionPolicy"
+}
+
+func (IntrospectionPolicy) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (p IntrospectionPolicy) MutateOperationContext(ctx context.Context, opCtx *graphql.OperationContext) *gqlerror.Error {
+	_, authenticated := auth.FromContext(ctx)
+	opCtx.DisableIntrospection = !p.Enabled || !authenticated
+	return nil
+}
@@ -10,4 +10,9 @@ import (
 
 type Resolver struct{
 	UserService *services.UserService
+	ImportService services.ImportServiceInterface
+	// ImportNotifier is told about finished imports; it may be nil
+	ImportNotifier services.ImportNotifier
+	// Captcha guards login and signup; it may be nil
+	Captcha services.CaptchaChecker
 }
@@ -9,8 +9,13 @@ import (
 	"fmt"
 	"seta-training/api/graphql/generated"
 	"seta-training/api/graphql/model"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/models"
 	"seta-training/internal/services"
+	"seta-training/pkg/auth"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/google/uuid"
 )
 
 // CreateUser is the resolver for the createUser field.
@@ -21,18 +26,28 @@ func (r *mutationResolver) CreateUser(ctx context.Context, input model.CreateUse
 		Password: input.Password,
 		Role:     models.UserRole(input.Role),
 	}
+	// Users created by a signed-in user join their organization; anyone
+	// else is signing up
+	if claims, ok := auth.FromContext(ctx); ok {
+		serviceInput.OrganizationID = claims.OrgID
+	} else if err := r.checkCaptcha(ctx); err != nil {
+		return nil, err
+	}
 
-	return r.UserService.CreateUser(serviceInput)
+	return r.UserService.CreateUser(ctx, serviceInput)
 }
 
 // Login is the resolver for the login field.
 func (r *mutationResolver) Login(ctx context.Context, input model.LoginInput) (*model.LoginResponse, error) {
+	if err := r.checkCaptcha(ctx); err != nil {
+		return nil, err
+	}
 	serviceInput := &services.LoginInput{
 		Email:    input.Email,
 		Password: input.Password,
 	}
 
-	response, err := r.UserService.Login(serviceInput)
+	response, err := r.UserService.Login(ctx, serviceInput)
 	if err != nil {
 		return nil, err
 	}
@@ -50,9 +65,60 @@ func (r *mutationResolver) Logout(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+// ImportUsers is the resolver for the importUsers field.
+func (r *mutationResolver) ImportUsers(ctx context.Context, file graphql.Upload, config *model.ImportConfigInput) (*model.ImportSummary, error) {
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, apperrors.Unauthorized("Authentication required")
+	}
+	if !claims.HasScope(auth.ScopeUsersImport) {
+		return nil, apperrors.Forbidden("Token lacks the %s scope", auth.ScopeUsersImport)
+	}
+	if claims.Role != models.RoleManager {
+		return nil, apperrors.Forbidden("Only managers can import users")
+	}
+	if err := validateImportFile(file); err != nil {
+		return nil, err
+	}
+	importConfig, err := importConfigFromInput(config)
+	if err != nil {
+		return nil, err
+	}
+	importConfig.OrganizationID = claims.OrgID
+
+	// Like the REST import, the import is not cancelled if the client
+	// disconnects
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), importConfig.Timeout)
+	defer cancel()
+
+	summary, err := r.ImportService.ImportUsersFromCSV(ctx, file.File, importConfig)
+	if err != nil {
+		r.notifyImport(ctx, services.ImportEvent{
+			Event:     services.ImportEventFailed,
+			ManagerID: claims.UserID.String(),
+			Filename:  file.Filename,
+			Error:     err.Error(),
+		})
+		return nil, err
+	}
+	r.notifyImport(ctx, services.ImportEvent{
+		Event:     services.ImportEventCompleted,
+		ManagerID: claims.UserID.String(),
+		Filename:  file.Filename,
+		Summary:   summary,
+	})
+	return importSummary(summary), nil
+}
+
 // FetchUsers is the resolver for the fetchUsers field.
 func (r *queryResolver) FetchUsers(ctx context.Context) ([]*models.User, error) {
-	users, err := r.UserService.GetAllUsers()
+	// Only users of the caller's organization are listed; anonymous callers
+	// see the default tenant
+	var orgID *uuid.UUID
+	if claims, ok := auth.FromContext(ctx); ok {
+		orgID = claims.OrgID
+	}
+	users, err := r.UserService.GetAllUsers(ctx, orgID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"seta-training/api/grpc/setav1"
+	"seta-training/internal/apperrors"
+	"seta-training/pkg/auth"
+)
+
+// TokenValidator checks the bearer tokens calls carry
+type TokenValidator interface {
+	ValidateToken(tokenString string) (*auth.Claims, error)
+}
+
+// servicePrefix covers the methods that require a token
+const servicePrefix = "/seta.v1."
+
+// methodScopes are the token scopes methods require beyond authentication,
+// matching the REST routes
+var methodScopes = map[string]string{
+	setav1.TeamService_CreateTeam_FullMethodName:    auth.ScopeTeamsManage,
+	setav1.TeamService_AddMember_FullMethodName:     auth.ScopeTeamsManage,
+	setav1.TeamService_RemoveMember_FullMethodName:  auth.ScopeTeamsManage,
+	setav1.TeamService_AddManager_FullMethodName:    auth.ScopeTeamsManage,
+	setav1.TeamService_RemoveManager_FullMethodName: auth.ScopeTeamsManage,
+}
+
+// authenticate validates the "authorization: Bearer <token>" metadata of
+// calls to the API services and stores the claims in the context
+func authenticate(tokens TokenValidator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if !strings.HasPrefix(info.FullMethod, servicePrefix) {
+			return handler(ctx, req)
+		}
+
+		token := bearerToken(ctx)
+		if token == "" {
+			return nil, apperrors.Unauthorized("Authorization token required")
+		}
+		claims, err := tokens.ValidateToken(token)
+		if err != nil {
+			return nil, apperrors.Unauthorized("Invalid or expired token")
+		}
+		if scope, ok := methodScopes[info.FullMethod]; ok && !claims.HasScope(scope) {
+			return nil, apperrors.Forbidden("Token lacks the %s scope", scope)
+		}
+		return handler(auth.NewContext(ctx, claims), req)
+	}
+}
+
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for _, value := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(value, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// currentUser returns the claims authenticate stored in ctx
+func currentUser(ctx context.Context) (*auth.Claims, error) {
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, apperrors.Unauthorized("Authentication required")
+	}
+	return claims, nil
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"seta-training/api/grpc/setav1"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
+)
+
+// parseID parses the ID of the named resource
+func parseID(value, what string) (uuid.UUID, error) {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, apperrors.Validation("Invalid %s ID", what)
+	}
+	return id, nil
+}
+
+// validate checks input against its binding tags, as the REST handlers do
+// when binding JSON
+func validate(input interface{}) error {
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		return apperrors.FromBinding(err)
+	}
+	return nil
+}
+
+// pageParams reads a page request with the rules of the REST limit and
+// cursor query parameters
+func pageParams(page *setav1.PageRequest) (pagination.Params, error) {
+	var p pagination.Params
+	fields := make(map[string]string)
+
+	if limit := page.GetLimit(); limit != 0 {
+		if limit < 0 || limit > pagination.MaxLimit {
+			fields["limit"] = "must be between 1 and " + strconv.Itoa(pagination.MaxLimit)
+		}
+		p.Limit = int(limit)
+	}
+	if cursor := page.GetCursor(); cursor != "" {
+		after, err := pagination.Decode(cursor)
+		if err != nil {
+			fields["cursor"] = "must be a cursor returned in next_cursor"
+		}
+		p.After = &after
+	}
+
+	if len(fields) > 0 {
+		return p, apperrors.ValidationFields("Invalid page", fields)
+	}
+	return p, nil
+}
+
+func optionalID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func userMessage(user *models.User) *setav1.User {
+	return &setav1.User{
+		Id:             user.ID.String(),
+		Username:       user.Username,
+		Email:          user.Email,
+		Role:           string(user.Role),
+		OrganizationId: optionalID(user.OrganizationID),
+		CreatedAt:      timestamp(user.CreatedAt),
+		UpdatedAt:      timestamp(user.UpdatedAt),
+	}
+}
+
+func userMessages(users []models.User) []*setav1.User {
+	messages := make([]*setav1.User, len(users))
+	for i := range users {
+		messages[i] = userMessage(&users[i])
+	}
+	return messages
+}
+
+// teamMessage converts a team. The message predates team roles, so admins
+// are listed with the managers and viewers with the members.
+func teamMessage(team *models.Team) *setav1.Team {
+	return &setav1.Team{
+		Id:             team.ID.String(),
+		Name:           team.Name,
+		OrganizationId: optionalID(team.OrganizationID),
+		Managers:       userMessages(append(slices.Clone(team.Admins), team.Managers...)),
+		Members:        userMessages(append(slices.Clone(team.Members), team.Viewers...)),
+		CreatedAt:      timestamp(team.CreatedAt),
+		UpdatedAt:      timestamp(team.UpdatedAt),
+	}
+}
+
+func folderMessage(folder *models.Folder) *setav1.Folder {
+	return &setav1.Folder{
+		Id:             folder.ID.String(),
+		Name:           folder.Name,
+		OwnerId:        folder.OwnerID.String(),
+		OrganizationId: optionalID(folder.OrganizationID),
+		CreatedAt:      timestamp(folder.CreatedAt),
+		UpdatedAt:      timestamp(folder.UpdatedAt),
+	}
+}
+
+func noteMessage(note *models.Note) *setav1.Note {
+	return &setav1.Note{
+		Id:        note.ID.String(),
+		Title:     note.Title,
+		Body:      note.Body,
+		FolderId:  note.FolderID.String(),
+		OwnerId:   note.OwnerID.String(),
+		CreatedAt: timestamp(note.CreatedAt),
+		UpdatedAt: timestamp(note.UpdatedAt),
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"seta-training/internal/apperrors"
+	"seta-training/pkg/logger"
+)
+
+// grpcCodes maps error codes to the gRPC status codes clients branch on
+var grpcCodes = map[apperrors.Code]codes.Code{
+	apperrors.CodeValidation:      codes.InvalidArgument,
+	apperrors.CodeUnauthorized:    codes.Unauthenticated,
+	apperrors.CodeForbidden:       codes.PermissionDenied,
+	apperrors.CodeQuotaExceeded:   codes.ResourceExhausted,
+	apperrors.CodeNotFound:        codes.NotFound,
+	apperrors.CodeConflict:        codes.AlreadyExists,
+	apperrors.CodePayloadTooLarge: codes.ResourceExhausted,
+	apperrors.CodeUnprocessable:   codes.FailedPrecondition,
+	apperrors.CodeRateLimited:     codes.ResourceExhausted,
+	apperrors.CodeUnavailable:     codes.Unavailable,
+	apperrors.CodeTimeout:         codes.DeadlineExceeded,
+	apperrors.CodeInternal:        codes.Internal,
+}
+
+// logCalls logs every call and turns the errors services return into gRPC
+// statuses, with per-field validation messages as BadRequest details.
+// Internal causes are logged, never sent.
+func logCalls(log logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		st := statusOf(err)
+
+		fields := []logger.Field{
+			logger.String("method", info.FullMethod),
+			logger.String("code", st.Code().String()),
+			logger.Duration("latency", time.Since(start)),
+		}
+		if st.Code() == codes.Internal {
+			log.Error("gRPC Request", append(fields, logger.Error(err))...)
+		} else {
+			log.Info("gRPC Request", fields...)
+		}
+		return resp, st.Err()
+	}
+}
+
+func statusOf(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	appErr := apperrors.From(err)
+	code, ok := grpcCodes[appErr.Code]
+	if !ok {
+		code = codes.Internal
+	}
+	st := status.New(code, appErr.Message)
+	if len(appErr.Fields) == 0 {
+		return st
+	}
+
+	details := &errdetails.BadRequest{}
+	for field, msg := range appErr.Fields {
+		details.FieldViolations = append(details.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: msg,
+		})
+	}
+	if withDetails, err := st.WithDetails(details); err == nil {
+		return withDetails
+	}
+	return st
+}
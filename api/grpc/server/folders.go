@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"seta-training/api/grpc/setav1"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+)
+
+type folderServer struct {
+	setav1.UnimplementedFolderServiceServer
+	folders services.FolderServiceInterface
+}
+
+func (s *folderServer) CreateFolder(ctx context.Context, req *setav1.CreateFolderRequest) (*setav1.Folder, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	input := &services.CreateFolderInput{Name: req.GetName()}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	folder, err := s.folders.CreateFolder(ctx, input, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return folderMessage(folder), nil
+}
+
+func (s *folderServer) GetFolder(ctx context.Context, req *setav1.GetFolderRequest) (*setav1.Folder, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folderID, err := parseID(req.GetId(), "folder")
+	if err != nil {
+		return nil, err
+	}
+	folder, err := s.folders.GetFolder(ctx, folderID, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return folderMessage(folder), nil
+}
+
+func (s *folderServer) ListFolders(ctx context.Context, _ *setav1.ListFoldersRequest) (*setav1.ListFoldersResponse, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folders, err := s.folders.GetUserFolders(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &setav1.ListFoldersResponse{}
+	for i := range folders {
+		resp.Folders = append(resp.Folders, folderMessage(&folders[i]))
+	}
+	return resp, nil
+}
+
+func (s *folderServer) UpdateFolder(ctx context.Context, req *setav1.UpdateFolderRequest) (*setav1.Folder, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folderID, err := parseID(req.GetId(), "folder")
+	if err != nil {
+		return nil, err
+	}
+	input := &services.UpdateFolderInput{Name: req.GetName()}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	folder, err := s.folders.UpdateFolder(ctx, folderID, input, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return folderMessage(folder), nil
+}
+
+func (s *folderServer) DeleteFolder(ctx context.Context, req *setav1.DeleteFolderRequest) (*emptypb.Empty, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folderID, err := parseID(req.GetId(), "folder")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.folders.DeleteFolder(ctx, folderID, claims.UserID); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *folderServer) ShareFolder(ctx context.Context, req *setav1.ShareFolderRequest) (*emptypb.Empty, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folderID, err := parseID(req.GetFolderId(), "folder")
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseID(req.GetShare().GetUserId(), "user")
+	if err != nil {
+		return nil, err
+	}
+	input := &services.ShareFolderInput{UserID: userID, Access: models.AccessLevel(req.GetShare().GetAccess())}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	if _, err := s.folders.ShareFolder(ctx, folderID, input, claims.UserID); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *folderServer) RevokeFolderShare(ctx context.Context, req *setav1.RevokeFolderShareRequest) (*emptypb.Empty, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folderID, err := parseID(req.GetFolderId(), "folder")
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseID(req.GetUserId(), "user")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.folders.RevokeShare(ctx, folderID, userID, claims.UserID); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"seta-training/api/grpc/setav1"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+)
+
+type noteServer struct {
+	setav1.UnimplementedNoteServiceServer
+	notes services.NoteServiceInterface
+}
+
+func (s *noteServer) CreateNote(ctx context.Context, req *setav1.CreateNoteRequest) (*setav1.Note, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	folderID, err := parseID(req.GetFolderId(), "folder")
+	if err != nil {
+		return nil, err
+	}
+	input := &services.CreateNoteInput{Title: req.GetTitle(), Body: req.GetBody()}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	note, err := s.notes.CreateNote(ctx, folderID, input, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return noteMessage(note), nil
+}
+
+func (s *noteServer) GetNote(ctx context.Context, req *setav1.GetNoteRequest) (*setav1.Note, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	noteID, err := parseID(req.GetId(), "note")
+	if err != nil {
+		return nil, err
+	}
+	note, err := s.notes.GetNote(ctx, noteID, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return noteMessage(note), nil
+}
+
+func (s *noteServer) ListNotes(ctx context.Context, req *setav1.ListNotesRequest) (*setav1.ListNotesResponse, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params, err := pageParams(req.GetPage())
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.notes.ListOwnedNotes(ctx, claims.UserID, models.NoteFilter{}, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &setav1.ListNotesResponse{NextCursor: page.Next}
+	for i := range page.Items {
+		resp.Notes = append(resp.Notes, noteMessage(&page.Items[i]))
+	}
+	return resp, nil
+}
+
+func (s *noteServer) UpdateNote(ctx context.Context, req *setav1.UpdateNoteRequest) (*setav1.Note, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	noteID, err := parseID(req.GetId(), "note")
+	if err != nil {
+		return nil, err
+	}
+	input := &services.UpdateNoteInput{Title: req.GetTitle(), Body: req.GetBody()}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	note, err := s.notes.UpdateNote(ctx, noteID, input, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return noteMessage(note), nil
+}
+
+func (s *noteServer) DeleteNote(ctx context.Context, req *setav1.DeleteNoteRequest) (*emptypb.Empty, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	noteID, err := parseID(req.GetId(), "note")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.notes.DeleteNote(ctx, noteID, claims.UserID); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *noteServer) ShareNote(ctx context.Context, req *setav1.ShareNoteRequest) (*emptypb.Empty, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	noteID, err := parseID(req.GetNoteId(), "note")
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseID(req.GetShare().GetUserId(), "user")
+	if err != nil {
+		return nil, err
+	}
+	input := &services.ShareNoteInput{UserID: userID, Access: models.AccessLevel(req.GetShare().GetAccess())}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+	if _, err := s.notes.ShareNote(ctx, noteID, input, claims.UserID); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *noteServer) RevokeNoteShare(ctx context.Context, req *setav1.RevokeNoteShareRequest) (*emptypb.Empty, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	noteID, err := parseID(req.GetNoteId(), "note")
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseID(req.GetUserId(), "user")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.notes.RevokeShare(ctx, noteID, userID, claims.UserID); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,46 @@
+// Package server implements the gRPC API defined in api/grpc/proto on top
+// of the same services as the REST handlers, for internal services that
+// would rather not speak HTTP/JSON.
+package server
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"seta-training/api/grpc/setav1"
+	"seta-training/internal/services"
+	"seta-training/pkg/logger"
+)
+
+// New returns a gRPC server exposing the user, team, folder and note
+// services. Calls must carry a token from tokens in their authorization
+// metadata; the standard health and reflection services are open. log may
+// be nil.
+func New(
+	userService services.UserServiceInterface,
+	teamService services.TeamServiceInterface,
+	folderService services.FolderServiceInterface,
+	noteService services.NoteServiceInterface,
+	tokens TokenValidator,
+	log logger.Logger,
+	opts ...grpc.ServerOption,
+) *grpc.Server {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		logCalls(log),
+		authenticate(tokens),
+	))
+	srv := grpc.NewServer(opts...)
+
+	setav1.RegisterUserServiceServer(srv, &userServer{users: userService})
+	setav1.RegisterTeamServiceServer(srv, &teamServer{teams: teamService})
+	setav1.RegisterFolderServiceServer(srv, &folderServer{folders: folderService})
+	setav1.RegisterNoteServiceServer(srv, &noteServer{notes: noteService})
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	return srv
+}
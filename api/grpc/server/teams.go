@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"seta-training/api/grpc/setav1"
+	"seta-training/internal/services"
+)
+
+type teamServer struct {
+	setav1.UnimplementedTeamServiceServer
+	teams services.TeamServiceInterface
+}
+
+func (s *teamServer) CreateTeam(ctx context.Context, req *setav1.CreateTeamRequest) (*setav1.Team, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	input := &services.CreateTeamInput{Name: req.GetName()}
+	for _, value := range req.GetManagerIds() {
+		id, err := parseID(value, "manager")
+		if err != nil {
+			return nil, err
+		}
+		input.Managers = append(input.Managers, services.TeamMemberInput{ID: id})
+	}
+	for _, value := range req.GetMemberIds() {
+		id, err := parseID(value, "member")
+		if err != nil {
+			return nil, err
+		}
+		input.Members = append(input.Members, services.TeamMemberInput{ID: id})
+	}
+	if err := validate(input); err != nil {
+		return nil, err
+	}
+
+	team, err := s.teams.CreateTeam(ctx, input, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return teamMessage(team), nil
+}
+
+func (s *teamServer) GetTeam(ctx context.Context, req *setav1.GetTeamRequest) (*setav1.Team, error) {
+	teamID, err := parseID(req.GetId(), "team")
+	if err != nil {
+		return nil, err
+	}
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	team, err := s.teams.GetTeam(ctx, teamID, claims.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	return teamMessage(team), nil
+}
+
+func (s *teamServer) ListTeams(ctx context.Context, req *setav1.ListTeamsRequest) (*setav1.ListTeamsResponse, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	params, err := pageParams(req.GetPage())
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.teams.ListTeams(ctx, claims.OrgID, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &setav1.ListTeamsResponse{NextCursor: page.Next}
+	for i := range page.Items {
+		resp.Teams = append(resp.Teams, teamMessage(&page.Items[i]))
+	}
+	return resp, nil
+}
+
+func (s *teamServer) AddMember(ctx context.Context, req *setav1.TeamUserRequest) (*emptypb.Empty, error) {
+	return s.changeMembership(ctx, req, s.teams.AddMember)
+}
+
+func (s *teamServer) RemoveMember(ctx context.Context, req *setav1.TeamUserRequest) (*emptypb.Empty, error) {
+	return s.changeMembership(ctx, req, s.teams.RemoveMember)
+}
+
+func (s *teamServer) AddManager(ctx context.Context, req *setav1.TeamUserRequest) (*emptypb.Empty, error) {
+	return s.changeMembership(ctx, req, s.teams.AddManager)
+}
+
+func (s *teamServer) RemoveManager(ctx context.Context, req *setav1.TeamUserRequest) (*emptypb.Empty, error) {
+	return s.changeMembership(ctx, req, s.teams.RemoveManager)
+}
+
+// changeMembership applies change to the team and user of req on behalf of
+// the caller
+func (s *teamServer) changeMembership(ctx context.Context, req *setav1.TeamUserRequest, change func(ctx context.Context, teamID, userID, actorID uuid.UUID) error) (*emptypb.Empty, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	teamID, err := parseID(req.GetTeamId(), "team")
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseID(req.GetUserId(), "user")
+	if err != nil {
+		return nil, err
+	}
+	if err := change(ctx, teamID, userID, claims.UserID); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"seta-training/api/grpc/setav1"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/services"
+)
+
+type userServer struct {
+	setav1.UnimplementedUserServiceServer
+	users services.UserServiceInterface
+}
+
+func (s *userServer) GetCurrentUser(ctx context.Context, _ *setav1.GetCurrentUserRequest) (*setav1.User, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return userMessage(user), nil
+}
+
+// GetUser returns a user of the caller's organization
+func (s *userServer) GetUser(ctx context.Context, req *setav1.GetUserRequest) (*setav1.User, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := parseID(req.GetId(), "user")
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !sameOrganization(user.OrganizationID, claims.OrgID) {
+		return nil, apperrors.NotFound("user not found")
+	}
+	return userMessage(user), nil
+}
+
+// ListUsers returns the users of the caller's organization
+func (s *userServer) ListUsers(ctx context.Context, _ *setav1.ListUsersRequest) (*setav1.ListUsersResponse, error) {
+	claims, err := currentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	users, err := s.users.GetAllUsers(ctx, claims.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	return &setav1.ListUsersResponse{Users: userMessages(users)}, nil
+}
+
+// sameOrganization reports whether a and b name the same organization; nil
+// is the default tenant
+func sameOrganization(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
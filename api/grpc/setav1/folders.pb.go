@@ -0,0 +1,498 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: seta/v1/folders.proto
+
+package setav1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateFolderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateFolderRequest) Reset() {
+	*x = CreateFolderRequest{}
+	mi := &file_seta_v1_folders_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateFolderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateFolderRequest) ProtoMessage() {}
+
+func (x *CreateFolderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_folders_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateFolderRequest.ProtoReflect.Descriptor instead.
+func (*CreateFolderRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_folders_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateFolderRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetFolderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFolderRequest) Reset() {
+	*x = GetFolderRequest{}
+	mi := &file_seta_v1_folders_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFolderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFolderRequest) ProtoMessage() {}
+
+func (x *GetFolderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_folders_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFolderRequest.ProtoReflect.Descriptor instead.
+func (*GetFolderRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_folders_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetFolderRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListFoldersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFoldersRequest) Reset() {
+	*x = ListFoldersRequest{}
+	mi := &file_seta_v1_folders_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFoldersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFoldersRequest) ProtoMessage() {}
+
+func (x *ListFoldersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_folders_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFoldersRequest.ProtoReflect.Descriptor instead.
+func (*ListFoldersRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_folders_proto_rawDescGZIP(), []int{2}
+}
+
+type ListFoldersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Folders       []*Folder              `protobuf:"bytes,1,rep,name=folders,proto3" json:"folders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFoldersResponse) Reset() {
+	*x = ListFoldersResponse{}
+	mi := &file_seta_v1_folders_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFoldersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFoldersResponse) ProtoMessage() {}
+
+func (x *ListFoldersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_folders_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFoldersResponse.ProtoReflect.Descriptor instead.
+func (*ListFoldersResponse) Descriptor() ([]byte, []int) {
+	return file_seta_v1_folders_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListFoldersResponse) GetFolders() []*Folder {
+	if x != nil {
+		return x.Folders
+	}
+	return nil
+}
+
+type UpdateFolderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateFolderRequest) Reset() {
+	*x = UpdateFolderRequest{}
+	mi := &file_seta_v1_folders_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateFolderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateFolderRequest) ProtoMessage() {}
+
+func (x *UpdateFolderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_folders_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateFolderRequest.ProtoReflect.Descriptor instead.
+func (*UpdateFolderRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_folders_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateFolderRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateFolderRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DeleteFolderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteFolderRequest) Reset() {
+	*x = DeleteFolderRequest{}
+	mi := &file_seta_v1_folders_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteFolderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteFolderRequest) ProtoMessage() {}
+
+func (x *DeleteFolderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_folders_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteFolderRequest.ProtoReflect.Descriptor instead.
+func (*DeleteFolderRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_folders_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteFolderRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ShareFolderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FolderId      string                 `protobuf:"bytes,1,opt,name=folder_id,json=folderId,proto3" json:"folder_id,omitempty"`
+	Share         *Share                 `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShareFolderRequest) Reset() {
+	*x = ShareFolderRequest{}
+	mi := &file_seta_v1_folders_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShareFolderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareFolderRequest) ProtoMessage() {}
+
+func (x *ShareFolderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_folders_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareFolderRequest.ProtoReflect.Descriptor instead.
+func (*ShareFolderRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_folders_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ShareFolderRequest) GetFolderId() string {
+	if x != nil {
+		return x.FolderId
+	}
+	return ""
+}
+
+func (x *ShareFolderRequest) GetShare() *Share {
+	if x != nil {
+		return x.Share
+	}
+	return nil
+}
+
+type RevokeFolderShareRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FolderId      string                 `protobuf:"bytes,1,opt,name=folder_id,json=folderId,proto3" json:"folder_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeFolderShareRequest) Reset() {
+	*x = RevokeFolderShareRequest{}
+	mi := &file_seta_v1_folders_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeFolderShareRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeFolderShareRequest) ProtoMessage() {}
+
+func (x *RevokeFolderShareRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_folders_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeFolderShareRequest.ProtoReflect.Descriptor instead.
+func (*RevokeFolderShareRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_folders_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RevokeFolderShareRequest) GetFolderId() string {
+	if x != nil {
+		return x.FolderId
+	}
+	return ""
+}
+
+func (x *RevokeFolderShareRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+var File_seta_v1_folders_proto protoreflect.FileDescriptor
+
+const file_seta_v1_folders_proto_rawDesc = "" +
+	"\n" +
+	"\x15seta/v1/folders.proto\x12\aseta.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x14seta/v1/common.proto\")\n" +
+	"\x13CreateFolderRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\"\n" +
+	"\x10GetFolderRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x14\n" +
+	"\x12ListFoldersRequest\"@\n" +
+	"\x13ListFoldersResponse\x12)\n" +
+	"\afolders\x18\x01 \x03(\v2\x0f.seta.v1.FolderR\afolders\"9\n" +
+	"\x13UpdateFolderRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"%\n" +
+	"\x13DeleteFolderRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"W\n" +
+	"\x12ShareFolderRequest\x12\x1b\n" +
+	"\tfolder_id\x18\x01 \x01(\tR\bfolderId\x12$\n" +
+	"\x05share\x18\x02 \x01(\v2\x0e.seta.v1.ShareR\x05share\"P\n" +
+	"\x18RevokeFolderShareRequest\x12\x1b\n" +
+	"\tfolder_id\x18\x01 \x01(\tR\bfolderId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId2\xea\x03\n" +
+	"\rFolderService\x12=\n" +
+	"\fCreateFolder\x12\x1c.seta.v1.CreateFolderRequest\x1a\x0f.seta.v1.Folder\x127\n" +
+	"\tGetFolder\x12\x19.seta.v1.GetFolderRequest\x1a\x0f.seta.v1.Folder\x12H\n" +
+	"\vListFolders\x12\x1b.seta.v1.ListFoldersRequest\x1a\x1c.seta.v1.ListFoldersResponse\x12=\n" +
+	"\fUpdateFolder\x12\x1c.seta.v1.UpdateFolderRequest\x1a\x0f.seta.v1.Folder\x12D\n" +
+	"\fDeleteFolder\x12\x1c.seta.v1.DeleteFolderRequest\x1a\x16.google.protobuf.Empty\x12B\n" +
+	"\vShareFolder\x12\x1b.seta.v1.ShareFolderRequest\x1a\x16.google.protobuf.Empty\x12N\n" +
+	"\x11RevokeFolderShare\x12!.seta.v1.RevokeFolderShareRequest\x1a\x16.google.protobuf.EmptyB&Z$seta-training/api/grpc/setav1;setav1b\x06proto3"
+
+var (
+	file_seta_v1_folders_proto_rawDescOnce sync.Once
+	file_seta_v1_folders_proto_rawDescData []byte
+)
+
+func file_seta_v1_folders_proto_rawDescGZIP() []byte {
+	file_seta_v1_folders_proto_rawDescOnce.Do(func() {
+		file_seta_v1_folders_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_seta_v1_folders_proto_rawDesc), len(file_seta_v1_folders_proto_rawDesc)))
+	})
+	return file_seta_v1_folders_proto_rawDescData
+}
+
+var file_seta_v1_folders_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_seta_v1_folders_proto_goTypes = []any{
+	(*CreateFolderRequest)(nil),      // 0: seta.v1.CreateFolderRequest
+	(*GetFolderRequest)(nil),         // 1: seta.v1.GetFolderRequest
+	(*ListFoldersRequest)(nil),       // 2: seta.v1.ListFoldersRequest
+	(*ListFoldersResponse)(nil),      // 3: seta.v1.ListFoldersResponse
+	(*UpdateFolderRequest)(nil),      // 4: seta.v1.UpdateFolderRequest
+	(*DeleteFolderRequest)(nil),      // 5: seta.v1.DeleteFolderRequest
+	(*ShareFolderRequest)(nil),       // 6: seta.v1.ShareFolderRequest
+	(*RevokeFolderShareRequest)(nil), // 7: seta.v1.RevokeFolderShareRequest
+	(*Folder)(nil),                   // 8: seta.v1.Folder
+	(*Share)(nil),                    // 9: seta.v1.Share
+	(*emptypb.Empty)(nil),            // 10: google.protobuf.Empty
+}
+var file_seta_v1_folders_proto_depIdxs = []int32{
+	8,  // 0: seta.v1.ListFoldersResponse.folders:type_name -> seta.v1.Folder
+	9,  // 1: seta.v1.ShareFolderRequest.share:type_name -> seta.v1.Share
+	0,  // 2: seta.v1.FolderService.CreateFolder:input_type -> seta.v1.CreateFolderRequest
+	1,  // 3: seta.v1.FolderService.GetFolder:input_type -> seta.v1.GetFolderRequest
+	2,  // 4: seta.v1.FolderService.ListFolders:input_type -> seta.v1.ListFoldersRequest
+	4,  // 5: seta.v1.FolderService.UpdateFolder:input_type -> seta.v1.UpdateFolderRequest
+	5,  // 6: seta.v1.FolderService.DeleteFolder:input_type -> seta.v1.DeleteFolderRequest
+	6,  // 7: seta.v1.FolderService.ShareFolder:input_type -> seta.v1.ShareFolderRequest
+	7,  // 8: seta.v1.FolderService.RevokeFolderShare:input_type -> seta.v1.RevokeFolderShareRequest
+	8,  // 9: seta.v1.FolderService.CreateFolder:output_type -> seta.v1.Folder
+	8,  // 10: seta.v1.FolderService.GetFolder:output_type -> seta.v1.Folder
+	3,  // 11: seta.v1.FolderService.ListFolders:output_type -> seta.v1.ListFoldersResponse
+	8,  // 12: seta.v1.FolderService.UpdateFolder:output_type -> seta.v1.Folder
+	10, // 13: seta.v1.FolderService.DeleteFolder:output_type -> google.protobuf.Empty
+	10, // 14: seta.v1.FolderService.ShareFolder:output_type -> google.protobuf.Empty
+	10, // 15: seta.v1.FolderService.RevokeFolderShare:output_type -> google.protobuf.Empty
+	9,  // [9:16] is the sub-list for method output_type
+	2,  // [2:9] is the sub-list for method input_type
+	2,  // [2:2] is the sub-list for extension type_name
+	2,  // [2:2] is the sub-list for extension extendee
+	0,  // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_seta_v1_folders_proto_init() }
+func file_seta_v1_folders_proto_init() {
+	if File_seta_v1_folders_proto != nil {
+		return
+	}
+	file_seta_v1_common_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_seta_v1_folders_proto_rawDesc), len(file_seta_v1_folders_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_seta_v1_folders_proto_goTypes,
+		DependencyIndexes: file_seta_v1_folders_proto_depIdxs,
+		MessageInfos:      file_seta_v1_folders_proto_msgTypes,
+	}.Build()
+	File_seta_v1_folders_proto = out.File
+	file_seta_v1_folders_proto_goTypes = nil
+	file_seta_v1_folders_proto_depIdxs = nil
+}
@@ -0,0 +1,550 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: seta/v1/notes.proto
+
+package setav1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateNoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FolderId      string                 `protobuf:"bytes,1,opt,name=folder_id,json=folderId,proto3" json:"folder_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Body          string                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateNoteRequest) Reset() {
+	*x = CreateNoteRequest{}
+	mi := &file_seta_v1_notes_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateNoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateNoteRequest) ProtoMessage() {}
+
+func (x *CreateNoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_notes_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateNoteRequest.ProtoReflect.Descriptor instead.
+func (*CreateNoteRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_notes_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateNoteRequest) GetFolderId() string {
+	if x != nil {
+		return x.FolderId
+	}
+	return ""
+}
+
+func (x *CreateNoteRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreateNoteRequest) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+type GetNoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetNoteRequest) Reset() {
+	*x = GetNoteRequest{}
+	mi := &file_seta_v1_notes_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetNoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetNoteRequest) ProtoMessage() {}
+
+func (x *GetNoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_notes_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetNoteRequest.ProtoReflect.Descriptor instead.
+func (*GetNoteRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_notes_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetNoteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListNotesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          *PageRequest           `protobuf:"bytes,1,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNotesRequest) Reset() {
+	*x = ListNotesRequest{}
+	mi := &file_seta_v1_notes_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNotesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNotesRequest) ProtoMessage() {}
+
+func (x *ListNotesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_notes_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNotesRequest.ProtoReflect.Descriptor instead.
+func (*ListNotesRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_notes_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListNotesRequest) GetPage() *PageRequest {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type ListNotesResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Notes []*Note                `protobuf:"bytes,1,rep,name=notes,proto3" json:"notes,omitempty"`
+	// next_cursor continues the listing; empty on the last page
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNotesResponse) Reset() {
+	*x = ListNotesResponse{}
+	mi := &file_seta_v1_notes_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNotesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNotesResponse) ProtoMessage() {}
+
+func (x *ListNotesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_notes_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNotesResponse.ProtoReflect.Descriptor instead.
+func (*ListNotesResponse) Descriptor() ([]byte, []int) {
+	return file_seta_v1_notes_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListNotesResponse) GetNotes() []*Note {
+	if x != nil {
+		return x.Notes
+	}
+	return nil
+}
+
+func (x *ListNotesResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type UpdateNoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Body          string                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateNoteRequest) Reset() {
+	*x = UpdateNoteRequest{}
+	mi := &file_seta_v1_notes_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateNoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateNoteRequest) ProtoMessage() {}
+
+func (x *UpdateNoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_notes_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateNoteRequest.ProtoReflect.Descriptor instead.
+func (*UpdateNoteRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_notes_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateNoteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateNoteRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *UpdateNoteRequest) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+type DeleteNoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteNoteRequest) Reset() {
+	*x = DeleteNoteRequest{}
+	mi := &file_seta_v1_notes_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteNoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteNoteRequest) ProtoMessage() {}
+
+func (x *DeleteNoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_notes_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteNoteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteNoteRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_notes_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DeleteNoteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ShareNoteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NoteId        string                 `protobuf:"bytes,1,opt,name=note_id,json=noteId,proto3" json:"note_id,omitempty"`
+	Share         *Share                 `protobuf:"bytes,2,opt,name=share,proto3" json:"share,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShareNoteRequest) Reset() {
+	*x = ShareNoteRequest{}
+	mi := &file_seta_v1_notes_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShareNoteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShareNoteRequest) ProtoMessage() {}
+
+func (x *ShareNoteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_notes_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShareNoteRequest.ProtoReflect.Descriptor instead.
+func (*ShareNoteRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_notes_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ShareNoteRequest) GetNoteId() string {
+	if x != nil {
+		return x.NoteId
+	}
+	return ""
+}
+
+func (x *ShareNoteRequest) GetShare() *Share {
+	if x != nil {
+		return x.Share
+	}
+	return nil
+}
+
+type RevokeNoteShareRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NoteId        string                 `protobuf:"bytes,1,opt,name=note_id,json=noteId,proto3" json:"note_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RevokeNoteShareRequest) Reset() {
+	*x = RevokeNoteShareRequest{}
+	mi := &file_seta_v1_notes_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RevokeNoteShareRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RevokeNoteShareRequest) ProtoMessage() {}
+
+func (x *RevokeNoteShareRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_notes_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RevokeNoteShareRequest.ProtoReflect.Descriptor instead.
+func (*RevokeNoteShareRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_notes_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RevokeNoteShareRequest) GetNoteId() string {
+	if x != nil {
+		return x.NoteId
+	}
+	return ""
+}
+
+func (x *RevokeNoteShareRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+var File_seta_v1_notes_proto protoreflect.FileDescriptor
+
+const file_seta_v1_notes_proto_rawDesc = "" +
+	"\n" +
+	"\x13seta/v1/notes.proto\x12\aseta.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x14seta/v1/common.proto\"Z\n" +
+	"\x11CreateNoteRequest\x12\x1b\n" +
+	"\tfolder_id\x18\x01 \x01(\tR\bfolderId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\tR\x04body\" \n" +
+	"\x0eGetNoteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"<\n" +
+	"\x10ListNotesRequest\x12(\n" +
+	"\x04page\x18\x01 \x01(\v2\x14.seta.v1.PageRequestR\x04page\"Y\n" +
+	"\x11ListNotesResponse\x12#\n" +
+	"\x05notes\x18\x01 \x03(\v2\r.seta.v1.NoteR\x05notes\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"M\n" +
+	"\x11UpdateNoteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\tR\x04body\"#\n" +
+	"\x11DeleteNoteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"Q\n" +
+	"\x10ShareNoteRequest\x12\x17\n" +
+	"\anote_id\x18\x01 \x01(\tR\x06noteId\x12$\n" +
+	"\x05share\x18\x02 \x01(\v2\x0e.seta.v1.ShareR\x05share\"J\n" +
+	"\x16RevokeNoteShareRequest\x12\x17\n" +
+	"\anote_id\x18\x01 \x01(\tR\x06noteId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId2\xc4\x03\n" +
+	"\vNoteService\x127\n" +
+	"\n" +
+	"CreateNote\x12\x1a.seta.v1.CreateNoteRequest\x1a\r.seta.v1.Note\x121\n" +
+	"\aGetNote\x12\x17.seta.v1.GetNoteRequest\x1a\r.seta.v1.Note\x12B\n" +
+	"\tListNotes\x12\x19.seta.v1.ListNotesRequest\x1a\x1a.seta.v1.ListNotesResponse\x127\n" +
+	"\n" +
+	"UpdateNote\x12\x1a.seta.v1.UpdateNoteRequest\x1a\r.seta.v1.Note\x12@\n" +
+	"\n" +
+	"DeleteNote\x12\x1a.seta.v1.DeleteNoteRequest\x1a\x16.google.protobuf.Empty\x12>\n" +
+	"\tShareNote\x12\x19.seta.v1.ShareNoteRequest\x1a\x16.google.protobuf.Empty\x12J\n" +
+	"\x0fRevokeNoteShare\x12\x1f.seta.v1.RevokeNoteShareRequest\x1a\x16.google.protobuf.EmptyB&Z$seta-training/api/grpc/setav1;setav1b\x06proto3"
+
+var (
+	file_seta_v1_notes_proto_rawDescOnce sync.Once
+	file_seta_v1_notes_proto_rawDescData []byte
+)
+
+func file_seta_v1_notes_proto_rawDescGZIP() []byte {
+	file_seta_v1_notes_proto_rawDescOnce.Do(func() {
+		file_seta_v1_notes_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_seta_v1_notes_proto_rawDesc), len(file_seta_v1_notes_proto_rawDesc)))
+	})
+	return file_seta_v1_notes_proto_rawDescData
+}
+
+var file_seta_v1_notes_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_seta_v1_notes_proto_goTypes = []any{
+	(*CreateNoteRequest)(nil),      // 0: seta.v1.CreateNoteRequest
+	(*GetNoteRequest)(nil),         // 1: seta.v1.GetNoteRequest
+	(*ListNotesRequest)(nil),       // 2: seta.v1.ListNotesRequest
+	(*ListNotesResponse)(nil),      // 3: seta.v1.ListNotesResponse
+	(*UpdateNoteRequest)(nil),      // 4: seta.v1.UpdateNoteRequest
+	(*DeleteNoteRequest)(nil),      // 5: seta.v1.DeleteNoteRequest
+	(*ShareNoteRequest)(nil),       // 6: seta.v1.ShareNoteRequest
+	(*RevokeNoteShareRequest)(nil), // 7: seta.v1.RevokeNoteShareRequest
+	(*PageRequest)(nil),            // 8: seta.v1.PageRequest
+	(*Note)(nil),                   // 9: seta.v1.Note
+	(*Share)(nil),                  // 10: seta.v1.Share
+	(*emptypb.Empty)(nil),          // 11: google.protobuf.Empty
+}
+var file_seta_v1_notes_proto_depIdxs = []int32{
+	8,  // 0: seta.v1.ListNotesRequest.page:type_name -> seta.v1.PageRequest
+	9,  // 1: seta.v1.ListNotesResponse.notes:type_name -> seta.v1.Note
+	10, // 2: seta.v1.ShareNoteRequest.share:type_name -> seta.v1.Share
+	0,  // 3: seta.v1.NoteService.CreateNote:input_type -> seta.v1.CreateNoteRequest
+	1,  // 4: seta.v1.NoteService.GetNote:input_type -> seta.v1.GetNoteRequest
+	2,  // 5: seta.v1.NoteService.ListNotes:input_type -> seta.v1.ListNotesRequest
+	4,  // 6: seta.v1.NoteService.UpdateNote:input_type -> seta.v1.UpdateNoteRequest
+	5,  // 7: seta.v1.NoteService.DeleteNote:input_type -> seta.v1.DeleteNoteRequest
+	6,  // 8: seta.v1.NoteService.ShareNote:input_type -> seta.v1.ShareNoteRequest
+	7,  // 9: seta.v1.NoteService.RevokeNoteShare:input_type -> seta.v1.RevokeNoteShareRequest
+	9,  // 10: seta.v1.NoteService.CreateNote:output_type -> seta.v1.Note
+	9,  // 11: seta.v1.NoteService.GetNote:output_type -> seta.v1.Note
+	3,  // 12: seta.v1.NoteService.ListNotes:output_type -> seta.v1.ListNotesResponse
+	9,  // 13: seta.v1.NoteService.UpdateNote:output_type -> seta.v1.Note
+	11, // 14: seta.v1.NoteService.DeleteNote:output_type -> google.protobuf.Empty
+	11, // 15: seta.v1.NoteService.ShareNote:output_type -> google.protobuf.Empty
+	11, // 16: seta.v1.NoteService.RevokeNoteShare:output_type -> google.protobuf.Empty
+	10, // [10:17] is the sub-list for method output_type
+	3,  // [3:10] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_seta_v1_notes_proto_init() }
+func file_seta_v1_notes_proto_init() {
+	if File_seta_v1_notes_proto != nil {
+		return
+	}
+	file_seta_v1_common_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_seta_v1_notes_proto_rawDesc), len(file_seta_v1_notes_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_seta_v1_notes_proto_goTypes,
+		DependencyIndexes: file_seta_v1_notes_proto_depIdxs,
+		MessageInfos:      file_seta_v1_notes_proto_msgTypes,
+	}.Build()
+	File_seta_v1_notes_proto = out.File
+	file_seta_v1_notes_proto_goTypes = nil
+	file_seta_v1_notes_proto_depIdxs = nil
+}
@@ -0,0 +1,381 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: seta/v1/teams.proto
+
+package setav1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type CreateTeamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	ManagerIds    []string               `protobuf:"bytes,2,rep,name=manager_ids,json=managerIds,proto3" json:"manager_ids,omitempty"`
+	MemberIds     []string               `protobuf:"bytes,3,rep,name=member_ids,json=memberIds,proto3" json:"member_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTeamRequest) Reset() {
+	*x = CreateTeamRequest{}
+	mi := &file_seta_v1_teams_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTeamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTeamRequest) ProtoMessage() {}
+
+func (x *CreateTeamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_teams_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTeamRequest.ProtoReflect.Descriptor instead.
+func (*CreateTeamRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_teams_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateTeamRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateTeamRequest) GetManagerIds() []string {
+	if x != nil {
+		return x.ManagerIds
+	}
+	return nil
+}
+
+func (x *CreateTeamRequest) GetMemberIds() []string {
+	if x != nil {
+		return x.MemberIds
+	}
+	return nil
+}
+
+type GetTeamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTeamRequest) Reset() {
+	*x = GetTeamRequest{}
+	mi := &file_seta_v1_teams_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTeamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTeamRequest) ProtoMessage() {}
+
+func (x *GetTeamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_teams_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTeamRequest.ProtoReflect.Descriptor instead.
+func (*GetTeamRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_teams_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetTeamRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListTeamsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          *PageRequest           `protobuf:"bytes,1,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTeamsRequest) Reset() {
+	*x = ListTeamsRequest{}
+	mi := &file_seta_v1_teams_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTeamsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTeamsRequest) ProtoMessage() {}
+
+func (x *ListTeamsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_teams_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTeamsRequest.ProtoReflect.Descriptor instead.
+func (*ListTeamsRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_teams_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListTeamsRequest) GetPage() *PageRequest {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type ListTeamsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Teams []*Team                `protobuf:"bytes,1,rep,name=teams,proto3" json:"teams,omitempty"`
+	// next_cursor continues the listing; empty on the last page
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTeamsResponse) Reset() {
+	*x = ListTeamsResponse{}
+	mi := &file_seta_v1_teams_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTeamsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTeamsResponse) ProtoMessage() {}
+
+func (x *ListTeamsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_teams_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTeamsResponse.ProtoReflect.Descriptor instead.
+func (*ListTeamsResponse) Descriptor() ([]byte, []int) {
+	return file_seta_v1_teams_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListTeamsResponse) GetTeams() []*Team {
+	if x != nil {
+		return x.Teams
+	}
+	return nil
+}
+
+func (x *ListTeamsResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type TeamUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TeamId        string                 `protobuf:"bytes,1,opt,name=team_id,json=teamId,proto3" json:"team_id,omitempty"`
+	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TeamUserRequest) Reset() {
+	*x = TeamUserRequest{}
+	mi := &file_seta_v1_teams_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TeamUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TeamUserRequest) ProtoMessage() {}
+
+func (x *TeamUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_teams_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TeamUserRequest.ProtoReflect.Descriptor instead.
+func (*TeamUserRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_teams_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TeamUserRequest) GetTeamId() string {
+	if x != nil {
+		return x.TeamId
+	}
+	return ""
+}
+
+func (x *TeamUserRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+var File_seta_v1_teams_proto protoreflect.FileDescriptor
+
+const file_seta_v1_teams_proto_rawDesc = "" +
+	"\n" +
+	"\x13seta/v1/teams.proto\x12\aseta.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x14seta/v1/common.proto\"g\n" +
+	"\x11CreateTeamRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vmanager_ids\x18\x02 \x03(\tR\n" +
+	"managerIds\x12\x1d\n" +
+	"\n" +
+	"member_ids\x18\x03 \x03(\tR\tmemberIds\" \n" +
+	"\x0eGetTeamRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"<\n" +
+	"\x10ListTeamsRequest\x12(\n" +
+	"\x04page\x18\x01 \x01(\v2\x14.seta.v1.PageRequestR\x04page\"Y\n" +
+	"\x11ListTeamsResponse\x12#\n" +
+	"\x05teams\x18\x01 \x03(\v2\r.seta.v1.TeamR\x05teams\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"C\n" +
+	"\x0fTeamUserRequest\x12\x17\n" +
+	"\ateam_id\x18\x01 \x01(\tR\x06teamId\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\tR\x06userId2\xc1\x03\n" +
+	"\vTeamService\x127\n" +
+	"\n" +
+	"CreateTeam\x12\x1a.seta.v1.CreateTeamRequest\x1a\r.seta.v1.Team\x121\n" +
+	"\aGetTeam\x12\x17.seta.v1.GetTeamRequest\x1a\r.seta.v1.Team\x12B\n" +
+	"\tListTeams\x12\x19.seta.v1.ListTeamsRequest\x1a\x1a.seta.v1.ListTeamsResponse\x12=\n" +
+	"\tAddMember\x12\x18.seta.v1.TeamUserRequest\x1a\x16.google.protobuf.Empty\x12@\n" +
+	"\fRemoveMember\x12\x18.seta.v1.TeamUserRequest\x1a\x16.google.protobuf.Empty\x12>\n" +
+	"\n" +
+	"AddManager\x12\x18.seta.v1.TeamUserRequest\x1a\x16.google.protobuf.Empty\x12A\n" +
+	"\rRemoveManager\x12\x18.seta.v1.TeamUserRequest\x1a\x16.google.protobuf.EmptyB&Z$seta-training/api/grpc/setav1;setav1b\x06proto3"
+
+var (
+	file_seta_v1_teams_proto_rawDescOnce sync.Once
+	file_seta_v1_teams_proto_rawDescData []byte
+)
+
+func file_seta_v1_teams_proto_rawDescGZIP() []byte {
+	file_seta_v1_teams_proto_rawDescOnce.Do(func() {
+		file_seta_v1_teams_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_seta_v1_teams_proto_rawDesc), len(file_seta_v1_teams_proto_rawDesc)))
+	})
+	return file_seta_v1_teams_proto_rawDescData
+}
+
+var file_seta_v1_teams_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_seta_v1_teams_proto_goTypes = []any{
+	(*CreateTeamRequest)(nil), // 0: seta.v1.CreateTeamRequest
+	(*GetTeamRequest)(nil),    // 1: seta.v1.GetTeamRequest
+	(*ListTeamsRequest)(nil),  // 2: seta.v1.ListTeamsRequest
+	(*ListTeamsResponse)(nil), // 3: seta.v1.ListTeamsResponse
+	(*TeamUserRequest)(nil),   // 4: seta.v1.TeamUserRequest
+	(*PageRequest)(nil),       // 5: seta.v1.PageRequest
+	(*Team)(nil),              // 6: seta.v1.Team
+	(*emptypb.Empty)(nil),     // 7: google.protobuf.Empty
+}
+var file_seta_v1_teams_proto_depIdxs = []int32{
+	5, // 0: seta.v1.ListTeamsRequest.page:type_name -> seta.v1.PageRequest
+	6, // 1: seta.v1.ListTeamsResponse.teams:type_name -> seta.v1.Team
+	0, // 2: seta.v1.TeamService.CreateTeam:input_type -> seta.v1.CreateTeamRequest
+	1, // 3: seta.v1.TeamService.GetTeam:input_type -> seta.v1.GetTeamRequest
+	2, // 4: seta.v1.TeamService.ListTeams:input_type -> seta.v1.ListTeamsRequest
+	4, // 5: seta.v1.TeamService.AddMember:input_type -> seta.v1.TeamUserRequest
+	4, // 6: seta.v1.TeamService.RemoveMember:input_type -> seta.v1.TeamUserRequest
+	4, // 7: seta.v1.TeamService.AddManager:input_type -> seta.v1.TeamUserRequest
+	4, // 8: seta.v1.TeamService.RemoveManager:input_type -> seta.v1.TeamUserRequest
+	6, // 9: seta.v1.TeamService.CreateTeam:output_type -> seta.v1.Team
+	6, // 10: seta.v1.TeamService.GetTeam:output_type -> seta.v1.Team
+	3, // 11: seta.v1.TeamService.ListTeams:output_type -> seta.v1.ListTeamsResponse
+	7, // 12: seta.v1.TeamService.AddMember:output_type -> google.protobuf.Empty
+	7, // 13: seta.v1.TeamService.RemoveMember:output_type -> google.protobuf.Empty
+	7, // 14: seta.v1.TeamService.AddManager:output_type -> google.protobuf.Empty
+	7, // 15: seta.v1.TeamService.RemoveManager:output_type -> google.protobuf.Empty
+	9, // [9:16] is the sub-list for method output_type
+	2, // [2:9] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_seta_v1_teams_proto_init() }
+func file_seta_v1_teams_proto_init() {
+	if File_seta_v1_teams_proto != nil {
+		return
+	}
+	file_seta_v1_common_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_seta_v1_teams_proto_rawDesc), len(file_seta_v1_teams_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_seta_v1_teams_proto_goTypes,
+		DependencyIndexes: file_seta_v1_teams_proto_depIdxs,
+		MessageInfos:      file_seta_v1_teams_proto_msgTypes,
+	}.Build()
+	File_seta_v1_teams_proto = out.File
+	file_seta_v1_teams_proto_goTypes = nil
+	file_seta_v1_teams_proto_depIdxs = nil
+}
@@ -0,0 +1,258 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: seta/v1/users.proto
+
+package setav1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GetCurrentUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentUserRequest) Reset() {
+	*x = GetCurrentUserRequest{}
+	mi := &file_seta_v1_users_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentUserRequest) ProtoMessage() {}
+
+func (x *GetCurrentUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_users_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentUserRequest.ProtoReflect.Descriptor instead.
+func (*GetCurrentUserRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_users_proto_rawDescGZIP(), []int{0}
+}
+
+type GetUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_seta_v1_users_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_users_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_users_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetUserRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersRequest) Reset() {
+	*x = ListUsersRequest{}
+	mi := &file_seta_v1_users_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersRequest) ProtoMessage() {}
+
+func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_users_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersRequest.ProtoReflect.Descriptor instead.
+func (*ListUsersRequest) Descriptor() ([]byte, []int) {
+	return file_seta_v1_users_proto_rawDescGZIP(), []int{2}
+}
+
+type ListUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersResponse) Reset() {
+	*x = ListUsersResponse{}
+	mi := &file_seta_v1_users_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersResponse) ProtoMessage() {}
+
+func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_seta_v1_users_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersResponse.ProtoReflect.Descriptor instead.
+func (*ListUsersResponse) Descriptor() ([]byte, []int) {
+	return file_seta_v1_users_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+var File_seta_v1_users_proto protoreflect.FileDescriptor
+
+const file_seta_v1_users_proto_rawDesc = "" +
+	"\n" +
+	"\x13seta/v1/users.proto\x12\aseta.v1\x1a\x14seta/v1/common.proto\"\x17\n" +
+	"\x15GetCurrentUserRequest\" \n" +
+	"\x0eGetUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x12\n" +
+	"\x10ListUsersRequest\"8\n" +
+	"\x11ListUsersResponse\x12#\n" +
+	"\x05users\x18\x01 \x03(\v2\r.seta.v1.UserR\x05users2\xc5\x01\n" +
+	"\vUserService\x12?\n" +
+	"\x0eGetCurrentUser\x12\x1e.seta.v1.GetCurrentUserRequest\x1a\r.seta.v1.User\x121\n" +
+	"\aGetUser\x12\x17.seta.v1.GetUserRequest\x1a\r.seta.v1.User\x12B\n" +
+	"\tListUsers\x12\x19.seta.v1.ListUsersRequest\x1a\x1a.seta.v1.ListUsersResponseB&Z$seta-training/api/grpc/setav1;setav1b\x06proto3"
+
+var (
+	file_seta_v1_users_proto_rawDescOnce sync.Once
+	file_seta_v1_users_proto_rawDescData []byte
+)
+
+func file_seta_v1_users_proto_rawDescGZIP() []byte {
+	file_seta_v1_users_proto_rawDescOnce.Do(func() {
+		file_seta_v1_users_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_seta_v1_users_proto_rawDesc), len(file_seta_v1_users_proto_rawDesc)))
+	})
+	return file_seta_v1_users_proto_rawDescData
+}
+
+var file_seta_v1_users_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_seta_v1_users_proto_goTypes = []any{
+	(*GetCurrentUserRequest)(nil), // 0: seta.v1.GetCurrentUserRequest
+	(*GetUserRequest)(nil),        // 1: seta.v1.GetUserRequest
+	(*ListUsersRequest)(nil),      // 2: seta.v1.ListUsersRequest
+	(*ListUsersResponse)(nil),     // 3: seta.v1.ListUsersResponse
+	(*User)(nil),                  // 4: seta.v1.User
+}
+var file_seta_v1_users_proto_depIdxs = []int32{
+	4, // 0: seta.v1.ListUsersResponse.users:type_name -> seta.v1.User
+	0, // 1: seta.v1.UserService.GetCurrentUser:input_type -> seta.v1.GetCurrentUserRequest
+	1, // 2: seta.v1.UserService.GetUser:input_type -> seta.v1.GetUserRequest
+	2, // 3: seta.v1.UserService.ListUsers:input_type -> seta.v1.ListUsersRequest
+	4, // 4: seta.v1.UserService.GetCurrentUser:output_type -> seta.v1.User
+	4, // 5: seta.v1.UserService.GetUser:output_type -> seta.v1.User
+	3, // 6: seta.v1.UserService.ListUsers:output_type -> seta.v1.ListUsersResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_seta_v1_users_proto_init() }
+func file_seta_v1_users_proto_init() {
+	if File_seta_v1_users_proto != nil {
+		return
+	}
+	file_seta_v1_common_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_seta_v1_users_proto_rawDesc), len(file_seta_v1_users_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_seta_v1_users_proto_goTypes,
+		DependencyIndexes: file_seta_v1_users_proto_depIdxs,
+		MessageInfos:      file_seta_v1_users_proto_msgTypes,
+	}.Build()
+	File_seta_v1_users_proto = out.File
+	file_seta_v1_users_proto_goTypes = nil
+	file_seta_v1_users_proto_depIdxs = nil
+}
@@ -0,0 +1,67 @@
+// Command ldap-sync syncs the users and teams of an organization with its
+// LDAP directory and prints the report. With -dry-run it only prints what
+// would change.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/config"
+	"seta-training/internal/database"
+	"seta-training/internal/repositories"
+	"seta-training/internal/services"
+	"seta-training/pkg/logger"
+)
+
+func main() {
+	orgID := flag.String("org", "", "ID of the organization to sync")
+	dryRun := flag.Bool("dry-run", false, "only report what would change")
+	flag.Parse()
+
+	org, err := uuid.Parse(*orgID)
+	if err != nil {
+		log.Fatalf("Invalid -org: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	appLogger := logger.NewLogger(cfg.Logging.Level, "text", nil)
+
+	db, err := database.New(cfg)
+	if err != nil {
+		appLogger.Fatal("Failed to connect to database", logger.Error(err))
+	}
+	defer db.Close()
+
+	userRepo := repositories.NewUserRepository(db.DB)
+	userService := services.NewUserService(userRepo, nil, nil, nil, nil, nil, nil)
+	service := services.NewLDAPSyncService(
+		repositories.NewLDAPRepository(db.DB),
+		repositories.NewOrganizationRepository(db.DB),
+		userRepo,
+		repositories.NewTeamRepository(db.DB),
+		userService,
+		nil,
+		time.Duration(cfg.LDAP.TimeoutSeconds)*time.Second,
+		nil,
+		appLogger,
+	)
+	report, err := service.Sync(context.Background(), org, *dryRun, uuid.Nil)
+	if err != nil {
+		appLogger.Fatal("LDAP sync failed", logger.String("organization_id", *orgID), logger.Error(err))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		appLogger.Fatal("Failed to write the report", logger.Error(err))
+	}
+}
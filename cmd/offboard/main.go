@@ -0,0 +1,73 @@
+// Command offboard hands the folders and notes of a user who is leaving, or
+// was already deleted, over to a manager. With -dry-run it only prints what
+// would be handed over.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/google/uuid"
+	"seta-training/internal/audit"
+	"seta-training/internal/config"
+	"seta-training/internal/database"
+	"seta-training/internal/repositories"
+	"seta-training/internal/services"
+	"seta-training/pkg/logger"
+)
+
+func main() {
+	userID := flag.String("user", "", "ID of the user leaving")
+	managerID := flag.String("manager", "", "ID of the manager taking over their folders and notes")
+	archive := flag.Bool("archive", false, "also move the notes to the archived status")
+	dryRun := flag.Bool("dry-run", false, "only report what would be handed over")
+	actorID := flag.String("actor", "", "ID of the admin running the offboarding, recorded in the audit log")
+	flag.Parse()
+
+	input := &services.OffboardInput{Archive: *archive, DryRun: *dryRun}
+	var err error
+	if input.UserID, err = uuid.Parse(*userID); err != nil {
+		log.Fatalf("Invalid -user: %v", err)
+	}
+	if input.ManagerID, err = uuid.Parse(*managerID); err != nil {
+		log.Fatalf("Invalid -manager: %v", err)
+	}
+	if *actorID != "" {
+		if input.ActorID, err = uuid.Parse(*actorID); err != nil {
+			log.Fatalf("Invalid -actor: %v", err)
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	appLogger := logger.NewLogger(cfg.Logging.Level, "text", nil)
+
+	db, err := database.New(cfg)
+	if err != nil {
+		appLogger.Fatal("Failed to connect to database", logger.Error(err))
+	}
+	defer db.Close()
+
+	auditWriter := audit.NewWriter(audit.NewGormStore(db.DB), 1, appLogger)
+	auditWriter.Start()
+
+	service := services.NewOffboardingService(repositories.NewOffboardingRepository(db.DB), auditWriter, appLogger)
+	report, err := service.Offboard(context.Background(), input)
+	if err != nil {
+		appLogger.Fatal("Offboarding failed", logger.String("user_id", *userID), logger.Error(err))
+	}
+	if err := auditWriter.Shutdown(context.Background()); err != nil {
+		appLogger.Error("Failed to write the audit log", logger.Error(err))
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		appLogger.Fatal("Failed to write the report", logger.Error(err))
+	}
+}
@@ -0,0 +1,62 @@
+// Command seed loads demo users, teams, folders and notes from a seed file
+// into the configured database. It is meant for local development and demo
+// environments and can be run repeatedly.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"seta-training/internal/config"
+	"seta-training/internal/database"
+	"seta-training/internal/repositories"
+	"seta-training/internal/seed"
+	"seta-training/pkg/compression"
+	"seta-training/pkg/logger"
+)
+
+func main() {
+	path := flag.String("file", "seeds/demo.yaml", "path to the seed file")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	appLogger := logger.NewLogger(cfg.Logging.Level, "text", nil)
+
+	file, err := seed.LoadFile(*path)
+	if err != nil {
+		appLogger.Fatal("Failed to load seed file", logger.Error(err))
+	}
+	if err := file.Validate(); err != nil {
+		appLogger.Fatal("Invalid seed file", logger.Error(err))
+	}
+
+	db, err := database.New(cfg)
+	if err != nil {
+		appLogger.Fatal("Failed to connect to database", logger.Error(err))
+	}
+	defer db.Close()
+
+	if err := db.Migrate(); err != nil {
+		appLogger.Fatal("Failed to run migrations", logger.Error(err))
+	}
+
+	noteCompressor, err := compression.NewCompressor(cfg.NoteStorage.CompressionAlgorithm, cfg.NoteStorage.CompressionThreshold)
+	if err != nil {
+		appLogger.Fatal("Invalid note compression configuration", logger.Error(err))
+	}
+
+	noteEnvelope, err := cfg.NoteStorage.Encryption.Envelope()
+	if err != nil {
+		appLogger.Fatal("Invalid note encryption configuration", logger.Error(err))
+	}
+
+	noteCodec := repositories.NewNoteBodyCodec(noteCompressor, noteEnvelope, nil)
+	if err := seed.NewSeeder(db.DB, noteCodec, appLogger).Run(context.Background(), file); err != nil {
+		appLogger.Fatal("Seeding failed", logger.String("file", *path), logger.Error(err))
+	}
+	appLogger.Info("Database seeded", logger.String("file", *path))
+}
@@ -1,34 +1,80 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"os/signal"
+	"syscall"
+	"time"
+	// Embed the time zone database so user time zones resolve in minimal
+	// container images
+	_ "time/tzdata"
 
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 
 	"seta-training/api/graphql/generated"
 	"seta-training/api/graphql/resolvers"
+	grpcserver "seta-training/api/grpc/server"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
 	"seta-training/internal/config"
 	"seta-training/internal/database"
 	"seta-training/internal/handlers"
+	"seta-training/internal/jobs"
 	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/internal/outbox"
+	"seta-training/internal/realtime"
 	"seta-training/internal/repositories"
+	"seta-training/internal/search"
 	"seta-training/internal/services"
+	"seta-training/internal/usage"
 	"seta-training/pkg/auth"
+	"seta-training/pkg/captcha"
+	"seta-training/pkg/compression"
+	"seta-training/pkg/events"
+	"seta-training/pkg/featureflags"
+	"seta-training/pkg/i18n"
 	"seta-training/pkg/logger"
 	"seta-training/pkg/metrics"
+	"seta-training/pkg/sso"
+	"seta-training/pkg/webhook"
 )
 
 func main() {
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
 
-	// Initialize structured logging
-	logger.InitGlobalLogger(cfg.Logging.Level, cfg.Logging.Format, nil)
-	appLogger := logger.GetLogger()
+	// Initialize structured logging and metrics. Both are passed explicitly to
+	// the components below; the globals are only kept for legacy callers.
+	logOutput, logCloser, err := logger.OpenOutput(cfg.Logging.OutputConfig())
+	if err != nil {
+		log.Fatalf("Failed to open log output: %v", err)
+	}
+	defer logCloser.Close()
+	appLogger := logger.NewBackend(cfg.Logging.Backend, cfg.Logging.Level, cfg.Logging.Format, logOutput)
+	if err := applyComponentLevels(appLogger, cfg.Logging.ComponentLevels); err != nil {
+		log.Fatalf("Invalid component log levels: %v", err)
+	}
+	if sampled, ok := appLogger.(logger.SamplingSetter); ok {
+		sampled.SetSampling(cfg.Logging.SamplingInitial, cfg.Logging.SamplingThereafter)
+	}
+	logger.SetGlobalLogger(appLogger)
+	serviceLogger := logger.ForComponent(appLogger, logger.ComponentService)
+	handlerLogger := logger.ForComponent(appLogger, logger.ComponentHandler)
 
-	// Initialize metrics
 	appMetrics := metrics.InitGlobalMetrics()
 
 	// Set Gin mode
@@ -39,10 +85,34 @@ func main() {
 	if err != nil {
 		appLogger.Fatal("Failed to connect to database", logger.Error(err))
 	}
-	defer db.Close()
 
 	appLogger.Info("Database connection established")
 
+	// Export connection pool statistics
+	sqlDB, err := db.DB.DB()
+	if err != nil {
+		appLogger.Fatal("Failed to access database connection pool", logger.Error(err))
+	}
+	if err := appMetrics.RegisterDBStats(sqlDB, cfg.Database.Name); err != nil {
+		appLogger.Error("Failed to register database pool metrics", logger.Error(err))
+	}
+	if err := db.DB.Use(appMetrics.GormPlugin()); err != nil {
+		appLogger.Fatal("Failed to register database query metrics", logger.Error(err))
+	}
+	// Fail fast with 503 instead of queueing on the pool while the database is down
+	if cfg.Database.BreakerFailureThreshold > 0 {
+		breakers := repositories.NewCircuitBreakers(
+			cfg.Database.BreakerFailureThreshold,
+			time.Duration(cfg.Database.BreakerOpenSeconds)*time.Second,
+			cfg.Database.BreakerHalfOpenProbes,
+			appMetrics,
+			logger.ForComponent(appLogger, logger.ComponentRepo),
+		)
+		if err := db.DB.Use(breakers); err != nil {
+			appLogger.Fatal("Failed to register database circuit breakers", logger.Error(err))
+		}
+	}
+
 	// Run migrations
 	if err := db.Migrate(); err != nil {
 		appLogger.Fatal("Failed to run migrations", logger.Error(err))
@@ -51,111 +121,514 @@ func main() {
 	appLogger.Info("Database migrations completed")
 
 	// Initialize JWT manager
-	jwtManager := auth.NewJWTManager(cfg.JWT.Secret, cfg.JWT.ExpiryHours)
+	jwtKeys, err := loadJWTKeys(cfg.JWT)
+	if err != nil {
+		appLogger.Fatal("Failed to load JWT signing keys", logger.Error(err))
+	}
+	jwtManager := auth.NewJWTManagerWithKeys(cfg.JWT.Secret, cfg.JWT.ExpiryHours, jwtKeys)
+
+	// Initialize note body compression and encryption
+	noteCompressor, err := compression.NewCompressor(cfg.NoteStorage.CompressionAlgorithm, cfg.NoteStorage.CompressionThreshold)
+	if err != nil {
+		appLogger.Fatal("Invalid note compression configuration", logger.Error(err))
+	}
+	noteEnvelope, err := cfg.NoteStorage.Encryption.Envelope()
+	if err != nil {
+		appLogger.Fatal("Invalid note encryption configuration", logger.Error(err))
+	}
+	noteCodec := repositories.NewNoteBodyCodec(noteCompressor, noteEnvelope, appMetrics)
 
 	// Initialize repositories
 	userRepo := repositories.NewUserRepository(db.DB)
 	teamRepo := repositories.NewTeamRepository(db.DB)
-	folderRepo := repositories.NewFolderRepository(db.DB)
-	noteRepo := repositories.NewNoteRepository(db.DB)
+	folderRepo := repositories.NewFolderRepository(db.DB, noteCodec)
+	noteRepo := repositories.NewNoteRepository(db.DB, noteCodec)
+	savedFilterRepo := repositories.NewSavedFilterRepository(db.DB, noteCodec)
+	searchRepo := repositories.NewSearchRepository(db.DB, noteCodec)
+	exportJobRepo := repositories.NewExportJobRepository(db.DB)
+	assetReportRepo := repositories.NewAssetReportRepository(db.DB)
+	notificationRepo := repositories.NewNotificationRepository(db.DB)
+	mentionRepo := repositories.NewMentionRepository(db.DB, noteCodec)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db.DB)
+	outboxRepo := repositories.NewOutboxRepository(db.DB)
+	webhookRepo := repositories.NewWebhookRepository(db.DB)
+	orgRepo := repositories.NewOrganizationRepository(db.DB)
+	prefRepo := repositories.NewUserPreferenceRepository(db.DB)
+	quotaRepo := repositories.NewQuotaRepository(db.DB)
+	statsRepo := repositories.NewStatsRepository(db.DB)
+	featureFlagRepo := repositories.NewFeatureFlagRepository(db.DB)
+	announcementRepo := repositories.NewAnnouncementRepository(db.DB)
+	reminderRepo := repositories.NewReminderRepository(db.DB)
+	checklistRepo := repositories.NewChecklistRepository(db.DB)
+	accessRequestRepo := repositories.NewAccessRequestRepository(db.DB)
+	loginAttemptRepo := repositories.NewLoginAttemptRepository(db.DB)
+	deviceRepo := repositories.NewDeviceRepository(db.DB)
+	ssoRepo := repositories.NewSSORepository(db.DB)
+	ldapRepo := repositories.NewLDAPRepository(db.DB)
+	scimRepo := repositories.NewSCIMRepository(db.DB)
+	txManager := repositories.NewTxManager(db.DB, noteRepo)
+
+	// Load the message catalogs used for error responses and notifications
+	messages, err := i18n.NewBundle()
+	if err != nil {
+		appLogger.Fatal("Failed to load message catalogs", logger.Error(err))
+	}
+
+	// Initialize the audit log; entries are written in the background
+	auditStore := audit.NewGormStore(db.DB)
+	auditWriter := audit.NewWriter(auditStore, cfg.Audit.BufferSize, appLogger)
+	auditWriter.Start()
+
+	// Publish domain events written to the outbox on the message bus
+	eventBus, err := newEventBus(cfg.Events, appLogger)
+	if err != nil {
+		appLogger.Fatal("Failed to initialize event bus", logger.Error(err))
+	}
+	if _, err := eventBus.Subscribe(events.All, func(ctx context.Context, event events.Event) error {
+		appLogger.Debug("Domain event published",
+			logger.String("event_id", event.ID.String()),
+			logger.String("event_type", event.Type),
+			logger.String("aggregate_id", event.AggregateID.String()),
+		)
+		return nil
+	}); err != nil {
+		appLogger.Fatal("Failed to subscribe to domain events", logger.Error(err))
+	}
+
+	// Stream each audited change to its actor's activity feeds as well
+	activityPublisher := realtime.NewActivityPublisher(eventBus, cfg.Audit.BufferSize, appLogger)
+	auditRecorder := audit.Recorders{auditWriter, activityPublisher}
+
+	// Count requests per user and route, flushed in the background
+	usageStore := usage.NewGormStore(db.DB)
+	var usageRecorder *usage.Recorder
+	if cfg.Usage.Enabled {
+		usageRecorder = usage.NewRecorder(usageStore, time.Duration(cfg.Usage.FlushIntervalSeconds)*time.Second, appLogger)
+		usageRecorder.Start()
+	}
 
 	// Initialize services
-	userService := services.NewUserService(userRepo, jwtManager)
-	teamService := services.NewTeamService(teamRepo, userRepo)
-	folderService := services.NewFolderService(folderRepo, noteRepo)
-	noteService := services.NewNoteService(noteRepo, folderRepo)
-	importService := services.NewImportService(userService, appLogger)
+	loginHistoryService := services.NewLoginHistoryService(loginAttemptRepo, teamRepo, notificationRepo, prefRepo, messages, serviceLogger)
+	deviceService := services.NewDeviceService(deviceRepo, auditRecorder, time.Duration(cfg.JWT.ExpiryHours)*time.Hour, serviceLogger)
+	jwtManager.SetTokenCheck(deviceService.CheckToken)
+	userService := services.NewUserService(userRepo, txManager, jwtManager, auditRecorder, appMetrics, loginHistoryService, deviceService)
+	teamService := services.NewTeamService(teamRepo, userRepo, txManager, auditRecorder)
+	orgService := services.NewOrganizationService(orgRepo, userRepo, auditRecorder)
+	ssoConfig, err := newSSOConfig(cfg.SSO)
+	if err != nil {
+		appLogger.Fatal("Failed to load SSO configuration", logger.Error(err))
+	}
+	ssoService := services.NewSSOService(ssoRepo, orgRepo, userRepo, userService, ssoConfig, auditRecorder, serviceLogger)
+	scimService := services.NewSCIMService(scimRepo, orgRepo, teamRepo, userService, auditRecorder)
+	ldapSyncService := services.NewLDAPSyncService(ldapRepo, orgRepo, userRepo, teamRepo, userService, nil, time.Duration(cfg.LDAP.TimeoutSeconds)*time.Second, auditRecorder, serviceLogger)
+	impersonationService := services.NewImpersonationService(userRepo, jwtManager, auditRecorder, time.Duration(cfg.Admin.ImpersonationMinutes)*time.Minute)
+	jwtManager.SetClaimsBuilder(auth.ChainClaimsBuilders(teamService.BuildClaims, auth.AdminScope(cfg.Admin.Users)))
+	quotaService := services.NewQuotaService(quotaRepo, teamRepo, services.QuotaLimits{
+		NotesPerUser:   int64(cfg.Quota.MaxNotesPerUser),
+		FoldersPerUser: int64(cfg.Quota.MaxFoldersPerUser),
+		NotesPerTeam:   int64(cfg.Quota.MaxNotesPerTeam),
+		FoldersPerTeam: int64(cfg.Quota.MaxFoldersPerTeam),
+	})
+	folderService := services.NewFolderService(folderRepo, noteRepo, teamRepo, txManager, quotaService, auditRecorder, appMetrics)
+	prefService := services.NewUserPreferenceService(prefRepo, messages.Languages())
+	notificationService := services.NewNotificationService(notificationRepo, prefRepo)
+	mentionService := services.NewMentionService(mentionRepo, notificationRepo, noteRepo, folderRepo, prefRepo, messages, serviceLogger)
+	noteService := services.NewNoteService(noteRepo, folderRepo, teamRepo, txManager, mentionService, quotaService, auditRecorder, appMetrics)
+	importService := services.NewImportService(userService, logger.ForComponent(appLogger, logger.ComponentImport), appMetrics)
+	savedFilterService := services.NewSavedFilterService(savedFilterRepo, auditRecorder)
+	var searchService services.SearchServiceInterface = services.NewSearchService(searchRepo)
+	exportService := services.NewExportService(exportJobRepo, folderRepo, noteRepo, prefRepo, serviceLogger, cfg.Export.Workers, cfg.Export.QueueSize)
+	exportService.Start()
+	// Asset reports scan a whole organization, so one runs at a time
+	assetReportService := services.NewAssetReportService(assetReportRepo, serviceLogger, 1, cfg.Export.QueueSize)
+	assetReportService.Start()
+
+	// Search the Elasticsearch index instead of the database when configured,
+	// keeping it up to date from domain events
+	var searchClient *search.Client
+	if cfg.Search.ElasticsearchURL != "" {
+		searchClient = search.NewClient(cfg.Search.ElasticsearchURL, cfg.Search.Index,
+			cfg.Search.Username, cfg.Search.Password, time.Duration(cfg.Search.TimeoutSeconds)*time.Second)
+		if err := searchClient.EnsureIndex(context.Background()); err != nil {
+			appLogger.Fatal("Failed to initialize search index", logger.Error(err))
+		}
+		indexer := search.NewIndexer(searchClient, repositories.Stores{
+			Users: userRepo, Teams: teamRepo, Folders: folderRepo, Notes: noteRepo,
+		}, serviceLogger)
+		for _, eventType := range search.EventTypes {
+			if _, err := eventBus.Subscribe(eventType, indexer.Handle); err != nil {
+				appLogger.Fatal("Failed to subscribe search indexer to domain events", logger.Error(err))
+			}
+		}
+		searchService = search.NewService(searchClient)
+	}
+	outboxRelay := outbox.NewRelay(outboxRepo, eventBus, cfg.Outbox.BatchSize,
+		time.Duration(cfg.Outbox.RetentionHours)*time.Hour, appLogger)
+
+	// Initialize outbound webhooks
+	webhookSender := webhook.NewSender(
+		time.Duration(cfg.Webhook.TimeoutSeconds)*time.Second,
+		cfg.Webhook.MaxRetries,
+		time.Duration(cfg.Webhook.RetryBackoffMillis)*time.Millisecond,
+		appLogger,
+	)
+
+	// Push shares, team changes and import results to connected users. Every
+	// instance receives the events, as the user may be connected to any.
+	notificationHub := realtime.NewHub(time.Duration(cfg.WebSocket.PingIntervalSeconds)*time.Second,
+		cfg.WebSocket.MaxConnectionsPerUser, logger.ForComponent(appLogger, logger.ComponentHandler), appMetrics)
+	for _, eventType := range realtime.EventTypes {
+		if _, err := eventBus.SubscribeBroadcast(eventType, notificationHub.HandleEvent); err != nil {
+			appLogger.Fatal("Failed to subscribe notifications to domain events", logger.Error(err))
+		}
+	}
+
+	importNotifiers := services.ImportNotifiers{notificationHub, services.NewAuditImportNotifier(auditRecorder)}
+	if cfg.Webhook.ImportURL != "" {
+		importNotifiers = append(importNotifiers, services.NewWebhookImportNotifier(webhookSender, webhook.Endpoint{
+			URL:    cfg.Webhook.ImportURL,
+			Secret: cfg.Webhook.ImportSecret,
+		}))
+	}
+
+	// Deliver domain events to the webhooks managers have registered
+	webhookService := services.NewWebhookService(webhookRepo, webhookSender, auditRecorder, serviceLogger)
+	for _, eventType := range models.WebhookEventTypes {
+		if _, err := eventBus.Subscribe(eventType, webhookService.Dispatch); err != nil {
+			appLogger.Fatal("Failed to subscribe webhooks to domain events", logger.Error(err))
+		}
+	}
 
 	// Initialize handlers
 	teamHandler := handlers.NewTeamHandler(teamService)
+	teamHandlerV2 := handlers.NewVersionedTeamHandler(teamService, handlers.TeamCodecV2{})
 	folderHandler := handlers.NewFolderHandler(folderService)
 	noteHandler := handlers.NewNoteHandler(noteService)
-	assetHandler := handlers.NewAssetHandler(folderService, noteService, teamService)
-	importHandler := handlers.NewImportHandler(importService, appLogger, appMetrics)
+	assetHandler := handlers.NewAssetHandler(folderService, noteService, teamService, userService)
+	loginHistoryHandler := handlers.NewLoginHistoryHandler(loginHistoryService)
+	deviceHandler := handlers.NewDeviceHandler(deviceService)
+	importHandler := handlers.NewImportHandler(importService, importNotifiers, handlerLogger, appMetrics)
+	savedFilterHandler := handlers.NewSavedFilterHandler(savedFilterService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	exportHandler := handlers.NewExportHandler(exportService)
+	assetReportHandler := handlers.NewAssetReportHandler(assetReportService)
+	auditHandler := handlers.NewAuditHandler(auditStore)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	orgHandler := handlers.NewOrganizationHandler(orgService)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
+	ssoHandler := handlers.NewSSOHandler(ssoService, cfg.CORS.AllowedOrigins, ssoConfig.BaseURL.Scheme == "https")
+	ldapHandler := handlers.NewLDAPHandler(ldapSyncService)
+	scimHandler := handlers.NewSCIMHandler(scimService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, mentionService)
+	prefHandler := handlers.NewUserPreferenceHandler(prefService)
+	quotaHandler := handlers.NewQuotaHandler(quotaService)
+	usageHandler := handlers.NewUsageHandler(usageStore)
+	statsHandler := handlers.NewStatsHandler(services.NewStatsService(statsRepo))
+	announcementHandler := handlers.NewAnnouncementHandler(services.NewAnnouncementService(announcementRepo, auditRecorder))
+	reminderService := services.NewReminderService(reminderRepo, noteRepo, notificationRepo, prefRepo, eventBus, messages, serviceLogger)
+	reminderHandler := handlers.NewReminderHandler(reminderService)
+	checklistHandler := handlers.NewChecklistHandler(services.NewChecklistService(checklistRepo, noteRepo, auditRecorder))
+	accessRequestHandler := handlers.NewAccessRequestHandler(services.NewAccessRequestService(accessRequestRepo, folderRepo, noteRepo, teamRepo, userRepo, notificationRepo, prefRepo, txManager, messages, auditRecorder, appMetrics, serviceLogger))
+	notificationStreamHandler := handlers.NewNotificationStreamHandler(notificationHub, cfg.CORS.AllowedOrigins)
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
 
 	// Initialize GraphQL resolver
 	resolver := &resolvers.Resolver{
-		UserService: userService,
+		UserService:    userService,
+		ImportService:  importService,
+		ImportNotifier: importNotifiers,
+	}
+
+	// Ask for a CAPTCHA on login and signup from IPs with many failed logins
+	if cfg.Captcha.Provider != "" {
+		verifier, err := captcha.New(cfg.Captcha.Provider, cfg.Captcha.Secret, time.Duration(cfg.Captcha.TimeoutSeconds)*time.Second)
+		if err != nil {
+			appLogger.Fatal("Failed to initialize captcha", logger.Error(err))
+		}
+		resolver.Captcha = services.NewCaptchaGuard(verifier, loginAttemptRepo, cfg.Captcha.FailureThreshold, time.Duration(cfg.Captcha.WindowMinutes)*time.Minute, serviceLogger)
 	}
 
 	// Create GraphQL server
 	gqlServer := handler.NewDefaultServer(generated.NewExecutableSchema(generated.Config{
 		Resolvers: resolver,
 	}))
+	gqlServer.SetErrorPresenter(resolvers.ErrorPresenter)
+	gqlServer.Use(resolvers.IntrospectionPolicy{Enabled: cfg.IntrospectionEnabled()})
+
+	// Initialize rate limiting. The store is always created so that limits
+	// can be switched on by a config reload.
+	var rateLimitStore middleware.RateLimitStore = middleware.NewMemoryRateLimitStore()
+	var maintenanceStore middleware.MaintenanceStore
+	var redisClient *redis.Client
+	if cfg.Redis.URL != "" {
+		redisOpts, err := redis.ParseURL(cfg.Redis.URL)
+		if err != nil {
+			appLogger.Fatal("Invalid REDIS_URL", logger.Error(err))
+		}
+		redisClient = redis.NewClient(redisOpts)
+		rateLimitStore = middleware.NewRedisRateLimitStore(redisClient)
+		maintenanceStore = middleware.NewRedisMaintenanceStore(redisClient)
+	}
+	maintenance := middleware.NewMaintenance(cfg.Maintenance, maintenanceStore, appLogger)
+	rateLimiter := middleware.NewRateLimiter(rateLimitStore, appLogger)
+	idempotency := middleware.NewIdempotency(idempotencyRepo, time.Duration(cfg.Idempotency.TTLHours)*time.Hour, appLogger)
+	idempotent := idempotency.Middleware()
+	if err := applyRateLimits(rateLimiter, cfg.RateLimit); err != nil {
+		appLogger.Fatal("Invalid rate limit configuration", logger.Error(err))
+	}
+
+	// Schedule recurring background work. Instances share job locks, so each
+	// scheduled run happens on one of them only.
+	scheduler := jobs.NewScheduler(jobs.NewGormLocker(db.DB), appLogger, appMetrics)
+	retentionService := services.NewRetentionService(repositories.NewRetentionRepository(db.DB),
+		time.Duration(cfg.Retention.SoftDeleteDays)*24*time.Hour, cfg.Retention.BatchSize, serviceLogger, appMetrics)
+	if err := registerJobs(scheduler, cfg.Jobs, idempotency, outboxRelay, retentionService, reminderService, ldapSyncService); err != nil {
+		appLogger.Fatal("Invalid job configuration", logger.Error(err))
+	}
+
+	// Apply the runtime-safe part of the config on SIGHUP or config file change
+	reloader := config.NewReloader(cfg, appLogger)
+
+	// Feature flags come from the config file, overridden by those admins
+	// store in the database. Other instances see a change once their cache
+	// expires.
+	featureFlags := featureflags.NewClient(30*time.Second, serviceLogger,
+		featureflags.Static(func() map[string]bool { return reloader.Current().Features }),
+		featureFlagRepo,
+	)
+	maintenanceHandler := handlers.NewMaintenanceHandler(maintenance, auditRecorder)
+	featureFlagHandler := handlers.NewFeatureFlagHandler(services.NewFeatureFlagService(featureFlagRepo, featureFlags, auditRecorder))
+	reloader.OnReload(func(next *config.Config) {
+		if setter, ok := appLogger.(logger.LevelSetter); ok {
+			if err := setter.SetLevel(next.Logging.Level); err != nil {
+				appLogger.Error("Failed to apply log level", logger.Error(err))
+			}
+		}
+		if err := applyComponentLevels(appLogger, next.Logging.ComponentLevels); err != nil {
+			appLogger.Error("Failed to apply component log levels", logger.Error(err))
+		}
+		if err := applyRateLimits(rateLimiter, next.RateLimit); err != nil {
+			appLogger.Error("Failed to apply rate limits", logger.Error(err))
+		}
+		if keys, err := loadJWTKeys(next.JWT); err != nil {
+			appLogger.Error("Failed to reload JWT signing keys", logger.Error(err))
+		} else {
+			jwtManager.SetKeys(keys)
+		}
+		featureFlags.Invalidate()
+		maintenance.SetConfig(next.Maintenance)
+	})
+
+	// Readiness covers everything a request may need
+	healthHandler := handlers.NewHealthHandler(handlerLogger, appMetrics)
+	healthHandler.AddDetailedCheck("database", func(ctx context.Context) (interface{}, error) {
+		return db.CheckPool(ctx)
+	})
+	healthHandler.AddCheck("migrations", db.CheckMigrations)
+	if redisClient != nil {
+		healthHandler.AddCheck("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+	// Domain events are written to the outbox first, so an unreachable bus
+	// only delays delivery
+	healthHandler.AddOptionalCheck("event_bus", eventBus.Ping)
+	if searchClient != nil {
+		healthHandler.AddOptionalCheck("search", searchClient.Ping)
+	}
+
+	// Report validation failures by JSON field name
+	apperrors.UseJSONFieldNames()
 
 	// Initialize Gin router
 	router := gin.Default()
+	router.NoRoute(func(c *gin.Context) {
+		middleware.RespondError(c, apperrors.NotFound("Route not found"))
+	})
+
+	// Compress responses; registered before RequestID so error bodies are
+	// finalised before they are encoded
+	if cfg.ResponseCompression.Enabled {
+		router.Use(middleware.NewCompressor(cfg.ResponseCompression).Middleware())
+	}
+
+	// Tag every request with an ID before anything can log or respond
+	router.Use(middleware.RequestID(appLogger))
+
+	// Translate error messages into the language asked for in Accept-Language
+	router.Use(middleware.Locale(messages))
+
+	// Remember where each request comes from for login history
+	router.Use(middleware.ClientInfo())
+
+	// Add CORS before auth and rate limiting so preflight requests are answered first
+	corsMiddleware, err := middleware.NewCORS(cfg.CORS)
+	if err != nil {
+		appLogger.Fatal("Invalid CORS configuration", logger.Error(err))
+	}
+	if corsMiddleware != nil {
+		router.Use(corsMiddleware)
+	}
 
 	// Add metrics middleware
 	router.Use(appMetrics.PrometheusMiddleware())
 
+	// Reject oversized bodies before handlers buffer them
+	bodyLimiter := middleware.NewBodyLimiter(int64(cfg.BodyLimit.JSONBytes), int64(cfg.BodyLimit.MultipartBytes))
+	bodyLimiter.SetRouteLimit(http.MethodPost, "/api/v1/import-users", int64(cfg.BodyLimit.ImportBytes))
+	router.Use(bodyLimiter.Middleware())
+
+	// Give every request a deadline its queries are cancelled at. Bulk
+	// routes get longer; streams and CPU profiles, which run for as long as
+	// asked, get none.
+	requestTimeout := middleware.NewRequestTimeout(time.Duration(cfg.RequestTimeout.DefaultSeconds) * time.Second)
+	importTimeout := time.Duration(cfg.RequestTimeout.ImportSeconds) * time.Second
+	requestTimeout.SetRouteTimeout(http.MethodPost, "/api/v1/import-users", importTimeout)
+	exportTimeout := time.Duration(cfg.RequestTimeout.ExportSeconds) * time.Second
+	requestTimeout.SetRouteTimeout(http.MethodGet, "/api/v1/folders/:folderId/export", exportTimeout)
+	requestTimeout.SetRouteTimeout(http.MethodPost, "/api/v1/folders/:folderId/duplicate", exportTimeout)
+	requestTimeout.SetRouteTimeout(http.MethodGet, "/api/v1/exports/:jobId/download", exportTimeout)
+	requestTimeout.SetRouteTimeout(http.MethodGet, "/api/v1/admin/reports/assets/:reportId/download", exportTimeout)
+	requestTimeout.SetRouteTimeout(http.MethodGet, "/ws/notifications", 0)
+	requestTimeout.SetRouteTimeout(http.MethodGet, "/api/v1/me/activity/stream", 0)
+	requestTimeout.SetRouteTimeout(http.MethodGet, "/debug/pprof/*profile", 0)
+	requestTimeout.SetRouteTimeout(http.MethodPost, "/debug/pprof/*profile", 0)
+	router.Use(requestTimeout.Middleware())
+
+	// Apply the default rate limit per user, or per client IP for anonymous
+	// requests. Tokens are read first so that managers and admins get their
+	// larger budgets; routes still require authentication on their own.
+	router.Use(authMiddleware.OptionalAuth())
+	if usageRecorder != nil {
+		router.Use(middleware.TrackUsage(usageRecorder))
+	}
+	// During maintenance, answer 503 outside health checks and admin routes
+	router.Use(maintenance.Middleware("/graphql"))
+	router.Use(rateLimiter.Limit("default"))
+
 	// Add logging middleware
 	router.Use(gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
 		appLogger.Info("HTTP Request",
 			logger.String("method", param.Method),
-			logger.String("path", param.Path),
+			logger.String("path", middleware.RedactAccessToken(param.Path)),
 			logger.Int("status", param.StatusCode),
 			logger.Duration("latency", param.Latency),
 			logger.String("client_ip", param.ClientIP),
+			logger.Any("request_id", param.Keys[middleware.RequestIDContextKey]),
 		)
 		return ""
 	}))
 
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		if err := db.Ping(); err != nil {
-			appLogger.Error("Health check failed", logger.Error(err))
-			appMetrics.RecordError("database", "health_check")
-			c.JSON(http.StatusServiceUnavailable, gin.H{
-				"status": "unhealthy",
-				"error":  err.Error(),
-			})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{
-			"status": "healthy",
-		})
-	})
+	// Liveness and readiness probes; /health adds per-dependency detail
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+	router.GET("/health", healthHandler.Health)
+
+	// Public keys for verifying tokens
+	router.GET("/.well-known/jwks.json", handlers.NewJWKSHandler(jwtManager).Keys)
+
+	// SAML single sign-on, with a service provider per organization
+	if cfg.SSO.BaseURL != "" {
+		saml := router.Group("/sso/saml/:slug")
+		saml.GET("/metadata", ssoHandler.Metadata)
+		saml.GET("/login", ssoHandler.Login)
+		saml.POST("/acs", rateLimiter.Limit("login"), ssoHandler.ACS)
+	}
+
+	// SCIM provisioning of users and teams, authenticated with the bearer
+	// token of an organization's SCIM connection
+	scimAPI := router.Group("/scim/v2")
+	scimAPI.Use(scimHandler.Authenticate())
+	{
+		scimAPI.GET("/Users", scimHandler.ListUsers)
+		scimAPI.POST("/Users", scimHandler.CreateUser)
+		scimAPI.GET("/Users/:id", scimHandler.GetUser)
+		scimAPI.PUT("/Users/:id", scimHandler.ReplaceUser)
+		scimAPI.PATCH("/Users/:id", scimHandler.PatchUser)
+		scimAPI.DELETE("/Users/:id", scimHandler.DeleteUser)
+		scimAPI.GET("/Groups", scimHandler.ListGroups)
+		scimAPI.POST("/Groups", scimHandler.CreateGroup)
+		scimAPI.GET("/Groups/:id", scimHandler.GetGroup)
+		scimAPI.PUT("/Groups/:id", scimHandler.ReplaceGroup)
+		scimAPI.PATCH("/Groups/:id", scimHandler.PatchGroup)
+		scimAPI.DELETE("/Groups/:id", scimHandler.DeleteGroup)
+	}
 
 	// Metrics endpoint
 	router.GET("/metrics", gin.WrapH(appMetrics.Handler()))
 
+	// Profiling and runtime stats for admins
+	if cfg.Debug.Enabled {
+		registerDebugRoutes(router.Group("/debug"), handlers.NewDebugHandler(), authMiddleware)
+	}
+
+	// v1 routes that have a v2 successor advertise their deprecation
+	var v1Deprecation gin.HandlerFunc
+	if since, sunset, ok := cfg.API.V1Deprecation(); ok {
+		v1Deprecation = middleware.Deprecated(since, sunset, "/api/v1", "/api/v2")
+	}
+
+	// OpenAPI document and Swagger UI for the REST API
+	openAPIHandler, err := handlers.NewOpenAPIHandler(handlers.NewAPISpec(v1Deprecation != nil), "/openapi.json")
+	if err != nil {
+		appLogger.Fatal("Failed to build OpenAPI document", logger.Error(err))
+	}
+	router.GET("/openapi.json", openAPIHandler.Spec)
+	router.GET("/docs", openAPIHandler.SwaggerUI)
+
+	// Real-time notifications over WebSocket (require authentication)
+	router.GET("/ws/notifications", authMiddleware.RequireStreamAuth(), notificationStreamHandler.Stream)
+
 	// GraphQL endpoints
-	router.POST("/graphql", gin.WrapH(gqlServer))
+	router.POST("/graphql",
+		rateLimiter.LimitCost("login", middleware.CountGraphQLMutations("login")),
+		rateLimiter.LimitCost("import", middleware.CountGraphQLMutations("importUsers")),
+		rateLimiter.Limit("graphql"),
+		gin.WrapH(gqlServer),
+	)
 	if cfg.GraphQL.Playground {
-		router.GET("/playground", gin.WrapH(playground.Handler("GraphQL Playground", "/graphql")))
+		// Browsers open the playground without headers, so the token may be
+		// passed as ?access_token; queries need it in the Authorization header
+		router.GET("/playground", authMiddleware.RequireStreamAuth(), gin.WrapH(playground.Handler("GraphQL Playground", "/graphql")))
 	}
 
 	// REST API routes
 	api := router.Group("/api/v1")
+	api.Use(rateLimiter.LimitIf("read", middleware.IsReadRequest), rateLimiter.LimitIf("write", middleware.IsWriteRequest))
 	{
-		// Team management routes (require authentication)
+		// Team management routes (require authentication); superseded by v2
 		teams := api.Group("/teams")
-		teams.Use(authMiddleware.RequireAuth())
-		{
-			teams.POST("", authMiddleware.RequireManager(), teamHandler.CreateTeam)
-			teams.GET("/:teamId", teamHandler.GetTeam)
-			teams.GET("", teamHandler.GetAllTeams)
-			teams.POST("/:teamId/members", authMiddleware.RequireManager(), teamHandler.AddMember)
-			teams.DELETE("/:teamId/members/:memberId", authMiddleware.RequireManager(), teamHandler.RemoveMember)
-			teams.POST("/:teamId/managers", authMiddleware.RequireManager(), teamHandler.AddManager)
-			teams.DELETE("/:teamId/managers/:managerId", authMiddleware.RequireManager(), teamHandler.RemoveManager)
+		if v1Deprecation != nil {
+			teams.Use(v1Deprecation)
 		}
+		registerTeamRoutes(teams, teamHandler, authMiddleware, idempotent)
 
 		// Folder management routes (require authentication)
 		folders := api.Group("/folders")
 		folders.Use(authMiddleware.RequireAuth())
 		{
-			folders.POST("", folderHandler.CreateFolder)
+			folders.POST("", idempotent, folderHandler.CreateFolder)
 			folders.GET("/:folderId", folderHandler.GetFolder)
 			folders.PUT("/:folderId", folderHandler.UpdateFolder)
 			folders.DELETE("/:folderId", folderHandler.DeleteFolder)
-			folders.POST("/:folderId/share", folderHandler.ShareFolder)
+			folders.POST("/:folderId/duplicate", idempotent, folderHandler.DuplicateFolder)
+			folders.POST("/:folderId/merge", folderHandler.MergeFolder)
+			folders.POST("/:folderId/share", idempotent, folderHandler.ShareFolder)
 			folders.DELETE("/:folderId/share/:userId", folderHandler.RevokeShare)
-			folders.POST("/:folderId/notes", noteHandler.CreateNote)
+			folders.GET("/:folderId/shares", folderHandler.GetShares)
+			folders.POST("/:folderId/access-requests", idempotent, accessRequestHandler.RequestFolderAccess)
+			folders.POST("/:folderId/notes", idempotent, noteHandler.CreateNote)
+			folders.GET("/:folderId/export", exportHandler.ExportFolder)
+		}
+
+		// Export job routes (require authentication)
+		exports := api.Group("/exports")
+		exports.Use(authMiddleware.RequireAuth())
+		{
+			exports.GET("/:jobId", exportHandler.GetExportJob)
+			exports.GET("/:jobId/download", exportHandler.DownloadExport)
 		}
 
 		// Note management routes (require authentication)
@@ -165,29 +638,478 @@ func main() {
 			notes.GET("/:noteId", noteHandler.GetNote)
 			notes.PUT("/:noteId", noteHandler.UpdateNote)
 			notes.DELETE("/:noteId", noteHandler.DeleteNote)
-			notes.POST("/:noteId/share", noteHandler.ShareNote)
+			notes.PUT("/:noteId/status", noteHandler.ChangeStatus)
+			notes.GET("/:noteId/backlinks", noteHandler.GetBacklinks)
+			notes.POST("/:noteId/share", idempotent, noteHandler.ShareNote)
 			notes.DELETE("/:noteId/share/:userId", noteHandler.RevokeShare)
+			notes.GET("/:noteId/shares", noteHandler.GetShares)
+			notes.POST("/:noteId/access-requests", idempotent, accessRequestHandler.RequestNoteAccess)
+			notes.GET("/:noteId/reminders", reminderHandler.GetReminders)
+			notes.POST("/:noteId/reminders", idempotent, reminderHandler.CreateReminder)
+			notes.PUT("/:noteId/reminders/:reminderId", reminderHandler.UpdateReminder)
+			notes.DELETE("/:noteId/reminders/:reminderId", reminderHandler.DeleteReminder)
+			notes.GET("/:noteId/checklist", checklistHandler.GetChecklist)
+			notes.POST("/:noteId/checklist", idempotent, checklistHandler.AddItem)
+			notes.PUT("/:noteId/checklist/:itemId", checklistHandler.UpdateItem)
+			notes.DELETE("/:noteId/checklist/:itemId", checklistHandler.DeleteItem)
+		}
+
+		// Saved filter routes (require authentication)
+		savedFilters := api.Group("/saved-filters")
+		savedFilters.Use(authMiddleware.RequireAuth())
+		{
+			savedFilters.POST("", idempotent, savedFilterHandler.CreateSavedFilter)
+			savedFilters.GET("", savedFilterHandler.GetSavedFilters)
+			savedFilters.GET("/:filterId", savedFilterHandler.GetSavedFilter)
+			savedFilters.PUT("/:filterId", savedFilterHandler.UpdateSavedFilter)
+			savedFilters.DELETE("/:filterId", savedFilterHandler.DeleteSavedFilter)
+			savedFilters.GET("/:filterId/results", savedFilterHandler.ExecuteSavedFilter)
+		}
+		api.GET("/home", authMiddleware.RequireAuth(), savedFilterHandler.GetHome)
+
+		// Search across notes, folders, teams and users (require authentication)
+		api.GET("/search", authMiddleware.RequireAuth(), searchHandler.Search)
+
+		// Current user routes (require authentication)
+		me := api.Group("/me")
+		me.Use(authMiddleware.RequireAuth())
+		{
+			me.GET("/notes", noteHandler.GetMyNotes)
+			me.GET("/mentions", notificationHandler.GetMyMentions)
+			me.GET("/notifications", notificationHandler.GetMyNotifications)
+			me.POST("/notifications/:notificationId/read", notificationHandler.MarkNotificationRead)
+			me.GET("/preferences", prefHandler.GetMyPreferences)
+			me.PUT("/preferences", prefHandler.UpdateMyPreferences)
+			me.GET("/quota", quotaHandler.GetMyQuota)
+			me.GET("/features", featureFlagHandler.GetMyFeatures)
+			me.GET("/access-requests", accessRequestHandler.GetPending)
+			me.GET("/login-history", loginHistoryHandler.GetMyLoginHistory)
+			me.GET("/devices", deviceHandler.GetMyDevices)
+			me.DELETE("/devices/:deviceId", deviceHandler.RevokeDevice)
+		}
+		// Server-Sent Events can't carry headers from browsers either
+		api.GET("/me/activity/stream", authMiddleware.RequireStreamAuth(), notificationStreamHandler.ActivityStream)
+
+		// Access request decisions (require authentication, owner only)
+		accessRequests := api.Group("/access-requests")
+		accessRequests.Use(authMiddleware.RequireAuth())
+		{
+			accessRequests.POST("/:requestId/approve", accessRequestHandler.Approve)
+			accessRequests.POST("/:requestId/deny", accessRequestHandler.Deny)
 		}
 
 		// Asset viewing routes (require authentication)
 		api.GET("/users/:userId/assets", authMiddleware.RequireAuth(), assetHandler.GetUserAssets)
-		api.GET("/teams/:teamId/assets", authMiddleware.RequireAuth(), authMiddleware.RequireManager(), assetHandler.GetTeamAssets)
+		api.GET("/teams/:teamId/assets", authMiddleware.RequireAuth(), authMiddleware.RequireManager(), authMiddleware.RequireTeamManager("teamId"), assetHandler.GetTeamAssets)
+
+		// Login history of a team's people (require authentication, team managers only)
+		api.GET("/teams/:teamId/login-history", authMiddleware.RequireAuth(), authMiddleware.RequireManager(), authMiddleware.RequireTeamManager("teamId"), loginHistoryHandler.GetTeamLoginHistory)
 
-		// Import routes (require authentication and manager role)
-		api.POST("/import-users", authMiddleware.RequireAuth(), authMiddleware.RequireManager(), importHandler.ImportUsers)
+		// Import routes (require authentication and the users:import scope).
+		// Replayed imports do not count against the import rate limit.
+		api.POST("/import-users", authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeUsersImport), idempotent, rateLimiter.Limit("import"), importHandler.ImportUsers)
 		api.GET("/import-users/template", authMiddleware.RequireAuth(), importHandler.GetImportTemplate)
-		api.GET("/import-users/status", authMiddleware.RequireAuth(), authMiddleware.RequireManager(), importHandler.GetImportStatus)
+		api.GET("/import-users/status", authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeUsersImport), importHandler.GetImportStatus)
+
+		// Audit log routes (require authentication and the audit:read scope)
+		api.GET("/audit-logs", authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAuditRead), auditHandler.GetAuditLogs)
+
+		// Webhook routes (require authentication and the webhooks:manage scope)
+		webhooks := api.Group("/webhooks")
+		webhooks.Use(authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeWebhooksManage))
+		{
+			webhooks.POST("", idempotent, webhookHandler.CreateWebhook)
+			webhooks.GET("", webhookHandler.GetWebhooks)
+			webhooks.GET("/:webhookId", webhookHandler.GetWebhook)
+			webhooks.PUT("/:webhookId", webhookHandler.UpdateWebhook)
+			webhooks.DELETE("/:webhookId", webhookHandler.DeleteWebhook)
+			webhooks.GET("/:webhookId/deliveries", webhookHandler.GetDeliveries)
+		}
+
+		// Organization admin routes (require authentication and the admin scope)
+		orgs := api.Group("/admin/organizations")
+		orgs.Use(authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin))
+		{
+			orgs.POST("", idempotent, orgHandler.CreateOrganization)
+			orgs.GET("", orgHandler.GetOrganizations)
+			orgs.GET("/:orgId", orgHandler.GetOrganization)
+			orgs.GET("/:orgId/users", orgHandler.GetOrganizationUsers)
+			orgs.PUT("/:orgId/users/:userId", orgHandler.AddUser)
+			orgs.GET("/:orgId/saml", ssoHandler.GetSAMLConnection)
+			orgs.PUT("/:orgId/saml", ssoHandler.PutSAMLConnection)
+			orgs.GET("/:orgId/ldap", ldapHandler.GetLDAPConnection)
+			orgs.PUT("/:orgId/ldap", ldapHandler.PutLDAPConnection)
+			orgs.POST("/:orgId/ldap/sync", ldapHandler.SyncLDAP)
+			orgs.GET("/:orgId/scim", scimHandler.GetSCIMConnection)
+			orgs.POST("/:orgId/scim/token", scimHandler.CreateSCIMToken)
+			orgs.DELETE("/:orgId/scim", scimHandler.DeleteSCIMConnection)
+		}
+
+		// Support staff act as a user to reproduce what they reported
+		// (requires authentication and the admin scope)
+		api.POST("/admin/impersonate/:userId", authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin), impersonationHandler.Impersonate)
+
+		// API usage reports and dashboard statistics (require authentication
+		// and the admin scope)
+		api.GET("/admin/usage", authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin), usageHandler.GetUsage)
+		api.GET("/admin/stats", authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin), statsHandler.GetStats)
+
+		// Cross-team asset reports of the admin's organization (require
+		// authentication and the admin scope)
+		assetReports := api.Group("/admin/reports/assets")
+		assetReports.Use(authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin))
+		{
+			assetReports.GET("", assetReportHandler.RequestReport)
+			assetReports.GET("/:reportId", assetReportHandler.GetReport)
+			assetReports.GET("/:reportId/download", assetReportHandler.DownloadReport)
+		}
+
+		// Announcements: clients read the current ones without signing in,
+		// admins manage them (require authentication and the admin scope)
+		api.GET("/announcements", announcementHandler.GetActiveAnnouncements)
+		announcements := api.Group("/admin/announcements")
+		announcements.Use(authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin))
+		{
+			announcements.GET("", announcementHandler.GetAnnouncements)
+			announcements.POST("", idempotent, announcementHandler.CreateAnnouncement)
+			announcements.PUT("/:announcementId", announcementHandler.UpdateAnnouncement)
+			announcements.DELETE("/:announcementId", announcementHandler.DeleteAnnouncement)
+		}
+
+		// Maintenance mode (requires authentication and the admin scope)
+		maintenanceRoutes := api.Group("/admin/maintenance")
+		maintenanceRoutes.Use(authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin))
+		{
+			maintenanceRoutes.GET("", maintenanceHandler.GetMaintenance)
+			maintenanceRoutes.PUT("", maintenanceHandler.PutMaintenance)
+			maintenanceRoutes.DELETE("", maintenanceHandler.DeleteMaintenance)
+		}
+
+		// Feature flag routes (require authentication and the admin scope)
+		flags := api.Group("/admin/feature-flags")
+		flags.Use(authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin))
+		{
+			flags.GET("", featureFlagHandler.GetFlags)
+			flags.PUT("/:name", featureFlagHandler.PutFlag)
+			flags.DELETE("/:name", featureFlagHandler.DeleteFlag)
+		}
+	}
+
+	// REST API v2 routes. Resources move here one at a time as their payloads
+	// change; each shares its handler with v1 and only swaps the codec.
+	apiV2 := router.Group("/api/v2")
+	apiV2.Use(rateLimiter.LimitIf("read", middleware.IsReadRequest), rateLimiter.LimitIf("write", middleware.IsWriteRequest))
+	{
+		registerTeamRoutes(apiV2.Group("/teams"), teamHandlerV2, authMiddleware, idempotent)
+	}
+
+	for _, route := range openAPIHandler.Undocumented(router.Routes()) {
+		appLogger.Warn("Route missing from the OpenAPI document", logger.String("route", route))
 	}
 
 	appLogger.Info("Server starting",
 		logger.String("port", cfg.Server.Port),
 		logger.String("mode", cfg.Server.GinMode),
 	)
-	appLogger.Info("GraphQL Playground available", logger.String("url", "http://localhost:"+cfg.Server.Port+"/playground"))
-	appLogger.Info("Health check available", logger.String("url", "http://localhost:"+cfg.Server.Port+"/health"))
+	if cfg.GraphQL.Playground {
+		appLogger.Info("GraphQL Playground available", logger.String("url", "http://localhost:"+cfg.Server.Port+"/playground"))
+	}
+	appLogger.Info("Health checks available",
+		logger.String("liveness", "http://localhost:"+cfg.Server.Port+"/healthz"),
+		logger.String("readiness", "http://localhost:"+cfg.Server.Port+"/readyz"),
+	)
 	appLogger.Info("Metrics available", logger.String("url", "http://localhost:"+cfg.Server.Port+"/metrics"))
+	appLogger.Info("API documentation available", logger.String("url", "http://localhost:"+cfg.Server.Port+"/docs"))
+
+	srv := &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: router,
+	}
+
+	useTLS := cfg.Server.TLS.Enabled()
+	var redirectSrv *http.Server
+	if useTLS {
+		redirectSrv, err = configureTLS(srv, cfg.Server.TLS, cfg.Server.Port)
+		if err != nil {
+			appLogger.Fatal("Invalid TLS configuration", logger.Error(err))
+		}
+		appLogger.Info("TLS enabled",
+			logger.Any("autocert_domains", cfg.Server.TLS.AutocertDomains),
+			logger.String("redirect_port", cfg.Server.TLS.RedirectPort),
+		)
+	}
+
+	// gRPC API for internal services, sharing the service layer with REST
+	var grpcSrv *grpc.Server
+	if cfg.Server.GRPCPort != "" {
+		var grpcOpts []grpc.ServerOption
+		if useTLS {
+			creds, err := grpcCredentials(srv, cfg.Server.TLS)
+			if err != nil {
+				appLogger.Fatal("Invalid TLS configuration for gRPC", logger.Error(err))
+			}
+			grpcOpts = append(grpcOpts, grpc.Creds(creds))
+		}
+		grpcSrv = grpcserver.New(userService, teamService, folderService, noteService, jwtManager,
+			logger.ForComponent(appLogger, logger.ComponentHandler), grpcOpts...)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go reloader.Watch(ctx)
+	if cfg.Database.HealthCheckIntervalSeconds > 0 {
+		go db.Monitor(ctx, time.Duration(cfg.Database.HealthCheckIntervalSeconds)*time.Second)
+	}
+	go outboxRelay.Run(ctx, time.Duration(cfg.Outbox.PollIntervalMillis)*time.Millisecond)
+	go activityPublisher.Run(ctx)
+	if cfg.Jobs.Enabled {
+		scheduler.Start(ctx)
+	}
 
-	if err := router.Run(":" + cfg.Server.Port); err != nil {
+	serverErr := make(chan error, 3)
+	healthHandler.SetReady(true)
+	go func() {
+		var err error
+		if useTLS {
+			// Certificates come from TLSConfig when autocert is in use
+			err = srv.ListenAndServeTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	if redirectSrv != nil {
+		go func() {
+			if err := redirectSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- err
+			}
+		}()
+	}
+	if grpcSrv != nil {
+		listener, err := net.Listen("tcp", ":"+cfg.Server.GRPCPort)
+		if err != nil {
+			appLogger.Fatal("Failed to listen for gRPC", logger.Error(err))
+		}
+		appLogger.Info("gRPC server starting", logger.String("port", cfg.Server.GRPCPort))
+		go func() {
+			if err := grpcSrv.Serve(listener); err != nil {
+				serverErr <- err
+			}
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
 		appLogger.Fatal("Failed to start server", logger.Error(err))
+	case <-ctx.Done():
+	}
+	stop()
+
+	// Fail readiness first and give load balancers time to notice before
+	// connections are drained
+	healthHandler.SetReady(false)
+	if delay := time.Duration(cfg.Server.ShutdownDelaySeconds) * time.Second; delay > 0 {
+		appLogger.Info("Marked not ready, waiting before shutdown", logger.Duration("delay", delay))
+		time.Sleep(delay)
+	}
+
+	gracePeriod := time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+	appLogger.Info("Shutting down server", logger.Duration("grace_period", gracePeriod))
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	// Shutdown does not wait for WebSocket connections, so tell their clients
+	// to reconnect elsewhere first
+	notificationHub.Close()
+
+	// Stop accepting connections and drain in-flight requests first, so that
+	// any webhooks they enqueue are covered by the sender shutdown below
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("HTTP server did not drain in time", logger.Error(err))
+	}
+	if redirectSrv != nil {
+		if err := redirectSrv.Shutdown(shutdownCtx); err != nil {
+			appLogger.Error("HTTPS redirect server did not drain in time", logger.Error(err))
+		}
+	}
+	if grpcSrv != nil {
+		stopGRPC(shutdownCtx, grpcSrv, appLogger)
+	}
+
+	if err := scheduler.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("Scheduled jobs did not finish in time", logger.Error(err))
+	}
+
+	if err := exportService.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("Export workers did not finish in time", logger.Error(err))
+	}
+
+	if err := assetReportService.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("Asset report workers did not finish in time", logger.Error(err))
+	}
+
+	// Close the bus before the sender so no new webhook deliveries start
+	if err := eventBus.Close(); err != nil {
+		appLogger.Error("Failed to close event bus", logger.Error(err))
+	}
+
+	if err := webhookSender.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("Pending webhook deliveries were cancelled", logger.Error(err))
+	}
+
+	if usageRecorder != nil {
+		if err := usageRecorder.Shutdown(shutdownCtx); err != nil {
+			appLogger.Error("API usage counts were not all written", logger.Error(err))
+		}
+	}
+
+	if err := auditWriter.Shutdown(shutdownCtx); err != nil {
+		appLogger.Error("Audit log entries were not all written", logger.Error(err))
+	}
+
+	if redisClient != nil {
+		if err := redisClient.Close(); err != nil {
+			appLogger.Error("Failed to close Redis connection", logger.Error(err))
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		appLogger.Error("Failed to close database connection", logger.Error(err))
+	}
+
+	appLogger.Info("Server stopped")
+}
+
+// stopGRPC lets in-flight calls finish, cancelling those still running when
+// ctx is done
+func stopGRPC(ctx context.Context, srv *grpc.Server, log logger.Logger) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Error("gRPC server did not drain in time", logger.Error(ctx.Err()))
+		srv.Stop()
+	}
+}
+
+// registerDebugRoutes mounts pprof and the runtime stats endpoints on group,
+// restricted to tokens with the admin scope
+func registerDebugRoutes(group *gin.RouterGroup, h *handlers.DebugHandler, authMiddleware *middleware.AuthMiddleware) {
+	group.Use(authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin))
+	group.GET("/runtime", h.Runtime)
+	group.GET("/vars", h.Vars)
+	group.GET("/pprof/*profile", h.Pprof)
+	group.POST("/pprof/*profile", h.Pprof)
+}
+
+// registerTeamRoutes mounts the team endpoints on group; every API version
+// uses the same routes with its own handler. idempotent guards the POSTs.
+func registerTeamRoutes(group *gin.RouterGroup, h *handlers.TeamHandler, authMiddleware *middleware.AuthMiddleware, idempotent gin.HandlerFunc) {
+	group.Use(authMiddleware.RequireAuth())
+	group.POST("", authMiddleware.RequireScope(auth.ScopeTeamsManage), idempotent, h.CreateTeam)
+	group.GET("/:teamId", h.GetTeam)
+	group.GET("", h.GetAllTeams)
+	group.POST("/:teamId/members", authMiddleware.RequireScope(auth.ScopeTeamsManage), idempotent, h.AddMember)
+	group.DELETE("/:teamId/members/:memberId", authMiddleware.RequireScope(auth.ScopeTeamsManage), h.RemoveMember)
+	group.POST("/:teamId/managers", authMiddleware.RequireScope(auth.ScopeTeamsManage), idempotent, h.AddManager)
+	group.DELETE("/:teamId/managers/:managerId", authMiddleware.RequireScope(auth.ScopeTeamsManage), h.RemoveManager)
+	group.PUT("/:teamId/roles/:userId", authMiddleware.RequireScope(auth.ScopeTeamsManage), h.SetRole)
+	group.PUT("/:teamId/settings", authMiddleware.RequireScope(auth.ScopeTeamsManage), h.UpdateSettings)
+}
+
+// newEventBus connects to the message bus selected by cfg
+func newEventBus(cfg config.EventsConfig, log logger.Logger) (events.Bus, error) {
+	if cfg.Driver == "nats" {
+		return events.NewNATSBus(cfg.NATSURL, cfg.SubjectPrefix, cfg.QueueGroup, log)
+	}
+	return events.NewMemoryBus(log), nil
+}
+
+// loadJWTKeys reads the signing keys from cfg.KeysDir. It returns nil for
+// HS256, in which case tokens use the shared secret.
+func loadJWTKeys(cfg config.JWTConfig) (*auth.KeySet, error) {
+	if !cfg.Asymmetric() {
+		return nil, nil
+	}
+	return auth.LoadKeySet(cfg.KeysDir, cfg.ActiveKeyID, cfg.Algorithm)
+}
+
+// newSSOConfig builds the configuration shared by the SAML service providers
+// of all organizations, reading their certificate and key when configured
+func newSSOConfig(cfg config.SSOConfig) (*sso.Config, error) {
+	baseURL, err := url.Parse(cfg.BaseURL)
+	if err != nil {
+		return nil, err
+	}
+	ssoConfig := &sso.Config{BaseURL: *baseURL}
+	if cfg.CertFile != "" {
+		if ssoConfig.Certificate, ssoConfig.Key, err = sso.LoadKeyPair(cfg.CertFile, cfg.KeyFile); err != nil {
+			return nil, err
+		}
+	}
+	return ssoConfig, nil
+}
+
+// registerJobs adds the recurring background jobs to scheduler
+func registerJobs(scheduler *jobs.Scheduler, cfg config.JobsConfig, idempotency *middleware.Idempotency, relay *outbox.Relay, retention *services.RetentionService, reminders *services.ReminderService, ldapSync *services.LDAPSyncService) error {
+	const timeout = 10 * time.Minute
+	if err := scheduler.Register("idempotency-purge", cfg.IdempotencyPurgeSchedule, timeout, idempotency.PurgeExpired); err != nil {
+		return err
+	}
+	if err := scheduler.Register("outbox-purge", cfg.OutboxPurgeSchedule, timeout, relay.PurgePublished); err != nil {
+		return err
+	}
+	if err := scheduler.Register("soft-delete-purge", cfg.SoftDeletePurgeSchedule, time.Hour, retention.PurgeDeleted); err != nil {
+		return err
+	}
+	if err := scheduler.Register("reminders", cfg.ReminderSchedule, 5*time.Minute, reminders.SendDue); err != nil {
+		return err
+	}
+	return scheduler.Register("ldap-sync", cfg.LDAPSyncSchedule, 30*time.Minute, ldapSync.SyncAll)
+}
+
+// applyRateLimits installs the configured rules on rl. Rules are left
+// untouched while limiting is disabled.
+func applyRateLimits(rl *middleware.RateLimiter, cfg config.RateLimitConfig) error {
+	rl.SetEnabled(cfg.Enabled)
+	if !cfg.Enabled {
+		return nil
+	}
+	rl.SetMultiplier(middleware.TierManager, cfg.ManagerMultiplier)
+	rl.SetMultiplier(middleware.TierAdmin, cfg.AdminMultiplier)
+
+	rules := map[string]string{
+		"default": cfg.Default,
+		"graphql": cfg.GraphQL,
+		"login":   cfg.Login,
+		"import":  cfg.Import,
+		"read":    cfg.Read,
+		"write":   cfg.Write,
+	}
+	for scope, value := range rules {
+		rule, err := middleware.ParseRateLimitRule(value)
+		if err != nil {
+			return fmt.Errorf("rate_limit.%s: %w", scope, err)
+		}
+		rl.SetRule(scope, rule)
+	}
+	return nil
+}
+
+// applyComponentLevels sets the per-component log level overrides on l, if
+// it supports them
+func applyComponentLevels(l logger.Logger, levels map[string]string) error {
+	setter, ok := l.(logger.ComponentLevelSetter)
+	if !ok {
+		return nil
 	}
+	return setter.SetComponentLevels(levels)
 }
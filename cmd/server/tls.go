@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc/credentials"
+
+	"seta-training/internal/config"
+)
+
+// configureTLS prepares srv to terminate TLS and returns the optional plain
+// HTTP server that redirects to HTTPS. HTTP/2 is negotiated via ALPN.
+func configureTLS(srv *http.Server, cfg config.TLSConfig, httpsPort string) (*http.Server, error) {
+	var challengeHandler func(http.Handler) http.Handler
+
+	if len(cfg.AutocertDomains) > 0 {
+		if cfg.CertFile != "" || cfg.KeyFile != "" {
+			return nil, errors.New("TLS_CERT_FILE/TLS_KEY_FILE and TLS_AUTOCERT_DOMAINS are mutually exclusive")
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		srv.TLSConfig = manager.TLSConfig()
+		challengeHandler = manager.HTTPHandler
+	} else {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, errors.New("both TLS_CERT_FILE and TLS_KEY_FILE are required")
+		}
+		srv.TLSConfig = &tls.Config{
+			NextProtos: []string{"h2", "http/1.1"},
+		}
+	}
+	srv.TLSConfig.MinVersion = tls.VersionTLS12
+
+	if cfg.RedirectPort == "" {
+		return nil, nil
+	}
+
+	var redirect http.Handler = httpsRedirectHandler(httpsPort)
+	if challengeHandler != nil {
+		// Answers ACME HTTP-01 challenges and redirects everything else
+		redirect = challengeHandler(redirect)
+	}
+	return &http.Server{
+		Addr:    ":" + cfg.RedirectPort,
+		Handler: redirect,
+	}, nil
+}
+
+// httpsRedirectHandler permanently redirects requests to the same URL on the
+// HTTPS port
+func httpsRedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "" && httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// grpcCredentials serves gRPC with the certificates of srv, which
+// configureTLS has prepared
+func grpcCredentials(srv *http.Server, cfg config.TLSConfig) (credentials.TransportCredentials, error) {
+	tlsConfig := srv.TLSConfig.Clone()
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return credentials.NewTLS(tlsConfig), nil
+}
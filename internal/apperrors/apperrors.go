@@ -0,0 +1,266 @@
+// Package apperrors defines the typed errors services return and the JSON
+// envelope they are rendered as, so that handlers map every failure to the
+// right HTTP status without inspecting error strings.
+package apperrors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Code identifies a class of error in API responses
+type Code string
+
+const (
+	CodeValidation      Code = "validation_failed"
+	CodeUnauthorized    Code = "unauthorized"
+	CodeForbidden       Code = "forbidden"
+	CodeQuotaExceeded   Code = "quota_exceeded"
+	CodeCaptchaRequired Code = "captcha_required"
+	CodeNotFound        Code = "not_found"
+	CodeConflict        Code = "conflict"
+	CodePayloadTooLarge Code = "payload_too_large"
+	CodeUnprocessable   Code = "unprocessable"
+	CodeRateLimited     Code = "rate_limited"
+	CodeUnavailable     Code = "unavailable"
+	CodeTimeout         Code = "timeout"
+	CodeInternal        Code = "internal_error"
+)
+
+var codeStatus = map[Code]int{
+	CodeValidation:      http.StatusBadRequest,
+	CodeUnauthorized:    http.StatusUnauthorized,
+	CodeForbidden:       http.StatusForbidden,
+	CodeQuotaExceeded:   http.StatusForbidden,
+	CodeCaptchaRequired: http.StatusForbidden,
+	CodeNotFound:        http.StatusNotFound,
+	CodeConflict:        http.StatusConflict,
+	CodePayloadTooLarge: http.StatusRequestEntityTooLarge,
+	CodeUnprocessable:   http.StatusUnprocessableEntity,
+	CodeRateLimited:     http.StatusTooManyRequests,
+	CodeUnavailable:     http.StatusServiceUnavailable,
+	CodeTimeout:         http.StatusGatewayTimeout,
+	CodeInternal:        http.StatusInternalServerError,
+}
+
+// Sentinels for errors.Is; any *Error with the same code matches
+var (
+	ErrValidation    = &Error{Code: CodeValidation, Message: "invalid input"}
+	ErrUnauthorized  = &Error{Code: CodeUnauthorized, Message: "unauthorized"}
+	ErrForbidden     = &Error{Code: CodeForbidden, Message: "access denied"}
+	ErrQuotaExceeded = &Error{Code: CodeQuotaExceeded, Message: "quota exceeded"}
+	ErrCaptcha       = &Error{Code: CodeCaptchaRequired, Message: "captcha required"}
+	ErrNotFound      = &Error{Code: CodeNotFound, Message: "not found"}
+	ErrConflict      = &Error{Code: CodeConflict, Message: "conflict"}
+	ErrUnavailable   = &Error{Code: CodeUnavailable, Message: "temporarily unavailable"}
+)
+
+// Error is an error with a client-facing code and message. Fields holds
+// per-field validation messages keyed by JSON field name.
+type Error struct {
+	Code    Code
+	Message string
+	Fields  map[string]string
+	// Err is the underlying cause; it is logged but never sent to clients
+	Err error
+
+	// msg and fieldMsgs keep the untranslated formats behind Message and
+	// Fields so Localize can render them in another language
+	msg       message
+	fieldMsgs map[string]message
+}
+
+// message is a format string and the arguments it is rendered with
+type message struct {
+	format string
+	args   []interface{}
+}
+
+// Translator renders format with args in the client's language. It must
+// only apply args when there are some, since untranslated messages are
+// passed as formats with none.
+type Translator func(format string, args ...interface{}) string
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches sentinels by code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Status is the HTTP status for the error's code
+func (e *Error) Status() int {
+	if status, ok := codeStatus[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Localize returns a copy of e with its message and field messages
+// rendered by translate. Messages built without a format, such as the
+// sentinels, are looked up by their text.
+func (e *Error) Localize(translate Translator) *Error {
+	if translate == nil {
+		return e
+	}
+	localized := *e
+	if e.msg.format != "" {
+		localized.Message = translate(e.msg.format, e.msg.args...)
+	} else {
+		localized.Message = translate(e.Message)
+	}
+	if len(e.Fields) > 0 {
+		localized.Fields = make(map[string]string, len(e.Fields))
+		for field, text := range e.Fields {
+			if m, ok := e.fieldMsgs[field]; ok {
+				localized.Fields[field] = translate(m.format, m.args...)
+			} else {
+				localized.Fields[field] = translate(text)
+			}
+		}
+	}
+	return &localized
+}
+
+func newError(code Code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...), msg: message{format, args}}
+}
+
+func Validation(format string, args ...interface{}) *Error {
+	return newError(CodeValidation, format, args...)
+}
+
+// ValidationFields is a validation error with per-field messages
+func ValidationFields(message string, fields map[string]string) *Error {
+	return &Error{Code: CodeValidation, Message: message, Fields: fields}
+}
+
+func Unauthorized(format string, args ...interface{}) *Error {
+	return newError(CodeUnauthorized, format, args...)
+}
+
+func Forbidden(format string, args ...interface{}) *Error {
+	return newError(CodeForbidden, format, args...)
+}
+
+// QuotaExceeded reports that creating something would take its owner past a
+// configured limit
+func QuotaExceeded(format string, args ...interface{}) *Error {
+	return newError(CodeQuotaExceeded, format, args...)
+}
+
+// CaptchaRequired reports that the request must carry a solved CAPTCHA
+func CaptchaRequired(format string, args ...interface{}) *Error {
+	return newError(CodeCaptchaRequired, format, args...)
+}
+
+func NotFound(format string, args ...interface{}) *Error {
+	return newError(CodeNotFound, format, args...)
+}
+
+func Conflict(format string, args ...interface{}) *Error {
+	return newError(CodeConflict, format, args...)
+}
+
+func PayloadTooLarge(format string, args ...interface{}) *Error {
+	return newError(CodePayloadTooLarge, format, args...)
+}
+
+func Unprocessable(format string, args ...interface{}) *Error {
+	return newError(CodeUnprocessable, format, args...)
+}
+
+func RateLimited(format string, args ...interface{}) *Error {
+	return newError(CodeRateLimited, format, args...)
+}
+
+func Unavailable(format string, args ...interface{}) *Error {
+	return newError(CodeUnavailable, format, args...)
+}
+
+func Timeout(format string, args ...interface{}) *Error {
+	return newError(CodeTimeout, format, args...)
+}
+
+// Wrap returns an error with the given code whose message is prefix
+// followed by err's message
+func Wrap(code Code, err error, prefix string) *Error {
+	return &Error{
+		Code:    code,
+		Message: prefix + ": " + err.Error(),
+		Err:     err,
+		msg:     message{strings.ReplaceAll(prefix, "%", "%%") + ": %s", []interface{}{err.Error()}},
+	}
+}
+
+// Internal wraps an unexpected failure. Clients only see a generic message.
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "internal server error", Err: err}
+}
+
+// From returns err as an *Error. Missing records become not found, work cut
+// short by a context deadline becomes a timeout and any other untyped error
+// is treated as internal.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &Error{Code: CodeNotFound, Message: "resource not found", Err: err}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Code: CodeTimeout, Message: "request timed out", Err: err}
+	}
+	return Internal(err)
+}
+
+// Response is the JSON body of every error response
+type Response struct {
+	Code      Code                   `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// NewResponse renders err for a client
+func NewResponse(err *Error, requestID string) Response {
+	resp := Response{
+		Code:      err.Code,
+		Message:   err.Message,
+		RequestID: requestID,
+	}
+	if len(err.Fields) > 0 {
+		resp.Details = make(map[string]interface{}, len(err.Fields))
+		for field, msg := range err.Fields {
+			resp.Details[field] = msg
+		}
+	}
+	return resp
+}
+
+// CodeForStatus picks the code for responses written without an *Error
+func CodeForStatus(status int) Code {
+	for code, s := range codeStatus {
+		// quota_exceeded shares 403 with forbidden, the general case
+		if s == status && code != CodeQuotaExceeded {
+			return code
+		}
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return Code(strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"))
+}
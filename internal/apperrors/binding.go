@@ -0,0 +1,90 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// UseJSONFieldNames makes gin's validator report fields by their JSON name,
+// so validation details use the names clients send
+func UseJSONFieldNames() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+}
+
+// FromBinding converts an error from c.ShouldBind* into a validation error
+// with a message per invalid field
+func FromBinding(err error) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fieldMsgs := make(map[string]message, len(validationErrs))
+		for _, fe := range validationErrs {
+			fieldMsgs[fe.Field()] = ruleMessage(fe)
+		}
+		return validationMessages(fieldMsgs)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return validationMessages(map[string]message{
+			typeErr.Field: {"must be a %s", []interface{}{typeErr.Type.String()}},
+		})
+	}
+	return &Error{
+		Code:    CodeValidation,
+		Message: "Invalid input: " + err.Error(),
+		Err:     err,
+		msg:     message{"Invalid input: %s", []interface{}{err.Error()}},
+	}
+}
+
+// validationMessages renders per-field formats, keeping them for Localize
+func validationMessages(fieldMsgs map[string]message) *Error {
+	fields := make(map[string]string, len(fieldMsgs))
+	for field, m := range fieldMsgs {
+		fields[field] = fmt.Sprintf(m.format, m.args...)
+	}
+	appErr := ValidationFields("Invalid input", fields)
+	appErr.fieldMsgs = fieldMsgs
+	return appErr
+}
+
+func ruleMessage(fe validator.FieldError) message {
+	switch fe.Tag() {
+	case "required":
+		return message{format: "is required"}
+	case "min":
+		if fe.Kind() == reflect.String {
+			return message{"must be at least %s characters", []interface{}{fe.Param()}}
+		}
+		return message{"must be at least %s", []interface{}{fe.Param()}}
+	case "max":
+		if fe.Kind() == reflect.String {
+			return message{"must be at most %s characters", []interface{}{fe.Param()}}
+		}
+		return message{"must be at most %s", []interface{}{fe.Param()}}
+	case "email":
+		return message{format: "must be a valid email address"}
+	case "oneof":
+		return message{"must be one of: %s", []interface{}{strings.ReplaceAll(fe.Param(), " ", ", ")}}
+	}
+	return message{"failed the %q rule", []interface{}{fe.Tag()}}
+}
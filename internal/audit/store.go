@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/models"
+)
+
+// Filter narrows an audit log query. Zero values match everything.
+type Filter struct {
+	ActorID        uuid.UUID
+	ImpersonatorID uuid.UUID
+	TargetType     string
+	TargetID       uuid.UUID
+	From           time.Time
+	To             time.Time
+	Limit          int
+}
+
+// Store persists and queries audit log entries
+type Store interface {
+	Create(logs []models.AuditLog) error
+	Query(filter Filter) ([]models.AuditLog, error)
+}
+
+// GormStore keeps the audit log in the audit_logs table
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Create(logs []models.AuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	if err := s.db.Create(&logs).Error; err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Query returns matching entries, newest first
+func (s *GormStore) Query(filter Filter) ([]models.AuditLog, error) {
+	query := s.db.Model(&models.AuditLog{})
+	if filter.ActorID != uuid.Nil {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.ImpersonatorID != uuid.Nil {
+		query = query.Where("impersonator_id = ?", filter.ImpersonatorID)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != uuid.Nil {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("created_at DESC").Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	return logs, nil
+}
@@ -0,0 +1,114 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"seta-training/internal/models"
+	"seta-training/pkg/logger"
+)
+
+// maxBatchSize caps how many queued entries are written in one insert
+const maxBatchSize = 100
+
+// Writer is a Recorder that queues entries and writes them to a Store from
+// a background goroutine. When the queue is full, or after Shutdown, entries
+// are written synchronously instead of being dropped.
+type Writer struct {
+	store  Store
+	logger logger.Logger
+	queue  chan models.AuditLog
+
+	mu     sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+func NewWriter(store Store, bufferSize int, log logger.Logger) *Writer {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &Writer{
+		store:  store,
+		logger: log,
+		queue:  make(chan models.AuditLog, bufferSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// Start launches the background writer
+func (w *Writer) Start() {
+	go w.run()
+}
+
+// Record queues entry, timestamped now
+func (w *Writer) Record(ctx context.Context, entry Entry) {
+	record := entry.log(ctx, time.Now())
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.closed {
+		select {
+		case w.queue <- record:
+			return
+		default:
+			w.logger.Warn("Audit log queue full, writing synchronously")
+		}
+	}
+	w.write([]models.AuditLog{record})
+}
+
+// Shutdown stops accepting queued entries and waits for the queue to drain
+func (w *Writer) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+	for record := range w.queue {
+		batch := append(make([]models.AuditLog, 0, maxBatchSize), record)
+		batch = w.drain(batch)
+		w.write(batch)
+	}
+}
+
+// drain adds whatever is already queued to batch without waiting
+func (w *Writer) drain(batch []models.AuditLog) []models.AuditLog {
+	for len(batch) < maxBatchSize {
+		select {
+		case record, ok := <-w.queue:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, record)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+func (w *Writer) write(batch []models.AuditLog) {
+	if err := w.store.Create(batch); err != nil {
+		w.logger.Error("Failed to write audit log",
+			logger.Int("entries", len(batch)),
+			logger.Error(err),
+		)
+	}
+}
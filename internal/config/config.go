@@ -1,103 +1,682 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
-	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
+	"seta-training/pkg/crypto"
+	"seta-training/pkg/logger"
 )
 
+// ConfigFileEnv names the environment variable pointing at an optional YAML
+// or TOML config file
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Config is built from defaults, then the config file, then environment
+// variables. Each field's env tag names the variable that overrides it.
 type Config struct {
-	Database DatabaseConfig
-	JWT      JWTConfig
-	Server   ServerConfig
-	GraphQL  GraphQLConfig
-	Logging  LoggingConfig
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	JWT         JWTConfig         `yaml:"jwt" toml:"jwt"`
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	GraphQL     GraphQLConfig     `yaml:"graphql" toml:"graphql"`
+	Logging     LoggingConfig     `yaml:"logging" toml:"logging"`
+	NoteStorage NoteStorageConfig `yaml:"note_storage" toml:"note_storage"`
+	Webhook     WebhookConfig     `yaml:"webhook" toml:"webhook"`
+	Export      ExportConfig      `yaml:"export" toml:"export"`
+	Redis       RedisConfig       `yaml:"redis" toml:"redis"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	CORS        CORSConfig        `yaml:"cors" toml:"cors"`
+	BodyLimit   BodyLimitConfig   `yaml:"body_limit" toml:"body_limit"`
+
+	ResponseCompression ResponseCompressionConfig `yaml:"response_compression" toml:"response_compression"`
+	RequestTimeout      RequestTimeoutConfig      `yaml:"request_timeout" toml:"request_timeout"`
+	API                 APIConfig                 `yaml:"api" toml:"api"`
+	Idempotency         IdempotencyConfig         `yaml:"idempotency" toml:"idempotency"`
+	Audit               AuditConfig               `yaml:"audit" toml:"audit"`
+	Usage               UsageConfig               `yaml:"usage" toml:"usage"`
+	Outbox              OutboxConfig              `yaml:"outbox" toml:"outbox"`
+	Events              EventsConfig              `yaml:"events" toml:"events"`
+	Search              SearchConfig              `yaml:"search" toml:"search"`
+	WebSocket           WebSocketConfig           `yaml:"websocket" toml:"websocket"`
+	Jobs                JobsConfig                `yaml:"jobs" toml:"jobs"`
+	Retention           RetentionConfig           `yaml:"retention" toml:"retention"`
+	Quota               QuotaConfig               `yaml:"quota" toml:"quota"`
+	Admin               AdminConfig               `yaml:"admin" toml:"admin"`
+	Captcha             CaptchaConfig             `yaml:"captcha" toml:"captcha"`
+	SSO                 SSOConfig                 `yaml:"sso" toml:"sso"`
+	LDAP                LDAPConfig                `yaml:"ldap" toml:"ldap"`
+	Debug               DebugConfig               `yaml:"debug" toml:"debug"`
+	Maintenance         MaintenanceConfig         `yaml:"maintenance" toml:"maintenance"`
+	// Features toggles optional behaviour by name; see FeatureEnabled
+	Features map[string]bool `yaml:"features" toml:"features" env:"FEATURE_FLAGS"`
+}
+
+// IntrospectionEnabled reports whether GraphQL schema introspection is
+// served: as configured, or outside release mode when not configured
+func (c *Config) IntrospectionEnabled() bool {
+	if c.GraphQL.Introspection != nil {
+		return *c.GraphQL.Introspection
+	}
+	return c.Server.GinMode != "release"
+}
+
+// FeatureEnabled reports whether the named feature flag is switched on.
+// Unknown flags are off.
+func (c *Config) FeatureEnabled(name string) bool {
+	return c.Features[name]
 }
 
+// DatabaseDrivers are the supported values of DatabaseConfig.Driver
+var DatabaseDrivers = []string{"postgres", "mysql", "mariadb"}
+
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	Name     string
-	SSLMode  string
+	// Driver is postgres, mysql or mariadb
+	Driver   string `yaml:"driver" toml:"driver" env:"DB_DRIVER"`
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port     string `yaml:"port" toml:"port" env:"DB_PORT"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE"`
+
+	// Connection pool settings; zero lifetimes keep connections forever
+	MaxOpenConns           int `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns           int `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetimeMinutes int `yaml:"conn_max_lifetime_minutes" toml:"conn_max_lifetime_minutes" env:"DB_CONN_MAX_LIFETIME_MINUTES"`
+	ConnMaxIdleTimeMinutes int `yaml:"conn_max_idle_time_minutes" toml:"conn_max_idle_time_minutes" env:"DB_CONN_MAX_IDLE_TIME_MINUTES"`
+	// ReplicaDSNs are read replicas used for listing and search queries
+	ReplicaDSNs []string `yaml:"replica_dsns" toml:"replica_dsns" env:"DB_REPLICA_DSNS"`
+	// StatementTimeoutMillis aborts queries running longer than this on the
+	// server side; zero disables the limit
+	StatementTimeoutMillis int `yaml:"statement_timeout_ms" toml:"statement_timeout_ms" env:"DB_STATEMENT_TIMEOUT_MS"`
+	// HealthCheckIntervalSeconds is how often the pool is checked for
+	// dropped connections and a demoted primary; zero disables the check
+	HealthCheckIntervalSeconds int `yaml:"health_check_interval_seconds" toml:"health_check_interval_seconds" env:"DB_HEALTH_CHECK_INTERVAL_SECONDS"`
+	// The circuit breakers around reads and writes open after
+	// BreakerFailureThreshold consecutive connection errors or timeouts,
+	// reject statements for BreakerOpenSeconds, then close once
+	// BreakerHalfOpenProbes probe statements succeed. Zero disables them.
+	BreakerFailureThreshold int `yaml:"breaker_failure_threshold" toml:"breaker_failure_threshold" env:"DB_BREAKER_FAILURE_THRESHOLD"`
+	BreakerOpenSeconds      int `yaml:"breaker_open_seconds" toml:"breaker_open_seconds" env:"DB_BREAKER_OPEN_SECONDS"`
+	BreakerHalfOpenProbes   int `yaml:"breaker_half_open_probes" toml:"breaker_half_open_probes" env:"DB_BREAKER_HALF_OPEN_PROBES"`
 }
 
 type JWTConfig struct {
-	Secret      string
-	ExpiryHours int
+	// Algorithm is HS256 (shared Secret) or an asymmetric algorithm such as
+	// RS256 or EdDSA, which signs with the keys in KeysDir
+	Algorithm   string `yaml:"algorithm" toml:"algorithm" env:"JWT_ALGORITHM"`
+	Secret      string `yaml:"secret" toml:"secret" env:"JWT_SECRET"`
+	ExpiryHours int    `yaml:"expiry_hours" toml:"expiry_hours" env:"JWT_EXPIRY_HOURS"`
+	// KeysDir holds <kid>.pem signing keys; tokens are signed with
+	// ActiveKeyID and the public keys are served as a JWKS. Both can be
+	// changed at runtime to rotate keys.
+	KeysDir     string `yaml:"keys_dir" toml:"keys_dir" env:"JWT_KEYS_DIR"`
+	ActiveKeyID string `yaml:"active_key_id" toml:"active_key_id" env:"JWT_ACTIVE_KEY_ID"`
+}
+
+// Asymmetric reports whether tokens are signed with keys rather than the
+// shared secret
+func (c JWTConfig) Asymmetric() bool {
+	return c.Algorithm != "HS256"
 }
 
 type ServerConfig struct {
-	Port    string
-	GinMode string
+	Port    string `yaml:"port" toml:"port" env:"SERVER_PORT"`
+	GinMode string `yaml:"gin_mode" toml:"gin_mode" env:"GIN_MODE"`
+	// GRPCPort serves the gRPC API; empty disables it
+	GRPCPort string `yaml:"grpc_port" toml:"grpc_port" env:"GRPC_PORT"`
+	// ShutdownTimeoutSeconds is the grace period for draining in-flight
+	// requests and background work on SIGTERM/SIGINT
+	ShutdownTimeoutSeconds int `yaml:"shutdown_timeout_seconds" toml:"shutdown_timeout_seconds" env:"SHUTDOWN_TIMEOUT_SECONDS"`
+	// ShutdownDelaySeconds is how long /readyz reports not ready before
+	// draining starts, so load balancers stop sending new traffic
+	ShutdownDelaySeconds int       `yaml:"shutdown_delay_seconds" toml:"shutdown_delay_seconds" env:"SHUTDOWN_DELAY_SECONDS"`
+	TLS                  TLSConfig `yaml:"tls" toml:"tls"`
+}
+
+// TLSConfig enables HTTPS termination in the server itself. Either a
+// certificate/key pair or a list of autocert domains turns it on.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" toml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" toml:"key_file" env:"TLS_KEY_FILE"`
+	// AutocertDomains are served certificates obtained from Let's Encrypt
+	AutocertDomains  []string `yaml:"autocert_domains" toml:"autocert_domains" env:"TLS_AUTOCERT_DOMAINS"`
+	AutocertEmail    string   `yaml:"autocert_email" toml:"autocert_email" env:"TLS_AUTOCERT_EMAIL"`
+	AutocertCacheDir string   `yaml:"autocert_cache_dir" toml:"autocert_cache_dir" env:"TLS_AUTOCERT_CACHE_DIR"`
+	// RedirectPort is the plain HTTP port that redirects to HTTPS and answers
+	// ACME challenges; empty disables the listener
+	RedirectPort string `yaml:"redirect_port" toml:"redirect_port" env:"TLS_REDIRECT_PORT"`
+}
+
+// Enabled reports whether the server should terminate TLS
+func (c TLSConfig) Enabled() bool {
+	return (c.CertFile != "" && c.KeyFile != "") || len(c.AutocertDomains) > 0
 }
 
+// GraphQLConfig controls the GraphQL developer tools. Both are only served
+// to authenticated callers.
 type GraphQLConfig struct {
-	Playground bool
+	Playground bool `yaml:"playground" toml:"playground" env:"GRAPHQL_PLAYGROUND"`
+	// Introspection answers schema introspection queries. Unset, it is on
+	// except in release mode; see IntrospectionEnabled.
+	Introspection *bool `yaml:"introspection" toml:"introspection" env:"GRAPHQL_INTROSPECTION"`
 }
 
 type LoggingConfig struct {
-	Level  string
-	Format string
+	// Backend is logrus or zap, which allocates less under heavy logging
+	Backend string `yaml:"backend" toml:"backend" env:"LOG_BACKEND"`
+	Level   string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	Format  string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+	// Output is stdout, file or both; File is rotated past MaxSizeMB
+	Output     string `yaml:"output" toml:"output" env:"LOG_OUTPUT"`
+	File       string `yaml:"file" toml:"file" env:"LOG_FILE"`
+	MaxSizeMB  int    `yaml:"max_size_mb" toml:"max_size_mb" env:"LOG_MAX_SIZE_MB"`
+	MaxAgeDays int    `yaml:"max_age_days" toml:"max_age_days" env:"LOG_MAX_AGE_DAYS"`
+	MaxBackups int    `yaml:"max_backups" toml:"max_backups" env:"LOG_MAX_BACKUPS"`
+	// ComponentLevels overrides Level per component, e.g. import=warn
+	ComponentLevels map[string]string `yaml:"component_levels" toml:"component_levels" env:"LOG_COMPONENT_LEVELS"`
+	// SamplingInitial debug lines per message per second are logged, then
+	// every SamplingThereafter-th; 0 disables sampling
+	SamplingInitial    int `yaml:"sampling_initial" toml:"sampling_initial" env:"LOG_SAMPLING_INITIAL"`
+	SamplingThereafter int `yaml:"sampling_thereafter" toml:"sampling_thereafter" env:"LOG_SAMPLING_THEREAFTER"`
 }
 
-func Load() *Config {
-	// Load .env file if it exists
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using environment variables")
+// OutputConfig returns the logger output settings
+func (c LoggingConfig) OutputConfig() logger.OutputConfig {
+	if c.Output == "stdout" {
+		return logger.OutputConfig{Stdout: true}
+	}
+	return logger.OutputConfig{
+		Stdout:     c.Output == "both",
+		File:       c.File,
+		MaxSizeMB:  c.MaxSizeMB,
+		MaxAgeDays: c.MaxAgeDays,
+		MaxBackups: c.MaxBackups,
+	}
+}
+
+type NoteStorageConfig struct {
+	CompressionAlgorithm string `yaml:"compression_algorithm" toml:"compression_algorithm" env:"NOTE_COMPRESSION_ALGORITHM"`
+	CompressionThreshold int    `yaml:"compression_threshold" toml:"compression_threshold" env:"NOTE_COMPRESSION_THRESHOLD"`
+
+	Encryption NoteEncryptionConfig `yaml:"encryption" toml:"encryption"`
+}
+
+// NoteEncryptionConfig turns on envelope encryption of note bodies at rest.
+// Each body is sealed with its own data key, wrapped by a master key from
+// KeySource: local keys from this config, or a Vault transit key.
+type NoteEncryptionConfig struct {
+	// KeySource is local or vault; empty leaves new bodies unencrypted
+	KeySource string `yaml:"key_source" toml:"key_source" env:"NOTE_ENCRYPTION_KEY_SOURCE"`
+	// Keys maps master key IDs to base64-encoded 32-byte keys. Keys other
+	// than ActiveKey only decrypt the notes they encrypted before a rotation.
+	Keys      map[string]string `yaml:"keys" toml:"keys" env:"NOTE_ENCRYPTION_KEYS"`
+	ActiveKey string            `yaml:"active_key" toml:"active_key" env:"NOTE_ENCRYPTION_ACTIVE_KEY"`
+
+	VaultAddress string `yaml:"vault_address" toml:"vault_address" env:"NOTE_ENCRYPTION_VAULT_ADDR"`
+	VaultToken   string `yaml:"vault_token" toml:"vault_token" env:"NOTE_ENCRYPTION_VAULT_TOKEN"`
+	VaultMount   string `yaml:"vault_mount" toml:"vault_mount" env:"NOTE_ENCRYPTION_VAULT_MOUNT"`
+	VaultKey     string `yaml:"vault_key" toml:"vault_key" env:"NOTE_ENCRYPTION_VAULT_KEY"`
+}
+
+// vaultTimeout bounds each call to Vault
+const vaultTimeout = 10 * time.Second
+
+// Envelope returns the envelope note bodies are encrypted with, or nil when
+// no key source is configured
+func (c NoteEncryptionConfig) Envelope() (*crypto.Envelope, error) {
+	switch c.KeySource {
+	case "":
+		return nil, nil
+	case "local":
+		keys, err := crypto.NewLocalKeySource(c.Keys, c.ActiveKey)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.NewEnvelope(keys), nil
+	case "vault":
+		return crypto.NewEnvelope(crypto.NewVaultKeySource(c.VaultAddress, c.VaultToken, c.VaultMount, c.VaultKey, vaultTimeout)), nil
+	default:
+		return nil, fmt.Errorf("unsupported key source %q", c.KeySource)
+	}
+}
+
+type WebhookConfig struct {
+	TimeoutSeconds     int    `yaml:"timeout_seconds" toml:"timeout_seconds" env:"WEBHOOK_TIMEOUT_SECONDS"`
+	MaxRetries         int    `yaml:"max_retries" toml:"max_retries" env:"WEBHOOK_MAX_RETRIES"`
+	RetryBackoffMillis int    `yaml:"retry_backoff_ms" toml:"retry_backoff_ms" env:"WEBHOOK_RETRY_BACKOFF_MS"`
+	ImportURL          string `yaml:"import_url" toml:"import_url" env:"IMPORT_WEBHOOK_URL"`
+	ImportSecret       string `yaml:"import_secret" toml:"import_secret" env:"IMPORT_WEBHOOK_SECRET"`
+}
+
+type ExportConfig struct {
+	Workers   int `yaml:"workers" toml:"workers" env:"EXPORT_WORKERS"`
+	QueueSize int `yaml:"queue_size" toml:"queue_size" env:"EXPORT_QUEUE_SIZE"`
+}
+
+type RedisConfig struct {
+	// URL such as redis://localhost:6379/0; empty disables Redis-backed features
+	URL string `yaml:"url" toml:"url" env:"REDIS_URL"`
+}
+
+// RateLimitConfig holds token bucket rules written as "<requests>/<s|min|h>".
+// Login is the auth class; Read and Write apply to the REST API by method.
+// Managers and admins get their rules times the multipliers.
+type RateLimitConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Default string `yaml:"default" toml:"default" env:"RATE_LIMIT_DEFAULT"`
+	GraphQL string `yaml:"graphql" toml:"graphql" env:"RATE_LIMIT_GRAPHQL"`
+	Login   string `yaml:"login" toml:"login" env:"RATE_LIMIT_LOGIN"`
+	Import  string `yaml:"import" toml:"import" env:"RATE_LIMIT_IMPORT"`
+	Read    string `yaml:"read" toml:"read" env:"RATE_LIMIT_READ"`
+	Write   string `yaml:"write" toml:"write" env:"RATE_LIMIT_WRITE"`
+
+	ManagerMultiplier int `yaml:"manager_multiplier" toml:"manager_multiplier" env:"RATE_LIMIT_MANAGER_MULTIPLIER"`
+	AdminMultiplier   int `yaml:"admin_multiplier" toml:"admin_multiplier" env:"RATE_LIMIT_ADMIN_MULTIPLIER"`
+}
+
+// CORSConfig controls cross-origin access for browser clients. CORS is
+// disabled while AllowedOrigins is empty.
+type CORSConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string `yaml:"allowed_methods" toml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string `yaml:"allowed_headers" toml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string `yaml:"exposed_headers" toml:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAgeSeconds    int      `yaml:"max_age_seconds" toml:"max_age_seconds" env:"CORS_MAX_AGE_SECONDS"`
+}
+
+// BodyLimitConfig caps request body sizes in bytes; zero disables a limit
+type BodyLimitConfig struct {
+	JSONBytes      int `yaml:"json_bytes" toml:"json_bytes" env:"MAX_JSON_BODY_BYTES"`
+	MultipartBytes int `yaml:"multipart_bytes" toml:"multipart_bytes" env:"MAX_MULTIPART_BODY_BYTES"`
+	// ImportBytes applies to the CSV import upload
+	ImportBytes int `yaml:"import_bytes" toml:"import_bytes" env:"MAX_IMPORT_BODY_BYTES"`
+}
+
+// RequestTimeoutConfig bounds how long a request may run, in seconds; zero
+// leaves requests without a deadline
+type RequestTimeoutConfig struct {
+	DefaultSeconds int `yaml:"default_seconds" toml:"default_seconds" env:"REQUEST_TIMEOUT_SECONDS"`
+	// ImportSeconds applies to the CSV import upload
+	ImportSeconds int `yaml:"import_seconds" toml:"import_seconds" env:"IMPORT_REQUEST_TIMEOUT_SECONDS"`
+	// ExportSeconds applies to folder exports, their downloads and folder
+	// duplication
+	ExportSeconds int `yaml:"export_seconds" toml:"export_seconds" env:"EXPORT_REQUEST_TIMEOUT_SECONDS"`
+}
+
+// ResponseCompressionConfig controls gzip/deflate encoding of responses
+type ResponseCompressionConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"RESPONSE_COMPRESSION_ENABLED"`
+	// Level is the compression level from 1 (fastest) to 9 (smallest)
+	Level        int `yaml:"level" toml:"level" env:"RESPONSE_COMPRESSION_LEVEL"`
+	MinSizeBytes int `yaml:"min_size_bytes" toml:"min_size_bytes" env:"RESPONSE_COMPRESSION_MIN_BYTES"`
+	// ExcludedPaths are path prefixes whose responses are never compressed
+	ExcludedPaths []string `yaml:"excluded_paths" toml:"excluded_paths" env:"RESPONSE_COMPRESSION_EXCLUDED_PATHS"`
+}
+
+// MaintenanceConfig puts the API into maintenance, answering 503 to writes
+// (read_only) or to everything (full) outside the exempt path prefixes. Admins
+// can override the mode at runtime through /api/v1/admin/maintenance.
+type MaintenanceConfig struct {
+	Mode string `yaml:"mode" toml:"mode" env:"MAINTENANCE_MODE"`
+	// RetryAfterSeconds is sent as Retry-After with the 503s
+	RetryAfterSeconds int      `yaml:"retry_after_seconds" toml:"retry_after_seconds" env:"MAINTENANCE_RETRY_AFTER_SECONDS"`
+	ExemptPaths       []string `yaml:"exempt_paths" toml:"exempt_paths" env:"MAINTENANCE_EXEMPT_PATHS"`
+}
+
+// IdempotencyConfig controls how long Idempotency-Key responses are kept
+type IdempotencyConfig struct {
+	TTLHours int `yaml:"ttl_hours" toml:"ttl_hours" env:"IDEMPOTENCY_TTL_HOURS"`
+}
+
+// UsageConfig controls the per-user API usage rollups behind /admin/usage
+type UsageConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"USAGE_TRACKING_ENABLED"`
+	// FlushIntervalSeconds is how often counts are added to the database
+	FlushIntervalSeconds int `yaml:"flush_interval_seconds" toml:"flush_interval_seconds" env:"USAGE_FLUSH_INTERVAL_SECONDS"`
+}
+
+// AuditConfig controls the background audit log writer
+type AuditConfig struct {
+	// BufferSize is how many entries may wait to be written before requests
+	// start writing them synchronously
+	BufferSize int `yaml:"buffer_size" toml:"buffer_size" env:"AUDIT_BUFFER_SIZE"`
+}
+
+// OutboxConfig controls the relay that publishes domain events
+type OutboxConfig struct {
+	PollIntervalMillis int `yaml:"poll_interval_ms" toml:"poll_interval_ms" env:"OUTBOX_POLL_INTERVAL_MS"`
+	BatchSize          int `yaml:"batch_size" toml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+	// RetentionHours is how long published events are kept before deletion
+	RetentionHours int `yaml:"retention_hours" toml:"retention_hours" env:"OUTBOX_RETENTION_HOURS"`
+}
+
+// EventsConfig selects the message bus domain events are published on
+type EventsConfig struct {
+	// Driver is "memory" (in-process only) or "nats"
+	Driver  string `yaml:"driver" toml:"driver" env:"EVENTS_DRIVER"`
+	NATSURL string `yaml:"nats_url" toml:"nats_url" env:"EVENTS_NATS_URL"`
+	// SubjectPrefix namespaces subjects, e.g. "seta" publishes user.created
+	// on "seta.user.created"
+	SubjectPrefix string `yaml:"subject_prefix" toml:"subject_prefix" env:"EVENTS_SUBJECT_PREFIX"`
+	// QueueGroup is shared by all instances so each event is handled once
+	QueueGroup string `yaml:"queue_group" toml:"queue_group" env:"EVENTS_QUEUE_GROUP"`
+}
+
+// SearchConfig selects the backend of the search endpoint. Without an
+// Elasticsearch URL searches run against the database.
+type SearchConfig struct {
+	// ElasticsearchURL is an Elasticsearch or OpenSearch cluster, e.g.
+	// http://localhost:9200
+	ElasticsearchURL string `yaml:"elasticsearch_url" toml:"elasticsearch_url" env:"SEARCH_ELASTICSEARCH_URL"`
+	Index            string `yaml:"index" toml:"index" env:"SEARCH_INDEX"`
+	Username         string `yaml:"username" toml:"username" env:"SEARCH_USERNAME"`
+	Password         string `yaml:"password" toml:"password" env:"SEARCH_PASSWORD"`
+	TimeoutSeconds   int    `yaml:"timeout_seconds" toml:"timeout_seconds" env:"SEARCH_TIMEOUT_SECONDS"`
+}
+
+// WebSocketConfig controls the connections of the notification channel.
+// Connections not answering a ping within two intervals are closed.
+type WebSocketConfig struct {
+	PingIntervalSeconds   int `yaml:"ping_interval_seconds" toml:"ping_interval_seconds" env:"WS_PING_INTERVAL_SECONDS"`
+	MaxConnectionsPerUser int `yaml:"max_connections_per_user" toml:"max_connections_per_user" env:"WS_MAX_CONNECTIONS_PER_USER"`
+}
+
+// JobsConfig controls the background job scheduler. Schedules are cron
+// expressions (evaluated in UTC), @hourly/@daily/@weekly/@monthly or
+// "@every <duration>".
+type JobsConfig struct {
+	Enabled                  bool   `yaml:"enabled" toml:"enabled" env:"JOBS_ENABLED"`
+	IdempotencyPurgeSchedule string `yaml:"idempotency_purge_schedule" toml:"idempotency_purge_schedule" env:"JOBS_IDEMPOTENCY_PURGE_SCHEDULE"`
+	OutboxPurgeSchedule      string `yaml:"outbox_purge_schedule" toml:"outbox_purge_schedule" env:"JOBS_OUTBOX_PURGE_SCHEDULE"`
+	SoftDeletePurgeSchedule  string `yaml:"soft_delete_purge_schedule" toml:"soft_delete_purge_schedule" env:"JOBS_SOFT_DELETE_PURGE_SCHEDULE"`
+	// ReminderSchedule is how often due note reminders are sent
+	ReminderSchedule string `yaml:"reminder_schedule" toml:"reminder_schedule" env:"JOBS_REMINDER_SCHEDULE"`
+	// LDAPSyncSchedule is how often organizations are synced with their
+	// LDAP directory
+	LDAPSyncSchedule string `yaml:"ldap_sync_schedule" toml:"ldap_sync_schedule" env:"JOBS_LDAP_SYNC_SCHEDULE"`
+}
+
+// RetentionConfig controls how long soft-deleted records are kept
+type RetentionConfig struct {
+	// SoftDeleteDays is how long deleted users, teams, folders and notes can
+	// be restored before they are permanently deleted
+	SoftDeleteDays int `yaml:"soft_delete_days" toml:"soft_delete_days" env:"SOFT_DELETE_RETENTION_DAYS"`
+	BatchSize      int `yaml:"batch_size" toml:"batch_size" env:"SOFT_DELETE_PURGE_BATCH_SIZE"`
+}
+
+// QuotaConfig caps the notes and folders a user owns, and those the managers
+// and members of a team own together; zero leaves a limit off
+type QuotaConfig struct {
+	MaxNotesPerUser   int `yaml:"max_notes_per_user" toml:"max_notes_per_user" env:"QUOTA_MAX_NOTES_PER_USER"`
+	MaxFoldersPerUser int `yaml:"max_folders_per_user" toml:"max_folders_per_user" env:"QUOTA_MAX_FOLDERS_PER_USER"`
+	MaxNotesPerTeam   int `yaml:"max_notes_per_team" toml:"max_notes_per_team" env:"QUOTA_MAX_NOTES_PER_TEAM"`
+	MaxFoldersPerTeam int `yaml:"max_folders_per_team" toml:"max_folders_per_team" env:"QUOTA_MAX_FOLDERS_PER_TEAM"`
+}
+
+// AdminConfig names the users granted the admin scope
+type AdminConfig struct {
+	// Users are usernames or emails; they get the admin scope in their tokens
+	Users []string `yaml:"users" toml:"users" env:"ADMIN_USERS"`
+	// ImpersonationMinutes caps how long an impersonation token is valid
+	ImpersonationMinutes int `yaml:"impersonation_minutes" toml:"impersonation_minutes" env:"ADMIN_IMPERSONATION_MINUTES"`
+}
+
+// CaptchaConfig asks clients with many recent failed logins to solve a
+// CAPTCHA before logging in or signing up. An empty provider disables it.
+type CaptchaConfig struct {
+	// Provider is recaptcha, hcaptcha or turnstile
+	Provider string `yaml:"provider" toml:"provider" env:"CAPTCHA_PROVIDER"`
+	Secret   string `yaml:"secret" toml:"secret" env:"CAPTCHA_SECRET"`
+	// FailureThreshold is how many failed logins from an IP within
+	// WindowMinutes require a CAPTCHA; 0 always requires one
+	FailureThreshold int `yaml:"failure_threshold" toml:"failure_threshold" env:"CAPTCHA_FAILURE_THRESHOLD"`
+	WindowMinutes    int `yaml:"window_minutes" toml:"window_minutes" env:"CAPTCHA_WINDOW_MINUTES"`
+	TimeoutSeconds   int `yaml:"timeout_seconds" toml:"timeout_seconds" env:"CAPTCHA_TIMEOUT_SECONDS"`
+}
+
+// SSOConfig sets up the SAML service providers organizations sign in
+// through. An empty BaseURL disables single sign-on.
+type SSOConfig struct {
+	// BaseURL is the public URL of this server; each organization's service
+	// provider is at <BaseURL>/sso/saml/<slug>
+	BaseURL string `yaml:"base_url" toml:"base_url" env:"SSO_BASE_URL"`
+	// CertFile and KeyFile are an optional PEM certificate and key for
+	// signing authentication requests and decrypting assertions
+	CertFile string `yaml:"cert_file" toml:"cert_file" env:"SSO_SP_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" toml:"key_file" env:"SSO_SP_KEY_FILE"`
+}
+
+// LDAPConfig tunes the LDAP sync of organizations, whose directories are
+// configured through the admin API
+type LDAPConfig struct {
+	// TimeoutSeconds bounds connecting to a directory and each request to it
+	TimeoutSeconds int `yaml:"timeout_seconds" toml:"timeout_seconds" env:"LDAP_TIMEOUT_SECONDS"`
+}
+
+// DebugConfig controls the pprof and runtime stats endpoints under /debug
+type DebugConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"DEBUG_ENDPOINTS_ENABLED"`
+}
+
+// APIConfig holds REST API versioning settings. Dates are YYYY-MM-DD.
+type APIConfig struct {
+	// V1DeprecatedSince turns on Deprecation headers for v1 routes that have
+	// a v2 successor; empty disables them
+	V1DeprecatedSince string `yaml:"v1_deprecated_since" toml:"v1_deprecated_since" env:"API_V1_DEPRECATED_SINCE"`
+	// V1Sunset is announced in the Sunset header as the date v1 goes away
+	V1Sunset string `yaml:"v1_sunset" toml:"v1_sunset" env:"API_V1_SUNSET"`
+}
+
+// V1Deprecation returns the parsed v1 deprecation and sunset dates. ok is
+// false when v1 is not deprecated; sunset is zero when not announced.
+func (c APIConfig) V1Deprecation() (since, sunset time.Time, ok bool) {
+	if c.V1DeprecatedSince == "" {
+		return time.Time{}, time.Time{}, false
+	}
+	since, err := time.Parse(time.DateOnly, c.V1DeprecatedSince)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
 	}
+	if c.V1Sunset != "" {
+		sunset, _ = time.Parse(time.DateOnly, c.V1Sunset)
+	}
+	return since, sunset, true
+}
 
+// Default returns the configuration used when nothing overrides it
+func Default() *Config {
 	return &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "password"),
-			Name:     getEnv("DB_NAME", "seta_training"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Driver:   "postgres",
+			Host:     "localhost",
+			Port:     "5432",
+			User:     "postgres",
+			Password: "password",
+			Name:     "seta_training",
+			SSLMode:  "disable",
+
+			MaxOpenConns:           100,
+			MaxIdleConns:           10,
+			ConnMaxLifetimeMinutes: 30,
+			ConnMaxIdleTimeMinutes: 5,
+			StatementTimeoutMillis: 30000,
+
+			HealthCheckIntervalSeconds: 10,
+
+			BreakerFailureThreshold: 5,
+			BreakerOpenSeconds:      10,
+			BreakerHalfOpenProbes:   3,
 		},
 		JWT: JWTConfig{
-			Secret:      getEnv("JWT_SECRET", "default-secret-change-this"),
-			ExpiryHours: getEnvAsInt("JWT_EXPIRY_HOURS", 24),
+			Algorithm:   "HS256",
+			ExpiryHours: 24,
 		},
 		Server: ServerConfig{
-			Port:    getEnv("SERVER_PORT", "8080"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:                   "8080",
+			GinMode:                "debug",
+			ShutdownTimeoutSeconds: 15,
+			ShutdownDelaySeconds:   5,
+			TLS: TLSConfig{
+				AutocertCacheDir: "certs",
+			},
 		},
 		GraphQL: GraphQLConfig{
-			Playground: getEnvAsBool("GRAPHQL_PLAYGROUND", true),
+			Playground: true,
 		},
 		Logging: LoggingConfig{
-			Level:  getEnv("LOG_LEVEL", "info"),
-			Format: getEnv("LOG_FORMAT", "json"),
+			Backend:            "logrus",
+			Level:              "info",
+			Format:             "json",
+			Output:             "stdout",
+			File:               "logs/server.log",
+			MaxSizeMB:          100,
+			MaxAgeDays:         7,
+			MaxBackups:         5,
+			SamplingInitial:    100,
+			SamplingThereafter: 100,
+		},
+		NoteStorage: NoteStorageConfig{
+			CompressionAlgorithm: "gzip",
+			CompressionThreshold: 4096,
+			Encryption: NoteEncryptionConfig{
+				VaultMount: "transit",
+			},
+		},
+		Webhook: WebhookConfig{
+			TimeoutSeconds:     10,
+			MaxRetries:         3,
+			RetryBackoffMillis: 500,
+		},
+		Export: ExportConfig{
+			Workers:   2,
+			QueueSize: 100,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Default: "300/min",
+			GraphQL: "120/min",
+			Login:   "10/min",
+			Import:  "5/min",
+			Read:    "240/min",
+			Write:   "60/min",
+
+			ManagerMultiplier: 2,
+			AdminMultiplier:   5,
+		},
+		CORS: CORSConfig{
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Origin", "Content-Type", "Accept", "Authorization", "Idempotency-Key", "X-Captcha-Token"},
+			ExposedHeaders:   []string{"Location", "Content-Disposition", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-Request-ID", "Idempotent-Replayed", "X-Next-Cursor"},
+			AllowCredentials: false,
+			MaxAgeSeconds:    600,
+		},
+		BodyLimit: BodyLimitConfig{
+			JSONBytes:      1 << 20,
+			MultipartBytes: 10 << 20,
+			ImportBytes:    6 << 20,
+		},
+		RequestTimeout: RequestTimeoutConfig{
+			DefaultSeconds: 15,
+			ImportSeconds:  300,
+			ExportSeconds:  120,
+		},
+		ResponseCompression: ResponseCompressionConfig{
+			Enabled:       true,
+			Level:         5,
+			MinSizeBytes:  1024,
+			ExcludedPaths: []string{"/api/v1/exports/", "/metrics"},
+		},
+		API: APIConfig{
+			V1DeprecatedSince: "2026-10-16",
+		},
+		Idempotency: IdempotencyConfig{
+			TTLHours: 24,
+		},
+		Maintenance: MaintenanceConfig{
+			Mode:              "off",
+			RetryAfterSeconds: 300,
+			ExemptPaths:       []string{"/healthz", "/readyz", "/health", "/metrics", "/api/v1/admin/", "/api/v1/announcements"},
+		},
+		Audit: AuditConfig{
+			BufferSize: 1024,
+		},
+		Usage: UsageConfig{
+			Enabled:              true,
+			FlushIntervalSeconds: 60,
+		},
+		Outbox: OutboxConfig{
+			PollIntervalMillis: 1000,
+			BatchSize:          100,
+			RetentionHours:     168,
+		},
+		Events: EventsConfig{
+			Driver:        "memory",
+			NATSURL:       "nats://localhost:4222",
+			SubjectPrefix: "seta",
+			QueueGroup:    "seta-training",
+		},
+		Search: SearchConfig{
+			Index:          "seta-search",
+			TimeoutSeconds: 5,
+		},
+		WebSocket: WebSocketConfig{
+			PingIntervalSeconds:   30,
+			MaxConnectionsPerUser: 5,
+		},
+		Jobs: JobsConfig{
+			Enabled:                  true,
+			IdempotencyPurgeSchedule: "@hourly",
+			OutboxPurgeSchedule:      "@hourly",
+			SoftDeletePurgeSchedule:  "0 3 * * *",
+			ReminderSchedule:         "@every 1m",
+			LDAPSyncSchedule:         "@hourly",
+		},
+		Retention: RetentionConfig{
+			SoftDeleteDays: 30,
+			BatchSize:      500,
+		},
+		Admin: AdminConfig{
+			ImpersonationMinutes: 30,
+		},
+		Captcha: CaptchaConfig{
+			FailureThreshold: 5,
+			WindowMinutes:    15,
+			TimeoutSeconds:   5,
+		},
+		LDAP: LDAPConfig{
+			TimeoutSeconds: 10,
 		},
 	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Load builds the configuration from defaults, the file named by CONFIG_FILE
+// (if any) and environment variables, in increasing order of precedence, and
+// validates the result
+func Load() (*Config, error) {
+	// Load .env file if it exists
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
 	}
-	return defaultValue
-}
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
+	cfg := Default()
+
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		if err := loadFile(path, cfg); err != nil {
+			return nil, err
 		}
 	}
-	return defaultValue
-}
 
-func getEnvAsBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
-		if boolValue, err := strconv.ParseBool(value); err == nil {
-			return boolValue
-		}
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
-	return defaultValue
+	return cfg, nil
 }
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"seta-training/pkg/logger"
+)
+
+// reloadDebounce coalesces the burst of events editors produce on save
+const reloadDebounce = 500 * time.Millisecond
+
+// Reloader holds the live configuration and re-reads it on SIGHUP or when the
+// config file changes. Only the runtime-safe subset is applied: the log level,
+// rate limits, feature flags and JWT signing keys. Everything else keeps its
+// startup value until the process restarts.
+type Reloader struct {
+	current atomic.Pointer[Config]
+	logger  logger.Logger
+
+	mu        sync.Mutex
+	listeners []func(*Config)
+}
+
+func NewReloader(initial *Config, log logger.Logger) *Reloader {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	r := &Reloader{logger: log}
+	r.current.Store(initial)
+	return r
+}
+
+// Current returns the configuration in effect. Callers must not modify it.
+func (r *Reloader) Current() *Config {
+	return r.current.Load()
+}
+
+// FeatureEnabled reports whether the named flag is on in the current config
+func (r *Reloader) FeatureEnabled(name string) bool {
+	return r.Current().FeatureEnabled(name)
+}
+
+// OnReload registers fn to be called with the new configuration after every
+// successful reload
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
+
+// Reload loads and validates the configuration again. On error the current
+// configuration is kept.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := Load()
+	if err != nil {
+		r.logger.Error("Configuration reload failed, keeping current settings", logger.Error(err))
+		return err
+	}
+
+	prev := r.Current()
+	updated := *prev
+	updated.Logging.Level = next.Logging.Level
+	updated.Logging.ComponentLevels = next.Logging.ComponentLevels
+	updated.RateLimit = next.RateLimit
+	updated.Features = next.Features
+	updated.Maintenance = next.Maintenance
+	updated.JWT.Algorithm = next.JWT.Algorithm
+	updated.JWT.KeysDir = next.JWT.KeysDir
+	updated.JWT.ActiveKeyID = next.JWT.ActiveKeyID
+
+	if ignored := restartRequired(&updated, next); len(ignored) > 0 {
+		r.logger.Warn("Configuration changes require a restart and were not applied",
+			logger.Any("sections", ignored))
+	}
+
+	r.current.Store(&updated)
+	for _, fn := range r.listeners {
+		fn(&updated)
+	}
+
+	r.logger.Info("Configuration reloaded",
+		logger.String("log_level", updated.Logging.Level),
+		logger.Any("rate_limit_enabled", updated.RateLimit.Enabled),
+		logger.Any("features", updated.Features),
+		logger.String("maintenance_mode", updated.Maintenance.Mode),
+	)
+	return nil
+}
+
+// restartRequired lists the top-level sections where next differs from the
+// applied configuration
+func restartRequired(applied, next *Config) []string {
+	var sections []string
+	a := reflect.ValueOf(applied).Elem()
+	n := reflect.ValueOf(next).Elem()
+	for i := 0; i < a.NumField(); i++ {
+		if !reflect.DeepEqual(a.Field(i).Interface(), n.Field(i).Interface()) {
+			sections = append(sections, a.Type().Field(i).Name)
+		}
+	}
+	return sections
+}
+
+// Watch reloads on SIGHUP and, when CONFIG_FILE is set, whenever that file is
+// written or replaced. It blocks until ctx is cancelled.
+func (r *Reloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var fileEvents <-chan fsnotify.Event
+	var fileErrors <-chan error
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		watcher, err := r.watchFile(path)
+		if err != nil {
+			r.logger.Error("Failed to watch config file, reload with SIGHUP instead",
+				logger.String("path", path), logger.Error(err))
+		} else {
+			defer watcher.Close()
+			fileEvents, fileErrors = filterEvents(ctx, watcher, path), watcher.Errors
+		}
+	}
+
+	debounce := time.NewTimer(reloadDebounce)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.logger.Info("Received SIGHUP, reloading configuration")
+			_ = r.Reload()
+		case _, ok := <-fileEvents:
+			if !ok {
+				fileEvents = nil
+				continue
+			}
+			debounce.Reset(reloadDebounce)
+		case <-debounce.C:
+			r.logger.Info("Config file changed, reloading configuration")
+			_ = r.Reload()
+		case err := <-fileErrors:
+			r.logger.Warn("Config file watcher error", logger.Error(err))
+		}
+	}
+}
+
+// watchFile watches the directory holding path, since editors and config
+// management tools often replace the file rather than write it in place
+func (r *Reloader) watchFile(path string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
+// filterEvents forwards only the events that change the file at path
+func filterEvents(ctx context.Context, watcher *fsnotify.Watcher, path string) <-chan fsnotify.Event {
+	target := filepath.Clean(path)
+	out := make(chan fsnotify.Event)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != target || event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				select {
+				case out <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
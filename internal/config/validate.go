@@ -0,0 +1,246 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"seta-training/internal/jobs"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/captcha"
+	"seta-training/pkg/compression"
+	"seta-training/pkg/sso"
+)
+
+// insecureJWTSecrets are placeholder values shipped in examples
+var insecureJWTSecrets = []string{
+	"default-secret-change-this",
+	"your-super-secret-jwt-key-change-this-in-production",
+}
+
+var rateLimitRulePattern = regexp.MustCompile(`^[1-9][0-9]*/(s|sec|second|m|min|minute|h|hour)$`)
+
+// Validate reports every invalid or missing setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(slices.Contains(DatabaseDrivers, c.Database.Driver),
+		"database.driver (DB_DRIVER) must be one of %s, got %q", strings.Join(DatabaseDrivers, ", "), c.Database.Driver)
+	check(c.Database.Host != "", "database.host (DB_HOST) is required")
+	check(validPort(c.Database.Port), "database.port (DB_PORT) must be a port number, got %q", c.Database.Port)
+	check(c.Database.User != "", "database.user (DB_USER) is required")
+	check(c.Database.Name != "", "database.name (DB_NAME) is required")
+	check(c.Database.MaxOpenConns > 0, "database.max_open_conns (DB_MAX_OPEN_CONNS) must be positive")
+	check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database.max_idle_conns (DB_MAX_IDLE_CONNS) must be between 0 and max_open_conns")
+	check(c.Database.ConnMaxLifetimeMinutes >= 0, "database.conn_max_lifetime_minutes (DB_CONN_MAX_LIFETIME_MINUTES) must not be negative")
+	check(c.Database.ConnMaxIdleTimeMinutes >= 0, "database.conn_max_idle_time_minutes (DB_CONN_MAX_IDLE_TIME_MINUTES) must not be negative")
+	check(c.Database.StatementTimeoutMillis >= 0, "database.statement_timeout_ms (DB_STATEMENT_TIMEOUT_MS) must not be negative")
+	check(c.Database.HealthCheckIntervalSeconds >= 0, "database.health_check_interval_seconds (DB_HEALTH_CHECK_INTERVAL_SECONDS) must not be negative")
+	check(c.Database.BreakerFailureThreshold >= 0, "database.breaker_failure_threshold (DB_BREAKER_FAILURE_THRESHOLD) must not be negative")
+	if c.Database.BreakerFailureThreshold > 0 {
+		check(c.Database.BreakerOpenSeconds > 0, "database.breaker_open_seconds (DB_BREAKER_OPEN_SECONDS) must be positive")
+		check(c.Database.BreakerHalfOpenProbes > 0, "database.breaker_half_open_probes (DB_BREAKER_HALF_OPEN_PROBES) must be positive")
+	}
+
+	check(slices.Contains(auth.Algorithms, c.JWT.Algorithm),
+		"jwt.algorithm (JWT_ALGORITHM) must be one of %s, got %q", strings.Join(auth.Algorithms, ", "), c.JWT.Algorithm)
+	check(c.JWT.Asymmetric() || c.JWT.Secret != "", "jwt.secret (JWT_SECRET) is required with HS256")
+	check(c.Server.GinMode != "release" || c.JWT.Secret == "" || !slices.Contains(insecureJWTSecrets, c.JWT.Secret),
+		"jwt.secret (JWT_SECRET) must be changed from the example value in release mode")
+	check(c.JWT.ExpiryHours > 0, "jwt.expiry_hours (JWT_EXPIRY_HOURS) must be positive")
+	switch {
+	case !c.JWT.Asymmetric():
+		check(c.JWT.KeysDir == "", "jwt.keys_dir (JWT_KEYS_DIR) requires an asymmetric jwt.algorithm (JWT_ALGORITHM)")
+	case c.JWT.KeysDir == "":
+		check(false, "jwt.keys_dir (JWT_KEYS_DIR) is required with %s", c.JWT.Algorithm)
+	case slices.Contains(auth.Algorithms, c.JWT.Algorithm):
+		if _, err := auth.LoadKeySet(c.JWT.KeysDir, c.JWT.ActiveKeyID, c.JWT.Algorithm); err != nil {
+			check(false, "jwt.keys_dir (JWT_KEYS_DIR): %v", err)
+		}
+	}
+
+	check(validPort(c.Server.Port), "server.port (SERVER_PORT) must be a port number, got %q", c.Server.Port)
+	check(c.Server.GRPCPort == "" || validPort(c.Server.GRPCPort),
+		"server.grpc_port (GRPC_PORT) must be a port number, got %q", c.Server.GRPCPort)
+	check(c.Server.GRPCPort != c.Server.Port, "server.grpc_port (GRPC_PORT) must differ from server.port (SERVER_PORT)")
+	check(slices.Contains([]string{"debug", "release", "test"}, c.Server.GinMode),
+		"server.gin_mode (GIN_MODE) must be debug, release or test, got %q", c.Server.GinMode)
+	check(c.Server.ShutdownTimeoutSeconds > 0, "server.shutdown_timeout_seconds (SHUTDOWN_TIMEOUT_SECONDS) must be positive")
+	check(c.Server.ShutdownDelaySeconds >= 0, "server.shutdown_delay_seconds (SHUTDOWN_DELAY_SECONDS) must not be negative")
+	check((c.Server.TLS.CertFile == "") == (c.Server.TLS.KeyFile == ""),
+		"server.tls.cert_file and server.tls.key_file (TLS_CERT_FILE, TLS_KEY_FILE) must be set together")
+	check(c.Server.TLS.RedirectPort == "" || validPort(c.Server.TLS.RedirectPort),
+		"server.tls.redirect_port (TLS_REDIRECT_PORT) must be a port number, got %q", c.Server.TLS.RedirectPort)
+
+	check(slices.Contains([]string{"logrus", "zap"}, c.Logging.Backend),
+		"logging.backend (LOG_BACKEND) must be logrus or zap, got %q", c.Logging.Backend)
+	check(slices.Contains([]string{"debug", "info", "warn", "error"}, c.Logging.Level),
+		"logging.level (LOG_LEVEL) must be debug, info, warn or error, got %q", c.Logging.Level)
+	check(slices.Contains([]string{"json", "text"}, c.Logging.Format),
+		"logging.format (LOG_FORMAT) must be json or text, got %q", c.Logging.Format)
+	for component, level := range c.Logging.ComponentLevels {
+		check(slices.Contains([]string{"debug", "info", "warn", "error"}, level),
+			"logging.component_levels (LOG_COMPONENT_LEVELS) %s must be debug, info, warn or error, got %q", component, level)
+	}
+	check(c.Logging.SamplingInitial >= 0, "logging.sampling_initial (LOG_SAMPLING_INITIAL) must not be negative")
+	check(c.Logging.SamplingThereafter >= 0, "logging.sampling_thereafter (LOG_SAMPLING_THEREAFTER) must not be negative")
+	check(slices.Contains([]string{"stdout", "file", "both"}, c.Logging.Output),
+		"logging.output (LOG_OUTPUT) must be stdout, file or both, got %q", c.Logging.Output)
+	if c.Logging.Output != "stdout" {
+		check(c.Logging.File != "", "logging.file (LOG_FILE) is required when logging to a file")
+		check(c.Logging.MaxSizeMB > 0, "logging.max_size_mb (LOG_MAX_SIZE_MB) must be positive")
+		check(c.Logging.MaxAgeDays >= 0, "logging.max_age_days (LOG_MAX_AGE_DAYS) must not be negative")
+		check(c.Logging.MaxBackups >= 0, "logging.max_backups (LOG_MAX_BACKUPS) must not be negative")
+	}
+
+	if _, err := compression.ParseAlgorithm(c.NoteStorage.CompressionAlgorithm); err != nil {
+		errs = append(errs, fmt.Errorf("note_storage.compression_algorithm (NOTE_COMPRESSION_ALGORITHM): %w", err))
+	}
+	check(c.NoteStorage.CompressionThreshold >= 0, "note_storage.compression_threshold (NOTE_COMPRESSION_THRESHOLD) must not be negative")
+	switch enc := c.NoteStorage.Encryption; enc.KeySource {
+	case "":
+	case "local":
+		if _, err := enc.Envelope(); err != nil {
+			errs = append(errs, fmt.Errorf("note_storage.encryption.keys (NOTE_ENCRYPTION_KEYS): %w", err))
+		}
+	case "vault":
+		check(enc.VaultAddress != "" && enc.VaultToken != "" && enc.VaultKey != "",
+			"note_storage.encryption.vault_address, vault_token and vault_key (NOTE_ENCRYPTION_VAULT_ADDR, NOTE_ENCRYPTION_VAULT_TOKEN, NOTE_ENCRYPTION_VAULT_KEY) are required with the vault key source")
+	default:
+		check(false, "note_storage.encryption.key_source (NOTE_ENCRYPTION_KEY_SOURCE) must be local or vault, got %q", enc.KeySource)
+	}
+
+	check(c.Webhook.TimeoutSeconds > 0, "webhook.timeout_seconds (WEBHOOK_TIMEOUT_SECONDS) must be positive")
+	check(c.Webhook.MaxRetries >= 0, "webhook.max_retries (WEBHOOK_MAX_RETRIES) must not be negative")
+
+	check(c.Export.Workers > 0, "export.workers (EXPORT_WORKERS) must be positive")
+	check(c.Export.QueueSize > 0, "export.queue_size (EXPORT_QUEUE_SIZE) must be positive")
+
+	if c.RateLimit.Enabled {
+		for name, rule := range map[string]string{
+			"rate_limit.default (RATE_LIMIT_DEFAULT)": c.RateLimit.Default,
+			"rate_limit.graphql (RATE_LIMIT_GRAPHQL)": c.RateLimit.GraphQL,
+			"rate_limit.login (RATE_LIMIT_LOGIN)":     c.RateLimit.Login,
+			"rate_limit.import (RATE_LIMIT_IMPORT)":   c.RateLimit.Import,
+			"rate_limit.read (RATE_LIMIT_READ)":       c.RateLimit.Read,
+			"rate_limit.write (RATE_LIMIT_WRITE)":     c.RateLimit.Write,
+		} {
+			check(rateLimitRulePattern.MatchString(rule), "%s must look like 100/min, got %q", name, rule)
+		}
+		check(c.RateLimit.ManagerMultiplier >= 1, "rate_limit.manager_multiplier (RATE_LIMIT_MANAGER_MULTIPLIER) must be at least 1")
+		check(c.RateLimit.AdminMultiplier >= 1, "rate_limit.admin_multiplier (RATE_LIMIT_ADMIN_MULTIPLIER) must be at least 1")
+	}
+
+	check(!c.CORS.AllowCredentials || !slices.Contains(c.CORS.AllowedOrigins, "*"),
+		"cors.allow_credentials (CORS_ALLOW_CREDENTIALS) cannot be combined with a wildcard origin")
+
+	check(c.BodyLimit.JSONBytes >= 0, "body_limit.json_bytes (MAX_JSON_BODY_BYTES) must not be negative")
+	check(c.BodyLimit.MultipartBytes >= 0, "body_limit.multipart_bytes (MAX_MULTIPART_BODY_BYTES) must not be negative")
+	check(c.BodyLimit.ImportBytes >= 0, "body_limit.import_bytes (MAX_IMPORT_BODY_BYTES) must not be negative")
+
+	check(c.RequestTimeout.DefaultSeconds >= 0, "request_timeout.default_seconds (REQUEST_TIMEOUT_SECONDS) must not be negative")
+	check(c.RequestTimeout.ImportSeconds >= 0, "request_timeout.import_seconds (IMPORT_REQUEST_TIMEOUT_SECONDS) must not be negative")
+	check(c.RequestTimeout.ExportSeconds >= 0, "request_timeout.export_seconds (EXPORT_REQUEST_TIMEOUT_SECONDS) must not be negative")
+
+	check(c.Idempotency.TTLHours >= 1, "idempotency.ttl_hours (IDEMPOTENCY_TTL_HOURS) must be at least 1, got %d", c.Idempotency.TTLHours)
+	check(c.Audit.BufferSize > 0, "audit.buffer_size (AUDIT_BUFFER_SIZE) must be positive")
+	check(c.Usage.FlushIntervalSeconds > 0, "usage.flush_interval_seconds (USAGE_FLUSH_INTERVAL_SECONDS) must be positive")
+	check(c.Outbox.PollIntervalMillis > 0, "outbox.poll_interval_ms (OUTBOX_POLL_INTERVAL_MS) must be positive")
+	check(c.Outbox.BatchSize > 0, "outbox.batch_size (OUTBOX_BATCH_SIZE) must be positive")
+	check(c.Outbox.RetentionHours >= 1, "outbox.retention_hours (OUTBOX_RETENTION_HOURS) must be at least 1, got %d", c.Outbox.RetentionHours)
+	check(c.Events.Driver == "memory" || c.Events.Driver == "nats",
+		"events.driver (EVENTS_DRIVER) must be memory or nats, got %q", c.Events.Driver)
+	if c.Events.Driver == "nats" {
+		check(c.Events.NATSURL != "", "events.nats_url (EVENTS_NATS_URL) is required when events.driver is nats")
+		check(c.Events.SubjectPrefix != "", "events.subject_prefix (EVENTS_SUBJECT_PREFIX) is required when events.driver is nats")
+	}
+	if c.Search.ElasticsearchURL != "" {
+		u, err := url.Parse(c.Search.ElasticsearchURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"search.elasticsearch_url (SEARCH_ELASTICSEARCH_URL) must be an http(s) URL, got %q", c.Search.ElasticsearchURL)
+		check(c.Search.Index != "", "search.index (SEARCH_INDEX) is required when search.elasticsearch_url is set")
+		check(c.Search.TimeoutSeconds > 0, "search.timeout_seconds (SEARCH_TIMEOUT_SECONDS) must be positive")
+	}
+
+	check(c.WebSocket.PingIntervalSeconds > 0, "websocket.ping_interval_seconds (WS_PING_INTERVAL_SECONDS) must be positive")
+	check(c.WebSocket.MaxConnectionsPerUser > 0, "websocket.max_connections_per_user (WS_MAX_CONNECTIONS_PER_USER) must be positive")
+
+	check(c.Retention.SoftDeleteDays >= 1, "retention.soft_delete_days (SOFT_DELETE_RETENTION_DAYS) must be at least 1, got %d", c.Retention.SoftDeleteDays)
+	check(c.Retention.BatchSize > 0, "retention.batch_size (SOFT_DELETE_PURGE_BATCH_SIZE) must be positive")
+	check(c.Quota.MaxNotesPerUser >= 0, "quota.max_notes_per_user (QUOTA_MAX_NOTES_PER_USER) must not be negative")
+	check(c.Quota.MaxFoldersPerUser >= 0, "quota.max_folders_per_user (QUOTA_MAX_FOLDERS_PER_USER) must not be negative")
+	check(c.Quota.MaxNotesPerTeam >= 0, "quota.max_notes_per_team (QUOTA_MAX_NOTES_PER_TEAM) must not be negative")
+	check(c.Quota.MaxFoldersPerTeam >= 0, "quota.max_folders_per_team (QUOTA_MAX_FOLDERS_PER_TEAM) must not be negative")
+	check(c.Admin.ImpersonationMinutes > 0, "admin.impersonation_minutes (ADMIN_IMPERSONATION_MINUTES) must be positive")
+	check(!c.Debug.Enabled || len(c.Admin.Users) > 0, "debug.enabled (DEBUG_ENDPOINTS_ENABLED) requires admin.users (ADMIN_USERS)")
+	if c.Captcha.Provider != "" {
+		check(slices.Contains(captcha.Providers, c.Captcha.Provider),
+			"captcha.provider (CAPTCHA_PROVIDER) must be empty or one of %s, got %q", strings.Join(captcha.Providers, ", "), c.Captcha.Provider)
+		check(c.Captcha.Secret != "", "captcha.secret (CAPTCHA_SECRET) is required when captcha.provider is set")
+		check(c.Captcha.FailureThreshold >= 0, "captcha.failure_threshold (CAPTCHA_FAILURE_THRESHOLD) must not be negative")
+		check(c.Captcha.WindowMinutes >= 1, "captcha.window_minutes (CAPTCHA_WINDOW_MINUTES) must be at least 1")
+		check(c.Captcha.TimeoutSeconds > 0, "captcha.timeout_seconds (CAPTCHA_TIMEOUT_SECONDS) must be positive")
+	}
+	if c.SSO.BaseURL != "" {
+		u, err := url.Parse(c.SSO.BaseURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"sso.base_url (SSO_BASE_URL) must be an http(s) URL, got %q", c.SSO.BaseURL)
+	}
+	check((c.SSO.CertFile == "") == (c.SSO.KeyFile == ""),
+		"sso.cert_file (SSO_SP_CERT_FILE) and sso.key_file (SSO_SP_KEY_FILE) must be set together")
+	if c.SSO.CertFile != "" && c.SSO.KeyFile != "" {
+		if _, _, err := sso.LoadKeyPair(c.SSO.CertFile, c.SSO.KeyFile); err != nil {
+			check(false, "sso.cert_file (SSO_SP_CERT_FILE): %v", err)
+		}
+	}
+	check(c.LDAP.TimeoutSeconds > 0, "ldap.timeout_seconds (LDAP_TIMEOUT_SECONDS) must be positive")
+	for name, spec := range map[string]string{
+		"jobs.idempotency_purge_schedule (JOBS_IDEMPOTENCY_PURGE_SCHEDULE)": c.Jobs.IdempotencyPurgeSchedule,
+		"jobs.outbox_purge_schedule (JOBS_OUTBOX_PURGE_SCHEDULE)":           c.Jobs.OutboxPurgeSchedule,
+		"jobs.soft_delete_purge_schedule (JOBS_SOFT_DELETE_PURGE_SCHEDULE)": c.Jobs.SoftDeletePurgeSchedule,
+		"jobs.reminder_schedule (JOBS_REMINDER_SCHEDULE)":                   c.Jobs.ReminderSchedule,
+		"jobs.ldap_sync_schedule (JOBS_LDAP_SYNC_SCHEDULE)":                 c.Jobs.LDAPSyncSchedule,
+	} {
+		if _, err := jobs.ParseSchedule(spec); err != nil {
+			check(false, "%s: %v", name, err)
+		}
+	}
+
+	check(c.ResponseCompression.Level >= 1 && c.ResponseCompression.Level <= 9,
+		"response_compression.level (RESPONSE_COMPRESSION_LEVEL) must be between 1 and 9, got %d", c.ResponseCompression.Level)
+	check(c.ResponseCompression.MinSizeBytes >= 0, "response_compression.min_size_bytes (RESPONSE_COMPRESSION_MIN_BYTES) must not be negative")
+
+	check(slices.Contains([]string{"off", "read_only", "full"}, c.Maintenance.Mode),
+		"maintenance.mode (MAINTENANCE_MODE) must be one of off, read_only, full, got %q", c.Maintenance.Mode)
+	check(c.Maintenance.RetryAfterSeconds >= 1, "maintenance.retry_after_seconds (MAINTENANCE_RETRY_AFTER_SECONDS) must be at least 1")
+
+	check(validDate(c.API.V1DeprecatedSince), "api.v1_deprecated_since (API_V1_DEPRECATED_SINCE) must be a YYYY-MM-DD date, got %q", c.API.V1DeprecatedSince)
+	check(validDate(c.API.V1Sunset), "api.v1_sunset (API_V1_SUNSET) must be a YYYY-MM-DD date, got %q", c.API.V1Sunset)
+
+	return errors.Join(errs...)
+}
+
+// validDate accepts an empty string or a YYYY-MM-DD date
+func validDate(value string) bool {
+	if value == "" {
+		return true
+	}
+	_, err := time.Parse(time.DateOnly, value)
+	return err == nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n < 65536
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type actorContextKey struct{}
+
+// WithActor returns a copy of ctx carrying the ID of the user making a
+// change. Statements run with that context record it in the CreatedBy and
+// UpdatedBy columns of models that have them.
+func WithActor(ctx context.Context, userID uuid.UUID) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, userID)
+}
+
+// ActorFromContext returns the user ID stored by WithActor
+func ActorFromContext(ctx context.Context) (uuid.UUID, bool) {
+	if ctx == nil {
+		return uuid.Nil, false
+	}
+	userID, ok := ctx.Value(actorContextKey{}).(uuid.UUID)
+	return userID, ok && userID != uuid.Nil
+}
+
+// AuditColumns is a GORM plugin that fills CreatedBy on create and UpdatedBy
+// on create and update from the actor in the statement's context. Models
+// without those fields, and statements without an actor, are left alone.
+type AuditColumns struct{}
+
+func (AuditColumns) Name() string {
+	return "audit_columns"
+}
+
+func (AuditColumns) Initialize(db *gorm.DB) error {
+	err := db.Callback().Create().Before("gorm:create").
+		Register("audit_columns:create", setActorColumns("CreatedBy", "UpdatedBy"))
+	if err != nil {
+		return err
+	}
+	return db.Callback().Update().Before("gorm:update").
+		Register("audit_columns:update", setActorColumns("UpdatedBy"))
+}
+
+// setActorColumns sets the named fields to the statement's actor
+func setActorColumns(fields ...string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.Statement.Schema == nil {
+			return
+		}
+		actor, ok := ActorFromContext(db.Statement.Context)
+		if !ok {
+			return
+		}
+		for _, name := range fields {
+			if db.Statement.Schema.LookUpField(name) != nil {
+				db.Statement.SetColumn(name, &actor, true)
+			}
+		}
+	}
+}
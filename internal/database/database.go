@@ -1,12 +1,18 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync/atomic"
+	"time"
 
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 
 	"seta-training/internal/config"
 	"seta-training/internal/models"
@@ -14,19 +20,20 @@ import (
 
 type Database struct {
 	DB *gorm.DB
+
+	migrated atomic.Bool
+
+	// generation is bumped by ResetPool; connections opened in an earlier
+	// generation are discarded instead of reused
+	generation atomic.Uint64
+	resets     atomic.Int64
+	// lastReset is the UnixNano time of the last reset
+	lastReset atomic.Int64
+	// maxIdleConns is restored after ResetPool has closed idle connections
+	maxIdleConns int
 }
 
 func New(cfg *config.Config) (*Database, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
-		cfg.Database.Host,
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.Name,
-		cfg.Database.Port,
-		cfg.Database.SSLMode,
-	)
-
 	// Configure GORM logger
 	var gormLogger logger.Interface
 	if cfg.Server.GinMode == "release" {
@@ -35,48 +42,199 @@ func New(cfg *config.Config) (*Database, error) {
 		gormLogger = logger.Default.LogMode(logger.Info)
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	d := &Database{maxIdleConns: cfg.Database.MaxIdleConns}
+	var primary gorm.Dialector
+	openReplica := postgres.Open
+	switch cfg.Database.Driver {
+	case DriverMySQL, DriverMariaDB:
+		primary = newMySQLDialector(cfg.Database)
+		openReplica = mysql.Open
+	default:
+		sqlDB, err := d.openPool(postgresDSN(cfg.Database))
+		if err != nil {
+			return nil, err
+		}
+		primary = postgres.New(postgres.Config{Conn: sqlDB})
+	}
+
+	db, err := gorm.Open(primary, &gorm.Config{
 		Logger: gormLogger,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
+	if IsMySQL(db) {
+		if err := db.Use(uuidKeys{}); err != nil {
+			return nil, fmt.Errorf("failed to register UUID keys: %w", err)
+		}
+	}
+	if err := db.Use(AuditColumns{}); err != nil {
+		return nil, fmt.Errorf("failed to register audit columns: %w", err)
+	}
+	if err := db.Use(failoverDetector{database: d}); err != nil {
+		return nil, fmt.Errorf("failed to register failover detection: %w", err)
+	}
 
-	// Get underlying sql.DB to configure connection pool
+	// Configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
+		return nil, err
 	}
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.Database.ConnMaxLifetimeMinutes) * time.Minute)
+	sqlDB.SetConnMaxIdleTime(time.Duration(cfg.Database.ConnMaxIdleTimeMinutes) * time.Minute)
 
-	// Configure connection pool
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
+	if err := registerReplicas(db, cfg.Database, openReplica); err != nil {
+		return nil, err
+	}
+
+	d.DB = db
+	return d, nil
+}
+
+func postgresDSN(cfg config.DatabaseConfig) string {
+	dsn := fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
+		cfg.Host,
+		cfg.User,
+		cfg.Password,
+		cfg.Name,
+		cfg.Port,
+		cfg.SSLMode,
+	)
+	// Unknown DSN keys are sent to the server as session parameters
+	if cfg.StatementTimeoutMillis > 0 {
+		dsn += fmt.Sprintf(" statement_timeout=%d", cfg.StatementTimeoutMillis)
+	}
+	return dsn
+}
 
-	return &Database{DB: db}, nil
+// registerReplicas routes queries opted in with ReadReplica to the configured
+// replicas. Everything else, including all writes, stays on the primary.
+func registerReplicas(db *gorm.DB, cfg config.DatabaseConfig, open func(dsn string) gorm.Dialector) error {
+	if len(cfg.ReplicaDSNs) == 0 {
+		return nil
+	}
+
+	replicas := make([]gorm.Dialector, 0, len(cfg.ReplicaDSNs))
+	for _, dsn := range cfg.ReplicaDSNs {
+		replicas = append(replicas, open(dsn))
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}, replicaResolver)
+	if err := db.Use(resolver); err != nil {
+		return fmt.Errorf("failed to configure read replicas: %w", err)
+	}
+	resolver.SetMaxOpenConns(cfg.MaxOpenConns).
+		SetMaxIdleConns(cfg.MaxIdleConns).
+		SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeMinutes) * time.Minute).
+		SetConnMaxIdleTime(time.Duration(cfg.ConnMaxIdleTimeMinutes) * time.Minute)
+	return nil
+}
+
+// replicaResolver names the dbresolver pool holding the read replicas
+const replicaResolver = "replica"
+
+// ReadReplica sends the queries of db to a read replica when replicas are
+// configured, and to the primary otherwise. Use it only for reads that can
+// tolerate replication lag, never right after a write that must be visible.
+func ReadReplica(db *gorm.DB) *gorm.DB {
+	return db.Clauses(dbresolver.Use(replicaResolver))
 }
 
 func (d *Database) Migrate() error {
 	log.Println("Running database migrations...")
 
+	db := d.DB
+	if IsMySQL(db) {
+		db = db.Set("gorm:table_options", mysqlTableOptions)
+	}
+
 	// Auto-migrate all models
-	err := d.DB.AutoMigrate(
+	err := db.AutoMigrate(
+		&models.Organization{},
 		&models.User{},
 		&models.Team{},
-		&models.TeamManager{},
-		&models.TeamMember{},
+		&models.TeamMembership{},
 		&models.Folder{},
 		&models.FolderShare{},
 		&models.Note{},
 		&models.NoteShare{},
+		&models.SavedFilter{},
+		&models.ExportJob{},
+		&models.AssetReport{},
+		&models.Notification{},
+		&models.Mention{},
+		&models.IdempotencyKey{},
+		&models.AuditLog{},
+		&models.OutboxEvent{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.JobLock{},
+		&models.UserPreference{},
+		&models.APIUsage{},
+		&models.FeatureFlag{},
+		&models.Announcement{},
+		&models.Reminder{},
+		&models.ChecklistItem{},
+		&models.NoteLink{},
+		&models.AccessRequest{},
+		&models.LoginAttempt{},
+		&models.Device{},
+		&models.SAMLConnection{},
+		&models.LDAPConnection{},
+		&models.LDAPUser{},
+		&models.SCIMConnection{},
+		&models.SCIMUser{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
+	if err := migrateTeamMemberships(d.DB); err != nil {
+		return fmt.Errorf("failed to migrate team memberships: %w", err)
+	}
 
 	log.Println("Database migrations completed successfully")
+	d.migrated.Store(true)
 	return nil
 }
 
+// migrateTeamMemberships moves the rows of the team_managers and
+// team_members tables of earlier versions into team_memberships, with the
+// manager and member roles, and drops them. A user who was both keeps the
+// manager role.
+func migrateTeamMemberships(db *gorm.DB) error {
+	legacy := []struct {
+		table string
+		role  models.TeamRole
+	}{
+		{"team_managers", models.TeamRoleManager},
+		{"team_members", models.TeamRoleMember},
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, l := range legacy {
+			if !tx.Migrator().HasTable(l.table) {
+				continue
+			}
+			err := tx.Exec("INSERT INTO team_memberships (team_id, user_id, role, created_at) "+
+				"SELECT l.team_id, l.user_id, ?, COALESCE(l.created_at, CURRENT_TIMESTAMP) FROM "+l.table+" l "+
+				"WHERE NOT EXISTS (SELECT 1 FROM team_memberships m WHERE m.team_id = l.team_id AND m.user_id = l.user_id)",
+				l.role).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropTable(l.table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (d *Database) Close() error {
 	sqlDB, err := d.DB.DB()
 	if err != nil {
@@ -87,9 +245,22 @@ func (d *Database) Close() error {
 
 // Health check for database connection
 func (d *Database) Ping() error {
+	return d.PingContext(context.Background())
+}
+
+// PingContext checks the database connection, giving up when ctx is done
+func (d *Database) PingContext(ctx context.Context) error {
 	sqlDB, err := d.DB.DB()
 	if err != nil {
 		return err
 	}
-	return sqlDB.Ping()
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckMigrations reports an error until Migrate has completed
+func (d *Database) CheckMigrations(ctx context.Context) error {
+	if !d.migrated.Load() {
+		return errors.New("migrations not applied")
+	}
+	return nil
 }
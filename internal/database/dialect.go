@@ -0,0 +1,212 @@
+package database
+
+import (
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+
+	"seta-training/internal/config"
+)
+
+// Values of config.DatabaseConfig.Driver
+const (
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+	DriverMariaDB  = "mariadb"
+)
+
+// uuidDefault is the column default the models give their UUID primary keys
+const uuidDefault = "gen_random_uuid()"
+
+// mysqlTableOptions are used for the tables Migrate creates on MySQL. The
+// case-insensitive collation makes LIKE match the way ILIKE does on Postgres.
+const mysqlTableOptions = "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"
+
+// mysqlTLS maps Postgres sslmode values onto the MySQL driver's tls option
+var mysqlTLS = map[string]string{
+	"disable":     "false",
+	"allow":       "preferred",
+	"prefer":      "preferred",
+	"require":     "skip-verify",
+	"verify-ca":   "true",
+	"verify-full": "true",
+}
+
+// mysqlDSN builds the DSN of a MySQL or MariaDB primary. Sessions use UTC
+// like on Postgres, and the statement timeout maps onto the server's own
+// limit, which MySQL takes in milliseconds and MariaDB in seconds.
+func mysqlDSN(cfg config.DatabaseConfig) string {
+	c := mysqldriver.NewConfig()
+	c.User = cfg.User
+	c.Passwd = cfg.Password
+	c.Net = "tcp"
+	c.Addr = net.JoinHostPort(cfg.Host, cfg.Port)
+	c.DBName = cfg.Name
+	c.ParseTime = true
+	c.Loc = time.UTC
+	c.TLSConfig = mysqlTLS[cfg.SSLMode]
+	c.Params = map[string]string{
+		"charset":   "utf8mb4",
+		"time_zone": "'+00:00'",
+	}
+	if cfg.StatementTimeoutMillis > 0 {
+		if cfg.Driver == DriverMariaDB {
+			c.Params["max_statement_time"] = strconv.FormatFloat(float64(cfg.StatementTimeoutMillis)/1000, 'f', -1, 64)
+		} else {
+			c.Params["max_execution_time"] = strconv.Itoa(cfg.StatementTimeoutMillis)
+		}
+	}
+	return c.FormatDSN()
+}
+
+// mysqlDialector adapts the Postgres column types of the models to MySQL and
+// MariaDB: UUIDs are stored as CHAR(36), JSONB as JSON and BYTEA as LONGBLOB.
+// MariaDB's JSON is an alias of LONGTEXT, which is used directly.
+type mysqlDialector struct {
+	mysql.Dialector
+	mariaDB bool
+}
+
+func newMySQLDialector(cfg config.DatabaseConfig) mysqlDialector {
+	return mysqlDialector{
+		Dialector: mysql.Dialector{Config: &mysql.Config{
+			DSN:               mysqlDSN(cfg),
+			DefaultStringSize: 255,
+		}},
+		mariaDB: cfg.Driver == DriverMariaDB,
+	}
+}
+
+func (d mysqlDialector) DataTypeOf(field *schema.Field) string {
+	switch strings.ToLower(string(field.DataType)) {
+	case "uuid":
+		return "char(36)"
+	case "jsonb":
+		if d.mariaDB {
+			return "longtext"
+		}
+		return "json"
+	case "bytea":
+		return "longblob"
+	}
+	return d.Dialector.DataTypeOf(field)
+}
+
+func (d mysqlDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return mysqlMigrator{mysql.Migrator{
+		Migrator:  migrator.Migrator{Config: migrator.Config{DB: db, Dialector: d}},
+		Dialector: d.Dialector,
+	}}
+}
+
+// mysqlMigrator leaves out the gen_random_uuid() defaults of primary keys,
+// which MySQL cannot evaluate; uuidKeys assigns the IDs instead
+type mysqlMigrator struct {
+	mysql.Migrator
+}
+
+func (m mysqlMigrator) FullDataTypeOf(field *schema.Field) clause.Expr {
+	if field.DefaultValue == uuidDefault {
+		withoutDefault := *field
+		withoutDefault.HasDefaultValue = false
+		withoutDefault.DefaultValue = ""
+		field = &withoutDefault
+	}
+	return m.Migrator.FullDataTypeOf(field)
+}
+
+// uuidKeys is a GORM plugin that generates the UUIDs Postgres would fill in
+// with gen_random_uuid(), for databases that cannot. Records created with an
+// ID keep it.
+type uuidKeys struct{}
+
+func (uuidKeys) Name() string {
+	return "uuid_keys"
+}
+
+func (uuidKeys) Initialize(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register("uuid_keys:create", assignUUIDs)
+}
+
+func assignUUIDs(db *gorm.DB) {
+	if db.Error != nil || db.Statement.Schema == nil {
+		return
+	}
+	var fields []*schema.Field
+	for _, field := range db.Statement.Schema.Fields {
+		if field.DefaultValue == uuidDefault {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+
+	ctx := db.Statement.Context
+	assign := func(record reflect.Value) {
+		for _, field := range fields {
+			if _, zero := field.ValueOf(ctx, record); zero {
+				db.AddError(field.Set(ctx, record, uuid.New()))
+			}
+		}
+	}
+	switch value := db.Statement.ReflectValue; value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			assign(reflect.Indirect(value.Index(i)))
+		}
+	case reflect.Struct:
+		assign(value)
+	}
+}
+
+// IsMySQL reports whether db talks to MySQL or MariaDB
+func IsMySQL(db *gorm.DB) bool {
+	return db.Dialector.Name() == mysql.DefaultDriverName
+}
+
+// ILike returns the case-insensitive LIKE operator of db's dialect. On MySQL
+// that is plain LIKE, since tables use a case-insensitive collation.
+func ILike(db *gorm.DB) string {
+	if IsMySQL(db) {
+		return "LIKE"
+	}
+	return "ILIKE"
+}
+
+// Excluded refers to column of the row an upsert failed to insert, for use
+// in its update assignments
+func Excluded(db *gorm.DB, column string) string {
+	if IsMySQL(db) {
+		return "VALUES(" + column + ")"
+	}
+	return "excluded." + column
+}
+
+// IRegexp returns the case-insensitive regular expression match operator of
+// db's dialect
+func IRegexp(db *gorm.DB) string {
+	if IsMySQL(db) {
+		return "REGEXP"
+	}
+	return "~*"
+}
+
+// JSONArrayContains returns a condition, taking one string argument, that
+// holds when the JSON array in column contains that string
+func JSONArrayContains(db *gorm.DB, column string) string {
+	if IsMySQL(db) {
+		return "JSON_CONTAINS(" + column + ", JSON_QUOTE(?))"
+	}
+	return column + " @> to_jsonb(?::text)"
+}
@@ -0,0 +1,234 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// connGenerationKey tags each connection with the pool generation it was
+// opened in
+const connGenerationKey = "seta:generation"
+
+// minResetInterval keeps a burst of failing statements from resetting the
+// pool over and over
+const minResetInterval = time.Second
+
+// openPool opens the primary's connection pool. Go resolves the host on
+// every dial, so once ResetPool has retired the old connections, new ones
+// follow DNS to whichever server is primary now.
+func (d *Database) openPool(dsn string) (*sql.DB, error) {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database settings: %w", err)
+	}
+	config.RuntimeParams["timezone"] = "UTC"
+
+	return stdlib.OpenDB(*config,
+		stdlib.OptionAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+			conn.TypeMap().RegisterType(&pgtype.Type{
+				Name:  "timestamp",
+				OID:   pgtype.TimestampOID,
+				Codec: &pgtype.TimestampCodec{ScanLocation: time.UTC},
+			})
+			conn.PgConn().CustomData()[connGenerationKey] = d.generation.Load()
+			return nil
+		}),
+		// Runs whenever a pooled connection is reused; ErrBadConn makes
+		// database/sql close it and take or open another
+		stdlib.OptionResetSession(func(ctx context.Context, conn *pgx.Conn) error {
+			if generation, _ := conn.PgConn().CustomData()[connGenerationKey].(uint64); generation != d.generation.Load() {
+				return driver.ErrBadConn
+			}
+			return nil
+		}),
+	), nil
+}
+
+// ResetPool retires every open connection: idle ones are closed at once and
+// ones in use once they are returned and taken again. Resets within a second
+// of the last one are ignored.
+func (d *Database) ResetPool(reason string) {
+	now := time.Now().UnixNano()
+	last := d.lastReset.Load()
+	if now-last < int64(minResetInterval) || !d.lastReset.CompareAndSwap(last, now) {
+		return
+	}
+	d.generation.Add(1)
+	d.resets.Add(1)
+	// The generation check only runs on Postgres connections; closing the
+	// idle ones covers the other dialects
+	if d.DB != nil {
+		if sqlDB, err := d.DB.DB(); err == nil {
+			sqlDB.SetMaxIdleConns(0)
+			sqlDB.SetMaxIdleConns(d.maxIdleConns)
+		}
+	}
+	log.Printf("Resetting database connections: %s", reason)
+}
+
+// Monitor checks the primary every interval until ctx is done. Together
+// with the failover detection on statements, this lets the service recover
+// from a dropped connection or a primary switchover without a restart.
+func (d *Database) Monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		checkCtx, cancel := context.WithTimeout(ctx, interval)
+		err := d.checkPrimary(checkCtx)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Database health check failed: %v", err)
+		}
+	}
+}
+
+// checkPrimary reports an error unless a pooled connection reaches a
+// writable primary. A connection left on a demoted primary resets the pool.
+func (d *Database) checkPrimary(ctx context.Context) error {
+	query := "SELECT pg_is_in_recovery()"
+	if IsMySQL(d.DB) {
+		query = "SELECT @@global.read_only"
+	}
+	var readOnly bool
+	if err := d.DB.WithContext(ctx).Raw(query).Scan(&readOnly).Error; err != nil {
+		return err
+	}
+	if readOnly {
+		d.ResetPool("connected to a read-only server")
+		return errors.New("connected to a read-only standby")
+	}
+	return nil
+}
+
+// PoolHealth describes the primary's connection pool
+type PoolHealth struct {
+	OpenConnections    int        `json:"open_connections"`
+	InUse              int        `json:"in_use"`
+	Idle               int        `json:"idle"`
+	MaxOpenConnections int        `json:"max_open_connections"`
+	WaitCount          int64      `json:"wait_count"`
+	WaitDurationMs     int64      `json:"wait_duration_ms"`
+	Resets             int64      `json:"resets"`
+	LastReset          *time.Time `json:"last_reset,omitempty"`
+}
+
+// CheckPool reports the state of the connection pool, with an error unless
+// it reaches a writable primary
+func (d *Database) CheckPool(ctx context.Context) (PoolHealth, error) {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return PoolHealth{}, err
+	}
+	stats := sqlDB.Stats()
+	health := PoolHealth{
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		MaxOpenConnections: stats.MaxOpenConnections,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		Resets:             d.resets.Load(),
+	}
+	if last := d.lastReset.Load(); last != 0 {
+		at := time.Unix(0, last).UTC()
+		health.LastReset = &at
+	}
+	return health, d.checkPrimary(ctx)
+}
+
+// SQLState returns the SQLSTATE code carried by err, or "" if it has none
+func SQLState(err error) string {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState()
+	}
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) && myErr.SQLState != [5]byte{} {
+		return string(myErr.SQLState[:])
+	}
+	return ""
+}
+
+// failoverDetector is a GORM plugin that resets the pool when a statement
+// fails in a way suggesting its connection, and likely the others, point at
+// a server that is gone or no longer the primary
+type failoverDetector struct {
+	database *Database
+}
+
+func (failoverDetector) Name() string {
+	return "failover_detector"
+}
+
+func (p failoverDetector) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().After("gorm:create").Register("failover:after_create", p.after),
+		cb.Query().After("gorm:query").Register("failover:after_query", p.after),
+		cb.Update().After("gorm:update").Register("failover:after_update", p.after),
+		cb.Delete().After("gorm:delete").Register("failover:after_delete", p.after),
+		cb.Row().After("gorm:row").Register("failover:after_row", p.after),
+		cb.Raw().After("gorm:raw").Register("failover:after_raw", p.after),
+	)
+}
+
+func (p failoverDetector) after(db *gorm.DB) {
+	if reason := failoverReason(db.Error); reason != "" {
+		p.database.ResetPool(reason)
+	}
+}
+
+// failoverReason says why err calls for new connections, or returns "" if
+// it does not
+func failoverReason(err error) string {
+	// Deadlines and cancellations come from callers, not the server
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return ""
+	}
+
+	// MySQL reports most of these with the catch-all SQLSTATE HY000
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		switch myErr.Number {
+		case 1290, 1792, 1836:
+			return "write rejected by a read-only server"
+		}
+	}
+
+	switch state := SQLState(err); {
+	case state == "25006":
+		return "write rejected by a read-only server"
+	case state == "57P01", state == "57P02", state == "57P03":
+		return "server shutting down or starting up"
+	case len(state) >= 2 && state[:2] == "08":
+		return "connection exception " + state
+	case state != "":
+		return ""
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, &netErr) {
+		return "connection lost: " + err.Error()
+	}
+	return ""
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+)
+
+// AccessRequestHandler serves requests for access to folders and notes and
+// their owners' decisions
+type AccessRequestHandler struct {
+	accessRequestService services.AccessRequestServiceInterface
+}
+
+func NewAccessRequestHandler(accessRequestService services.AccessRequestServiceInterface) *AccessRequestHandler {
+	return &AccessRequestHandler{
+		accessRequestService: accessRequestService,
+	}
+}
+
+// RequestFolderAccess asks the owner of a folder to share it
+func (h *AccessRequestHandler) RequestFolderAccess(c *gin.Context) {
+	folderID, err := uuid.Parse(c.Param("folderId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
+		return
+	}
+	h.requestAccess(c, models.ResourceFolder, folderID)
+}
+
+// RequestNoteAccess asks the owner of a note to share it
+func (h *AccessRequestHandler) RequestNoteAccess(c *gin.Context) {
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return
+	}
+	h.requestAccess(c, models.ResourceNote, noteID)
+}
+
+func (h *AccessRequestHandler) requestAccess(c *gin.Context, resourceType string, resourceID uuid.UUID) {
+	// The body is optional; read access is asked for by default
+	input := services.AccessRequestInput{Access: models.AccessRead}
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	request, err := h.accessRequestService.RequestAccess(c.Request.Context(), resourceType, resourceID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// GetPending lists the pending requests for the user's folders and notes,
+// one page at a time
+func (h *AccessRequestHandler) GetPending(c *gin.Context) {
+	params, _, err := parsePageParams(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	page, err := h.accessRequestService.ListPending(c.Request.Context(), claims.UserID, params)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	setNextCursor(c, page.Next)
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// Approve shares the requested folder or note with the requester
+func (h *AccessRequestHandler) Approve(c *gin.Context) {
+	h.decide(c, true)
+}
+
+// Deny turns the request down
+func (h *AccessRequestHandler) Deny(c *gin.Context) {
+	h.decide(c, false)
+}
+
+func (h *AccessRequestHandler) decide(c *gin.Context, approve bool) {
+	requestID, err := uuid.Parse(c.Param("requestId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid access request ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	request, err := h.accessRequestService.Decide(c.Request.Context(), requestID, approve, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
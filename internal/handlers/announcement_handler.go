@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// AnnouncementHandler serves announcements to clients and lets admins
+// manage them
+type AnnouncementHandler struct {
+	announcementService services.AnnouncementServiceInterface
+}
+
+func NewAnnouncementHandler(announcementService services.AnnouncementServiceInterface) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		announcementService: announcementService,
+	}
+}
+
+// GetActiveAnnouncements lists the announcements shown now. It needs no
+// authentication so clients can show them before signing in.
+func (h *AnnouncementHandler) GetActiveAnnouncements(c *gin.Context) {
+	announcements, err := h.announcementService.GetActiveAnnouncements(c.Request.Context())
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, announcements)
+}
+
+// GetAnnouncements lists every announcement for admins
+func (h *AnnouncementHandler) GetAnnouncements(c *gin.Context) {
+	announcements, err := h.announcementService.GetAnnouncements(c.Request.Context())
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, announcements)
+}
+
+// CreateAnnouncement schedules an announcement
+func (h *AnnouncementHandler) CreateAnnouncement(c *gin.Context) {
+	var input services.AnnouncementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	announcement, err := h.announcementService.CreateAnnouncement(c.Request.Context(), &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, announcement)
+}
+
+// UpdateAnnouncement replaces an announcement's content and schedule
+func (h *AnnouncementHandler) UpdateAnnouncement(c *gin.Context) {
+	announcementID, err := uuid.Parse(c.Param("announcementId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid announcement ID"))
+		return
+	}
+
+	var input services.AnnouncementInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	announcement, err := h.announcementService.UpdateAnnouncement(c.Request.Context(), announcementID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, announcement)
+}
+
+// DeleteAnnouncement removes an announcement
+func (h *AnnouncementHandler) DeleteAnnouncement(c *gin.Context) {
+	announcementID, err := uuid.Parse(c.Param("announcementId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid announcement ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.announcementService.DeleteAnnouncement(c.Request.Context(), announcementID, claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Announcement deleted successfully",
+	})
+}
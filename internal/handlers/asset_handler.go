@@ -2,24 +2,42 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/middleware"
+	"seta-training/internal/models"
 	"seta-training/internal/services"
 )
 
+const (
+	defaultTeamAssetLimit = 50
+	maxTeamAssetLimit     = 200
+)
+
+// How a team member relates to an asset listed for them
+const (
+	AssetAccessOwned  = "owned"
+	AssetAccessShared = "shared"
+)
+
 type AssetHandler struct {
 	folderService services.FolderServiceInterface
 	noteService   services.NoteServiceInterface
 	teamService   services.TeamServiceInterface
+	userService   services.UserServiceInterface
 }
 
-func NewAssetHandler(folderService services.FolderServiceInterface, noteService services.NoteServiceInterface, teamService services.TeamServiceInterface) *AssetHandler {
+func NewAssetHandler(folderService services.FolderServiceInterface, noteService services.NoteServiceInterface, teamService services.TeamServiceInterface, userService services.UserServiceInterface) *AssetHandler {
 	return &AssetHandler{
 		folderService: folderService,
 		noteService:   noteService,
 		teamService:   teamService,
+		userService:   userService,
 	}
 }
 
@@ -28,44 +46,47 @@ func (h *AssetHandler) GetUserAssets(c *gin.Context) {
 	userIDStr := c.Param("userId")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid user ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
 	// Only managers can view other users' assets, or users can view their own
 	if claims.UserID != userID && claims.Role != "manager" {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Insufficient permissions",
-		})
+		middleware.RespondError(c, apperrors.Forbidden("Insufficient permissions"))
 		return
 	}
 
+	// Managers only see users of their own organization
+	if claims.UserID != userID {
+		user, err := h.userService.GetUserByID(c.Request.Context(), userID)
+		if err != nil {
+			middleware.RespondError(c, err)
+			return
+		}
+		if !models.SameOrganization(user.OrganizationID, claims.OrgID) {
+			middleware.RespondError(c, apperrors.NotFound("user not found"))
+			return
+		}
+	}
+
 	// Get user's folders
-	folders, err := h.folderService.GetUserFolders(userID)
+	folders, err := h.folderService.GetUserFolders(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user folders: " + err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
 	// Get user's notes
-	notes, err := h.noteService.GetUserNotes(userID)
+	notes, err := h.noteService.GetUserNotes(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user notes: " + err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -80,95 +101,201 @@ func (h *AssetHandler) GetTeamAssets(c *gin.Context) {
 	teamIDStr := c.Param("teamId")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid team ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid team ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
 	// Only managers can view team assets
 	if claims.Role != "manager" {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Only managers can view team assets",
-		})
+		middleware.RespondError(c, apperrors.Forbidden("Only managers can view team assets"))
 		return
 	}
 
 	// Verify user is a manager of this team
-	team, err := h.teamService.GetTeam(teamID)
+	team, err := h.teamService.GetTeam(c.Request.Context(), teamID, claims.OrgID)
+	if err != nil {
+		middleware.RespondError(c, apperrors.NotFound("Team not found"))
+		return
+	}
+
+	// Check if current user is an admin or manager of this team
+	if role, _ := team.RoleOf(claims.UserID); !role.Manages() {
+		middleware.RespondError(c, apperrors.Forbidden("You are not a manager of this team"))
+		return
+	}
+
+	query, err := parseTeamAssetQuery(c)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Team not found",
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	// Check if current user is a manager of this team
-	isManager := false
-	for _, manager := range team.Managers {
-		if manager.ID == claims.UserID {
-			isManager = true
-			break
+	// Get all team members (including managers), each once, in a stable order
+	// so that pages do not shift between requests
+	allMembers := teamAssetMembers(team)
+	if query.memberID != uuid.Nil {
+		idx := slices.IndexFunc(allMembers, func(u models.User) bool { return u.ID == query.memberID })
+		if idx < 0 {
+			middleware.RespondError(c, apperrors.ValidationFields("Invalid team asset query", map[string]string{
+				"member_id": "must be a member or manager of the team",
+			}))
+			return
 		}
+		allMembers = allMembers[idx : idx+1]
 	}
 
-	if !isManager {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "You are not a manager of this team",
-		})
+	// Load every member's assets at once rather than querying per member
+	memberIDs := make([]uuid.UUID, len(allMembers))
+	for i, member := range allMembers {
+		memberIDs[i] = member.ID
+	}
+	foldersByMember, err := h.folderService.GetFoldersByUsers(c.Request.Context(), memberIDs)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+	notesByMember, err := h.noteService.GetNotesByUsers(c.Request.Context(), memberIDs)
+	if err != nil {
+		middleware.RespondError(c, err)
 		return
 	}
 
-	// Get all team members (including managers)
-	allMembers := append(team.Members, team.Managers...)
-	
 	// Collect all assets from team members
 	var allFolders []interface{}
 	var allNotes []interface{}
 
 	for _, member := range allMembers {
-		// Get member's folders
-		folders, err := h.folderService.GetUserFolders(member.ID)
-		if err != nil {
-			continue // Skip on error, don't fail the entire request
+		for _, folder := range foldersByMember[member.ID] {
+			if access := assetAccess(folder.OwnerID, member.ID); query.matches(access) {
+				allFolders = append(allFolders, gin.H{
+					"folder": folder,
+					"owner":  member,
+					"access": access,
+				})
+			}
 		}
-		
-		for _, folder := range folders {
-			allFolders = append(allFolders, gin.H{
-				"folder": folder,
-				"owner":  member,
-			})
+
+		for _, note := range notesByMember[member.ID] {
+			if access := assetAccess(note.OwnerID, member.ID); query.matches(access) {
+				allNotes = append(allNotes, gin.H{
+					"note":   note,
+					"owner":  member,
+					"access": access,
+				})
+			}
 		}
+	}
 
-		// Get member's notes
-		notes, err := h.noteService.GetUserNotes(member.ID)
+	resp := gin.H{
+		"team_id":       teamID,
+		"team_name":     team.Name,
+		"total_folders": len(allFolders),
+		"total_notes":   len(allNotes),
+	}
+	if !query.countsOnly {
+		resp["folders"] = page(allFolders, query.offset, query.limit)
+		resp["notes"] = page(allNotes, query.offset, query.limit)
+		resp["limit"] = query.limit
+		resp["offset"] = query.offset
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// teamAssetQuery selects the assets returned by GetTeamAssets
+type teamAssetQuery struct {
+	// memberID limits the listing to one member; uuid.Nil means everyone
+	memberID uuid.UUID
+	// access is AssetAccessOwned, AssetAccessShared or empty for both
+	access     string
+	countsOnly bool
+	limit      int
+	offset     int
+}
+
+func (q teamAssetQuery) matches(access string) bool {
+	return q.access == "" || q.access == access
+}
+
+func parseTeamAssetQuery(c *gin.Context) (teamAssetQuery, error) {
+	query := teamAssetQuery{limit: defaultTeamAssetLimit}
+	fields := make(map[string]string)
+
+	if v := c.Query("member_id"); v != "" {
+		id, err := uuid.Parse(v)
 		if err != nil {
-			continue // Skip on error, don't fail the entire request
+			fields["member_id"] = "must be a UUID"
 		}
-		
-		for _, note := range notes {
-			allNotes = append(allNotes, gin.H{
-				"note":  note,
-				"owner": member,
-			})
+		query.memberID = id
+	}
+	switch v := c.Query("access"); v {
+	case "", "all":
+	case AssetAccessOwned, AssetAccessShared:
+		query.access = v
+	default:
+		fields["access"] = "must be one of: all, owned, shared"
+	}
+	if v := c.Query("counts_only"); v != "" {
+		countsOnly, err := strconv.ParseBool(v)
+		if err != nil {
+			fields["counts_only"] = "must be true or false"
+		}
+		query.countsOnly = countsOnly
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxTeamAssetLimit {
+			fields["limit"] = "must be between 1 and " + strconv.Itoa(maxTeamAssetLimit)
+		}
+		query.limit = limit
+	}
+	if v := c.Query("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			fields["offset"] = "must be a non-negative integer"
 		}
+		query.offset = offset
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"team_id": teamID,
-		"team_name": team.Name,
-		"folders": allFolders,
-		"notes":   allNotes,
-		"total_folders": len(allFolders),
-		"total_notes":   len(allNotes),
+	if len(fields) > 0 {
+		return query, apperrors.ValidationFields("Invalid team asset query", fields)
+	}
+	return query, nil
+}
+
+// teamAssetMembers returns everyone in the team, whatever their role,
+// ordered by username
+func teamAssetMembers(team *models.Team) []models.User {
+	members := team.People()
+	slices.SortFunc(members, func(a, b models.User) int {
+		if a.Username != b.Username {
+			return strings.Compare(a.Username, b.Username)
+		}
+		return strings.Compare(a.ID.String(), b.ID.String())
 	})
+	return members
+}
+
+// assetAccess tells whether an asset listed for memberID is theirs or was
+// shared with them
+func assetAccess(ownerID, memberID uuid.UUID) string {
+	if ownerID == memberID {
+		return AssetAccessOwned
+	}
+	return AssetAccessShared
+}
+
+// page returns the items in [offset, offset+limit), never nil so empty
+// pages encode as []
+func page(items []interface{}, offset, limit int) []interface{} {
+	if offset >= len(items) {
+		return []interface{}{}
+	}
+	return items[offset:min(offset+limit, len(items))]
 }
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+	"seta-training/pkg/auth"
+)
+
+// MockFolderService mocks the FolderServiceInterface methods used for team assets
+type MockFolderService struct {
+	services.FolderServiceInterface
+	mock.Mock
+}
+
+func (m *MockFolderService) GetFoldersByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]models.Folder, error) {
+	args := m.Called(userIDs)
+	return args.Get(0).(map[uuid.UUID][]models.Folder), args.Error(1)
+}
+
+// MockNoteService mocks the NoteServiceInterface methods used for team assets
+type MockNoteService struct {
+	services.NoteServiceInterface
+	mock.Mock
+}
+
+func (m *MockNoteService) GetNotesByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]models.Note, error) {
+	args := m.Called(userIDs)
+	return args.Get(0).(map[uuid.UUID][]models.Note), args.Error(1)
+}
+
+func TestAssetHandler_GetTeamAssets(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	manager := models.User{ID: uuid.New(), Username: "carol", Role: models.RoleManager}
+	alice := models.User{ID: uuid.New(), Username: "alice", Role: models.RoleMember}
+	bob := models.User{ID: uuid.New(), Username: "bob", Role: models.RoleMember}
+	team := &models.Team{ID: uuid.New(), Name: "Platform", Members: []models.User{bob, alice}, Managers: []models.User{manager}}
+
+	aliceFolder := models.Folder{ID: uuid.New(), Name: "alice's", OwnerID: alice.ID}
+	bobFolder := models.Folder{ID: uuid.New(), Name: "bob's", OwnerID: bob.ID}
+	aliceNote := models.Note{ID: uuid.New(), Title: "todo", OwnerID: alice.ID}
+
+	mockTeamService := new(MockTeamService)
+	mockFolderService := new(MockFolderService)
+	mockNoteService := new(MockNoteService)
+	mockTeamService.On("GetTeam", team.ID, (*uuid.UUID)(nil)).Return(team, nil)
+	// One batched call per asset type, for every member in username order
+	mockFolderService.On("GetFoldersByUsers", []uuid.UUID{alice.ID, bob.ID, manager.ID}).Return(map[uuid.UUID][]models.Folder{
+		alice.ID: {aliceFolder, bobFolder},
+		bob.ID:   {bobFolder},
+	}, nil)
+	mockFolderService.On("GetFoldersByUsers", []uuid.UUID{alice.ID}).Return(map[uuid.UUID][]models.Folder{
+		alice.ID: {aliceFolder, bobFolder},
+	}, nil)
+	mockNoteService.On("GetNotesByUsers", mock.Anything).Return(map[uuid.UUID][]models.Note{
+		alice.ID: {aliceNote},
+	}, nil)
+
+	h := NewAssetHandler(mockFolderService, mockNoteService, mockTeamService, nil)
+	router := gin.New()
+	router.GET("/teams/:teamId/assets", func(c *gin.Context) {
+		c.Set(middleware.ClaimsContextKey, &auth.Claims{UserID: manager.ID, Role: models.RoleManager})
+		h.GetTeamAssets(c)
+	})
+
+	get := func(t *testing.T, query string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teams/"+team.ID.String()+"/assets"+query, nil))
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+	folderNames := func(body map[string]interface{}) []string {
+		var names []string
+		for _, entry := range body["folders"].([]interface{}) {
+			folder := entry.(map[string]interface{})["folder"].(map[string]interface{})
+			names = append(names, folder["name"].(string)+"/"+entry.(map[string]interface{})["access"].(string))
+		}
+		return names
+	}
+
+	t.Run("lists every member's assets by username", func(t *testing.T) {
+		code, body := get(t, "")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"alice's/owned", "bob's/shared", "bob's/owned"}, folderNames(body))
+		assert.EqualValues(t, 3, body["total_folders"])
+		assert.EqualValues(t, 1, body["total_notes"])
+	})
+
+	t.Run("filters by member and access", func(t *testing.T) {
+		code, body := get(t, "?member_id="+alice.ID.String()+"&access=shared")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"bob's/shared"}, folderNames(body))
+		assert.EqualValues(t, 0, body["total_notes"])
+	})
+
+	t.Run("pages folders and notes", func(t *testing.T) {
+		code, body := get(t, "?limit=1&offset=1")
+		require.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"bob's/shared"}, folderNames(body))
+		assert.Empty(t, body["notes"])
+		assert.EqualValues(t, 3, body["total_folders"])
+	})
+
+	t.Run("counts only", func(t *testing.T) {
+		code, body := get(t, "?counts_only=true&access=owned")
+		require.Equal(t, http.StatusOK, code)
+		assert.NotContains(t, body, "folders")
+		assert.NotContains(t, body, "notes")
+		assert.EqualValues(t, 2, body["total_folders"])
+		assert.EqualValues(t, 1, body["total_notes"])
+	})
+
+	t.Run("rejects invalid query", func(t *testing.T) {
+		code, body := get(t, "?member_id="+uuid.NewString()+"&access=mine&limit=0")
+		require.Equal(t, http.StatusBadRequest, code)
+		assert.Contains(t, body["details"], "access")
+		assert.Contains(t, body["details"], "limit")
+
+		code, body = get(t, "?member_id="+uuid.NewString())
+		require.Equal(t, http.StatusBadRequest, code)
+		assert.Contains(t, body["details"], "member_id")
+	})
+}
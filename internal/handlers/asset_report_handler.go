@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+type AssetReportHandler struct {
+	reportService services.AssetReportServiceInterface
+}
+
+func NewAssetReportHandler(reportService services.AssetReportServiceInterface) *AssetReportHandler {
+	return &AssetReportHandler{
+		reportService: reportService,
+	}
+}
+
+// RequestReport queues the asset report of the admin's organization
+func (h *AssetReportHandler) RequestReport(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	report, err := h.reportService.RequestReport(c.Request.Context(), claims.UserID, claims.OrgID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v1/admin/reports/assets/"+report.ID.String())
+	c.JSON(http.StatusAccepted, report)
+}
+
+// GetReport reports the status of an asset report
+func (h *AssetReportHandler) GetReport(c *gin.Context) {
+	reportID, err := uuid.Parse(c.Param("reportId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid asset report ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	report, err := h.reportService.GetReport(c.Request.Context(), reportID, claims.OrgID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// DownloadReport streams the CSV of a completed asset report
+func (h *AssetReportHandler) DownloadReport(c *gin.Context) {
+	reportID, err := uuid.Parse(c.Param("reportId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid asset report ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	report, data, err := h.reportService.GetReportArtifact(c.Request.Context(), reportID, claims.OrgID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", report.FileName))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
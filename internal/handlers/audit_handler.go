@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/middleware"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// AuditHandler serves the audit log to managers
+type AuditHandler struct {
+	store audit.Store
+}
+
+func NewAuditHandler(store audit.Store) *AuditHandler {
+	return &AuditHandler{store: store}
+}
+
+// GetAuditLogs lists audit log entries, newest first, filtered by actor_id,
+// impersonator_id, target_type, target_id and a from/to date range
+func (h *AuditHandler) GetAuditLogs(c *gin.Context) {
+	filter, err := parseAuditFilter(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	logs, err := h.store.Query(filter)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries": logs,
+		"count":   len(logs),
+	})
+}
+
+func parseAuditFilter(c *gin.Context) (audit.Filter, error) {
+	filter := audit.Filter{
+		TargetType: c.Query("target_type"),
+		Limit:      defaultAuditLimit,
+	}
+	fields := make(map[string]string)
+
+	if v := c.Query("actor_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			fields["actor_id"] = "must be a UUID"
+		}
+		filter.ActorID = id
+	}
+	if v := c.Query("impersonator_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			fields["impersonator_id"] = "must be a UUID"
+		}
+		filter.ImpersonatorID = id
+	}
+	if v := c.Query("target_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			fields["target_id"] = "must be a UUID"
+		}
+		filter.TargetID = id
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := parseAuditTime(v, false)
+		if err != nil {
+			fields["from"] = "must be an RFC 3339 timestamp or a YYYY-MM-DD date"
+		}
+		filter.From = from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := parseAuditTime(v, true)
+		if err != nil {
+			fields["to"] = "must be an RFC 3339 timestamp or a YYYY-MM-DD date"
+		}
+		filter.To = to
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxAuditLimit {
+			fields["limit"] = "must be between 1 and " + strconv.Itoa(maxAuditLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if len(fields) > 0 {
+		return filter, apperrors.ValidationFields("Invalid audit log filter", fields)
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return filter, apperrors.ValidationFields("Invalid audit log filter", map[string]string{
+			"to": "must be after from",
+		})
+	}
+	return filter, nil
+}
+
+// parseAuditTime accepts a timestamp or a date. A date used as the end of a
+// range includes the whole day.
+func parseAuditTime(value string, endOfRange bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfRange {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
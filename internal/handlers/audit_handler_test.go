@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+)
+
+// MockAuditStore is a mock implementation of audit.Store
+type MockAuditStore struct {
+	mock.Mock
+}
+
+func (m *MockAuditStore) Create(logs []models.AuditLog) error {
+	args := m.Called(logs)
+	return args.Error(0)
+}
+
+func (m *MockAuditStore) Query(filter audit.Filter) ([]models.AuditLog, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.AuditLog), args.Error(1)
+}
+
+func TestAuditHandler_GetAuditLogs_Filters(t *testing.T) {
+	// Setup
+	store := new(MockAuditStore)
+	handler := NewAuditHandler(store)
+	router := gin.New()
+	router.GET("/audit-logs", handler.GetAuditLogs)
+
+	actorID, adminID := uuid.New(), uuid.New()
+	expected := audit.Filter{
+		ActorID:        actorID,
+		ImpersonatorID: adminID,
+		TargetType:     audit.TargetNote,
+		From:           time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		Limit:          10,
+	}
+	store.On("Query", expected).Return([]models.AuditLog{{ActorID: actorID, Action: audit.ActionShare}}, nil)
+
+	// Test
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet,
+		"/audit-logs?actor_id="+actorID.String()+"&impersonator_id="+adminID.String()+"&target_type=note&from=2026-10-01&to=2026-10-15&limit=10", nil))
+
+	// Assert
+	require.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Entries []models.AuditLog `json:"entries"`
+		Count   int               `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Count)
+	assert.Equal(t, audit.ActionShare, response.Entries[0].Action)
+	store.AssertExpectations(t)
+}
+
+func TestAuditHandler_GetAuditLogs_InvalidFilter(t *testing.T) {
+	// Setup
+	store := new(MockAuditStore)
+	handler := NewAuditHandler(store)
+	router := gin.New()
+	router.GET("/audit-logs", handler.GetAuditLogs)
+
+	// Test
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/audit-logs?actor_id=nope&impersonator_id=nope&from=yesterday&limit=0", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response struct {
+		Code    string            `json:"code"`
+		Details map[string]string `json:"details"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "validation_failed", response.Code)
+	assert.Contains(t, response.Details, "actor_id")
+	assert.Contains(t, response.Details, "impersonator_id")
+	assert.Contains(t, response.Details, "from")
+	assert.Contains(t, response.Details, "limit")
+	store.AssertNotCalled(t, "Query", mock.Anything)
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// ChecklistHandler serves the checklist items of notes
+type ChecklistHandler struct {
+	checklistService services.ChecklistServiceInterface
+}
+
+func NewChecklistHandler(checklistService services.ChecklistServiceInterface) *ChecklistHandler {
+	return &ChecklistHandler{
+		checklistService: checklistService,
+	}
+}
+
+// GetChecklist lists the items of a note in order
+func (h *ChecklistHandler) GetChecklist(c *gin.Context) {
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	items, err := h.checklistService.GetChecklist(c.Request.Context(), noteID, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, items)
+}
+
+// AddItem adds an item to a note's checklist
+func (h *ChecklistHandler) AddItem(c *gin.Context) {
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return
+	}
+
+	var input services.ChecklistItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	item, err := h.checklistService.AddItem(c.Request.Context(), noteID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, item)
+}
+
+// UpdateItem edits, ticks or moves a checklist item
+func (h *ChecklistHandler) UpdateItem(c *gin.Context) {
+	noteID, itemID, ok := checklistItemIDs(c)
+	if !ok {
+		return
+	}
+
+	var input services.UpdateChecklistItemInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	item, err := h.checklistService.UpdateItem(c.Request.Context(), noteID, itemID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+// DeleteItem removes a checklist item
+func (h *ChecklistHandler) DeleteItem(c *gin.Context) {
+	noteID, itemID, ok := checklistItemIDs(c)
+	if !ok {
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.checklistService.DeleteItem(c.Request.Context(), noteID, itemID, claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Checklist item deleted successfully",
+	})
+}
+
+// checklistItemIDs parses the note and item IDs of the path, responding
+// with an error when either is invalid
+func checklistItemIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	itemID, err := uuid.Parse(c.Param("itemId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid checklist item ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return noteID, itemID, true
+}
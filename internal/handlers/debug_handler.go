@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugHandler serves profiling and runtime statistics. It exposes process
+// internals, so its routes must only be mounted for admins.
+type DebugHandler struct {
+	started time.Time
+}
+
+func NewDebugHandler() *DebugHandler {
+	return &DebugHandler{
+		started: time.Now(),
+	}
+}
+
+// RuntimeStats is the body of GET /debug/runtime
+type RuntimeStats struct {
+	GoVersion     string  `json:"go_version"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	Goroutines    int     `json:"goroutines"`
+	GOMAXPROCS    int     `json:"gomaxprocs"`
+	NumCPU        int     `json:"num_cpu"`
+	HeapAlloc     uint64  `json:"heap_alloc_bytes"`
+	HeapInuse     uint64  `json:"heap_inuse_bytes"`
+	HeapObjects   uint64  `json:"heap_objects"`
+	Sys           uint64  `json:"sys_bytes"`
+	TotalAlloc    uint64  `json:"total_alloc_bytes"`
+	NumGC         uint32  `json:"num_gc"`
+	LastGC        string  `json:"last_gc,omitempty"`
+	GCPauseTotal  string  `json:"gc_pause_total"`
+	MemoryLimit   int64   `json:"memory_limit_bytes"`
+}
+
+// Runtime reports goroutine, memory and GC statistics
+func (h *DebugHandler) Runtime(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: time.Since(h.started).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		NumCPU:        runtime.NumCPU(),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapInuse:     mem.HeapInuse,
+		HeapObjects:   mem.HeapObjects,
+		Sys:           mem.Sys,
+		TotalAlloc:    mem.TotalAlloc,
+		NumGC:         mem.NumGC,
+		GCPauseTotal:  time.Duration(mem.PauseTotalNs).String(),
+		// A negative limit reads the current limit without changing it
+		MemoryLimit: debug.SetMemoryLimit(-1),
+	}
+	if mem.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC)).UTC().Format(time.RFC3339)
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// Vars serves the expvar variables, including cmdline and memstats
+func (h *DebugHandler) Vars(c *gin.Context) {
+	expvar.Handler().ServeHTTP(c.Writer, c.Request)
+}
+
+// Pprof serves net/http/pprof under /debug/pprof/*profile: the index, the
+// cmdline, CPU profile, symbol and trace endpoints and every named profile
+// such as heap or goroutine
+func (h *DebugHandler) Pprof(c *gin.Context) {
+	switch c.Param("profile") {
+	case "/cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "/profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "/symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "/trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		pprof.Index(c.Writer, c.Request)
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+func TestDebugHandler_AdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager := auth.NewJWTManager("secret", 1)
+	jwtManager.SetClaimsBuilder(auth.AdminScope([]string{"ops@example.com"}))
+	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
+
+	h := NewDebugHandler()
+	router := gin.New()
+	debug := router.Group("/debug", authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAdmin))
+	debug.GET("/runtime", h.Runtime)
+	debug.GET("/vars", h.Vars)
+	debug.GET("/pprof/*profile", h.Pprof)
+
+	admin, err := jwtManager.GenerateToken(&models.User{ID: uuid.New(), Username: "ops", Email: "OPS@example.com", Role: models.RoleMember})
+	require.NoError(t, err)
+	manager, err := jwtManager.GenerateToken(&models.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", Role: models.RoleManager})
+	require.NoError(t, err)
+
+	get := func(token, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(middleware.AuthorizationHeader, middleware.BearerPrefix+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(admin, "/debug/runtime")
+	require.Equal(t, http.StatusOK, w.Code)
+	var stats RuntimeStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Positive(t, stats.Goroutines)
+	assert.Positive(t, stats.HeapAlloc)
+
+	w = get(admin, "/debug/vars")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "memstats")
+
+	w = get(admin, "/debug/pprof/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine")
+
+	w = get(admin, "/debug/pprof/goroutine?debug=1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine profile")
+
+	assert.Equal(t, http.StatusForbidden, get(manager, "/debug/runtime").Code)
+	assert.Equal(t, http.StatusForbidden, get(manager, "/debug/pprof/heap").Code)
+}
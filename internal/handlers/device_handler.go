@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// DeviceHandler serves the devices the caller is logged in with under /me
+type DeviceHandler struct {
+	deviceService services.DeviceServiceInterface
+}
+
+func NewDeviceHandler(deviceService services.DeviceServiceInterface) *DeviceHandler {
+	return &DeviceHandler{deviceService: deviceService}
+}
+
+// GetMyDevices lists the devices the current user is logged in with
+func (h *DeviceHandler) GetMyDevices(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	devices, err := h.deviceService.GetMyDevices(c.Request.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, devices)
+}
+
+// RevokeDevice logs the current user out of one of their devices
+func (h *DeviceHandler) RevokeDevice(c *gin.Context) {
+	deviceID, err := uuid.Parse(c.Param("deviceId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid device ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.deviceService.RevokeDevice(c.Request.Context(), deviceID, claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Device revoked successfully",
+	})
+}
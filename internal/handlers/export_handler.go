@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+type ExportHandler struct {
+	exportService services.ExportServiceInterface
+}
+
+func NewExportHandler(exportService services.ExportServiceInterface) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportFolder queues an export of a folder's notes as a ZIP of markdown
+// files or a single PDF
+func (h *ExportHandler) ExportFolder(c *gin.Context) {
+	folderID, err := uuid.Parse(c.Param("folderId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
+		return
+	}
+
+	format, err := services.ParseExportFormat(c.DefaultQuery("format", "zip"))
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	job, err := h.exportService.RequestFolderExport(c.Request.Context(), folderID, claims.UserID, format)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/v1/exports/"+job.ID.String())
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetExportJob reports the status of an export job
+func (h *ExportHandler) GetExportJob(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid export job ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	job, err := h.exportService.GetExportJob(c.Request.Context(), jobID, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadExport streams the artifact of a completed export job
+func (h *ExportHandler) DownloadExport(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("jobId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid export job ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	job, data, err := h.exportService.GetExportArtifact(c.Request.Context(), jobID, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.FileName))
+	c.Data(http.StatusOK, job.ContentType, data)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+	"seta-training/pkg/featureflags"
+)
+
+// FeatureFlagHandler serves the admin feature flag routes and the features
+// enabled for the caller
+type FeatureFlagHandler struct {
+	flagService services.FeatureFlagServiceInterface
+}
+
+func NewFeatureFlagHandler(flagService services.FeatureFlagServiceInterface) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		flagService: flagService,
+	}
+}
+
+// GetFlags lists the flags stored in the database
+func (h *FeatureFlagHandler) GetFlags(c *gin.Context) {
+	flags, err := h.flagService.ListFlags(c.Request.Context())
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flags)
+}
+
+// PutFlag creates or replaces a flag
+func (h *FeatureFlagHandler) PutFlag(c *gin.Context) {
+	var input services.FeatureFlagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	flag, err := h.flagService.SaveFlag(c.Request.Context(), c.Param("name"), &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFlag removes a flag, leaving it to the config file
+func (h *FeatureFlagHandler) DeleteFlag(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.flagService.DeleteFlag(c.Request.Context(), c.Param("name"), claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Feature flag deleted successfully",
+	})
+}
+
+// GetMyFeatures returns the features that are on for the current user
+func (h *FeatureFlagHandler) GetMyFeatures(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	c.JSON(http.StatusOK, FeaturesResponse{
+		Features: h.flagService.EnabledFeatures(c.Request.Context(), featureflags.SubjectOf(claims)),
+	})
+}
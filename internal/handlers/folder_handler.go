@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/middleware"
 	"seta-training/internal/services"
 )
@@ -23,26 +27,20 @@ func NewFolderHandler(folderService services.FolderServiceInterface) *FolderHand
 func (h *FolderHandler) CreateFolder(c *gin.Context) {
 	var input services.CreateFolderInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input: " + err.Error(),
-		})
+		middleware.RespondError(c, apperrors.FromBinding(err))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	folder, err := h.folderService.CreateFolder(&input, claims.UserID)
+	folder, err := h.folderService.CreateFolder(c.Request.Context(), &input, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -54,26 +52,20 @@ func (h *FolderHandler) GetFolder(c *gin.Context) {
 	folderIDStr := c.Param("folderId")
 	folderID, err := uuid.Parse(folderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid folder ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	folder, err := h.folderService.GetFolder(folderID, claims.UserID)
+	folder, err := h.folderService.GetFolder(c.Request.Context(), folderID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -85,34 +77,26 @@ func (h *FolderHandler) UpdateFolder(c *gin.Context) {
 	folderIDStr := c.Param("folderId")
 	folderID, err := uuid.Parse(folderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid folder ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
 		return
 	}
 
 	var input services.UpdateFolderInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input: " + err.Error(),
-		})
+		middleware.RespondError(c, apperrors.FromBinding(err))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	folder, err := h.folderService.UpdateFolder(folderID, &input, claims.UserID)
+	folder, err := h.folderService.UpdateFolder(c.Request.Context(), folderID, &input, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -124,26 +108,20 @@ func (h *FolderHandler) DeleteFolder(c *gin.Context) {
 	folderIDStr := c.Param("folderId")
 	folderID, err := uuid.Parse(folderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid folder ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.folderService.DeleteFolder(folderID, claims.UserID)
+	err = h.folderService.DeleteFolder(c.Request.Context(), folderID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -152,44 +130,120 @@ func (h *FolderHandler) DeleteFolder(c *gin.Context) {
 	})
 }
 
+// DuplicateFolder copies a folder with its notes into a new folder owned by
+// the caller. Clients accepting text/event-stream get a progress event after
+// each batch of notes and then the new folder as a folder event, or an error
+// event if copying fails once the stream has started.
+func (h *FolderHandler) DuplicateFolder(c *gin.Context) {
+	folderIDStr := c.Param("folderId")
+	folderID, err := uuid.Parse(folderIDStr)
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
+		return
+	}
+
+	// The body is optional
+	var input services.DuplicateFolderInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if !strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+		folder, err := h.folderService.DuplicateFolder(c.Request.Context(), folderID, &input, claims.UserID, nil)
+		if err != nil {
+			middleware.RespondError(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, folder)
+		return
+	}
+
+	stream := &eventStream{c: c}
+	folder, err := h.folderService.DuplicateFolder(c.Request.Context(), folderID, &input, claims.UserID, func(copied, total int) {
+		stream.send("progress", gin.H{"copied": copied, "total": total})
+	})
+	switch {
+	case err != nil && !stream.started:
+		middleware.RespondError(c, err)
+	case err != nil:
+		_, resp := middleware.ErrorResponse(c, err)
+		stream.send("error", resp)
+	default:
+		stream.send("folder", folder)
+	}
+}
+
+// MergeFolder moves the notes of another folder into this one and trashes
+// the other folder
+func (h *FolderHandler) MergeFolder(c *gin.Context) {
+	folderIDStr := c.Param("folderId")
+	folderID, err := uuid.Parse(folderIDStr)
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
+		return
+	}
+
+	var input services.MergeFolderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	folder, err := h.folderService.MergeFolder(c.Request.Context(), folderID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, folder)
+}
+
 // ShareFolder shares a folder with another user
 func (h *FolderHandler) ShareFolder(c *gin.Context) {
 	folderIDStr := c.Param("folderId")
 	folderID, err := uuid.Parse(folderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid folder ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
 		return
 	}
 
 	var input services.ShareFolderInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input: " + err.Error(),
-		})
+		middleware.RespondError(c, apperrors.FromBinding(err))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.folderService.ShareFolder(folderID, &input, claims.UserID)
+	results, err := h.folderService.ShareFolder(c.Request.Context(), folderID, &input, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Folder shared successfully",
+		"results": results,
 	})
 }
 
@@ -198,35 +252,27 @@ func (h *FolderHandler) RevokeShare(c *gin.Context) {
 	folderIDStr := c.Param("folderId")
 	folderID, err := uuid.Parse(folderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid folder ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
 		return
 	}
 
 	userIDStr := c.Param("userId")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid user ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.folderService.RevokeShare(folderID, userID, claims.UserID)
+	err = h.folderService.RevokeShare(c.Request.Context(), folderID, userID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -234,3 +280,54 @@ func (h *FolderHandler) RevokeShare(c *gin.Context) {
 		"message": "Folder sharing revoked successfully",
 	})
 }
+
+// GetShares lists the users a folder is shared with, one page at a time
+func (h *FolderHandler) GetShares(c *gin.Context) {
+	folderIDStr := c.Param("folderId")
+	folderID, err := uuid.Parse(folderIDStr)
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
+		return
+	}
+
+	params, _, err := parsePageParams(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	page, err := h.folderService.ListShares(c.Request.Context(), folderID, claims.UserID, params)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	setNextCursor(c, page.Next)
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// eventStream writes Server-Sent Events, starting the response with the
+// first one so errors before it can still be answered with a status
+type eventStream struct {
+	c       *gin.Context
+	started bool
+}
+
+func (s *eventStream) send(event string, data interface{}) {
+	if !s.started {
+		s.started = true
+		s.c.Header("Cache-Control", "no-cache")
+		// Stop reverse proxies such as nginx from buffering the stream
+		s.c.Header("X-Accel-Buffering", "no")
+		s.c.Status(http.StatusOK)
+	}
+	s.c.SSEvent(event, data)
+	s.c.Writer.Flush()
+}
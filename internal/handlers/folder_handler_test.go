@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+	"seta-training/pkg/auth"
+)
+
+func (m *MockFolderService) DuplicateFolder(ctx context.Context, folderID uuid.UUID, input *services.DuplicateFolderInput, userID uuid.UUID, progress func(copied, total int)) (*models.Folder, error) {
+	args := m.Called(folderID, *input)
+	// Copy 250 notes in batches of 100
+	if progress != nil {
+		progress(100, 250)
+		progress(200, 250)
+		progress(250, 250)
+	}
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Folder), args.Error(1)
+}
+
+func TestFolderHandler_DuplicateFolder(t *testing.T) {
+	folderID := uuid.New()
+	copyID := uuid.New()
+	mockFolderService := new(MockFolderService)
+	mockFolderService.On("DuplicateFolder", folderID, services.DuplicateFolderInput{}).Return(&models.Folder{ID: copyID, Name: "Projects (copy)"}, nil)
+
+	h := NewFolderHandler(mockFolderService)
+	router := gin.New()
+	router.POST("/folders/:folderId/duplicate", func(c *gin.Context) {
+		c.Set(middleware.ClaimsContextKey, &auth.Claims{UserID: uuid.New()})
+		h.DuplicateFolder(c)
+	})
+
+	duplicate := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/folders/"+folderID.String()+"/duplicate", nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("answers with the folder", func(t *testing.T) {
+		w := duplicate("application/json")
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Contains(t, w.Body.String(), copyID.String())
+	})
+
+	t.Run("streams progress", func(t *testing.T) {
+		w := duplicate("text/event-stream")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.Equal(t, 3, strings.Count(body, "event:progress\n"))
+		assert.Contains(t, body, `data:{"copied":200,"total":250}`)
+		assert.True(t, strings.Index(body, "event:folder\n") > strings.LastIndex(body, "event:progress\n"))
+		assert.Contains(t, body, copyID.String())
+	})
+}
+
+func TestFolderHandler_DuplicateFolder_StreamErrors(t *testing.T) {
+	folderID := uuid.New()
+	mockFolderService := new(MockFolderService)
+	mockFolderService.On("DuplicateFolder", folderID, services.DuplicateFolderInput{Name: "Copy"}).Return(nil, apperrors.Internal(assert.AnError))
+
+	h := NewFolderHandler(mockFolderService)
+	router := gin.New()
+	router.POST("/folders/:folderId/duplicate", func(c *gin.Context) {
+		c.Set(middleware.ClaimsContextKey, &auth.Claims{UserID: uuid.New()})
+		h.DuplicateFolder(c)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/folders/"+folderID.String()+"/duplicate", strings.NewReader(`{"name":"Copy"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// The failure arrives after the progress events already sent
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "event:error\n")
+	assert.Contains(t, w.Body.String(), `"code":"`+string(apperrors.CodeInternal)+`"`)
+	mockFolderService.AssertExpectations(t)
+}
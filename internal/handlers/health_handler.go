@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/middleware"
+	"seta-training/pkg/logger"
+	"seta-training/pkg/metrics"
+)
+
+// checkTimeout bounds each dependency check. Checks run concurrently, so a
+// probe takes at most this long.
+const checkTimeout = 2 * time.Second
+
+// ReadinessCheck reports an error while a dependency cannot serve traffic
+type ReadinessCheck func(ctx context.Context) error
+
+// DetailedCheck is a ReadinessCheck that also describes the dependency's
+// state, e.g. connection pool statistics, shown in GET /health
+type DetailedCheck func(ctx context.Context) (details interface{}, err error)
+
+type namedCheck struct {
+	name     string
+	check    DetailedCheck
+	critical bool
+}
+
+// Overall and per-dependency health states
+const (
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+	StatusUp        = "up"
+	StatusDown      = "down"
+)
+
+// DependencyStatus is the result of one dependency check in GET /health
+type DependencyStatus struct {
+	Status    string      `json:"status"`
+	Critical  bool        `json:"critical"`
+	LatencyMs float64     `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// HealthHandler serves liveness and readiness probes. Liveness only says the
+// process is up; readiness runs every registered check and turns false as soon
+// as shutdown begins so load balancers stop routing new requests.
+type HealthHandler struct {
+	ready   atomic.Bool
+	logger  logger.Logger
+	metrics *metrics.Metrics
+
+	mu     sync.RWMutex
+	checks []namedCheck
+}
+
+func NewHealthHandler(log logger.Logger, m *metrics.Metrics) *HealthHandler {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
+	return &HealthHandler{
+		logger:  log,
+		metrics: m,
+	}
+}
+
+// AddCheck registers a dependency that must pass for the service to be ready
+func (h *HealthHandler) AddCheck(name string, check ReadinessCheck) {
+	h.addCheck(name, withoutDetails(check), true)
+}
+
+// AddDetailedCheck registers a dependency that must pass for the service to
+// be ready and reports details about its state
+func (h *HealthHandler) AddDetailedCheck(name string, check DetailedCheck) {
+	h.addCheck(name, check, true)
+}
+
+// AddOptionalCheck registers a dependency whose failure degrades the service
+// without taking it out of rotation
+func (h *HealthHandler) AddOptionalCheck(name string, check ReadinessCheck) {
+	h.addCheck(name, withoutDetails(check), false)
+}
+
+func withoutDetails(check ReadinessCheck) DetailedCheck {
+	return func(ctx context.Context) (interface{}, error) {
+		return nil, check(ctx)
+	}
+}
+
+func (h *HealthHandler) addCheck(name string, check DetailedCheck, critical bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, check: check, critical: critical})
+}
+
+// SetReady marks the service as accepting (true) or refusing (false) traffic
+func (h *HealthHandler) SetReady(ready bool) {
+	h.ready.Store(ready)
+}
+
+// Liveness reports that the process is running and able to serve HTTP
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "alive",
+	})
+}
+
+// Readiness reports whether the service should receive traffic. Only
+// critical checks can make it fail.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	if !h.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "not_ready",
+		})
+		return
+	}
+
+	statuses, overall := h.runChecks(c)
+	results := make(map[string]string, len(statuses))
+	for name, status := range statuses {
+		results[name] = "ok"
+		if status.Status == StatusDown {
+			results[name] = status.Error
+		}
+	}
+
+	code := http.StatusOK
+	if overall == StatusUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status": overall,
+		"checks": results,
+	})
+}
+
+// Health reports the status and latency of every dependency. It answers 200
+// while healthy or degraded, i.e. only optional dependencies are down, and
+// 503 when a critical dependency is down.
+func (h *HealthHandler) Health(c *gin.Context) {
+	if !h.ready.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "not_ready",
+		})
+		return
+	}
+
+	statuses, overall := h.runChecks(c)
+	code := http.StatusOK
+	if overall == StatusUnhealthy {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status": overall,
+		"checks": statuses,
+	})
+}
+
+// runChecks runs every check concurrently, each with its own timeout, and
+// returns their statuses and the overall state
+func (h *HealthHandler) runChecks(c *gin.Context) (map[string]DependencyStatus, string) {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	statuses := make([]DependencyStatus, len(checks))
+	var wg sync.WaitGroup
+	for i, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = runCheck(c.Request.Context(), nc)
+		}()
+	}
+	wg.Wait()
+
+	log := middleware.GetLogger(c, h.logger)
+	results := make(map[string]DependencyStatus, len(checks))
+	overall := StatusHealthy
+	for i, nc := range checks {
+		status := statuses[i]
+		results[nc.name] = status
+		if status.Status == StatusUp {
+			continue
+		}
+		h.metrics.RecordError(nc.name, "readiness_check")
+		if nc.critical {
+			log.Error("Readiness check failed", logger.String("check", nc.name), logger.String("error", status.Error))
+			overall = StatusUnhealthy
+		} else {
+			log.Warn("Optional dependency check failed", logger.String("check", nc.name), logger.String("error", status.Error))
+			if overall == StatusHealthy {
+				overall = StatusDegraded
+			}
+		}
+	}
+	return results, overall
+}
+
+// runCheck runs nc with checkTimeout, giving up on checks that ignore their
+// context once it expires
+func runCheck(ctx context.Context, nc namedCheck) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	type result struct {
+		details interface{}
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		details, err := nc.check(ctx)
+		done <- result{details, err}
+	}()
+
+	var res result
+	select {
+	case res = <-done:
+	case <-ctx.Done():
+		res.err = fmt.Errorf("timed out after %s", checkTimeout)
+	}
+
+	status := DependencyStatus{
+		Status:    StatusUp,
+		Critical:  nc.critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   res.details,
+	}
+	if res.err != nil {
+		status.Status = StatusDown
+		status.Error = res.err.Error()
+	}
+	return status
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupHealthRouter(h *HealthHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/healthz", h.Liveness)
+	router.GET("/readyz", h.Readiness)
+	return router
+}
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	t.Run("not ready before startup completes", func(t *testing.T) {
+		h := NewHealthHandler(nil, nil)
+		router := setupHealthRouter(h)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("ready when all checks pass", func(t *testing.T) {
+		h := NewHealthHandler(nil, nil)
+		h.AddCheck("database", func(ctx context.Context) error { return nil })
+		h.SetReady(true)
+
+		w := httptest.NewRecorder()
+		setupHealthRouter(h).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"database":"ok"`)
+	})
+
+	t.Run("not ready when a check fails", func(t *testing.T) {
+		h := NewHealthHandler(nil, nil)
+		h.AddCheck("database", func(ctx context.Context) error { return nil })
+		h.AddCheck("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+		h.SetReady(true)
+
+		w := httptest.NewRecorder()
+		setupHealthRouter(h).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), "connection refused")
+	})
+
+	t.Run("not ready once shutdown begins", func(t *testing.T) {
+		h := NewHealthHandler(nil, nil)
+		h.SetReady(true)
+		h.SetReady(false)
+
+		w := httptest.NewRecorder()
+		setupHealthRouter(h).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
+
+func TestHealthHandler_Health(t *testing.T) {
+	get := func(h *HealthHandler) (int, map[string]any) {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		router.GET("/health", h.Health)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var body map[string]any
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		return w.Code, body
+	}
+	up := func(ctx context.Context) error { return nil }
+	down := func(ctx context.Context) error { return errors.New("connection refused") }
+
+	t.Run("reports each dependency", func(t *testing.T) {
+		h := NewHealthHandler(nil, nil)
+		h.AddCheck("database", up)
+		h.AddOptionalCheck("event_bus", up)
+		h.SetReady(true)
+
+		code, body := get(h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, StatusHealthy, body["status"])
+		checks := body["checks"].(map[string]any)
+		database := checks["database"].(map[string]any)
+		assert.Equal(t, StatusUp, database["status"])
+		assert.Equal(t, true, database["critical"])
+		assert.Contains(t, database, "latency_ms")
+		assert.Equal(t, false, checks["event_bus"].(map[string]any)["critical"])
+	})
+
+	t.Run("includes details of detailed checks", func(t *testing.T) {
+		h := NewHealthHandler(nil, nil)
+		h.AddDetailedCheck("database", func(ctx context.Context) (interface{}, error) {
+			return map[string]int{"open_connections": 3}, errors.New("connected to a read-only standby")
+		})
+		h.SetReady(true)
+
+		code, body := get(h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		database := body["checks"].(map[string]any)["database"].(map[string]any)
+		assert.Equal(t, StatusDown, database["status"])
+		assert.Equal(t, "connected to a read-only standby", database["error"])
+		assert.Equal(t, map[string]any{"open_connections": float64(3)}, database["details"])
+	})
+
+	t.Run("degraded when an optional dependency is down", func(t *testing.T) {
+		h := NewHealthHandler(nil, nil)
+		h.AddCheck("database", up)
+		h.AddOptionalCheck("event_bus", down)
+		h.SetReady(true)
+
+		code, body := get(h)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, StatusDegraded, body["status"])
+		bus := body["checks"].(map[string]any)["event_bus"].(map[string]any)
+		assert.Equal(t, StatusDown, bus["status"])
+		assert.Equal(t, "connection refused", bus["error"])
+
+		// Readiness ignores optional dependencies
+		w := httptest.NewRecorder()
+		setupHealthRouter(h).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("unhealthy when a critical dependency is down", func(t *testing.T) {
+		h := NewHealthHandler(nil, nil)
+		h.AddCheck("database", down)
+		h.AddOptionalCheck("event_bus", down)
+		h.SetReady(true)
+
+		code, body := get(h)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, StatusUnhealthy, body["status"])
+	})
+}
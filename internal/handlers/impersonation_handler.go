@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+type ImpersonationHandler struct {
+	impersonationService services.ImpersonationServiceInterface
+}
+
+func NewImpersonationHandler(impersonationService services.ImpersonationServiceInterface) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationService: impersonationService,
+	}
+}
+
+// Impersonate issues the admin a short-lived token acting as a user
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid user ID"))
+		return
+	}
+
+	var input services.ImpersonateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	token, err := h.impersonationService.Impersonate(c.Request.Context(), userID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
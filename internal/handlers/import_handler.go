@@ -2,31 +2,50 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/middleware"
+	"seta-training/internal/models"
 	"seta-training/internal/services"
 	"seta-training/pkg/logger"
 	"seta-training/pkg/metrics"
+	"seta-training/pkg/upload"
 )
 
+// csvUploads accepts import files
+var csvUploads = upload.NewValidator(services.MaxImportFileSize, upload.TypeCSV)
+
 // ImportHandler handles CSV import operations
 type ImportHandler struct {
 	importService services.ImportServiceInterface
+	notifier      services.ImportNotifier
 	logger        logger.Logger
 	metrics       *metrics.Metrics
 }
 
-// NewImportHandler creates a new import handler
-func NewImportHandler(importService services.ImportServiceInterface, logger logger.Logger, metrics *metrics.Metrics) *ImportHandler {
+// NewImportHandler creates a new import handler. notifier may be nil when no
+// completion webhook is configured; a nil logger or metrics falls back to a
+// private no-op instance rather than the process globals.
+func NewImportHandler(importService services.ImportServiceInterface, notifier services.ImportNotifier, log logger.Logger, m *metrics.Metrics) *ImportHandler {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
 	return &ImportHandler{
 		importService: importService,
-		logger:        logger,
-		metrics:       metrics,
+		notifier:      notifier,
+		logger:        log,
+		metrics:       m,
 	}
 }
 
@@ -37,35 +56,38 @@ type ImportUsersRequest struct {
 	MaxRecords     int  `form:"max_records" json:"max_records"`
 	SkipDuplicates bool `form:"skip_duplicates" json:"skip_duplicates"`
 	TimeoutSeconds int  `form:"timeout_seconds" json:"timeout_seconds"`
+	// ColumnMapping is a JSON object mapping CSV headers to import fields,
+	// e.g. {"full_name":"username","mail":"email"}
+	ColumnMapping string `form:"column_mapping" json:"column_mapping"`
+	DefaultRole   string `form:"default_role" json:"default_role"`
+	// Delimiter is comma, semicolon, tab or auto, the default
+	Delimiter string `form:"delimiter" json:"delimiter"`
 }
 
 // ImportUsers handles POST /import-users endpoint
 func (h *ImportHandler) ImportUsers(c *gin.Context) {
+	log := middleware.GetLogger(c, h.logger)
 	startTime := time.Now()
-	
+
 	// Get current user from context (only managers can import users)
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
 	// Only managers can import users
 	if claims.Role != "manager" {
-		h.logger.Warn("Non-manager attempted user import",
+		log.Warn("Non-manager attempted user import",
 			logger.String("user_id", claims.UserID.String()),
 			logger.String("role", string(claims.Role)),
 		)
 		h.metrics.RecordError("authorization", "import_handler")
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Only managers can import users",
-		})
+		middleware.RespondError(c, apperrors.Forbidden("Only managers can import users"))
 		return
 	}
 
-	h.logger.Info("User import request started",
+	log.Info("User import request started",
 		logger.String("manager_id", claims.UserID.String()),
 		logger.String("client_ip", c.ClientIP()),
 	)
@@ -73,56 +95,43 @@ func (h *ImportHandler) ImportUsers(c *gin.Context) {
 	// Parse multipart form
 	err := c.Request.ParseMultipartForm(10 << 20) // 10 MB max
 	if err != nil {
-		h.logger.Error("Failed to parse multipart form", logger.Error(err))
+		log.Error("Failed to parse multipart form", logger.Error(err))
 		h.metrics.RecordError("validation", "import_handler")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to parse form data: " + err.Error(),
-		})
+		if middleware.IsBodyTooLarge(err) {
+			middleware.RespondError(c, apperrors.PayloadTooLarge("Request body too large"))
+			return
+		}
+		middleware.RespondError(c, apperrors.Wrap(apperrors.CodeValidation, err, "Failed to parse form data"))
 		return
 	}
 
 	// Get CSV file from form
 	file, header, err := c.Request.FormFile("csv_file")
 	if err != nil {
-		h.logger.Error("Failed to get CSV file from form", logger.Error(err))
+		log.Error("Failed to get CSV file from form", logger.Error(err))
 		h.metrics.RecordError("validation", "import_handler")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "CSV file is required. Please upload a file with key 'csv_file'",
-		})
+		middleware.RespondError(c, apperrors.Validation("CSV file is required. Please upload a file with key 'csv_file'"))
 		return
 	}
 	defer file.Close()
 
-	// Validate file type
-	if header.Header.Get("Content-Type") != "text/csv" && 
-	   !isCSVFile(header.Filename) {
-		h.logger.Warn("Invalid file type uploaded",
+	// Validate the file by its content; the name and Content-Type are the
+	// client's word
+	detected, err := csvUploads.Validate(file, header.Size)
+	if err != nil {
+		log.Warn("Invalid file uploaded",
 			logger.String("filename", header.Filename),
 			logger.String("content_type", header.Header.Get("Content-Type")),
-		)
-		h.metrics.RecordError("validation", "import_handler")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "File must be a CSV file (.csv extension or text/csv content type)",
-		})
-		return
-	}
-
-	// Validate file size (max 5MB)
-	const maxFileSize = 5 << 20 // 5 MB
-	if header.Size > maxFileSize {
-		h.logger.Warn("File too large",
-			logger.String("filename", header.Filename),
+			logger.String("detected_type", detected),
 			logger.Int("size_bytes", int(header.Size)),
-			logger.Int("max_size_bytes", maxFileSize),
+			logger.Error(err),
 		)
 		h.metrics.RecordError("validation", "import_handler")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("File size too large. Maximum allowed: %d MB", maxFileSize/(1<<20)),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	h.logger.Info("CSV file received",
+	log.Info("CSV file received",
 		logger.String("filename", header.Filename),
 		logger.Int("size_bytes", int(header.Size)),
 		logger.String("content_type", header.Header.Get("Content-Type")),
@@ -130,35 +139,47 @@ func (h *ImportHandler) ImportUsers(c *gin.Context) {
 
 	// Parse import configuration from form or use defaults
 	config := h.parseImportConfig(c)
-	
-	h.logger.Info("Import configuration",
+	config.OrganizationID = claims.OrgID
+	if err := h.parseColumnOptions(c, &config); err != nil {
+		log.Warn("Invalid column options", logger.Error(err))
+		h.metrics.RecordError("validation", "import_handler")
+		middleware.RespondError(c, err)
+		return
+	}
+
+	log.Info("Import configuration",
 		logger.Int("worker_count", config.WorkerCount),
 		logger.Int("batch_size", config.BatchSize),
 		logger.Int("max_records", config.MaxRecords),
 		logger.Duration("timeout", config.Timeout),
 		logger.Any("skip_duplicates", config.SkipDuplicates),
+		logger.Any("column_mapping", config.ColumnMapping),
+		logger.String("default_role", config.DefaultRole),
+		logger.String("delimiter", delimiterName(config.Delimiter)),
 	)
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+	// Create context with timeout. It keeps request values such as the
+	// request-scoped logger but is not cancelled if the client disconnects.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), config.Timeout)
 	defer cancel()
 
 	// Process CSV import
 	summary, err := h.importService.ImportUsersFromCSV(ctx, file, config)
 	if err != nil {
-		h.logger.Error("CSV import failed", logger.Error(err))
+		log.Error("CSV import failed", logger.Error(err))
 		h.metrics.RecordError("processing", "import_handler")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process CSV import: " + err.Error(),
+		h.notify(ctx, services.ImportEvent{
+			Event:     services.ImportEventFailed,
+			ManagerID: claims.UserID.String(),
+			Filename:  header.Filename,
+			Error:     err.Error(),
 		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	// Record metrics
-	h.metrics.RecordDatabaseQuery("bulk_insert", "users")
-	
 	// Log summary
-	h.logger.Info("CSV import completed",
+	log.Info("CSV import completed",
 		logger.String("manager_id", claims.UserID.String()),
 		logger.String("filename", header.Filename),
 		logger.Int("total_records", summary.TotalRecords),
@@ -168,6 +189,13 @@ func (h *ImportHandler) ImportUsers(c *gin.Context) {
 		logger.Duration("total_time", time.Since(startTime)),
 	)
 
+	h.notify(ctx, services.ImportEvent{
+		Event:     services.ImportEventCompleted,
+		ManagerID: claims.UserID.String(),
+		Filename:  header.Filename,
+		Summary:   summary,
+	})
+
 	// Return success response with summary
 	response := gin.H{
 		"message": "CSV import completed",
@@ -183,6 +211,9 @@ func (h *ImportHandler) ImportUsers(c *gin.Context) {
 			"max_records":     config.MaxRecords,
 			"timeout_seconds": int(config.Timeout.Seconds()),
 			"skip_duplicates": config.SkipDuplicates,
+			"column_mapping":  config.ColumnMapping,
+			"default_role":    config.DefaultRole,
+			"delimiter":       delimiterName(config.Delimiter),
 		},
 		"processed_by": gin.H{
 			"manager_id": claims.UserID.String(),
@@ -201,6 +232,15 @@ func (h *ImportHandler) ImportUsers(c *gin.Context) {
 	c.JSON(statusCode, response)
 }
 
+// notify reports a finished import to the configured notifier, if any
+func (h *ImportHandler) notify(ctx context.Context, event services.ImportEvent) {
+	if h.notifier == nil {
+		return
+	}
+	event.FinishedAt = time.Now().UTC()
+	h.notifier.ImportFinished(ctx, event)
+}
+
 // parseImportConfig parses import configuration from request or returns defaults
 func (h *ImportHandler) parseImportConfig(c *gin.Context) services.ImportConfig {
 	config := services.DefaultImportConfig()
@@ -241,9 +281,54 @@ func (h *ImportHandler) parseImportConfig(c *gin.Context) services.ImportConfig
 	return config
 }
 
-// isCSVFile checks if filename has CSV extension
-func isCSVFile(filename string) bool {
-	return len(filename) > 4 && filename[len(filename)-4:] == ".csv"
+// parseColumnOptions parses the optional column mapping, default role and
+// delimiter
+func (h *ImportHandler) parseColumnOptions(c *gin.Context, config *services.ImportConfig) error {
+	if mappingStr := c.PostForm("column_mapping"); mappingStr != "" {
+		var mapping map[string]string
+		if err := json.Unmarshal([]byte(mappingStr), &mapping); err != nil {
+			return apperrors.ValidationFields("Invalid column options", map[string]string{
+				"column_mapping": "must be a JSON object of CSV header to field: " + err.Error(),
+			})
+		}
+		for source, target := range mapping {
+			if !slices.Contains(services.ImportFields, strings.ToLower(strings.TrimSpace(target))) {
+				return apperrors.ValidationFields("Invalid column options", map[string]string{
+					"column_mapping": fmt.Sprintf("column %q is mapped to unknown field %q. Allowed fields: %v", source, target, services.ImportFields),
+				})
+			}
+		}
+		config.ColumnMapping = mapping
+	}
+
+	if defaultRole := strings.ToLower(strings.TrimSpace(c.PostForm("default_role"))); defaultRole != "" {
+		if defaultRole != string(models.RoleManager) && defaultRole != string(models.RoleMember) {
+			return apperrors.ValidationFields("Invalid column options", map[string]string{
+				"default_role": fmt.Sprintf("invalid default_role '%s'. Must be 'manager' or 'member'", defaultRole),
+			})
+		}
+		config.DefaultRole = defaultRole
+	}
+
+	delimiter, err := services.ParseImportDelimiter(c.PostForm("delimiter"))
+	if err != nil {
+		return apperrors.ValidationFields("Invalid column options", map[string]string{
+			"delimiter": apperrors.From(err).Message,
+		})
+	}
+	config.Delimiter = delimiter
+
+	return nil
+}
+
+// delimiterName returns the name a request gives delimiter
+func delimiterName(delimiter rune) string {
+	for name, d := range services.ImportDelimiters {
+		if d == delimiter {
+			return name
+		}
+	}
+	return "auto"
 }
 
 // GetImportTemplate returns a CSV template for user import
@@ -251,9 +336,7 @@ func (h *ImportHandler) GetImportTemplate(c *gin.Context) {
 	// Only authenticated users can download template
 	_, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
@@ -273,16 +356,12 @@ func (h *ImportHandler) GetImportStatus(c *gin.Context) {
 	// Only managers can check import status
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
 	if string(claims.Role) != "manager" {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error": "Only managers can check import status",
-		})
+		middleware.RespondError(c, apperrors.Forbidden("Only managers can check import status"))
 		return
 	}
 
@@ -290,17 +369,19 @@ func (h *ImportHandler) GetImportStatus(c *gin.Context) {
 	// This could be extended to track async import jobs
 	c.JSON(http.StatusOK, gin.H{
 		"import_capabilities": gin.H{
-			"max_file_size_mb":     5,
-			"max_records":          10000,
-			"max_workers":          20,
-			"max_timeout_seconds":  300,
-			"supported_formats":    []string{"CSV"},
-			"required_columns":     []string{"username", "email", "password", "role"},
-			"supported_roles":      []string{"manager", "member"},
+			"max_file_size_mb":    5,
+			"max_records":         10000,
+			"max_workers":         20,
+			"max_timeout_seconds": 300,
+			"supported_formats":   []string{"CSV"},
+			"required_columns":    []string{"username", "email", "password", "role"},
+			"mappable_fields":     services.ImportFields,
+			"optional_parameters": []string{"column_mapping", "default_role"},
+			"supported_roles":     []string{"manager", "member"},
 		},
 		"current_limits": gin.H{
 			"concurrent_imports": 1, // Currently synchronous
-			"queue_size":        0,
+			"queue_size":         0,
 		},
 	})
 }
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/upload"
+)
+
+// MockImportService records the content of each imported file
+type MockImportService struct {
+	mock.Mock
+}
+
+func (m *MockImportService) ImportUsersFromCSV(ctx context.Context, csvReader io.Reader, config services.ImportConfig) (*services.ImportSummary, error) {
+	data, err := io.ReadAll(csvReader)
+	if err != nil {
+		return nil, err
+	}
+	args := m.Called(string(data))
+	return args.Get(0).(*services.ImportSummary), args.Error(1)
+}
+
+func TestImportHandler_ImportUsers_SniffsContent(t *testing.T) {
+	csvData := "\ufeffusername,email,password,role\nalice,alice@example.com,password123,member\n"
+
+	mockImportService := new(MockImportService)
+	mockImportService.On("ImportUsersFromCSV", csvData).Return(&services.ImportSummary{TotalRecords: 1, SuccessCount: 1}, nil)
+
+	h := NewImportHandler(mockImportService, nil, nil, nil)
+	router := gin.New()
+	router.POST("/import-users", func(c *gin.Context) {
+		c.Set(middleware.ClaimsContextKey, &auth.Claims{UserID: uuid.New(), Role: models.RoleManager})
+		h.ImportUsers(c)
+	})
+
+	post := func(t *testing.T, content []byte) (int, apperrors.Response) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreatePart(textproto.MIMEHeader{
+			"Content-Disposition": {`form-data; name="csv_file"; filename="users.csv"`},
+			"Content-Type":        {"text/csv"},
+		})
+		require.NoError(t, err)
+		_, err = part.Write(content)
+		require.NoError(t, err)
+		require.NoError(t, form.Close())
+
+		req := httptest.NewRequest(http.MethodPost, "/import-users", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var resp apperrors.Response
+		if w.Code >= http.StatusBadRequest {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		}
+		return w.Code, resp
+	}
+
+	t.Run("imports text from the start of the file", func(t *testing.T) {
+		code, _ := post(t, []byte(csvData))
+		assert.Equal(t, http.StatusOK, code)
+		mockImportService.AssertCalled(t, "ImportUsersFromCSV", csvData)
+	})
+
+	rejected := map[string][]byte{
+		"executable":         append([]byte("\x7fELF\x02\x01\x01\x00"), make([]byte, 64)...),
+		"archive":            zipOf(t, map[string]string{"users.csv": csvData}),
+		"PNG":                []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		"binary behind text": append([]byte(strings.Repeat(csvData, 20)), 0x00, 0x01, 0x02),
+		"invalid UTF-8":      []byte("username,email\n\xff\xfe\xfd,alice@example.com\n"),
+		"UTF-16 with a BOM":  []byte("\xff\xfeu\x00s\x00e\x00r\x00"),
+	}
+	for name, content := range rejected {
+		t.Run("rejects "+name, func(t *testing.T) {
+			code, resp := post(t, content)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Equal(t, apperrors.CodeValidation, resp.Code)
+		})
+	}
+
+	t.Run("rejects files over the limit", func(t *testing.T) {
+		code, resp := post(t, bytes.Repeat([]byte("a"), services.MaxImportFileSize+1))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+		assert.Equal(t, apperrors.CodePayloadTooLarge, resp.Code)
+	})
+
+	mockImportService.AssertNumberOfCalls(t, "ImportUsersFromCSV", 1)
+}
+
+func TestUploadValidator_RejectsArchiveBombs(t *testing.T) {
+	validator := upload.NewValidator(10<<20, upload.TypeZIP)
+
+	small := zipOf(t, map[string]string{"notes.txt": "hello"})
+	detected, err := validator.Validate(bytes.NewReader(small), int64(len(small)))
+	require.NoError(t, err)
+	assert.Equal(t, upload.TypeZIP, detected)
+
+	bomb := zipOf(t, map[string]string{"zeros.txt": strings.Repeat("0", 50<<20)})
+	_, err = validator.Validate(bytes.NewReader(bomb), int64(len(bomb)))
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+	assert.ErrorIs(t, err, upload.ErrArchiveBomb)
+
+	entries := make(map[string]string, upload.MaxArchiveEntries+1)
+	for i := 0; i <= upload.MaxArchiveEntries; i++ {
+		entries[uuid.NewString()] = ""
+	}
+	many := zipOf(t, entries)
+	_, err = validator.Validate(bytes.NewReader(many), int64(len(many)))
+	assert.ErrorIs(t, err, upload.ErrArchiveBomb)
+}
+
+func zipOf(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		require.NoError(t, err)
+		_, err = io.WriteString(f, content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/pkg/auth"
+)
+
+// JWKSProvider returns the public keys tokens are signed with
+type JWKSProvider interface {
+	JWKS() auth.JWKS
+}
+
+// JWKSHandler publishes the token signing keys so other services can verify
+// tokens without sharing a secret
+type JWKSHandler struct {
+	provider JWKSProvider
+}
+
+func NewJWKSHandler(provider JWKSProvider) *JWKSHandler {
+	return &JWKSHandler{
+		provider: provider,
+	}
+}
+
+// Keys serves the JSON Web Key Set. Clients may cache it briefly; after a
+// rotation they should refetch when they see an unknown kid.
+func (h *JWKSHandler) Keys(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.provider.JWKS())
+}
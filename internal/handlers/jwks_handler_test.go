@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+func writeKey(t *testing.T, dir, id string, key interface{}) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600))
+}
+
+func fetchJWKS(t *testing.T, provider JWKSProvider) auth.JWKS {
+	router := gin.New()
+	router.GET("/.well-known/jwks.json", NewJWKSHandler(provider).Keys)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Cache-Control"), "max-age")
+
+	var doc auth.JWKS
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	return doc
+}
+
+func TestJWKSHandler_KeyRotation(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeKey(t, dir, "2026-09", rsaKey)
+
+	keys, err := auth.LoadKeySet(dir, "", "RS256")
+	require.NoError(t, err)
+	manager := auth.NewJWTManagerWithKeys("secret", 1, keys)
+	user := &models.User{ID: uuid.New(), Username: "alice", Role: models.RoleManager}
+
+	oldToken, err := manager.GenerateToken(user)
+	require.NoError(t, err)
+
+	// Rotate: add a new key and make it active
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	writeKey(t, dir, "2026-10", ecKey)
+	keys, err = auth.LoadKeySet(dir, "2026-10", "ES256")
+	require.NoError(t, err)
+	manager.SetKeys(keys)
+
+	newToken, err := manager.GenerateToken(user)
+	require.NoError(t, err)
+
+	for _, token := range []string{oldToken, newToken} {
+		claims, err := manager.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, claims.UserID)
+	}
+
+	doc := fetchJWKS(t, manager)
+	require.Len(t, doc.Keys, 2)
+	assert.Equal(t, "2026-09", doc.Keys[0].KeyID)
+	assert.Equal(t, "RS256", doc.Keys[0].Algorithm)
+	assert.Equal(t, "RSA", doc.Keys[0].KeyType)
+	assert.Equal(t, "2026-10", doc.Keys[1].KeyID)
+	assert.Equal(t, "ES256", doc.Keys[1].Algorithm)
+	assert.Equal(t, "P-256", doc.Keys[1].Curve)
+
+	// Retire the old key: its tokens stop validating
+	require.NoError(t, os.Remove(filepath.Join(dir, "2026-09.pem")))
+	keys, err = auth.LoadKeySet(dir, "2026-10", "ES256")
+	require.NoError(t, err)
+	manager.SetKeys(keys)
+
+	_, err = manager.ValidateToken(oldToken)
+	assert.Error(t, err)
+	_, err = manager.ValidateToken(newToken)
+	assert.NoError(t, err)
+}
+
+func TestJWKSHandler_SharedSecret(t *testing.T) {
+	manager := auth.NewJWTManager("secret", 1)
+
+	doc := fetchJWKS(t, manager)
+	assert.Empty(t, doc.Keys)
+}
+
+func TestJWKSHandler_PublicKeyVerification(t *testing.T) {
+	dir := t.TempDir()
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	writeKey(t, dir, "ed-1", edKey)
+
+	rsaDir := t.TempDir()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	writeKey(t, rsaDir, "rsa-1", rsaKey)
+	_, err = auth.LoadKeySet(rsaDir, "", "EdDSA")
+	assert.Error(t, err, "an RSA key cannot sign EdDSA")
+
+	keys, err := auth.LoadKeySet(dir, "", "EdDSA")
+	require.NoError(t, err)
+	manager := auth.NewJWTManagerWithKeys("", 1, keys)
+	user := &models.User{ID: uuid.New(), Username: "alice", Role: models.RoleMember}
+	token, err := manager.GenerateToken(user)
+	require.NoError(t, err)
+
+	// A downstream service verifies with the published public key only
+	doc := fetchJWKS(t, manager)
+	require.Len(t, doc.Keys, 1)
+	assert.Equal(t, "EdDSA", doc.Keys[0].Algorithm)
+	public, err := base64.RawURLEncoding.DecodeString(doc.Keys[0].X)
+	require.NoError(t, err)
+
+	claims := &auth.Claims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		assert.Equal(t, "ed-1", token.Header["kid"])
+		return ed25519.PublicKey(public), nil
+	}, jwt.WithValidMethods([]string{"EdDSA"}))
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+
+	// Without a secret, HS256 tokens are not accepted
+	hsToken, err := auth.NewJWTManager("secret", 1).GenerateToken(user)
+	require.NoError(t, err)
+	_, err = manager.ValidateToken(hsToken)
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// LDAPHandler serves the admin endpoints that configure and run the LDAP
+// sync of organizations
+type LDAPHandler struct {
+	ldapService services.LDAPSyncServiceInterface
+}
+
+func NewLDAPHandler(ldapService services.LDAPSyncServiceInterface) *LDAPHandler {
+	return &LDAPHandler{ldapService: ldapService}
+}
+
+// GetLDAPConnection returns the LDAP connection of an organization
+func (h *LDAPHandler) GetLDAPConnection(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	conn, err := h.ldapService.GetConnection(c.Request.Context(), orgID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conn)
+}
+
+// PutLDAPConnection sets up or replaces the LDAP connection of an
+// organization
+func (h *LDAPHandler) PutLDAPConnection(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	var input services.LDAPConnectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	conn, err := h.ldapService.SaveConnection(c.Request.Context(), orgID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conn)
+}
+
+// SyncLDAP syncs an organization with its directory and returns the
+// report, or with ?dry_run=true only reports what would change
+func (h *LDAPHandler) SyncLDAP(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	dryRun := false
+	if v := c.Query("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			middleware.RespondError(c, apperrors.ValidationFields("Invalid sync", map[string]string{
+				"dry_run": "must be true or false",
+			}))
+			return
+		}
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	report, err := h.ldapService.Sync(c.Request.Context(), orgID, dryRun, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
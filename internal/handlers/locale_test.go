@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/pkg/i18n"
+)
+
+func newLocaleRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	bundle, err := i18n.NewBundle()
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(middleware.RequestID(nil), middleware.Locale(bundle))
+	router.POST("/teams", func(c *gin.Context) {
+		var input struct {
+			TeamName string `json:"teamName" binding:"required,min=3"`
+		}
+		if err := c.ShouldBindJSON(&input); err != nil {
+			middleware.RespondError(c, apperrors.FromBinding(err))
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+	router.GET("/teams/:teamId", func(c *gin.Context) {
+		middleware.RespondError(c, apperrors.NotFound("team not found"))
+	})
+	router.GET("/legacy", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+	})
+	return router
+}
+
+func TestLocale_TranslatesErrorsForAcceptLanguage(t *testing.T) {
+	apperrors.UseJSONFieldNames()
+	router := newLocaleRouter(t)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		acceptLanguage string
+		wantLanguage   string
+		wantMessage    string
+		wantDetails    map[string]interface{}
+	}{
+		{
+			name:           "validation in Vietnamese",
+			method:         http.MethodPost,
+			path:           "/teams",
+			body:           `{"teamName":"ab"}`,
+			acceptLanguage: "vi-VN,vi;q=0.9,en;q=0.8",
+			wantLanguage:   "vi",
+			wantMessage:    "Dữ liệu không hợp lệ",
+			wantDetails:    map[string]interface{}{"teamName": "phải có ít nhất 3 ký tự"},
+		},
+		{
+			name:         "validation in English by default",
+			method:       http.MethodPost,
+			path:         "/teams",
+			body:         `{}`,
+			wantLanguage: "en",
+			wantMessage:  "Invalid input",
+			wantDetails:  map[string]interface{}{"teamName": "is required"},
+		},
+		{
+			name:           "preferred language wins by quality",
+			method:         http.MethodGet,
+			path:           "/teams/1",
+			acceptLanguage: "vi;q=0.5,en;q=0.9",
+			wantLanguage:   "en",
+			wantMessage:    "team not found",
+		},
+		{
+			name:           "unsupported language falls back to English",
+			method:         http.MethodGet,
+			path:           "/teams/1",
+			acceptLanguage: "fr-FR",
+			wantLanguage:   "en",
+			wantMessage:    "team not found",
+		},
+		{
+			name:           "error message in Vietnamese",
+			method:         http.MethodGet,
+			path:           "/teams/1",
+			acceptLanguage: "vi",
+			wantLanguage:   "vi",
+			wantMessage:    "không tìm thấy nhóm",
+		},
+		{
+			name:           "legacy error body in Vietnamese",
+			method:         http.MethodGet,
+			path:           "/legacy",
+			acceptLanguage: "vi",
+			wantLanguage:   "vi",
+			wantMessage:    "ID nhóm không hợp lệ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.acceptLanguage != "" {
+				req.Header.Set(middleware.AcceptLanguageHeader, tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantLanguage, w.Header().Get(middleware.ContentLanguageHeader))
+			var resp apperrors.Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.wantMessage, resp.Message)
+			assert.Equal(t, tt.wantDetails, resp.Details)
+		})
+	}
+}
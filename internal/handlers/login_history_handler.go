@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// LoginHistoryHandler serves the login attempts of the caller and of the
+// people of the teams they manage
+type LoginHistoryHandler struct {
+	loginHistoryService services.LoginHistoryServiceInterface
+}
+
+func NewLoginHistoryHandler(loginHistoryService services.LoginHistoryServiceInterface) *LoginHistoryHandler {
+	return &LoginHistoryHandler{loginHistoryService: loginHistoryService}
+}
+
+// GetMyLoginHistory lists the latest attempts to log in as the current user
+func (h *LoginHistoryHandler) GetMyLoginHistory(c *gin.Context) {
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	attempts, err := h.loginHistoryService.GetMyHistory(c.Request.Context(), claims.UserID, limit)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
+
+// GetTeamLoginHistory lists the latest attempts to log in as the people of a
+// team, optionally only as one of them
+func (h *LoginHistoryHandler) GetTeamLoginHistory(c *gin.Context) {
+	teamID, err := uuid.Parse(c.Param("teamId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid team ID"))
+		return
+	}
+	var memberID *uuid.UUID
+	if raw := c.Query("member_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			middleware.RespondError(c, apperrors.Validation("Invalid member ID"))
+			return
+		}
+		memberID = &id
+	}
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	attempts, err := h.loginHistoryService.GetTeamHistory(c.Request.Context(), teamID, memberID, limit, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+)
+
+// TestMain configures gin the way the server does. The validator caches
+// field names per struct, so this must happen before any request is bound.
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	apperrors.UseJSONFieldNames()
+	os.Exit(m.Run())
+}
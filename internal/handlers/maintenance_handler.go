@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/middleware"
+)
+
+// MaintenanceHandler lets admins read and change the maintenance mode
+type MaintenanceHandler struct {
+	maintenance *middleware.Maintenance
+	audit       audit.Recorder
+}
+
+// NewMaintenanceHandler creates a maintenance handler. auditor may be nil.
+func NewMaintenanceHandler(maintenance *middleware.Maintenance, auditor audit.Recorder) *MaintenanceHandler {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	return &MaintenanceHandler{
+		maintenance: maintenance,
+		audit:       auditor,
+	}
+}
+
+// MaintenanceInput sets the maintenance mode. RetryAfterSeconds defaults to
+// the one in effect.
+type MaintenanceInput struct {
+	Mode              string `json:"mode" binding:"required,oneof=off read_only full"`
+	RetryAfterSeconds int    `json:"retry_after_seconds" binding:"omitempty,min=1"`
+}
+
+// MaintenanceResponse is the mode in effect and whether an admin set it,
+// overriding the configuration
+type MaintenanceResponse struct {
+	middleware.MaintenanceState
+	Overridden bool `json:"overridden"`
+}
+
+// GetMaintenance returns the maintenance mode in effect
+func (h *MaintenanceHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, h.response(c))
+}
+
+// PutMaintenance sets the maintenance mode on every instance sharing the
+// store, overriding the configuration
+func (h *MaintenanceHandler) PutMaintenance(c *gin.Context) {
+	var input MaintenanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	state := middleware.MaintenanceState{
+		Mode:              middleware.MaintenanceMode(input.Mode),
+		RetryAfterSeconds: input.RetryAfterSeconds,
+	}
+	if state.RetryAfterSeconds == 0 {
+		state.RetryAfterSeconds = h.maintenance.State(ctx).RetryAfterSeconds
+	}
+	if err := h.maintenance.Set(ctx, state); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+	h.audit.Record(c.Request.Context(), audit.Entry{
+		ActorID:    claims.UserID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetMaintenance,
+		Details: map[string]string{
+			"mode":                input.Mode,
+			"retry_after_seconds": strconv.Itoa(state.RetryAfterSeconds),
+		},
+	})
+
+	c.JSON(http.StatusOK, h.response(c))
+}
+
+// DeleteMaintenance removes the mode an admin set, so the configured one
+// applies again
+func (h *MaintenanceHandler) DeleteMaintenance(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.maintenance.Clear(c.Request.Context()); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+	h.audit.Record(c.Request.Context(), audit.Entry{
+		ActorID:    claims.UserID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetMaintenance,
+	})
+
+	c.JSON(http.StatusOK, h.response(c))
+}
+
+func (h *MaintenanceHandler) response(c *gin.Context) MaintenanceResponse {
+	ctx := c.Request.Context()
+	return MaintenanceResponse{
+		MaintenanceState: h.maintenance.State(ctx),
+		Overridden:       h.maintenance.Overridden(ctx),
+	}
+}
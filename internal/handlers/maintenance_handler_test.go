@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/config"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+func TestMaintenanceHandler(t *testing.T) {
+	maintenance := middleware.NewMaintenance(config.MaintenanceConfig{
+		Mode:              "off",
+		RetryAfterSeconds: 300,
+		ExemptPaths:       []string{"/healthz", "/api/v1/admin/"},
+	}, nil, nil)
+	handler := NewMaintenanceHandler(maintenance, nil)
+
+	router := gin.New()
+	router.Use(maintenance.Middleware("/graphql"))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/healthz", ok)
+	router.GET("/api/v1/notes", ok)
+	router.POST("/api/v1/notes", ok)
+	router.POST("/graphql", ok)
+	admin := router.Group("/api/v1/admin/maintenance", func(c *gin.Context) {
+		c.Set(middleware.ClaimsContextKey, &auth.Claims{UserID: uuid.New(), Role: models.RoleManager})
+	})
+	admin.GET("", handler.GetMaintenance)
+	admin.PUT("", handler.PutMaintenance)
+	admin.DELETE("", handler.DeleteMaintenance)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	state := func(w *httptest.ResponseRecorder) MaintenanceResponse {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp MaintenanceResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	assertUnavailable := func(t *testing.T, w *httptest.ResponseRecorder, retryAfter string) {
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, retryAfter, w.Header().Get("Retry-After"))
+		var resp apperrors.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, apperrors.CodeUnavailable, resp.Code)
+	}
+	query := `{"query": "{ users { id } }"}`
+	login := `{"query": "mutation { login(input: {email: \"a@example.com\", password: \"x\"}) { token } }"}`
+	mutation := `{"query": "mutation { createUser(input: {}) { id } }"}`
+
+	t.Run("off serves everything", func(t *testing.T) {
+		assert.Equal(t, MaintenanceResponse{MaintenanceState: middleware.MaintenanceState{Mode: middleware.MaintenanceOff, RetryAfterSeconds: 300}},
+			state(do(http.MethodGet, "/api/v1/admin/maintenance", "")))
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/notes", "").Code)
+	})
+
+	t.Run("rejects unknown modes", func(t *testing.T) {
+		w := do(http.MethodPut, "/api/v1/admin/maintenance", `{"mode": "partial"}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("read-only rejects writes", func(t *testing.T) {
+		resp := state(do(http.MethodPut, "/api/v1/admin/maintenance", `{"mode": "read_only"}`))
+		assert.Equal(t, MaintenanceResponse{MaintenanceState: middleware.MaintenanceState{Mode: middleware.MaintenanceReadOnly, RetryAfterSeconds: 300}, Overridden: true}, resp)
+
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/notes", "").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/graphql", query).Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/graphql", login).Code)
+		assertUnavailable(t, do(http.MethodPost, "/api/v1/notes", ""), "300")
+		assertUnavailable(t, do(http.MethodPost, "/graphql", mutation), "300")
+		assertUnavailable(t, do(http.MethodPost, "/graphql", "not json"), "300")
+	})
+
+	t.Run("full rejects everything but exempt paths", func(t *testing.T) {
+		state(do(http.MethodPut, "/api/v1/admin/maintenance", `{"mode": "full", "retry_after_seconds": 60}`))
+
+		assertUnavailable(t, do(http.MethodGet, "/api/v1/notes", ""), "60")
+		assertUnavailable(t, do(http.MethodPost, "/graphql", login), "60")
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/healthz", "").Code)
+	})
+
+	t.Run("deleting returns to the configured mode", func(t *testing.T) {
+		resp := state(do(http.MethodDelete, "/api/v1/admin/maintenance", ""))
+		assert.Equal(t, middleware.MaintenanceOff, resp.Mode)
+		assert.False(t, resp.Overridden)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/notes", "").Code)
+
+		maintenance.SetConfig(config.MaintenanceConfig{Mode: "read_only", RetryAfterSeconds: 30, ExemptPaths: []string{"/healthz"}})
+		assertUnavailable(t, do(http.MethodPost, "/api/v1/notes", ""), "30")
+	})
+}
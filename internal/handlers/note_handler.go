@@ -2,13 +2,20 @@ package handlers
 
 import (
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/middleware"
+	"seta-training/internal/models"
 	"seta-training/internal/services"
 )
 
+// noStatus selects notes outside the publishing workflow in a status filter
+const noStatus = "none"
+
 type NoteHandler struct {
 	noteService services.NoteServiceInterface
 }
@@ -24,34 +31,26 @@ func (h *NoteHandler) CreateNote(c *gin.Context) {
 	folderIDStr := c.Param("folderId")
 	folderID, err := uuid.Parse(folderIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid folder ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid folder ID"))
 		return
 	}
 
 	var input services.CreateNoteInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input: " + err.Error(),
-		})
+		middleware.RespondError(c, apperrors.FromBinding(err))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	note, err := h.noteService.CreateNote(folderID, &input, claims.UserID)
+	note, err := h.noteService.CreateNote(c.Request.Context(), folderID, &input, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -63,26 +62,20 @@ func (h *NoteHandler) GetNote(c *gin.Context) {
 	noteIDStr := c.Param("noteId")
 	noteID, err := uuid.Parse(noteIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid note ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	note, err := h.noteService.GetNote(noteID, claims.UserID)
+	note, err := h.noteService.GetNote(c.Request.Context(), noteID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -94,65 +87,105 @@ func (h *NoteHandler) UpdateNote(c *gin.Context) {
 	noteIDStr := c.Param("noteId")
 	noteID, err := uuid.Parse(noteIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid note ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
 		return
 	}
 
 	var input services.UpdateNoteInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input: " + err.Error(),
-		})
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	note, err := h.noteService.UpdateNote(c.Request.Context(), noteID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, note)
+}
+
+// ChangeStatus moves a note along the publishing workflow
+func (h *NoteHandler) ChangeStatus(c *gin.Context) {
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return
+	}
+
+	var input services.NoteStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	note, err := h.noteService.UpdateNote(noteID, &input, claims.UserID)
+	note, err := h.noteService.ChangeStatus(c.Request.Context(), noteID, &input, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, note)
 }
 
+// GetBacklinks lists the notes linking to a note
+func (h *NoteHandler) GetBacklinks(c *gin.Context) {
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	backlinks, err := h.noteService.GetBacklinks(c.Request.Context(), noteID, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, backlinks)
+}
+
 // DeleteNote deletes a note
 func (h *NoteHandler) DeleteNote(c *gin.Context) {
 	noteIDStr := c.Param("noteId")
 	noteID, err := uuid.Parse(noteIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid note ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.noteService.DeleteNote(noteID, claims.UserID)
+	err = h.noteService.DeleteNote(c.Request.Context(), noteID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -166,39 +199,32 @@ func (h *NoteHandler) ShareNote(c *gin.Context) {
 	noteIDStr := c.Param("noteId")
 	noteID, err := uuid.Parse(noteIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid note ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
 		return
 	}
 
 	var input services.ShareNoteInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input: " + err.Error(),
-		})
+		middleware.RespondError(c, apperrors.FromBinding(err))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.noteService.ShareNote(noteID, &input, claims.UserID)
+	results, err := h.noteService.ShareNote(c.Request.Context(), noteID, &input, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Note shared successfully",
+		"results": results,
 	})
 }
 
@@ -207,35 +233,27 @@ func (h *NoteHandler) RevokeShare(c *gin.Context) {
 	noteIDStr := c.Param("noteId")
 	noteID, err := uuid.Parse(noteIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid note ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
 		return
 	}
 
 	userIDStr := c.Param("userId")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid user ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid user ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.noteService.RevokeShare(noteID, userID, claims.UserID)
+	err = h.noteService.RevokeShare(c.Request.Context(), noteID, userID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
@@ -243,3 +261,96 @@ func (h *NoteHandler) RevokeShare(c *gin.Context) {
 		"message": "Note sharing revoked successfully",
 	})
 }
+
+// GetShares lists the users a note is shared with, one page at a time
+func (h *NoteHandler) GetShares(c *gin.Context) {
+	noteIDStr := c.Param("noteId")
+	noteID, err := uuid.Parse(noteIDStr)
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return
+	}
+
+	params, _, err := parsePageParams(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	page, err := h.noteService.ListShares(c.Request.Context(), noteID, claims.UserID, params)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	setNextCursor(c, page.Next)
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// GetMyNotes lists the notes the current user owns, one page at a time.
+// ?status= narrows them to a comma-separated list of statuses.
+func (h *NoteHandler) GetMyNotes(c *gin.Context) {
+	params, _, err := parsePageParams(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+	filter, err := parseNoteFilter(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	page, err := h.noteService.ListOwnedNotes(c.Request.Context(), claims.UserID, filter, params)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	setNextCursor(c, page.Next)
+	c.JSON(http.StatusOK, page.Items)
+}
+
+// parseNoteFilter reads ?status=, where "none" stands for notes outside the
+// workflow
+func parseNoteFilter(c *gin.Context) (models.NoteFilter, error) {
+	var filter models.NoteFilter
+	v := c.Query("status")
+	if v == "" {
+		return filter, nil
+	}
+	for _, name := range strings.Split(v, ",") {
+		name = strings.TrimSpace(name)
+		status := models.NoteStatus(name)
+		if name == noStatus {
+			status = ""
+		} else if !status.Valid() {
+			allowed := make([]string, 0, len(models.NoteStatuses)+1)
+			for _, s := range models.NoteStatuses {
+				allowed = append(allowed, string(s))
+			}
+			allowed = append(allowed, noStatus)
+			return filter, apperrors.ValidationFields("Invalid filter", map[string]string{
+				"status": "must be a comma-separated list of: " + strings.Join(allowed, ", "),
+			})
+		}
+		if !slices.Contains(filter.Statuses, status) {
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+	return filter, nil
+}
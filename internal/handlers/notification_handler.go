@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// NotificationHandler serves the caller's notifications and mentions under /me
+type NotificationHandler struct {
+	notificationService services.NotificationServiceInterface
+	mentionService      services.MentionServiceInterface
+}
+
+func NewNotificationHandler(notificationService services.NotificationServiceInterface, mentionService services.MentionServiceInterface) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		mentionService:      mentionService,
+	}
+}
+
+// GetMyMentions lists notes where the current user has been mentioned
+func (h *NotificationHandler) GetMyMentions(c *gin.Context) {
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	mentions, err := h.mentionService.GetUserMentions(c.Request.Context(), claims.UserID, limit)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, mentions)
+}
+
+// GetMyNotifications lists the current user's notifications, optionally only unread ones
+func (h *NotificationHandler) GetMyNotifications(c *gin.Context) {
+	limit, ok := parseLimit(c)
+	if !ok {
+		return
+	}
+	unreadOnly := c.Query("unread") == "true"
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	notifications, err := h.notificationService.GetUserNotifications(c.Request.Context(), claims.UserID, unreadOnly, limit)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// MarkNotificationRead marks one of the current user's notifications as read
+func (h *NotificationHandler) MarkNotificationRead(c *gin.Context) {
+	notificationID, err := uuid.Parse(c.Param("notificationId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid notification ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), notificationID, claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Notification marked as read",
+	})
+}
+
+// parseLimit reads an optional positive ?limit= query parameter, writing a
+// 400 response and returning false when it is malformed
+func parseLimit(c *gin.Context) (int, bool) {
+	limitStr := c.Query("limit")
+	if limitStr == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		middleware.RespondError(c, apperrors.Validation("Invalid limit"))
+		return 0, false
+	}
+	return limit, true
+}
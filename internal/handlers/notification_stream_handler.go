@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/realtime"
+)
+
+// NotificationStreamHandler streams the caller's notifications as they
+// happen, over WebSocket or Server-Sent Events
+type NotificationStreamHandler struct {
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+}
+
+// NewNotificationStreamHandler creates a handler accepting browser
+// connections from the page's own host and from allowedOrigins, which may
+// contain "*"
+func NewNotificationStreamHandler(hub *realtime.Hub, allowedOrigins []string) *NotificationStreamHandler {
+	return &NotificationStreamHandler{
+		hub: hub,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				return originAllowed(r, allowedOrigins)
+			},
+		},
+	}
+}
+
+// Stream serves /ws/notifications until the client disconnects
+func (h *NotificationStreamHandler) Stream(c *gin.Context) {
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	// Failed handshakes are answered with the usual error envelope
+	upgrader := h.upgrader
+	upgrader.Error = func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		switch status {
+		case http.StatusForbidden:
+			middleware.RespondError(c, apperrors.Forbidden("Origin not allowed"))
+		case http.StatusInternalServerError:
+			middleware.RespondError(c, apperrors.Internal(reason))
+		default:
+			middleware.RespondError(c, apperrors.Validation("A WebSocket handshake is required"))
+		}
+	}
+	ws, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	h.hub.Serve(ws, claims.UserID)
+}
+
+// ActivityStream serves /me/activity/stream as Server-Sent Events, for
+// clients such as dashboards that only listen. Each message is an event
+// named after its type with the message as JSON data; comments keep idle
+// connections open. ?types= narrows the stream to some message types.
+func (h *NotificationStreamHandler) ActivityStream(c *gin.Context) {
+	types := realtime.MessageTypes
+	if param := c.Query("types"); param != "" {
+		types = strings.Split(param, ",")
+		if !realtime.ValidTypes(types) {
+			middleware.RespondError(c, apperrors.ValidationFields("Invalid stream types", map[string]string{
+				"types": "must be a comma-separated list of: " + strings.Join(realtime.MessageTypes, ", "),
+			}))
+			return
+		}
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	listener, err := h.hub.Listen(claims.UserID, "sse", types)
+	switch {
+	case errors.Is(err, realtime.ErrTooManyListeners):
+		middleware.RespondError(c, apperrors.RateLimited("Too many open notification streams"))
+		return
+	case err != nil:
+		middleware.RespondError(c, apperrors.Unavailable("Server is shutting down"))
+		return
+	}
+	defer h.hub.Unlisten(listener)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	// Stop reverse proxies such as nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.hub.PingInterval())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case msg := <-listener.Messages():
+			c.SSEvent(msg.Type, msg)
+		case <-heartbeat.C:
+			_, _ = c.Writer.WriteString(": ping\n\n")
+		case <-listener.Done():
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// originAllowed accepts clients that send no Origin, such as other
+// services, pages of the API's own host and the allowed origins
+func originAllowed(r *http.Request, allowedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || slices.Contains(allowedOrigins, "*") || slices.Contains(allowedOrigins, origin) {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/audit"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/internal/realtime"
+	"seta-training/internal/services"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/events"
+)
+
+// newNotificationServer serves the stream to the user named in the X-User
+// header, standing in for the auth middleware
+func newNotificationServer(t *testing.T, hub *realtime.Hub) *httptest.Server {
+	router := gin.New()
+	h := NewNotificationStreamHandler(hub, []string{"https://app.example.com"})
+	authenticate := func(c *gin.Context) {
+		if userID, err := uuid.Parse(c.GetHeader("X-User")); err == nil {
+			c.Set(middleware.ClaimsContextKey, &auth.Claims{UserID: userID, Role: models.RoleMember})
+		}
+	}
+	router.GET("/ws/notifications", authenticate, h.Stream)
+	router.GET("/me/activity/stream", authenticate, h.ActivityStream)
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+	})
+	return server
+}
+
+func dialNotifications(t *testing.T, server *httptest.Server, header http.Header) (*websocket.Conn, *http.Response, error) {
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/notifications"
+	ws, resp, err := websocket.DefaultDialer.Dial(url, header)
+	if ws != nil {
+		t.Cleanup(func() { ws.Close() })
+	}
+	return ws, resp, err
+}
+
+// connect opens a stream for userID and waits until the hub has registered it
+func connect(t *testing.T, server *httptest.Server, userID uuid.UUID) *websocket.Conn {
+	ws, _, err := dialNotifications(t, server, http.Header{"X-User": {userID.String()}})
+	require.NoError(t, err)
+	ping(t, ws)
+	return ws
+}
+
+func readMessage(t *testing.T, ws *websocket.Conn) realtime.Message {
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	var msg realtime.Message
+	require.NoError(t, ws.ReadJSON(&msg))
+	return msg
+}
+
+// ping round-trips a ping, so every message sent before it has arrived
+func ping(t *testing.T, ws *websocket.Conn) {
+	require.NoError(t, ws.WriteJSON(map[string]string{"type": realtime.MessagePing}))
+	assert.Equal(t, realtime.MessagePong, readMessage(t, ws).Type)
+}
+
+func sharedEvent(t *testing.T, userID uuid.UUID) events.Event {
+	data, err := json.Marshal(models.NoteSharedEvent{NoteID: uuid.New(), UserID: userID, Access: models.AccessRead})
+	require.NoError(t, err)
+	return events.Event{ID: uuid.New(), Type: models.EventNoteShared, OccurredAt: time.Now(), Data: data}
+}
+
+func TestNotificationStream_PushesEventsToTheirUser(t *testing.T) {
+	hub := realtime.NewHub(time.Minute, 5, nil, nil)
+	server := newNotificationServer(t, hub)
+	alice, bob := uuid.New(), uuid.New()
+	aliceWS := connect(t, server, alice)
+	bobWS := connect(t, server, bob)
+
+	event := sharedEvent(t, alice)
+	require.NoError(t, hub.HandleEvent(context.Background(), event))
+
+	msg := readMessage(t, aliceWS)
+	assert.Equal(t, models.EventNoteShared, msg.Type)
+	assert.JSONEq(t, string(event.Data), string(msg.Data))
+	// Bob's next message is the answer to his ping, not Alice's share
+	ping(t, bobWS)
+}
+
+func TestNotificationStream_PushesImportResults(t *testing.T) {
+	hub := realtime.NewHub(time.Minute, 5, nil, nil)
+	server := newNotificationServer(t, hub)
+	manager := uuid.New()
+	ws := connect(t, server, manager)
+
+	hub.ImportFinished(context.Background(), services.ImportEvent{
+		Event:      services.ImportEventCompleted,
+		ManagerID:  manager.String(),
+		Filename:   "users.csv",
+		FinishedAt: time.Now(),
+	})
+
+	msg := readMessage(t, ws)
+	assert.Equal(t, services.ImportEventCompleted, msg.Type)
+	assert.Contains(t, string(msg.Data), `"filename":"users.csv"`)
+}
+
+func TestNotificationStream_Subscriptions(t *testing.T) {
+	hub := realtime.NewHub(time.Minute, 5, nil, nil)
+	server := newNotificationServer(t, hub)
+	alice := uuid.New()
+	ws := connect(t, server, alice)
+
+	require.NoError(t, ws.WriteJSON(map[string]interface{}{
+		"type":   realtime.MessageUnsubscribe,
+		"events": []string{models.EventNoteShared},
+	}))
+	msg := readMessage(t, ws)
+	require.Equal(t, realtime.MessageSubscriptions, msg.Type)
+	var subscriptions struct {
+		Events []string `json:"events"`
+	}
+	require.NoError(t, json.Unmarshal(msg.Data, &subscriptions))
+	assert.NotContains(t, subscriptions.Events, models.EventNoteShared)
+	assert.Contains(t, subscriptions.Events, models.EventFolderShared)
+
+	require.NoError(t, hub.HandleEvent(context.Background(), sharedEvent(t, alice)))
+	ping(t, ws)
+
+	require.NoError(t, ws.WriteJSON(map[string]interface{}{
+		"type":   realtime.MessageSubscribe,
+		"events": []string{"note.liked"},
+	}))
+	assert.Equal(t, realtime.MessageError, readMessage(t, ws).Type)
+}
+
+func TestNotificationStream_RejectsConnections(t *testing.T) {
+	hub := realtime.NewHub(time.Minute, 1, nil, nil)
+	server := newNotificationServer(t, hub)
+	alice := uuid.New()
+
+	t.Run("without authentication", func(t *testing.T) {
+		_, resp, err := dialNotifications(t, server, nil)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("from other origins", func(t *testing.T) {
+		_, resp, err := dialNotifications(t, server, http.Header{
+			"X-User": {alice.String()},
+			"Origin": {"https://evil.example.com"},
+		})
+		require.Error(t, err)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("without a handshake", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/ws/notifications", nil)
+		require.NoError(t, err)
+		req.Header.Set("X-User", alice.String())
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "validation_failed", body["code"])
+	})
+
+	t.Run("over the per-user limit", func(t *testing.T) {
+		connect(t, server, alice)
+		ws, _, err := dialNotifications(t, server, http.Header{
+			"X-User": {alice.String()},
+			"Origin": {"https://app.example.com"},
+		})
+		require.NoError(t, err)
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+		_, _, err = ws.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), "got %v", err)
+	})
+}
+
+// openActivityStream starts an SSE stream for userID; the hub has
+// registered it once the response headers arrive
+func openActivityStream(t *testing.T, server *httptest.Server, userID uuid.UUID, query string) (*http.Response, *bufio.Reader) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/me/activity/stream"+query, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-User", userID.String())
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// readEvent returns the name and data of the next event, skipping comments
+func readEvent(t *testing.T, r *bufio.Reader) (string, realtime.Message) {
+	var name string
+	var msg realtime.Message
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimPrefix(line, "event:")
+		case strings.HasPrefix(line, "data:"):
+			require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &msg))
+		case line == "" && name != "":
+			return name, msg
+		}
+	}
+}
+
+func TestActivityStream_StreamsActivityAndNotifications(t *testing.T) {
+	bus := events.NewMemoryBus(nil)
+	hub := realtime.NewHub(time.Minute, 5, nil, nil)
+	for _, eventType := range realtime.EventTypes {
+		_, err := bus.SubscribeBroadcast(eventType, hub.HandleEvent)
+		require.NoError(t, err)
+	}
+	publisher := realtime.NewActivityPublisher(bus, 10, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go publisher.Run(ctx)
+
+	server := newNotificationServer(t, hub)
+	alice := uuid.New()
+	resp, stream := openActivityStream(t, server, alice, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	folderID := uuid.New()
+	publisher.Record(context.Background(), audit.Entry{ActorID: alice, Action: audit.ActionCreate, TargetType: audit.TargetFolder, TargetID: folderID})
+	name, msg := readEvent(t, stream)
+	assert.Equal(t, realtime.MessageActivity, name)
+	var activity realtime.Activity
+	require.NoError(t, json.Unmarshal(msg.Data, &activity))
+	assert.Equal(t, folderID, activity.TargetID)
+	assert.Equal(t, audit.ActionCreate, activity.Action)
+
+	require.NoError(t, bus.Publish(context.Background(), sharedEvent(t, alice)))
+	name, _ = readEvent(t, stream)
+	assert.Equal(t, models.EventNoteShared, name)
+}
+
+func TestActivityStream_Types(t *testing.T) {
+	hub := realtime.NewHub(time.Minute, 5, nil, nil)
+	server := newNotificationServer(t, hub)
+	alice := uuid.New()
+
+	t.Run("narrows the stream", func(t *testing.T) {
+		_, stream := openActivityStream(t, server, alice, "?types="+realtime.MessageActivity)
+		require.NoError(t, hub.HandleEvent(context.Background(), sharedEvent(t, alice)))
+		hub.Send(alice, realtime.Message{Type: realtime.MessageActivity, Time: time.Now()})
+		name, _ := readEvent(t, stream)
+		assert.Equal(t, realtime.MessageActivity, name)
+	})
+
+	t.Run("rejects unknown types", func(t *testing.T) {
+		resp, _ := openActivityStream(t, server, alice, "?types=note.liked")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
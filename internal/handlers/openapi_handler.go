@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/openapi"
+)
+
+// swaggerUIVersion pins the Swagger UI assets loaded from the CDN
+const swaggerUIVersion = "5.17.14"
+
+var swaggerUIPage = template.Must(template.New("swagger-ui").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@{{.Version}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: {{.SpecURL}}, dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`))
+
+// OpenAPIHandler serves the OpenAPI document and a Swagger UI page for it
+type OpenAPIHandler struct {
+	doc     *openapi.Document
+	spec    []byte
+	specURL string
+}
+
+// NewOpenAPIHandler serves doc; specURL is where Swagger UI fetches it from
+func NewOpenAPIHandler(doc *openapi.Document, specURL string) (*OpenAPIHandler, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI document: %w", err)
+	}
+	return &OpenAPIHandler{
+		doc:     doc,
+		spec:    spec,
+		specURL: specURL,
+	}, nil
+}
+
+// Spec serves the OpenAPI document as JSON
+func (h *OpenAPIHandler) Spec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", h.spec)
+}
+
+// SwaggerUI serves an interactive browser for the document
+func (h *OpenAPIHandler) SwaggerUI(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	_ = swaggerUIPage.Execute(c.Writer, gin.H{
+		"Title":   h.doc.Info.Title,
+		"Version": swaggerUIVersion,
+		"SpecURL": h.specURL,
+	})
+}
+
+// Undocumented lists the routes under /api that the document does not cover
+func (h *OpenAPIHandler) Undocumented(routes gin.RoutesInfo) []string {
+	var missing []string
+	for _, r := range routes {
+		if len(r.Path) < 5 || r.Path[:5] != "/api/" {
+			continue
+		}
+		if !h.doc.Has(r.Method, r.Path) {
+			missing = append(missing, r.Method+" "+r.Path)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIHandler_Spec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h, err := NewOpenAPIHandler(NewAPISpec(true), "/openapi.json")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/openapi.json", h.Spec)
+	router.GET("/docs", h.SwaggerUI)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+				Required   []string               `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	getFolder := doc.Paths["/api/v1/folders/{folderId}"]["get"]
+	require.NotNil(t, getFolder)
+	params := getFolder["parameters"].([]interface{})
+	require.Len(t, params, 1)
+	param := params[0].(map[string]interface{})
+	assert.Equal(t, "folderId", param["name"])
+	assert.Equal(t, "path", param["in"])
+	assert.Equal(t, "uuid", param["schema"].(map[string]interface{})["format"])
+
+	assert.Equal(t, true, doc.Paths["/api/v1/teams"]["post"]["deprecated"])
+	assert.Nil(t, doc.Paths["/api/v2/teams"]["post"]["deprecated"])
+	assert.Contains(t, doc.Paths["/api/v2/teams/{teamId}/members"]["post"]["responses"], "204")
+
+	note := doc.Components.Schemas["Note"]
+	assert.Contains(t, note.Properties, "title")
+	assert.NotContains(t, note.Properties, "DeletedAt")
+	assert.Contains(t, doc.Components.Schemas["CreateNoteInput"].Required, "title")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `"/openapi.json"`)
+}
+
+func TestOpenAPIHandler_Undocumented(t *testing.T) {
+	h, err := NewOpenAPIHandler(NewAPISpec(false), "/openapi.json")
+	require.NoError(t, err)
+
+	missing := h.Undocumented(gin.RoutesInfo{
+		{Method: http.MethodGet, Path: "/api/v1/notes/:noteId"},
+		{Method: http.MethodPatch, Path: "/api/v1/notes/:noteId"},
+		{Method: http.MethodGet, Path: "/metrics"},
+	})
+	assert.Equal(t, []string{"PATCH /api/v1/notes/:noteId"}, missing)
+}
@@ -0,0 +1,1555 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"seta-training/internal/audit"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/internal/openapi"
+	"seta-training/internal/realtime"
+	"seta-training/internal/services"
+	"seta-training/internal/usage"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/pagination"
+	"seta-training/pkg/scim"
+)
+
+// APIVersion is the version reported in the OpenAPI document
+const APIVersion = "2.0.0"
+
+// ErrorResponse documents the body of every 4xx and 5xx response
+type ErrorResponse struct {
+	Code      string                 `json:"code" binding:"oneof=validation_failed unauthorized forbidden quota_exceeded not_found conflict payload_too_large unprocessable rate_limited unavailable internal_error"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// MessageResponse documents the acknowledgement returned by mutations
+// without a resource to return
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// ShareResponse documents the acknowledgement of a folder or note share,
+// listing the users it was shared with
+type ShareResponse struct {
+	Message string                 `json:"message"`
+	Results []services.ShareResult `json:"results"`
+}
+
+// UserAssetsResponse documents GET /users/{userId}/assets
+type UserAssetsResponse struct {
+	Folders []models.Folder `json:"folders"`
+	Notes   []models.Note   `json:"notes"`
+}
+
+type OwnedFolder struct {
+	Folder models.Folder `json:"folder"`
+	Owner  models.User   `json:"owner"`
+	Access string        `json:"access" binding:"oneof=owned shared"`
+}
+
+type OwnedNote struct {
+	Note   models.Note `json:"note"`
+	Owner  models.User `json:"owner"`
+	Access string      `json:"access" binding:"oneof=owned shared"`
+}
+
+// TeamAssetsResponse documents GET /teams/{teamId}/assets
+type TeamAssetsResponse struct {
+	TeamID       string        `json:"team_id"`
+	TeamName     string        `json:"team_name"`
+	Folders      []OwnedFolder `json:"folders,omitempty"`
+	Notes        []OwnedNote   `json:"notes,omitempty"`
+	TotalFolders int           `json:"total_folders"`
+	TotalNotes   int           `json:"total_notes"`
+	Limit        int           `json:"limit,omitempty"`
+	Offset       int           `json:"offset,omitempty"`
+}
+
+// ImportResponse documents POST /import-users
+type ImportResponse struct {
+	Message     string                 `json:"message"`
+	Summary     services.ImportSummary `json:"summary"`
+	FileInfo    map[string]interface{} `json:"file_info"`
+	Config      map[string]interface{} `json:"config"`
+	ProcessedBy map[string]interface{} `json:"processed_by"`
+}
+
+// AuditLogsResponse documents the audit log listing
+type AuditLogsResponse struct {
+	Entries []models.AuditLog `json:"entries"`
+	Count   int               `json:"count"`
+}
+
+// UsageResponse documents the API usage report
+type UsageResponse struct {
+	GroupBy string          `json:"group_by"`
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Entries []usage.Summary `json:"entries"`
+}
+
+// FeaturesResponse lists the features that are on for the caller
+type FeaturesResponse struct {
+	Features []string `json:"features"`
+}
+
+// HealthResponse documents the liveness and readiness probes
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// DependencyHealthResponse documents GET /health
+type DependencyHealthResponse struct {
+	Status string                      `json:"status" binding:"oneof=healthy degraded unhealthy not_ready"`
+	Checks map[string]DependencyStatus `json:"checks,omitempty"`
+}
+
+const (
+	bearerAuth = "bearerAuth"
+	scimAuth   = "scimAuth"
+)
+
+var maxIdempotencyKeyLength = 255
+
+// NewAPISpec documents every REST endpoint. v1Deprecated marks the v1
+// routes that have a v2 successor as deprecated. Keep it in step with the
+// routes registered in cmd/server; the server logs any route missing here.
+func NewAPISpec(v1Deprecated bool) *openapi.Document {
+	b := openapi.NewBuilder("SETA Training API", APIVersion)
+	b.Info().Description = "Team, folder and note management. Authenticate with the JWT returned by the GraphQL login mutation. Error messages are translated into the language asked for in Accept-Language (en or vi)."
+	b.PathParamSchema = &openapi.Schema{Type: "string", Format: "uuid"}
+	b.AddSecurityScheme(bearerAuth, &openapi.SecurityScheme{
+		Type:         "http",
+		Scheme:       "bearer",
+		BearerFormat: "JWT",
+	})
+	b.AddSecurityScheme(scimAuth, &openapi.SecurityScheme{
+		Type:        "http",
+		Scheme:      "bearer",
+		Description: "Token of an organization's SCIM connection",
+	})
+	for _, tag := range [][2]string{
+		{"health", "Liveness and readiness probes"},
+		{"auth", "Token signing keys and SAML single sign-on"},
+		{"teams", "Teams and the roles of their people"},
+		{"folders", "Folders and folder sharing"},
+		{"notes", "Notes and note sharing"},
+		{"access-requests", "Requests for access to folders and notes"},
+		{"exports", "Asynchronous folder exports"},
+		{"saved-filters", "Saved filters and the home page"},
+		{"search", "Search across notes, folders, teams and users"},
+		{"me", "The current user's mentions and notifications"},
+		{"assets", "Assets owned by or shared with users and teams"},
+		{"import", "Bulk user import from CSV"},
+		{"audit", "Audit log of changes made through the API"},
+		{"webhooks", "Outbound webhooks for domain events"},
+		{"organizations", "Tenant administration (admins only)"},
+		{"announcements", "Scheduled banners shown to every client"},
+		{"scim", "SCIM 2.0 provisioning of users and teams by identity providers"},
+	} {
+		b.AddTag(tag[0], tag[1])
+	}
+
+	s := &specBuilder{b: b}
+	s.health()
+	s.sso()
+	s.teams("/api/v1/teams", TeamCodecV1{}, v1Deprecated)
+	s.teams("/api/v2/teams", TeamCodecV2{}, false)
+	s.folders()
+	s.notes()
+	s.accessRequests()
+	s.savedFilters()
+	s.search()
+	s.me()
+	s.assets()
+	s.imports()
+	s.audit()
+	s.webhooks()
+	s.organizations()
+	s.announcements()
+	s.scim()
+	return b.Document()
+}
+
+// specBuilder holds the helpers shared by the route groups
+type specBuilder struct {
+	b *openapi.Builder
+}
+
+type route struct {
+	summary     string
+	description string
+	query       []openapi.Parameter
+	body        *openapi.RequestBody
+	responses   map[int]*openapi.Response
+	public      bool
+	// security is the scheme the route is authenticated with, bearerAuth
+	// when empty
+	security   string
+	deprecated bool
+	// idempotent routes accept an Idempotency-Key header
+	idempotent bool
+}
+
+func (s *specBuilder) add(method, path, tag string, r route) {
+	op := openapi.Operation{
+		Tags:        []string{tag},
+		Summary:     r.summary,
+		Description: r.description,
+		Parameters:  r.query,
+		RequestBody: r.body,
+		Responses:   make(map[string]*openapi.Response),
+		Deprecated:  r.deprecated,
+	}
+	for code, resp := range r.responses {
+		op.Responses[openapi.Status(code)] = resp
+	}
+	errResp := s.err
+	if r.security == scimAuth {
+		errResp = s.scimErr
+	}
+	if !r.public {
+		scheme := r.security
+		if scheme == "" {
+			scheme = bearerAuth
+		}
+		op.Security = []openapi.SecurityRequirement{{scheme: {}}}
+		op.Responses[openapi.Status(http.StatusUnauthorized)] = errResp("Missing or invalid token")
+	}
+	if r.idempotent {
+		op.Parameters = append(op.Parameters, openapi.Parameter{
+			Name:        "Idempotency-Key",
+			In:          "header",
+			Description: "Client-chosen key; retries with the same key replay the first response instead of repeating the request",
+			Schema:      &openapi.Schema{Type: "string", MaxLength: &maxIdempotencyKeyLength},
+		})
+		op.Responses[openapi.Status(http.StatusConflict)] = s.err("A request with this key is still being processed")
+		op.Responses[openapi.Status(http.StatusUnprocessableEntity)] = s.err("The key was already used for a different request")
+	}
+	if r.body != nil {
+		op.Responses[openapi.Status(http.StatusBadRequest)] = errResp("Invalid request")
+		op.Responses[openapi.Status(http.StatusRequestEntityTooLarge)] = errResp("Request body too large")
+	}
+	s.b.Add(method, path, op)
+}
+
+func (s *specBuilder) ok(description string, v interface{}) *openapi.Response {
+	return s.b.JSON(description, v)
+}
+
+func (s *specBuilder) err(description string) *openapi.Response {
+	return s.b.JSON(description, ErrorResponse{})
+}
+
+// scimErr is an error of the SCIM API
+func (s *specBuilder) scimErr(description string) *openapi.Response {
+	return s.scimJSON(description, scim.Error{})
+}
+
+// scimJSON is a SCIM API response shaped like v
+func (s *specBuilder) scimJSON(description string, v interface{}) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content:     map[string]*openapi.MediaType{scim.ContentType: {Schema: s.b.Schema(v)}},
+	}
+}
+
+func (s *specBuilder) message(description string) *openapi.Response {
+	return s.b.JSON(description, MessageResponse{})
+}
+
+func queryParam(name, description string, schema *openapi.Schema) openapi.Parameter {
+	return openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// pageQuery documents the parameters of a keyset-paged listing
+func pageQuery() []openapi.Parameter {
+	return []openapi.Parameter{
+		queryParam("limit", fmt.Sprintf("Page size, %d by default and at most %d", pagination.DefaultLimit, pagination.MaxLimit), &openapi.Schema{Type: "integer", Format: "int32"}),
+		queryParam("cursor", "Cursor from the "+NextCursorHeader+" header of the previous page", &openapi.Schema{Type: "string"}),
+	}
+}
+
+// page documents one page of a paged listing
+func (s *specBuilder) page(description string, v interface{}) *openapi.Response {
+	resp := s.ok(description, v)
+	resp.Headers = map[string]*openapi.Header{
+		NextCursorHeader: {Description: "Cursor of the next page, absent on the last page", Schema: &openapi.Schema{Type: "string"}},
+	}
+	return resp
+}
+
+func (s *specBuilder) health() {
+	probe := func(summary string) route {
+		return route{
+			summary: summary,
+			public:  true,
+			responses: map[int]*openapi.Response{
+				http.StatusOK:                 s.ok("Healthy", HealthResponse{}),
+				http.StatusServiceUnavailable: s.ok("Not ready or a dependency is failing", HealthResponse{}),
+			},
+		}
+	}
+	s.add(http.MethodGet, "/.well-known/jwks.json", "auth", route{
+		summary:     "Public keys for verifying access tokens",
+		description: "JSON Web Key Set of the keys tokens are signed with, matched by the token's `kid` header. Empty when tokens are signed with a shared secret.",
+		public:      true,
+		responses:   map[int]*openapi.Response{http.StatusOK: s.ok("Key set", auth.JWKS{})},
+	})
+	s.add(http.MethodGet, "/healthz", "health", route{
+		summary:   "Liveness probe",
+		public:    true,
+		responses: map[int]*openapi.Response{http.StatusOK: s.ok("The process is running", HealthResponse{})},
+	})
+	s.add(http.MethodGet, "/readyz", "health", probe("Readiness probe"))
+	s.add(http.MethodGet, "/health", "health", route{
+		summary:     "Dependency health breakdown",
+		description: "Status and latency of each dependency. `degraded` means only optional dependencies, such as the event bus, are down.",
+		public:      true,
+		responses: map[int]*openapi.Response{
+			http.StatusOK:                 s.ok("Healthy or degraded", DependencyHealthResponse{}),
+			http.StatusServiceUnavailable: s.ok("Not ready or a critical dependency is down", DependencyHealthResponse{}),
+		},
+	})
+}
+
+func (s *specBuilder) sso() {
+	slug := openapi.Parameter{Name: "slug", In: "path", Required: true, Description: "Slug of the organization", Schema: &openapi.Schema{Type: "string"}}
+	notFound := s.err("Organization or SAML connection not found")
+
+	s.add(http.MethodGet, "/sso/saml/:slug/metadata", "auth", route{
+		summary:     "SAML service provider metadata of an organization",
+		description: "Register it with the organization's identity provider. Served when `SSO_BASE_URL` is set.",
+		public:      true,
+		query:       []openapi.Parameter{slug},
+		responses: map[int]*openapi.Response{
+			http.StatusOK: {
+				Description: "Metadata XML",
+				Content:     map[string]*openapi.MediaType{"application/samlmetadata+xml": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+			http.StatusNotFound: notFound,
+		},
+	})
+	s.add(http.MethodGet, "/sso/saml/:slug/login", "auth", route{
+		summary:     "Sign in through an organization's identity provider",
+		description: "Redirects the browser to the identity provider. `redirect_to` must be on one of `CORS_ALLOWED_ORIGINS`; the login ends there with the token in the URL fragment as `#token=...`.",
+		public:      true,
+		query: []openapi.Parameter{
+			slug,
+			queryParam("redirect_to", "Page to send the user to once signed in", &openapi.Schema{Type: "string", Format: "uri"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusFound:      openapi.Empty("Redirect to the identity provider"),
+			http.StatusBadRequest: s.err("redirect_to is not on an allowed origin"),
+			http.StatusForbidden:  s.err("Single sign-on is disabled for the organization"),
+			http.StatusNotFound:   notFound,
+		},
+	})
+	s.add(http.MethodPost, "/sso/saml/:slug/acs", "auth", route{
+		summary:     "Assertion consumer service",
+		description: "The identity provider posts its response here. It must answer the request of a login started in the same browser within 10 minutes. Users without an account are created in the organization; users of another organization are refused.",
+		public:      true,
+		query:       []openapi.Parameter{slug},
+		body: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]*openapi.MediaType{"application/x-www-form-urlencoded": {Schema: &openapi.Schema{
+				Type: "object",
+				Properties: map[string]*openapi.Schema{
+					"SAMLResponse": {Type: "string", Description: "Base64 encoded SAML response"},
+					"RelayState":   {Type: "string", Description: "The login's redirect_to"},
+				},
+				Required: []string{"SAMLResponse"},
+			}}},
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:           s.ok("Logged in; returned when the login had no redirect_to", services.LoginResponse{}),
+			http.StatusSeeOther:     openapi.Empty("Logged in; redirect to redirect_to with the token in the fragment"),
+			http.StatusUnauthorized: s.err("Invalid SAML response"),
+			http.StatusForbidden:    s.err("Single sign-on is disabled, or the user belongs to another organization"),
+			http.StatusNotFound:     notFound,
+		},
+	})
+}
+
+func (s *specBuilder) teams(prefix string, codec TeamCodec, deprecated bool) {
+	var (
+		createBody interface{} = services.CreateTeamInput{}
+		team       interface{} = models.Team{}
+		teams      interface{} = []models.Team{}
+		done                   = s.message("Membership updated")
+		status                 = http.StatusOK
+	)
+	if _, ok := codec.(TeamCodecV2); ok {
+		createBody = CreateTeamRequestV2{}
+		team = TeamV2{}
+		teams = struct {
+			Data  []TeamV2 `json:"data"`
+			Count int      `json:"count"`
+		}{}
+		done = openapi.Empty("Membership updated")
+		status = http.StatusNoContent
+	}
+
+	s.add(http.MethodPost, prefix, "teams", route{
+		summary:     "Create a team (managers only)",
+		description: "With `sharedFolder`, a folder named after the team is created for it, owned by the caller. It is shared with everyone in the team, with read access for viewers and write access for the other roles, and the share follows them as they join, leave and change roles. The caller joins the team as its admin.",
+		idempotent:  true,
+		body:        s.b.JSONBody(createBody),
+		deprecated:  deprecated,
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:   s.ok("Team created", team),
+			http.StatusForbidden: s.err("Not a manager"),
+		},
+	})
+	s.add(http.MethodGet, prefix, "teams", route{
+		summary:     "List teams",
+		description: "Returns every team unless `limit` or `cursor` is given, in which case one page is returned.",
+		deprecated:  deprecated,
+		query:       pageQuery(),
+		responses:   map[int]*openapi.Response{http.StatusOK: s.page("Teams", teams)},
+	})
+	s.add(http.MethodGet, prefix+"/:teamId", "teams", route{
+		summary:    "Get a team",
+		deprecated: deprecated,
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.ok("Team", team),
+			http.StatusNotFound: s.err("Team not found"),
+		},
+	})
+	s.add(http.MethodPut, prefix+"/:teamId/settings", "teams", route{
+		summary:     "Change a team's sharing policy (admins and managers only)",
+		description: "Omitted settings are left as they are. The policy applies to the folders and notes of everyone in the team. Without `allowExternalSharing` they can only share with users in one of the teams that forbid sharing outside the team, including through access requests. Shares that leave out `access` get `defaultAccess`, or `read` when the owner's teams disagree. `allowPublicLinks` is stored for public links, which the API does not offer yet.",
+		body:        s.b.JSONBody(services.TeamSettingsInput{}),
+		deprecated:  deprecated,
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Team", team),
+			http.StatusForbidden: s.err("Not a manager"),
+		},
+	})
+	s.add(http.MethodPut, prefix+"/:teamId/roles/:userId", "teams", route{
+		summary:     "Give a user a role in a team (admins and managers only)",
+		description: "Adds the user to the team if needed. The roles are `admin`, `manager`, `member` and `viewer`; only users who are managers can be admins or managers of a team. Managers can give and take away every role but `admin`, which only admins can.",
+		body:        s.b.JSONBody(services.SetRoleInput{}),
+		deprecated:  deprecated,
+		responses: map[int]*openapi.Response{
+			status:                done,
+			http.StatusBadRequest: s.err("Unknown role, or a managing role for a user who is not a manager"),
+			http.StatusForbidden:  s.err("Not an admin or manager of the team, or changing an admin without being one"),
+		},
+	})
+	for _, role := range []struct{ group, param, noun string }{
+		{"members", "memberId", "member"},
+		{"managers", "managerId", "manager"},
+	} {
+		s.add(http.MethodPost, prefix+"/:teamId/"+role.group, "teams", route{
+			summary:    "Add a " + role.noun + " (admins and managers only)",
+			idempotent: true,
+			body:       s.b.JSONBody(userIDRequest{}),
+			deprecated: deprecated,
+			responses: map[int]*openapi.Response{
+				status:               done,
+				http.StatusForbidden: s.err("Not an admin or manager of the team"),
+				http.StatusConflict:  s.err("Already in the team"),
+			},
+		})
+		s.add(http.MethodDelete, prefix+"/:teamId/"+role.group+"/:"+role.param, "teams", route{
+			summary:     "Remove a user from the team (admins and managers only)",
+			description: "Removes the user whatever their role. Only admins can remove admins.",
+			deprecated:  deprecated,
+			responses: map[int]*openapi.Response{
+				status:               done,
+				http.StatusForbidden: s.err("Not an admin or manager of the team, or removing an admin without being one"),
+			},
+		})
+	}
+}
+
+func (s *specBuilder) folders() {
+	s.add(http.MethodPost, "/api/v1/folders", "folders", route{
+		summary:    "Create a folder",
+		idempotent: true,
+		body:       s.b.JSONBody(services.CreateFolderInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:   s.ok("Folder created", models.Folder{}),
+			http.StatusForbidden: s.err("Folder quota reached (`quota_exceeded`)"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/folders/:folderId", "folders", route{
+		summary: "Get a folder",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Folder", models.Folder{}),
+			http.StatusForbidden: s.err("No access to the folder"),
+			http.StatusNotFound:  s.err("Folder not found"),
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/folders/:folderId", "folders", route{
+		summary: "Rename a folder",
+		body:    s.b.JSONBody(services.UpdateFolderInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Folder updated", models.Folder{}),
+			http.StatusForbidden: s.err("No write access to the folder"),
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/folders/:folderId", "folders", route{
+		summary:     "Delete a folder and its notes",
+		description: "Deletes the folder, every note in it and the shares of both in one transaction, so either everything is deleted or nothing is.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.message("Folder deleted"),
+			http.StatusForbidden: s.err("Only the owner can delete the folder"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/folders/:folderId/duplicate", "folders", route{
+		summary:     "Duplicate a folder with its notes",
+		description: "Copies the folder and every note in it, with their checklists and links, into a new folder owned by the caller in one transaction. The copy is named `<name> (copy)` unless `name` is given; `include_shares` also copies the shares of the folder and its notes and is only allowed for the owner. The body is optional. Clients sending `Accept: text/event-stream` get a `progress` event with `{copied, total}` after each batch of notes, then the new folder as a `folder` event, or an `error` event with the error envelope if copying fails once the stream started.",
+		idempotent:  true,
+		body:        s.b.JSONBody(services.DuplicateFolderInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated: s.ok("Folder copied", models.Folder{}),
+			http.StatusOK: {
+				Description: "Event stream of the copy, for clients accepting `text/event-stream`",
+				Content:     map[string]*openapi.MediaType{"text/event-stream": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+			http.StatusForbidden: s.err("No access to the folder, shares asked for by someone other than the owner, or quota reached (`quota_exceeded`)"),
+			http.StatusNotFound:  s.err("Folder not found"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/folders/:folderId/merge", "folders", route{
+		summary:     "Merge another folder into a folder",
+		description: "Moves every note of the folder `source_id` into this folder, then trashes the source folder and removes its shares, in one transaction. Moved notes whose titles, ignoring case, are already taken are renamed `<title> (2)`, `<title> (3)` and so on. Only the owner of both folders may merge them.",
+		body:        s.b.JSONBody(services.MergeFolderInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Folder with the merged notes", models.Folder{}),
+			http.StatusBadRequest: s.err("Invalid source folder, or a folder merged into itself"),
+			http.StatusForbidden:  s.err("Not the owner of both folders"),
+			http.StatusNotFound:   s.err("Folder not found"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/folders/:folderId/share", "folders", route{
+		summary:     "Share a folder with one or more users",
+		description: "Give `userId` and `access` to share with one user, or `shares`, a list of up to 100 `{userId, access}` entries, to share with several at once. `access` may be left out when the owner is in a team, to use the teams' default access; sharing with users outside the owner's teams is forbidden when a team does not allow it. The entries are shared in one transaction: if any fail, none are shared and the error's `details` give the reason for each failed entry, keyed `shares[i]`.",
+		idempotent:  true,
+		body:        s.b.JSONBody(services.ShareFolderInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Folder shared", ShareResponse{}),
+			http.StatusBadRequest: s.err("Invalid share, or entries that could not be shared"),
+			http.StatusForbidden:  s.err("Only the owner can share the folder"),
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/folders/:folderId/share/:userId", "folders", route{
+		summary:   "Revoke a folder share",
+		responses: map[int]*openapi.Response{http.StatusOK: s.message("Share revoked")},
+	})
+	s.add(http.MethodGet, "/api/v1/folders/:folderId/shares", "folders", route{
+		summary: "List the users a folder is shared with",
+		query:   pageQuery(),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.page("Shares", []models.FolderShare{}),
+			http.StatusForbidden: s.err("Only the owner can list shares"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/folders/:folderId/notes", "notes", route{
+		summary:    "Create a note in a folder",
+		idempotent: true,
+		body:       s.b.JSONBody(services.CreateNoteInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:   s.ok("Note created", models.Note{}),
+			http.StatusForbidden: s.err("No write access to the folder, or note quota reached (`quota_exceeded`)"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/folders/:folderId/export", "exports", route{
+		summary: "Queue an export of a folder's notes",
+		query: []openapi.Parameter{
+			queryParam("format", "Artifact format", &openapi.Schema{Type: "string", Enum: []string{"zip", "pdf"}}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusAccepted:   s.ok("Export queued; poll the job at the Location header", models.ExportJob{}),
+			http.StatusBadRequest: s.err("Invalid folder or format"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/exports/:jobId", "exports", route{
+		summary: "Get an export job",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.ok("Export job", models.ExportJob{}),
+			http.StatusNotFound: s.err("Export job not found"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/exports/:jobId/download", "exports", route{
+		summary: "Download a finished export",
+		responses: map[int]*openapi.Response{
+			http.StatusOK: {
+				Description: "The export artifact",
+				Content: map[string]*openapi.MediaType{
+					"application/zip": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+					"application/pdf": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+				},
+			},
+			http.StatusNotFound: s.err("Export not found or not finished"),
+		},
+	})
+}
+
+func (s *specBuilder) notes() {
+	s.add(http.MethodGet, "/api/v1/notes/:noteId", "notes", route{
+		summary: "Get a note",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Note", models.Note{}),
+			http.StatusForbidden: s.err("No access to the note"),
+			http.StatusNotFound:  s.err("Note not found"),
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/notes/:noteId", "notes", route{
+		summary: "Update a note",
+		body:    s.b.JSONBody(services.UpdateNoteInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Note updated", models.Note{}),
+			http.StatusForbidden: s.err("No write access to the note"),
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/notes/:noteId/status", "notes", route{
+		summary:     "Move a note along the publishing workflow",
+		description: "Drafts can go to review, publication or the archive; notes in review back to draft, to publication or the archive; published notes back to draft or to the archive; archived notes back to draft. Notes without a status can enter at any status. The owner and the users the note is shared with, except whoever made the change, receive a `note.status_changed` message.",
+		body:        s.b.JSONBody(services.NoteStatusInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Status changed", models.Note{}),
+			http.StatusBadRequest: s.err("Unknown status or transition not allowed"),
+			http.StatusForbidden:  s.err("No write access to the note"),
+			http.StatusConflict:   s.err("The status was changed concurrently"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/notes/:noteId/backlinks", "notes", route{
+		summary:     "List the notes linking to a note",
+		description: "Notes link to others with `[[note-id]]` or a Markdown or HTML link to `…/notes/{noteId}`. Only linking notes the caller owns or has been shared are listed, most recently updated first.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Backlinks", []models.Backlink{}),
+			http.StatusForbidden: s.err("No access to the note"),
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/notes/:noteId", "notes", route{
+		summary: "Delete a note",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.message("Note deleted"),
+			http.StatusForbidden: s.err("Only the owner can delete the note"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/notes/:noteId/share", "notes", route{
+		summary:     "Share a note with one or more users",
+		description: "Give `userId` and `access` to share with one user, or `shares`, a list of up to 100 `{userId, access}` entries, to share with several at once. `access` may be left out when the owner is in a team, to use the teams' default access; sharing with users outside the owner's teams is forbidden when a team does not allow it. The entries are shared in one transaction: if any fail, none are shared and the error's `details` give the reason for each failed entry, keyed `shares[i]`.",
+		idempotent:  true,
+		body:        s.b.JSONBody(services.ShareNoteInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Note shared", ShareResponse{}),
+			http.StatusBadRequest: s.err("Invalid share, or entries that could not be shared"),
+			http.StatusForbidden:  s.err("Only the owner can share the note"),
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/notes/:noteId/share/:userId", "notes", route{
+		summary:   "Revoke a note share",
+		responses: map[int]*openapi.Response{http.StatusOK: s.message("Share revoked")},
+	})
+	s.add(http.MethodGet, "/api/v1/notes/:noteId/shares", "notes", route{
+		summary: "List the users a note is shared with",
+		query:   pageQuery(),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.page("Shares", []models.NoteShare{}),
+			http.StatusForbidden: s.err("Only the owner can list shares"),
+		},
+	})
+
+	const reminders = "Reminders are private to the user who set them. When one is due the user gets a `reminder` notification and a `reminder.due` message on their live streams."
+	s.add(http.MethodGet, "/api/v1/notes/:noteId/reminders", "notes", route{
+		summary:     "List your reminders on a note",
+		description: "Includes reminders already sent, soonest first.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Reminders", []models.Reminder{}),
+			http.StatusForbidden: s.err("No access to the note"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/notes/:noteId/reminders", "notes", route{
+		summary:     "Set a reminder on a note",
+		description: reminders,
+		idempotent:  true,
+		body:        s.b.JSONBody(services.ReminderInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:    s.ok("Reminder created", models.Reminder{}),
+			http.StatusBadRequest: s.err("Invalid reminder, due time in the past or too many pending reminders"),
+			http.StatusForbidden:  s.err("No access to the note"),
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/notes/:noteId/reminders/:reminderId", "notes", route{
+		summary:     "Reschedule a reminder",
+		description: "A reminder already sent is sent again at the new time.",
+		body:        s.b.JSONBody(services.ReminderInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Reminder updated", models.Reminder{}),
+			http.StatusBadRequest: s.err("Invalid reminder or due time in the past"),
+			http.StatusNotFound:   s.err("Reminder not found"),
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/notes/:noteId/reminders/:reminderId", "notes", route{
+		summary: "Delete a reminder",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.message("Reminder deleted"),
+			http.StatusNotFound: s.err("Reminder not found"),
+		},
+	})
+
+	s.add(http.MethodGet, "/api/v1/notes/:noteId/checklist", "notes", route{
+		summary: "List the checklist of a note",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Checklist items in order", []models.ChecklistItem{}),
+			http.StatusForbidden: s.err("No access to the note"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/notes/:noteId/checklist", "notes", route{
+		summary:     "Add a checklist item",
+		description: "Without a position the item goes last; items from the position on move down one.",
+		idempotent:  true,
+		body:        s.b.JSONBody(services.ChecklistItemInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:    s.ok("Checklist item added", models.ChecklistItem{}),
+			http.StatusBadRequest: s.err("Invalid item or checklist full"),
+			http.StatusForbidden:  s.err("No write access to the note"),
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/notes/:noteId/checklist/:itemId", "notes", route{
+		summary:     "Edit, tick or move a checklist item",
+		description: "Only the fields present change.",
+		body:        s.b.JSONBody(services.UpdateChecklistItemInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Checklist item updated", models.ChecklistItem{}),
+			http.StatusBadRequest: s.err("Invalid item"),
+			http.StatusForbidden:  s.err("No write access to the note"),
+			http.StatusNotFound:   s.err("Checklist item not found"),
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/notes/:noteId/checklist/:itemId", "notes", route{
+		summary: "Delete a checklist item",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.message("Checklist item deleted"),
+			http.StatusForbidden: s.err("No write access to the note"),
+			http.StatusNotFound:  s.err("Checklist item not found"),
+		},
+	})
+}
+
+func (s *specBuilder) savedFilters() {
+	limit := queryParam("limit", "Maximum number of results", &openapi.Schema{Type: "integer", Format: "int32"})
+
+	s.add(http.MethodPost, "/api/v1/saved-filters", "saved-filters", route{
+		summary:    "Create a saved filter",
+		idempotent: true,
+		body:       s.b.JSONBody(services.SavedFilterInput{}),
+		responses:  map[int]*openapi.Response{http.StatusCreated: s.ok("Saved filter created", models.SavedFilter{})},
+	})
+	s.add(http.MethodGet, "/api/v1/saved-filters", "saved-filters", route{
+		summary:   "List the current user's saved filters",
+		responses: map[int]*openapi.Response{http.StatusOK: s.ok("Saved filters", []models.SavedFilter{})},
+	})
+	s.add(http.MethodGet, "/api/v1/saved-filters/:filterId", "saved-filters", route{
+		summary: "Get a saved filter",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.ok("Saved filter", models.SavedFilter{}),
+			http.StatusNotFound: s.err("Saved filter not found"),
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/saved-filters/:filterId", "saved-filters", route{
+		summary:   "Update a saved filter",
+		body:      s.b.JSONBody(services.SavedFilterInput{}),
+		responses: map[int]*openapi.Response{http.StatusOK: s.ok("Saved filter updated", models.SavedFilter{})},
+	})
+	s.add(http.MethodDelete, "/api/v1/saved-filters/:filterId", "saved-filters", route{
+		summary:   "Delete a saved filter",
+		responses: map[int]*openapi.Response{http.StatusOK: s.message("Saved filter deleted")},
+	})
+	s.add(http.MethodGet, "/api/v1/saved-filters/:filterId/results", "saved-filters", route{
+		summary:   "Run a saved filter",
+		query:     []openapi.Parameter{limit},
+		responses: map[int]*openapi.Response{http.StatusOK: s.ok("Matching assets", services.FilterResults{})},
+	})
+	s.add(http.MethodGet, "/api/v1/home", "saved-filters", route{
+		summary:   "Home page with pinned filters and their results",
+		responses: map[int]*openapi.Response{http.StatusOK: s.ok("Home payload", services.HomePayload{})},
+	})
+}
+
+func (s *specBuilder) search() {
+	q := queryParam("q", fmt.Sprintf("Text to search for, %d to %d characters", services.MinSearchQueryLength, services.MaxSearchQueryLength), &openapi.Schema{Type: "string"})
+	q.Required = true
+
+	s.add(http.MethodGet, "/api/v1/search", "search", route{
+		summary:     "Search notes, folders, teams and users",
+		description: "Matches note titles and bodies, folder and team names, and usernames and emails. Notes and folders are limited to those the caller owns or has been shared, teams and users to the caller's organization. Results of every type are ranked together by `score`: 3 for an exact title, 2 for a title prefix, 1 for a title containing the text and 0 for a body or email match. `facets` counts all matches per type.",
+		query: []openapi.Parameter{
+			q,
+			queryParam("types", "Comma-separated types to search, all by default: "+strings.Join(services.SearchTypes, ", "), &openapi.Schema{Type: "string"}),
+			queryParam("limit", fmt.Sprintf("Maximum number of results, %d by default and at most %d", services.DefaultSearchLimit, services.MaxSearchLimit), &openapi.Schema{Type: "integer", Format: "int32"}),
+		},
+		responses: map[int]*openapi.Response{http.StatusOK: s.ok("Ranked results with per-type counts", services.SearchResults{})},
+	})
+}
+
+func (s *specBuilder) me() {
+	limit := queryParam("limit", "Maximum number of results", &openapi.Schema{Type: "integer", Format: "int32"})
+
+	s.add(http.MethodGet, "/api/v1/me/notes", "me", route{
+		summary: "Notes the current user owns, oldest first",
+		query: append(pageQuery(),
+			queryParam("status", "Comma-separated statuses to list; `none` lists notes outside the workflow", &openapi.Schema{Type: "string"}),
+		),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.page("Notes", []models.Note{}),
+			http.StatusBadRequest: s.err("Invalid page or status"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/me/mentions", "me", route{
+		summary:   "Notes that mention the current user",
+		query:     []openapi.Parameter{limit},
+		responses: map[int]*openapi.Response{http.StatusOK: s.ok("Mentions", []services.MentionView{})},
+	})
+	s.add(http.MethodGet, "/api/v1/me/notifications", "me", route{
+		summary: "The current user's notifications",
+		query: []openapi.Parameter{
+			limit,
+			queryParam("unread", "Only unread notifications", &openapi.Schema{Type: "boolean"}),
+		},
+		responses: map[int]*openapi.Response{http.StatusOK: s.ok("Notifications", []models.Notification{})},
+	})
+	s.add(http.MethodGet, "/api/v1/me/devices", "me", route{
+		summary:     "Devices the current user is logged in with",
+		description: "A device is a user agent the user logged in with; tokens issued at login carry its `device_id`. Most recently used first, with `current` set on the device of the token used.",
+		responses:   map[int]*openapi.Response{http.StatusOK: s.ok("Devices", []models.Device{})},
+	})
+	s.add(http.MethodDelete, "/api/v1/me/devices/:deviceId", "me", route{
+		summary:     "Revoke a device",
+		description: "Its tokens are rejected, and cannot be refreshed, within 30 seconds on every instance. Logging in again from it adds a new device.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.message("Device revoked"),
+			http.StatusNotFound: s.err("Device not found or already revoked"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/me/login-history", "me", route{
+		summary:     "Attempts to log in as the current user, newest first",
+		description: "Each attempt has its time, IP address, user agent and outcome. `new_device` marks a successful login with a user agent never used to log in before; those send a `new_device_login` notification.",
+		query:       []openapi.Parameter{queryParam("limit", "Maximum number of attempts (default 50, max 200)", &openapi.Schema{Type: "integer", Format: "int32"})},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Login attempts", []models.LoginAttempt{}),
+			http.StatusBadRequest: s.err("Invalid limit"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/me/notifications/:notificationId/read", "me", route{
+		summary: "Mark a notification as read",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.message("Notification marked as read"),
+			http.StatusNotFound: s.err("Notification not found"),
+		},
+	})
+	s.add(http.MethodGet, "/ws/notifications", "me", route{
+		summary:     "Stream the current user's notifications over WebSocket",
+		description: "Pushes `{type, data, time}` messages as the user is given or loses access to a folder or note, joins or leaves a team, or an import of theirs finishes. `type` is the event type, e.g. `note.shared`, and `data` its payload. Clients may send `{\"type\": \"subscribe\" | \"unsubscribe\", \"events\": [...]}` to choose the types they receive, all by default, and `{\"type\": \"ping\"}`, answered with `pong`. Connections that answer neither pings nor send anything for two ping intervals are closed.",
+		query: []openapi.Parameter{
+			queryParam(middleware.AccessTokenParam, "Access token, for clients such as browsers that cannot send the Authorization header", &openapi.Schema{Type: "string"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusSwitchingProtocols: openapi.Empty("Switched to the WebSocket protocol"),
+			http.StatusBadRequest:         s.err("Not a WebSocket handshake"),
+			http.StatusForbidden:          s.err("Origin not allowed"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/me/activity/stream", "me", route{
+		summary:     "Stream the current user's activity and notifications as Server-Sent Events",
+		description: "Emits the changes the caller makes, as recorded in the audit log, as `activity` events, and the notifications of `/ws/notifications` as events named after their type. Each event's data is a `{type, data, time}` message. `: ping` comments are sent while idle.",
+		query: []openapi.Parameter{
+			queryParam("types", "Comma-separated message types to stream, all by default: "+strings.Join(realtime.MessageTypes, ", "), &openapi.Schema{Type: "string"}),
+			queryParam(middleware.AccessTokenParam, "Access token, for clients such as EventSource that cannot send the Authorization header", &openapi.Schema{Type: "string"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK: {
+				Description: "Event stream",
+				Content:     map[string]*openapi.MediaType{"text/event-stream": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+			http.StatusBadRequest:         s.err("Invalid stream types"),
+			http.StatusTooManyRequests:    s.err("Too many open notification streams"),
+			http.StatusServiceUnavailable: s.err("Server is shutting down"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/me/preferences", "me", route{
+		summary:     "The current user's preferences",
+		description: "Time zone, locale and notification channels. Users who never changed them get the defaults: UTC, `en` and every notification type in the app.",
+		responses:   map[int]*openapi.Response{http.StatusOK: s.ok("Preferences", models.UserPreference{})},
+	})
+	s.add(http.MethodPut, "/api/v1/me/preferences", "me", route{
+		summary:     "Update the current user's preferences",
+		description: "Only the settings present in the body change. The time zone is used for timestamps in notifications, mentions and exports, the locale for notification text, and `notification_channels` lists the channels each notification type is delivered on; an empty list mutes it.",
+		body:        s.b.JSONBody(services.UpdatePreferencesInput{}),
+		responses:   map[int]*openapi.Response{http.StatusOK: s.ok("Updated preferences", models.UserPreference{})},
+	})
+	s.add(http.MethodGet, "/api/v1/me/quota", "me", route{
+		summary:     "The current user's quota usage",
+		description: "How many notes and folders the user owns, and the people of each of their teams own together, against the configured limits. A null `limit` is unlimited.",
+		responses:   map[int]*openapi.Response{http.StatusOK: s.ok("Quota", services.Quota{})},
+	})
+	s.add(http.MethodGet, "/api/v1/me/features", "me", route{
+		summary:     "Features enabled for the current user",
+		description: "Names of the feature flags that are on for the user, directly, through one of their teams or by percentage rollout. Clients can use it to show or hide optional features.",
+		responses:   map[int]*openapi.Response{http.StatusOK: s.ok("Features", FeaturesResponse{})},
+	})
+}
+
+func (s *specBuilder) assets() {
+	s.add(http.MethodGet, "/api/v1/users/:userId/assets", "assets", route{
+		summary: "Folders and notes a user owns or can access",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("User assets", UserAssetsResponse{}),
+			http.StatusForbidden: s.err("Not allowed to view this user's assets"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/teams/:teamId/assets", "assets", route{
+		summary:     "Assets of every team member (team managers only)",
+		description: "Folders and notes are paged separately with the same limit and offset; the totals count every match. Members are listed by username.",
+		query: []openapi.Parameter{
+			queryParam("member_id", "Only this member's assets", &openapi.Schema{Type: "string", Format: "uuid"}),
+			queryParam("access", "Only assets the member owns, or only those shared with them", &openapi.Schema{Type: "string", Enum: []string{"all", AssetAccessOwned, AssetAccessShared}}),
+			queryParam("counts_only", "Return the totals without the assets", &openapi.Schema{Type: "boolean"}),
+			queryParam("limit", "Page size for folders and notes (default 50, max 200)", &openapi.Schema{Type: "integer", Format: "int32"}),
+			queryParam("offset", "Number of folders and notes to skip", &openapi.Schema{Type: "integer", Format: "int32"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Team assets", TeamAssetsResponse{}),
+			http.StatusForbidden: s.err("Not a manager of this team"),
+			http.StatusNotFound:  s.err("Team not found"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/teams/:teamId/login-history", "assets", route{
+		summary:     "Attempts to log in as the team's people, newest first (team managers only)",
+		description: "Attempts with an email that matches no one are not listed.",
+		query: []openapi.Parameter{
+			queryParam("member_id", "Only this person's attempts", &openapi.Schema{Type: "string", Format: "uuid"}),
+			queryParam("limit", "Maximum number of attempts (default 50, max 200)", &openapi.Schema{Type: "integer", Format: "int32"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Login attempts", []models.LoginAttempt{}),
+			http.StatusBadRequest: s.err("Invalid member ID or limit, or the member is not in the team"),
+			http.StatusForbidden:  s.err("Not a manager of this team"),
+			http.StatusNotFound:   s.err("Team not found"),
+		},
+	})
+}
+
+func (s *specBuilder) imports() {
+	form := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"csv_file":        {Type: "string", Format: "binary"},
+			"worker_count":    {Type: "integer"},
+			"batch_size":      {Type: "integer"},
+			"max_records":     {Type: "integer"},
+			"timeout_seconds": {Type: "integer"},
+			"skip_duplicates": {Type: "boolean"},
+			"column_mapping":  {Type: "string", Description: "JSON object mapping CSV headers to fields"},
+			"default_role":    {Type: "string", Enum: []string{"manager", "member"}},
+		},
+		Required: []string{"csv_file"},
+	}
+	s.add(http.MethodPost, "/api/v1/import-users", "import", route{
+		summary:    "Import users from a CSV file (managers only)",
+		idempotent: true,
+		body: &openapi.RequestBody{
+			Required: true,
+			Content:  map[string]*openapi.MediaType{"multipart/form-data": {Schema: form}},
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:              s.ok("All records imported", ImportResponse{}),
+			http.StatusPartialContent:  s.ok("Some records failed", ImportResponse{}),
+			http.StatusForbidden:       s.err("Not a manager"),
+			http.StatusTooManyRequests: s.err("Import rate limit exceeded"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/import-users/template", "import", route{
+		summary: "CSV template for user import",
+		responses: map[int]*openapi.Response{
+			http.StatusOK: {
+				Description: "CSV template",
+				Content:     map[string]*openapi.MediaType{"text/csv": {Schema: &openapi.Schema{Type: "string"}}},
+			},
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/import-users/status", "import", route{
+		summary: "Import limits and supported options (managers only)",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Import capabilities", map[string]interface{}{}),
+			http.StatusForbidden: s.err("Not a manager"),
+		},
+	})
+}
+
+func (s *specBuilder) audit() {
+	date := &openapi.Schema{Type: "string", Description: "RFC 3339 timestamp or YYYY-MM-DD date"}
+	s.add(http.MethodGet, "/api/v1/audit-logs", "audit", route{
+		summary:     "Audit log entries, newest first (managers only)",
+		description: "A date used as `to` includes that whole day.",
+		query: []openapi.Parameter{
+			queryParam("actor_id", "Only changes made by this user", &openapi.Schema{Type: "string", Format: "uuid"}),
+			queryParam("impersonator_id", "Only changes made by this admin while impersonating a user", &openapi.Schema{Type: "string", Format: "uuid"}),
+			queryParam("target_type", "Only changes to this kind of resource", &openapi.Schema{
+				Type: "string",
+				Enum: []string{audit.TargetUser, audit.TargetTeam, audit.TargetFolder, audit.TargetNote, audit.TargetSavedFilter, audit.TargetWebhook, audit.TargetFeatureFlag, audit.TargetMaintenance, audit.TargetAnnouncement, audit.TargetAccessRequest},
+			}),
+			queryParam("target_id", "Only changes to this resource", &openapi.Schema{Type: "string", Format: "uuid"}),
+			queryParam("from", "Only changes at or after this time", date),
+			queryParam("to", "Only changes before this time", date),
+			queryParam("limit", "Maximum number of entries (default 50, at most 500)", &openapi.Schema{Type: "integer", Format: "int32"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Audit log entries", AuditLogsResponse{}),
+			http.StatusBadRequest: s.err("Invalid filter"),
+			http.StatusForbidden:  s.err("Not a manager"),
+		},
+	})
+}
+
+func (s *specBuilder) webhooks() {
+	forbidden := s.err("Not a manager, or not the webhook's owner")
+	notFound := s.err("Webhook not found")
+
+	s.add(http.MethodPost, "/api/v1/webhooks", "webhooks", route{
+		summary: "Register a webhook (managers only)",
+		description: "Events are POSTed as JSON and signed with the returned secret: `X-Webhook-Signature` is " +
+			"`sha256=` followed by the hex HMAC-SHA256 of `<X-Webhook-Timestamp>.<body>`. " +
+			"The secret is only returned here.",
+		idempotent: true,
+		body:       s.b.JSONBody(services.WebhookInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:   s.ok("Webhook created", services.WebhookWithSecret{}),
+			http.StatusForbidden: forbidden,
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/webhooks", "webhooks", route{
+		summary: "List the current manager's webhooks",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Webhooks", []models.Webhook{}),
+			http.StatusForbidden: forbidden,
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/webhooks/:webhookId", "webhooks", route{
+		summary: "Get a webhook",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Webhook", models.Webhook{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/webhooks/:webhookId", "webhooks", route{
+		summary: "Update a webhook",
+		body:    s.b.JSONBody(services.WebhookInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Webhook updated", models.Webhook{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/webhooks/:webhookId", "webhooks", route{
+		summary: "Delete a webhook and its delivery log",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.message("Webhook deleted"),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/webhooks/:webhookId/deliveries", "webhooks", route{
+		summary: "Recent deliveries to a webhook, newest first",
+		query: []openapi.Parameter{
+			queryParam("limit", "Maximum number of deliveries (default 50, at most 200)", &openapi.Schema{Type: "integer", Format: "int32"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Deliveries", []models.WebhookDelivery{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+		},
+	})
+}
+
+func (s *specBuilder) accessRequests() {
+	request := "The body is optional and asks for read access by default. The owner gets an `access_request` notification and an `access_request.created` message on their live streams."
+	conflict := s.err("You already have the access asked for, or a request of yours is still pending")
+	for _, resource := range []struct{ path, name, notFound string }{
+		{"/api/v1/folders/:folderId/access-requests", "folder", "Folder not found"},
+		{"/api/v1/notes/:noteId/access-requests", "note", "Note not found"},
+	} {
+		s.add(http.MethodPost, resource.path, "access-requests", route{
+			summary:     "Ask the owner of a " + resource.name + " for access",
+			description: request,
+			idempotent:  true,
+			body:        s.b.JSONBody(services.AccessRequestInput{}),
+			responses: map[int]*openapi.Response{
+				http.StatusCreated:    s.ok("Access requested", models.AccessRequest{}),
+				http.StatusBadRequest: s.err("Invalid access request"),
+				http.StatusNotFound:   s.err(resource.notFound),
+				http.StatusConflict:   conflict,
+			},
+		})
+	}
+	s.add(http.MethodGet, "/api/v1/me/access-requests", "access-requests", route{
+		summary:     "Pending requests for access to your folders and notes",
+		description: "Oldest first, with the requesting user.",
+		query:       pageQuery(),
+		responses:   map[int]*openapi.Response{http.StatusOK: s.page("Access requests", []models.AccessRequest{})},
+	})
+
+	decided := s.err("The request was already decided")
+	forbidden := s.err("Not the owner of the folder or note")
+	notFound := s.err("Access request, folder or note not found")
+	s.add(http.MethodPost, "/api/v1/access-requests/:requestId/approve", "access-requests", route{
+		summary:     "Approve an access request",
+		description: "Shares the folder or note with the requester with the access asked for, replacing a read share when write access was asked for. The requester gets an `access_request` notification and an `access_request.decided` message.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Access request approved", models.AccessRequest{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+			http.StatusConflict:  decided,
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/access-requests/:requestId/deny", "access-requests", route{
+		summary:     "Deny an access request",
+		description: "The requester gets an `access_request` notification, which does not name the folder or note, and an `access_request.decided` message.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Access request denied", models.AccessRequest{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+			http.StatusConflict:  decided,
+		},
+	})
+}
+
+func (s *specBuilder) announcements() {
+	forbidden := s.err("Not an admin")
+	notFound := s.err("Announcement not found")
+	schedule := "`starts_at` defaults to now; without `ends_at` the announcement is shown until it is changed or deleted. `level` is info (default), warning or critical."
+
+	s.add(http.MethodGet, "/api/v1/announcements", "announcements", route{
+		summary:     "Announcements shown now",
+		description: "Latest first. Also served during maintenance so clients can show its banner.",
+		public:      true,
+		responses:   map[int]*openapi.Response{http.StatusOK: s.ok("Announcements", []models.Announcement{})},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/announcements", "announcements", route{
+		summary:     "List every announcement",
+		description: "Includes scheduled and expired announcements, latest start first.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Announcements", []models.Announcement{}),
+			http.StatusForbidden: forbidden,
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/admin/announcements", "announcements", route{
+		summary:     "Schedule an announcement",
+		description: schedule,
+		idempotent:  true,
+		body:        s.b.JSONBody(services.AnnouncementInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:    s.ok("Announcement created", models.Announcement{}),
+			http.StatusBadRequest: s.err("Invalid announcement or schedule"),
+			http.StatusForbidden:  forbidden,
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/admin/announcements/:announcementId", "announcements", route{
+		summary:     "Replace an announcement",
+		description: schedule,
+		body:        s.b.JSONBody(services.AnnouncementInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Announcement updated", models.Announcement{}),
+			http.StatusBadRequest: s.err("Invalid announcement or schedule"),
+			http.StatusForbidden:  forbidden,
+			http.StatusNotFound:   notFound,
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/admin/announcements/:announcementId", "announcements", route{
+		summary: "Delete an announcement",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.message("Announcement deleted"),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+		},
+	})
+}
+
+func (s *specBuilder) organizations() {
+	forbidden := s.err("Not an admin")
+	notFound := s.err("Organization not found")
+
+	s.add(http.MethodPost, "/api/v1/admin/organizations", "organizations", route{
+		summary:    "Create an organization",
+		idempotent: true,
+		body:       s.b.JSONBody(services.CreateOrganizationInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:   s.ok("Organization created", models.Organization{}),
+			http.StatusForbidden: forbidden,
+			http.StatusConflict:  s.err("Slug already exists"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/organizations", "organizations", route{
+		summary: "List organizations",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Organizations", []models.Organization{}),
+			http.StatusForbidden: forbidden,
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/organizations/:orgId", "organizations", route{
+		summary: "Get an organization",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Organization", models.Organization{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/organizations/:orgId/users", "organizations", route{
+		summary:     "List the users of an organization",
+		description: "Returns every user unless `limit` or `cursor` is given, in which case one page is returned.",
+		query:       pageQuery(),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.page("Users", []models.User{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/admin/organizations/:orgId/users/:userId", "organizations", route{
+		summary:     "Move a user into an organization",
+		description: "The user's folders and notes move with them. Their tokens keep the old organization until they log in again.",
+		responses: map[int]*openapi.Response{
+			http.StatusNoContent: openapi.Empty("User moved"),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  s.err("Organization or user not found"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/organizations/:orgId/saml", "organizations", route{
+		summary: "Get the SAML connection of an organization",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("SAML connection", models.SAMLConnection{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  s.err("Organization has no SAML connection"),
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/admin/organizations/:orgId/saml", "organizations", route{
+		summary:     "Set up or replace the SAML connection of an organization",
+		description: "Its users sign in at `/sso/saml/{slug}/login` and are created on their first login. The email comes from `email_attribute`, or the NameID when empty. When `role_attribute` is set, a user's role is its value mapped through `role_mapping` on every login, `manager` winning over `member`, and `default_role` when no value is mapped.",
+		body:        s.b.JSONBody(services.SAMLConnectionInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("SAML connection saved", models.SAMLConnection{}),
+			http.StatusBadRequest: s.err("Invalid metadata or role mapping"),
+			http.StatusForbidden:  forbidden,
+			http.StatusNotFound:   notFound,
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/organizations/:orgId/ldap", "organizations", route{
+		summary: "Get the LDAP connection of an organization",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("LDAP connection", models.LDAPConnection{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  s.err("Organization has no LDAP connection"),
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/admin/organizations/:orgId/ldap", "organizations", route{
+		summary:     "Set up or replace the LDAP connection of an organization",
+		description: "Enabled connections are synced on `JOBS_LDAP_SYNC_SCHEDULE`. Users matching `user_filter` under `user_base_dn` are created, updated and deactivated; the members of the groups in `role_mapping` get their role, `manager` winning over `member`, and the members of the groups in `team_mapping` are put into its teams. `bind_password` is never returned and may be left out to keep the current one.",
+		body:        s.b.JSONBody(services.LDAPConnectionInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("LDAP connection saved", models.LDAPConnection{}),
+			http.StatusBadRequest: s.err("Invalid URL, DN, filter or mapping"),
+			http.StatusForbidden:  forbidden,
+			http.StatusNotFound:   notFound,
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/admin/organizations/:orgId/ldap/sync", "organizations", route{
+		summary:     "Sync an organization with its LDAP directory",
+		description: "Returns what changed. With `dry_run` nothing is changed and the report lists what would be, which also works for disabled connections.",
+		query: []openapi.Parameter{
+			queryParam("dry_run", "Only report what would change", &openapi.Schema{Type: "boolean"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:                 s.ok("Sync report", services.LDAPSyncReport{}),
+			http.StatusBadRequest:         s.err("Missing mapped group, or no users found"),
+			http.StatusForbidden:          s.err("Not an admin, or the connection is disabled"),
+			http.StatusNotFound:           s.err("Organization has no LDAP connection"),
+			http.StatusServiceUnavailable: s.err("Directory unreachable"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/organizations/:orgId/scim", "organizations", route{
+		summary: "Get the SCIM connection of an organization",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("SCIM connection", models.SCIMConnection{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  s.err("Organization has no SCIM connection"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/admin/organizations/:orgId/scim/token", "organizations", route{
+		summary:     "Issue the SCIM token of an organization",
+		description: "The identity provider provisions the organization's users and teams at `/scim/v2` with this token. It is only returned now, and replaces the organization's previous token.",
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:   s.ok("SCIM token", services.SCIMToken{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  notFound,
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/admin/organizations/:orgId/scim", "organizations", route{
+		summary: "Delete the SCIM connection of an organization, revoking its token",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.message("SCIM connection deleted"),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  s.err("Organization has no SCIM connection"),
+		},
+	})
+	s.add(http.MethodPost, "/api/v1/admin/impersonate/:userId", "organizations", route{
+		summary:     "Issue a token acting as a user",
+		description: "The token is valid for `minutes`, by default and at most `ADMIN_IMPERSONATION_MINUTES`, and cannot be refreshed. It carries the user's scopes and teams but never `admin`. Changes made with it are audited as the user's, with the admin as `impersonator_id`.",
+		body:        s.b.JSONBody(services.ImpersonateInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:    s.ok("Impersonation token", services.ImpersonationToken{}),
+			http.StatusBadRequest: s.err("Missing reason, too many minutes, or the admin themselves"),
+			http.StatusForbidden:  forbidden,
+			http.StatusNotFound:   s.err("User not found"),
+		},
+	})
+	date := &openapi.Schema{Type: "string", Format: "date"}
+	s.add(http.MethodGet, "/api/v1/admin/usage", "organizations", route{
+		summary:     "API usage by user, team or route, busiest first",
+		description: "Counts requests made with a valid token, rolled up per day in UTC. Both ends of the range are inclusive; the default is the last 30 days. A user in several teams counts towards each.",
+		query: []openapi.Parameter{
+			queryParam("group_by", "What to group requests by (default user)", &openapi.Schema{Type: "string", Enum: usage.GroupBys}),
+			queryParam("from", "First day", date),
+			queryParam("to", "Last day", date),
+			queryParam("limit", "Maximum number of entries (default 50, at most 500)", &openapi.Schema{Type: "integer", Format: "int32"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Usage", UsageResponse{}),
+			http.StatusBadRequest: s.err("Invalid filter"),
+			http.StatusForbidden:  forbidden,
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/stats", "organizations", route{
+		summary:     "Totals and daily statistics for the ops dashboard",
+		description: "`series` has one entry per day (UTC), oldest first, counting the users, teams, notes, shares and imports created that day and the users who made an authenticated request. Imports are counted from the audit log.",
+		query: []openapi.Parameter{
+			queryParam("days", "How many days the series covers, today included (default 30, at most 365)", &openapi.Schema{Type: "integer", Format: "int32"}),
+		},
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Statistics", services.Stats{}),
+			http.StatusBadRequest: s.err("Invalid range"),
+			http.StatusForbidden:  forbidden,
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/reports/assets", "organizations", route{
+		summary:     "Queue an asset report of the admin's organization",
+		description: "The CSV has a row per team: the people in it, the folders and notes they own, how many of those are shared, with how many users and how many outside the team, and how many belong to deleted users. Someone in several teams counts towards each; a final `(no team)` row covers owners in no team. Any admin of the organization may read the report. While a report of the organization is pending or running, that one is returned instead of queuing another.",
+		responses: map[int]*openapi.Response{
+			http.StatusAccepted:           s.ok("Report queued or under way; poll it at the Location header", models.AssetReport{}),
+			http.StatusForbidden:          forbidden,
+			http.StatusServiceUnavailable: s.err("Too many reports queued"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/reports/assets/:reportId", "organizations", route{
+		summary: "Get an asset report",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Asset report", models.AssetReport{}),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  s.err("Asset report not found"),
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/reports/assets/:reportId/download", "organizations", route{
+		summary: "Download a finished asset report as CSV",
+		responses: map[int]*openapi.Response{
+			http.StatusOK: {
+				Description: "The report",
+				Content: map[string]*openapi.MediaType{
+					"text/csv": {Schema: &openapi.Schema{Type: "string"}},
+				},
+			},
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  s.err("Asset report not found"),
+			http.StatusConflict:  s.err("Asset report not finished"),
+		},
+	})
+	maintenanceNote := "In `read_only` mode writes answer 503, except the GraphQL `login` mutation; in `full` mode every request does. Health checks, `/metrics`, admin routes and announcements are always served. 503s carry a `Retry-After` header."
+	s.add(http.MethodGet, "/api/v1/admin/maintenance", "organizations", route{
+		summary:     "Get the maintenance mode",
+		description: maintenanceNote,
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Maintenance mode", MaintenanceResponse{}),
+			http.StatusForbidden: forbidden,
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/admin/maintenance", "organizations", route{
+		summary:     "Set the maintenance mode",
+		description: "Overrides `MAINTENANCE_MODE` until deleted. With Redis configured every instance follows within 2 seconds; without it only the instance serving the request does. " + maintenanceNote,
+		body:        s.b.JSONBody(MaintenanceInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Maintenance mode set", MaintenanceResponse{}),
+			http.StatusBadRequest: s.err("Invalid mode"),
+			http.StatusForbidden:  forbidden,
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/admin/maintenance", "organizations", route{
+		summary:     "Return to the configured maintenance mode",
+		description: "Removes the mode set through the API, so `MAINTENANCE_MODE` applies again.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Maintenance mode", MaintenanceResponse{}),
+			http.StatusForbidden: forbidden,
+		},
+	})
+	s.add(http.MethodGet, "/api/v1/admin/feature-flags", "organizations", route{
+		summary:     "List the feature flags stored in the database",
+		description: "Flags set in the config file or `FEATURE_FLAGS` are not listed; a stored flag overrides one of the same name there.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.ok("Feature flags", []models.FeatureFlag{}),
+			http.StatusForbidden: forbidden,
+		},
+	})
+	s.add(http.MethodPut, "/api/v1/admin/feature-flags/:name", "organizations", route{
+		summary:     "Create or replace a feature flag",
+		description: "A flag is on for everyone when `enabled`, otherwise for the listed users, the people of the listed teams, whatever their roles, and a stable `percentage` of users. Other instances pick up the change within 30 seconds.",
+		body:        s.b.JSONBody(services.FeatureFlagInput{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.ok("Feature flag saved", models.FeatureFlag{}),
+			http.StatusBadRequest: s.err("Invalid name or targeting"),
+			http.StatusForbidden:  forbidden,
+		},
+	})
+	s.add(http.MethodDelete, "/api/v1/admin/feature-flags/:name", "organizations", route{
+		summary:     "Delete a feature flag",
+		description: "The config file decides the flag again.",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:        s.message("Feature flag deleted"),
+			http.StatusForbidden: forbidden,
+			http.StatusNotFound:  s.err("Feature flag not found"),
+		},
+	})
+}
+
+func (s *specBuilder) scim() {
+	list := []openapi.Parameter{
+		queryParam("filter", "Only `attribute eq \"value\"` is supported", &openapi.Schema{Type: "string"}),
+		queryParam("startIndex", "1-based index of the first result (default 1)", &openapi.Schema{Type: "integer", Format: "int32"}),
+		queryParam("count", fmt.Sprintf("Page size, at most %d (default %d)", scim.MaxResults, scim.MaxResults), &openapi.Schema{Type: "integer", Format: "int32"}),
+	}
+	body := func(v interface{}) *openapi.RequestBody {
+		return &openapi.RequestBody{
+			Required: true,
+			Content:  map[string]*openapi.MediaType{scim.ContentType: {Schema: s.b.Schema(v)}},
+		}
+	}
+	resource := func(r route) route {
+		r.security = scimAuth
+		return r
+	}
+
+	userNotFound := s.scimErr("User not found")
+	s.add(http.MethodGet, "/scim/v2/Users", "scim", resource(route{
+		summary:     "List the users of the organization",
+		description: "Deactivated users are listed with `active` false. Filters on `userName`, `emails` and `externalId`.",
+		query:       list,
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.scimJSON("Users", scim.ListResponse{}),
+			http.StatusBadRequest: s.scimErr("Invalid or unsupported filter"),
+		},
+	}))
+	s.add(http.MethodPost, "/scim/v2/Users", "scim", resource(route{
+		summary:     "Provision a user",
+		description: "Creates a member of the organization with a random password, so they sign in through single sign-on. The email is the primary one, or `userName` when there are none.",
+		body:        body(scim.User{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated:  s.scimJSON("User created", scim.User{}),
+			http.StatusConflict: s.scimErr("Username or email already exists"),
+		},
+	}))
+	s.add(http.MethodGet, "/scim/v2/Users/:id", "scim", resource(route{
+		summary: "Get a user",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.scimJSON("User", scim.User{}),
+			http.StatusNotFound: userNotFound,
+		},
+	}))
+	s.add(http.MethodPut, "/scim/v2/Users/:id", "scim", resource(route{
+		summary:     "Replace a user",
+		description: "Setting `active` to false deactivates the user, who can no longer sign in; setting it back to true restores them.",
+		body:        body(scim.User{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.scimJSON("User updated", scim.User{}),
+			http.StatusNotFound: userNotFound,
+			http.StatusConflict: s.scimErr("Username or email already exists"),
+		},
+	}))
+	s.add(http.MethodPatch, "/scim/v2/Users/:id", "scim", resource(route{
+		summary:     "Update a user",
+		description: "Supports `active`, `userName`, `externalId` and `emails`; other attributes are ignored.",
+		body:        body(scim.PatchRequest{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.scimJSON("User updated", scim.User{}),
+			http.StatusNotFound: userNotFound,
+			http.StatusConflict: s.scimErr("Username or email already exists"),
+		},
+	}))
+	s.add(http.MethodDelete, "/scim/v2/Users/:id", "scim", resource(route{
+		summary:     "Deprovision a user",
+		description: "Deactivates the user, keeping what they own.",
+		responses: map[int]*openapi.Response{
+			http.StatusNoContent: openapi.Empty("User deactivated"),
+			http.StatusNotFound:  userNotFound,
+		},
+	}))
+
+	groupNotFound := s.scimErr("Team not found")
+	s.add(http.MethodGet, "/scim/v2/Groups", "scim", resource(route{
+		summary:     "List the teams of the organization",
+		description: "Filters on `displayName`.",
+		query:       list,
+		responses: map[int]*openapi.Response{
+			http.StatusOK:         s.scimJSON("Teams", scim.ListResponse{}),
+			http.StatusBadRequest: s.scimErr("Invalid or unsupported filter"),
+		},
+	}))
+	s.add(http.MethodPost, "/scim/v2/Groups", "scim", resource(route{
+		summary:     "Provision a team",
+		description: "Its members must be users of the organization and join as members.",
+		body:        body(scim.Group{}),
+		responses: map[int]*openapi.Response{
+			http.StatusCreated: s.scimJSON("Team created", scim.Group{}),
+		},
+	}))
+	s.add(http.MethodGet, "/scim/v2/Groups/:id", "scim", resource(route{
+		summary: "Get a team",
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.scimJSON("Team", scim.Group{}),
+			http.StatusNotFound: groupNotFound,
+		},
+	}))
+	s.add(http.MethodPut, "/scim/v2/Groups/:id", "scim", resource(route{
+		summary:     "Replace a team",
+		description: "People missing from `members` are removed; the roles of those who stay are kept. Deactivated users are left alone.",
+		body:        body(scim.Group{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.scimJSON("Team updated", scim.Group{}),
+			http.StatusNotFound: groupNotFound,
+		},
+	}))
+	s.add(http.MethodPatch, "/scim/v2/Groups/:id", "scim", resource(route{
+		summary:     "Update a team",
+		description: "Supports `displayName` and adding, replacing and removing `members`.",
+		body:        body(scim.PatchRequest{}),
+		responses: map[int]*openapi.Response{
+			http.StatusOK:       s.scimJSON("Team updated", scim.Group{}),
+			http.StatusNotFound: groupNotFound,
+		},
+	}))
+	s.add(http.MethodDelete, "/scim/v2/Groups/:id", "scim", resource(route{
+		summary:     "Deprovision a team",
+		description: "Removes everyone from the team, then deletes it.",
+		responses: map[int]*openapi.Response{
+			http.StatusNoContent: openapi.Empty("Team deleted"),
+			http.StatusNotFound:  groupNotFound,
+		},
+	}))
+}
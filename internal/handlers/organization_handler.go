@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// OrganizationHandler serves the organization admin endpoints. Its routes
+// must only be mounted for admins.
+type OrganizationHandler struct {
+	orgService services.OrganizationServiceInterface
+}
+
+func NewOrganizationHandler(orgService services.OrganizationServiceInterface) *OrganizationHandler {
+	return &OrganizationHandler{
+		orgService: orgService,
+	}
+}
+
+// CreateOrganization creates a tenant
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	var input services.CreateOrganizationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	org, err := h.orgService.CreateOrganization(c.Request.Context(), &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, org)
+}
+
+// GetOrganizations lists every organization
+func (h *OrganizationHandler) GetOrganizations(c *gin.Context) {
+	orgs, err := h.orgService.GetAllOrganizations(c.Request.Context())
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, orgs)
+}
+
+// GetOrganization gets an organization
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	org, err := h.orgService.GetOrganization(c.Request.Context(), orgID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, org)
+}
+
+// GetOrganizationUsers lists the users of an organization, one page at a
+// time when limit or cursor is given
+func (h *OrganizationHandler) GetOrganizationUsers(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	params, paged, err := parsePageParams(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+	if paged {
+		page, err := h.orgService.ListOrganizationUsers(c.Request.Context(), orgID, params)
+		if err != nil {
+			middleware.RespondError(c, err)
+			return
+		}
+		setNextCursor(c, page.Next)
+		c.JSON(http.StatusOK, page.Items)
+		return
+	}
+
+	users, err := h.orgService.GetOrganizationUsers(c.Request.Context(), orgID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// AddUser moves a user into an organization
+func (h *OrganizationHandler) AddUser(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid user ID"))
+		return
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.orgService.AddUser(c.Request.Context(), orgID, userID, claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/pkg/pagination"
+)
+
+// NextCursorHeader carries the cursor of the next page of a paged listing.
+// It is absent on the last page.
+const NextCursorHeader = "X-Next-Cursor"
+
+// parsePageParams reads the limit and cursor query parameters of a paged
+// listing. paged reports whether the client sent either of them, for
+// listings that still return everything to clients that do not page.
+func parsePageParams(c *gin.Context) (p pagination.Params, paged bool, err error) {
+	fields := make(map[string]string)
+
+	if v, ok := c.GetQuery("limit"); ok {
+		paged = true
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > pagination.MaxLimit {
+			fields["limit"] = "must be between 1 and " + strconv.Itoa(pagination.MaxLimit)
+		}
+		p.Limit = limit
+	}
+	if v, ok := c.GetQuery("cursor"); ok {
+		paged = true
+		cursor, err := pagination.Decode(v)
+		if err != nil {
+			fields["cursor"] = "must be a cursor returned in " + NextCursorHeader
+		}
+		p.After = &cursor
+	}
+
+	if len(fields) > 0 {
+		return p, paged, apperrors.ValidationFields("Invalid page", fields)
+	}
+	return p, paged, nil
+}
+
+// setNextCursor tells the client where the next page starts
+func setNextCursor(c *gin.Context, next string) {
+	if next != "" {
+		c.Header(NextCursorHeader, next)
+	}
+}
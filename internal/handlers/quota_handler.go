@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// QuotaHandler serves the caller's quota usage under /me
+type QuotaHandler struct {
+	quotaService services.QuotaServiceInterface
+}
+
+func NewQuotaHandler(quotaService services.QuotaServiceInterface) *QuotaHandler {
+	return &QuotaHandler{
+		quotaService: quotaService,
+	}
+}
+
+// GetMyQuota returns how many notes and folders the current user and each
+// of their teams own, against the configured limits
+func (h *QuotaHandler) GetMyQuota(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	quota, err := h.quotaService.GetQuota(c.Request.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, quota)
+}
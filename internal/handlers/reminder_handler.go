@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// ReminderHandler serves the current user's reminders on a note
+type ReminderHandler struct {
+	reminderService services.ReminderServiceInterface
+}
+
+func NewReminderHandler(reminderService services.ReminderServiceInterface) *ReminderHandler {
+	return &ReminderHandler{
+		reminderService: reminderService,
+	}
+}
+
+// CreateReminder schedules a reminder on a note the user can read
+func (h *ReminderHandler) CreateReminder(c *gin.Context) {
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return
+	}
+
+	var input services.ReminderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	reminder, err := h.reminderService.CreateReminder(c.Request.Context(), noteID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reminder)
+}
+
+// GetReminders lists the user's reminders on a note
+func (h *ReminderHandler) GetReminders(c *gin.Context) {
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	reminders, err := h.reminderService.GetNoteReminders(c.Request.Context(), noteID, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reminders)
+}
+
+// UpdateReminder reschedules one of the user's reminders
+func (h *ReminderHandler) UpdateReminder(c *gin.Context) {
+	noteID, reminderID, ok := reminderIDs(c)
+	if !ok {
+		return
+	}
+
+	var input services.ReminderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	reminder, err := h.reminderService.UpdateReminder(c.Request.Context(), noteID, reminderID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reminder)
+}
+
+// DeleteReminder removes one of the user's reminders
+func (h *ReminderHandler) DeleteReminder(c *gin.Context) {
+	noteID, reminderID, ok := reminderIDs(c)
+	if !ok {
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.reminderService.DeleteReminder(c.Request.Context(), noteID, reminderID, claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reminder deleted successfully",
+	})
+}
+
+// reminderIDs parses the note and reminder IDs of the path, responding with
+// an error when either is invalid
+func reminderIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	noteID, err := uuid.Parse(c.Param("noteId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid note ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	reminderID, err := uuid.Parse(c.Param("reminderId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid reminder ID"))
+		return uuid.Nil, uuid.Nil, false
+	}
+	return noteID, reminderID, true
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/logger"
+)
+
+func TestRequestLogger_CarriesRequestUserAndRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	base := logger.NewLogger("info", "json", &buf)
+	// A service logger that never saw the request, e.g. one held in a struct
+	background := logger.NewLogger("info", "json", &buf)
+
+	jwtManager := auth.NewJWTManager("secret", 1)
+	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
+	router := gin.New()
+	router.Use(middleware.RequestID(base))
+	router.GET("/notes/:noteId", authMiddleware.RequireAuth(), func(c *gin.Context) {
+		middleware.GetLogger(c, base).Info("from handler")
+		background.WithContext(c.Request.Context()).Info("from service")
+		c.Status(http.StatusNoContent)
+	})
+
+	userID := uuid.New()
+	token, err := jwtManager.GenerateToken(&models.User{ID: userID, Role: models.RoleMember})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/notes/"+uuid.NewString(), nil)
+	req.Header.Set(middleware.AuthorizationHeader, middleware.BearerPrefix+token)
+	req.Header.Set(middleware.RequestIDHeader, "req-42")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Contains(t, string(line), `"request_id":"req-42"`)
+		assert.Contains(t, string(line), `"user_id":"`+userID.String()+`"`)
+		assert.Contains(t, string(line), `"route":"/notes/:noteId"`)
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+type SavedFilterHandler struct {
+	filterService services.SavedFilterServiceInterface
+}
+
+func NewSavedFilterHandler(filterService services.SavedFilterServiceInterface) *SavedFilterHandler {
+	return &SavedFilterHandler{
+		filterService: filterService,
+	}
+}
+
+// CreateSavedFilter creates a new saved filter for the current user
+func (h *SavedFilterHandler) CreateSavedFilter(c *gin.Context) {
+	var input services.SavedFilterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	filter, err := h.filterService.CreateSavedFilter(c.Request.Context(), &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, filter)
+}
+
+// GetSavedFilters lists the current user's saved filters
+func (h *SavedFilterHandler) GetSavedFilters(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	filters, err := h.filterService.GetUserSavedFilters(c.Request.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, filters)
+}
+
+// GetSavedFilter gets a saved filter
+func (h *SavedFilterHandler) GetSavedFilter(c *gin.Context) {
+	filterID, err := uuid.Parse(c.Param("filterId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid filter ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	filter, err := h.filterService.GetSavedFilter(c.Request.Context(), filterID, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, filter)
+}
+
+// UpdateSavedFilter updates a saved filter
+func (h *SavedFilterHandler) UpdateSavedFilter(c *gin.Context) {
+	filterID, err := uuid.Parse(c.Param("filterId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid filter ID"))
+		return
+	}
+
+	var input services.SavedFilterInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	filter, err := h.filterService.UpdateSavedFilter(c.Request.Context(), filterID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, filter)
+}
+
+// DeleteSavedFilter deletes a saved filter
+func (h *SavedFilterHandler) DeleteSavedFilter(c *gin.Context) {
+	filterID, err := uuid.Parse(c.Param("filterId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid filter ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.filterService.DeleteSavedFilter(c.Request.Context(), filterID, claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Saved filter deleted successfully",
+	})
+}
+
+// ExecuteSavedFilter returns the assets currently matching a saved filter
+func (h *SavedFilterHandler) ExecuteSavedFilter(c *gin.Context) {
+	filterID, err := uuid.Parse(c.Param("filterId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid filter ID"))
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			middleware.RespondError(c, apperrors.Validation("Invalid limit"))
+			return
+		}
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	results, err := h.filterService.ExecuteSavedFilter(c.Request.Context(), filterID, claims.UserID, limit)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// GetHome returns the current user's home payload, including pinned filters
+func (h *SavedFilterHandler) GetHome(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	home, err := h.filterService.GetHome(c.Request.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, home)
+}
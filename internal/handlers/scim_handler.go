@@ -0,0 +1,408 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+	"seta-training/pkg/logger"
+	"seta-training/pkg/scim"
+)
+
+// scimOrganizationKey holds the organization the SCIM token of a request
+// belongs to
+const scimOrganizationKey = "scim_organization_id"
+
+// SCIMHandler serves the SCIM 2.0 API identity providers provision the
+// users and teams of organizations through, and the admin endpoints that
+// issue its tokens
+type SCIMHandler struct {
+	scimService services.SCIMServiceInterface
+}
+
+func NewSCIMHandler(scimService services.SCIMServiceInterface) *SCIMHandler {
+	return &SCIMHandler{scimService: scimService}
+}
+
+// GetSCIMConnection returns the SCIM connection of an organization
+func (h *SCIMHandler) GetSCIMConnection(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	conn, err := h.scimService.GetConnection(c.Request.Context(), orgID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conn)
+}
+
+// CreateSCIMToken issues a new SCIM token for an organization, revoking
+// the one it had. The token is only returned here.
+func (h *SCIMHandler) CreateSCIMToken(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	token, err := h.scimService.CreateToken(c.Request.Context(), orgID, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// DeleteSCIMConnection deletes the SCIM connection of an organization,
+// revoking its token
+func (h *SCIMHandler) DeleteSCIMConnection(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.scimService.DeleteConnection(c.Request.Context(), orgID, claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "SCIM connection deleted successfully",
+	})
+}
+
+// Authenticate middleware checks the bearer token of SCIM requests and
+// puts the organization it belongs to in the context
+func (h *SCIMHandler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader(middleware.AuthorizationHeader), middleware.BearerPrefix)
+		if !ok || token == "" {
+			respondSCIMError(c, apperrors.Unauthorized("Authorization token required"))
+			return
+		}
+		orgID, err := h.scimService.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			respondSCIMError(c, err)
+			return
+		}
+		c.Set(scimOrganizationKey, orgID)
+		middleware.AddLogFields(c, logger.String("organization_id", orgID.String()))
+		c.Next()
+	}
+}
+
+// ListUsers lists the users of the organization, optionally filtered
+func (h *SCIMHandler) ListUsers(c *gin.Context) {
+	filter, startIndex, count, err := scimListParams(c)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	list, err := h.scimService.ListUsers(c.Request.Context(), scimOrganization(c), filter, startIndex, count)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusOK, list)
+}
+
+// GetUser returns a user of the organization
+func (h *SCIMHandler) GetUser(c *gin.Context) {
+	userID, ok := scimResourceID(c, apperrors.NotFound("user not found"))
+	if !ok {
+		return
+	}
+
+	user, err := h.scimService.GetUser(c.Request.Context(), scimOrganization(c), userID)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusOK, user)
+}
+
+// CreateUser provisions a user in the organization
+func (h *SCIMHandler) CreateUser(c *gin.Context) {
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		respondSCIMError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	user, err := h.scimService.CreateUser(c.Request.Context(), scimOrganization(c), &resource)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusCreated, user)
+}
+
+// ReplaceUser replaces the attributes of a user
+func (h *SCIMHandler) ReplaceUser(c *gin.Context) {
+	userID, ok := scimResourceID(c, apperrors.NotFound("user not found"))
+	if !ok {
+		return
+	}
+
+	var resource scim.User
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		respondSCIMError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	user, err := h.scimService.ReplaceUser(c.Request.Context(), scimOrganization(c), userID, &resource)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusOK, user)
+}
+
+// PatchUser changes some attributes of a user, such as whether they are
+// active
+func (h *SCIMHandler) PatchUser(c *gin.Context) {
+	userID, ok := scimResourceID(c, apperrors.NotFound("user not found"))
+	if !ok {
+		return
+	}
+
+	var patch scim.PatchRequest
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondSCIMError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	user, err := h.scimService.PatchUser(c.Request.Context(), scimOrganization(c), userID, &patch)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusOK, user)
+}
+
+// DeleteUser deactivates a user, who is kept along with what they own
+func (h *SCIMHandler) DeleteUser(c *gin.Context) {
+	userID, ok := scimResourceID(c, apperrors.NotFound("user not found"))
+	if !ok {
+		return
+	}
+
+	if err := h.scimService.DeactivateUser(c.Request.Context(), scimOrganization(c), userID); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListGroups lists the teams of the organization, optionally filtered
+func (h *SCIMHandler) ListGroups(c *gin.Context) {
+	filter, startIndex, count, err := scimListParams(c)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	list, err := h.scimService.ListGroups(c.Request.Context(), scimOrganization(c), filter, startIndex, count)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusOK, list)
+}
+
+// GetGroup returns a team of the organization
+func (h *SCIMHandler) GetGroup(c *gin.Context) {
+	teamID, ok := scimResourceID(c, apperrors.NotFound("team not found"))
+	if !ok {
+		return
+	}
+
+	group, err := h.scimService.GetGroup(c.Request.Context(), scimOrganization(c), teamID)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusOK, group)
+}
+
+// CreateGroup creates a team in the organization
+func (h *SCIMHandler) CreateGroup(c *gin.Context) {
+	var resource scim.Group
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		respondSCIMError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	group, err := h.scimService.CreateGroup(c.Request.Context(), scimOrganization(c), &resource)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusCreated, group)
+}
+
+// ReplaceGroup renames a team and replaces its people
+func (h *SCIMHandler) ReplaceGroup(c *gin.Context) {
+	teamID, ok := scimResourceID(c, apperrors.NotFound("team not found"))
+	if !ok {
+		return
+	}
+
+	var resource scim.Group
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		respondSCIMError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	group, err := h.scimService.ReplaceGroup(c.Request.Context(), scimOrganization(c), teamID, &resource)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusOK, group)
+}
+
+// PatchGroup renames a team or adds and removes people
+func (h *SCIMHandler) PatchGroup(c *gin.Context) {
+	teamID, ok := scimResourceID(c, apperrors.NotFound("team not found"))
+	if !ok {
+		return
+	}
+
+	var patch scim.PatchRequest
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondSCIMError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	group, err := h.scimService.PatchGroup(c.Request.Context(), scimOrganization(c), teamID, &patch)
+	if err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	respondSCIM(c, http.StatusOK, group)
+}
+
+// DeleteGroup deletes a team
+func (h *SCIMHandler) DeleteGroup(c *gin.Context) {
+	teamID, ok := scimResourceID(c, apperrors.NotFound("team not found"))
+	if !ok {
+		return
+	}
+
+	if err := h.scimService.DeleteGroup(c.Request.Context(), scimOrganization(c), teamID); err != nil {
+		respondSCIMError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// scimOrganization returns the organization set by Authenticate
+func scimOrganization(c *gin.Context) uuid.UUID {
+	orgID, _ := c.Get(scimOrganizationKey)
+	id, _ := orgID.(uuid.UUID)
+	return id
+}
+
+// scimResourceID parses the id route parameter. Resources that cannot
+// exist are reported with notFound.
+func scimResourceID(c *gin.Context, notFound *apperrors.Error) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		respondSCIMError(c, notFound)
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// scimListParams reads the filter, 1-based startIndex and count of a
+// listing, which returns scim.MaxResults resources from the first by
+// default
+func scimListParams(c *gin.Context) (filter string, startIndex, count int, err error) {
+	startIndex, count = 1, scim.MaxResults
+	fields := map[string]string{}
+	if v := c.Query("startIndex"); v != "" {
+		if startIndex, err = strconv.Atoi(v); err != nil {
+			fields["startIndex"] = "must be a number"
+		}
+	}
+	if v := c.Query("count"); v != "" {
+		if count, err = strconv.Atoi(v); err != nil {
+			fields["count"] = "must be a number"
+		}
+	}
+	if len(fields) > 0 {
+		return "", 0, 0, apperrors.ValidationFields("Invalid SCIM query", fields)
+	}
+	return c.Query("filter"), startIndex, count, nil
+}
+
+// respondSCIM writes body as a SCIM message
+func respondSCIM(c *gin.Context, status int, body interface{}) {
+	c.Header("Content-Type", scim.ContentType)
+	c.JSON(status, body)
+}
+
+// respondSCIMError aborts a SCIM request with err rendered as a SCIM
+// error. Field errors are appended to the detail.
+func respondSCIMError(c *gin.Context, err error) {
+	status, resp := middleware.ErrorResponse(c, err)
+	detail := resp.Message
+	if len(resp.Details) > 0 {
+		fields := make([]string, 0, len(resp.Details))
+		for field, message := range resp.Details {
+			fields = append(fields, fmt.Sprintf("%s %v", field, message))
+		}
+		slices.Sort(fields)
+		detail += ": " + strings.Join(fields, "; ")
+	}
+
+	var scimType string
+	switch resp.Code {
+	case apperrors.CodeConflict:
+		scimType = "uniqueness"
+	case apperrors.CodeValidation:
+		scimType = "invalidValue"
+	}
+	c.Header("Content-Type", scim.ContentType)
+	c.AbortWithStatusJSON(status, scim.NewError(status, scimType, detail))
+}
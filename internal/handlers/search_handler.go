@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+type SearchHandler struct {
+	searchService services.SearchServiceInterface
+}
+
+func NewSearchHandler(searchService services.SearchServiceInterface) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// Search finds the notes, folders, teams and users the current user can
+// open that match the q query parameter
+func (h *SearchHandler) Search(c *gin.Context) {
+	query, err := parseSearchQuery(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	results, err := h.searchService.Search(c.Request.Context(), claims.UserID, claims.OrgID, query)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+func parseSearchQuery(c *gin.Context) (services.SearchQuery, error) {
+	query := services.SearchQuery{Text: strings.TrimSpace(c.Query("q"))}
+	fields := make(map[string]string)
+
+	if n := utf8.RuneCountInString(query.Text); n < services.MinSearchQueryLength || n > services.MaxSearchQueryLength {
+		fields["q"] = "must be between " + strconv.Itoa(services.MinSearchQueryLength) + " and " + strconv.Itoa(services.MaxSearchQueryLength) + " characters"
+	}
+	if v := c.Query("types"); v != "" {
+		for _, typ := range strings.Split(v, ",") {
+			typ = strings.TrimSpace(typ)
+			if !slices.Contains(services.SearchTypes, typ) {
+				fields["types"] = "must be a comma-separated list of: " + strings.Join(services.SearchTypes, ", ")
+				break
+			}
+			if !slices.Contains(query.Types, typ) {
+				query.Types = append(query.Types, typ)
+			}
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > services.MaxSearchLimit {
+			fields["limit"] = "must be between 1 and " + strconv.Itoa(services.MaxSearchLimit)
+		}
+		query.Limit = limit
+	}
+
+	if len(fields) > 0 {
+		return query, apperrors.ValidationFields("Invalid search", fields)
+	}
+	return query, nil
+}
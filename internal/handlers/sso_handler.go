@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// samlRequestCookie remembers the ID of the authentication request a login
+// waits for the answer to, for samlRequestMaxAge seconds
+const (
+	samlRequestCookie = "saml_request"
+	samlRequestMaxAge = 600
+)
+
+// SSOHandler serves the SAML service provider endpoints of each
+// organization and the admin endpoints that configure them
+type SSOHandler struct {
+	ssoService     services.SSOServiceInterface
+	allowedOrigins []string
+	secureCookies  bool
+}
+
+// NewSSOHandler creates an SSO handler. Logins started with a redirect_to on
+// one of allowedOrigins are sent back there with the token. secureCookies
+// must be set behind HTTPS, where the identity provider's cross-site POST
+// only carries Secure cookies.
+func NewSSOHandler(ssoService services.SSOServiceInterface, allowedOrigins []string, secureCookies bool) *SSOHandler {
+	return &SSOHandler{
+		ssoService:     ssoService,
+		allowedOrigins: allowedOrigins,
+		secureCookies:  secureCookies,
+	}
+}
+
+// Metadata serves the service provider metadata of an organization
+func (h *SSOHandler) Metadata(c *gin.Context) {
+	metadata, err := h.ssoService.Metadata(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// Login sends the user to their organization's identity provider
+func (h *SSOHandler) Login(c *gin.Context) {
+	slug := c.Param("slug")
+	redirectTo := c.Query("redirect_to")
+	if redirectTo != "" && !h.redirectAllowed(redirectTo) {
+		middleware.RespondError(c, apperrors.ValidationFields("Invalid login", map[string]string{
+			"redirect_to": "must be on an allowed origin",
+		}))
+		return
+	}
+
+	loginURL, requestID, err := h.ssoService.LoginURL(c.Request.Context(), slug, redirectTo)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	h.setRequestCookie(c, slug, requestID, samlRequestMaxAge)
+	c.Redirect(http.StatusFound, loginURL)
+}
+
+// ACS is the assertion consumer service the identity provider posts its
+// response to. The user is sent to the login's redirect_to with the token
+// in the URL fragment, or the login response is returned without one.
+func (h *SSOHandler) ACS(c *gin.Context) {
+	slug := c.Param("slug")
+	samlResponse := c.PostForm("SAMLResponse")
+	if samlResponse == "" {
+		middleware.RespondError(c, apperrors.Validation("SAMLResponse is required"))
+		return
+	}
+
+	var requestIDs []string
+	if requestID, err := c.Cookie(samlRequestCookie); err == nil && requestID != "" {
+		requestIDs = []string{requestID}
+	}
+	h.setRequestCookie(c, slug, "", -1)
+
+	response, err := h.ssoService.CompleteLogin(c.Request.Context(), slug, samlResponse, requestIDs)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	if relayState := c.PostForm("RelayState"); relayState != "" && h.redirectAllowed(relayState) {
+		target, _ := url.Parse(relayState)
+		target.Fragment = "token=" + response.Token
+		c.Redirect(http.StatusSeeOther, target.String())
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// redirectAllowed reports whether raw is an absolute URL on one of the
+// allowed origins. A wildcard origin allows none, since the token is sent
+// along.
+func (h *SSOHandler) redirectAllowed(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	return slices.Contains(h.allowedOrigins, u.Scheme+"://"+u.Host)
+}
+
+// setRequestCookie sets, or with a negative maxAge clears, the cookie with
+// the ID of the authentication request a login of slug waits for
+func (h *SSOHandler) setRequestCookie(c *gin.Context, slug, requestID string, maxAge int) {
+	if h.secureCookies {
+		c.SetSameSite(http.SameSiteNoneMode)
+	} else {
+		c.SetSameSite(http.SameSiteLaxMode)
+	}
+	c.SetCookie(samlRequestCookie, requestID, maxAge, "/sso/saml/"+slug, "", h.secureCookies, true)
+}
+
+// GetSAMLConnection returns the SAML connection of an organization
+func (h *SSOHandler) GetSAMLConnection(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	conn, err := h.ssoService.GetConnection(c.Request.Context(), orgID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conn)
+}
+
+// PutSAMLConnection sets up or replaces the SAML connection of an
+// organization
+func (h *SSOHandler) PutSAMLConnection(c *gin.Context) {
+	orgID, err := uuid.Parse(c.Param("orgId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid organization ID"))
+		return
+	}
+
+	var input services.SAMLConnectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	conn, err := h.ssoService.SaveConnection(c.Request.Context(), orgID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conn)
+}
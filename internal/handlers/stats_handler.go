@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+const defaultStatsDays = 30
+
+// StatsHandler serves the admin dashboard statistics
+type StatsHandler struct {
+	statsService services.StatsServiceInterface
+}
+
+func NewStatsHandler(statsService services.StatsServiceInterface) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+	}
+}
+
+// GetStats returns totals and a daily series over the last days days
+func (h *StatsHandler) GetStats(c *gin.Context) {
+	days := defaultStatsDays
+	if v := c.Query("days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > services.MaxStatsDays {
+			middleware.RespondError(c, apperrors.ValidationFields("Invalid statistics range", map[string]string{
+				"days": "must be between 1 and " + strconv.Itoa(services.MaxStatsDays),
+			}))
+			return
+		}
+		days = n
+	}
+
+	stats, err := h.statsService.GetStats(c.Request.Context(), days)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+)
+
+// TeamCodec maps team payloads to and from one API version's wire format.
+// TeamHandler's logic is shared by every version; only the shapes differ.
+type TeamCodec interface {
+	BindCreateTeam(c *gin.Context) (*services.CreateTeamInput, error)
+	// BindUserID reads the user being added as a member or manager
+	BindUserID(c *gin.Context) (uuid.UUID, error)
+	Team(team *models.Team) interface{}
+	Teams(teams []models.Team) interface{}
+	// Done acknowledges a membership change
+	Done(c *gin.Context, message string)
+}
+
+type userIDRequest struct {
+	UserID uuid.UUID `json:"userId" binding:"required"`
+}
+
+// TeamCodecV1 serves the models as they are
+type TeamCodecV1 struct{}
+
+func (TeamCodecV1) BindCreateTeam(c *gin.Context) (*services.CreateTeamInput, error) {
+	var input services.CreateTeamInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return nil, err
+	}
+	return &input, nil
+}
+
+func (TeamCodecV1) BindUserID(c *gin.Context) (uuid.UUID, error) {
+	var input userIDRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		return uuid.Nil, err
+	}
+	return input.UserID, nil
+}
+
+func (TeamCodecV1) Team(team *models.Team) interface{} {
+	return team
+}
+
+func (TeamCodecV1) Teams(teams []models.Team) interface{} {
+	return teams
+}
+
+func (TeamCodecV1) Done(c *gin.Context, message string) {
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+	})
+}
+
+// CreateTeamRequestV2 names members by ID only
+type CreateTeamRequestV2 struct {
+	Name         string      `json:"name" binding:"required,min=3,max=100"`
+	ManagerIDs   []uuid.UUID `json:"managerIds"`
+	MemberIDs    []uuid.UUID `json:"memberIds"`
+	SharedFolder bool        `json:"sharedFolder"`
+}
+
+// TeamUserV2 is the public view of a team's manager or member
+type TeamUserV2 struct {
+	ID       uuid.UUID       `json:"id"`
+	Username string          `json:"username"`
+	Role     models.UserRole `json:"role"`
+}
+
+type TeamV2 struct {
+	ID        uuid.UUID      `json:"id"`
+	Name      string         `json:"name"`
+	Admins    []TeamUserV2   `json:"admins"`
+	Managers  []TeamUserV2   `json:"managers"`
+	Members   []TeamUserV2   `json:"members"`
+	Viewers   []TeamUserV2   `json:"viewers"`
+	FolderID  *uuid.UUID     `json:"folderId,omitempty"`
+	Settings  TeamSettingsV2 `json:"settings"`
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// TeamSettingsV2 is a team's sharing policy
+type TeamSettingsV2 struct {
+	AllowExternalSharing bool               `json:"allowExternalSharing"`
+	AllowPublicLinks     bool               `json:"allowPublicLinks"`
+	DefaultAccess        models.AccessLevel `json:"defaultAccess"`
+}
+
+// ListResponseV2 wraps every v2 collection so fields such as paging can be
+// added without breaking clients
+type ListResponseV2 struct {
+	Data  interface{} `json:"data"`
+	Count int         `json:"count"`
+}
+
+// TeamCodecV2 uses camelCase DTOs, wraps lists and answers membership
+// changes with 204 No Content
+type TeamCodecV2 struct{}
+
+func (TeamCodecV2) BindCreateTeam(c *gin.Context) (*services.CreateTeamInput, error) {
+	var req CreateTeamRequestV2
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, err
+	}
+
+	input := &services.CreateTeamInput{Name: req.Name, SharedFolder: req.SharedFolder}
+	for _, id := range req.ManagerIDs {
+		input.Managers = append(input.Managers, services.TeamMemberInput{ID: id})
+	}
+	for _, id := range req.MemberIDs {
+		input.Members = append(input.Members, services.TeamMemberInput{ID: id})
+	}
+	return input, nil
+}
+
+func (TeamCodecV2) BindUserID(c *gin.Context) (uuid.UUID, error) {
+	return TeamCodecV1{}.BindUserID(c)
+}
+
+func (TeamCodecV2) Team(team *models.Team) interface{} {
+	return newTeamV2(team)
+}
+
+func (TeamCodecV2) Teams(teams []models.Team) interface{} {
+	data := make([]TeamV2, 0, len(teams))
+	for i := range teams {
+		data = append(data, newTeamV2(&teams[i]))
+	}
+	return ListResponseV2{Data: data, Count: len(data)}
+}
+
+func (TeamCodecV2) Done(c *gin.Context, message string) {
+	c.Status(http.StatusNoContent)
+}
+
+func newTeamV2(team *models.Team) TeamV2 {
+	return TeamV2{
+		ID:        team.ID,
+		Name:      team.Name,
+		Admins:    newTeamUsersV2(team.Admins),
+		Managers:  newTeamUsersV2(team.Managers),
+		Members:   newTeamUsersV2(team.Members),
+		Viewers:   newTeamUsersV2(team.Viewers),
+		FolderID:  team.FolderID,
+		Settings:  TeamSettingsV2(team.Settings),
+		CreatedAt: team.CreatedAt,
+		UpdatedAt: team.UpdatedAt,
+	}
+}
+
+func newTeamUsersV2(users []models.User) []TeamUserV2 {
+	out := make([]TeamUserV2, 0, len(users))
+	for _, u := range users {
+		out = append(out, TeamUserV2{ID: u.ID, Username: u.Username, Role: u.Role})
+	}
+	return out
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+)
+
+func TestTeamHandlerV2_CreateTeam_MapsIDs(t *testing.T) {
+	mockService := new(MockTeamService)
+	handler := NewVersionedTeamHandler(mockService, TeamCodecV2{})
+	router := setupTestRouter()
+
+	userID := uuid.New()
+	managerID := uuid.New()
+	memberID := uuid.New()
+	expectedInput := &services.CreateTeamInput{
+		Name:     "Platform",
+		Managers: []services.TeamMemberInput{{ID: managerID}},
+		Members:  []services.TeamMemberInput{{ID: memberID}},
+	}
+	team := &models.Team{
+		ID:       uuid.New(),
+		Name:     "Platform",
+		Managers: []models.User{{ID: managerID, Username: "alice", Role: models.RoleManager}},
+		Members:  []models.User{{ID: memberID, Username: "bob", Role: models.RoleMember}},
+	}
+	mockService.On("CreateTeam", expectedInput, userID).Return(team, nil)
+
+	router.POST("/teams", func(c *gin.Context) {
+		setupAuthContext(c, userID, models.RoleManager)
+		handler.CreateTeam(c)
+	})
+
+	body, _ := json.Marshal(CreateTeamRequestV2{
+		Name:       "Platform",
+		ManagerIDs: []uuid.UUID{managerID},
+		MemberIDs:  []uuid.UUID{memberID},
+	})
+	req, _ := http.NewRequest("POST", "/teams", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response TeamV2
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Platform", response.Name)
+	assert.Equal(t, "alice", response.Managers[0].Username)
+	assert.Contains(t, w.Body.String(), `"createdAt"`)
+	assert.NotContains(t, w.Body.String(), "email")
+	mockService.AssertExpectations(t)
+}
+
+func TestTeamHandlerV2_GetAllTeams_WrapsList(t *testing.T) {
+	mockService := new(MockTeamService)
+	handler := NewVersionedTeamHandler(mockService, TeamCodecV2{})
+	router := setupTestRouter()
+
+	mockService.On("GetAllTeams", (*uuid.UUID)(nil)).Return([]models.Team{{ID: uuid.New(), Name: "Platform"}}, nil)
+	router.GET("/teams", handler.GetAllTeams)
+
+	req, _ := http.NewRequest("GET", "/teams", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Data  []TeamV2 `json:"data"`
+		Count int      `json:"count"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 1, response.Count)
+	assert.Equal(t, "Platform", response.Data[0].Name)
+}
+
+func TestTeamHandlerV2_AddMember_NoContent(t *testing.T) {
+	mockService := new(MockTeamService)
+	handler := NewVersionedTeamHandler(mockService, TeamCodecV2{})
+	router := setupTestRouter()
+
+	managerID := uuid.New()
+	teamID := uuid.New()
+	memberID := uuid.New()
+	mockService.On("AddMember", teamID, memberID, managerID).Return(nil)
+
+	router.POST("/teams/:teamId/members", func(c *gin.Context) {
+		setupAuthContext(c, managerID, models.RoleManager)
+		handler.AddMember(c)
+	})
+
+	body, _ := json.Marshal(map[string]uuid.UUID{"userId": memberID})
+	req, _ := http.NewRequest("POST", "/teams/"+teamID.String()+"/members", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	mockService.AssertExpectations(t)
+}
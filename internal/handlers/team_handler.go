@@ -5,48 +5,51 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/middleware"
 	"seta-training/internal/services"
 )
 
 type TeamHandler struct {
 	teamService services.TeamServiceInterface
+	codec       TeamCodec
 }
 
+// NewTeamHandler serves teams in the v1 wire format
 func NewTeamHandler(teamService services.TeamServiceInterface) *TeamHandler {
+	return NewVersionedTeamHandler(teamService, TeamCodecV1{})
+}
+
+// NewVersionedTeamHandler serves teams in the wire format of codec
+func NewVersionedTeamHandler(teamService services.TeamServiceInterface, codec TeamCodec) *TeamHandler {
 	return &TeamHandler{
 		teamService: teamService,
+		codec:       codec,
 	}
 }
 
 // CreateTeam creates a new team
 func (h *TeamHandler) CreateTeam(c *gin.Context) {
-	var input services.CreateTeamInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input: " + err.Error(),
-		})
+	input, err := h.codec.BindCreateTeam(c)
+	if err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	team, err := h.teamService.CreateTeam(&input, claims.UserID)
+	team, err := h.teamService.CreateTeam(c.Request.Context(), input, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, team)
+	c.JSON(http.StatusCreated, h.codec.Team(team))
 }
 
 // AddMember adds a member to a team
@@ -54,42 +57,30 @@ func (h *TeamHandler) AddMember(c *gin.Context) {
 	teamIDStr := c.Param("teamId")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid team ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid team ID"))
 		return
 	}
 
-	var input struct {
-		UserID uuid.UUID `json:"userId" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input: " + err.Error(),
-		})
+	userID, err := h.codec.BindUserID(c)
+	if err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.teamService.AddMember(teamID, input.UserID, claims.UserID)
+	err = h.teamService.AddMember(c.Request.Context(), teamID, userID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Member added successfully",
-	})
+	h.codec.Done(c, "Member added successfully")
 }
 
 // RemoveMember removes a member from a team
@@ -97,41 +88,31 @@ func (h *TeamHandler) RemoveMember(c *gin.Context) {
 	teamIDStr := c.Param("teamId")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid team ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid team ID"))
 		return
 	}
 
 	memberIDStr := c.Param("memberId")
 	memberID, err := uuid.Parse(memberIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid member ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid member ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.teamService.RemoveMember(teamID, memberID, claims.UserID)
+	err = h.teamService.RemoveMember(c.Request.Context(), teamID, memberID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Member removed successfully",
-	})
+	h.codec.Done(c, "Member removed successfully")
 }
 
 // AddManager adds a manager to a team
@@ -139,42 +120,30 @@ func (h *TeamHandler) AddManager(c *gin.Context) {
 	teamIDStr := c.Param("teamId")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid team ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid team ID"))
 		return
 	}
 
-	var input struct {
-		UserID uuid.UUID `json:"userId" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input: " + err.Error(),
-		})
+	userID, err := h.codec.BindUserID(c)
+	if err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.teamService.AddManager(teamID, input.UserID, claims.UserID)
+	err = h.teamService.AddManager(c.Request.Context(), teamID, userID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Manager added successfully",
-	})
+	h.codec.Done(c, "Manager added successfully")
 }
 
 // RemoveManager removes a manager from a team
@@ -182,41 +151,31 @@ func (h *TeamHandler) RemoveManager(c *gin.Context) {
 	teamIDStr := c.Param("teamId")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid team ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid team ID"))
 		return
 	}
 
 	managerIDStr := c.Param("managerId")
 	managerID, err := uuid.Parse(managerIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid manager ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid manager ID"))
 		return
 	}
 
 	// Get current user from context
 	claims, exists := middleware.GetCurrentUser(c)
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Authentication required",
-		})
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
 		return
 	}
 
-	err = h.teamService.RemoveManager(teamID, managerID, claims.UserID)
+	err = h.teamService.RemoveManager(c.Request.Context(), teamID, managerID, claims.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Manager removed successfully",
-	})
+	h.codec.Done(c, "Manager removed successfully")
 }
 
 // GetTeam gets team details
@@ -224,32 +183,116 @@ func (h *TeamHandler) GetTeam(c *gin.Context) {
 	teamIDStr := c.Param("teamId")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid team ID",
-		})
+		middleware.RespondError(c, apperrors.Validation("Invalid team ID"))
 		return
 	}
 
-	team, err := h.teamService.GetTeam(teamID)
+	var orgID *uuid.UUID
+	if claims, ok := middleware.GetCurrentUser(c); ok {
+		orgID = claims.OrgID
+	}
+
+	team, err := h.teamService.GetTeam(c.Request.Context(), teamID, orgID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, team)
+	c.JSON(http.StatusOK, h.codec.Team(team))
 }
 
-// GetAllTeams gets all teams
+// SetRole gives a user a role in a team, adding them to it if needed
+func (h *TeamHandler) SetRole(c *gin.Context) {
+	teamID, err := uuid.Parse(c.Param("teamId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid team ID"))
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid user ID"))
+		return
+	}
+
+	var input services.SetRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	err = h.teamService.SetRole(c.Request.Context(), teamID, userID, input.Role, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	h.codec.Done(c, "Role updated successfully")
+}
+
+// UpdateSettings changes the sharing policy of a team
+func (h *TeamHandler) UpdateSettings(c *gin.Context) {
+	teamID, err := uuid.Parse(c.Param("teamId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid team ID"))
+		return
+	}
+
+	var input services.TeamSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	team, err := h.teamService.UpdateSettings(c.Request.Context(), teamID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.codec.Team(team))
+}
+
+// GetAllTeams gets the teams of the caller's organization, one page at a
+// time when limit or cursor is given
 func (h *TeamHandler) GetAllTeams(c *gin.Context) {
-	teams, err := h.teamService.GetAllTeams()
+	var orgID *uuid.UUID
+	if claims, ok := middleware.GetCurrentUser(c); ok {
+		orgID = claims.OrgID
+	}
+
+	params, paged, err := parsePageParams(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+	if paged {
+		page, err := h.teamService.ListTeams(c.Request.Context(), orgID, params)
+		if err != nil {
+			middleware.RespondError(c, err)
+			return
+		}
+		setNextCursor(c, page.Next)
+		c.JSON(http.StatusOK, h.codec.Teams(page.Items))
+		return
+	}
+
+	teams, err := h.teamService.GetAllTeams(c.Request.Context(), orgID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		middleware.RespondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, teams)
+	c.JSON(http.StatusOK, h.codec.Teams(teams))
 }
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,6 +16,7 @@ import (
 	"seta-training/internal/models"
 	"seta-training/internal/services"
 	"seta-training/pkg/auth"
+	"seta-training/pkg/pagination"
 )
 
 // MockTeamService is a mock implementation of TeamServiceInterface
@@ -21,7 +24,7 @@ type MockTeamService struct {
 	mock.Mock
 }
 
-func (m *MockTeamService) CreateTeam(input *services.CreateTeamInput, creatorID uuid.UUID) (*models.Team, error) {
+func (m *MockTeamService) CreateTeam(ctx context.Context, input *services.CreateTeamInput, creatorID uuid.UUID) (*models.Team, error) {
 	args := m.Called(input, creatorID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -29,39 +32,57 @@ func (m *MockTeamService) CreateTeam(input *services.CreateTeamInput, creatorID
 	return args.Get(0).(*models.Team), args.Error(1)
 }
 
-func (m *MockTeamService) AddMember(teamID, userID, managerID uuid.UUID) error {
+func (m *MockTeamService) AddMember(ctx context.Context, teamID, userID, managerID uuid.UUID) error {
 	args := m.Called(teamID, userID, managerID)
 	return args.Error(0)
 }
 
-func (m *MockTeamService) RemoveMember(teamID, userID, managerID uuid.UUID) error {
+func (m *MockTeamService) RemoveMember(ctx context.Context, teamID, userID, managerID uuid.UUID) error {
 	args := m.Called(teamID, userID, managerID)
 	return args.Error(0)
 }
 
-func (m *MockTeamService) AddManager(teamID, userID, requestorID uuid.UUID) error {
+func (m *MockTeamService) AddManager(ctx context.Context, teamID, userID, requestorID uuid.UUID) error {
 	args := m.Called(teamID, userID, requestorID)
 	return args.Error(0)
 }
 
-func (m *MockTeamService) RemoveManager(teamID, userID, requestorID uuid.UUID) error {
+func (m *MockTeamService) RemoveManager(ctx context.Context, teamID, userID, requestorID uuid.UUID) error {
 	args := m.Called(teamID, userID, requestorID)
 	return args.Error(0)
 }
 
-func (m *MockTeamService) GetTeam(teamID uuid.UUID) (*models.Team, error) {
-	args := m.Called(teamID)
+func (m *MockTeamService) GetTeam(ctx context.Context, teamID uuid.UUID, orgID *uuid.UUID) (*models.Team, error) {
+	args := m.Called(teamID, orgID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Team), args.Error(1)
 }
 
-func (m *MockTeamService) GetAllTeams() ([]models.Team, error) {
-	args := m.Called()
+func (m *MockTeamService) GetAllTeams(ctx context.Context, orgID *uuid.UUID) ([]models.Team, error) {
+	args := m.Called(orgID)
 	return args.Get(0).([]models.Team), args.Error(1)
 }
 
+func (m *MockTeamService) ListTeams(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.Team], error) {
+	args := m.Called(orgID, p)
+	return args.Get(0).(pagination.Page[models.Team]), args.Error(1)
+}
+
+func (m *MockTeamService) SetRole(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole, requesterID uuid.UUID) error {
+	args := m.Called(teamID, userID, role, requesterID)
+	return args.Error(0)
+}
+
+func (m *MockTeamService) UpdateSettings(ctx context.Context, teamID uuid.UUID, input *services.TeamSettingsInput, managerID uuid.UUID) (*models.Team, error) {
+	args := m.Called(teamID, input, managerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Team), args.Error(1)
+}
+
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	return gin.New()
@@ -146,10 +167,42 @@ func TestTeamHandler_CreateTeam_InvalidInput(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	
-	var response map[string]string
+	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Contains(t, response["error"], "Invalid input")
+	assert.Equal(t, "validation_failed", response["code"])
+	assert.Contains(t, response["message"], "Invalid input")
+}
+
+func TestTeamHandler_CreateTeam_FieldDetails(t *testing.T) {
+	// Setup
+	mockService := new(MockTeamService)
+	handler := NewTeamHandler(mockService)
+	router := setupTestRouter()
+
+	router.POST("/teams", func(c *gin.Context) {
+		setupAuthContext(c, uuid.New(), models.RoleManager)
+		handler.CreateTeam(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/teams", bytes.NewBuffer([]byte(`{"teamName": "ab"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	// Test
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response struct {
+		Code    string            `json:"code"`
+		Details map[string]string `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "validation_failed", response.Code)
+	assert.Equal(t, "must be at least 3 characters", response.Details["teamName"])
+	mockService.AssertNotCalled(t, "CreateTeam", mock.Anything, mock.Anything)
 }
 
 func TestTeamHandler_GetTeam_Success(t *testing.T) {
@@ -166,7 +219,7 @@ func TestTeamHandler_GetTeam_Success(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockService.On("GetTeam", teamID).Return(expectedTeam, nil)
+	mockService.On("GetTeam", teamID, (*uuid.UUID)(nil)).Return(expectedTeam, nil)
 
 	// Setup route with auth context
 	router.GET("/teams/:teamId", func(c *gin.Context) {
@@ -215,10 +268,11 @@ func TestTeamHandler_GetTeam_InvalidID(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	
-	var response map[string]string
+	var response map[string]interface{}
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Contains(t, response["error"], "Invalid team ID")
+	assert.Equal(t, "validation_failed", response["code"])
+	assert.Contains(t, response["message"], "Invalid team ID")
 }
 
 func TestTeamHandler_AddMember_Success(t *testing.T) {
@@ -264,3 +318,40 @@ func TestTeamHandler_AddMember_Success(t *testing.T) {
 	assert.Equal(t, "Member added successfully", response["message"])
 	mockService.AssertExpectations(t)
 }
+
+func TestTeamHandler_GetAllTeams_Paged(t *testing.T) {
+	// Setup
+	mockService := new(MockTeamService)
+	handler := NewTeamHandler(mockService)
+	router := setupTestRouter()
+	router.GET("/teams", handler.GetAllTeams)
+
+	after := pagination.Cursor{CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC), ID: uuid.New()}
+	next := pagination.Cursor{CreatedAt: after.CreatedAt.Add(time.Second), ID: uuid.New()}
+	teams := []models.Team{{ID: next.ID, Name: "Platform"}}
+	mockService.On("ListTeams", (*uuid.UUID)(nil), pagination.Params{Limit: 1, After: &after}).
+		Return(pagination.Page[models.Team]{Items: teams, Next: next.Encode()}, nil)
+
+	// Test
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teams?limit=1&cursor="+after.Encode(), nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, next.Encode(), w.Header().Get(NextCursorHeader))
+	var response []models.Team
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Platform", response[0].Name)
+	mockService.AssertNotCalled(t, "GetAllTeams", mock.Anything)
+
+	// An unknown cursor is rejected rather than restarting the listing
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teams?cursor=bogus&limit=500", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var errResponse struct {
+		Details map[string]string `json:"details"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResponse))
+	assert.Contains(t, errResponse.Details, "cursor")
+	assert.Contains(t, errResponse.Details, "limit")
+}
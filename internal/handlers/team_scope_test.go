@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/middleware"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+func TestAuthMiddleware_TeamScopedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	administered, managed, joined := uuid.New(), uuid.New(), uuid.New()
+	builder := func(claims *auth.Claims) error {
+		claims.Teams = []auth.TeamClaim{
+			{ID: administered, Role: models.TeamRoleAdmin},
+			{ID: managed, Role: models.TeamRoleManager},
+			{ID: joined, Role: models.TeamRoleMember},
+		}
+		return nil
+	}
+
+	jwtManager := auth.NewJWTManager("secret", 1)
+	authMiddleware := middleware.NewAuthMiddleware(jwtManager)
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	router := gin.New()
+	router.GET("/teams/:teamId/assets", authMiddleware.RequireAuth(), authMiddleware.RequireTeamManager("teamId"), ok)
+	router.GET("/audit-logs", authMiddleware.RequireAuth(), authMiddleware.RequireScope(auth.ScopeAuditRead), ok)
+
+	request := func(token, path string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(middleware.AuthorizationHeader, middleware.BearerPrefix+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Without a builder the token carries no memberships and the handler decides
+	legacy, err := jwtManager.GenerateToken(&models.User{ID: uuid.New(), Role: models.RoleManager})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, request(legacy, "/teams/"+uuid.NewString()+"/assets"))
+
+	jwtManager.SetClaimsBuilder(builder)
+	manager, err := jwtManager.GenerateToken(&models.User{ID: uuid.New(), Role: models.RoleManager})
+	require.NoError(t, err)
+	member, err := jwtManager.GenerateToken(&models.User{ID: uuid.New(), Role: models.RoleMember})
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusNoContent, request(manager, "/teams/"+administered.String()+"/assets"))
+	assert.Equal(t, http.StatusNoContent, request(manager, "/teams/"+managed.String()+"/assets"))
+	assert.Equal(t, http.StatusForbidden, request(manager, "/teams/"+joined.String()+"/assets"))
+	assert.Equal(t, http.StatusForbidden, request(manager, "/teams/"+uuid.NewString()+"/assets"))
+	assert.Equal(t, http.StatusBadRequest, request(manager, "/teams/not-a-uuid/assets"))
+
+	assert.Equal(t, http.StatusNoContent, request(manager, "/audit-logs"))
+	assert.Equal(t, http.StatusForbidden, request(member, "/audit-logs"))
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/usage"
+)
+
+const (
+	defaultUsageDays  = 30
+	defaultUsageLimit = 50
+	maxUsageLimit     = 500
+)
+
+// UsageHandler serves API usage reports to admins
+type UsageHandler struct {
+	store usage.Store
+	now   func() time.Time
+}
+
+func NewUsageHandler(store usage.Store) *UsageHandler {
+	return &UsageHandler{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// GetUsage reports requests, errors and latency grouped by user, team or
+// route over a from/to range of days, busiest first
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	filter, err := h.parseUsageFilter(c)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	summaries, err := h.store.Summarize(c.Request.Context(), filter)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group_by": filter.GroupBy,
+		"from":     filter.From.Format(time.DateOnly),
+		"to":       filter.To.AddDate(0, 0, -1).Format(time.DateOnly),
+		"entries":  summaries,
+	})
+}
+
+// parseUsageFilter defaults to users over the last 30 days, today included.
+// Both ends of the range are inclusive.
+func (h *UsageHandler) parseUsageFilter(c *gin.Context) (usage.Filter, error) {
+	today := h.now().UTC().Truncate(24 * time.Hour)
+	filter := usage.Filter{
+		GroupBy: c.DefaultQuery("group_by", usage.GroupByUser),
+		From:    today.AddDate(0, 0, 1-defaultUsageDays),
+		To:      today.AddDate(0, 0, 1),
+		Limit:   defaultUsageLimit,
+	}
+	fields := make(map[string]string)
+
+	if !slices.Contains(usage.GroupBys, filter.GroupBy) {
+		fields["group_by"] = "must be one of: " + strings.Join(usage.GroupBys, ", ")
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			fields["from"] = "must be a YYYY-MM-DD date"
+		}
+		filter.From = from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			fields["to"] = "must be a YYYY-MM-DD date"
+		}
+		filter.To = to.AddDate(0, 0, 1)
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxUsageLimit {
+			fields["limit"] = "must be between 1 and " + strconv.Itoa(maxUsageLimit)
+		}
+		filter.Limit = limit
+	}
+
+	if len(fields) > 0 {
+		return filter, apperrors.ValidationFields("Invalid usage filter", fields)
+	}
+	if !filter.From.Before(filter.To) {
+		return filter, apperrors.ValidationFields("Invalid usage filter", map[string]string{
+			"to": "must not be before from",
+		})
+	}
+	return filter, nil
+}
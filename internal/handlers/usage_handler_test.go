@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/usage"
+)
+
+// MockUsageStore is a mock implementation of usage.Store
+type MockUsageStore struct {
+	mock.Mock
+}
+
+func (m *MockUsageStore) Add(ctx context.Context, rows []models.APIUsage) error {
+	args := m.Called(rows)
+	return args.Error(0)
+}
+
+func (m *MockUsageStore) Summarize(ctx context.Context, filter usage.Filter) ([]usage.Summary, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]usage.Summary), args.Error(1)
+}
+
+func TestUsageHandler_GetUsage(t *testing.T) {
+	store := new(MockUsageStore)
+	handler := NewUsageHandler(store)
+	handler.now = func() time.Time { return time.Date(2026, 10, 16, 15, 4, 5, 0, time.UTC) }
+	router := gin.New()
+	router.GET("/admin/usage", handler.GetUsage)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/usage"+query, nil))
+		return w
+	}
+
+	t.Run("defaults to users over the last 30 days", func(t *testing.T) {
+		store.On("Summarize", usage.Filter{
+			GroupBy: usage.GroupByUser,
+			From:    time.Date(2026, 9, 17, 0, 0, 0, 0, time.UTC),
+			To:      time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC),
+			Limit:   50,
+		}).Return([]usage.Summary{{Key: "user-id", Name: "alice", Requests: 4}}, nil).Once()
+
+		w := get("")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var response UsageResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "2026-09-17", response.From)
+		assert.Equal(t, "2026-10-16", response.To)
+		require.Len(t, response.Entries, 1)
+		assert.Equal(t, "alice", response.Entries[0].Name)
+	})
+
+	t.Run("includes the last day of the range", func(t *testing.T) {
+		store.On("Summarize", usage.Filter{
+			GroupBy: usage.GroupByTeam,
+			From:    time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			To:      time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			Limit:   10,
+		}).Return([]usage.Summary{}, nil).Once()
+
+		w := get("?group_by=team&from=2026-10-01&to=2026-10-15&limit=10")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	for name, query := range map[string]string{
+		"unknown grouping": "?group_by=org",
+		"bad date":         "?from=yesterday",
+		"reversed range":   "?from=2026-10-15&to=2026-10-01",
+		"limit too large":  "?limit=501",
+	} {
+		t.Run("rejects "+name, func(t *testing.T) {
+			w := get(query)
+
+			require.Equal(t, http.StatusBadRequest, w.Code)
+			var response apperrors.Response
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, apperrors.CodeValidation, response.Code)
+		})
+	}
+	store.AssertExpectations(t)
+}
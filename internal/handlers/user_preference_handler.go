@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+// UserPreferenceHandler serves the caller's preferences under /me
+type UserPreferenceHandler struct {
+	prefService services.UserPreferenceServiceInterface
+}
+
+func NewUserPreferenceHandler(prefService services.UserPreferenceServiceInterface) *UserPreferenceHandler {
+	return &UserPreferenceHandler{
+		prefService: prefService,
+	}
+}
+
+// GetMyPreferences returns the current user's preferences, or the defaults
+// if they have never changed them
+func (h *UserPreferenceHandler) GetMyPreferences(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	pref, err := h.prefService.GetPreferences(c.Request.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
+
+// UpdateMyPreferences changes the settings present in the body
+func (h *UserPreferenceHandler) UpdateMyPreferences(c *gin.Context) {
+	var input services.UpdatePreferencesInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	pref, err := h.prefService.UpdatePreferences(c.Request.Context(), claims.UserID, &input)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pref)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/middleware"
+	"seta-training/internal/services"
+)
+
+type WebhookHandler struct {
+	webhookService services.WebhookServiceInterface
+}
+
+func NewWebhookHandler(webhookService services.WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook registers a webhook for the current manager
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var input services.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	hook, err := h.webhookService.CreateWebhook(c.Request.Context(), &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, hook)
+}
+
+// GetWebhooks lists the current manager's webhooks
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	hooks, err := h.webhookService.GetUserWebhooks(c.Request.Context(), claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+// GetWebhook gets a webhook
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid webhook ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	hook, err := h.webhookService.GetWebhook(c.Request.Context(), webhookID, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// UpdateWebhook changes a webhook's URL, event types or active flag
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid webhook ID"))
+		return
+	}
+
+	var input services.WebhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		middleware.RespondError(c, apperrors.FromBinding(err))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	hook, err := h.webhookService.UpdateWebhook(c.Request.Context(), webhookID, &input, claims.UserID)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook deletes a webhook and its delivery log
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid webhook ID"))
+		return
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), webhookID, claims.UserID); err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// GetDeliveries lists recent deliveries to a webhook, newest first
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	webhookID, err := uuid.Parse(c.Param("webhookId"))
+	if err != nil {
+		middleware.RespondError(c, apperrors.Validation("Invalid webhook ID"))
+		return
+	}
+
+	limit := 0
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 {
+			middleware.RespondError(c, apperrors.Validation("Invalid limit"))
+			return
+		}
+	}
+
+	// Get current user from context
+	claims, exists := middleware.GetCurrentUser(c)
+	if !exists {
+		middleware.RespondError(c, apperrors.Unauthorized("Authentication required"))
+		return
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(c.Request.Context(), webhookID, claims.UserID, limit)
+	if err != nil {
+		middleware.RespondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, deliveries)
+}
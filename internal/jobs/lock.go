@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/models"
+)
+
+// Locker makes sure a scheduled run happens on one instance only
+type Locker interface {
+	// TryLock claims the run of job scheduled at scheduledAt for owner. It
+	// fails if another instance holds the lock or already claimed this run.
+	TryLock(job, owner string, scheduledAt, leaseUntil time.Time) (bool, error)
+	// Unlock records the outcome of the run and releases the lock
+	Unlock(job, owner string, runErr error) error
+}
+
+// GormLocker keeps job locks in the job_locks table
+type GormLocker struct {
+	db *gorm.DB
+}
+
+func NewGormLocker(db *gorm.DB) *GormLocker {
+	return &GormLocker{db: db}
+}
+
+func (l *GormLocker) TryLock(job, owner string, scheduledAt, leaseUntil time.Time) (bool, error) {
+	if err := l.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.JobLock{Name: job}).Error; err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	res := l.db.Model(&models.JobLock{}).
+		Where("name = ?", job).
+		Where("locked_until IS NULL OR locked_until < ?", now).
+		Where("last_scheduled_at IS NULL OR last_scheduled_at < ?", scheduledAt).
+		Updates(map[string]interface{}{
+			"locked_by":         owner,
+			"locked_until":      leaseUntil,
+			"last_scheduled_at": scheduledAt,
+			"last_started_at":   now,
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (l *GormLocker) Unlock(job, owner string, runErr error) error {
+	lastError := ""
+	if runErr != nil {
+		lastError = runErr.Error()
+	}
+	return l.db.Model(&models.JobLock{}).
+		Where("name = ? AND locked_by = ?", job, owner).
+		Updates(map[string]interface{}{
+			"locked_until":     nil,
+			"last_finished_at": time.Now(),
+			"last_error":       lastError,
+		}).Error
+}
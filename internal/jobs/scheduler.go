@@ -0,0 +1,191 @@
+// Package jobs runs recurring background work on cron-style schedules.
+// Every instance runs the scheduler, and a lock shared through the database
+// makes sure each scheduled run happens on only one of them.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/pkg/logger"
+	"seta-training/pkg/metrics"
+)
+
+// Run does one run of a job. ctx is cancelled when the job's timeout passes
+// or the scheduler shuts down.
+type Run func(ctx context.Context) error
+
+// Job is a registered recurring job
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Timeout bounds a run; the lock is held for no longer than this
+	Timeout time.Duration
+	Run     Run
+}
+
+// Scheduler runs registered jobs on their schedules until its context is done
+type Scheduler struct {
+	locker   Locker
+	logger   logger.Logger
+	metrics  *metrics.Metrics
+	instance string
+
+	mu      sync.Mutex
+	jobs    []*Job
+	started bool
+	wg      sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler coordinating through locker. log and m
+// may be nil.
+func NewScheduler(locker Locker, log logger.Logger, m *metrics.Metrics) *Scheduler {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
+	return &Scheduler{
+		locker:   locker,
+		logger:   log,
+		metrics:  m,
+		instance: instanceID(),
+	}
+}
+
+// Register adds a job running on spec (see ParseSchedule). It must be
+// called before Start.
+func (s *Scheduler) Register(name, spec string, timeout time.Duration, run Run) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("job %s: timeout must be positive", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return fmt.Errorf("job %s: scheduler already started", name)
+	}
+	for _, job := range s.jobs {
+		if job.Name == name {
+			return fmt.Errorf("job %s is already registered", name)
+		}
+	}
+	s.jobs = append(s.jobs, &Job{Name: name, Schedule: schedule, Timeout: timeout, Run: run})
+	return nil
+}
+
+// Start runs every registered job on its schedule until ctx is done
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = true
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}()
+	}
+	s.logger.Info("Job scheduler started", logger.Int("jobs", len(s.jobs)))
+}
+
+// Shutdown waits for running jobs to return after the Start context is
+// done, or until ctx expires
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job *Job) {
+	for {
+		next := job.Schedule.Next(time.Now())
+		if next.IsZero() {
+			s.logger.Error("Job schedule never fires", logger.String("job", job.Name))
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.RunNow(ctx, job, next)
+	}
+}
+
+// RunNow runs job for the run scheduled at scheduledAt if no other instance
+// has claimed it
+func (s *Scheduler) RunNow(ctx context.Context, job *Job, scheduledAt time.Time) {
+	log := s.logger.WithFields(logger.String("job", job.Name))
+
+	acquired, err := s.locker.TryLock(job.Name, s.instance, scheduledAt, time.Now().Add(job.Timeout))
+	if err != nil {
+		log.Error("Failed to lock job", logger.Error(err))
+		s.metrics.RecordJobRun(job.Name, "lock_error", 0)
+		return
+	}
+	if !acquired {
+		log.Debug("Job run claimed by another instance")
+		s.metrics.RecordJobRun(job.Name, "skipped", 0)
+		return
+	}
+
+	start := time.Now()
+	runErr := s.run(ctx, job)
+	duration := time.Since(start)
+
+	status := "success"
+	if runErr != nil {
+		status = "failure"
+		log.Error("Job failed", logger.Duration("duration", duration), logger.Error(runErr))
+	} else {
+		log.Debug("Job finished", logger.Duration("duration", duration))
+	}
+	s.metrics.RecordJobRun(job.Name, status, duration)
+
+	if err := s.locker.Unlock(job.Name, s.instance, runErr); err != nil {
+		log.Error("Failed to unlock job", logger.Error(err))
+	}
+}
+
+// run calls the job with its timeout, turning a panic into an error
+func (s *Scheduler) run(ctx context.Context, job *Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+// instanceID identifies this process in job locks
+func instanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+}
@@ -1,12 +1,18 @@
 package middleware
 
 import (
-	"net/http"
+	"errors"
+	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/models"
 	"seta-training/pkg/auth"
+	"seta-training/pkg/captcha"
+	"seta-training/pkg/logger"
 )
 
 const (
@@ -14,6 +20,9 @@ const (
 	BearerPrefix        = "Bearer "
 	UserContextKey      = "user"
 	ClaimsContextKey    = "claims"
+	// AccessTokenParam carries the token of WebSocket handshakes, which
+	// browsers cannot add headers to
+	AccessTokenParam = "access_token"
 )
 
 type AuthMiddleware struct {
@@ -28,57 +37,66 @@ func NewAuthMiddleware(jwtManager *auth.JWTManager) *AuthMiddleware {
 
 // RequireAuth middleware validates JWT token and sets user context
 func (a *AuthMiddleware) RequireAuth() gin.HandlerFunc {
+	return a.requireAuth(a.extractToken)
+}
+
+// RequireStreamAuth is RequireAuth for WebSocket endpoints: the token may
+// also be passed in the access_token query parameter
+func (a *AuthMiddleware) RequireStreamAuth() gin.HandlerFunc {
+	return a.requireAuth(func(c *gin.Context) string {
+		if token := a.extractToken(c); token != "" {
+			return token
+		}
+		return c.Query(AccessTokenParam)
+	})
+}
+
+func (a *AuthMiddleware) requireAuth(extractToken func(c *gin.Context) string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token := a.extractToken(c)
+		token := extractToken(c)
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization token required",
-			})
-			c.Abort()
+			RespondError(c, apperrors.Unauthorized("Authorization token required"))
 			return
 		}
 
 		claims, err := a.jwtManager.ValidateToken(token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or expired token",
-			})
-			c.Abort()
+			RespondError(c, apperrors.Unauthorized("Invalid or expired token"))
 			return
 		}
 
-		// Set claims in context for use in handlers
-		c.Set(ClaimsContextKey, claims)
+		setClaims(c, claims)
 		c.Next()
 	}
 }
 
+// setClaims puts claims in the context for use in handlers and services
+func setClaims(c *gin.Context, claims *auth.Claims) {
+	c.Set(ClaimsContextKey, claims)
+	c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), claims))
+	AddLogFields(c, logger.String("user_id", claims.UserID.String()))
+	if claims.ImpersonatorID != nil {
+		AddLogFields(c, logger.String("impersonator_id", claims.ImpersonatorID.String()))
+	}
+}
+
 // RequireRole middleware checks if user has required role
 func (a *AuthMiddleware) RequireRole(role models.UserRole) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		claims, exists := c.Get(ClaimsContextKey)
 		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authentication required",
-			})
-			c.Abort()
+			RespondError(c, apperrors.Unauthorized("Authentication required"))
 			return
 		}
 
 		userClaims, ok := claims.(*auth.Claims)
 		if !ok {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Invalid token claims",
-			})
-			c.Abort()
+			RespondError(c, apperrors.Internal(errors.New("invalid token claims")))
 			return
 		}
 
 		if userClaims.Role != role {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions",
-			})
-			c.Abort()
+			RespondError(c, apperrors.Forbidden("Insufficient permissions"))
 			return
 		}
 
@@ -91,19 +109,84 @@ func (a *AuthMiddleware) RequireManager() gin.HandlerFunc {
 	return a.RequireRole(models.RoleManager)
 }
 
+// RequireScope middleware checks that the token grants scope
+func (a *AuthMiddleware) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := GetCurrentUser(c)
+		if !exists {
+			RespondError(c, apperrors.Unauthorized("Authentication required"))
+			return
+		}
+		if !claims.HasScope(scope) {
+			RespondError(c, apperrors.Forbidden("Token lacks the %s scope", scope))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTeamRole middleware checks, from the token's team claims, that the
+// user holds one of roles in the team named by the param route parameter.
+// Tokens issued without team claims are passed on for the handler to check.
+func (a *AuthMiddleware) RequireTeamRole(param string, roles ...models.TeamRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, exists := GetCurrentUser(c)
+		if !exists {
+			RespondError(c, apperrors.Unauthorized("Authentication required"))
+			return
+		}
+		if claims.Teams == nil {
+			c.Next()
+			return
+		}
+
+		teamID, err := uuid.Parse(c.Param(param))
+		if err != nil {
+			RespondError(c, apperrors.Validation("Invalid team ID"))
+			return
+		}
+		role, member := claims.TeamRole(teamID)
+		if !member || !slices.Contains(roles, role) {
+			RespondError(c, apperrors.Forbidden("Insufficient permissions for this team"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireTeamManager middleware checks that the user manages the team, as
+// one of its admins or managers
+func (a *AuthMiddleware) RequireTeamManager(param string) gin.HandlerFunc {
+	return a.RequireTeamRole(param, models.TeamRoleAdmin, models.TeamRoleManager)
+}
+
 // OptionalAuth middleware validates JWT token if present but doesn't require it
 func (a *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := a.extractToken(c)
 		if token != "" {
 			if claims, err := a.jwtManager.ValidateToken(token); err == nil {
-				c.Set(ClaimsContextKey, claims)
+				setClaims(c, claims)
 			}
 		}
 		c.Next()
 	}
 }
 
+// ClientInfo stores the caller's IP and user agent in the request context,
+// where logins record them, along with any CAPTCHA token they sent
+func ClientInfo() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := auth.Client{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+		ctx := auth.WithClient(c.Request.Context(), client)
+		if token := c.GetHeader(captcha.TokenHeader); token != "" {
+			ctx = captcha.WithToken(ctx, token)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
 // extractToken extracts JWT token from Authorization header
 func (a *AuthMiddleware) extractToken(c *gin.Context) string {
 	authHeader := c.GetHeader(AuthorizationHeader)
@@ -118,6 +201,21 @@ func (a *AuthMiddleware) extractToken(c *gin.Context) string {
 	return strings.TrimPrefix(authHeader, BearerPrefix)
 }
 
+// RedactAccessToken hides the access_token query parameter of a request
+// path before it is logged
+func RedactAccessToken(path string) string {
+	route, query, ok := strings.Cut(path, "?")
+	if !ok || !strings.Contains(query, AccessTokenParam+"=") {
+		return path
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return route
+	}
+	values.Set(AccessTokenParam, "REDACTED")
+	return route + "?" + values.Encode()
+}
+
 // GetCurrentUser returns the current user claims from context
 func GetCurrentUser(c *gin.Context) (*auth.Claims, bool) {
 	claims, exists := c.Get(ClaimsContextKey)
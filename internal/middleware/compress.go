@@ -0,0 +1,271 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/config"
+)
+
+// compressibleTypes are the response content types worth compressing.
+// Archives, PDFs and images are already compressed, and event streams must
+// reach the client as soon as they are flushed.
+var compressibleTypes = []string{
+	"application/json",
+	"application/graphql-response+json",
+	"application/javascript",
+	"application/xml",
+	"text/html",
+	"text/plain",
+	"text/csv",
+	"text/css",
+	"text/markdown",
+}
+
+// Compressor gzip- or deflate-encodes responses for clients that accept it.
+// Bodies smaller than the minimum size are sent as they are.
+type Compressor struct {
+	level         int
+	minSize       int
+	excludedPaths []string
+
+	gzipPool  sync.Pool
+	flatePool sync.Pool
+}
+
+func NewCompressor(cfg config.ResponseCompressionConfig) *Compressor {
+	c := &Compressor{
+		level:         cfg.Level,
+		minSize:       cfg.MinSizeBytes,
+		excludedPaths: cfg.ExcludedPaths,
+	}
+	c.gzipPool.New = func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, c.level)
+		return w
+	}
+	c.flatePool.New = func() interface{} {
+		w, _ := flate.NewWriter(io.Discard, c.level)
+		return w
+	}
+	return c
+}
+
+// Middleware compresses eligible responses. Register it before RequestID so
+// that error bodies are rewritten before they are compressed.
+func (cp *Compressor) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead ||
+			c.GetHeader("Upgrade") != "" || cp.excluded(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Accept-Encoding")
+		writer := &compressWriter{
+			ResponseWriter: c.Writer,
+			compressor:     cp,
+			encoding:       encoding,
+		}
+		c.Writer = writer
+		defer writer.close()
+		c.Next()
+	}
+}
+
+func (cp *Compressor) excluded(path string) bool {
+	for _, prefix := range cp.excludedPaths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// negotiateEncoding picks gzip over deflate from an Accept-Encoding header,
+// honouring q=0 exclusions
+func negotiateEncoding(header string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[name] = q > 0
+	}
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if accepted[encoding] {
+			return encoding
+		}
+	}
+	return ""
+}
+
+type flushWriteCloser interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressWriter holds back the status and body until it has seen enough of
+// the response to decide whether to compress it
+type compressWriter struct {
+	gin.ResponseWriter
+	compressor *Compressor
+	encoding   string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     flushWriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.passThrough()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Status() int {
+	if !w.decided && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Written() bool {
+	return len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		if !w.eligible() {
+			w.passThrough()
+			return w.ResponseWriter.Write(data)
+		}
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.compressor.minSize {
+			return len(data), nil
+		}
+		if err := w.startCompression(); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.eligible() && len(w.buf) > 0 {
+			_ = w.startCompression()
+		} else {
+			w.passThrough()
+		}
+	}
+	if w.enc != nil {
+		_ = w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressWriter) eligible() bool {
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if w.status != 0 && (w.status < http.StatusOK || w.status == http.StatusNoContent || w.status == http.StatusNotModified) {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	for _, compressible := range compressibleTypes {
+		if strings.HasPrefix(contentType, compressible) {
+			return true
+		}
+	}
+	return false
+}
+
+// passThrough sends the held status and body uncompressed
+func (w *compressWriter) passThrough() {
+	w.decided = true
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if len(w.buf) > 0 {
+		buf := w.buf
+		w.buf = nil
+		_, _ = w.ResponseWriter.Write(buf)
+	}
+}
+
+func (w *compressWriter) startCompression() error {
+	w.decided = true
+	header := w.Header()
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+
+	switch w.encoding {
+	case "gzip":
+		gz := w.compressor.gzipPool.Get().(*gzip.Writer)
+		gz.Reset(w.ResponseWriter)
+		w.enc = gz
+	default:
+		fl := w.compressor.flatePool.Get().(*flate.Writer)
+		fl.Reset(w.ResponseWriter)
+		w.enc = fl
+	}
+
+	buf := w.buf
+	w.buf = nil
+	_, err := w.enc.Write(buf)
+	return err
+}
+
+// close finishes the response: small bodies are sent as they are and the
+// encoder is flushed and returned to its pool
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.passThrough()
+		return
+	}
+	if w.enc == nil {
+		return
+	}
+	_ = w.enc.Close()
+	switch enc := w.enc.(type) {
+	case *gzip.Writer:
+		w.compressor.gzipPool.Put(enc)
+	case *flate.Writer:
+		w.compressor.flatePool.Put(enc)
+	}
+	w.enc = nil
+}
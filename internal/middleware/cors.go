@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/config"
+)
+
+// NewCORS builds the CORS middleware from configuration. It answers
+// preflight requests itself, so it must be registered on the engine before
+// routing-dependent middleware such as auth or rate limiting. It returns nil
+// when no origins are allowed.
+func NewCORS(cfg config.CORSConfig) (gin.HandlerFunc, error) {
+	if len(cfg.AllowedOrigins) == 0 {
+		return nil, nil
+	}
+
+	allowAll := slices.Contains(cfg.AllowedOrigins, "*")
+	if allowAll && cfg.AllowCredentials {
+		return nil, errors.New("CORS_ALLOW_CREDENTIALS cannot be combined with a wildcard origin")
+	}
+
+	corsConfig := cors.Config{
+		AllowMethods:     cfg.AllowedMethods,
+		AllowHeaders:     cfg.AllowedHeaders,
+		ExposeHeaders:    cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           time.Duration(cfg.MaxAgeSeconds) * time.Second,
+	}
+	if allowAll {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = cfg.AllowedOrigins
+	}
+
+	if err := corsConfig.Validate(); err != nil {
+		return nil, err
+	}
+	return cors.New(corsConfig), nil
+}
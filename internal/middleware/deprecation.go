@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks responses of a superseded API version with Deprecation
+// (RFC 9745) and, when sunset is set, Sunset (RFC 8594) headers. The Link
+// header points at the same route under toPrefix.
+func Deprecated(since, sunset time.Time, fromPrefix, toPrefix string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if successor, ok := strings.CutPrefix(c.Request.URL.Path, fromPrefix); ok {
+			c.Header("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, toPrefix, successor))
+		}
+		c.Next()
+	}
+}
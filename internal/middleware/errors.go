@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/pkg/logger"
+)
+
+// RespondError aborts the request with err rendered as an error envelope.
+// Internal errors are logged with their cause, which is not sent. Messages
+// are translated into the language picked by the Locale middleware.
+func RespondError(c *gin.Context, err error) {
+	status, resp := ErrorResponse(c, err)
+	c.AbortWithStatusJSON(status, resp)
+}
+
+// ErrorResponse records err on the request like RespondError and returns
+// its status and envelope without writing them, for handlers that report
+// errors after the response has started, such as in an event stream
+func ErrorResponse(c *gin.Context, err error) (int, apperrors.Response) {
+	appErr := apperrors.From(err)
+	if appErr.Code == apperrors.CodeInternal {
+		GetLogger(c, logger.NewNopLogger()).Error("Request failed",
+			logger.String("path", c.FullPath()), logger.Error(err))
+	}
+	_ = c.Error(err)
+	if localizer := GetLocalizer(c); localizer != nil {
+		appErr = appErr.Localize(localizer.T)
+	}
+	return appErr.Status(), apperrors.NewResponse(appErr, GetRequestID(c))
+}
@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/logger"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyReserveRetries = 1
+)
+
+// Idempotency makes POST endpoints safe to retry. The first request sent with
+// an Idempotency-Key header runs normally and its response is stored; later
+// requests from the same user with the same key get the stored response
+// back instead of running again. Reusing a key for a different request is
+// rejected with 422, and a retry that arrives while the first request is
+// still running gets 409. Server errors are not stored so they can be
+// retried.
+type Idempotency struct {
+	repo   repositories.IdempotencyRepositoryInterface
+	ttl    time.Duration
+	logger logger.Logger
+}
+
+func NewIdempotency(repo repositories.IdempotencyRepositoryInterface, ttl time.Duration, log logger.Logger) *Idempotency {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &Idempotency{
+		repo:   repo,
+		ttl:    ttl,
+		logger: log,
+	}
+}
+
+// Middleware must run after RequireAuth; keys are scoped to the current user
+func (i *Idempotency) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		claims, authenticated := GetCurrentUser(c)
+		if key == "" || !authenticated {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			RespondError(c, apperrors.Validation("Idempotency-Key must be at most 255 characters"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				AbortBodyTooLarge(c, maxBytesErr.Limit)
+				return
+			}
+			RespondError(c, apperrors.Validation("Failed to read request body"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		log := GetLogger(c, i.logger)
+		record := &models.IdempotencyKey{
+			UserID:      claims.UserID,
+			Key:         key,
+			RequestHash: requestHash(c.Request, body),
+			ExpiresAt:   time.Now().Add(i.ttl),
+		}
+		reserved, existing, err := i.reserve(c.Request.Context(), record)
+		if err != nil {
+			RespondError(c, fmt.Errorf("failed to reserve idempotency key: %w", err))
+			return
+		}
+		if !reserved {
+			i.replay(c, existing, record.RequestHash)
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		// A handler that panics has not finished, so its retries must run
+		// rather than wait out the TTL behind a 409
+		defer func() {
+			if p := recover(); p != nil {
+				if err := i.repo.Release(context.WithoutCancel(c.Request.Context()), record.ID); err != nil {
+					log.Error("Failed to release idempotency key", logger.Error(err))
+				}
+				panic(p)
+			}
+		}()
+		c.Next()
+
+		// The outcome is stored even when the request ran out of time, so a
+		// retry replays it rather than running the handler again
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := writer.Status()
+		if status >= http.StatusInternalServerError || status == http.StatusConflict || status == http.StatusTooManyRequests {
+			if err := i.repo.Release(ctx, record.ID); err != nil {
+				log.Error("Failed to release idempotency key", logger.Error(err))
+			}
+			return
+		}
+
+		record.StatusCode = status
+		record.ContentType = writer.Header().Get("Content-Type")
+		record.Location = writer.Header().Get("Location")
+		record.Body = writer.body.Bytes()
+		if err := i.repo.Complete(ctx, record); err != nil {
+			log.Error("Failed to store idempotent response", logger.Error(err))
+		}
+	}
+}
+
+// reserve claims the key for this request. When the key is taken it returns
+// the existing record; an expired record is dropped and the key claimed anew.
+func (i *Idempotency) reserve(ctx context.Context, record *models.IdempotencyKey) (bool, *models.IdempotencyKey, error) {
+	for attempt := 0; ; attempt++ {
+		reserved, err := i.repo.Reserve(ctx, record)
+		if err != nil || reserved {
+			return reserved, nil, err
+		}
+
+		existing, err := i.repo.Get(ctx, record.UserID, record.Key)
+		if err != nil {
+			return false, nil, err
+		}
+		if existing.ExpiresAt.After(time.Now()) || attempt == idempotencyReserveRetries {
+			return false, existing, nil
+		}
+		if err := i.repo.Release(ctx, existing.ID); err != nil {
+			return false, nil, err
+		}
+	}
+}
+
+func (i *Idempotency) replay(c *gin.Context, existing *models.IdempotencyKey, hash string) {
+	if existing.RequestHash != hash {
+		RespondError(c, apperrors.Unprocessable("Idempotency-Key was already used for a different request"))
+		return
+	}
+	if !existing.Completed() {
+		c.Header("Retry-After", "1")
+		RespondError(c, apperrors.Conflict("A request with this Idempotency-Key is still being processed"))
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	if existing.Location != "" {
+		c.Header("Location", existing.Location)
+	}
+	c.Abort()
+	c.Data(existing.StatusCode, existing.ContentType, existing.Body)
+}
+
+// PurgeExpired deletes expired keys. It runs as a scheduled job.
+func (i *Idempotency) PurgeExpired(ctx context.Context) error {
+	deleted, err := i.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to purge expired idempotency keys: %w", err)
+	}
+	if deleted > 0 {
+		i.logger.Debug("Purged expired idempotency keys", logger.Int("count", int(deleted)))
+	}
+	return nil
+}
+
+// requestHash fingerprints the parts of a request that must match for a
+// retry to be treated as the same request
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of everything the handler writes
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
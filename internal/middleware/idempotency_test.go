@@ -0,0 +1,318 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+// fakeIdempotencyRepository keeps idempotency keys in memory, unique per
+// user and key like the table
+type fakeIdempotencyRepository struct {
+	mu         sync.Mutex
+	records    map[string]*models.IdempotencyKey
+	released   int
+	reserveErr error
+}
+
+func newFakeIdempotencyRepository() *fakeIdempotencyRepository {
+	return &fakeIdempotencyRepository{records: make(map[string]*models.IdempotencyKey)}
+}
+
+func idempotencyRecordKey(userID uuid.UUID, key string) string {
+	return userID.String() + "/" + key
+}
+
+func (r *fakeIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyKey) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reserveErr != nil {
+		return false, r.reserveErr
+	}
+	k := idempotencyRecordKey(record.UserID, record.Key)
+	if _, taken := r.records[k]; taken {
+		return false, nil
+	}
+	if record.ID == uuid.Nil {
+		record.ID = uuid.New()
+	}
+	stored := *record
+	r.records[k] = &stored
+	return true, nil
+}
+
+func (r *fakeIdempotencyRepository) Get(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[idempotencyRecordKey(userID, key)]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *record
+	return &found, nil
+}
+
+func (r *fakeIdempotencyRepository) Complete(ctx context.Context, record *models.IdempotencyKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *record
+	r.records[idempotencyRecordKey(record.UserID, record.Key)] = &stored
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Release(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, record := range r.records {
+		if record.ID == id {
+			delete(r.records, k)
+			r.released++
+		}
+	}
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted int64
+	for k, record := range r.records {
+		if !record.ExpiresAt.After(now) {
+			delete(r.records, k)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// idempotentRouter serves POST /notes through the middleware as userID,
+// answering with status and counting the calls that reach the handler
+func idempotentRouter(repo *fakeIdempotencyRepository, userID uuid.UUID, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(ClaimsContextKey, &auth.Claims{UserID: userID})
+	})
+	router.POST("/notes", NewIdempotency(repo, time.Hour, nil).Middleware(), handler)
+	return router
+}
+
+func postNote(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/notes", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysCompletedRequests(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	router := idempotentRouter(repo, uuid.New(), func(c *gin.Context) {
+		calls++
+		c.Header("Location", "/notes/1")
+		c.JSON(http.StatusCreated, gin.H{"id": calls})
+	})
+
+	first := postNote(router, "key-1", `{"title":"a"}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	retry := postNote(router, "key-1", `{"title":"a"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, "/notes/1", retry.Header().Get("Location"))
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	assert.JSONEq(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// Other keys, and requests without one, run again
+	assert.Equal(t, http.StatusCreated, postNote(router, "key-2", `{"title":"a"}`).Code)
+	assert.Equal(t, http.StatusCreated, postNote(router, "", `{"title":"a"}`).Code)
+	assert.Equal(t, 3, calls)
+}
+
+func TestIdempotency_KeysAreScopedToTheUser(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	handler := func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	}
+
+	postNote(idempotentRouter(repo, uuid.New(), handler), "key-1", `{}`)
+	w := postNote(idempotentRouter(repo, uuid.New(), handler), "key-1", `{}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_RejectsKeyReuseForAnotherRequest(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	router := idempotentRouter(repo, uuid.New(), func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+
+	postNote(router, "key-1", `{"title":"a"}`)
+	w := postNote(router, "key-1", `{"title":"b"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "already used for a different request")
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_ConflictsWhileInFlight(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	var router *gin.Engine
+	var retry *httptest.ResponseRecorder
+	router = idempotentRouter(repo, uuid.New(), func(c *gin.Context) {
+		// The retry arrives while the first request is still running
+		if retry == nil {
+			retry = postNote(router, "key-1", `{}`)
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	first := postNote(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	require.NotNil(t, retry)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.Equal(t, "1", retry.Header().Get("Retry-After"))
+}
+
+func TestIdempotency_ReclaimsExpiredKeys(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	userID := uuid.New()
+	repo.records[idempotencyRecordKey(userID, "key-1")] = &models.IdempotencyKey{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Key:         "key-1",
+		RequestHash: "from a request long ago",
+		StatusCode:  http.StatusCreated,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	}
+	calls := 0
+	router := idempotentRouter(repo, userID, func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+
+	w := postNote(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, repo.released)
+
+	// The reclaimed key now replays the new response
+	assert.Equal(t, "true", postNote(router, "key-1", `{}`).Header().Get(IdempotentReplayedHeader))
+}
+
+func TestIdempotency_ReleasesRetryableFailures(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusConflict, http.StatusTooManyRequests} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			repo := newFakeIdempotencyRepository()
+			calls := 0
+			router := idempotentRouter(repo, uuid.New(), func(c *gin.Context) {
+				calls++
+				if calls == 1 {
+					c.Status(status)
+					return
+				}
+				c.Status(http.StatusCreated)
+			})
+
+			assert.Equal(t, status, postNote(router, "key-1", `{}`).Code)
+			assert.Empty(t, repo.records, "the key is released")
+
+			w := postNote(router, "key-1", `{}`)
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+			assert.Equal(t, 2, calls)
+		})
+	}
+
+	t.Run("client errors are replayed", func(t *testing.T) {
+		repo := newFakeIdempotencyRepository()
+		calls := 0
+		router := idempotentRouter(repo, uuid.New(), func(c *gin.Context) {
+			calls++
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid"})
+		})
+
+		postNote(router, "key-1", `{}`)
+		w := postNote(router, "key-1", `{}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestIdempotency_ReleasesAfterPanic(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	calls := 0
+	router := idempotentRouter(repo, uuid.New(), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("handler bug")
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	assert.Panics(t, func() { postNote(router, "key-1", `{}`) }, "the panic reaches the recovery middleware")
+	assert.Empty(t, repo.records, "the key is released")
+	assert.Equal(t, http.StatusCreated, postNote(router, "key-1", `{}`).Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_ReserveFailure(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	repo.reserveErr = errors.New("connection refused")
+	calls := 0
+	router := idempotentRouter(repo, uuid.New(), func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+
+	w := postNote(router, "key-1", `{}`)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"internal_error"`)
+	assert.NotContains(t, w.Body.String(), "connection refused")
+	assert.Zero(t, calls)
+}
+
+func TestIdempotency_RejectsLongKeys(t *testing.T) {
+	router := idempotentRouter(newFakeIdempotencyRepository(), uuid.New(), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	w := postNote(router, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestIdempotency_PurgeExpired(t *testing.T) {
+	repo := newFakeIdempotencyRepository()
+	userID := uuid.New()
+	repo.records[idempotencyRecordKey(userID, "old")] = &models.IdempotencyKey{ID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+	repo.records[idempotencyRecordKey(userID, "new")] = &models.IdempotencyKey{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Minute)}
+
+	require.NoError(t, NewIdempotency(repo, time.Hour, nil).PurgeExpired(context.Background()))
+	assert.Len(t, repo.records, 1)
+	assert.Contains(t, repo.records, idempotencyRecordKey(userID, "new"))
+}
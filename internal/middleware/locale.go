@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"seta-training/pkg/i18n"
+)
+
+const (
+	AcceptLanguageHeader  = "Accept-Language"
+	ContentLanguageHeader = "Content-Language"
+	LocalizerContextKey   = "localizer"
+)
+
+// Locale picks the response language from the Accept-Language header and
+// stores a localizer for it in the gin and request contexts. RespondError
+// uses it to translate error and validation messages.
+func Locale(bundle *i18n.Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		localizer := bundle.Localizer(bundle.Match(c.GetHeader(AcceptLanguageHeader)))
+		c.Set(LocalizerContextKey, localizer)
+		c.Request = c.Request.WithContext(i18n.NewContext(c.Request.Context(), localizer))
+		c.Header(ContentLanguageHeader, localizer.Language())
+		c.Writer.Header().Add("Vary", AcceptLanguageHeader)
+		c.Next()
+	}
+}
+
+// GetLocalizer returns the request's localizer, or nil outside of the
+// Locale middleware, which leaves messages untranslated
+func GetLocalizer(c *gin.Context) *i18n.Localizer {
+	if l, ok := c.Get(LocalizerContextKey); ok {
+		if localizer, ok := l.(*i18n.Localizer); ok {
+			return localizer
+		}
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/config"
+	"seta-training/pkg/logger"
+)
+
+// MaintenanceMode is how much of the API is available during maintenance
+type MaintenanceMode string
+
+const (
+	// MaintenanceOff serves every request
+	MaintenanceOff MaintenanceMode = "off"
+	// MaintenanceReadOnly answers writes with 503 and serves reads
+	MaintenanceReadOnly MaintenanceMode = "read_only"
+	// MaintenanceFull answers every request with 503
+	MaintenanceFull MaintenanceMode = "full"
+)
+
+// MaintenanceModes lists the valid modes
+var MaintenanceModes = []string{string(MaintenanceOff), string(MaintenanceReadOnly), string(MaintenanceFull)}
+
+// MaintenanceState is the mode in effect and the Retry-After sent with 503s
+type MaintenanceState struct {
+	Mode              MaintenanceMode `json:"mode"`
+	RetryAfterSeconds int             `json:"retry_after_seconds"`
+}
+
+// MaintenanceStore holds a mode set at runtime, which overrides the
+// configured one. A shared store puts every instance in the same mode.
+type MaintenanceStore interface {
+	// Get returns the stored state, or nil when none is stored
+	Get(ctx context.Context) (*MaintenanceState, error)
+	Set(ctx context.Context, state MaintenanceState) error
+	Clear(ctx context.Context) error
+}
+
+// maintenanceCacheTTL is how long a stored state is used before the store
+// is asked again, so requests do not each hit it
+const maintenanceCacheTTL = 2 * time.Second
+
+// Maintenance rejects requests with 503 and a Retry-After header while the
+// API is in maintenance, for use during migrations. Requests to the exempt
+// path prefixes, such as health checks and admin routes, are always served.
+type Maintenance struct {
+	store  MaintenanceStore
+	logger logger.Logger
+	now    func() time.Time
+
+	configured  atomic.Pointer[MaintenanceState]
+	exemptPaths atomic.Pointer[[]string]
+
+	mu      sync.Mutex
+	stored  *MaintenanceState
+	fetched time.Time
+}
+
+// NewMaintenance creates the middleware in the configured state. store may
+// be nil to keep modes set at runtime in memory.
+func NewMaintenance(cfg config.MaintenanceConfig, store MaintenanceStore, log logger.Logger) *Maintenance {
+	if store == nil {
+		store = NewMemoryMaintenanceStore()
+	}
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	m := &Maintenance{
+		store:  store,
+		logger: log,
+		now:    time.Now,
+	}
+	m.SetConfig(cfg)
+	return m
+}
+
+// SetConfig replaces the configured state and exempt paths, for config
+// reloads
+func (m *Maintenance) SetConfig(cfg config.MaintenanceConfig) {
+	m.configured.Store(&MaintenanceState{
+		Mode:              MaintenanceMode(cfg.Mode),
+		RetryAfterSeconds: cfg.RetryAfterSeconds,
+	})
+	m.exemptPaths.Store(&cfg.ExemptPaths)
+}
+
+// State returns the state in effect: the stored one if any, else the
+// configured one. When the store fails the last stored state is kept.
+func (m *Maintenance) State(ctx context.Context) MaintenanceState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if now := m.now(); now.Sub(m.fetched) >= maintenanceCacheTTL {
+		stored, err := m.store.Get(ctx)
+		if err != nil {
+			m.logger.Error("Failed to read maintenance mode, keeping the last one", logger.Error(err))
+		} else {
+			m.stored = stored
+		}
+		m.fetched = now
+	}
+	if m.stored != nil {
+		return *m.stored
+	}
+	return *m.configured.Load()
+}
+
+// Overridden reports whether a stored state overrides the configured one
+func (m *Maintenance) Overridden(ctx context.Context) bool {
+	m.State(ctx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stored != nil
+}
+
+// Set stores state, overriding the configured one
+func (m *Maintenance) Set(ctx context.Context, state MaintenanceState) error {
+	if err := m.store.Set(ctx, state); err != nil {
+		return err
+	}
+	m.refresh(&state)
+	return nil
+}
+
+// Clear removes the stored state, so the configured one applies again
+func (m *Maintenance) Clear(ctx context.Context) error {
+	if err := m.store.Clear(ctx); err != nil {
+		return err
+	}
+	m.refresh(nil)
+	return nil
+}
+
+func (m *Maintenance) refresh(stored *MaintenanceState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stored = stored
+	m.fetched = m.now()
+}
+
+// Middleware rejects requests the current mode does not allow. In
+// read-only mode, GraphQL requests to graphQLPath are reads unless they
+// contain a mutation other than login.
+func (m *Maintenance) Middleware(graphQLPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := m.State(c.Request.Context())
+		if state.Mode == MaintenanceOff || state.Mode == "" || m.exempt(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+
+		var err *apperrors.Error
+		switch {
+		case state.Mode == MaintenanceFull:
+			err = apperrors.Unavailable("The API is down for maintenance")
+		case isMaintenanceWrite(c, graphQLPath):
+			err = apperrors.Unavailable("The API is read-only during maintenance")
+		default:
+			c.Next()
+			return
+		}
+		if state.RetryAfterSeconds > 0 {
+			c.Header("Retry-After", strconv.Itoa(state.RetryAfterSeconds))
+		}
+		RespondError(c, err)
+	}
+}
+
+func (m *Maintenance) exempt(path string) bool {
+	for _, prefix := range *m.exemptPaths.Load() {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMaintenanceWrite reports whether read-only mode rejects the request
+func isMaintenanceWrite(c *gin.Context, graphQLPath string) bool {
+	if IsReadRequest(c) {
+		return false
+	}
+	if c.Request.URL.Path != graphQLPath {
+		return true
+	}
+	mutations, ok := graphQLMutationFields(c)
+	if !ok {
+		return true
+	}
+	for _, name := range mutations {
+		if name != "login" {
+			return true
+		}
+	}
+	return false
+}
+
+// MemoryMaintenanceStore keeps the stored state in process memory, so it
+// only applies to this instance
+type MemoryMaintenanceStore struct {
+	mu    sync.Mutex
+	state *MaintenanceState
+}
+
+func NewMemoryMaintenanceStore() *MemoryMaintenanceStore {
+	return &MemoryMaintenanceStore{}
+}
+
+func (s *MemoryMaintenanceStore) Get(context.Context) (*MaintenanceState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.state == nil {
+		return nil, nil
+	}
+	state := *s.state
+	return &state, nil
+}
+
+func (s *MemoryMaintenanceStore) Set(_ context.Context, state MaintenanceState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = &state
+	return nil
+}
+
+func (s *MemoryMaintenanceStore) Clear(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = nil
+	return nil
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maintenanceKey holds the stored maintenance state as JSON
+const maintenanceKey = "maintenance"
+
+// RedisMaintenanceStore shares the maintenance mode between instances
+// through Redis
+type RedisMaintenanceStore struct {
+	client redis.Cmdable
+}
+
+func NewRedisMaintenanceStore(client redis.Cmdable) *RedisMaintenanceStore {
+	return &RedisMaintenanceStore{client: client}
+}
+
+func (s *RedisMaintenanceStore) Get(ctx context.Context) (*MaintenanceState, error) {
+	data, err := s.client.Get(ctx, maintenanceKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state MaintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (s *RedisMaintenanceStore) Set(ctx context.Context, state MaintenanceState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, maintenanceKey, data, 0).Err()
+}
+
+func (s *RedisMaintenanceStore) Clear(ctx context.Context) error {
+	return s.client.Del(ctx, maintenanceKey).Err()
+}
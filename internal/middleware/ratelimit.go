@@ -0,0 +1,410 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/logger"
+)
+
+// RateLimitRule allows Requests per Period, refilled continuously. Requests is
+// also the bucket size, so a quiet client may burst up to it.
+type RateLimitRule struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseRateLimitRule parses rules such as "100/min", "10/s" or "1000/h"
+func ParseRateLimitRule(s string) (RateLimitRule, error) {
+	count, unit, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return RateLimitRule{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<s|min|h>", s)
+	}
+	requests, err := strconv.Atoi(count)
+	if err != nil || requests <= 0 {
+		return RateLimitRule{}, fmt.Errorf("invalid rate limit %q: request count must be a positive integer", s)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s", "sec", "second":
+		period = time.Second
+	case "m", "min", "minute":
+		period = time.Minute
+	case "h", "hour":
+		period = time.Hour
+	default:
+		return RateLimitRule{}, fmt.Errorf("invalid rate limit %q: unknown unit %q", s, unit)
+	}
+	return RateLimitRule{Requests: requests, Period: period}, nil
+}
+
+// refillPerSecond is the token refill rate of the rule's bucket
+func (r RateLimitRule) refillPerSecond() float64 {
+	return float64(r.Requests) / r.Period.Seconds()
+}
+
+// RateLimitResult is the outcome of taking tokens from a bucket
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// RateLimitTier is the budget class of a caller. Each tier's budget is the
+// rule's request count times the tier's multiplier.
+type RateLimitTier string
+
+const (
+	TierAnonymous RateLimitTier = "anonymous"
+	TierMember    RateLimitTier = "member"
+	TierManager   RateLimitTier = "manager"
+	TierAdmin     RateLimitTier = "admin"
+)
+
+// RateLimitStore keeps token buckets. The Redis store shares them across
+// instances; the memory store is per process. Take takes n tokens at once,
+// or none when fewer are left.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rule RateLimitRule, n int) (RateLimitResult, error)
+}
+
+// RateLimiter builds token bucket middleware keyed by the authenticated user,
+// or by client IP for anonymous requests. Rules are looked up per request by
+// scope, so they can be changed at runtime with SetRule, and scaled by the
+// multiplier of the caller's tier. Scopes without a rule, a disabled limiter
+// and a limiter without a store let every request through.
+type RateLimiter struct {
+	store   RateLimitStore
+	logger  logger.Logger
+	enabled atomic.Bool
+
+	mu          sync.RWMutex
+	rules       map[string]RateLimitRule
+	multipliers map[RateLimitTier]int
+}
+
+func NewRateLimiter(store RateLimitStore, log logger.Logger) *RateLimiter {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	rl := &RateLimiter{
+		store:       store,
+		logger:      log,
+		rules:       make(map[string]RateLimitRule),
+		multipliers: make(map[RateLimitTier]int),
+	}
+	rl.enabled.Store(true)
+	return rl
+}
+
+// SetEnabled turns limiting on or off for all scopes
+func (rl *RateLimiter) SetEnabled(enabled bool) {
+	rl.enabled.Store(enabled)
+}
+
+// SetRule sets or replaces the rule for scope. Existing buckets keep their
+// tokens and refill at the new rate.
+func (rl *RateLimiter) SetRule(scope string, rule RateLimitRule) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.rules[scope] = rule
+}
+
+// SetMultiplier scales the budget of callers in tier. Tiers without a
+// multiplier get the rules as they are.
+func (rl *RateLimiter) SetMultiplier(tier RateLimitTier, multiplier int) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.multipliers[tier] = multiplier
+}
+
+// rule returns the scope's rule scaled for tier
+func (rl *RateLimiter) rule(scope string, tier RateLimitTier) (RateLimitRule, bool) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	rule, ok := rl.rules[scope]
+	if multiplier := rl.multipliers[tier]; multiplier > 1 {
+		rule.Requests *= multiplier
+	}
+	return rule, ok
+}
+
+// Limit applies the scope's rule to every request. scope namespaces the
+// buckets so that per-route overrides do not share tokens with the global
+// limit.
+func (rl *RateLimiter) Limit(scope string) gin.HandlerFunc {
+	return rl.LimitIf(scope, nil)
+}
+
+// LimitIf applies the scope's rule only to requests for which match returns true
+func (rl *RateLimiter) LimitIf(scope string, match func(*gin.Context) bool) gin.HandlerFunc {
+	if match == nil {
+		return rl.LimitCost(scope, nil)
+	}
+	return rl.LimitCost(scope, func(c *gin.Context) int {
+		if match(c) {
+			return 1
+		}
+		return 0
+	})
+}
+
+// LimitCost applies the scope's rule taking as many tokens as cost returns
+// for the request, such as one per GraphQL login in a request. Requests
+// costing nothing are let through; a nil cost takes one token per request.
+func (rl *RateLimiter) LimitCost(scope string, cost func(*gin.Context) int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := rl.rule(scope, rateLimitTier(c))
+		if rl.store == nil || !rl.enabled.Load() || !ok {
+			c.Next()
+			return
+		}
+		n := 1
+		if cost != nil {
+			n = cost(c)
+		}
+		if n <= 0 {
+			c.Next()
+			return
+		}
+
+		result, err := rl.store.Take(c.Request.Context(), "ratelimit:"+scope+":"+rateLimitSubject(c), rule, n)
+		if err != nil {
+			// Fail open: an unavailable store must not take the API down
+			rl.logger.Error("Rate limit store unavailable", logger.String("scope", scope), logger.Error(err))
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rule.Requests))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			RespondError(c, apperrors.RateLimited("Rate limit exceeded, try again later"))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func rateLimitSubject(c *gin.Context) string {
+	if claims, ok := GetCurrentUser(c); ok {
+		return "user:" + claims.UserID.String()
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// rateLimitTier classes the caller by the admin scope, then by role
+func rateLimitTier(c *gin.Context) RateLimitTier {
+	claims, ok := GetCurrentUser(c)
+	switch {
+	case !ok:
+		return TierAnonymous
+	case claims.HasScope(auth.ScopeAdmin):
+		return TierAdmin
+	case claims.Role == models.RoleManager:
+		return TierManager
+	}
+	return TierMember
+}
+
+// IsReadRequest matches requests that do not change anything, for the read
+// rate limit class
+func IsReadRequest(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// IsWriteRequest matches the requests IsReadRequest does not
+func IsWriteRequest(c *gin.Context) bool {
+	return !IsReadRequest(c)
+}
+
+// maxGraphQLPeekBytes bounds how much of a GraphQL body is inspected
+const maxGraphQLPeekBytes = 64 << 10
+
+// CountGraphQLMutations returns a LimitCost cost counting the top-level
+// mutation fields of a GraphQL request that are any of fields, so that
+// aliased copies of a mutation each take a token. Requests that cannot be
+// inspected, because they are larger than maxGraphQLPeekBytes or do not
+// parse, cost one token: they might hide a matching mutation.
+func CountGraphQLMutations(fields ...string) func(*gin.Context) int {
+	return func(c *gin.Context) int {
+		mutations, ok := graphQLMutationFields(c)
+		if !ok {
+			return 1
+		}
+		count := 0
+		for _, name := range mutations {
+			if slices.Contains(fields, name) {
+				count++
+			}
+		}
+		return count
+	}
+}
+
+// graphQLMutationFields returns the top-level fields selected by the
+// mutations of a GraphQL request, once per selection and including those
+// selected through fragments. It returns false when the request cannot be
+// parsed, such as when it is larger than maxGraphQLPeekBytes. The body is
+// restored for the handler.
+func graphQLMutationFields(c *gin.Context) ([]string, bool) {
+	if c.Request.Body == nil {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxGraphQLPeekBytes+1))
+	c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), c.Request.Body))
+	if err != nil || len(body) > maxGraphQLPeekBytes {
+		return nil, false
+	}
+
+	if c.ContentType() == "multipart/form-data" {
+		if body = graphQLOperations(c, body); body == nil {
+			return nil, false
+		}
+	}
+	var req struct {
+		Query string `json:"query"`
+	}
+	if json.Unmarshal(body, &req) != nil || req.Query == "" {
+		return nil, false
+	}
+	doc, gqlErr := parser.ParseQuery(&ast.Source{Input: req.Query})
+	if gqlErr != nil {
+		return nil, false
+	}
+
+	var fields []string
+	for _, op := range doc.Operations {
+		if op.Operation != ast.Mutation {
+			continue
+		}
+		fields = appendTopLevelFields(fields, doc, op.SelectionSet, map[string]bool{})
+	}
+	return fields, true
+}
+
+// appendTopLevelFields appends the names of the fields in set, looking
+// through inline fragments and fragment spreads, which select fields of the
+// same type. spread guards against fragments that spread themselves.
+func appendTopLevelFields(fields []string, doc *ast.QueryDocument, set ast.SelectionSet, spread map[string]bool) []string {
+	for _, sel := range set {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			fields = append(fields, sel.Name)
+		case *ast.InlineFragment:
+			fields = appendTopLevelFields(fields, doc, sel.SelectionSet, spread)
+		case *ast.FragmentSpread:
+			fragment := doc.Fragments.ForName(sel.Name)
+			if fragment == nil || spread[sel.Name] {
+				continue
+			}
+			spread[sel.Name] = true
+			fields = appendTopLevelFields(fields, doc, fragment.SelectionSet, spread)
+		}
+	}
+	return fields
+}
+
+// graphQLOperations returns the operations field of a multipart GraphQL
+// upload, which the multipart request spec puts before the files, or nil
+func graphQLOperations(c *gin.Context, body []byte) []byte {
+	_, params, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if err != nil || params["boundary"] == "" {
+		return nil
+	}
+	part, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).NextPart()
+	if err != nil || part.FormName() != "operations" {
+		return nil
+	}
+	operations, err := io.ReadAll(part)
+	if err != nil {
+		return nil
+	}
+	return operations
+}
+
+// MemoryRateLimitStore keeps buckets in process memory
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	idle   time.Duration
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, rule RateLimitRule, n int) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.prune(now)
+
+	capacity := float64(rule.Requests)
+	rate := rule.refillPerSecond()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: capacity, last: now, idle: rule.Period}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens < float64(n) {
+		wait := time.Duration((float64(n) - b.tokens) / rate * float64(time.Second))
+		return RateLimitResult{Allowed: false, Remaining: int(b.tokens), RetryAfter: wait}, nil
+	}
+	b.tokens -= float64(n)
+	return RateLimitResult{Allowed: true, Remaining: int(b.tokens)}, nil
+}
+
+// prune drops buckets that have refilled completely, at most once a minute
+func (s *MemoryRateLimitStore) prune(now time.Time) {
+	if now.Sub(s.lastPrune) < time.Minute {
+		return
+	}
+	s.lastPrune = now
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > b.idle {
+			delete(s.buckets, key)
+		}
+	}
+}
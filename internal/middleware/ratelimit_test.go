@@ -0,0 +1,374 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+// testMemoryStore returns a memory store on a clock tests move by hand
+func testMemoryStore() (*MemoryRateLimitStore, *time.Time) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryRateLimitStore()
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestParseRateLimitRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RateLimitRule
+		wantErr string
+	}{
+		{in: "100/min", want: RateLimitRule{Requests: 100, Period: time.Minute}},
+		{in: " 10/s ", want: RateLimitRule{Requests: 10, Period: time.Second}},
+		{in: "1000/hour", want: RateLimitRule{Requests: 1000, Period: time.Hour}},
+		{in: "100", wantErr: "expected <requests>/<s|min|h>"},
+		{in: "0/min", wantErr: "must be a positive integer"},
+		{in: "ten/min", wantErr: "must be a positive integer"},
+		{in: "10/day", wantErr: `unknown unit "day"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseRateLimitRule(tt.in)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMemoryRateLimitStore_Burst(t *testing.T) {
+	store, _ := testMemoryStore()
+	rule := RateLimitRule{Requests: 3, Period: 3 * time.Second}
+
+	for want := 2; want >= 0; want-- {
+		result, err := store.Take(context.Background(), "k", rule, 1)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, want, result.Remaining)
+	}
+
+	result, err := store.Take(context.Background(), "k", rule, 1)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// Buckets are per key
+	result, err = store.Take(context.Background(), "other", rule, 1)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestMemoryRateLimitStore_Refill(t *testing.T) {
+	store, now := testMemoryStore()
+	rule := RateLimitRule{Requests: 2, Period: 2 * time.Second}
+	take := func() RateLimitResult {
+		t.Helper()
+		result, err := store.Take(context.Background(), "k", rule, 1)
+		require.NoError(t, err)
+		return result
+	}
+
+	take()
+	take()
+	*now = now.Add(500 * time.Millisecond)
+	result := take()
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter, "half a token has refilled")
+
+	*now = now.Add(500 * time.Millisecond)
+	assert.True(t, take().Allowed)
+	assert.False(t, take().Allowed)
+
+	// A quiet client refills up to the bucket size and no further
+	*now = now.Add(time.Hour)
+	assert.Equal(t, 1, take().Remaining)
+	assert.Equal(t, 0, take().Remaining)
+	assert.False(t, take().Allowed)
+}
+
+func TestMemoryRateLimitStore_PrunesIdleBuckets(t *testing.T) {
+	store, now := testMemoryStore()
+	rule := RateLimitRule{Requests: 1, Period: time.Second}
+
+	_, err := store.Take(context.Background(), "idle", rule, 1)
+	require.NoError(t, err)
+	*now = now.Add(2 * time.Minute)
+	_, err = store.Take(context.Background(), "busy", rule, 1)
+	require.NoError(t, err)
+
+	assert.NotContains(t, store.buckets, "idle")
+	assert.Contains(t, store.buckets, "busy")
+}
+
+// failingRateLimitStore stands in for an unreachable Redis
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, RateLimitRule, int) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("connection refused")
+}
+
+// limitedRouter serves GET and POST /notes through rl's "api" scope, as the
+// caller claims describe or anonymously when claims is nil
+func limitedRouter(rl *RateLimiter, claims *auth.Claims, match func(*gin.Context) bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if claims != nil {
+			c.Set(ClaimsContextKey, claims)
+		}
+	})
+	router.Use(rl.LimitIf("api", match))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/notes", ok)
+	router.POST("/notes", ok)
+	return router
+}
+
+func requestNotes(router http.Handler, method, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/notes", nil)
+	if remoteAddr != "" {
+		req.RemoteAddr = remoteAddr
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimiter_RejectsOverBudget(t *testing.T) {
+	store, now := testMemoryStore()
+	rl := NewRateLimiter(store, nil)
+	rl.SetRule("api", RateLimitRule{Requests: 2, Period: 10 * time.Second})
+	router := limitedRouter(rl, &auth.Claims{UserID: uuid.New(), Role: models.RoleMember}, nil)
+
+	w := requestNotes(router, http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, http.StatusOK, requestNotes(router, http.MethodGet, "").Code)
+
+	w = requestNotes(router, http.MethodGet, "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	assert.Contains(t, w.Body.String(), "rate_limited")
+
+	// Retry-After rounds up, so a client that waits is let through
+	*now = now.Add(4500 * time.Millisecond)
+	assert.Equal(t, "1", requestNotes(router, http.MethodGet, "").Header().Get("Retry-After"))
+	*now = now.Add(500 * time.Millisecond)
+	assert.Equal(t, http.StatusOK, requestNotes(router, http.MethodGet, "").Code)
+}
+
+func TestRateLimiter_KeysBySubject(t *testing.T) {
+	store, _ := testMemoryStore()
+	rl := NewRateLimiter(store, nil)
+	rl.SetRule("api", RateLimitRule{Requests: 1, Period: time.Minute})
+
+	anonymous := limitedRouter(rl, nil, nil)
+	assert.Equal(t, http.StatusOK, requestNotes(anonymous, http.MethodGet, "192.0.2.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, requestNotes(anonymous, http.MethodGet, "192.0.2.1:5678").Code)
+	assert.Equal(t, http.StatusOK, requestNotes(anonymous, http.MethodGet, "192.0.2.2:1234").Code)
+
+	// A signed in user has a bucket of their own wherever they connect from
+	user := limitedRouter(rl, &auth.Claims{UserID: uuid.New(), Role: models.RoleMember}, nil)
+	assert.Equal(t, http.StatusOK, requestNotes(user, http.MethodGet, "192.0.2.1:1234").Code)
+	assert.Equal(t, http.StatusTooManyRequests, requestNotes(user, http.MethodGet, "192.0.2.2:1234").Code)
+}
+
+func TestRateLimiter_TierMultipliers(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		want   int
+	}{
+		{name: "anonymous", claims: nil, want: 2},
+		{name: "member", claims: &auth.Claims{UserID: uuid.New(), Role: models.RoleMember}, want: 2},
+		{name: "manager", claims: &auth.Claims{UserID: uuid.New(), Role: models.RoleManager}, want: 6},
+		{name: "admin", claims: &auth.Claims{UserID: uuid.New(), Role: models.RoleMember, Scopes: []string{auth.ScopeAdmin}}, want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := testMemoryStore()
+			rl := NewRateLimiter(store, nil)
+			rl.SetRule("api", RateLimitRule{Requests: 2, Period: time.Minute})
+			rl.SetMultiplier(TierManager, 3)
+			rl.SetMultiplier(TierAdmin, 5)
+			router := limitedRouter(rl, tt.claims, nil)
+
+			for i := 0; i < tt.want; i++ {
+				w := requestNotes(router, http.MethodGet, "")
+				require.Equal(t, http.StatusOK, w.Code, "request %d", i)
+				assert.Equal(t, strconv.Itoa(tt.want), w.Header().Get("X-RateLimit-Limit"))
+			}
+			assert.Equal(t, http.StatusTooManyRequests, requestNotes(router, http.MethodGet, "").Code)
+		})
+	}
+}
+
+func TestRateLimiter_LetsThrough(t *testing.T) {
+	rule := RateLimitRule{Requests: 1, Period: time.Minute}
+	tests := []struct {
+		name  string
+		setup func() *RateLimiter
+		match func(*gin.Context) bool
+	}{
+		{
+			name: "when disabled",
+			setup: func() *RateLimiter {
+				store, _ := testMemoryStore()
+				rl := NewRateLimiter(store, nil)
+				rl.SetRule("api", rule)
+				rl.SetEnabled(false)
+				return rl
+			},
+		},
+		{
+			name: "scopes without a rule",
+			setup: func() *RateLimiter {
+				store, _ := testMemoryStore()
+				return NewRateLimiter(store, nil)
+			},
+		},
+		{
+			name: "without a store",
+			setup: func() *RateLimiter {
+				rl := NewRateLimiter(nil, nil)
+				rl.SetRule("api", rule)
+				return rl
+			},
+		},
+		{
+			name: "when the store fails",
+			setup: func() *RateLimiter {
+				rl := NewRateLimiter(failingRateLimitStore{}, nil)
+				rl.SetRule("api", rule)
+				return rl
+			},
+		},
+		{
+			name: "requests the rule does not match",
+			setup: func() *RateLimiter {
+				store, _ := testMemoryStore()
+				rl := NewRateLimiter(store, nil)
+				rl.SetRule("api", rule)
+				return rl
+			},
+			match: IsWriteRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := limitedRouter(tt.setup(), nil, tt.match)
+			for i := 0; i < 3; i++ {
+				assert.Equal(t, http.StatusOK, requestNotes(router, http.MethodGet, "").Code, "request %d", i)
+			}
+		})
+	}
+}
+
+func TestRateLimiter_SetRuleKeepsBuckets(t *testing.T) {
+	store, _ := testMemoryStore()
+	rl := NewRateLimiter(store, nil)
+	rl.SetRule("api", RateLimitRule{Requests: 1, Period: time.Minute})
+	router := limitedRouter(rl, nil, IsWriteRequest)
+
+	assert.Equal(t, http.StatusOK, requestNotes(router, http.MethodPost, "").Code)
+	assert.Equal(t, http.StatusTooManyRequests, requestNotes(router, http.MethodPost, "").Code)
+
+	// A larger budget applies at once, but spent tokens are not handed back
+	rl.SetRule("api", RateLimitRule{Requests: 100, Period: time.Minute})
+	w := requestNotes(router, http.MethodPost, "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "100", w.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestMemoryRateLimitStore_TakesSeveralTokens(t *testing.T) {
+	store, _ := testMemoryStore()
+	rule := RateLimitRule{Requests: 5, Period: 5 * time.Second}
+
+	result, err := store.Take(context.Background(), "k", rule, 3)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+
+	// Too few tokens left: none are taken
+	result, err = store.Take(context.Background(), "k", rule, 3)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	result, err = store.Take(context.Background(), "k", rule, 2)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestCountGraphQLMutations(t *testing.T) {
+	login := `login(input: {email: \"a@example.com\", password: \"x\"}) { token }`
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{name: "one login", body: `{"query": "mutation { ` + login + ` }"}`, want: 1},
+		{name: "aliased logins", body: `{"query": "mutation { a: ` + login + ` b: ` + login + ` c: ` + login + ` }"}`, want: 3},
+		{name: "logins in fragments", body: `{"query": "mutation { ...F ... on Mutation { ` + login + ` } } fragment F on Mutation { ` + login + ` }"}`, want: 2},
+		{name: "other mutations", body: `{"query": "mutation { createTeam(input: {teamName: \"x\"}) { teamId } }"}`, want: 0},
+		{name: "queries", body: `{"query": "query { login }"}`, want: 0},
+		{name: "unparseable", body: `{"query": "mutation { login(`, want: 1},
+		{name: "not JSON", body: `login`, want: 1},
+		{name: "oversized", body: `{"query": "mutation { ` + login + ` }", "pad": "` + strings.Repeat("x", maxGraphQLPeekBytes) + `"}`, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tt.body))
+			c.Request.Header.Set("Content-Type", "application/json")
+
+			assert.Equal(t, tt.want, CountGraphQLMutations("login")(c))
+
+			// The handler still reads the whole body
+			body, err := io.ReadAll(c.Request.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
+
+func TestRateLimiter_LimitCostTakesOneTokenPerMutation(t *testing.T) {
+	store, _ := testMemoryStore()
+	rl := NewRateLimiter(store, nil)
+	rl.SetRule("login", RateLimitRule{Requests: 3, Period: time.Minute})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/graphql", rl.LimitCost("login", CountGraphQLMutations("login")), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	post := func(query string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, post("mutation { a: login { token } b: login { token } }"))
+	assert.Equal(t, http.StatusTooManyRequests, post("mutation { a: login { token } b: login { token } }"))
+	assert.Equal(t, http.StatusOK, post("mutation { createTeam { teamId } }"))
+	assert.Equal(t, http.StatusOK, post("mutation { login { token } }"))
+	assert.Equal(t, http.StatusTooManyRequests, post("mutation { login { token } }"))
+}
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/pkg/i18n"
+	"seta-training/pkg/logger"
+)
+
+const (
+	RequestIDHeader     = "X-Request-ID"
+	RequestIDContextKey = "request_id"
+	LoggerContextKey    = "logger"
+
+	maxRequestIDLength = 128
+)
+
+// RequestID propagates the caller's X-Request-ID, or generates one, and
+// makes it available to handlers, to the request-scoped logger and in the
+// response. The request-scoped logger also carries the matched route, and
+// the auth middleware adds the user ID once the token is validated. JSON
+// error bodies of the form {"error": ...} are rewritten into the apperrors
+// envelope, and every envelope gets a "request_id" field so users can quote
+// it in support reports.
+func RequestID(base logger.Logger) gin.HandlerFunc {
+	if base == nil {
+		base = logger.NewNopLogger()
+	}
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set(RequestIDContextKey, requestID)
+		c.Set(LoggerContextKey, base)
+		fields := []logger.Field{logger.String("request_id", requestID)}
+		if route := c.FullPath(); route != "" {
+			fields = append(fields, logger.String("route", route))
+		}
+		AddLogFields(c, fields...)
+		c.Header(RequestIDHeader, requestID)
+
+		writer := &errorBodyWriter{ResponseWriter: c.Writer, requestID: requestID}
+		c.Writer = writer
+		c.Next()
+		writer.localizer = GetLocalizer(c)
+		writer.flush()
+	}
+}
+
+// GetRequestID returns the current request's ID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDContextKey)
+}
+
+// GetLogger returns the request-scoped logger, tagged with fallback's
+// component, or fallback outside of the RequestID middleware
+func GetLogger(c *gin.Context, fallback logger.Logger) logger.Logger {
+	if l, ok := c.Get(LoggerContextKey); ok {
+		if reqLogger, ok := l.(logger.Logger); ok {
+			if component := logger.ComponentOf(fallback); component != "" {
+				return logger.ForComponent(reqLogger, component)
+			}
+			return reqLogger
+		}
+	}
+	return fallback
+}
+
+// AddLogFields attaches fields to the request context, so loggers given it
+// via WithContext include them, and to the request-scoped logger if the
+// RequestID middleware installed one
+func AddLogFields(c *gin.Context, fields ...logger.Field) {
+	ctx := logger.ContextWithFields(c.Request.Context(), fields...)
+	if l, ok := c.Get(LoggerContextKey); ok {
+		if reqLogger, ok := l.(logger.Logger); ok {
+			reqLogger = reqLogger.WithFields(fields...)
+			c.Set(LoggerContextKey, reqLogger)
+			ctx = logger.NewContext(ctx, reqLogger)
+		}
+	}
+	c.Request = c.Request.WithContext(ctx)
+}
+
+// validRequestID accepts short IDs made of visible ASCII so that caller
+// supplied values cannot inject into logs or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// errorBodyWriter buffers JSON error responses so they can be put into the
+// error envelope. Successful responses are passed straight through.
+type errorBodyWriter struct {
+	gin.ResponseWriter
+	requestID string
+	buf       *bytes.Buffer
+	// localizer translates the message of rewritten {"error": ...} bodies
+	localizer *i18n.Localizer
+}
+
+func (w *errorBodyWriter) capturing() bool {
+	if w.buf != nil {
+		return true
+	}
+	if w.ResponseWriter.Written() || w.Status() < http.StatusBadRequest ||
+		!strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return false
+	}
+	w.buf = &bytes.Buffer{}
+	return true
+}
+
+func (w *errorBodyWriter) Write(data []byte) (int, error) {
+	if w.capturing() {
+		return w.buf.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *errorBodyWriter) WriteString(s string) (int, error) {
+	if w.capturing() {
+		return w.buf.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *errorBodyWriter) flush() {
+	if w.buf == nil {
+		return
+	}
+	body := w.buf.Bytes()
+	w.buf = nil
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err == nil {
+		if updated, ok := w.envelope(payload); ok {
+			body = updated
+		}
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.ResponseWriter.Write(body)
+}
+
+// envelope converts a {"error": message, ...} body into the error envelope;
+// any other fields move to details. Bodies already in the envelope only get
+// the request ID.
+func (w *errorBodyWriter) envelope(payload map[string]interface{}) ([]byte, bool) {
+	if _, isEnvelope := payload["code"]; isEnvelope {
+		if _, hasID := payload["request_id"]; hasID {
+			return nil, false
+		}
+		payload["request_id"] = w.requestID
+		updated, err := json.Marshal(payload)
+		return updated, err == nil
+	}
+
+	message, ok := payload["error"].(string)
+	if !ok {
+		return nil, false
+	}
+	resp := apperrors.Response{
+		Code:      apperrors.CodeForStatus(w.Status()),
+		Message:   w.localizer.T(message),
+		RequestID: w.requestID,
+	}
+	for key, value := range payload {
+		if key == "error" || key == "request_id" {
+			continue
+		}
+		if resp.Details == nil {
+			resp.Details = make(map[string]interface{})
+		}
+		resp.Details[key] = value
+	}
+	updated, err := json.Marshal(resp)
+	return updated, err == nil
+}
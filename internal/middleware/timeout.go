@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"seta-training/internal/apperrors"
+)
+
+// RequestTimeout puts a deadline on each request's context so that handlers,
+// services and database queries working on it give up once it passes. Routes
+// doing bulk work such as imports and exports can be given longer, and
+// streams none at all. Requests whose handler returns nothing by the
+// deadline get 504.
+type RequestTimeout struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	routes map[string]time.Duration
+}
+
+func NewRequestTimeout(timeout time.Duration) *RequestTimeout {
+	return &RequestTimeout{
+		timeout: timeout,
+		routes:  make(map[string]time.Duration),
+	}
+}
+
+// SetRouteTimeout overrides the timeout for one route. path is the route
+// pattern as registered, e.g. /api/v1/import-users.
+func (t *RequestTimeout) SetRouteTimeout(method, path string, timeout time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.routes[method+" "+path] = timeout
+}
+
+func (t *RequestTimeout) timeoutFor(c *gin.Context) time.Duration {
+	t.mu.RLock()
+	timeout, ok := t.routes[c.Request.Method+" "+c.FullPath()]
+	t.mu.RUnlock()
+	if ok {
+		return timeout
+	}
+	return t.timeout
+}
+
+// Middleware applies the timeouts. A timeout of zero or less leaves the
+// request without a deadline.
+func (t *RequestTimeout) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		timeout := t.timeoutFor(c)
+		if timeout <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if !c.Writer.Written() && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			RespondError(c, apperrors.Timeout("request timed out"))
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// UsageRecorder counts the requests of authenticated users
+type UsageRecorder interface {
+	Record(userID uuid.UUID, route string, status int, latency time.Duration)
+}
+
+// TrackUsage records each request made with a valid token by route
+// template, so that IDs in paths do not split the counts. Requests that
+// match no route are not recorded.
+func TrackUsage(recorder UsageRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		claims, ok := GetCurrentUser(c)
+		if !ok || c.FullPath() == "" {
+			return
+		}
+		recorder.Record(claims.UserID, c.Request.Method+" "+c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AccessRequestStatus is where an access request stands
+type AccessRequestStatus string
+
+const (
+	AccessRequestPending  AccessRequestStatus = "pending"
+	AccessRequestApproved AccessRequestStatus = "approved"
+	AccessRequestDenied   AccessRequestStatus = "denied"
+)
+
+// Resource types access can be requested to
+const (
+	ResourceFolder = "folder"
+	ResourceNote   = "note"
+)
+
+// AccessRequest is a user asking the owner of a folder or note to share it
+// with them. Approving it shares the resource with the access asked for.
+type AccessRequest struct {
+	ID           uuid.UUID           `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ResourceType string              `json:"resource_type" gorm:"type:varchar(16);not null"`
+	ResourceID   uuid.UUID           `json:"resource_id" gorm:"type:uuid;not null;index"`
+	OwnerID      uuid.UUID           `json:"owner_id" gorm:"type:uuid;not null;index"`
+	RequesterID  uuid.UUID           `json:"requester_id" gorm:"type:uuid;not null;index"`
+	Access       AccessLevel         `json:"access" gorm:"type:varchar(10);not null"`
+	Message      string              `json:"message,omitempty" gorm:"type:varchar(500)"`
+	Status       AccessRequestStatus `json:"status" gorm:"type:varchar(16);not null;default:'pending';index"`
+	DecidedAt    *time.Time          `json:"decided_at,omitempty"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+
+	// Relationships
+	Requester User `json:"requester,omitempty" gorm:"foreignKey:RequesterID"`
+}
+
+func (r *AccessRequest) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
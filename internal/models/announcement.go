@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Announcement levels, which clients can use to style the banner
+const (
+	AnnouncementInfo     = "info"
+	AnnouncementWarning  = "warning"
+	AnnouncementCritical = "critical"
+)
+
+// Announcement is a banner admins publish to every client, such as a
+// maintenance window or a new feature. It is shown from StartsAt until
+// EndsAt, or indefinitely when EndsAt is nil.
+type Announcement struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Title     string     `json:"title" gorm:"type:varchar(200);not null"`
+	Body      string     `json:"body" gorm:"type:text;not null"`
+	Level     string     `json:"level" gorm:"type:varchar(16);not null;default:'info'"`
+	StartsAt  time.Time  `json:"starts_at" gorm:"not null;index"`
+	EndsAt    *time.Time `json:"ends_at,omitempty" gorm:"index"`
+	CreatedBy uuid.UUID  `json:"created_by" gorm:"type:uuid;not null"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (a *Announcement) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// ActiveAt reports whether the announcement is shown at t
+func (a *Announcement) ActiveAt(t time.Time) bool {
+	return !t.Before(a.StartsAt) && (a.EndsAt == nil || t.Before(*a.EndsAt))
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIUsage counts the requests one user made to one route on one day (UTC).
+// Route is the method and path template, such as "GET /api/v1/notes/:noteId".
+type APIUsage struct {
+	UserID   uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	Route    string    `json:"route" gorm:"type:varchar(255);primaryKey"`
+	Day      time.Time `json:"day" gorm:"type:date;primaryKey"`
+	Requests int64     `json:"requests" gorm:"not null"`
+	// Errors counts responses with a 4xx or 5xx status
+	Errors int64 `json:"errors" gorm:"not null"`
+	// LatencyMillis is the time spent serving all the requests
+	LatencyMillis int64 `json:"latency_millis" gorm:"not null"`
+}
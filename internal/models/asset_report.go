@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AssetReport is an asynchronous request by an admin for the asset report of
+// their organization, rendered as CSV. It goes through the same statuses as
+// an export job.
+type AssetReport struct {
+	ID             uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OwnerID        uuid.UUID    `json:"owner_id" gorm:"type:uuid;not null;index"`
+	OrganizationID *uuid.UUID   `json:"organization_id,omitempty" gorm:"type:uuid;index"`
+	Status         ExportStatus `json:"status" gorm:"type:varchar(16);not null;default:'pending'"`
+	Error          string       `json:"error,omitempty" gorm:"type:text"`
+	FileName       string       `json:"file_name,omitempty"`
+	TeamCount      int          `json:"team_count"`
+	SizeBytes      int          `json:"size_bytes"`
+	Artifact       []byte       `json:"-" gorm:"type:bytea"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	CompletedAt    *time.Time   `json:"completed_at,omitempty"`
+}
+
+func (r *AssetReport) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// OwnerAssets is what one user of an organization owns, as gathered for an
+// asset report. Recipients are the users their folders and notes are shared
+// with.
+type OwnerAssets struct {
+	UserID        uuid.UUID
+	Deleted       bool
+	Folders       int64
+	Notes         int64
+	SharedFolders int64
+	SharedNotes   int64
+	Recipients    []uuid.UUID
+}
+
+// TeamAssetSummary is one row of an asset report: the folders and notes
+// owned by the people of a team, how widely they are shared and how many
+// belong to deleted users. TeamID is nil on the row for owners in no team.
+type TeamAssetSummary struct {
+	TeamID   *uuid.UUID `json:"team_id"`
+	TeamName string     `json:"team_name"`
+	People   int        `json:"people"`
+	Folders  int64      `json:"folders"`
+	Notes    int64      `json:"notes"`
+	// SharedFolders and SharedNotes have at least one share
+	SharedFolders int64 `json:"shared_folders"`
+	SharedNotes   int64 `json:"shared_notes"`
+	// ShareRecipients counts the users they are shared with, and
+	// ExternalRecipients those of them outside the team
+	ShareRecipients    int `json:"share_recipients"`
+	ExternalRecipients int `json:"external_recipients"`
+	// OrphanedFolders and OrphanedNotes are owned by deleted users
+	OrphanedFolders int64 `json:"orphaned_folders"`
+	OrphanedNotes   int64 `json:"orphaned_notes"`
+}
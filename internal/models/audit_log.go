@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLog records a change made through the API: who did what to which
+// resource. Details holds action-specific context such as the user a note
+// was shared with. ImpersonatorID is the admin who made the change as the
+// actor with an impersonation token.
+type AuditLog struct {
+	ID             uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ActorID        uuid.UUID         `json:"actor_id" gorm:"type:uuid;not null;index"`
+	ImpersonatorID *uuid.UUID        `json:"impersonator_id,omitempty" gorm:"type:uuid;index"`
+	Action         string            `json:"action" gorm:"type:varchar(32);not null"`
+	TargetType     string            `json:"target_type" gorm:"type:varchar(32);not null;index:idx_audit_logs_target"`
+	TargetID       uuid.UUID         `json:"target_id" gorm:"type:uuid;not null;index:idx_audit_logs_target"`
+	Details        map[string]string `json:"details,omitempty" gorm:"type:jsonb;serializer:json"`
+	CreatedAt      time.Time         `json:"created_at" gorm:"not null;index"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ChecklistItem is one task of a note's checklist. Items are ordered by
+// Position, counted from 0.
+type ChecklistItem struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	NoteID    uuid.UUID  `json:"note_id" gorm:"type:uuid;not null;index"`
+	Text      string     `json:"text" gorm:"type:varchar(500);not null"`
+	Done      bool       `json:"done" gorm:"not null;default:false"`
+	Position  int        `json:"position" gorm:"not null"`
+	DoneAt    *time.Time `json:"done_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+func (i *ChecklistItem) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
+
+// ChecklistSummary counts the items of a note's checklist and how many are
+// done
+type ChecklistSummary struct {
+	Total int `json:"total"`
+	Done  int `json:"done"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Device is a browser or app a user logged in with, told apart by its user
+// agent. Tokens issued at login carry its ID; revoking the device rejects
+// them.
+type Device struct {
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	UserAgent   string     `json:"user_agent" gorm:"type:varchar(512)"`
+	LastIP      string     `json:"last_ip" gorm:"type:varchar(45)"`
+	LastLoginAt time.Time  `json:"last_login_at"`
+	RevokedAt   *time.Time `json:"-" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at"`
+	// Current marks the device of the token the devices were listed with
+	Current bool `json:"current" gorm:"-"`
+}
+
+func (d *Device) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportFormat is the artifact type produced by an export job
+type ExportFormat string
+
+const (
+	ExportFormatZip ExportFormat = "zip"
+	ExportFormatPDF ExportFormat = "pdf"
+)
+
+// ExportStatus tracks an export job through the background queue
+type ExportStatus string
+
+const (
+	ExportStatusPending   ExportStatus = "pending"
+	ExportStatusRunning   ExportStatus = "running"
+	ExportStatusCompleted ExportStatus = "completed"
+	ExportStatusFailed    ExportStatus = "failed"
+)
+
+// ExportJob is an asynchronous request to render a folder's notes into a
+// single downloadable artifact
+type ExportJob struct {
+	ID          uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OwnerID     uuid.UUID    `json:"owner_id" gorm:"type:uuid;not null;index"`
+	FolderID    uuid.UUID    `json:"folder_id" gorm:"type:uuid;not null"`
+	Format      ExportFormat `json:"format" gorm:"type:varchar(8);not null"`
+	Status      ExportStatus `json:"status" gorm:"type:varchar(16);not null;default:'pending'"`
+	Error       string       `json:"error,omitempty" gorm:"type:text"`
+	FileName    string       `json:"file_name,omitempty"`
+	ContentType string       `json:"content_type,omitempty"`
+	NoteCount   int          `json:"note_count"`
+	SizeBytes   int          `json:"size_bytes"`
+	Artifact    []byte       `json:"-" gorm:"type:bytea"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+}
+
+func (j *ExportJob) BeforeCreate(tx *gorm.DB) error {
+	if j.ID == uuid.Nil {
+		j.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeatureFlag is a feature flag managed through the admin API. It overrides
+// a flag of the same name in the config file.
+type FeatureFlag struct {
+	Name        string `json:"name" gorm:"type:varchar(64);primary_key"`
+	Description string `json:"description" gorm:"type:text"`
+	// Enabled switches the feature on for everyone
+	Enabled bool `json:"enabled" gorm:"not null;default:false"`
+	// Users and Teams switch it on for the listed users and teams
+	Users []uuid.UUID `json:"users" gorm:"type:jsonb;serializer:json;not null"`
+	Teams []uuid.UUID `json:"teams" gorm:"type:jsonb;serializer:json;not null"`
+	// Percentage switches it on for that share of all users
+	Percentage int        `json:"percentage" gorm:"not null;default:0"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	UpdatedBy  *uuid.UUID `json:"updated_by,omitempty" gorm:"type:uuid"`
+}
@@ -17,10 +17,22 @@ const (
 type Folder struct {
 	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Name      string    `json:"name" gorm:"not null"`
+	// Color, Icon and Description are optional and help tell folders apart.
+	// Color is a lowercase hex color such as #3b82f6 and Icon the name of an
+	// icon the clients know, such as "briefcase".
+	Color       string `json:"color,omitempty" gorm:"type:varchar(7)"`
+	Icon        string `json:"icon,omitempty" gorm:"type:varchar(32)"`
+	Description string `json:"description,omitempty" gorm:"type:varchar(500)"`
 	OwnerID   uuid.UUID `json:"owner_id" gorm:"type:uuid;not null"`
+	// OrganizationID is the owner's organization, set on create
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid;index"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	// CreatedBy and UpdatedBy are the users who created the folder and last
+	// changed it, set from the actor of the request
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty" gorm:"type:uuid"`
 
 	// Relationships
 	Owner       User         `json:"owner,omitempty" gorm:"foreignKey:OwnerID"`
@@ -33,6 +45,13 @@ func (f *Folder) BeforeCreate(tx *gorm.DB) error {
 	if f.ID == uuid.Nil {
 		f.ID = uuid.New()
 	}
+	if f.OrganizationID == nil {
+		orgID, err := organizationOf(tx, &User{}, f.OwnerID)
+		if err != nil {
+			return err
+		}
+		f.OrganizationID = orgID
+	}
 	return nil
 }
 
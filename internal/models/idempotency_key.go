@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IdempotencyKey records the response to a request sent with an
+// Idempotency-Key header so that retries replay it instead of repeating the
+// request. Keys are scoped to the user who sent them.
+type IdempotencyKey struct {
+	ID     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	Key    string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_keys_user_key"`
+	// RequestHash fingerprints the method, URL and body of the first request
+	RequestHash string `gorm:"type:varchar(64);not null"`
+	// StatusCode is zero while the first request is still being processed
+	StatusCode  int
+	ContentType string `gorm:"type:varchar(255)"`
+	Location    string `gorm:"type:varchar(512)"`
+	Body        []byte `gorm:"type:bytea"`
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (k *IdempotencyKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// Completed reports whether the stored response is ready to be replayed
+func (k *IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// JobLock coordinates a scheduled job across instances. An instance may run
+// the job only after claiming the row for a scheduled time later than
+// LastScheduledAt, so each run happens once however many instances are up.
+// LockedUntil bounds how long a crashed instance can hold the lock.
+type JobLock struct {
+	Name            string `gorm:"type:varchar(100);primaryKey"`
+	LockedBy        string `gorm:"type:varchar(255)"`
+	LockedUntil     *time.Time
+	LastScheduledAt *time.Time
+	LastStartedAt   *time.Time
+	LastFinishedAt  *time.Time
+	LastError       string `gorm:"type:text"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LDAPConnection is an organization's LDAP or Active Directory server. Its
+// users and the members of the mapped groups are synced into the
+// organization's users and teams.
+type LDAPConnection struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex"`
+	// URL is the ldap:// or ldaps:// address of the server. StartTLS
+	// upgrades ldap:// connections before binding.
+	URL      string `json:"url" gorm:"type:varchar(255);not null"`
+	StartTLS bool   `json:"start_tls" gorm:"not null"`
+	BindDN   string `json:"bind_dn" gorm:"type:varchar(255);not null"`
+	// BindPassword is never returned by the API
+	BindPassword string `json:"-" gorm:"type:varchar(255);not null"`
+	// UserBaseDN and UserFilter select the directory's users
+	UserBaseDN string `json:"user_base_dn" gorm:"type:varchar(255);not null"`
+	UserFilter string `json:"user_filter" gorm:"type:varchar(1024);not null"`
+	// IDAttribute holds the stable identifier of users, such as entryUUID
+	// or objectGUID; their DN is used when empty
+	IDAttribute       string `json:"id_attribute" gorm:"type:varchar(255)"`
+	EmailAttribute    string `json:"email_attribute" gorm:"type:varchar(255);not null"`
+	UsernameAttribute string `json:"username_attribute" gorm:"type:varchar(255);not null"`
+	// MemberAttribute lists the member DNs of the mapped groups
+	MemberAttribute string `json:"member_attribute" gorm:"type:varchar(255);not null"`
+	// RoleMapping maps group DNs to roles. Users in none of them get
+	// DefaultRole.
+	RoleMapping map[string]UserRole `json:"role_mapping" gorm:"type:jsonb;serializer:json;not null"`
+	DefaultRole UserRole            `json:"default_role" gorm:"type:varchar(20);not null;default:'member'"`
+	// TeamMapping puts the members of groups into teams
+	TeamMapping []LDAPTeamMapping `json:"team_mapping" gorm:"type:jsonb;serializer:json;not null"`
+	Enabled     bool              `json:"enabled" gorm:"not null"`
+	// LastSyncedAt and LastSyncError describe the last sync that ran,
+	// scheduled or not, other than a dry run
+	LastSyncedAt  *time.Time `json:"last_synced_at,omitempty"`
+	LastSyncError string     `json:"last_sync_error,omitempty" gorm:"type:text"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+func (c *LDAPConnection) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// LDAPTeamMapping gives the members of the group with DN Group the role
+// Role in the team TeamID
+type LDAPTeamMapping struct {
+	Group  string    `json:"group" binding:"required"`
+	TeamID uuid.UUID `json:"team_id" binding:"required"`
+	Role   TeamRole  `json:"role" binding:"omitempty,oneof=admin manager member viewer"`
+}
+
+// LDAPUser links a user to the directory entry they were synced from. Only
+// linked users are updated and deactivated by syncs.
+type LDAPUser struct {
+	UserID         uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex:idx_ldap_users_org_external"`
+	// ExternalID is the value of the connection's IDAttribute, or the DN
+	ExternalID string    `json:"external_id" gorm:"type:varchar(255);not null;uniqueIndex:idx_ldap_users_org_external"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginOutcome is how a login attempt ended
+type LoginOutcome string
+
+const (
+	LoginSucceeded LoginOutcome = "succeeded"
+	// LoginInvalidPassword is a known email with the wrong password
+	LoginInvalidPassword LoginOutcome = "invalid_password"
+	// LoginUnknownEmail matches no user; such attempts have no UserID
+	LoginUnknownEmail LoginOutcome = "unknown_email"
+)
+
+// LoginAttempt is one try at logging in. NewDevice marks a successful login
+// from a user agent the user had not logged in with before.
+type LoginAttempt struct {
+	ID        uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    *uuid.UUID   `json:"user_id,omitempty" gorm:"type:uuid;index:idx_login_attempts_user_created"`
+	Email     string       `json:"email" gorm:"type:varchar(255);not null"`
+	IP        string       `json:"ip" gorm:"type:varchar(45);index:idx_login_attempts_ip_created"`
+	UserAgent string       `json:"user_agent" gorm:"type:varchar(512)"`
+	Outcome   LoginOutcome `json:"outcome" gorm:"type:varchar(16);not null"`
+	NewDevice bool         `json:"new_device"`
+	CreatedAt time.Time    `json:"created_at" gorm:"index:idx_login_attempts_user_created;index:idx_login_attempts_ip_created"`
+}
+
+func (a *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
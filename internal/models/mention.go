@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Mention records that a note's body references a user with @username
+type Mention struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	NoteID          uuid.UUID `json:"note_id" gorm:"type:uuid;not null;uniqueIndex:idx_mention_note_user"`
+	MentionedUserID uuid.UUID `json:"mentioned_user_id" gorm:"type:uuid;not null;uniqueIndex:idx_mention_note_user;index"`
+	AuthorID        uuid.UUID `json:"author_id" gorm:"type:uuid;not null"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// Relationships
+	Note          Note `json:"note,omitempty" gorm:"foreignKey:NoteID"`
+	Author        User `json:"author,omitempty" gorm:"foreignKey:AuthorID"`
+	MentionedUser User `json:"mentioned_user,omitempty" gorm:"foreignKey:MentionedUserID"`
+}
+
+func (m *Mention) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
@@ -13,9 +13,31 @@ type Note struct {
 	Body      string    `json:"body" gorm:"type:text"`
 	FolderID  uuid.UUID `json:"folder_id" gorm:"type:uuid;not null"`
 	OwnerID   uuid.UUID `json:"owner_id" gorm:"type:uuid;not null"`
+	// Status is the note's place in the publishing workflow, empty for
+	// notes outside it
+	Status NoteStatus `json:"status,omitempty" gorm:"type:varchar(16);not null;default:'';index"`
+	// OrganizationID is the folder's organization, set on create
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid;index"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	// CreatedBy and UpdatedBy are the users who created the note and last
+	// changed it, set from the actor of the request
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty" gorm:"type:uuid"`
+
+	// Compressed storage. BodyEncoding names the algorithm used for
+	// CompressedBody; an empty value means the body is stored in Body as-is.
+	BodyEncoding   string `json:"-" gorm:"type:varchar(16)"`
+	CompressedBody []byte `json:"-" gorm:"type:bytea"`
+	// Encrypted storage. When BodyDataKey is set, CompressedBody is sealed
+	// with that data key, wrapped by the master key BodyKeyID.
+	BodyKeyID   string `json:"-" gorm:"type:varchar(255)"`
+	BodyDataKey []byte `json:"-" gorm:"type:bytea"`
+
+	// Checklist counts the note's checklist items. Note listings fill it
+	// in; it is not stored.
+	Checklist *ChecklistSummary `json:"checklist,omitempty" gorm:"-"`
 
 	// Relationships
 	Folder      Folder      `json:"folder,omitempty" gorm:"foreignKey:FolderID"`
@@ -28,6 +50,13 @@ func (n *Note) BeforeCreate(tx *gorm.DB) error {
 	if n.ID == uuid.Nil {
 		n.ID = uuid.New()
 	}
+	if n.OrganizationID == nil {
+		orgID, err := organizationOf(tx, &Folder{}, n.FolderID)
+		if err != nil {
+			return err
+		}
+		n.OrganizationID = orgID
+	}
 	return nil
 }
 
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NoteLink records that the body of note SourceID links to note TargetID,
+// with [[note-id]] or a Markdown or HTML link to the note
+type NoteLink struct {
+	SourceID  uuid.UUID `json:"source_id" gorm:"type:uuid;primary_key"`
+	TargetID  uuid.UUID `json:"target_id" gorm:"type:uuid;primary_key;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Backlink is a note linking to another, as listed on the linked note
+type Backlink struct {
+	NoteID    uuid.UUID `json:"note_id"`
+	Title     string    `json:"title"`
+	FolderID  uuid.UUID `json:"folder_id"`
+	OwnerID   uuid.UUID `json:"owner_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
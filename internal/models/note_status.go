@@ -0,0 +1,48 @@
+package models
+
+import "slices"
+
+// NoteStatus is where a note stands in the publishing workflow. Notes
+// outside the workflow have no status.
+type NoteStatus string
+
+const (
+	NoteStatusDraft     NoteStatus = "draft"
+	NoteStatusInReview  NoteStatus = "in_review"
+	NoteStatusPublished NoteStatus = "published"
+	NoteStatusArchived  NoteStatus = "archived"
+)
+
+// NoteStatuses are the valid statuses, in workflow order
+var NoteStatuses = []NoteStatus{NoteStatusDraft, NoteStatusInReview, NoteStatusPublished, NoteStatusArchived}
+
+// noteStatusTransitions lists the statuses each status can move to. A note
+// without a status can enter the workflow at any status.
+var noteStatusTransitions = map[NoteStatus][]NoteStatus{
+	NoteStatusDraft:     {NoteStatusInReview, NoteStatusPublished, NoteStatusArchived},
+	NoteStatusInReview:  {NoteStatusDraft, NoteStatusPublished, NoteStatusArchived},
+	NoteStatusPublished: {NoteStatusDraft, NoteStatusArchived},
+	NoteStatusArchived:  {NoteStatusDraft},
+}
+
+// Valid reports whether s is one of NoteStatuses
+func (s NoteStatus) Valid() bool {
+	return slices.Contains(NoteStatuses, s)
+}
+
+// CanBecome reports whether a note can move from s to to
+func (s NoteStatus) CanBecome(to NoteStatus) bool {
+	if !to.Valid() {
+		return false
+	}
+	if s == "" {
+		return true
+	}
+	return slices.Contains(noteStatusTransitions[s], to)
+}
+
+// NoteFilter narrows a note listing. The zero value matches every note.
+type NoteFilter struct {
+	// Statuses matches notes with any of the statuses
+	Statuses []NoteStatus
+}
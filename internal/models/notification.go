@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationType identifies what triggered a notification
+type NotificationType string
+
+const (
+	NotificationMention       NotificationType = "mention"
+	NotificationReminder      NotificationType = "reminder"
+	NotificationAccessRequest NotificationType = "access_request"
+	NotificationNewDevice     NotificationType = "new_device_login"
+)
+
+// Notification is an in-app message addressed to a single user
+type Notification struct {
+	ID           uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;index"`
+	Type         NotificationType `json:"type" gorm:"type:varchar(32);not null"`
+	ActorID      *uuid.UUID       `json:"actor_id,omitempty" gorm:"type:uuid"`
+	ResourceType string           `json:"resource_type,omitempty" gorm:"type:varchar(32)"`
+	ResourceID   *uuid.UUID       `json:"resource_id,omitempty" gorm:"type:uuid"`
+	Message      string           `json:"message" gorm:"not null"`
+	ReadAt       *time.Time       `json:"read_at,omitempty"`
+	CreatedAt    time.Time        `json:"created_at"`
+}
+
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Organization is a tenant. Users, teams, folders and notes belong to at most
+// one; rows without an organization form the default tenant, so single
+// company deployments need no organizations at all.
+type Organization struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string         `json:"name" gorm:"not null"`
+	Slug      string         `json:"slug" gorm:"uniqueIndex;not null"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+}
+
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return nil
+}
+
+// SameOrganization reports whether two organization IDs name the same
+// tenant, treating nil as the default tenant
+func SameOrganization(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// organizationOf returns the organization of the row id in model's table, or
+// nil if it has none or does not exist
+func organizationOf(tx *gorm.DB, model interface{}, id uuid.UUID) (*uuid.UUID, error) {
+	var orgIDs []uuid.NullUUID
+	err := tx.Session(&gorm.Session{NewDB: true}).Model(model).
+		Where("id = ?", id).Limit(1).Pluck("organization_id", &orgIDs).Error
+	if err != nil || len(orgIDs) == 0 || !orgIDs[0].Valid {
+		return nil, err
+	}
+	return &orgIDs[0].UUID, nil
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Domain event types written to the outbox
+const (
+	EventUserCreated             = "user.created"
+	EventUserOrganizationChanged = "user.organization.changed"
+	EventTeamCreated             = "team.created"
+	EventTeamMemberAdded         = "team.member.added"
+	EventTeamMemberRemoved       = "team.member.removed"
+	EventFolderCreated           = "folder.created"
+	EventFolderUpdated           = "folder.updated"
+	EventFolderDeleted           = "folder.deleted"
+	EventFolderShared            = "folder.shared"
+	EventFolderUnshared          = "folder.unshared"
+	EventNoteCreated             = "note.created"
+	EventNoteUpdated             = "note.updated"
+	EventNoteDeleted             = "note.deleted"
+	EventNoteShared              = "note.shared"
+	EventNoteUnshared            = "note.unshared"
+	EventNoteStatusChanged       = "note.status_changed"
+	EventAccessRequested         = "access_request.created"
+	EventAccessRequestDecided    = "access_request.decided"
+)
+
+// OutboxEvent is a domain event waiting to be published. It is written in
+// the same transaction as the change it describes, so an event exists if and
+// only if the change was committed. The relay publishes pending events and
+// sets PublishedAt.
+type OutboxEvent struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EventType   string          `gorm:"type:varchar(64);not null"`
+	AggregateID uuid.UUID       `gorm:"type:uuid;not null"`
+	Payload     json.RawMessage `gorm:"type:jsonb;serializer:json;not null"`
+	CreatedAt   time.Time       `gorm:"not null"`
+	PublishedAt *time.Time      `gorm:"index"`
+	// Attempts counts failed publishes; NextAttemptAt delays the next one
+	Attempts      int
+	LastError     string    `gorm:"type:text"`
+	NextAttemptAt time.Time `gorm:"not null;index:idx_outbox_events_pending,where:published_at IS NULL"`
+}
+
+func (e *OutboxEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// UserCreatedEvent is the payload of EventUserCreated
+type UserCreatedEvent struct {
+	UserID   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Email    string    `json:"email"`
+	Role     UserRole  `json:"role"`
+}
+
+// UserOrganizationEvent is the payload of EventUserOrganizationChanged
+type UserOrganizationEvent struct {
+	UserID         uuid.UUID `json:"user_id"`
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+// TeamCreatedEvent is the payload of EventTeamCreated
+type TeamCreatedEvent struct {
+	TeamID uuid.UUID `json:"team_id"`
+	Name   string    `json:"name"`
+}
+
+// TeamMemberEvent is the payload of EventTeamMemberAdded and
+// EventTeamMemberRemoved
+type TeamMemberEvent struct {
+	TeamID uuid.UUID `json:"team_id"`
+	UserID uuid.UUID `json:"user_id"`
+	// Role is the role the user joined with; empty when they left
+	Role TeamRole `json:"role,omitempty"`
+}
+
+// FolderEvent is the payload of EventFolderCreated, EventFolderUpdated and
+// EventFolderDeleted
+type FolderEvent struct {
+	FolderID uuid.UUID `json:"folder_id"`
+}
+
+// FolderSharedEvent is the payload of EventFolderShared
+type FolderSharedEvent struct {
+	FolderID uuid.UUID   `json:"folder_id"`
+	UserID   uuid.UUID   `json:"user_id"`
+	Access   AccessLevel `json:"access"`
+}
+
+// FolderUnsharedEvent is the payload of EventFolderUnshared
+type FolderUnsharedEvent struct {
+	FolderID uuid.UUID `json:"folder_id"`
+	UserID   uuid.UUID `json:"user_id"`
+}
+
+// NoteCreatedEvent is the payload of EventNoteCreated
+type NoteCreatedEvent struct {
+	NoteID   uuid.UUID `json:"note_id"`
+	FolderID uuid.UUID `json:"folder_id"`
+	OwnerID  uuid.UUID `json:"owner_id"`
+}
+
+// NoteSharedEvent is the payload of EventNoteShared
+type NoteSharedEvent struct {
+	NoteID uuid.UUID   `json:"note_id"`
+	UserID uuid.UUID   `json:"user_id"`
+	Access AccessLevel `json:"access"`
+}
+
+// NoteEvent is the payload of EventNoteUpdated and EventNoteDeleted
+type NoteEvent struct {
+	NoteID uuid.UUID `json:"note_id"`
+}
+
+// NoteUnsharedEvent is the payload of EventNoteUnshared
+type NoteUnsharedEvent struct {
+	NoteID uuid.UUID `json:"note_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// NoteStatusChangedEvent is the payload of EventNoteStatusChanged. UserIDs
+// are the users to notify: the owner and the users the note is shared
+// with, except the one who changed it.
+type NoteStatusChangedEvent struct {
+	NoteID    uuid.UUID   `json:"note_id"`
+	From      NoteStatus  `json:"from,omitempty"`
+	To        NoteStatus  `json:"to"`
+	ChangedBy uuid.UUID   `json:"changed_by"`
+	UserIDs   []uuid.UUID `json:"user_ids"`
+}
+
+// AccessRequestEvent is the payload of EventAccessRequested and
+// EventAccessRequestDecided. UserID is the user to notify: the owner of the
+// resource for a new request, and the requester once it is decided.
+type AccessRequestEvent struct {
+	RequestID    uuid.UUID           `json:"request_id"`
+	ResourceType string              `json:"resource_type"`
+	ResourceID   uuid.UUID           `json:"resource_id"`
+	RequesterID  uuid.UUID           `json:"requester_id"`
+	Access       AccessLevel         `json:"access"`
+	Status       AccessRequestStatus `json:"status"`
+	UserID       uuid.UUID           `json:"user_id"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// Usage counts the notes and folders a user, or the people of a team
+// together, own
+type Usage struct {
+	Notes   int64 `json:"notes"`
+	Folders int64 `json:"folders"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SAMLConnection is an organization's SAML 2.0 identity provider. Its users
+// sign in through it and are created on their first login.
+type SAMLConnection struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex"`
+	// IDPMetadata is the identity provider's metadata XML
+	IDPMetadata string `json:"idp_metadata" gorm:"type:text;not null"`
+	// EmailAttribute names the assertion attribute holding the email; the
+	// subject's NameID is used when empty
+	EmailAttribute    string `json:"email_attribute" gorm:"type:varchar(255)"`
+	UsernameAttribute string `json:"username_attribute" gorm:"type:varchar(255)"`
+	RoleAttribute     string `json:"role_attribute" gorm:"type:varchar(255)"`
+	// RoleMapping maps values of RoleAttribute to roles. Users with none of
+	// them get DefaultRole.
+	RoleMapping map[string]UserRole `json:"role_mapping" gorm:"type:jsonb;serializer:json;not null"`
+	DefaultRole UserRole            `json:"default_role" gorm:"type:varchar(20);not null;default:'member'"`
+	Enabled     bool                `json:"enabled" gorm:"not null"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+func (c *SAMLConnection) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
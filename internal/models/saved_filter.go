@@ -0,0 +1,186 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FilterTarget selects which asset types a saved filter matches
+type FilterTarget string
+
+const (
+	FilterTargetAll     FilterTarget = "all"
+	FilterTargetNotes   FilterTarget = "notes"
+	FilterTargetFolders FilterTarget = "folders"
+)
+
+// Supported filter fields
+const (
+	FilterFieldTitle     = "title"
+	FilterFieldBody      = "body"
+	FilterFieldTag       = "tag"
+	FilterFieldOwnerID   = "owner_id"
+	FilterFieldFolderID  = "folder_id"
+	FilterFieldTeamID    = "team_id"
+	FilterFieldCreatedAt = "created_at"
+	FilterFieldUpdatedAt = "updated_at"
+)
+
+// Supported filter operators
+const (
+	FilterOpEq       = "eq"
+	FilterOpContains = "contains"
+	FilterOpAfter    = "after"
+	FilterOpBefore   = "before"
+	FilterOpWithin   = "within"
+)
+
+// filterFieldOps lists the operators allowed for each field
+var filterFieldOps = map[string][]string{
+	FilterFieldTitle:     {FilterOpEq, FilterOpContains},
+	FilterFieldBody:      {FilterOpContains},
+	FilterFieldTag:       {FilterOpEq},
+	FilterFieldOwnerID:   {FilterOpEq},
+	FilterFieldFolderID:  {FilterOpEq},
+	FilterFieldTeamID:    {FilterOpEq},
+	FilterFieldCreatedAt: {FilterOpAfter, FilterOpBefore, FilterOpWithin},
+	FilterFieldUpdatedAt: {FilterOpAfter, FilterOpBefore, FilterOpWithin},
+}
+
+// noteOnlyFilterFields cannot be applied to folders
+var noteOnlyFilterFields = map[string]bool{
+	FilterFieldBody:     true,
+	FilterFieldTag:      true,
+	FilterFieldFolderID: true,
+}
+
+// MaxFilterConditions bounds the size of a filter expression
+const MaxFilterConditions = 20
+
+// FilterCondition is a single field/operator/value predicate
+type FilterCondition struct {
+	Field string `json:"field"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+}
+
+// FilterExpression is a list of conditions that must all match
+type FilterExpression struct {
+	Target     FilterTarget      `json:"target"`
+	Conditions []FilterCondition `json:"conditions"`
+}
+
+// Validate checks that the expression only uses known fields, operators and
+// well-formed values
+func (e FilterExpression) Validate() error {
+	switch e.Target {
+	case FilterTargetAll, FilterTargetNotes, FilterTargetFolders:
+	default:
+		return fmt.Errorf("invalid target %q: must be one of all, notes, folders", e.Target)
+	}
+
+	if len(e.Conditions) > MaxFilterConditions {
+		return fmt.Errorf("too many conditions: maximum is %d", MaxFilterConditions)
+	}
+
+	for i, cond := range e.Conditions {
+		ops, ok := filterFieldOps[cond.Field]
+		if !ok {
+			return fmt.Errorf("condition %d: unknown field %q", i, cond.Field)
+		}
+		if !slices.Contains(ops, cond.Op) {
+			return fmt.Errorf("condition %d: operator %q is not supported for field %q", i, cond.Op, cond.Field)
+		}
+		if e.Target == FilterTargetFolders && noteOnlyFilterFields[cond.Field] {
+			return fmt.Errorf("condition %d: field %q only applies to notes", i, cond.Field)
+		}
+		if strings.TrimSpace(cond.Value) == "" {
+			return fmt.Errorf("condition %d: value is required", i)
+		}
+
+		switch cond.Field {
+		case FilterFieldOwnerID, FilterFieldFolderID, FilterFieldTeamID:
+			if _, err := uuid.Parse(cond.Value); err != nil {
+				return fmt.Errorf("condition %d: %q is not a valid ID", i, cond.Value)
+			}
+		case FilterFieldCreatedAt, FilterFieldUpdatedAt:
+			if cond.Op == FilterOpWithin {
+				if _, err := ParseFilterDuration(cond.Value); err != nil {
+					return fmt.Errorf("condition %d: %w", i, err)
+				}
+			} else if _, err := time.Parse(time.RFC3339, cond.Value); err != nil {
+				return fmt.Errorf("condition %d: %q is not an RFC3339 timestamp", i, cond.Value)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IncludesNotes reports whether the expression matches notes
+func (e FilterExpression) IncludesNotes() bool {
+	return e.Target == FilterTargetAll || e.Target == FilterTargetNotes
+}
+
+// IncludesFolders reports whether the expression matches folders. Filters
+// using note-only fields never match folders.
+func (e FilterExpression) IncludesFolders() bool {
+	if e.Target != FilterTargetAll && e.Target != FilterTargetFolders {
+		return false
+	}
+	for _, cond := range e.Conditions {
+		if noteOnlyFilterFields[cond.Field] {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseFilterDuration parses relative windows such as "24h", "7d" or "2w"
+func ParseFilterDuration(value string) (time.Duration, error) {
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	unit := value[len(value)-1]
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	switch unit {
+	case 'h':
+		return time.Duration(n) * time.Hour, nil
+	case 'd':
+		return time.Duration(n) * 24 * time.Hour, nil
+	case 'w':
+		return time.Duration(n) * 7 * 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("invalid duration %q: unit must be h, d or w", value)
+	}
+}
+
+// SavedFilter is a named, reusable asset query owned by a user
+type SavedFilter struct {
+	ID         uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name       string           `json:"name" gorm:"not null"`
+	OwnerID    uuid.UUID        `json:"owner_id" gorm:"type:uuid;not null;index"`
+	Expression FilterExpression `json:"expression" gorm:"type:jsonb;serializer:json;not null"`
+	Pinned     bool             `json:"pinned" gorm:"not null;default:false"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt   `json:"-" gorm:"index"`
+}
+
+func (f *SavedFilter) BeforeCreate(tx *gorm.DB) error {
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	return nil
+}
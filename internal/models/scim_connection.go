@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SCIMConnection lets an organization's identity provider provision its
+// users and teams through the SCIM API, signing in with a bearer token
+type SCIMConnection struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;uniqueIndex"`
+	// TokenHash is the hex SHA-256 of the token, which is only shown when
+	// it is created
+	TokenHash string    `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (c *SCIMConnection) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// SCIMUser marks a user as provisioned through SCIM and keeps the
+// identity provider's ID for them
+type SCIMUser struct {
+	UserID         uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey"`
+	OrganizationID uuid.UUID `json:"organization_id" gorm:"type:uuid;not null;index"`
+	ExternalID     string    `json:"external_id" gorm:"type:varchar(255);not null"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// StatsTotals counts what exists now, for the admin dashboard. Shares
+// counts folder and note shares together.
+type StatsTotals struct {
+	Users   int64 `json:"users"`
+	Teams   int64 `json:"teams"`
+	Folders int64 `json:"folders"`
+	Notes   int64 `json:"notes"`
+	Shares  int64 `json:"shares"`
+	Imports int64 `json:"imports"`
+}
+
+// DayStats counts what was created on one day (UTC), and the users who made
+// an authenticated request that day
+type DayStats struct {
+	Day         time.Time `json:"day"`
+	Users       int64     `json:"users"`
+	Teams       int64     `json:"teams"`
+	Notes       int64     `json:"notes"`
+	Shares      int64     `json:"shares"`
+	Imports     int64     `json:"imports"`
+	ActiveUsers int64     `json:"active_users"`
+}
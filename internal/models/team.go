@@ -10,13 +10,49 @@ import (
 type Team struct {
 	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Name      string    `json:"name" gorm:"not null"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid;index"`
+	// FolderID is the team's shared folder, kept shared with everyone in
+	// the team as they join and leave, or nil when the team has none
+	FolderID *uuid.UUID `json:"folder_id,omitempty" gorm:"type:uuid"`
+	Settings TeamSettings `json:"settings" gorm:"embedded"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	// CreatedBy and UpdatedBy are the users who created the team and last
+	// changed it, set from the actor of the request
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	UpdatedBy *uuid.UUID `json:"updated_by,omitempty" gorm:"type:uuid"`
 
 	// Relationships
-	Managers []User `json:"managers,omitempty" gorm:"many2many:team_managers;"`
-	Members  []User `json:"members,omitempty" gorm:"many2many:team_members;"`
+	Memberships []TeamMembership `json:"-" gorm:"foreignKey:TeamID"`
+
+	// Admins, Managers, Members and Viewers list the people of the team by
+	// role, as grouped from Memberships by GroupMemberships
+	Admins   []User `json:"admins,omitempty" gorm:"-"`
+	Managers []User `json:"managers,omitempty" gorm:"-"`
+	Members  []User `json:"members,omitempty" gorm:"-"`
+	Viewers  []User `json:"viewers,omitempty" gorm:"-"`
+}
+
+// TeamSettings is a team's sharing policy. It applies to the folders and
+// notes of everyone who manages or belongs to the team.
+type TeamSettings struct {
+	// AllowExternalSharing lets them share with users outside the team
+	AllowExternalSharing bool `json:"allow_external_sharing" gorm:"not null;default:true"`
+	// AllowPublicLinks lets them share through public links
+	AllowPublicLinks bool `json:"allow_public_links" gorm:"not null;default:true"`
+	// DefaultAccess is granted by shares that do not name an access level
+	DefaultAccess AccessLevel `json:"default_access" gorm:"type:varchar(10);not null;default:'read'"`
+}
+
+// DefaultTeamSettings returns the settings of a new team, which restrict
+// nothing
+func DefaultTeamSettings() TeamSettings {
+	return TeamSettings{
+		AllowExternalSharing: true,
+		AllowPublicLinks:     true,
+		DefaultAccess:        AccessRead,
+	}
 }
 
 func (t *Team) BeforeCreate(tx *gorm.DB) error {
@@ -26,16 +62,96 @@ func (t *Team) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
-// TeamManager represents the many-to-many relationship between teams and managers
-type TeamManager struct {
-	TeamID    uuid.UUID `gorm:"type:uuid;primaryKey"`
-	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
-	CreatedAt time.Time
+// GroupMemberships fills Admins, Managers, Members and Viewers from the
+// memberships loaded with their users. Memberships of deleted users, which
+// load without one, are left out.
+func (t *Team) GroupMemberships() {
+	t.Admins, t.Managers, t.Members, t.Viewers = nil, nil, nil, nil
+	for _, membership := range t.Memberships {
+		if membership.User.ID == uuid.Nil {
+			continue
+		}
+		switch membership.Role {
+		case TeamRoleAdmin:
+			t.Admins = append(t.Admins, membership.User)
+		case TeamRoleManager:
+			t.Managers = append(t.Managers, membership.User)
+		case TeamRoleMember:
+			t.Members = append(t.Members, membership.User)
+		case TeamRoleViewer:
+			t.Viewers = append(t.Viewers, membership.User)
+		}
+	}
+}
+
+// People returns everyone in the team, whatever their role
+func (t *Team) People() []User {
+	people := make([]User, 0, len(t.Admins)+len(t.Managers)+len(t.Members)+len(t.Viewers))
+	people = append(people, t.Admins...)
+	people = append(people, t.Managers...)
+	people = append(people, t.Members...)
+	return append(people, t.Viewers...)
+}
+
+// RoleOf returns the role userID holds in the team, or false when they are
+// not in it
+func (t *Team) RoleOf(userID uuid.UUID) (TeamRole, bool) {
+	for role, users := range map[TeamRole][]User{
+		TeamRoleAdmin:   t.Admins,
+		TeamRoleManager: t.Managers,
+		TeamRoleMember:  t.Members,
+		TeamRoleViewer:  t.Viewers,
+	} {
+		for _, user := range users {
+			if user.ID == userID {
+				return role, true
+			}
+		}
+	}
+	return "", false
 }
 
-// TeamMember represents the many-to-many relationship between teams and members
-type TeamMember struct {
-	TeamID    uuid.UUID `gorm:"type:uuid;primaryKey"`
-	UserID    uuid.UUID `gorm:"type:uuid;primaryKey"`
-	CreatedAt time.Time
+// TeamRole is what a user may do in a team
+type TeamRole string
+
+const (
+	// TeamRoleAdmin manages the team, including its admins
+	TeamRoleAdmin TeamRole = "admin"
+	// TeamRoleManager manages the team's managers, members and viewers
+	TeamRoleManager TeamRole = "manager"
+	TeamRoleMember  TeamRole = "member"
+	// TeamRoleViewer only gets read access to what the team shares
+	TeamRoleViewer TeamRole = "viewer"
+)
+
+// TeamRoles lists the team roles from the most to the least privileged
+var TeamRoles = []TeamRole{TeamRoleAdmin, TeamRoleManager, TeamRoleMember, TeamRoleViewer}
+
+// Manages reports whether the role may change the team's people and
+// settings
+func (r TeamRole) Manages() bool {
+	return r == TeamRoleAdmin || r == TeamRoleManager
+}
+
+// CanAssign reports whether someone with the role may give role to a user,
+// or take it away
+func (r TeamRole) CanAssign(role TeamRole) bool {
+	switch r {
+	case TeamRoleAdmin:
+		return true
+	case TeamRoleManager:
+		return role != TeamRoleAdmin
+	}
+	return false
+}
+
+// TeamMembership is a user's role in a team. A user holds one role per
+// team.
+type TeamMembership struct {
+	TeamID    uuid.UUID `json:"team_id" gorm:"type:uuid;primaryKey"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey;index"`
+	Role      TeamRole  `json:"role" gorm:"type:varchar(16);not null"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID"`
 }
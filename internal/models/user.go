@@ -20,6 +20,7 @@ type User struct {
 	Email        string    `json:"email" gorm:"uniqueIndex;not null"`
 	PasswordHash string    `json:"-" gorm:"not null"`
 	Role         UserRole  `json:"role" gorm:"type:varchar(20);not null;default:'member'"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" gorm:"type:uuid;index"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
@@ -27,8 +28,7 @@ type User struct {
 	// Relationships
 	OwnedFolders    []Folder    `json:"owned_folders,omitempty" gorm:"foreignKey:OwnerID"`
 	OwnedNotes      []Note      `json:"owned_notes,omitempty" gorm:"foreignKey:OwnerID"`
-	ManagedTeams    []Team      `json:"managed_teams,omitempty" gorm:"many2many:team_managers;"`
-	MemberTeams     []Team      `json:"member_teams,omitempty" gorm:"many2many:team_members;"`
+	TeamMemberships []TeamMembership `json:"team_memberships,omitempty" gorm:"foreignKey:UserID"`
 	SharedFolders   []Folder    `json:"shared_folders,omitempty" gorm:"many2many:folder_shares;"`
 	SharedNotes     []Note      `json:"shared_notes,omitempty" gorm:"many2many:note_shares;"`
 }
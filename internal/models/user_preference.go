@@ -0,0 +1,75 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationChannel is a way notifications reach a user
+type NotificationChannel string
+
+const (
+	// ChannelInApp lists the notification under GET /me/notifications
+	ChannelInApp NotificationChannel = "in_app"
+)
+
+// NotificationChannels are the channels users can choose between
+var NotificationChannels = []NotificationChannel{ChannelInApp}
+
+// NotificationTypes are the notification types users can configure
+var NotificationTypes = []NotificationType{NotificationMention, NotificationReminder, NotificationAccessRequest, NotificationNewDevice}
+
+// Defaults for users who have not saved any preferences
+const (
+	DefaultTimezone = "UTC"
+	DefaultLocale   = "en"
+)
+
+// UserPreference holds per-user settings used to present timestamps and
+// messages and to route notifications. Users without a stored row get
+// DefaultUserPreference.
+type UserPreference struct {
+	UserID   uuid.UUID `json:"user_id" gorm:"type:uuid;primary_key"`
+	Timezone string    `json:"timezone" gorm:"type:varchar(64);not null;default:'UTC'"`
+	Locale   string    `json:"locale" gorm:"type:varchar(16);not null;default:'en'"`
+	// NotificationChannels lists the channels each notification type is
+	// delivered on; an empty list mutes the type
+	NotificationChannels map[NotificationType][]NotificationChannel `json:"notification_channels" gorm:"type:jsonb;serializer:json;not null"`
+	UpdatedAt            time.Time                                  `json:"updated_at"`
+}
+
+// DefaultUserPreference returns the preferences of a user who has not
+// changed any: UTC, English and every notification in the app
+func DefaultUserPreference(userID uuid.UUID) *UserPreference {
+	channels := make(map[NotificationType][]NotificationChannel, len(NotificationTypes))
+	for _, notificationType := range NotificationTypes {
+		channels[notificationType] = []NotificationChannel{ChannelInApp}
+	}
+	return &UserPreference{
+		UserID:               userID,
+		Timezone:             DefaultTimezone,
+		Locale:               DefaultLocale,
+		NotificationChannels: channels,
+	}
+}
+
+// Location is the user's time zone, or UTC if it cannot be loaded
+func (p *UserPreference) Location() *time.Location {
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Wants reports whether notifications of type t should be sent on channel.
+// Types the user has not configured use the default channels.
+func (p *UserPreference) Wants(t NotificationType, channel NotificationChannel) bool {
+	channels, ok := p.NotificationChannels[t]
+	if !ok {
+		return channel == ChannelInApp
+	}
+	return slices.Contains(channels, channel)
+}
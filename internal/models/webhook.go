@@ -0,0 +1,75 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WebhookEventTypes are the domain events webhooks can subscribe to
+var WebhookEventTypes = []string{
+	EventUserCreated,
+	EventTeamCreated,
+	EventTeamMemberAdded,
+	EventTeamMemberRemoved,
+	EventFolderShared,
+	EventNoteCreated,
+	EventNoteShared,
+	EventNoteStatusChanged,
+}
+
+// IsWebhookEventType reports whether webhooks can subscribe to eventType
+func IsWebhookEventType(eventType string) bool {
+	return slices.Contains(WebhookEventTypes, eventType)
+}
+
+// Webhook is a URL registered by a manager to receive domain events. Each
+// delivery is signed with Secret, which is only shown when the webhook is
+// created.
+type Webhook struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	OwnerID    uuid.UUID `json:"owner_id" gorm:"type:uuid;not null;index"`
+	URL        string    `json:"url" gorm:"type:varchar(2048);not null"`
+	Secret     string    `json:"-" gorm:"type:varchar(128);not null"`
+	EventTypes []string  `json:"event_types" gorm:"type:jsonb;serializer:json;not null"`
+	Active     bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (w *Webhook) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
+
+// Subscribes reports whether the webhook should receive eventType
+func (w *Webhook) Subscribes(eventType string) bool {
+	return w.Active && slices.Contains(w.EventTypes, eventType)
+}
+
+// WebhookDelivery records one attempt to deliver an event to a webhook,
+// including its retries
+type WebhookDelivery struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WebhookID uuid.UUID `json:"webhook_id" gorm:"type:uuid;not null;index:idx_webhook_deliveries_webhook"`
+	EventID   uuid.UUID `json:"event_id" gorm:"type:uuid;not null"`
+	EventType string    `json:"event_type" gorm:"type:varchar(64);not null"`
+	Success   bool      `json:"success"`
+	// StatusCode is zero when the receiver never responded
+	StatusCode int       `json:"status_code"`
+	Attempts   int       `json:"attempts"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index:idx_webhook_deliveries_webhook"`
+}
+
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) error {
+	if d.ID == uuid.Nil {
+		d.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+// Package openapi builds OpenAPI 3 documents in code. Request and response
+// schemas are derived from the Go types the handlers bind and return, so the
+// document follows the models as they change.
+package openapi
+
+import (
+	"strconv"
+	"strings"
+)
+
+const Version = "3.0.3"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations
+type PathItem map[string]*Operation
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// SecurityRequirement names the schemes an operation accepts
+type SecurityRequirement map[string][]string
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Builder assembles a Document route by route
+type Builder struct {
+	doc     *Document
+	schemas *schemaRegistry
+
+	// PathParamSchema is used for path parameters an operation does not
+	// declare itself
+	PathParamSchema *Schema
+}
+
+func NewBuilder(title, version string) *Builder {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas:         make(map[string]*Schema),
+			SecuritySchemes: make(map[string]*SecurityScheme),
+		},
+	}
+	return &Builder{
+		doc:             doc,
+		schemas:         newSchemaRegistry(doc.Components.Schemas),
+		PathParamSchema: &Schema{Type: "string"},
+	}
+}
+
+// Info returns the document info for callers to fill in descriptions
+func (b *Builder) Info() *Info {
+	return &b.doc.Info
+}
+
+func (b *Builder) AddServer(url, description string) {
+	b.doc.Servers = append(b.doc.Servers, Server{URL: url, Description: description})
+}
+
+func (b *Builder) AddTag(name, description string) {
+	b.doc.Tags = append(b.doc.Tags, Tag{Name: name, Description: description})
+}
+
+func (b *Builder) AddSecurityScheme(name string, scheme *SecurityScheme) {
+	b.doc.Components.SecuritySchemes[name] = scheme
+}
+
+// Add documents one route. path uses gin syntax (/folders/:folderId); path
+// parameters missing from op are added as required parameters.
+func (b *Builder) Add(method, path string, op Operation) {
+	openAPIPath, params := convertPath(path)
+
+	declared := make(map[string]bool, len(op.Parameters))
+	for _, p := range op.Parameters {
+		if p.In == "path" {
+			declared[p.Name] = true
+		}
+	}
+	var pathParams []Parameter
+	for _, name := range params {
+		if !declared[name] {
+			pathParams = append(pathParams, Parameter{Name: name, In: "path", Required: true, Schema: b.PathParamSchema})
+		}
+	}
+	op.Parameters = append(pathParams, op.Parameters...)
+
+	if op.OperationID == "" {
+		op.OperationID = operationID(method, openAPIPath)
+	}
+	if op.Responses == nil {
+		op.Responses = make(map[string]*Response)
+	}
+
+	item, ok := b.doc.Paths[openAPIPath]
+	if !ok {
+		item = make(PathItem)
+		b.doc.Paths[openAPIPath] = item
+	}
+	item[strings.ToLower(method)] = &op
+}
+
+// Schema returns the schema for the Go value v, registering named struct
+// types as components and referring to them by $ref
+func (b *Builder) Schema(v interface{}) *Schema {
+	return b.schemas.of(v)
+}
+
+// JSONBody is a required JSON request body shaped like v
+func (b *Builder) JSONBody(v interface{}) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]*MediaType{"application/json": {Schema: b.Schema(v)}},
+	}
+}
+
+// JSON is a response with a JSON body shaped like v
+func (b *Builder) JSON(description string, v interface{}) *Response {
+	return &Response{
+		Description: description,
+		Content:     map[string]*MediaType{"application/json": {Schema: b.Schema(v)}},
+	}
+}
+
+// Empty is a response without a body
+func Empty(description string) *Response {
+	return &Response{Description: description}
+}
+
+// Document returns the assembled document
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+// Has reports whether a route in gin syntax has been documented
+func (d *Document) Has(method, path string) bool {
+	openAPIPath, _ := convertPath(path)
+	item, ok := d.Paths[openAPIPath]
+	if !ok {
+		return false
+	}
+	_, ok = item[strings.ToLower(method)]
+	return ok
+}
+
+// Status formats an HTTP status code as a responses key
+func Status(code int) string {
+	return strconv.Itoa(code)
+}
+
+// convertPath turns gin's :param and *param segments into {param}
+func convertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives a stable identifier such as getApiV1FoldersByFolderId
+func operationID(method, path string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		if strings.HasPrefix(segment, "{") {
+			sb.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			sb.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return sb.String()
+}
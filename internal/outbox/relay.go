@@ -0,0 +1,135 @@
+// Package outbox relays domain events written to the outbox table to the
+// message bus. Events are written in the same transaction as the change
+// they describe and published afterwards, so delivery is at least once:
+// an event may be published again if the relay stops between publishing it
+// and recording that it did. Consumers should deduplicate on the event ID.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/events"
+	"seta-training/pkg/logger"
+)
+
+const (
+	baseRetryDelay = time.Second
+	maxRetryDelay  = 10 * time.Minute
+)
+
+// Relay publishes pending outbox events. Failed publishes are retried with
+// exponential backoff.
+type Relay struct {
+	repo      repositories.OutboxRepositoryInterface
+	publisher events.Publisher
+	batchSize int
+	retention time.Duration
+	logger    logger.Logger
+}
+
+// NewRelay creates a relay publishing up to batchSize events per poll.
+// Published events are kept for retention before being deleted.
+func NewRelay(repo repositories.OutboxRepositoryInterface, publisher events.Publisher, batchSize int, retention time.Duration, log logger.Logger) *Relay {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		batchSize: batchSize,
+		retention: retention,
+		logger:    log,
+	}
+}
+
+// Run polls for pending events every interval until ctx is done
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	poll := time.NewTicker(interval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			r.drain(ctx)
+		}
+	}
+}
+
+// drain publishes batches until no due events are left
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		handled, err := r.PublishPending(ctx)
+		if err != nil {
+			r.logger.Error("Failed to relay outbox events", logger.Error(err))
+			return
+		}
+		if handled < r.batchSize {
+			return
+		}
+	}
+}
+
+// PublishPending publishes one batch of due events and returns how many
+// were attempted
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	return r.repo.ProcessPending(ctx, r.batchSize, func(event *models.OutboxEvent) {
+		if err := r.publisher.Publish(ctx, busEvent(event)); err != nil {
+			event.Attempts++
+			event.LastError = err.Error()
+			event.NextAttemptAt = time.Now().Add(retryDelay(event.Attempts))
+			r.logger.Warn("Failed to publish outbox event",
+				logger.String("event_id", event.ID.String()),
+				logger.String("event_type", event.EventType),
+				logger.Int("attempts", event.Attempts),
+				logger.Error(err),
+			)
+			return
+		}
+		now := time.Now()
+		event.PublishedAt = &now
+		event.LastError = ""
+	})
+}
+
+// PurgePublished deletes published events older than the retention period.
+// It runs as a scheduled job.
+func (r *Relay) PurgePublished(ctx context.Context) error {
+	deleted, err := r.repo.DeletePublishedBefore(ctx, time.Now().Add(-r.retention))
+	if err != nil {
+		return fmt.Errorf("failed to purge published outbox events: %w", err)
+	}
+	if deleted > 0 {
+		r.logger.Debug("Purged published outbox events", logger.Int("count", int(deleted)))
+	}
+	return nil
+}
+
+// busEvent is the message published for an outbox event. The outbox ID is
+// kept as the event ID so consumers can deduplicate redeliveries.
+func busEvent(event *models.OutboxEvent) events.Event {
+	return events.Event{
+		ID:          event.ID,
+		Type:        event.EventType,
+		AggregateID: event.AggregateID,
+		OccurredAt:  event.CreatedAt,
+		Data:        event.Payload,
+	}
+}
+
+// retryDelay doubles with each failed attempt up to maxRetryDelay
+func retryDelay(attempts int) time.Duration {
+	delay := baseRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
@@ -0,0 +1,96 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/audit"
+	"seta-training/pkg/events"
+	"seta-training/pkg/logger"
+)
+
+// EventActivityRecorded carries an Activity from the instance that served
+// the change to the hubs of every instance. It is published straight to the
+// bus, not through the outbox: a lost event only costs a live update.
+const EventActivityRecorded = "activity.recorded"
+
+// MessageActivity is the message type of the changes a user made
+const MessageActivity = "activity"
+
+// Activity is a change the user made, as recorded in the audit log
+type Activity struct {
+	ActorID    uuid.UUID         `json:"actor_id"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type"`
+	TargetID   uuid.UUID         `json:"target_id"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// ActivityPublisher is an audit.Recorder publishing each entry on the bus
+// as an EventActivityRecorded event. Entries are published from Run; when
+// the bus falls behind and the queue is full they are dropped, as the audit
+// log itself is written separately.
+type ActivityPublisher struct {
+	bus    events.Publisher
+	queue  chan events.Event
+	logger logger.Logger
+}
+
+func NewActivityPublisher(bus events.Publisher, bufferSize int, log logger.Logger) *ActivityPublisher {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &ActivityPublisher{
+		bus:    bus,
+		queue:  make(chan events.Event, bufferSize),
+		logger: log,
+	}
+}
+
+// Record queues entry for publishing
+func (p *ActivityPublisher) Record(ctx context.Context, entry audit.Entry) {
+	data, err := json.Marshal(Activity{
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		Details:    entry.Details,
+	})
+	if err != nil {
+		p.logger.Error("Failed to encode activity", logger.Error(err))
+		return
+	}
+
+	event := events.Event{
+		ID:          uuid.New(),
+		Type:        EventActivityRecorded,
+		AggregateID: entry.TargetID,
+		OccurredAt:  time.Now().UTC(),
+		Data:        data,
+	}
+	select {
+	case p.queue <- event:
+	default:
+		p.logger.Warn("Activity queue full, dropping live update",
+			logger.String("actor_id", entry.ActorID.String()))
+	}
+}
+
+// Run publishes queued activity until ctx is cancelled
+func (p *ActivityPublisher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-p.queue:
+			if err := p.bus.Publish(ctx, event); err != nil {
+				p.logger.Warn("Failed to publish activity", logger.Error(err))
+			}
+		}
+	}
+}
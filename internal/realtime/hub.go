@@ -0,0 +1,249 @@
+// Package realtime pushes notifications to users as they happen. A Hub
+// holds the listeners of this instance, grouped by user, and sends each
+// message to the listeners of the user it concerns that subscribed to its
+// type. Listeners are fed to WebSocket connections (Serve) or Server-Sent
+// Events streams.
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/pkg/logger"
+	"seta-training/pkg/metrics"
+)
+
+// sendBuffer is the number of messages queued for a listener; a listener
+// falling further behind is dropped
+const sendBuffer = 64
+
+var (
+	ErrTooManyListeners = errors.New("too many open notification streams")
+	ErrShuttingDown     = errors.New("server shutting down")
+	ErrTooSlow          = errors.New("too slow to receive notifications")
+)
+
+// Message is sent to listeners. Notifications carry the event payload in
+// Data.
+type Message struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+	Time time.Time       `json:"time"`
+}
+
+// Hub tracks the listeners of this instance
+type Hub struct {
+	mu           sync.RWMutex
+	listeners    map[uuid.UUID]map[*Listener]struct{}
+	closed       bool
+	pingInterval time.Duration
+	maxPerUser   int
+	logger       logger.Logger
+	metrics      *metrics.Metrics
+}
+
+// NewHub creates a hub whose streams are pinged every pingInterval; WebSocket
+// connections silent for two intervals are closed. Users may hold maxPerUser
+// listeners at once.
+func NewHub(pingInterval time.Duration, maxPerUser int, log logger.Logger, m *metrics.Metrics) *Hub {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
+	return &Hub{
+		listeners:    make(map[uuid.UUID]map[*Listener]struct{}),
+		pingInterval: pingInterval,
+		maxPerUser:   maxPerUser,
+		logger:       log,
+		metrics:      m,
+	}
+}
+
+// PingInterval is how often streams should send a heartbeat
+func (h *Hub) PingInterval() time.Duration {
+	return h.pingInterval
+}
+
+// Listen registers a listener for the messages of userID of the given
+// types, streamed over transport. It fails with ErrTooManyListeners when the
+// user is at the limit and with ErrShuttingDown after Close. Callers must
+// Unlisten when done.
+func (h *Hub) Listen(userID uuid.UUID, transport string, types []string) (*Listener, error) {
+	l := &Listener{
+		userID: userID,
+		send:   make(chan Message, sendBuffer),
+		done:   make(chan struct{}),
+		types:  make(map[string]bool, len(types)),
+	}
+	for _, typ := range types {
+		l.types[typ] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, ErrShuttingDown
+	}
+	if len(h.listeners[userID]) >= h.maxPerUser {
+		return nil, ErrTooManyListeners
+	}
+	if h.listeners[userID] == nil {
+		h.listeners[userID] = make(map[*Listener]struct{})
+	}
+	h.listeners[userID][l] = struct{}{}
+	l.untrack = h.metrics.TrackNotificationStream(transport)
+	return l, nil
+}
+
+// Unlisten removes l from the hub
+func (h *Hub) Unlisten(l *Listener) {
+	l.stop(nil)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.listeners[l.userID][l]; !ok {
+		return
+	}
+	delete(h.listeners[l.userID], l)
+	if len(h.listeners[l.userID]) == 0 {
+		delete(h.listeners, l.userID)
+	}
+	l.untrack()
+}
+
+// Send queues msg for the listeners of userID subscribed to its type. It
+// does not block; listeners too far behind are dropped.
+func (h *Hub) Send(userID uuid.UUID, msg Message) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for l := range h.listeners[userID] {
+		if l.stopped() || !l.Subscribed(msg.Type) {
+			continue
+		}
+		if !l.queue(msg) {
+			h.logger.Warn("Dropping notification listener that fell behind",
+				logger.String("user_id", userID.String()))
+		}
+	}
+}
+
+// Close stops every listener with ErrShuttingDown and refuses new ones, so
+// that clients reconnect to another instance
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, listeners := range h.listeners {
+		for l := range listeners {
+			l.stop(ErrShuttingDown)
+		}
+	}
+}
+
+// Listener receives the messages of one user it subscribed to
+type Listener struct {
+	userID  uuid.UUID
+	send    chan Message
+	untrack func()
+
+	// done is closed by stop; err says why
+	done     chan struct{}
+	stopOnce sync.Once
+	err      error
+
+	mu    sync.Mutex
+	types map[string]bool
+}
+
+// Messages delivers the listener's messages
+func (l *Listener) Messages() <-chan Message {
+	return l.send
+}
+
+// Done is closed when the listener is unlistened or dropped by the hub
+func (l *Listener) Done() <-chan struct{} {
+	return l.done
+}
+
+// Err is why the hub dropped the listener: ErrShuttingDown or ErrTooSlow.
+// It is nil while the listener runs and after Unlisten.
+func (l *Listener) Err() error {
+	if !l.stopped() {
+		return nil
+	}
+	return l.err
+}
+
+func (l *Listener) stopped() bool {
+	select {
+	case <-l.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *Listener) stop(err error) {
+	l.stopOnce.Do(func() {
+		l.err = err
+		close(l.done)
+	})
+}
+
+// queue adds msg to the send buffer, stopping the listener instead if the
+// buffer is full
+func (l *Listener) queue(msg Message) bool {
+	select {
+	case l.send <- msg:
+		return true
+	default:
+		l.stop(ErrTooSlow)
+		return false
+	}
+}
+
+// Subscribed reports whether the listener receives messages of type typ
+func (l *Listener) Subscribed(typ string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.types[typ]
+}
+
+// Subscribe adds types to the listener's subscriptions, or removes them
+// when on is false, and returns the resulting subscriptions in the order
+// of MessageTypes
+func (l *Listener) Subscribe(types []string, on bool) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, typ := range types {
+		if on {
+			l.types[typ] = true
+		} else {
+			delete(l.types, typ)
+		}
+	}
+
+	subscribed := make([]string, 0, len(l.types))
+	for _, typ := range MessageTypes {
+		if l.types[typ] {
+			subscribed = append(subscribed, typ)
+		}
+	}
+	return subscribed
+}
+
+// ValidTypes reports whether every entry of types is one of MessageTypes
+func ValidTypes(types []string) bool {
+	for _, typ := range types {
+		if !slices.Contains(MessageTypes, typ) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+	"seta-training/pkg/events"
+	"seta-training/pkg/logger"
+)
+
+// notificationEvents are the events pushed to the users their payload names
+// in user_id or user_ids: the user given or losing access, added to or
+// removed from a team, or reminded of a note, the collaborators of a note
+// whose status changed, and the owner asked for access and the requester
+// once the request is decided
+var notificationEvents = []string{
+	models.EventFolderShared,
+	models.EventFolderUnshared,
+	models.EventNoteShared,
+	models.EventNoteUnshared,
+	models.EventTeamMemberAdded,
+	models.EventTeamMemberRemoved,
+	services.ReminderEventDue,
+	models.EventNoteStatusChanged,
+	models.EventAccessRequested,
+	models.EventAccessRequestDecided,
+}
+
+// EventTypes are the events HandleEvent pushes to users
+var EventTypes = append(slices.Clone(notificationEvents), EventActivityRecorded)
+
+// NotificationTypes are the message types about changes made to the user:
+// the domain events above and the results of the user's imports
+var NotificationTypes = append(slices.Clone(notificationEvents), services.ImportEventCompleted, services.ImportEventFailed)
+
+// MessageTypes are all the message types listeners can subscribe to:
+// NotificationTypes and MessageActivity, the changes the user made
+var MessageTypes = append(slices.Clone(NotificationTypes), MessageActivity)
+
+// recipients is the part of the payload of notification events naming the
+// users
+type recipients struct {
+	UserID  uuid.UUID   `json:"user_id"`
+	UserIDs []uuid.UUID `json:"user_ids"`
+}
+
+// HandleEvent pushes event to the users it concerns, if listening here
+func (h *Hub) HandleEvent(ctx context.Context, event events.Event) error {
+	if event.Type == EventActivityRecorded {
+		var activity Activity
+		if err := json.Unmarshal(event.Data, &activity); err != nil {
+			return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+		}
+		h.Send(activity.ActorID, Message{Type: MessageActivity, Data: event.Data, Time: event.OccurredAt})
+		return nil
+	}
+
+	var to recipients
+	if err := json.Unmarshal(event.Data, &to); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", event.Type, err)
+	}
+	message := Message{Type: event.Type, Data: event.Data, Time: event.OccurredAt}
+	if to.UserID != uuid.Nil {
+		h.Send(to.UserID, message)
+	}
+	for _, userID := range to.UserIDs {
+		h.Send(userID, message)
+	}
+	return nil
+}
+
+// ImportFinished pushes the result of an import to the manager who ran it
+func (h *Hub) ImportFinished(ctx context.Context, event services.ImportEvent) {
+	managerID, err := uuid.Parse(event.ManagerID)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		h.logger.Error("Failed to encode import notification", logger.Error(err))
+		return
+	}
+	h.Send(managerID, Message{Type: event.Event, Data: data, Time: event.FinishedAt})
+}
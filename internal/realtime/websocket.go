@@ -0,0 +1,160 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// writeWait bounds each write to a connection
+	writeWait = 10 * time.Second
+	// maxClientMessage bounds the messages clients send
+	maxClientMessage = 4096
+)
+
+// Message types WebSocket clients send. Subscribe and unsubscribe change the
+// message types a connection receives; ping is answered with pong.
+const (
+	MessageSubscribe   = "subscribe"
+	MessageUnsubscribe = "unsubscribe"
+	MessagePing        = "ping"
+)
+
+// Message types sent to WebSocket clients besides notifications
+const (
+	MessagePong          = "pong"
+	MessageSubscriptions = "subscriptions"
+	MessageError         = "error"
+)
+
+// clientMessage is read from WebSocket clients
+type clientMessage struct {
+	Type   string   `json:"type"`
+	Events []string `json:"events"`
+}
+
+// Serve runs ws for userID until either side closes it. It receives
+// NotificationTypes until the client changes its subscriptions.
+// Connections over the user's limit, or opened after Close, are closed
+// straight away.
+func (h *Hub) Serve(ws *websocket.Conn, userID uuid.UUID) {
+	l, err := h.Listen(userID, "websocket", NotificationTypes)
+	if err != nil {
+		_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode(err), err.Error()), time.Now().Add(writeWait))
+		ws.Close()
+		return
+	}
+
+	c := &conn{ws: ws, listener: l}
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		c.write(h.pingInterval)
+	}()
+	c.read(2 * h.pingInterval)
+	h.Unlisten(l)
+	<-written
+}
+
+// closeCode is the close code telling clients why the hub dropped them
+func closeCode(err error) int {
+	switch {
+	case errors.Is(err, ErrShuttingDown):
+		return websocket.CloseGoingAway
+	case errors.Is(err, ErrTooManyListeners):
+		return websocket.ClosePolicyViolation
+	case errors.Is(err, ErrTooSlow):
+		return websocket.CloseTryAgainLater
+	default:
+		return websocket.CloseNormalClosure
+	}
+}
+
+// conn is one client connection. read runs on the goroutine of Serve and
+// write on its own; only write writes data frames, while replies to client
+// messages go through the listener's queue.
+type conn struct {
+	ws       *websocket.Conn
+	listener *Listener
+}
+
+// write sends queued messages and pings until the listener stops or a
+// write fails, then closes the connection, which ends read
+func (c *conn) write(pingInterval time.Duration) {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+	defer c.ws.Close()
+
+	for {
+		select {
+		case msg := <-c.listener.Messages():
+			_ = c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.ws.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		case <-c.listener.Done():
+			err := c.listener.Err()
+			reason := ""
+			if err != nil {
+				reason = err.Error()
+			}
+			_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode(err), reason), time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+// read handles client messages until the connection fails or nothing,
+// not even a pong, arrives within timeout
+func (c *conn) read(timeout time.Duration) {
+	c.ws.SetReadLimit(maxClientMessage)
+	_ = c.ws.SetReadDeadline(time.Now().Add(timeout))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(timeout))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.ws.SetReadDeadline(time.Now().Add(timeout))
+
+		var msg clientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			c.listener.queue(errorMessage("Messages must be JSON objects"))
+			continue
+		}
+		c.handle(msg)
+	}
+}
+
+func (c *conn) handle(msg clientMessage) {
+	switch msg.Type {
+	case MessagePing:
+		c.listener.queue(Message{Type: MessagePong, Time: time.Now().UTC()})
+	case MessageSubscribe, MessageUnsubscribe:
+		if !ValidTypes(msg.Events) {
+			c.listener.queue(errorMessage("Unknown notification type"))
+			return
+		}
+		subscribed := c.listener.Subscribe(msg.Events, msg.Type == MessageSubscribe)
+		data, _ := json.Marshal(map[string][]string{"events": subscribed})
+		c.listener.queue(Message{Type: MessageSubscriptions, Data: data, Time: time.Now().UTC()})
+	default:
+		c.listener.queue(errorMessage("Unknown message type " + msg.Type))
+	}
+}
+
+func errorMessage(text string) Message {
+	data, _ := json.Marshal(map[string]string{"message": text})
+	return Message{Type: MessageError, Data: data, Time: time.Now().UTC()}
+}
@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
+)
+
+type AccessRequestRepository struct {
+	Repository[models.AccessRequest]
+}
+
+func NewAccessRequestRepository(db *gorm.DB) *AccessRequestRepository {
+	return &AccessRequestRepository{Repository: NewRepository[models.AccessRequest](db, apperrors.NotFound("access request not found"))}
+}
+
+// Create stores the request and the access_request.created outbox event,
+// which notifies the owner, in one transaction
+func (r *AccessRequestRepository) Create(ctx context.Context, request *models.AccessRequest) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(request).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventAccessRequested, request.ID, accessRequestEvent(request, request.OwnerID))
+	})
+}
+
+// GetByID returns the request with its requester
+func (r *AccessRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AccessRequest, error) {
+	var request models.AccessRequest
+	err := r.db.WithContext(ctx).Preload("Requester").Where("id = ?", id).First(&request).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, r.notFound
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// FindPending returns the requester's pending request for a resource, or nil
+// when there is none
+func (r *AccessRequestRepository) FindPending(ctx context.Context, resourceType string, resourceID, requesterID uuid.UUID) (*models.AccessRequest, error) {
+	var requests []models.AccessRequest
+	err := r.db.WithContext(ctx).
+		Where("resource_type = ? AND resource_id = ? AND requester_id = ? AND status = ?",
+			resourceType, resourceID, requesterID, models.AccessRequestPending).
+		Limit(1).Find(&requests).Error
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+	return &requests[0], nil
+}
+
+// ListPendingForOwner returns one page of the pending requests for resources
+// ownerID owns, oldest first
+func (r *AccessRequestRepository) ListPendingForOwner(ctx context.Context, ownerID uuid.UUID, p pagination.Params) (pagination.Page[models.AccessRequest], error) {
+	return r.ListAfter(ctx, p, func(a models.AccessRequest) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: a.CreatedAt, ID: a.ID}
+	}, func(db *gorm.DB) *gorm.DB {
+		return db.Where("owner_id = ? AND status = ?", ownerID, models.AccessRequestPending).Preload("Requester")
+	})
+}
+
+// Decide approves or denies a pending request and writes the
+// access_request.decided outbox event, which notifies the requester. It
+// fails with a conflict when the request was decided already, so two owners'
+// sessions deciding at once cannot both win.
+func (r *AccessRequestRepository) Decide(ctx context.Context, request *models.AccessRequest, status models.AccessRequestStatus) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.AccessRequest{}).
+			Where("id = ? AND status = ?", request.ID, models.AccessRequestPending).
+			Updates(map[string]interface{}{"status": status, "decided_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.Conflict("the access request was already decided")
+		}
+		request.Status = status
+		request.DecidedAt = &now
+		return enqueueEvent(tx, models.EventAccessRequestDecided, request.ID, accessRequestEvent(request, request.RequesterID))
+	})
+}
+
+func accessRequestEvent(request *models.AccessRequest, notify uuid.UUID) models.AccessRequestEvent {
+	return models.AccessRequestEvent{
+		RequestID:    request.ID,
+		ResourceType: request.ResourceType,
+		ResourceID:   request.ResourceID,
+		RequesterID:  request.RequesterID,
+		Access:       request.Access,
+		Status:       request.Status,
+		UserID:       notify,
+	}
+}
@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+)
+
+type AnnouncementRepository struct {
+	Repository[models.Announcement]
+}
+
+func NewAnnouncementRepository(db *gorm.DB) *AnnouncementRepository {
+	return &AnnouncementRepository{Repository: NewRepository[models.Announcement](db, apperrors.NotFound("announcement not found"))}
+}
+
+// GetAll returns every announcement, latest start first
+func (r *AnnouncementRepository) GetAll(ctx context.Context) ([]models.Announcement, error) {
+	var announcements []models.Announcement
+	err := r.db.WithContext(ctx).Order("starts_at DESC").Find(&announcements).Error
+	return announcements, err
+}
+
+// GetActive returns the announcements shown at now, latest start first
+func (r *AnnouncementRepository) GetActive(ctx context.Context, now time.Time) ([]models.Announcement, error) {
+	var announcements []models.Announcement
+	err := database.ReadReplica(r.db.WithContext(ctx)).
+		Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", now, now).
+		Order("starts_at DESC").
+		Find(&announcements).Error
+	return announcements, err
+}
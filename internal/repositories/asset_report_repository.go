@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+)
+
+type AssetReportRepository struct {
+	Repository[models.AssetReport]
+}
+
+func NewAssetReportRepository(db *gorm.DB) *AssetReportRepository {
+	return &AssetReportRepository{Repository: NewRepository[models.AssetReport](db, apperrors.NotFound("asset report not found"))}
+}
+
+// GetByID loads a report without its artifact
+func (r *AssetReportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AssetReport, error) {
+	var report models.AssetReport
+	err := r.db.WithContext(ctx).Omit("artifact").Where("id = ?", id).First(&report).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("asset report not found")
+		}
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (r *AssetReportRepository) GetArtifact(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	var report models.AssetReport
+	err := r.db.WithContext(ctx).Select("artifact").Where("id = ?", id).First(&report).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("asset report not found")
+		}
+		return nil, err
+	}
+	return report.Artifact, nil
+}
+
+// unfinishedStatuses are those of export jobs and reports still to be built
+var unfinishedStatuses = []models.ExportStatus{models.ExportStatusPending, models.ExportStatusRunning}
+
+// FindUnfinished returns the latest report of orgID, or of the default
+// tenant when nil, that is pending or running, or nil when there is none
+func (r *AssetReportRepository) FindUnfinished(ctx context.Context, orgID *uuid.UUID) (*models.AssetReport, error) {
+	var reports []models.AssetReport
+	err := r.db.WithContext(ctx).Omit("artifact").Scopes(inOrganization(orgID)).
+		Where("status IN ?", unfinishedStatuses).
+		Order("created_at DESC").Limit(1).
+		Find(&reports).Error
+	if err != nil || len(reports) == 0 {
+		return nil, err
+	}
+	return &reports[0], nil
+}
+
+// UnfinishedIDs returns the IDs of the reports that are pending or running,
+// oldest first
+func (r *AssetReportRepository) UnfinishedIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.AssetReport{}).
+		Where("status IN ?", unfinishedStatuses).
+		Order("created_at").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ownerCount is one row of a per-owner count
+type ownerCount struct {
+	OwnerID uuid.UUID
+	Count   int64
+}
+
+// Inventory returns the teams of orgID, or of the default tenant when nil,
+// with their memberships but not their users, and what every user of the
+// organization owns, deleted users included. It reads a replica: reports
+// tolerate lag.
+func (r *AssetReportRepository) Inventory(ctx context.Context, orgID *uuid.UUID) ([]models.Team, []models.OwnerAssets, error) {
+	db := database.ReadReplica(r.db.WithContext(ctx))
+
+	var teams []models.Team
+	err := db.Scopes(inOrganization(orgID)).Preload("Memberships").Order("name, id").Find(&teams).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var users []models.User
+	if err := db.Unscoped().Scopes(inOrganization(orgID)).Select("id", "deleted_at").Find(&users).Error; err != nil {
+		return nil, nil, err
+	}
+	owners := make([]models.OwnerAssets, len(users))
+	index := make(map[uuid.UUID]*models.OwnerAssets, len(users))
+	for i, user := range users {
+		owners[i] = models.OwnerAssets{UserID: user.ID, Deleted: user.DeletedAt.Valid}
+		index[user.ID] = &owners[i]
+	}
+	ownedBy := db.Unscoped().Model(&models.User{}).Scopes(inOrganization(orgID)).Select("id")
+
+	counts := []struct {
+		query *gorm.DB
+		field func(*models.OwnerAssets) *int64
+	}{
+		{db.Model(&models.Folder{}), func(o *models.OwnerAssets) *int64 { return &o.Folders }},
+		{db.Model(&models.Note{}), func(o *models.OwnerAssets) *int64 { return &o.Notes }},
+		{db.Model(&models.Folder{}).Where("id IN (?)", db.Model(&models.FolderShare{}).Select("folder_id")),
+			func(o *models.OwnerAssets) *int64 { return &o.SharedFolders }},
+		{db.Model(&models.Note{}).Where("id IN (?)", db.Model(&models.NoteShare{}).Select("note_id")),
+			func(o *models.OwnerAssets) *int64 { return &o.SharedNotes }},
+	}
+	for _, c := range counts {
+		var rows []ownerCount
+		err := c.query.Select("owner_id, COUNT(*) AS count").Where("owner_id IN (?)", ownedBy).Group("owner_id").Scan(&rows).Error
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, row := range rows {
+			if owner, ok := index[row.OwnerID]; ok {
+				*c.field(owner) += row.Count
+			}
+		}
+	}
+
+	var recipients []struct {
+		OwnerID uuid.UUID
+		UserID  uuid.UUID
+	}
+	err = db.Raw("SELECT folders.owner_id, folder_shares.user_id FROM folder_shares "+
+		"JOIN folders ON folders.id = folder_shares.folder_id AND folders.deleted_at IS NULL WHERE folders.owner_id IN (?) "+
+		"UNION SELECT notes.owner_id, note_shares.user_id FROM note_shares "+
+		"JOIN notes ON notes.id = note_shares.note_id AND notes.deleted_at IS NULL WHERE notes.owner_id IN (?)",
+		ownedBy, ownedBy).Scan(&recipients).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, row := range recipients {
+		if owner, ok := index[row.OwnerID]; ok {
+			owner.Recipients = append(owner.Recipients, row.UserID)
+		}
+	}
+	return teams, owners, nil
+}
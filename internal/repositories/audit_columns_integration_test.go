@@ -0,0 +1,63 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/testutils"
+)
+
+func TestAuditColumns(t *testing.T) {
+	db := testutils.PostgresTx(t)
+	owner := testutils.UserFactory().Create(t, db)
+	editor := testutils.UserFactory().Create(t, db)
+	notes := repositories.NewNoteRepository(db, nil)
+	folders := repositories.NewFolderRepository(db, nil)
+	tx := repositories.NewTxManager(db, notes)
+
+	folder := &models.Folder{Name: "Audited", OwnerID: owner.ID}
+	note := &models.Note{Title: "Audited", Body: "body", OwnerID: owner.ID}
+	ownerCtx := database.WithActor(context.Background(), owner.ID)
+	err := tx.WithTx(ownerCtx, func(stores repositories.Stores) error {
+		if err := stores.Folders.Create(ownerCtx, folder); err != nil {
+			return err
+		}
+		note.FolderID = folder.ID
+		return stores.Notes.Create(ownerCtx, note)
+	})
+	require.NoError(t, err)
+
+	gotFolder, err := folders.GetByID(context.Background(), folder.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &owner.ID, gotFolder.CreatedBy)
+	assert.Equal(t, &owner.ID, gotFolder.UpdatedBy)
+
+	editorCtx := database.WithActor(context.Background(), editor.ID)
+	note.Title = "Edited"
+	err = tx.WithTx(editorCtx, func(stores repositories.Stores) error {
+		return stores.Notes.Update(editorCtx, note)
+	})
+	require.NoError(t, err)
+
+	gotNote, err := notes.GetByID(context.Background(), note.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &owner.ID, gotNote.CreatedBy)
+	assert.Equal(t, &editor.ID, gotNote.UpdatedBy)
+
+	t.Run("writes without an actor leave the columns alone", func(t *testing.T) {
+		plain := &models.Folder{Name: "Plain", OwnerID: owner.ID}
+		require.NoError(t, folders.Create(context.Background(), plain))
+
+		got, err := folders.GetByID(context.Background(), plain.ID)
+		require.NoError(t, err)
+		assert.Nil(t, got.CreatedBy)
+		assert.Nil(t, got.UpdatedBy)
+	})
+}
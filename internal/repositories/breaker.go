@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"time"
+
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
+	"seta-training/pkg/breaker"
+	"seta-training/pkg/logger"
+	"seta-training/pkg/metrics"
+)
+
+// Names of the database circuit breakers, also used as metric labels
+const (
+	readBreaker  = "database_read"
+	writeBreaker = "database_write"
+)
+
+const breakerDoneKey = "breaker:done"
+
+// ErrDatabaseUnavailable is returned, without touching the database, for
+// statements rejected by an open circuit breaker
+var ErrDatabaseUnavailable = apperrors.Unavailable("database temporarily unavailable")
+
+// CircuitBreakers is a GORM plugin guarding every statement repositories run
+// with one circuit breaker for reads and one for writes. While the database
+// keeps failing with connection errors, timeouts or resource exhaustion, a
+// breaker opens and statements fail fast with ErrDatabaseUnavailable instead
+// of piling up behind the connection pool. Errors caused by the statement
+// itself, such as missing records or constraint violations, do not count.
+type CircuitBreakers struct {
+	read    *breaker.Breaker
+	write   *breaker.Breaker
+	metrics *metrics.Metrics
+	logger  logger.Logger
+}
+
+// NewCircuitBreakers creates the breakers, which open after failureThreshold
+// consecutive failures, stay open for openTimeout and close again once
+// probes statements have succeeded. m and log may be nil.
+func NewCircuitBreakers(failureThreshold int, openTimeout time.Duration, probes int, m *metrics.Metrics, log logger.Logger) *CircuitBreakers {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	p := &CircuitBreakers{metrics: m, logger: log}
+	p.read = breaker.New(failureThreshold, openTimeout, probes, p.stateChanged(readBreaker))
+	p.write = breaker.New(failureThreshold, openTimeout, probes, p.stateChanged(writeBreaker))
+	if m != nil {
+		m.SetBreakerState(readBreaker, breaker.Closed, false)
+		m.SetBreakerState(writeBreaker, breaker.Closed, false)
+	}
+	return p
+}
+
+func (p *CircuitBreakers) Name() string {
+	return "circuit_breakers"
+}
+
+// Initialize registers the breakers around the statements. Writes are
+// checked before GORM opens its implicit transaction, so a rejected write
+// never asks the pool for a connection, and judged after the commit.
+func (p *CircuitBreakers) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Query().Before("gorm:query").Register("breaker:before_query", p.before(p.read, readBreaker)),
+		cb.Query().After("gorm:query").Register("breaker:after_query", p.after),
+		cb.Row().Before("gorm:row").Register("breaker:before_row", p.before(p.read, readBreaker)),
+		cb.Row().After("gorm:row").Register("breaker:after_row", p.after),
+		cb.Create().Before("gorm:begin_transaction").Register("breaker:before_create", p.before(p.write, writeBreaker)),
+		cb.Create().After("gorm:commit_or_rollback_transaction").Register("breaker:after_create", p.after),
+		cb.Update().Before("gorm:begin_transaction").Register("breaker:before_update", p.before(p.write, writeBreaker)),
+		cb.Update().After("gorm:commit_or_rollback_transaction").Register("breaker:after_update", p.after),
+		cb.Delete().Before("gorm:begin_transaction").Register("breaker:before_delete", p.before(p.write, writeBreaker)),
+		cb.Delete().After("gorm:commit_or_rollback_transaction").Register("breaker:after_delete", p.after),
+		cb.Raw().Before("gorm:raw").Register("breaker:before_raw", p.before(p.write, writeBreaker)),
+		cb.Raw().After("gorm:raw").Register("breaker:after_raw", p.after),
+	)
+}
+
+func (p *CircuitBreakers) before(b *breaker.Breaker, name string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		// Statements that already failed, dry runs and statements whose
+		// caller has given up say nothing about the database
+		if db.Error != nil || db.DryRun || db.Statement.Context.Err() != nil {
+			return
+		}
+		done, err := b.Allow()
+		if err != nil {
+			if p.metrics != nil {
+				p.metrics.RecordBreakerRejection(name)
+			}
+			db.AddError(ErrDatabaseUnavailable)
+			return
+		}
+		db.InstanceSet(breakerDoneKey, done)
+	}
+}
+
+func (p *CircuitBreakers) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(breakerDoneKey)
+	if !ok {
+		return
+	}
+	if done, _ := value.(func(breaker.Outcome)); done != nil {
+		db.InstanceSet(breakerDoneKey, nil)
+		done(breakerOutcome(db.Error))
+	}
+}
+
+func (p *CircuitBreakers) stateChanged(name string) func(from, to breaker.State) {
+	return func(from, to breaker.State) {
+		if p.metrics != nil {
+			p.metrics.SetBreakerState(name, to, true)
+		}
+		fields := []logger.Field{
+			logger.String("breaker", name),
+			logger.String("from", from.String()),
+			logger.String("to", to.String()),
+		}
+		if to == breaker.Open {
+			p.logger.Warn("Database circuit breaker opened", fields...)
+		} else {
+			p.logger.Info("Database circuit breaker changed state", fields...)
+		}
+	}
+}
+
+// breakerOutcome tells whether err means the database is in trouble: it
+// could not be reached, did not answer in time or ran out of resources
+func breakerOutcome(err error) breaker.Outcome {
+	if err == nil {
+		return breaker.Succeeded
+	}
+	if errors.Is(err, context.Canceled) {
+		return breaker.Ignored
+	}
+
+	// Postgres errors carry an SQLSTATE whose class says whose fault it is:
+	// connection exceptions (08), insufficient resources (53), operator
+	// intervention such as statement timeouts (57) and system errors (58)
+	if state := database.SQLState(err); state != "" {
+		if len(state) >= 2 {
+			switch state[:2] {
+			case "08", "53", "57", "58":
+				return breaker.Failed
+			}
+		}
+		return breaker.Succeeded
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr) {
+		return breaker.Failed
+	}
+	return breaker.Succeeded
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// ChecklistRepository stores the checklist items of notes. Inserting, moving
+// and deleting an item shift the positions of the others so each note's
+// items stay numbered 0 to n-1.
+type ChecklistRepository struct {
+	Repository[models.ChecklistItem]
+}
+
+func NewChecklistRepository(db *gorm.DB) *ChecklistRepository {
+	return &ChecklistRepository{Repository: NewRepository[models.ChecklistItem](db, apperrors.NotFound("checklist item not found"))}
+}
+
+// GetByNote returns the items of a note in checklist order
+func (r *ChecklistRepository) GetByNote(ctx context.Context, noteID uuid.UUID) ([]models.ChecklistItem, error) {
+	var items []models.ChecklistItem
+	err := r.db.WithContext(ctx).Where("note_id = ?", noteID).
+		Order("position").Order("created_at").Find(&items).Error
+	return items, err
+}
+
+// CountByNote counts the items of a note
+func (r *ChecklistRepository) CountByNote(ctx context.Context, noteID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ChecklistItem{}).Where("note_id = ?", noteID).Count(&count).Error
+	return count, err
+}
+
+// Insert creates item at position, moving the items from there on down one.
+// Positions past the end append the item.
+func (r *ChecklistRepository) Insert(ctx context.Context, item *models.ChecklistItem, position int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		count, err := countItems(tx, item.NoteID)
+		if err != nil {
+			return err
+		}
+		item.Position = min(max(position, 0), int(count))
+		err = tx.Model(&models.ChecklistItem{}).
+			Where("note_id = ? AND position >= ?", item.NoteID, item.Position).
+			Update("position", gorm.Expr("position + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(item).Error
+	})
+}
+
+// Move saves item at position, shifting the items between its old and new
+// place. Positions past the end move it last.
+func (r *ChecklistRepository) Move(ctx context.Context, item *models.ChecklistItem, position int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		count, err := countItems(tx, item.NoteID)
+		if err != nil {
+			return err
+		}
+		from, to := item.Position, min(max(position, 0), int(count)-1)
+		shift := tx.Model(&models.ChecklistItem{}).Where("note_id = ? AND id <> ?", item.NoteID, item.ID)
+		switch {
+		case to < from:
+			err = shift.Where("position >= ? AND position < ?", to, from).
+				Update("position", gorm.Expr("position + 1")).Error
+		case to > from:
+			err = shift.Where("position > ? AND position <= ?", from, to).
+				Update("position", gorm.Expr("position - 1")).Error
+		}
+		if err != nil {
+			return err
+		}
+		item.Position = to
+		return tx.Save(item).Error
+	})
+}
+
+// Delete removes an item and closes the gap it leaves
+func (r *ChecklistRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var item models.ChecklistItem
+		if err := tx.Where("id = ?", id).First(&item).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ChecklistItem{}).
+			Where("note_id = ? AND position > ?", item.NoteID, item.Position).
+			Update("position", gorm.Expr("position - 1")).Error
+	})
+}
+
+func countItems(db *gorm.DB, noteID uuid.UUID) (int64, error) {
+	var count int64
+	err := db.Model(&models.ChecklistItem{}).Where("note_id = ?", noteID).Count(&count).Error
+	return count, err
+}
+
+// attachChecklists fills in the checklist counts of notes with one query.
+// Notes without a checklist are left without counts.
+func attachChecklists(db *gorm.DB, notes []models.Note) error {
+	if len(notes) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(notes))
+	for i := range notes {
+		ids[i] = notes[i].ID
+	}
+
+	var rows []struct {
+		NoteID uuid.UUID
+		Total  int
+		Done   int
+	}
+	err := db.Model(&models.ChecklistItem{}).
+		Select("note_id, COUNT(*) AS total, SUM(CASE WHEN done THEN 1 ELSE 0 END) AS done").
+		Where("note_id IN ?", ids).Group("note_id").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	summaries := make(map[uuid.UUID]*models.ChecklistSummary, len(rows))
+	for _, row := range rows {
+		summaries[row.NoteID] = &models.ChecklistSummary{Total: row.Total, Done: row.Done}
+	}
+	for i := range notes {
+		notes[i].Checklist = summaries[notes[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,391 @@
+// Package contract holds the behaviour every implementation of the
+// repository interfaces must share. The GORM repositories and their
+// in-memory stand-ins run the same suite, so neither can drift from what
+// services expect of the interfaces.
+package contract
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/testutils"
+	"seta-training/pkg/pagination"
+)
+
+// Repositories is a set of implementations sharing one backing store
+type Repositories struct {
+	Users         repositories.UserRepositoryInterface
+	Organizations repositories.OrganizationRepositoryInterface
+	Teams         repositories.TeamRepositoryInterface
+}
+
+// Run runs the suite. newRepositories is called for every subtest and must
+// return repositories over an empty store.
+func Run(t *testing.T, newRepositories func(t *testing.T) Repositories) {
+	t.Run("users", func(t *testing.T) { testUsers(t, newRepositories) })
+	t.Run("organizations", func(t *testing.T) { testOrganizations(t, newRepositories) })
+	t.Run("teams", func(t *testing.T) { testTeams(t, newRepositories) })
+}
+
+func testUsers(t *testing.T, newRepositories func(t *testing.T) Repositories) {
+	ctx := context.Background()
+
+	t.Run("gets a created user by ID and email", func(t *testing.T) {
+		repos := newRepositories(t)
+		user := testutils.UserFactory().Manager().Build()
+		require.NoError(t, repos.Users.Create(ctx, user))
+
+		got, err := repos.Users.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, user.Username, got.Username)
+		assert.Equal(t, models.RoleManager, got.Role)
+		assert.False(t, got.CreatedAt.IsZero())
+
+		got, err = repos.Users.GetByEmail(ctx, user.Email)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, got.ID)
+	})
+
+	t.Run("reports missing users as not found", func(t *testing.T) {
+		repos := newRepositories(t)
+		_, err := repos.Users.GetByID(ctx, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+		_, err = repos.Users.GetByEmail(ctx, "nobody@example.com")
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("rejects taken usernames and emails", func(t *testing.T) {
+		repos := newRepositories(t)
+		user := testutils.UserFactory().Named("alice").Build()
+		require.NoError(t, repos.Users.Create(ctx, user))
+
+		sameUsername := testutils.UserFactory().Build()
+		sameUsername.Username = "alice"
+		assert.Error(t, repos.Users.Create(ctx, sameUsername))
+		assert.Error(t, repos.Users.Create(ctx, testutils.UserFactory().Named("alice").Build()))
+	})
+
+	t.Run("tells whether usernames and emails exist", func(t *testing.T) {
+		repos := newRepositories(t)
+		user := testutils.UserFactory().Named("bob").Build()
+		require.NoError(t, repos.Users.Create(ctx, user))
+
+		exists, err := repos.Users.UsernameExists(ctx, "bob")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = repos.Users.EmailExists(ctx, "bob@example.com")
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = repos.Users.UsernameExists(ctx, "carol")
+		require.NoError(t, err)
+		assert.False(t, exists)
+		exists, err = repos.Users.EmailExists(ctx, "carol@example.com")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("lists the users of one organization", func(t *testing.T) {
+		repos := newRepositories(t)
+		org := createOrganization(t, repos)
+		inDefault := createUser(t, repos, testutils.UserFactory())
+		inOrg := createUser(t, repos, testutils.UserFactory().InOrganization(org.ID))
+
+		users, err := repos.Users.GetAll(ctx, nil)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uuid.UUID{inDefault.ID}, userIDs(users))
+
+		users, err = repos.Users.GetAll(ctx, &org.ID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uuid.UUID{inOrg.ID}, userIDs(users))
+	})
+
+	t.Run("pages users in creation order", func(t *testing.T) {
+		repos := newRepositories(t)
+		var want []uuid.UUID
+		for i := 0; i < 5; i++ {
+			want = append(want, createUser(t, repos, testutils.UserFactory()).ID)
+		}
+
+		items := collectPages(t, func(p pagination.Params) (pagination.Page[models.User], error) {
+			return repos.Users.ListPage(ctx, nil, p)
+		}, func(u models.User) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+		})
+		assert.ElementsMatch(t, want, userIDs(items))
+	})
+}
+
+func testOrganizations(t *testing.T, newRepositories func(t *testing.T) Repositories) {
+	ctx := context.Background()
+
+	t.Run("gets a created organization by ID", func(t *testing.T) {
+		repos := newRepositories(t)
+		org := createOrganization(t, repos)
+
+		got, err := repos.Organizations.GetByID(ctx, org.ID)
+		require.NoError(t, err)
+		assert.Equal(t, org.Slug, got.Slug)
+
+		_, err = repos.Organizations.GetByID(ctx, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("lists organizations by name", func(t *testing.T) {
+		repos := newRepositories(t)
+		for _, name := range []string{"Globex", "Acme", "Initech"} {
+			require.NoError(t, repos.Organizations.Create(ctx, &models.Organization{Name: name, Slug: name}))
+		}
+
+		orgs, err := repos.Organizations.GetAll(ctx)
+		require.NoError(t, err)
+		var names []string
+		for _, org := range orgs {
+			names = append(names, org.Name)
+		}
+		assert.Equal(t, []string{"Acme", "Globex", "Initech"}, names)
+	})
+
+	t.Run("tells whether slugs exist", func(t *testing.T) {
+		repos := newRepositories(t)
+		org := createOrganization(t, repos)
+
+		exists, err := repos.Organizations.SlugExists(ctx, org.Slug)
+		require.NoError(t, err)
+		assert.True(t, exists)
+		exists, err = repos.Organizations.SlugExists(ctx, "other")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("moves assigned users into the organization", func(t *testing.T) {
+		repos := newRepositories(t)
+		org := createOrganization(t, repos)
+		user := createUser(t, repos, testutils.UserFactory())
+
+		require.NoError(t, repos.Organizations.AssignUser(ctx, org.ID, user.ID))
+
+		got, err := repos.Users.GetByID(ctx, user.ID)
+		require.NoError(t, err)
+		require.NotNil(t, got.OrganizationID)
+		assert.Equal(t, org.ID, *got.OrganizationID)
+		users, err := repos.Users.GetAll(ctx, nil)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+	})
+
+	t.Run("reports assigning a missing user as not found", func(t *testing.T) {
+		repos := newRepositories(t)
+		org := createOrganization(t, repos)
+		err := repos.Organizations.AssignUser(ctx, org.ID, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	})
+}
+
+func testTeams(t *testing.T, newRepositories func(t *testing.T) Repositories) {
+	ctx := context.Background()
+
+	t.Run("gets a team with its people by role", func(t *testing.T) {
+		repos := newRepositories(t)
+		team := createTeam(t, repos, testutils.TeamFactory())
+		admin := createUser(t, repos, testutils.UserFactory().Manager())
+		manager := createUser(t, repos, testutils.UserFactory().Manager())
+		member := createUser(t, repos, testutils.UserFactory())
+		viewer := createUser(t, repos, testutils.UserFactory())
+		require.NoError(t, repos.Teams.AddMembership(ctx, team.ID, admin.ID, models.TeamRoleAdmin))
+		require.NoError(t, repos.Teams.AddMembership(ctx, team.ID, manager.ID, models.TeamRoleManager))
+		require.NoError(t, repos.Teams.AddMembership(ctx, team.ID, member.ID, models.TeamRoleMember))
+		require.NoError(t, repos.Teams.AddMembership(ctx, team.ID, viewer.ID, models.TeamRoleViewer))
+
+		got, err := repos.Teams.GetByID(ctx, team.ID)
+		require.NoError(t, err)
+		assert.Equal(t, team.Name, got.Name)
+		assert.ElementsMatch(t, []uuid.UUID{admin.ID}, userIDs(got.Admins))
+		assert.ElementsMatch(t, []uuid.UUID{manager.ID}, userIDs(got.Managers))
+		assert.ElementsMatch(t, []uuid.UUID{member.ID}, userIDs(got.Members))
+		assert.ElementsMatch(t, []uuid.UUID{viewer.ID}, userIDs(got.Viewers))
+
+		_, err = repos.Teams.GetByID(ctx, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("adds, changes and removes roles", func(t *testing.T) {
+		repos := newRepositories(t)
+		team := createTeam(t, repos, testutils.TeamFactory())
+		user := createUser(t, repos, testutils.UserFactory().Manager())
+
+		require.NoError(t, repos.Teams.AddMembership(ctx, team.ID, user.ID, models.TeamRoleManager))
+		role, err := repos.Teams.GetRole(ctx, team.ID, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.TeamRoleManager, role)
+
+		require.NoError(t, repos.Teams.SetRole(ctx, team.ID, user.ID, models.TeamRoleViewer))
+		role, err = repos.Teams.GetRole(ctx, team.ID, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.TeamRoleViewer, role)
+
+		require.NoError(t, repos.Teams.RemoveMembership(ctx, team.ID, user.ID))
+		role, err = repos.Teams.GetRole(ctx, team.ID, user.ID)
+		require.NoError(t, err)
+		assert.Empty(t, role)
+		got, err := repos.Teams.GetByID(ctx, team.ID)
+		require.NoError(t, err)
+		assert.Empty(t, got.People())
+	})
+
+	t.Run("removing someone not in the team is not an error", func(t *testing.T) {
+		repos := newRepositories(t)
+		team := createTeam(t, repos, testutils.TeamFactory())
+		assert.NoError(t, repos.Teams.RemoveMembership(ctx, team.ID, uuid.New()))
+	})
+
+	t.Run("changing the role of someone not in the team is not found", func(t *testing.T) {
+		repos := newRepositories(t)
+		team := createTeam(t, repos, testutils.TeamFactory())
+		user := createUser(t, repos, testutils.UserFactory())
+		assert.ErrorIs(t, repos.Teams.SetRole(ctx, team.ID, user.ID, models.TeamRoleMember), apperrors.ErrNotFound)
+	})
+
+	t.Run("rejects adding someone twice", func(t *testing.T) {
+		repos := newRepositories(t)
+		team := createTeam(t, repos, testutils.TeamFactory())
+		user := createUser(t, repos, testutils.UserFactory().Manager())
+		require.NoError(t, repos.Teams.AddMembership(ctx, team.ID, user.ID, models.TeamRoleManager))
+
+		assert.Error(t, repos.Teams.AddMembership(ctx, team.ID, user.ID, models.TeamRoleManager))
+		assert.Error(t, repos.Teams.AddMembership(ctx, team.ID, user.ID, models.TeamRoleMember))
+	})
+
+	t.Run("reports users of another organization as not found", func(t *testing.T) {
+		repos := newRepositories(t)
+		org := createOrganization(t, repos)
+		team := createTeam(t, repos, testutils.TeamFactory())
+		outsider := createUser(t, repos, testutils.UserFactory().Manager().InOrganization(org.ID))
+
+		assert.ErrorIs(t, repos.Teams.AddMembership(ctx, team.ID, outsider.ID, models.TeamRoleManager), apperrors.ErrNotFound)
+		assert.ErrorIs(t, repos.Teams.AddMembership(ctx, team.ID, outsider.ID, models.TeamRoleMember), apperrors.ErrNotFound)
+		assert.ErrorIs(t, repos.Teams.AddMembership(ctx, team.ID, uuid.New(), models.TeamRoleMember), apperrors.ErrNotFound)
+	})
+
+	t.Run("reports the role held in each team", func(t *testing.T) {
+		repos := newRepositories(t)
+		administered := createTeam(t, repos, testutils.TeamFactory())
+		viewed := createTeam(t, repos, testutils.TeamFactory())
+		createTeam(t, repos, testutils.TeamFactory())
+		user := createUser(t, repos, testutils.UserFactory().Manager())
+		require.NoError(t, repos.Teams.AddMembership(ctx, administered.ID, user.ID, models.TeamRoleAdmin))
+		require.NoError(t, repos.Teams.AddMembership(ctx, viewed.ID, user.ID, models.TeamRoleViewer))
+
+		roles, err := repos.Teams.GetUserTeamRoles(ctx, user.ID)
+		require.NoError(t, err)
+		assert.Equal(t, map[uuid.UUID]models.TeamRole{
+			administered.ID: models.TeamRoleAdmin,
+			viewed.ID:       models.TeamRoleViewer,
+		}, roles)
+	})
+
+	t.Run("lists the teams of one organization with their people", func(t *testing.T) {
+		repos := newRepositories(t)
+		org := createOrganization(t, repos)
+		inDefault := createTeam(t, repos, testutils.TeamFactory())
+		inOrg := createTeam(t, repos, testutils.TeamFactory().InOrganization(org.ID))
+		member := createUser(t, repos, testutils.UserFactory().InOrganization(org.ID))
+		require.NoError(t, repos.Teams.AddMembership(ctx, inOrg.ID, member.ID, models.TeamRoleMember))
+
+		teams, err := repos.Teams.GetAll(ctx, nil)
+		require.NoError(t, err)
+		require.Len(t, teams, 1)
+		assert.Equal(t, inDefault.ID, teams[0].ID)
+
+		teams, err = repos.Teams.GetAll(ctx, &org.ID)
+		require.NoError(t, err)
+		require.Len(t, teams, 1)
+		assert.Equal(t, inOrg.ID, teams[0].ID)
+		assert.ElementsMatch(t, []uuid.UUID{member.ID}, userIDs(teams[0].Members))
+	})
+
+	t.Run("pages teams in creation order", func(t *testing.T) {
+		repos := newRepositories(t)
+		var want []uuid.UUID
+		for i := 0; i < 5; i++ {
+			want = append(want, createTeam(t, repos, testutils.TeamFactory()).ID)
+		}
+
+		items := collectPages(t, func(p pagination.Params) (pagination.Page[models.Team], error) {
+			return repos.Teams.ListPage(ctx, nil, p)
+		}, func(team models.Team) pagination.Cursor {
+			return pagination.Cursor{CreatedAt: team.CreatedAt, ID: team.ID}
+		})
+		var got []uuid.UUID
+		for _, team := range items {
+			got = append(got, team.ID)
+		}
+		assert.ElementsMatch(t, want, got)
+	})
+}
+
+func createUser(t *testing.T, repos Repositories, b *testutils.UserBuilder) *models.User {
+	t.Helper()
+	user := b.Build()
+	require.NoError(t, repos.Users.Create(context.Background(), user))
+	return user
+}
+
+func createTeam(t *testing.T, repos Repositories, b *testutils.TeamBuilder) *models.Team {
+	t.Helper()
+	team := b.Build()
+	require.NoError(t, repos.Teams.Create(context.Background(), team))
+	return team
+}
+
+func createOrganization(t *testing.T, repos Repositories) *models.Organization {
+	t.Helper()
+	slug := "org-" + uuid.NewString()[:8]
+	org := &models.Organization{Name: slug, Slug: slug}
+	require.NoError(t, repos.Organizations.Create(context.Background(), org))
+	return org
+}
+
+// collectPages reads a listing two rows at a time and checks that pages are
+// full until the last and follow each other in cursor order
+func collectPages[T any](t *testing.T, list func(pagination.Params) (pagination.Page[T], error), cursorOf func(T) pagination.Cursor) []T {
+	t.Helper()
+	var items []T
+	p := pagination.Params{Limit: 2}
+	for {
+		page, err := list(p)
+		require.NoError(t, err)
+		if page.Next != "" {
+			require.Len(t, page.Items, 2)
+		}
+		for _, item := range page.Items {
+			if len(items) > 0 {
+				prev, next := cursorOf(items[len(items)-1]), cursorOf(item)
+				assert.True(t, prev.CreatedAt.Before(next.CreatedAt) ||
+					prev.CreatedAt.Equal(next.CreatedAt) && bytes.Compare(prev.ID[:], next.ID[:]) < 0,
+					"rows out of order")
+			}
+			items = append(items, item)
+		}
+		if page.Next == "" {
+			return items
+		}
+		after, err := pagination.Decode(page.Next)
+		require.NoError(t, err)
+		p.After = &after
+	}
+}
+
+func userIDs(users []models.User) []uuid.UUID {
+	ids := []uuid.UUID{}
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
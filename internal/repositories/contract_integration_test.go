@@ -0,0 +1,22 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"testing"
+
+	"seta-training/internal/repositories"
+	"seta-training/internal/repositories/contract"
+	"seta-training/internal/testutils"
+)
+
+func TestContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Repositories {
+		tx := testutils.PostgresTx(t)
+		return contract.Repositories{
+			Users:         repositories.NewUserRepository(tx),
+			Organizations: repositories.NewOrganizationRepository(tx),
+			Teams:         repositories.NewTeamRepository(tx),
+		}
+	})
+}
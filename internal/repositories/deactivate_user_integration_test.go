@@ -0,0 +1,61 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/testutils"
+)
+
+func TestDeactivateUser_RevokesDevices(t *testing.T) {
+	type deactivator interface {
+		DeactivateUser(ctx context.Context, userID uuid.UUID) error
+	}
+	tests := []struct {
+		name    string
+		newRepo func(db *gorm.DB) deactivator
+	}{
+		{name: "scim", newRepo: func(db *gorm.DB) deactivator { return repositories.NewSCIMRepository(db) }},
+		{name: "ldap", newRepo: func(db *gorm.DB) deactivator { return repositories.NewLDAPRepository(db) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := testutils.PostgresTx(t)
+			devices := repositories.NewDeviceRepository(db)
+			user := testutils.UserFactory().Create(t, db)
+			other := testutils.UserFactory().Create(t, db)
+			before := time.Now().Add(-time.Minute)
+
+			laptop := &models.Device{UserID: user.ID, UserAgent: "laptop", LastLoginAt: before}
+			phone := &models.Device{UserID: user.ID, UserAgent: "phone", LastLoginAt: before}
+			untouched := &models.Device{UserID: other.ID, UserAgent: "laptop", LastLoginAt: before}
+			for _, device := range []*models.Device{laptop, phone, untouched} {
+				require.NoError(t, devices.Create(ctx, device))
+			}
+
+			require.NoError(t, tt.newRepo(db).DeactivateUser(ctx, user.ID))
+
+			// The device service rejects the tokens of the devices revoked
+			revoked, err := devices.RevokedSince(ctx, before)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, []uuid.UUID{laptop.ID, phone.ID}, revoked)
+			active, err := devices.GetActiveByUser(ctx, other.ID)
+			require.NoError(t, err)
+			assert.Len(t, active, 1)
+
+			var deleted models.User
+			require.NoError(t, db.Unscoped().First(&deleted, "id = ?", user.ID).Error)
+			assert.True(t, deleted.DeletedAt.Valid)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+type DeviceRepository struct {
+	Repository[models.Device]
+}
+
+func NewDeviceRepository(db *gorm.DB) *DeviceRepository {
+	return &DeviceRepository{Repository: NewRepository[models.Device](db, apperrors.NotFound("device not found"))}
+}
+
+// FindActive returns userID's device with userAgent that was not revoked, or
+// nil when there is none
+func (r *DeviceRepository) FindActive(ctx context.Context, userID uuid.UUID, userAgent string) (*models.Device, error) {
+	var devices []models.Device
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND user_agent = ? AND revoked_at IS NULL", userID, userAgent).
+		Limit(1).Find(&devices).Error
+	if err != nil || len(devices) == 0 {
+		return nil, err
+	}
+	return &devices[0], nil
+}
+
+// GetActiveByUser returns the devices of userID that were not revoked, most
+// recently used first
+func (r *DeviceRepository) GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]models.Device, error) {
+	var devices []models.Device
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("last_login_at DESC, id").
+		Find(&devices).Error
+	return devices, err
+}
+
+// Revoke marks userID's device id as revoked at the given time
+func (r *DeviceRepository) Revoke(ctx context.Context, id, userID uuid.UUID, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&models.Device{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.notFound
+	}
+	return nil
+}
+
+// revokeUserDevices revokes every device of userID that is not revoked yet
+func revokeUserDevices(tx *gorm.DB, userID uuid.UUID, at time.Time) error {
+	return tx.Model(&models.Device{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", at).Error
+}
+
+// RevokedSince returns the IDs of the devices revoked after since
+func (r *DeviceRepository) RevokedSince(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.Device{}).
+		Where("revoked_at > ?", since).
+		Pluck("id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+type ExportJobRepository struct {
+	Repository[models.ExportJob]
+}
+
+func NewExportJobRepository(db *gorm.DB) *ExportJobRepository {
+	return &ExportJobRepository{Repository: NewRepository[models.ExportJob](db, apperrors.NotFound("export job not found"))}
+}
+
+// GetByID loads a job without its artifact
+func (r *ExportJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.WithContext(ctx).Omit("artifact").Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("export job not found")
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// UnfinishedIDs returns the IDs of the jobs that are pending or running,
+// oldest first
+func (r *ExportJobRepository) UnfinishedIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.ExportJob{}).
+		Where("status IN ?", unfinishedStatuses).
+		Order("created_at").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *ExportJobRepository) GetArtifact(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	var job models.ExportJob
+	err := r.db.WithContext(ctx).Select("artifact").Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("export job not found")
+		}
+		return nil, err
+	}
+	return job.Artifact, nil
+}
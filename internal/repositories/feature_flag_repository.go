@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/featureflags"
+)
+
+// FeatureFlagRepository stores the flags managed through the admin API. It
+// is a featureflags.Source.
+type FeatureFlagRepository struct {
+	db *gorm.DB
+}
+
+func NewFeatureFlagRepository(db *gorm.DB) *FeatureFlagRepository {
+	return &FeatureFlagRepository{db: db}
+}
+
+// List returns every flag by name
+func (r *FeatureFlagRepository) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	var flags []models.FeatureFlag
+	err := r.db.WithContext(ctx).Order("name").Find(&flags).Error
+	return flags, err
+}
+
+// Save inserts or replaces the flag
+func (r *FeatureFlagRepository) Save(ctx context.Context, flag *models.FeatureFlag) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		UpdateAll: true,
+	}).Create(flag).Error
+}
+
+func (r *FeatureFlagRepository) Delete(ctx context.Context, name string) error {
+	result := r.db.WithContext(ctx).Where("name = ?", name).Delete(&models.FeatureFlag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("feature flag %q not found", name)
+	}
+	return nil
+}
+
+// Flags returns the stored flags for evaluation
+func (r *FeatureFlagRepository) Flags(ctx context.Context) (map[string]featureflags.Flag, error) {
+	stored, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	flags := make(map[string]featureflags.Flag, len(stored))
+	for _, f := range stored {
+		flags[f.Name] = featureflags.Flag{
+			Name:       f.Name,
+			Enabled:    f.Enabled,
+			Users:      f.Users,
+			Teams:      f.Teams,
+			Percentage: f.Percentage,
+		}
+	}
+	return flags, nil
+}
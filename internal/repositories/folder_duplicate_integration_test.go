@@ -0,0 +1,65 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/testutils"
+	"seta-training/pkg/compression"
+	"seta-training/pkg/crypto"
+)
+
+func TestFolderRepository_DuplicateEncryptedNotes(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.PostgresTx(t)
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	keys, err := crypto.NewLocalKeySource(map[string]string{"k1": base64.StdEncoding.EncodeToString(key)}, "")
+	require.NoError(t, err)
+	compressor, err := compression.NewCompressor("gzip", 64)
+	require.NoError(t, err)
+	codec := repositories.NewNoteBodyCodec(compressor, crypto.NewEnvelope(keys), nil)
+	notes := repositories.NewNoteRepository(db, codec)
+	folders := repositories.NewFolderRepository(db, codec)
+
+	owner := testutils.UserFactory().Create(t, db)
+	source := &models.Folder{Name: "Source", OwnerID: owner.ID}
+	require.NoError(t, folders.Create(ctx, source))
+	bodies := map[string]string{
+		"Short": "sealed only",
+		"Long":  strings.Repeat("compressed, then sealed. ", 20),
+	}
+	for title, body := range bodies {
+		require.NoError(t, notes.Create(ctx, &models.Note{Title: title, Body: body, FolderID: source.ID, OwnerID: owner.ID}))
+	}
+
+	duplicate := &models.Folder{Name: "Copy", OwnerID: owner.ID}
+	copied, err := folders.Duplicate(ctx, source.ID, duplicate, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, len(bodies), copied)
+
+	got, err := notes.GetByFolder(ctx, duplicate.ID)
+	require.NoError(t, err)
+	require.Len(t, got, len(bodies))
+	for _, note := range got {
+		assert.Equal(t, bodies[note.Title], note.Body)
+	}
+
+	// The sources still open under their own IDs
+	got, err = notes.GetByFolder(ctx, source.ID)
+	require.NoError(t, err)
+	for _, note := range got {
+		assert.Equal(t, bodies[note.Title], note.Body)
+	}
+}
@@ -1,76 +1,386 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
 	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
 )
 
 type FolderRepository struct {
-	db *gorm.DB
+	Repository[models.Folder]
+	codec *NoteBodyCodec
 }
 
-func NewFolderRepository(db *gorm.DB) *FolderRepository {
-	return &FolderRepository{db: db}
+// NewFolderRepository creates a folder repository decoding the bodies of
+// the notes it loads with codec
+func NewFolderRepository(db *gorm.DB, codec *NoteBodyCodec) *FolderRepository {
+	return &FolderRepository{Repository: NewRepository[models.Folder](db, apperrors.NotFound("folder not found")), codec: codec}
 }
 
-func (r *FolderRepository) Create(folder *models.Folder) error {
-	return r.db.Create(folder).Error
+// Create inserts folder and a folder.created outbox event in one transaction
+func (r *FolderRepository) Create(ctx context.Context, folder *models.Folder) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(folder).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventFolderCreated, folder.ID, models.FolderEvent{FolderID: folder.ID})
+	})
 }
 
-func (r *FolderRepository) GetByID(id uuid.UUID) (*models.Folder, error) {
+// Update saves folder and a folder.updated outbox event in one transaction
+func (r *FolderRepository) Update(ctx context.Context, folder *models.Folder) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(folder).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventFolderUpdated, folder.ID, models.FolderEvent{FolderID: folder.ID})
+	})
+}
+
+func (r *FolderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Folder, error) {
 	var folder models.Folder
-	err := r.db.Preload("Owner").Preload("Notes").Preload("Shares.User").Where("id = ?", id).First(&folder).Error
+	err := r.db.WithContext(ctx).Preload("Owner").Preload("Notes").Preload("Shares.User").Where("id = ?", id).First(&folder).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("folder not found")
+			return nil, apperrors.NotFound("folder not found")
 		}
 		return nil, err
 	}
+	if err := r.codec.decodeAll(folder.Notes); err != nil {
+		return nil, err
+	}
+	if err := attachChecklists(r.db.WithContext(ctx), folder.Notes); err != nil {
+		return nil, err
+	}
 	return &folder, nil
 }
 
-func (r *FolderRepository) GetByOwner(ownerID uuid.UUID) ([]models.Folder, error) {
+func (r *FolderRepository) GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Folder, error) {
 	var folders []models.Folder
-	err := r.db.Where("owner_id = ?", ownerID).Preload("Notes").Find(&folders).Error
-	return folders, err
+	if err := database.ReadReplica(r.db.WithContext(ctx)).Where("owner_id = ?", ownerID).Preload("Notes").Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	return folders, r.codec.decodeFolderNotes(folders)
 }
 
-func (r *FolderRepository) Update(folder *models.Folder) error {
-	return r.db.Save(folder).Error
+// DeleteWithContents deletes the folder together with every note in it and
+// the shares of both, using one statement per table in one transaction. The
+// folder and notes are soft-deleted; shares are removed. It returns how many
+// notes were deleted.
+func (r *FolderRepository) DeleteWithContents(ctx context.Context, id uuid.UUID) (int64, error) {
+	var notesDeleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		noteIDs := tx.Unscoped().Model(&models.Note{}).Select("id").Where("folder_id = ?", id)
+		if err := tx.Where("note_id IN (?)", noteIDs).Delete(&models.NoteShare{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("folder_id = ?", id).Delete(&models.Note{})
+		if result.Error != nil {
+			return result.Error
+		}
+		notesDeleted = result.RowsAffected
+		if err := tx.Where("folder_id = ?", id).Delete(&models.FolderShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Folder{}, id).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventFolderDeleted, id, models.FolderEvent{FolderID: id})
+	})
+	return notesDeleted, err
+}
+
+// Merge moves every note of the folder sourceID into targetID, renaming the
+// notes in titles on the way, then trashes the emptied source like
+// DeleteWithContents, all in one transaction. A folder.deleted event and a
+// note.updated event per moved note are written with it. It returns how
+// many notes were moved.
+func (r *FolderRepository) Merge(ctx context.Context, sourceID, targetID uuid.UUID, titles map[uuid.UUID]string) (int64, error) {
+	var moved int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for noteID, title := range titles {
+			if err := tx.Model(&models.Note{}).Where("id = ? AND folder_id = ?", noteID, sourceID).Update("title", title).Error; err != nil {
+				return err
+			}
+		}
+
+		var noteIDs []uuid.UUID
+		if err := tx.Model(&models.Note{}).Where("folder_id = ?", sourceID).Pluck("id", &noteIDs).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.Note{}).Where("folder_id = ?", sourceID).Update("folder_id", targetID)
+		if result.Error != nil {
+			return result.Error
+		}
+		moved = result.RowsAffected
+
+		if err := tx.Where("folder_id = ?", sourceID).Delete(&models.FolderShare{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Folder{}, sourceID).Error; err != nil {
+			return err
+		}
+		// The source goes first so the moved notes are not dropped from the
+		// search index along with it
+		if err := enqueueEvent(tx, models.EventFolderDeleted, sourceID, models.FolderEvent{FolderID: sourceID}); err != nil {
+			return err
+		}
+		for _, noteID := range noteIDs {
+			if err := enqueueEvent(tx, models.EventNoteUpdated, noteID, models.NoteEvent{NoteID: noteID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return moved, err
 }
 
-func (r *FolderRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Folder{}, id).Error
+// duplicateBatchSize is how many notes Duplicate copies per statement
+const duplicateBatchSize = 100
+
+// Duplicate creates folder as a copy of the folder sourceID with every note
+// in it, and the notes' checklists and links, in one transaction. With
+// withShares the shares of the folder and its notes are copied too. Notes
+// are copied in batches, calling progress after each with how many of the
+// total are done; progress may be nil. Note bodies are copied as stored,
+// so they are not decoded and encoded again. A folder.created event and a
+// note.created event per note are written with the copies. It returns how
+// many notes were copied.
+func (r *FolderRepository) Duplicate(ctx context.Context, sourceID uuid.UUID, folder *models.Folder, withShares bool, progress func(copied, total int)) (int, error) {
+	var copied int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(folder).Error; err != nil {
+			return err
+		}
+		if err := enqueueEvent(tx, models.EventFolderCreated, folder.ID, models.FolderEvent{FolderID: folder.ID}); err != nil {
+			return err
+		}
+		if withShares {
+			var shares []models.FolderShare
+			if err := tx.Where("folder_id = ?", sourceID).Find(&shares).Error; err != nil {
+				return err
+			}
+			for i := range shares {
+				shares[i] = models.FolderShare{FolderID: folder.ID, UserID: shares[i].UserID, Access: shares[i].Access}
+			}
+			if err := createAll(tx, shares); err != nil {
+				return err
+			}
+		}
+
+		var total int64
+		if err := tx.Model(&models.Note{}).Where("folder_id = ?", sourceID).Count(&total).Error; err != nil {
+			return err
+		}
+		var notes []models.Note
+		return tx.Where("folder_id = ?", sourceID).FindInBatches(&notes, duplicateBatchSize, func(*gorm.DB, int) error {
+			if err := copyNotes(tx, r.codec, notes, folder, withShares); err != nil {
+				return err
+			}
+			copied += len(notes)
+			if progress != nil {
+				progress(copied, int(total))
+			}
+			return nil
+		}).Error
+	})
+	return copied, err
 }
 
-func (r *FolderRepository) ShareFolder(folderID, userID uuid.UUID, access models.AccessLevel) error {
+// copyNotes copies notes into folder, with their checklists, links and,
+// with withShares, their shares. Bodies are copied in their stored form,
+// encrypted ones re-sealed for their copy through codec.
+func copyNotes(tx *gorm.DB, codec *NoteBodyCodec, notes []models.Note, folder *models.Folder, withShares bool) error {
+	sourceIDs := make([]uuid.UUID, len(notes))
+	copyOf := make(map[uuid.UUID]uuid.UUID, len(notes))
+	copies := make([]models.Note, len(notes))
+	for i, note := range notes {
+		sourceIDs[i] = note.ID
+		copyOf[note.ID] = uuid.New()
+		copies[i] = models.Note{
+			ID:             copyOf[note.ID],
+			Title:          note.Title,
+			Body:           note.Body,
+			FolderID:       folder.ID,
+			OwnerID:        folder.OwnerID,
+			Status:         note.Status,
+			OrganizationID: folder.OrganizationID,
+			BodyEncoding:   note.BodyEncoding,
+			CompressedBody: note.CompressedBody,
+			BodyKeyID:      note.BodyKeyID,
+			BodyDataKey:    note.BodyDataKey,
+		}
+		if err := codec.reseal(&copies[i], note.ID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Create(&copies).Error; err != nil {
+		return err
+	}
+
+	var items []models.ChecklistItem
+	if err := tx.Where("note_id IN ?", sourceIDs).Find(&items).Error; err != nil {
+		return err
+	}
+	for i := range items {
+		items[i] = models.ChecklistItem{
+			NoteID:   copyOf[items[i].NoteID],
+			Text:     items[i].Text,
+			Done:     items[i].Done,
+			Position: items[i].Position,
+			DoneAt:   items[i].DoneAt,
+		}
+	}
+	if err := createAll(tx, items); err != nil {
+		return err
+	}
+
+	var links []models.NoteLink
+	if err := tx.Where("source_id IN ?", sourceIDs).Find(&links).Error; err != nil {
+		return err
+	}
+	for i := range links {
+		links[i] = models.NoteLink{SourceID: copyOf[links[i].SourceID], TargetID: links[i].TargetID}
+	}
+	if err := createAll(tx, links); err != nil {
+		return err
+	}
+
+	if withShares {
+		var shares []models.NoteShare
+		if err := tx.Where("note_id IN ?", sourceIDs).Find(&shares).Error; err != nil {
+			return err
+		}
+		for i := range shares {
+			shares[i] = models.NoteShare{NoteID: copyOf[shares[i].NoteID], UserID: shares[i].UserID, Access: shares[i].Access}
+		}
+		if err := createAll(tx, shares); err != nil {
+			return err
+		}
+	}
+
+	for _, note := range copies {
+		err := enqueueEvent(tx, models.EventNoteCreated, note.ID, models.NoteCreatedEvent{
+			NoteID:   note.ID,
+			FolderID: note.FolderID,
+			OwnerID:  note.OwnerID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createAll inserts rows in one statement, doing nothing when there are none
+func createAll[T any](tx *gorm.DB, rows []T) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return tx.Create(&rows).Error
+}
+
+// ShareFolder inserts the share and a folder.shared outbox event in one
+// transaction. Users of another organization are reported as not found.
+func (r *FolderRepository) ShareFolder(ctx context.Context, folderID, userID uuid.UUID, access models.AccessLevel) error {
 	share := &models.FolderShare{
 		FolderID: folderID,
 		UserID:   userID,
 		Access:   access,
 	}
-	return r.db.Create(share).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSameOrganization(tx, &models.Folder{}, folderID, userID); err != nil {
+			return err
+		}
+		if err := tx.Create(share).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventFolderShared, folderID, models.FolderSharedEvent{
+			FolderID: folderID,
+			UserID:   userID,
+			Access:   access,
+		})
+	})
 }
 
-func (r *FolderRepository) RevokeShare(folderID, userID uuid.UUID) error {
-	return r.db.Where("folder_id = ? AND user_id = ?", folderID, userID).Delete(&models.FolderShare{}).Error
+// ListShares returns one page of the shares of a folder
+func (r *FolderRepository) ListShares(ctx context.Context, folderID uuid.UUID, p pagination.Params) (pagination.Page[models.FolderShare], error) {
+	return listAfter(r.db.WithContext(ctx), p, func(s models.FolderShare) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: s.CreatedAt, ID: s.ID}
+	}, func(db *gorm.DB) *gorm.DB {
+		return db.Where("folder_id = ?", folderID).Preload("User")
+	})
 }
 
-func (r *FolderRepository) GetSharedFolders(userID uuid.UUID) ([]models.Folder, error) {
+// RevokeShare deletes the share and writes a folder.unshared outbox event
+// in one transaction
+func (r *FolderRepository) RevokeShare(ctx context.Context, folderID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("folder_id = ? AND user_id = ?", folderID, userID).Delete(&models.FolderShare{}).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventFolderUnshared, folderID, models.FolderUnsharedEvent{
+			FolderID: folderID,
+			UserID:   userID,
+		})
+	})
+}
+
+func (r *FolderRepository) GetSharedFolders(ctx context.Context, userID uuid.UUID) ([]models.Folder, error) {
 	var folders []models.Folder
-	err := r.db.Joins("JOIN folder_shares ON folders.id = folder_shares.folder_id").
+	err := database.ReadReplica(r.db.WithContext(ctx)).Joins("JOIN folder_shares ON folders.id = folder_shares.folder_id").
 		Where("folder_shares.user_id = ?", userID).
 		Preload("Owner").Preload("Notes").Preload("Shares.User").
 		Find(&folders).Error
-	return folders, err
+	if err != nil {
+		return nil, err
+	}
+	return folders, r.codec.decodeFolderNotes(folders)
+}
+
+// GetFoldersByOwners returns the folders owned by any of ownerIDs in one
+// query, rather than one query per owner
+func (r *FolderRepository) GetFoldersByOwners(ctx context.Context, ownerIDs []uuid.UUID) ([]models.Folder, error) {
+	var folders []models.Folder
+	if len(ownerIDs) == 0 {
+		return folders, nil
+	}
+	if err := database.ReadReplica(r.db.WithContext(ctx)).Where("owner_id IN ?", ownerIDs).Preload("Notes").Order("created_at").Find(&folders).Error; err != nil {
+		return nil, err
+	}
+	return folders, r.codec.decodeFolderNotes(folders)
+}
+
+// GetSharesByUsers returns the folder shares granted to any of userIDs,
+// each with its folder
+func (r *FolderRepository) GetSharesByUsers(ctx context.Context, userIDs []uuid.UUID) ([]models.FolderShare, error) {
+	var shares []models.FolderShare
+	if len(userIDs) == 0 {
+		return shares, nil
+	}
+	err := database.ReadReplica(r.db.WithContext(ctx)).Where("user_id IN ?", userIDs).
+		Preload("Folder.Owner").Preload("Folder.Notes").
+		Order("created_at").Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range shares {
+		if err := r.codec.decodeAll(shares[i].Folder.Notes); err != nil {
+			return nil, err
+		}
+	}
+	return shares, nil
 }
 
-func (r *FolderRepository) GetUserAccess(folderID, userID uuid.UUID) (*models.FolderShare, error) {
+func (r *FolderRepository) GetUserAccess(ctx context.Context, folderID, userID uuid.UUID) (*models.FolderShare, error) {
 	var share models.FolderShare
-	err := r.db.Where("folder_id = ? AND user_id = ?", folderID, userID).First(&share).Error
+	err := r.db.WithContext(ctx).Where("folder_id = ? AND user_id = ?", folderID, userID).First(&share).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -80,16 +390,16 @@ func (r *FolderRepository) GetUserAccess(folderID, userID uuid.UUID) (*models.Fo
 	return &share, nil
 }
 
-func (r *FolderRepository) HasAccess(folderID, userID uuid.UUID) (bool, models.AccessLevel, error) {
+func (r *FolderRepository) HasAccess(ctx context.Context, folderID, userID uuid.UUID) (bool, models.AccessLevel, error) {
 	// Check if user is owner
 	var folder models.Folder
-	err := r.db.Where("id = ? AND owner_id = ?", folderID, userID).First(&folder).Error
+	err := r.db.WithContext(ctx).Where("id = ? AND owner_id = ?", folderID, userID).First(&folder).Error
 	if err == nil {
 		return true, models.AccessWrite, nil
 	}
 
 	// Check if user has shared access
-	share, err := r.GetUserAccess(folderID, userID)
+	share, err := r.GetUserAccess(ctx, folderID, userID)
 	if err != nil {
 		return false, "", err
 	}
@@ -1,55 +1,341 @@
 package repositories
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
 )
 
 // UserRepositoryInterface defines the interface for user repository
 type UserRepositoryInterface interface {
-	Create(user *models.User) error
-	GetByID(id uuid.UUID) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetAll() ([]models.User, error)
-	EmailExists(email string) (bool, error)
-	UsernameExists(username string) (bool, error)
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetAll(ctx context.Context, orgID *uuid.UUID) ([]models.User, error)
+	ListPage(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.User], error)
+	EmailExists(ctx context.Context, email string) (bool, error)
+	UsernameExists(ctx context.Context, username string) (bool, error)
 }
 
 // TeamRepositoryInterface defines the interface for team repository
 type TeamRepositoryInterface interface {
-	Create(team *models.Team) error
-	GetByID(id uuid.UUID) (*models.Team, error)
-	GetAll() ([]models.Team, error)
-	AddManager(teamID, userID uuid.UUID) error
-	RemoveManager(teamID, userID uuid.UUID) error
-	AddMember(teamID, userID uuid.UUID) error
-	RemoveMember(teamID, userID uuid.UUID) error
-	IsManager(teamID, userID uuid.UUID) (bool, error)
+	Create(ctx context.Context, team *models.Team) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Team, error)
+	GetAll(ctx context.Context, orgID *uuid.UUID) ([]models.Team, error)
+	ListPage(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.Team], error)
+	AddMembership(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole) error
+	SetRole(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole) error
+	RemoveMembership(ctx context.Context, teamID, userID uuid.UUID) error
+	GetRole(ctx context.Context, teamID, userID uuid.UUID) (models.TeamRole, error)
+	GetUserTeamRoles(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]models.TeamRole, error)
+	GetUserTeams(ctx context.Context, userID uuid.UUID) ([]models.Team, error)
+	UpdateSettings(ctx context.Context, teamID uuid.UUID, settings models.TeamSettings) error
+}
+
+// OrganizationRepositoryInterface defines the interface for organization repository
+type OrganizationRepositoryInterface interface {
+	Create(ctx context.Context, org *models.Organization) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error)
+	GetAll(ctx context.Context) ([]models.Organization, error)
+	SlugExists(ctx context.Context, slug string) (bool, error)
+	AssignUser(ctx context.Context, orgID, userID uuid.UUID) error
 }
 
 // FolderRepositoryInterface defines the interface for folder repository
 type FolderRepositoryInterface interface {
-	Create(folder *models.Folder) error
-	GetByID(id uuid.UUID) (*models.Folder, error)
-	GetByOwner(ownerID uuid.UUID) ([]models.Folder, error)
-	Update(folder *models.Folder) error
-	Delete(id uuid.UUID) error
-	ShareFolder(folderID, userID uuid.UUID, access models.AccessLevel) error
-	RevokeShare(folderID, userID uuid.UUID) error
-	HasAccess(folderID, userID uuid.UUID) (bool, models.AccessLevel, error)
-	GetSharedFolders(userID uuid.UUID) ([]models.Folder, error)
+	Create(ctx context.Context, folder *models.Folder) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Folder, error)
+	GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Folder, error)
+	Update(ctx context.Context, folder *models.Folder) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteWithContents(ctx context.Context, id uuid.UUID) (int64, error)
+	Duplicate(ctx context.Context, sourceID uuid.UUID, folder *models.Folder, withShares bool, progress func(copied, total int)) (int, error)
+	Merge(ctx context.Context, sourceID, targetID uuid.UUID, titles map[uuid.UUID]string) (int64, error)
+	ShareFolder(ctx context.Context, folderID, userID uuid.UUID, access models.AccessLevel) error
+	RevokeShare(ctx context.Context, folderID, userID uuid.UUID) error
+	ListShares(ctx context.Context, folderID uuid.UUID, p pagination.Params) (pagination.Page[models.FolderShare], error)
+	HasAccess(ctx context.Context, folderID, userID uuid.UUID) (bool, models.AccessLevel, error)
+	GetSharedFolders(ctx context.Context, userID uuid.UUID) ([]models.Folder, error)
+	GetFoldersByOwners(ctx context.Context, ownerIDs []uuid.UUID) ([]models.Folder, error)
+	GetSharesByUsers(ctx context.Context, userIDs []uuid.UUID) ([]models.FolderShare, error)
 }
 
 // NoteRepositoryInterface defines the interface for note repository
 type NoteRepositoryInterface interface {
-	Create(note *models.Note) error
-	GetByID(id uuid.UUID) (*models.Note, error)
-	GetByOwner(ownerID uuid.UUID) ([]models.Note, error)
-	GetByFolder(folderID uuid.UUID) ([]models.Note, error)
-	Update(note *models.Note) error
-	Delete(id uuid.UUID) error
-	ShareNote(noteID, userID uuid.UUID, access models.AccessLevel) error
-	RevokeShare(noteID, userID uuid.UUID) error
-	HasAccess(noteID, userID uuid.UUID) (bool, models.AccessLevel, error)
-	GetSharedNotes(userID uuid.UUID) ([]models.Note, error)
+	Create(ctx context.Context, note *models.Note) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Note, error)
+	GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Note, error)
+	ListByOwner(ctx context.Context, ownerID uuid.UUID, filter models.NoteFilter, p pagination.Params) (pagination.Page[models.Note], error)
+	ChangeStatus(ctx context.Context, noteID uuid.UUID, from, to models.NoteStatus, changedBy uuid.UUID) error
+	ReplaceLinks(ctx context.Context, sourceID uuid.UUID, targetIDs []uuid.UUID) error
+	GetBacklinks(ctx context.Context, targetID, userID uuid.UUID) ([]models.Backlink, error)
+	GetByFolder(ctx context.Context, folderID uuid.UUID) ([]models.Note, error)
+	Update(ctx context.Context, note *models.Note) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ShareNote(ctx context.Context, noteID, userID uuid.UUID, access models.AccessLevel) error
+	RevokeShare(ctx context.Context, noteID, userID uuid.UUID) error
+	ListShares(ctx context.Context, noteID uuid.UUID, p pagination.Params) (pagination.Page[models.NoteShare], error)
+	HasAccess(ctx context.Context, noteID, userID uuid.UUID) (bool, models.AccessLevel, error)
+	GetSharedNotes(ctx context.Context, userID uuid.UUID) ([]models.Note, error)
+	GetNotesByOwners(ctx context.Context, ownerIDs []uuid.UUID) ([]models.Note, error)
+	GetSharesByUsers(ctx context.Context, userIDs []uuid.UUID) ([]models.NoteShare, error)
+}
+
+// QuotaRepositoryInterface defines the interface for quota repository
+type QuotaRepositoryInterface interface {
+	UserUsage(ctx context.Context, userID uuid.UUID) (models.Usage, error)
+	TeamUsage(ctx context.Context, teamID uuid.UUID) (models.Usage, error)
+}
+
+// StatsRepositoryInterface defines the interface for stats repository
+type StatsRepositoryInterface interface {
+	Totals(ctx context.Context) (models.StatsTotals, error)
+	Daily(ctx context.Context, from, to time.Time) ([]models.DayStats, error)
+}
+
+// ChecklistRepositoryInterface defines the interface for checklist repository
+type ChecklistRepositoryInterface interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ChecklistItem, error)
+	GetByNote(ctx context.Context, noteID uuid.UUID) ([]models.ChecklistItem, error)
+	CountByNote(ctx context.Context, noteID uuid.UUID) (int64, error)
+	Insert(ctx context.Context, item *models.ChecklistItem, position int) error
+	Update(ctx context.Context, item *models.ChecklistItem) error
+	Move(ctx context.Context, item *models.ChecklistItem, position int) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// ReminderRepositoryInterface defines the interface for reminder repository
+type ReminderRepositoryInterface interface {
+	Create(ctx context.Context, reminder *models.Reminder) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Reminder, error)
+	GetByNoteAndUser(ctx context.Context, noteID, userID uuid.UUID) ([]models.Reminder, error)
+	CountPending(ctx context.Context, noteID, userID uuid.UUID) (int64, error)
+	GetDue(ctx context.Context, now time.Time, limit int) ([]models.Reminder, error)
+	Update(ctx context.Context, reminder *models.Reminder) error
+	MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// AccessRequestRepositoryInterface defines the interface for access request repository
+type AccessRequestRepositoryInterface interface {
+	Create(ctx context.Context, request *models.AccessRequest) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AccessRequest, error)
+	FindPending(ctx context.Context, resourceType string, resourceID, requesterID uuid.UUID) (*models.AccessRequest, error)
+	ListPendingForOwner(ctx context.Context, ownerID uuid.UUID, p pagination.Params) (pagination.Page[models.AccessRequest], error)
+	Decide(ctx context.Context, request *models.AccessRequest, status models.AccessRequestStatus) error
+}
+
+// AnnouncementRepositoryInterface defines the interface for announcement repository
+type AnnouncementRepositoryInterface interface {
+	Create(ctx context.Context, announcement *models.Announcement) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Announcement, error)
+	GetAll(ctx context.Context) ([]models.Announcement, error)
+	GetActive(ctx context.Context, now time.Time) ([]models.Announcement, error)
+	Update(ctx context.Context, announcement *models.Announcement) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// FeatureFlagRepositoryInterface defines the interface for feature flag repository
+type FeatureFlagRepositoryInterface interface {
+	List(ctx context.Context) ([]models.FeatureFlag, error)
+	Save(ctx context.Context, flag *models.FeatureFlag) error
+	Delete(ctx context.Context, name string) error
+}
+
+// SavedFilterRepositoryInterface defines the interface for saved filter repository
+type SavedFilterRepositoryInterface interface {
+	Create(ctx context.Context, filter *models.SavedFilter) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.SavedFilter, error)
+	GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.SavedFilter, error)
+	GetPinned(ctx context.Context, ownerID uuid.UUID) ([]models.SavedFilter, error)
+	Update(ctx context.Context, filter *models.SavedFilter) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindNotes(ctx context.Context, userID uuid.UUID, expr models.FilterExpression, limit int) ([]models.Note, error)
+	FindFolders(ctx context.Context, userID uuid.UUID, expr models.FilterExpression, limit int) ([]models.Folder, error)
+}
+
+// SearchRepositoryInterface defines the interface for search repository
+type SearchRepositoryInterface interface {
+	SearchNotes(ctx context.Context, userID uuid.UUID, q string, limit int) ([]models.Note, int64, error)
+	SearchFolders(ctx context.Context, userID uuid.UUID, q string, limit int) ([]models.Folder, int64, error)
+	SearchTeams(ctx context.Context, orgID *uuid.UUID, q string, limit int) ([]models.Team, int64, error)
+	SearchUsers(ctx context.Context, orgID *uuid.UUID, q string, limit int) ([]models.User, int64, error)
+}
+
+// WebhookRepositoryInterface defines the interface for webhook repository
+type WebhookRepositoryInterface interface {
+	Create(ctx context.Context, hook *models.Webhook) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Webhook, error)
+	GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Webhook, error)
+	GetSubscribed(ctx context.Context, eventType string) ([]models.Webhook, error)
+	Update(ctx context.Context, hook *models.Webhook) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	GetDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+}
+
+// RetentionRepositoryInterface defines the interface for retention repository
+type RetentionRepositoryInterface interface {
+	PurgeNotes(ctx context.Context, before time.Time, limit int) (int64, error)
+	PurgeFolders(ctx context.Context, before time.Time, limit int) (int64, error)
+	PurgeTeams(ctx context.Context, before time.Time, limit int) (int64, error)
+	PurgeUsers(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// OffboardingRepositoryInterface defines the interface for offboarding repository
+type OffboardingRepositoryInterface interface {
+	GetUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+	Assets(ctx context.Context, userID uuid.UUID) ([]models.Folder, []models.Note, error)
+	TransferAssets(ctx context.Context, fromID, toID uuid.UUID, archive bool) ([]models.Folder, []models.Note, error)
 }
+
+// SSORepositoryInterface defines the interface for SSO repository
+type SSORepositoryInterface interface {
+	GetOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error)
+	GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SAMLConnection, error)
+	SaveConnection(ctx context.Context, conn *models.SAMLConnection) error
+	SetUserRole(ctx context.Context, userID uuid.UUID, role models.UserRole) error
+}
+
+// LDAPRepositoryInterface defines the interface for LDAP repository
+type LDAPRepositoryInterface interface {
+	GetConnection(ctx context.Context, orgID uuid.UUID) (*models.LDAPConnection, error)
+	GetEnabledConnections(ctx context.Context) ([]models.LDAPConnection, error)
+	SaveConnection(ctx context.Context, conn *models.LDAPConnection) error
+	RecordSync(ctx context.Context, connID uuid.UUID, at time.Time, syncErr string) error
+	GetLinkedUsers(ctx context.Context, orgID uuid.UUID) ([]models.LDAPUser, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	LinkUser(ctx context.Context, link *models.LDAPUser) error
+	UpdateUser(ctx context.Context, userID uuid.UUID, email string, role models.UserRole) error
+	DeactivateUser(ctx context.Context, userID uuid.UUID) error
+	RestoreUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// SCIMRepositoryInterface defines the interface for SCIM repository
+type SCIMRepositoryInterface interface {
+	GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SCIMConnection, error)
+	GetConnectionByTokenHash(ctx context.Context, tokenHash string) (*models.SCIMConnection, error)
+	SaveConnection(ctx context.Context, conn *models.SCIMConnection) error
+	DeleteConnection(ctx context.Context, orgID uuid.UUID) error
+	ListUsers(ctx context.Context, orgID uuid.UUID, filter SCIMUserFilter, page Page) ([]models.User, int64, error)
+	GetUser(ctx context.Context, orgID, id uuid.UUID) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
+	GetExternalIDs(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]string, error)
+	LinkUser(ctx context.Context, link *models.SCIMUser) error
+	UpdateUser(ctx context.Context, userID uuid.UUID, username, email string) error
+	DeactivateUser(ctx context.Context, userID uuid.UUID) error
+	RestoreUser(ctx context.Context, userID uuid.UUID) error
+	ListTeams(ctx context.Context, orgID uuid.UUID, displayName string, page Page) ([]models.Team, int64, error)
+	RenameTeam(ctx context.Context, teamID uuid.UUID, name string) error
+	DeleteTeam(ctx context.Context, teamID uuid.UUID) error
+}
+
+// ExportJobRepositoryInterface defines the interface for export job repository
+type ExportJobRepositoryInterface interface {
+	Create(ctx context.Context, job *models.ExportJob) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.ExportJob, error)
+	GetArtifact(ctx context.Context, id uuid.UUID) ([]byte, error)
+	Update(ctx context.Context, job *models.ExportJob) error
+	UnfinishedIDs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+// AssetReportRepositoryInterface defines the interface for asset report repository
+type AssetReportRepositoryInterface interface {
+	Create(ctx context.Context, report *models.AssetReport) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.AssetReport, error)
+	GetArtifact(ctx context.Context, id uuid.UUID) ([]byte, error)
+	Update(ctx context.Context, report *models.AssetReport) error
+	FindUnfinished(ctx context.Context, orgID *uuid.UUID) (*models.AssetReport, error)
+	UnfinishedIDs(ctx context.Context) ([]uuid.UUID, error)
+	Inventory(ctx context.Context, orgID *uuid.UUID) ([]models.Team, []models.OwnerAssets, error)
+}
+
+// LoginAttemptRepositoryInterface defines the interface for login attempt repository
+type LoginAttemptRepositoryInterface interface {
+	Create(ctx context.Context, attempt *models.LoginAttempt) error
+	GetByUsers(ctx context.Context, userIDs []uuid.UUID, limit int) ([]models.LoginAttempt, error)
+	PriorLogins(ctx context.Context, userID uuid.UUID, userAgent string) (loggedIn, fromDevice bool, err error)
+	CountFailures(ctx context.Context, ip string, since time.Time) (int64, error)
+}
+
+// DeviceRepositoryInterface defines the interface for device repository
+type DeviceRepositoryInterface interface {
+	Create(ctx context.Context, device *models.Device) error
+	Update(ctx context.Context, device *models.Device) error
+	FindActive(ctx context.Context, userID uuid.UUID, userAgent string) (*models.Device, error)
+	GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]models.Device, error)
+	Revoke(ctx context.Context, id, userID uuid.UUID, at time.Time) error
+	RevokedSince(ctx context.Context, since time.Time) ([]uuid.UUID, error)
+}
+
+// NotificationRepositoryInterface defines the interface for notification repository
+type NotificationRepositoryInterface interface {
+	Create(ctx context.Context, notification *models.Notification) error
+	GetByUser(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]models.Notification, error)
+	MarkRead(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// UserPreferenceRepositoryInterface defines the interface for user preference repository
+type UserPreferenceRepositoryInterface interface {
+	GetByUserID(ctx context.Context, userID uuid.UUID) (*models.UserPreference, error)
+	Save(ctx context.Context, pref *models.UserPreference) error
+}
+
+// MentionRepositoryInterface defines the interface for mention repository
+type MentionRepositoryInterface interface {
+	ResolveVisibleUsers(ctx context.Context, authorID uuid.UUID, usernames []string) ([]models.User, error)
+	ReplaceNoteMentions(ctx context.Context, noteID, authorID uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error)
+	GetByMentionedUser(ctx context.Context, userID uuid.UUID, limit int) ([]models.Mention, error)
+}
+
+// IdempotencyRepositoryInterface defines the interface for idempotency key repository
+type IdempotencyRepositoryInterface interface {
+	Reserve(ctx context.Context, record *models.IdempotencyKey) (bool, error)
+	Get(ctx context.Context, userID uuid.UUID, key string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, record *models.IdempotencyKey) error
+	Release(ctx context.Context, id uuid.UUID) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// OutboxRepositoryInterface defines the interface for outbox event repository
+type OutboxRepositoryInterface interface {
+	ProcessPending(ctx context.Context, limit int, handle func(event *models.OutboxEvent)) (int, error)
+	DeletePublishedBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+// The GORM repositories implement the interfaces above
+var (
+	_ UserRepositoryInterface           = (*UserRepository)(nil)
+	_ TeamRepositoryInterface           = (*TeamRepository)(nil)
+	_ OrganizationRepositoryInterface   = (*OrganizationRepository)(nil)
+	_ FolderRepositoryInterface         = (*FolderRepository)(nil)
+	_ NoteRepositoryInterface           = (*NoteRepository)(nil)
+	_ SavedFilterRepositoryInterface    = (*SavedFilterRepository)(nil)
+	_ SearchRepositoryInterface         = (*SearchRepository)(nil)
+	_ WebhookRepositoryInterface        = (*WebhookRepository)(nil)
+	_ RetentionRepositoryInterface      = (*RetentionRepository)(nil)
+	_ OffboardingRepositoryInterface    = (*OffboardingRepository)(nil)
+	_ SSORepositoryInterface            = (*SSORepository)(nil)
+	_ LDAPRepositoryInterface           = (*LDAPRepository)(nil)
+	_ SCIMRepositoryInterface           = (*SCIMRepository)(nil)
+	_ ExportJobRepositoryInterface      = (*ExportJobRepository)(nil)
+	_ AssetReportRepositoryInterface    = (*AssetReportRepository)(nil)
+	_ NotificationRepositoryInterface   = (*NotificationRepository)(nil)
+	_ LoginAttemptRepositoryInterface   = (*LoginAttemptRepository)(nil)
+	_ DeviceRepositoryInterface         = (*DeviceRepository)(nil)
+	_ UserPreferenceRepositoryInterface = (*UserPreferenceRepository)(nil)
+	_ MentionRepositoryInterface        = (*MentionRepository)(nil)
+	_ IdempotencyRepositoryInterface    = (*IdempotencyRepository)(nil)
+	_ OutboxRepositoryInterface         = (*OutboxRepository)(nil)
+	_ QuotaRepositoryInterface          = (*QuotaRepository)(nil)
+	_ StatsRepositoryInterface          = (*StatsRepository)(nil)
+	_ FeatureFlagRepositoryInterface    = (*FeatureFlagRepository)(nil)
+	_ AnnouncementRepositoryInterface   = (*AnnouncementRepository)(nil)
+	_ ReminderRepositoryInterface       = (*ReminderRepository)(nil)
+	_ ChecklistRepositoryInterface      = (*ChecklistRepository)(nil)
+	_ AccessRequestRepositoryInterface  = (*AccessRequestRepository)(nil)
+)
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// LDAPRepository stores the LDAP connections of organizations and the
+// user changes directory syncs make
+type LDAPRepository struct {
+	db *gorm.DB
+}
+
+func NewLDAPRepository(db *gorm.DB) *LDAPRepository {
+	return &LDAPRepository{db: db}
+}
+
+// GetConnection returns the LDAP connection of orgID
+func (r *LDAPRepository) GetConnection(ctx context.Context, orgID uuid.UUID) (*models.LDAPConnection, error) {
+	var conn models.LDAPConnection
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).First(&conn).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("LDAP connection not found")
+		}
+		return nil, err
+	}
+	return &conn, nil
+}
+
+// GetEnabledConnections returns the connections scheduled syncs run for
+func (r *LDAPRepository) GetEnabledConnections(ctx context.Context) ([]models.LDAPConnection, error) {
+	var conns []models.LDAPConnection
+	err := r.db.WithContext(ctx).Where("enabled = ?", true).Order("created_at").Find(&conns).Error
+	return conns, err
+}
+
+// SaveConnection creates the LDAP connection of its organization or
+// replaces the existing one, keeping the outcome of its last sync
+func (r *LDAPRepository) SaveConnection(ctx context.Context, conn *models.LDAPConnection) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []models.LDAPConnection
+		if err := tx.Where("organization_id = ?", conn.OrganizationID).Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			return tx.Create(conn).Error
+		}
+		conn.ID = existing[0].ID
+		conn.CreatedAt = existing[0].CreatedAt
+		conn.LastSyncedAt = existing[0].LastSyncedAt
+		conn.LastSyncError = existing[0].LastSyncError
+		return tx.Save(conn).Error
+	})
+}
+
+// RecordSync stores when the connection was last synced and why that sync
+// failed, or an empty syncErr when it did not
+func (r *LDAPRepository) RecordSync(ctx context.Context, connID uuid.UUID, at time.Time, syncErr string) error {
+	return r.db.WithContext(ctx).Model(&models.LDAPConnection{}).Where("id = ?", connID).
+		Updates(map[string]interface{}{"last_synced_at": at, "last_sync_error": syncErr}).Error
+}
+
+// GetLinkedUsers returns the users of orgID linked to directory entries,
+// including deactivated ones
+func (r *LDAPRepository) GetLinkedUsers(ctx context.Context, orgID uuid.UUID) ([]models.LDAPUser, error) {
+	var links []models.LDAPUser
+	err := r.db.WithContext(ctx).Preload("User", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).Where("organization_id = ?", orgID).Find(&links).Error
+	return links, err
+}
+
+// GetUserByEmail returns the user with email, including a deleted one
+func (r *LDAPRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Unscoped().Where("email = ?", email).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LinkUser links a user to a directory entry, replacing any link it had
+func (r *LDAPRepository) LinkUser(ctx context.Context, link *models.LDAPUser) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"organization_id", "external_id", "updated_at"}),
+	}).Create(link).Error
+}
+
+// UpdateUser changes the email and role of userID
+func (r *LDAPRepository) UpdateUser(ctx context.Context, userID uuid.UUID, email string, role models.UserRole) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"email": email, "role": role})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("user not found")
+	}
+	return nil
+}
+
+// DeactivateUser soft deletes userID, so they can no longer sign in, and
+// revokes their devices, so the tokens they hold are rejected as well
+func (r *LDAPRepository) DeactivateUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", userID).Delete(&models.User{}).Error; err != nil {
+			return err
+		}
+		return revokeUserDevices(tx, userID, time.Now())
+	})
+}
+
+// RestoreUser undoes the soft deletion of userID
+func (r *LDAPRepository) RestoreUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("id = ?", userID).
+		Update("deleted_at", nil).Error
+}
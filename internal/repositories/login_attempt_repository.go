@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+type LoginAttemptRepository struct {
+	Repository[models.LoginAttempt]
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{Repository: NewRepository[models.LoginAttempt](db, apperrors.NotFound("login attempt not found"))}
+}
+
+// GetByUsers returns the latest attempts to log in as any of userIDs,
+// newest first
+func (r *LoginAttemptRepository) GetByUsers(ctx context.Context, userIDs []uuid.UUID, limit int) ([]models.LoginAttempt, error) {
+	if len(userIDs) == 0 {
+		return []models.LoginAttempt{}, nil
+	}
+	var attempts []models.LoginAttempt
+	err := r.db.WithContext(ctx).
+		Where("user_id IN ?", userIDs).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&attempts).Error
+	return attempts, err
+}
+
+// CountFailures counts the failed attempts to log in from ip after since
+func (r *LoginAttemptRepository) CountFailures(ctx context.Context, ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.LoginAttempt{}).
+		Where("ip = ? AND outcome <> ? AND created_at > ?", ip, models.LoginSucceeded, since).
+		Count(&count).Error
+	return count, err
+}
+
+// PriorLogins reports whether userID has logged in successfully before, and
+// whether they did with userAgent
+func (r *LoginAttemptRepository) PriorLogins(ctx context.Context, userID uuid.UUID, userAgent string) (loggedIn, fromDevice bool, err error) {
+	var found []struct{ SameDevice bool }
+	err = r.db.WithContext(ctx).Model(&models.LoginAttempt{}).
+		Select("user_agent = ? AS same_device", userAgent).
+		Where("user_id = ? AND outcome = ?", userID, models.LoginSucceeded).
+		Order("same_device DESC").
+		Limit(1).
+		Find(&found).Error
+	if err != nil || len(found) == 0 {
+		return false, false, err
+	}
+	return true, found[0].SameDevice, nil
+}
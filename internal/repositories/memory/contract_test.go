@@ -0,0 +1,19 @@
+package memory_test
+
+import (
+	"testing"
+
+	"seta-training/internal/repositories/contract"
+	"seta-training/internal/repositories/memory"
+)
+
+func TestContract(t *testing.T) {
+	contract.Run(t, func(t *testing.T) contract.Repositories {
+		store := memory.NewStore()
+		return contract.Repositories{
+			Users:         memory.NewUserRepository(store),
+			Organizations: memory.NewOrganizationRepository(store),
+			Teams:         memory.NewTeamRepository(store),
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+type OrganizationRepository struct {
+	store *Store
+}
+
+func NewOrganizationRepository(store *Store) *OrganizationRepository {
+	return &OrganizationRepository{store: store}
+}
+
+// Create inserts org, rejecting a slug already taken with
+// gorm.ErrDuplicatedKey
+func (r *OrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.organizations {
+		if existing.ID == org.ID || existing.Slug == org.Slug {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	stamp(&org.ID, &org.CreatedAt, &org.UpdatedAt)
+	r.store.organizations[org.ID] = *org
+	return nil
+}
+
+func (r *OrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	org, ok := r.store.organizations[id]
+	if !ok {
+		return nil, apperrors.NotFound("organization not found")
+	}
+	return &org, nil
+}
+
+func (r *OrganizationRepository) GetAll(ctx context.Context) ([]models.Organization, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	orgs := []models.Organization{}
+	for _, org := range r.store.organizations {
+		orgs = append(orgs, org)
+	}
+	sort.Slice(orgs, func(i, j int) bool {
+		return orgs[i].Name < orgs[j].Name
+	})
+	return orgs, nil
+}
+
+func (r *OrganizationRepository) SlugExists(ctx context.Context, slug string) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, org := range r.store.organizations {
+		if org.Slug == slug {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// AssignUser moves the user into org. The store holds no folders or notes
+// to move with them.
+func (r *OrganizationRepository) AssignUser(ctx context.Context, orgID, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[userID]
+	if !ok {
+		return apperrors.NotFound("user not found")
+	}
+	user.OrganizationID = &orgID
+	r.store.users[userID] = user
+	return nil
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
+)
+
+type TeamRepository struct {
+	store *Store
+}
+
+func NewTeamRepository(store *Store) *TeamRepository {
+	return &TeamRepository{store: store}
+}
+
+func (r *TeamRepository) Create(ctx context.Context, team *models.Team) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.teams[team.ID]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	stamp(&team.ID, &team.CreatedAt, &team.UpdatedAt)
+	stored := *team
+	stored.Memberships = nil
+	stored.Admins, stored.Managers, stored.Members, stored.Viewers = nil, nil, nil, nil
+	r.store.teams[team.ID] = stored
+	return nil
+}
+
+// GetByID returns the team with its people
+func (r *TeamRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Team, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	team, ok := r.store.teams[id]
+	if !ok {
+		return nil, apperrors.NotFound("team not found")
+	}
+	team = r.store.withPeople(team)
+	return &team, nil
+}
+
+// GetAll returns the teams of orgID, or of the default tenant when nil
+func (r *TeamRepository) GetAll(ctx context.Context, orgID *uuid.UUID) ([]models.Team, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.teamsIn(orgID), nil
+}
+
+// ListPage returns one page of the teams of orgID, or of the default tenant
+// when nil
+func (r *TeamRepository) ListPage(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.Team], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return listAfter(r.store.teamsIn(orgID), p, func(t models.Team) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: t.CreatedAt, ID: t.ID}
+	}), nil
+}
+
+// AddMembership adds userID to the team with role. Like the join table's
+// keys, it rejects missing teams and users already in the team; users of
+// another organization are reported as not found.
+func (r *TeamRepository) AddMembership(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	team, ok := r.store.teams[teamID]
+	if !ok {
+		return gorm.ErrForeignKeyViolated
+	}
+	user, ok := r.store.users[userID]
+	if !ok || !models.SameOrganization(user.OrganizationID, team.OrganizationID) {
+		return apperrors.NotFound("user not found")
+	}
+	key := membership{teamID: teamID, userID: userID}
+	if _, ok := r.store.memberships[key]; ok {
+		return gorm.ErrDuplicatedKey
+	}
+	r.store.memberships[key] = role
+	return nil
+}
+
+func (r *TeamRepository) SetRole(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := membership{teamID: teamID, userID: userID}
+	if _, ok := r.store.memberships[key]; !ok {
+		return apperrors.NotFound("user is not in the team")
+	}
+	r.store.memberships[key] = role
+	return nil
+}
+
+func (r *TeamRepository) RemoveMembership(ctx context.Context, teamID, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	delete(r.store.memberships, membership{teamID: teamID, userID: userID})
+	return nil
+}
+
+func (r *TeamRepository) GetRole(ctx context.Context, teamID, userID uuid.UUID) (models.TeamRole, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.memberships[membership{teamID: teamID, userID: userID}], nil
+}
+
+// GetUserTeamRoles returns the role the user holds in each of their teams
+func (r *TeamRepository) GetUserTeamRoles(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]models.TeamRole, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	roles := make(map[uuid.UUID]models.TeamRole)
+	for m, role := range r.store.memberships {
+		if m.userID == userID {
+			roles[m.teamID] = role
+		}
+	}
+	return roles, nil
+}
+
+// GetUserTeams returns the teams userID is in, whatever their role, without
+// their people, ordered like the GORM repository
+func (r *TeamRepository) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]models.Team, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	teams := []models.Team{}
+	for id, team := range r.store.teams {
+		if _, ok := r.store.memberships[membership{teamID: id, userID: userID}]; ok {
+			teams = append(teams, team)
+		}
+	}
+	sort.Slice(teams, func(i, j int) bool {
+		return before(pagination.Cursor{CreatedAt: teams[i].CreatedAt, ID: teams[i].ID}, pagination.Cursor{CreatedAt: teams[j].CreatedAt, ID: teams[j].ID})
+	})
+	return teams, nil
+}
+
+func (r *TeamRepository) UpdateSettings(ctx context.Context, teamID uuid.UUID, settings models.TeamSettings) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	team, ok := r.store.teams[teamID]
+	if !ok {
+		return apperrors.NotFound("team not found")
+	}
+	team.Settings = settings
+	r.store.teams[teamID] = team
+	return nil
+}
+
+// teamsIn returns the teams of orgID with their people; the caller holds the
+// lock
+func (s *Store) teamsIn(orgID *uuid.UUID) []models.Team {
+	teams := []models.Team{}
+	for _, team := range s.teams {
+		if models.SameOrganization(team.OrganizationID, orgID) {
+			teams = append(teams, s.withPeople(team))
+		}
+	}
+	return teams
+}
+
+// withPeople fills in the Memberships of team, and its people by role; the
+// caller holds the lock
+func (s *Store) withPeople(team models.Team) models.Team {
+	team.Memberships = nil
+	for m, role := range s.memberships {
+		if user, ok := s.users[m.userID]; ok && m.teamID == team.ID {
+			team.Memberships = append(team.Memberships, models.TeamMembership{TeamID: team.ID, UserID: user.ID, Role: role, User: user})
+		}
+	}
+	sort.Slice(team.Memberships, func(i, j int) bool {
+		return team.Memberships[i].UserID.String() < team.Memberships[j].UserID.String()
+	})
+	team.GroupMemberships()
+	return team
+}
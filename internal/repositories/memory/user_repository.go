@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
+)
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store}
+}
+
+// Create inserts user, rejecting a username or email already taken with
+// gorm.ErrDuplicatedKey
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.users {
+		if existing.ID == user.ID || existing.Username == user.Username || existing.Email == user.Email {
+			return gorm.ErrDuplicatedKey
+		}
+	}
+	stamp(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	r.store.users[user.ID] = row(*user)
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, apperrors.NotFound("user not found")
+	}
+	return &user, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, apperrors.NotFound("user not found")
+}
+
+// GetAll returns the users of orgID, or of the default tenant when nil
+func (r *UserRepository) GetAll(ctx context.Context, orgID *uuid.UUID) ([]models.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return r.store.usersIn(orgID), nil
+}
+
+// ListPage returns one page of the users of orgID, or of the default tenant
+// when nil
+func (r *UserRepository) ListPage(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.User], error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+	return listAfter(r.store.usersIn(orgID), p, func(u models.User) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+	}), nil
+}
+
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+	return err == nil, nil
+}
+
+func (r *UserRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if user.Username == username {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// usersIn returns the users of orgID; the caller holds the lock
+func (s *Store) usersIn(orgID *uuid.UUID) []models.User {
+	users := []models.User{}
+	for _, user := range s.users {
+		if models.SameOrganization(user.OrganizationID, orgID) {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// row drops the associations of user, which live in their own tables
+func row(user models.User) models.User {
+	user.OwnedFolders = nil
+	user.OwnedNotes = nil
+	user.TeamMemberships = nil
+	user.SharedFolders = nil
+	user.SharedNotes = nil
+	return user
+}
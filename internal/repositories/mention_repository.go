@@ -0,0 +1,103 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/models"
+)
+
+type MentionRepository struct {
+	db    *gorm.DB
+	codec *NoteBodyCodec
+}
+
+// NewMentionRepository creates a mention repository decoding the bodies of
+// the notes it loads with codec
+func NewMentionRepository(db *gorm.DB, codec *NoteBodyCodec) *MentionRepository {
+	return &MentionRepository{db: db, codec: codec}
+}
+
+// ResolveVisibleUsers returns the users named in usernames that share a team
+// with authorID, whatever their roles. The author is never included.
+func (r *MentionRepository) ResolveVisibleUsers(ctx context.Context, authorID uuid.UUID, usernames []string) ([]models.User, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	authorTeams := r.db.Raw("SELECT team_id FROM team_memberships WHERE user_id = ?", authorID)
+	teamUsers := r.db.Raw("SELECT user_id FROM team_memberships WHERE team_id IN (?)", authorTeams)
+
+	var users []models.User
+	err := r.db.WithContext(ctx).Where("username IN ? AND id <> ? AND id IN (?)", usernames, authorID, teamUsers).
+		Find(&users).Error
+	return users, err
+}
+
+// ReplaceNoteMentions makes userIDs the full set of users mentioned in a
+// note and returns the ones that were not mentioned before
+func (r *MentionRepository) ReplaceNoteMentions(ctx context.Context, noteID, authorID uuid.UUID, userIDs []uuid.UUID) ([]uuid.UUID, error) {
+	var added []uuid.UUID
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []uuid.UUID
+		if err := tx.Model(&models.Mention{}).Where("note_id = ?", noteID).
+			Pluck("mentioned_user_id", &existing).Error; err != nil {
+			return err
+		}
+
+		keep := make(map[uuid.UUID]bool, len(userIDs))
+		for _, id := range userIDs {
+			keep[id] = true
+		}
+		seen := make(map[uuid.UUID]bool, len(existing))
+		var removed []uuid.UUID
+		for _, id := range existing {
+			seen[id] = true
+			if !keep[id] {
+				removed = append(removed, id)
+			}
+		}
+
+		if len(removed) > 0 {
+			if err := tx.Where("note_id = ? AND mentioned_user_id IN ?", noteID, removed).
+				Delete(&models.Mention{}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, id := range userIDs {
+			if seen[id] {
+				continue
+			}
+			mention := &models.Mention{NoteID: noteID, MentionedUserID: id, AuthorID: authorID}
+			if err := tx.Create(mention).Error; err != nil {
+				return err
+			}
+			added = append(added, id)
+		}
+		return nil
+	})
+	return added, err
+}
+
+// GetByMentionedUser returns the latest mentions of userID in notes that
+// still exist, newest first
+func (r *MentionRepository) GetByMentionedUser(ctx context.Context, userID uuid.UUID, limit int) ([]models.Mention, error) {
+	var mentions []models.Mention
+	err := r.db.WithContext(ctx).Joins("JOIN notes ON notes.id = mentions.note_id AND notes.deleted_at IS NULL").
+		Where("mentions.mentioned_user_id = ?", userID).
+		Preload("Note").Preload("Author").Preload("MentionedUser").
+		Order("mentions.created_at DESC").Limit(limit).
+		Find(&mentions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range mentions {
+		if err := r.codec.decode(&mentions[i].Note); err != nil {
+			return nil, err
+		}
+	}
+	return mentions, nil
+}
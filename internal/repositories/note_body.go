@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"seta-training/internal/models"
+	"seta-training/pkg/compression"
+	"seta-training/pkg/crypto"
+	"seta-training/pkg/metrics"
+)
+
+// NoteBodyCodec turns note bodies into their stored form and back. Bodies
+// are compressed when the compressor decides it is worth it, then sealed
+// when an envelope is configured. Stored bodies carry their algorithm and
+// wrapped data key, so rows written under another configuration still
+// decode. A nil codec stores bodies as-is and reads compressed ones.
+type NoteBodyCodec struct {
+	compressor *compression.Compressor
+	envelope   *crypto.Envelope
+	metrics    *metrics.Metrics
+}
+
+// NewNoteBodyCodec creates a codec. compressor and envelope may be nil to
+// skip compression or encryption; compression ratios are recorded on m when
+// it is non-nil.
+func NewNoteBodyCodec(compressor *compression.Compressor, envelope *crypto.Envelope, m *metrics.Metrics) *NoteBodyCodec {
+	return &NoteBodyCodec{compressor: compressor, envelope: envelope, metrics: m}
+}
+
+// encode moves note.Body into its stored form. It always resets the storage
+// fields so that a note shrinking below the threshold, or written after
+// encryption is turned off, is stored as plain text. Encrypted notes need
+// their ID, which is assigned here if missing.
+func (c *NoteBodyCodec) encode(note *models.Note) error {
+	note.BodyEncoding = ""
+	note.CompressedBody = nil
+	note.BodyKeyID = ""
+	note.BodyDataKey = nil
+	if c == nil {
+		return nil
+	}
+
+	data, algorithm, err := c.compressor.Compress([]byte(note.Body))
+	if err != nil {
+		return err
+	}
+	if algorithm != compression.AlgorithmNone {
+		if c.metrics != nil {
+			c.metrics.RecordNoteCompression(string(algorithm), len(note.Body), len(data))
+		}
+		note.BodyEncoding = string(algorithm)
+		note.CompressedBody = data
+		note.Body = ""
+	}
+
+	if c.envelope == nil {
+		return nil
+	}
+	if data == nil {
+		data = []byte(note.Body)
+	}
+	if note.ID == uuid.Nil {
+		note.ID = uuid.New()
+	}
+	sealed, err := c.envelope.Seal(data, note.ID[:])
+	if err != nil {
+		return fmt.Errorf("failed to encrypt body of note %s: %w", note.ID, err)
+	}
+	note.CompressedBody = sealed.Ciphertext
+	note.BodyKeyID = sealed.KeyID
+	note.BodyDataKey = sealed.WrappedKey
+	note.Body = ""
+	return nil
+}
+
+// decode restores note.Body from its stored form if needed
+func (c *NoteBodyCodec) decode(note *models.Note) error {
+	data := note.CompressedBody
+	if note.BodyDataKey != nil {
+		if c == nil || c.envelope == nil {
+			return fmt.Errorf("body of note %s is encrypted: %w", note.ID, errNoNoteEncryption)
+		}
+		plaintext, err := c.envelope.Open(crypto.Sealed{
+			KeyID:      note.BodyKeyID,
+			WrappedKey: note.BodyDataKey,
+			Ciphertext: note.CompressedBody,
+		}, note.ID[:])
+		if err != nil {
+			return fmt.Errorf("failed to decrypt body of note %s: %w", note.ID, err)
+		}
+		data = plaintext
+	} else if note.BodyEncoding == "" {
+		return nil
+	}
+
+	data, err := compression.Decompress(data, compression.Algorithm(note.BodyEncoding))
+	if err != nil {
+		return fmt.Errorf("failed to decode body of note %s: %w", note.ID, err)
+	}
+
+	note.Body = string(data)
+	note.BodyEncoding = ""
+	note.CompressedBody = nil
+	note.BodyKeyID = ""
+	note.BodyDataKey = nil
+	return nil
+}
+
+// reseal seals the stored body of note, copied from the note with ID from,
+// under note's own ID. Bodies are sealed with their note's ID as additional
+// data, so a copied ciphertext would not open otherwise.
+func (c *NoteBodyCodec) reseal(note *models.Note, from uuid.UUID) error {
+	if note.BodyDataKey == nil {
+		return nil
+	}
+	if c == nil || c.envelope == nil {
+		return fmt.Errorf("body of note %s is encrypted: %w", from, errNoNoteEncryption)
+	}
+	data, err := c.envelope.Open(crypto.Sealed{
+		KeyID:      note.BodyKeyID,
+		WrappedKey: note.BodyDataKey,
+		Ciphertext: note.CompressedBody,
+	}, from[:])
+	if err != nil {
+		return fmt.Errorf("failed to decrypt body of note %s: %w", from, err)
+	}
+	sealed, err := c.envelope.Seal(data, note.ID[:])
+	if err != nil {
+		return fmt.Errorf("failed to encrypt body of note %s: %w", note.ID, err)
+	}
+	note.CompressedBody = sealed.Ciphertext
+	note.BodyKeyID = sealed.KeyID
+	note.BodyDataKey = sealed.WrappedKey
+	return nil
+}
+
+// errNoNoteEncryption is returned for encrypted notes read without a key
+// source configured
+var errNoNoteEncryption = errors.New("note encryption is not configured")
+
+func (c *NoteBodyCodec) decodeAll(notes []models.Note) error {
+	for i := range notes {
+		if err := c.decode(&notes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *NoteBodyCodec) decodeFolderNotes(folders []models.Folder) error {
+	for i := range folders {
+		if err := c.decodeAll(folders[i].Notes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
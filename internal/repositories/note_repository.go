@@ -1,82 +1,314 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
 	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
 )
 
 type NoteRepository struct {
-	db *gorm.DB
+	Repository[models.Note]
+	codec *NoteBodyCodec
 }
 
-func NewNoteRepository(db *gorm.DB) *NoteRepository {
-	return &NoteRepository{db: db}
+// NewNoteRepository creates a note repository. Bodies are encoded with
+// codec before they are written and decoded when read; a nil codec stores
+// them as-is.
+func NewNoteRepository(db *gorm.DB, codec *NoteBodyCodec) *NoteRepository {
+	return &NoteRepository{Repository: NewRepository[models.Note](db, apperrors.NotFound("note not found")), codec: codec}
 }
 
-func (r *NoteRepository) Create(note *models.Note) error {
-	return r.db.Create(note).Error
+// withDB returns a copy of r that runs its queries on db
+func (r *NoteRepository) withDB(db *gorm.DB) *NoteRepository {
+	return NewNoteRepository(db, r.codec)
 }
 
-func (r *NoteRepository) GetByID(id uuid.UUID) (*models.Note, error) {
+// Create inserts note and a note.created outbox event in one transaction
+func (r *NoteRepository) Create(ctx context.Context, note *models.Note) error {
+	return r.write(note, func(n *models.Note) error {
+		return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(n).Error; err != nil {
+				return err
+			}
+			return enqueueEvent(tx, models.EventNoteCreated, n.ID, models.NoteCreatedEvent{
+				NoteID:   n.ID,
+				FolderID: n.FolderID,
+				OwnerID:  n.OwnerID,
+			})
+		})
+	})
+}
+
+// write stores note through fn with its body encoded, then restores the
+// plain body on the caller's struct
+func (r *NoteRepository) write(note *models.Note, fn func(*models.Note) error) error {
+	body := note.Body
+	if err := r.codec.encode(note); err != nil {
+		return err
+	}
+	err := fn(note)
+	note.Body = body
+	return err
+}
+
+func (r *NoteRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Note, error) {
 	var note models.Note
-	err := r.db.Preload("Owner").Preload("Folder").Preload("Shares.User").Where("id = ?", id).First(&note).Error
+	err := r.db.WithContext(ctx).Preload("Owner").Preload("Folder").Preload("Shares.User").Where("id = ?", id).First(&note).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("note not found")
+			return nil, apperrors.NotFound("note not found")
 		}
 		return nil, err
 	}
+	if err := r.codec.decode(&note); err != nil {
+		return nil, err
+	}
 	return &note, nil
 }
 
-func (r *NoteRepository) GetByFolder(folderID uuid.UUID) ([]models.Note, error) {
+func (r *NoteRepository) GetByFolder(ctx context.Context, folderID uuid.UUID) ([]models.Note, error) {
 	var notes []models.Note
-	err := r.db.Where("folder_id = ?", folderID).Preload("Owner").Find(&notes).Error
-	return notes, err
+	if err := r.db.WithContext(ctx).Where("folder_id = ?", folderID).Preload("Owner").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, r.listed(ctx, notes)
 }
 
-func (r *NoteRepository) GetByOwner(ownerID uuid.UUID) ([]models.Note, error) {
+func (r *NoteRepository) GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Note, error) {
 	var notes []models.Note
-	err := r.db.Where("owner_id = ?", ownerID).Preload("Folder").Find(&notes).Error
-	return notes, err
+	if err := database.ReadReplica(r.db.WithContext(ctx)).Where("owner_id = ?", ownerID).Preload("Folder").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, r.listed(ctx, notes)
+}
+
+// ListByOwner returns one page of the notes ownerID owns that match filter
+func (r *NoteRepository) ListByOwner(ctx context.Context, ownerID uuid.UUID, filter models.NoteFilter, p pagination.Params) (pagination.Page[models.Note], error) {
+	page, err := r.ListAfter(ctx, p, func(n models.Note) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: n.CreatedAt, ID: n.ID}
+	}, func(db *gorm.DB) *gorm.DB {
+		db = db.Where("owner_id = ?", ownerID).Preload("Folder")
+		if len(filter.Statuses) > 0 {
+			db = db.Where("status IN ?", filter.Statuses)
+		}
+		return db
+	})
+	if err != nil {
+		return page, err
+	}
+	return page, r.listed(ctx, page.Items)
+}
+
+// listed decodes the bodies of a listing and fills in checklist counts
+func (r *NoteRepository) listed(ctx context.Context, notes []models.Note) error {
+	if err := r.codec.decodeAll(notes); err != nil {
+		return err
+	}
+	return attachChecklists(database.ReadReplica(r.db.WithContext(ctx)), notes)
+}
+
+// Update saves note and a note.updated outbox event in one transaction
+func (r *NoteRepository) Update(ctx context.Context, note *models.Note) error {
+	return r.write(note, func(n *models.Note) error {
+		return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(n).Error; err != nil {
+				return err
+			}
+			return enqueueEvent(tx, models.EventNoteUpdated, n.ID, models.NoteEvent{NoteID: n.ID})
+		})
+	})
+}
+
+// Delete soft-deletes the note and writes a note.deleted outbox event in one
+// transaction
+func (r *NoteRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Note{}, id).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventNoteDeleted, id, models.NoteEvent{NoteID: id})
+	})
+}
+
+// ChangeStatus moves a note from one status to another and writes a
+// note.status_changed outbox event in one transaction. It fails with a
+// conflict if the note's status is no longer from.
+func (r *NoteRepository) ChangeStatus(ctx context.Context, noteID uuid.UUID, from, to models.NoteStatus, changedBy uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Note{}).Where("id = ? AND status = ?", noteID, from).Update("status", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.Conflict("the note's status was changed by someone else")
+		}
+
+		var note models.Note
+		if err := tx.Select("owner_id").Where("id = ?", noteID).First(&note).Error; err != nil {
+			return err
+		}
+		var sharedWith []uuid.UUID
+		if err := tx.Model(&models.NoteShare{}).Where("note_id = ?", noteID).Pluck("user_id", &sharedWith).Error; err != nil {
+			return err
+		}
+		recipients := []uuid.UUID{}
+		for _, userID := range append([]uuid.UUID{note.OwnerID}, sharedWith...) {
+			if userID != changedBy {
+				recipients = append(recipients, userID)
+			}
+		}
+		return enqueueEvent(tx, models.EventNoteStatusChanged, noteID, models.NoteStatusChangedEvent{
+			NoteID:    noteID,
+			From:      from,
+			To:        to,
+			ChangedBy: changedBy,
+			UserIDs:   recipients,
+		})
+	})
 }
 
-func (r *NoteRepository) Update(note *models.Note) error {
-	return r.db.Save(note).Error
+// ReplaceLinks stores the notes sourceID links to, replacing its previous
+// links. Links to itself and to notes that do not exist are dropped.
+func (r *NoteRepository) ReplaceLinks(ctx context.Context, sourceID uuid.UUID, targetIDs []uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("source_id = ?", sourceID).Delete(&models.NoteLink{}).Error; err != nil {
+			return err
+		}
+		if len(targetIDs) == 0 {
+			return nil
+		}
+
+		var existing []uuid.UUID
+		if err := tx.Model(&models.Note{}).Where("id IN ? AND id <> ?", targetIDs, sourceID).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			return nil
+		}
+		links := make([]models.NoteLink, len(existing))
+		for i, targetID := range existing {
+			links[i] = models.NoteLink{SourceID: sourceID, TargetID: targetID}
+		}
+		return tx.Create(&links).Error
+	})
 }
 
-func (r *NoteRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Note{}, id).Error
+// GetBacklinks returns the notes linking to targetID that userID owns or
+// has been shared, most recently updated first
+func (r *NoteRepository) GetBacklinks(ctx context.Context, targetID, userID uuid.UUID) ([]models.Backlink, error) {
+	var backlinks []models.Backlink
+	err := database.ReadReplica(r.db.WithContext(ctx)).Model(&models.Note{}).
+		Select("notes.id AS note_id, notes.title, notes.folder_id, notes.owner_id, notes.updated_at").
+		Joins("JOIN note_links ON note_links.source_id = notes.id").
+		Where("note_links.target_id = ?", targetID).
+		Where("notes.owner_id = ? OR notes.id IN (?)", userID,
+			r.db.Model(&models.NoteShare{}).Select("note_id").Where("user_id = ?", userID)).
+		Order("notes.updated_at DESC").
+		Scan(&backlinks).Error
+	return backlinks, err
 }
 
-func (r *NoteRepository) ShareNote(noteID, userID uuid.UUID, access models.AccessLevel) error {
+// ShareNote inserts the share and a note.shared outbox event in one
+// transaction. Users of another organization are reported as not found.
+func (r *NoteRepository) ShareNote(ctx context.Context, noteID, userID uuid.UUID, access models.AccessLevel) error {
 	share := &models.NoteShare{
 		NoteID: noteID,
 		UserID: userID,
 		Access: access,
 	}
-	return r.db.Create(share).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSameOrganization(tx, &models.Note{}, noteID, userID); err != nil {
+			return err
+		}
+		if err := tx.Create(share).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventNoteShared, noteID, models.NoteSharedEvent{
+			NoteID: noteID,
+			UserID: userID,
+			Access: access,
+		})
+	})
 }
 
-func (r *NoteRepository) RevokeShare(noteID, userID uuid.UUID) error {
-	return r.db.Where("note_id = ? AND user_id = ?", noteID, userID).Delete(&models.NoteShare{}).Error
+// ListShares returns one page of the shares of a note
+func (r *NoteRepository) ListShares(ctx context.Context, noteID uuid.UUID, p pagination.Params) (pagination.Page[models.NoteShare], error) {
+	return listAfter(r.db.WithContext(ctx), p, func(s models.NoteShare) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: s.CreatedAt, ID: s.ID}
+	}, func(db *gorm.DB) *gorm.DB {
+		return db.Where("note_id = ?", noteID).Preload("User")
+	})
 }
 
-func (r *NoteRepository) GetSharedNotes(userID uuid.UUID) ([]models.Note, error) {
+// RevokeShare deletes the share and writes a note.unshared outbox event in
+// one transaction
+func (r *NoteRepository) RevokeShare(ctx context.Context, noteID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("note_id = ? AND user_id = ?", noteID, userID).Delete(&models.NoteShare{}).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventNoteUnshared, noteID, models.NoteUnsharedEvent{
+			NoteID: noteID,
+			UserID: userID,
+		})
+	})
+}
+
+func (r *NoteRepository) GetSharedNotes(ctx context.Context, userID uuid.UUID) ([]models.Note, error) {
 	var notes []models.Note
-	err := r.db.Joins("JOIN note_shares ON notes.id = note_shares.note_id").
+	err := database.ReadReplica(r.db.WithContext(ctx)).Joins("JOIN note_shares ON notes.id = note_shares.note_id").
 		Where("note_shares.user_id = ?", userID).
 		Preload("Owner").Preload("Folder").Preload("Shares.User").
 		Find(&notes).Error
-	return notes, err
+	if err != nil {
+		return nil, err
+	}
+	return notes, r.listed(ctx, notes)
+}
+
+// GetNotesByOwners returns the notes owned by any of ownerIDs in one query,
+// rather than one query per owner
+func (r *NoteRepository) GetNotesByOwners(ctx context.Context, ownerIDs []uuid.UUID) ([]models.Note, error) {
+	var notes []models.Note
+	if len(ownerIDs) == 0 {
+		return notes, nil
+	}
+	if err := database.ReadReplica(r.db.WithContext(ctx)).Where("owner_id IN ?", ownerIDs).Preload("Folder").Order("created_at").Find(&notes).Error; err != nil {
+		return nil, err
+	}
+	return notes, r.listed(ctx, notes)
+}
+
+// GetSharesByUsers returns the note shares granted to any of userIDs, each
+// with its note
+func (r *NoteRepository) GetSharesByUsers(ctx context.Context, userIDs []uuid.UUID) ([]models.NoteShare, error) {
+	var shares []models.NoteShare
+	if len(userIDs) == 0 {
+		return shares, nil
+	}
+	err := database.ReadReplica(r.db.WithContext(ctx)).Where("user_id IN ?", userIDs).
+		Preload("Note.Owner").Preload("Note.Folder").
+		Order("created_at").Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range shares {
+		if err := r.codec.decode(&shares[i].Note); err != nil {
+			return nil, err
+		}
+	}
+	return shares, nil
 }
 
-func (r *NoteRepository) GetUserAccess(noteID, userID uuid.UUID) (*models.NoteShare, error) {
+func (r *NoteRepository) GetUserAccess(ctx context.Context, noteID, userID uuid.UUID) (*models.NoteShare, error) {
 	var share models.NoteShare
-	err := r.db.Where("note_id = ? AND user_id = ?", noteID, userID).First(&share).Error
+	err := r.db.WithContext(ctx).Where("note_id = ? AND user_id = ?", noteID, userID).First(&share).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -86,16 +318,16 @@ func (r *NoteRepository) GetUserAccess(noteID, userID uuid.UUID) (*models.NoteSh
 	return &share, nil
 }
 
-func (r *NoteRepository) HasAccess(noteID, userID uuid.UUID) (bool, models.AccessLevel, error) {
+func (r *NoteRepository) HasAccess(ctx context.Context, noteID, userID uuid.UUID) (bool, models.AccessLevel, error) {
 	// Check if user is owner
 	var note models.Note
-	err := r.db.Where("id = ? AND owner_id = ?", noteID, userID).First(&note).Error
+	err := r.db.WithContext(ctx).Where("id = ? AND owner_id = ?", noteID, userID).First(&note).Error
 	if err == nil {
 		return true, models.AccessWrite, nil
 	}
 
 	// Check if user has shared access
-	share, err := r.GetUserAccess(noteID, userID)
+	share, err := r.GetUserAccess(ctx, noteID, userID)
 	if err != nil {
 		return false, "", err
 	}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// OffboardingRepository hands the folders and notes of a departing user
+// over to another user
+type OffboardingRepository struct {
+	db *gorm.DB
+}
+
+func NewOffboardingRepository(db *gorm.DB) *OffboardingRepository {
+	return &OffboardingRepository{db: db}
+}
+
+// GetUser returns a user, including one that was already deleted
+func (r *OffboardingRepository) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Assets returns the folders and notes userID owns, without note bodies
+func (r *OffboardingRepository) Assets(ctx context.Context, userID uuid.UUID) ([]models.Folder, []models.Note, error) {
+	return ownedAssets(r.db.WithContext(ctx), userID)
+}
+
+// TransferAssets makes toID the owner of every folder and note fromID owns
+// and returns them. Shares of those folders and notes with toID are dropped,
+// since owners need none. With archive, the notes are also moved to the
+// archived status. folder.updated and note.updated outbox events are written
+// in the same transaction.
+func (r *OffboardingRepository) TransferAssets(ctx context.Context, fromID, toID uuid.UUID, archive bool) ([]models.Folder, []models.Note, error) {
+	var folders []models.Folder
+	var notes []models.Note
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		folders, notes, err = ownedAssets(tx, fromID)
+		if err != nil {
+			return err
+		}
+
+		if len(folders) > 0 {
+			ids := make([]uuid.UUID, len(folders))
+			for i, folder := range folders {
+				ids[i] = folder.ID
+			}
+			if err := tx.Model(&models.Folder{}).Where("id IN ?", ids).Update("owner_id", toID).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("folder_id IN ? AND user_id = ?", ids, toID).Delete(&models.FolderShare{}).Error; err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := enqueueEvent(tx, models.EventFolderUpdated, id, models.FolderEvent{FolderID: id}); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(notes) > 0 {
+			ids := make([]uuid.UUID, len(notes))
+			for i, note := range notes {
+				ids[i] = note.ID
+			}
+			updates := map[string]interface{}{"owner_id": toID}
+			if archive {
+				updates["status"] = models.NoteStatusArchived
+			}
+			if err := tx.Model(&models.Note{}).Where("id IN ?", ids).Updates(updates).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("note_id IN ? AND user_id = ?", ids, toID).Delete(&models.NoteShare{}).Error; err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if err := enqueueEvent(tx, models.EventNoteUpdated, id, models.NoteEvent{NoteID: id}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return folders, notes, nil
+}
+
+func ownedAssets(db *gorm.DB, userID uuid.UUID) ([]models.Folder, []models.Note, error) {
+	var folders []models.Folder
+	if err := db.Select("id", "name").Where("owner_id = ?", userID).Order("created_at, id").Find(&folders).Error; err != nil {
+		return nil, nil, err
+	}
+	var notes []models.Note
+	if err := db.Select("id", "title", "folder_id", "status").Where("owner_id = ?", userID).Order("created_at, id").Find(&notes).Error; err != nil {
+		return nil, nil, err
+	}
+	return folders, notes, nil
+}
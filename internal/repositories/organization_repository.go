@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+)
+
+type OrganizationRepository struct {
+	Repository[models.Organization]
+}
+
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{Repository: NewRepository[models.Organization](db, apperrors.NotFound("organization not found"))}
+}
+
+func (r *OrganizationRepository) GetAll(ctx context.Context) ([]models.Organization, error) {
+	var orgs []models.Organization
+	err := database.ReadReplica(r.db.WithContext(ctx)).Order("name").Find(&orgs).Error
+	return orgs, err
+}
+
+func (r *OrganizationRepository) SlugExists(ctx context.Context, slug string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Organization{}).Where("slug = ?", slug).Count(&count).Error
+	return count > 0, err
+}
+
+// AssignUser moves the user into org and writes a user.organization.changed
+// outbox event. Their folders and notes move with them; team memberships are
+// left as they are.
+func (r *OrganizationRepository) AssignUser(ctx context.Context, orgID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ?", userID).Update("organization_id", orgID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("user not found")
+		}
+		if err := tx.Model(&models.Folder{}).Where("owner_id = ?", userID).Update("organization_id", orgID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Note{}).Where("owner_id = ?", userID).Update("organization_id", orgID).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventUserOrganizationChanged, userID, models.UserOrganizationEvent{
+			UserID:         userID,
+			OrganizationID: orgID,
+		})
+	})
+}
+
+// inOrganization restricts a query on a tenant-scoped table to orgID, or to
+// the default tenant when orgID is nil
+func inOrganization(orgID *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if orgID == nil {
+			return db.Where("organization_id IS NULL")
+		}
+		return db.Where("organization_id = ?", *orgID)
+	}
+}
+
+// requireSameOrganization returns NotFound unless the user belongs to the
+// organization of the row id in model's table, so that nothing can be shared
+// with or joined by users of another tenant
+func requireSameOrganization(tx *gorm.DB, model interface{}, id, userID uuid.UUID) error {
+	var count int64
+	owner := tx.Session(&gorm.Session{NewDB: true}).Model(model).Select("organization_id").Where("id = ?", id)
+	err := tx.Session(&gorm.Session{NewDB: true}).Model(&models.User{}).
+		Where("id = ? AND organization_id IS NOT DISTINCT FROM (?)", userID, owner).
+		Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return apperrors.NotFound("user not found")
+	}
+	return nil
+}
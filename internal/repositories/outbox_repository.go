@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/models"
+)
+
+// enqueueEvent writes a domain event to the outbox using tx, so that it is
+// committed or rolled back together with the change it describes
+func enqueueEvent(tx *gorm.DB, eventType string, aggregateID uuid.UUID, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	now := time.Now()
+	return tx.Create(&models.OutboxEvent{
+		EventType:     eventType,
+		AggregateID:   aggregateID,
+		Payload:       data,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	}).Error
+}
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// ProcessPending locks up to limit unpublished events that are due, oldest
+// first, and passes each to handle, saving whatever handle changed on it.
+// Locked rows are skipped, so several relays can run at once without
+// publishing the same event concurrently. It returns how many events were
+// handled.
+func (r *OutboxRepository) ProcessPending(ctx context.Context, limit int, handle func(event *models.OutboxEvent)) (int, error) {
+	var count int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var events []models.OutboxEvent
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL AND next_attempt_at <= ?", time.Now()).
+			Order("created_at").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range events {
+			handle(&events[i])
+			if err := tx.Save(&events[i]).Error; err != nil {
+				return err
+			}
+		}
+		count = len(events)
+		return nil
+	})
+	return count, err
+}
+
+// DeletePublishedBefore removes events published before t and returns how many
+func (r *OutboxRepository) DeletePublishedBefore(ctx context.Context, t time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("published_at < ?", t).Delete(&models.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/models"
+)
+
+// QuotaRepository counts what users own for quota checks. It reads the
+// primary, since a lagging replica would let users create past a limit.
+type QuotaRepository struct {
+	db *gorm.DB
+}
+
+func NewQuotaRepository(db *gorm.DB) *QuotaRepository {
+	return &QuotaRepository{db: db}
+}
+
+// UserUsage counts the notes and folders userID owns
+func (r *QuotaRepository) UserUsage(ctx context.Context, userID uuid.UUID) (models.Usage, error) {
+	return r.usage(ctx, "owner_id = ?", userID)
+}
+
+// TeamUsage counts the notes and folders owned by the people of teamID,
+// whatever their roles
+func (r *QuotaRepository) TeamUsage(ctx context.Context, teamID uuid.UUID) (models.Usage, error) {
+	return r.usage(ctx, "owner_id IN (SELECT user_id FROM team_memberships WHERE team_id = ?)", teamID)
+}
+
+func (r *QuotaRepository) usage(ctx context.Context, query string, args ...interface{}) (models.Usage, error) {
+	var usage models.Usage
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&models.Note{}).Where(query, args...).Count(&usage.Notes).Error; err != nil {
+		return models.Usage{}, err
+	}
+	if err := db.Model(&models.Folder{}).Where(query, args...).Count(&usage.Folders).Error; err != nil {
+		return models.Usage{}, err
+	}
+	return usage, nil
+}
@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+type ReminderRepository struct {
+	Repository[models.Reminder]
+}
+
+func NewReminderRepository(db *gorm.DB) *ReminderRepository {
+	return &ReminderRepository{Repository: NewRepository[models.Reminder](db, apperrors.NotFound("reminder not found"))}
+}
+
+// GetByNoteAndUser returns the user's reminders on a note, soonest first
+func (r *ReminderRepository) GetByNoteAndUser(ctx context.Context, noteID, userID uuid.UUID) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	err := r.db.WithContext(ctx).Where("note_id = ? AND user_id = ?", noteID, userID).
+		Order("due_at").Find(&reminders).Error
+	return reminders, err
+}
+
+// CountPending counts the user's reminders on a note that have not been sent
+func (r *ReminderRepository) CountPending(ctx context.Context, noteID, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Reminder{}).
+		Where("note_id = ? AND user_id = ? AND sent_at IS NULL", noteID, userID).
+		Count(&count).Error
+	return count, err
+}
+
+// GetDue returns up to limit unsent reminders due at or before now, oldest
+// first
+func (r *ReminderRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	err := r.db.WithContext(ctx).Where("sent_at IS NULL AND due_at <= ?", now).
+		Order("due_at").Limit(limit).Find(&reminders).Error
+	return reminders, err
+}
+
+// MarkSent records that the reminder was sent at sentAt
+func (r *ReminderRepository) MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Reminder{}).Where("id = ?", id).
+		Update("sent_at", sentAt).Error
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
+	"seta-training/pkg/pagination"
+)
+
+// Repository provides the CRUD every model repository needs. Concrete
+// repositories embed it and override the methods that need more, such as
+// preloads or outbox events.
+type Repository[T any] struct {
+	db       *gorm.DB
+	notFound *apperrors.Error
+}
+
+// NewRepository creates a repository for T. notFound is the error GetByID
+// returns for a missing record, e.g. apperrors.NotFound("user not found").
+func NewRepository[T any](db *gorm.DB, notFound *apperrors.Error) Repository[T] {
+	return Repository[T]{db: db, notFound: notFound}
+}
+
+// Page is a window of a listing: at most Limit rows after skipping Offset.
+// A non-positive Limit returns every row.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+func (r Repository[T]) Create(ctx context.Context, entity *T) error {
+	return r.db.WithContext(ctx).Create(entity).Error
+}
+
+func (r Repository[T]) GetByID(ctx context.Context, id uuid.UUID) (*T, error) {
+	var entity T
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&entity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, r.notFound
+		}
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r Repository[T]) Update(ctx context.Context, entity *T) error {
+	return r.db.WithContext(ctx).Save(entity).Error
+}
+
+func (r Repository[T]) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(new(T), id).Error
+}
+
+// List returns one page of the rows matching scopes together with the
+// total number of matching rows. Rows are ordered by any order the scopes
+// set, then by id so pages never overlap.
+func (r Repository[T]) List(ctx context.Context, page Page, scopes ...func(*gorm.DB) *gorm.DB) ([]T, int64, error) {
+	query := database.ReadReplica(r.db.WithContext(ctx)).Model(new(T)).Scopes(scopes...)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: "id"}})
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	if page.Offset > 0 {
+		query = query.Offset(page.Offset)
+	}
+
+	var entities []T
+	if err := query.Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+// ListAfter returns one keyset page of the rows matching scopes, ordered by
+// created_at then id. cursorOf gives a row's position in that order.
+func (r Repository[T]) ListAfter(ctx context.Context, p pagination.Params, cursorOf func(T) pagination.Cursor, scopes ...func(*gorm.DB) *gorm.DB) (pagination.Page[T], error) {
+	return listAfter(r.db.WithContext(ctx), p, cursorOf, scopes...)
+}
+
+// listAfter pages any table with created_at and id columns, so listings of
+// models without a repository of their own, such as shares, page the same way
+func listAfter[T any](db *gorm.DB, p pagination.Params, cursorOf func(T) pagination.Cursor, scopes ...func(*gorm.DB) *gorm.DB) (pagination.Page[T], error) {
+	createdAt := clause.Column{Table: clause.CurrentTable, Name: "created_at"}
+	id := clause.Column{Table: clause.CurrentTable, Name: "id"}
+
+	query := database.ReadReplica(db).Model(new(T)).Scopes(scopes...)
+	if p.After != nil {
+		query = query.Where("(?, ?) > (?, ?)", createdAt, id, p.After.CreatedAt, p.After.ID)
+	}
+
+	var rows []T
+	err := query.Order(clause.OrderBy{Columns: []clause.OrderByColumn{{Column: createdAt}, {Column: id}}}).
+		Limit(p.Size() + 1).
+		Find(&rows).Error
+	if err != nil {
+		return pagination.Page[T]{}, err
+	}
+	return pagination.NewPage(rows, p.Size(), cursorOf), nil
+}
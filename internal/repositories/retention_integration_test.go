@@ -0,0 +1,49 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/testutils"
+)
+
+func TestRetentionRepository_PurgeUsersDeletesDependents(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.PostgresTx(t)
+	purged := testutils.UserFactory().Create(t, db)
+	kept := testutils.UserFactory().Create(t, db)
+
+	for _, user := range []*models.User{purged, kept} {
+		require.NoError(t, db.Create(&models.UserPreference{UserID: user.ID, Timezone: "UTC", Locale: "en"}).Error)
+		require.NoError(t, db.Create(&models.Device{UserID: user.ID, UserAgent: "laptop", LastLoginAt: time.Now()}).Error)
+		require.NoError(t, db.Create(&models.LoginAttempt{UserID: &user.ID, Email: strings.ToLower(user.Email), IP: "192.0.2.1", Outcome: models.LoginInvalidPassword}).Error)
+		// Once the account is deleted, attempts against it match no user
+		require.NoError(t, db.Create(&models.LoginAttempt{Email: strings.ToLower(user.Email), IP: "192.0.2.2", Outcome: models.LoginUnknownEmail}).Error)
+	}
+	require.NoError(t, db.Delete(purged).Error)
+
+	n, err := repositories.NewRetentionRepository(db).PurgeUsers(ctx, time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, n)
+
+	count := func(model interface{}, query string, args ...interface{}) int64 {
+		t.Helper()
+		var n int64
+		require.NoError(t, db.Model(model).Where(query, args...).Count(&n).Error)
+		return n
+	}
+	assert.Zero(t, count(&models.Device{}, "user_id = ?", purged.ID))
+	assert.Zero(t, count(&models.UserPreference{}, "user_id = ?", purged.ID))
+	assert.EqualValues(t, 1, count(&models.UserPreference{}, "user_id = ?", kept.ID))
+	assert.EqualValues(t, 1, count(&models.Device{}, "user_id = ?", kept.ID))
+	assert.Zero(t, count(&models.LoginAttempt{}, "user_id = ? OR LOWER(email) = LOWER(?)", purged.ID, purged.Email))
+	assert.EqualValues(t, 2, count(&models.LoginAttempt{}, "user_id = ? OR LOWER(email) = LOWER(?)", kept.ID, kept.Email))
+}
@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/models"
+)
+
+// RetentionRepository permanently deletes soft-deleted records together with
+// the rows that reference them. Each Purge method deletes one batch of up to
+// limit records deleted before the cutoff and returns how many it removed.
+type RetentionRepository struct {
+	db *gorm.DB
+}
+
+func NewRetentionRepository(db *gorm.DB) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+// dependent is a table whose rows reference a purged record through column
+type dependent struct {
+	model  interface{}
+	column string
+}
+
+var (
+	noteDependents = []dependent{
+		{&models.NoteShare{}, "note_id"},
+		{&models.Mention{}, "note_id"},
+		{&models.Reminder{}, "note_id"},
+		{&models.ChecklistItem{}, "note_id"},
+		{&models.NoteLink{}, "source_id"},
+		{&models.NoteLink{}, "target_id"},
+		{&models.AccessRequest{}, "resource_id"},
+	}
+	folderDependents = []dependent{
+		{&models.FolderShare{}, "folder_id"},
+		{&models.ExportJob{}, "folder_id"},
+		{&models.AccessRequest{}, "resource_id"},
+	}
+	teamDependents = []dependent{
+		{&models.TeamMembership{}, "team_id"},
+	}
+	userDependents = []dependent{
+		{&models.NoteShare{}, "user_id"},
+		{&models.FolderShare{}, "user_id"},
+		{&models.TeamMembership{}, "user_id"},
+		{&models.Mention{}, "author_id"},
+		{&models.Mention{}, "mentioned_user_id"},
+		{&models.Notification{}, "user_id"},
+		{&models.SavedFilter{}, "owner_id"},
+		{&models.ExportJob{}, "owner_id"},
+		{&models.IdempotencyKey{}, "user_id"},
+		{&models.Reminder{}, "user_id"},
+		{&models.AccessRequest{}, "requester_id"},
+		{&models.AccessRequest{}, "owner_id"},
+		{&models.LDAPUser{}, "user_id"},
+		{&models.SCIMUser{}, "user_id"},
+		{&models.Device{}, "user_id"},
+		{&models.UserPreference{}, "user_id"},
+	}
+)
+
+// PurgeNotes deletes notes along with their shares, mentions, reminders,
+// checklists, links and access requests
+func (r *RetentionRepository) PurgeNotes(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids, err := expiredIDs(tx.Model(&models.Note{}), before, limit)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		purged, err = deleteNotes(tx, ids)
+		return err
+	})
+	return purged, err
+}
+
+// PurgeFolders deletes folders along with their shares, export jobs,
+// access requests and every note they contain, deleted or not
+func (r *RetentionRepository) PurgeFolders(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids, err := expiredIDs(tx.Model(&models.Folder{}), before, limit)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		var noteIDs []uuid.UUID
+		if err := tx.Unscoped().Model(&models.Note{}).Where("folder_id IN ?", ids).Pluck("id", &noteIDs).Error; err != nil {
+			return err
+		}
+		if len(noteIDs) > 0 {
+			if _, err := deleteNotes(tx, noteIDs); err != nil {
+				return err
+			}
+		}
+
+		purged, err = deleteWithDependents(tx, &models.Folder{}, ids, folderDependents)
+		return err
+	})
+	return purged, err
+}
+
+// PurgeTeams deletes teams along with their manager and member links
+func (r *RetentionRepository) PurgeTeams(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids, err := expiredIDs(tx.Model(&models.Team{}), before, limit)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		purged, err = deleteWithDependents(tx, &models.Team{}, ids, teamDependents)
+		return err
+	})
+	return purged, err
+}
+
+// PurgeUsers deletes users along with their shares, memberships, mentions,
+// notifications, saved filters, exports, idempotency keys, reminders,
+// devices, preferences, webhooks and login history, including failed attempts made
+// against their email that were not tied to the account.
+// Users who still own folders or notes are skipped until those are purged.
+func (r *RetentionRepository) PurgeUsers(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids, err := expiredIDs(tx.Model(&models.User{}).
+			Where("NOT EXISTS (SELECT 1 FROM folders WHERE folders.owner_id = users.id)").
+			Where("NOT EXISTS (SELECT 1 FROM notes WHERE notes.owner_id = users.id)"),
+			before, limit)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		webhooks := tx.Model(&models.Webhook{}).Select("id").Where("owner_id IN ?", ids)
+		if err := tx.Where("webhook_id IN (?)", webhooks).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("owner_id IN ?", ids).Delete(&models.Webhook{}).Error; err != nil {
+			return err
+		}
+
+		// Emails are unique across deleted users too, so no one else's
+		// attempts are matched
+		emails := tx.Unscoped().Model(&models.User{}).Select("LOWER(email)").Where("id IN ?", ids)
+		if err := tx.Where("user_id IN ? OR (user_id IS NULL AND email IN (?))", ids, emails).Delete(&models.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+
+		purged, err = deleteWithDependents(tx, &models.User{}, ids, userDependents)
+		return err
+	})
+	return purged, err
+}
+
+// expiredIDs returns up to limit IDs from query soft-deleted before the
+// cutoff, oldest first
+func expiredIDs(query *gorm.DB, before time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := query.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at").Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func deleteNotes(tx *gorm.DB, ids []uuid.UUID) (int64, error) {
+	return deleteWithDependents(tx, &models.Note{}, ids, noteDependents)
+}
+
+// deleteWithDependents hard-deletes the rows of model with the given IDs
+// after deleting the rows that reference them
+func deleteWithDependents(tx *gorm.DB, model interface{}, ids []uuid.UUID, dependents []dependent) (int64, error) {
+	for _, dep := range dependents {
+		if err := tx.Unscoped().Where(dep.column+" IN ?", ids).Delete(dep.model).Error; err != nil {
+			return 0, err
+		}
+	}
+	res := tx.Unscoped().Where("id IN ?", ids).Delete(model)
+	return res.RowsAffected, res.Error
+}
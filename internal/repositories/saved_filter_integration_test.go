@@ -0,0 +1,95 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/testutils"
+	"seta-training/pkg/compression"
+	"seta-training/pkg/crypto"
+)
+
+func TestSavedFilterRepository_FindNotesVisibility(t *testing.T) {
+	db := testutils.PostgresTx(t)
+	owner := testutils.UserFactory().Create(t, db)
+	reader := testutils.UserFactory().Create(t, db)
+
+	private := testutils.FolderFactory(owner).Named("Private").Create(t, db)
+	shared := testutils.FolderFactory(owner).Named("Shared").SharedWith(reader, models.AccessRead).Create(t, db)
+	own := testutils.FolderFactory(reader).Named("Own").Create(t, db)
+
+	hidden := testutils.NoteFactory(private).Titled("Roadmap hidden").Create(t, db)
+	sharedNote := testutils.NoteFactory(private).Titled("Roadmap shared note").SharedWith(reader, models.AccessRead).Create(t, db)
+	inSharedFolder := testutils.NoteFactory(shared).Titled("Roadmap in shared folder").Create(t, db)
+	owned := testutils.NoteFactory(own).Titled("Roadmap owned").Create(t, db)
+
+	filters := repositories.NewSavedFilterRepository(db, nil)
+	expr := models.FilterExpression{
+		Target:     models.FilterTargetNotes,
+		Conditions: []models.FilterCondition{{Field: models.FilterFieldTitle, Op: models.FilterOpContains, Value: "roadmap"}},
+	}
+	notes, err := filters.FindNotes(context.Background(), reader.ID, expr, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{sharedNote.ID, inSharedFolder.ID, owned.ID}, noteIDs(notes))
+	assert.NotContains(t, noteIDs(notes), hidden.ID)
+}
+
+func TestSavedFilterRepository_FindNotesMatchesEncodedBodies(t *testing.T) {
+	ctx := context.Background()
+	db := testutils.PostgresTx(t)
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	keys, err := crypto.NewLocalKeySource(map[string]string{"k1": base64.StdEncoding.EncodeToString(key)}, "")
+	require.NoError(t, err)
+	compressor, err := compression.NewCompressor("gzip", 64)
+	require.NoError(t, err)
+	plain := repositories.NewNoteRepository(db, nil)
+	sealed := repositories.NewNoteRepository(db, repositories.NewNoteBodyCodec(nil, crypto.NewEnvelope(keys), nil))
+	compressed := repositories.NewNoteRepository(db, repositories.NewNoteBodyCodec(compressor, nil, nil))
+
+	owner := testutils.UserFactory().Create(t, db)
+	folder := testutils.FolderFactory(owner).Create(t, db)
+	create := func(repo *repositories.NoteRepository, title, body string) *models.Note {
+		t.Helper()
+		note := &models.Note{Title: title, Body: body, FolderID: folder.ID, OwnerID: owner.ID}
+		require.NoError(t, repo.Create(ctx, note))
+		return note
+	}
+	plainMatch := create(plain, "Plain", "Quarterly plan #Launch")
+	sealedMatch := create(sealed, "Sealed", "Secret plan #launch")
+	compressedMatch := create(compressed, "Compressed", strings.Repeat("long plan, ", 20)+"#launch")
+	create(sealed, "Sealed without tag", "Secret plan #launchpad")
+	create(compressed, "Compressed without word", strings.Repeat("nothing here, ", 20)+"#launch")
+
+	codec := repositories.NewNoteBodyCodec(compressor, crypto.NewEnvelope(keys), nil)
+	filters := repositories.NewSavedFilterRepository(db, codec)
+	expr := models.FilterExpression{
+		Target: models.FilterTargetNotes,
+		Conditions: []models.FilterCondition{
+			{Field: models.FilterFieldBody, Op: models.FilterOpContains, Value: "PLAN"},
+			{Field: models.FilterFieldTag, Op: models.FilterOpEq, Value: "launch"},
+		},
+	}
+	notes, err := filters.FindNotes(ctx, owner.ID, expr, 10)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{plainMatch.ID, sealedMatch.ID, compressedMatch.ID}, noteIDs(notes))
+
+	// Notes the database lets through but that do not match do not count
+	// towards the limit
+	notes, err = filters.FindNotes(ctx, owner.ID, expr, 2)
+	require.NoError(t, err)
+	assert.Len(t, notes, 2)
+	assert.Subset(t, []uuid.UUID{plainMatch.ID, sealedMatch.ID, compressedMatch.ID}, noteIDs(notes))
+}
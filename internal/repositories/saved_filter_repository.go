@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+)
+
+type SavedFilterRepository struct {
+	Repository[models.SavedFilter]
+	codec *NoteBodyCodec
+}
+
+// NewSavedFilterRepository creates a saved filter repository decoding the
+// bodies of the notes its filters match with codec
+func NewSavedFilterRepository(db *gorm.DB, codec *NoteBodyCodec) *SavedFilterRepository {
+	return &SavedFilterRepository{Repository: NewRepository[models.SavedFilter](db, apperrors.NotFound("saved filter not found")), codec: codec}
+}
+
+func (r *SavedFilterRepository) GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.SavedFilter, error) {
+	var filters []models.SavedFilter
+	err := r.db.WithContext(ctx).Where("owner_id = ?", ownerID).Order("created_at").Find(&filters).Error
+	return filters, err
+}
+
+func (r *SavedFilterRepository) GetPinned(ctx context.Context, ownerID uuid.UUID) ([]models.SavedFilter, error) {
+	var filters []models.SavedFilter
+	err := r.db.WithContext(ctx).Where("owner_id = ? AND pinned = ?", ownerID, true).Order("created_at").Find(&filters).Error
+	return filters, err
+}
+
+// FindNotes returns notes visible to userID that match every condition of
+// expr: notes they own, that are shared with them or that are in a folder
+// shared with them. Compressed and encrypted bodies cannot be matched in
+// the database, so body and tag conditions let them through and are checked
+// again once the bodies are decoded, reading further batches until limit
+// notes match.
+func (r *SavedFilterRepository) FindNotes(ctx context.Context, userID uuid.UUID, expr models.FilterExpression, limit int) ([]models.Note, error) {
+	query := database.ReadReplica(r.db.WithContext(ctx)).Model(&models.Note{}).
+		Where("(notes.owner_id = ? OR notes.id IN (?) OR notes.folder_id IN (?))", userID,
+			r.db.Model(&models.NoteShare{}).Select("note_id").Where("user_id = ?", userID),
+			r.db.Model(&models.FolderShare{}).Select("folder_id").Where("user_id = ?", userID))
+
+	var inBody []models.FilterCondition
+	for _, cond := range expr.Conditions {
+		if cond.Field != models.FilterFieldBody && cond.Field != models.FilterFieldTag {
+			query = applyFilterCondition(r.db, query, "notes", cond)
+			continue
+		}
+		inBody = append(inBody, cond)
+		fresh := r.db.Session(&gorm.Session{NewDB: true})
+		query = query.Where(fresh.Where("notes.compressed_body IS NOT NULL").
+			Or(applyFilterCondition(r.db, fresh, "notes", cond)))
+	}
+
+	var notes []models.Note
+	for offset := 0; ; offset += limit {
+		var batch []models.Note
+		err := query.Session(&gorm.Session{}).Preload("Owner").Preload("Folder").
+			Order("notes.updated_at DESC, notes.id").Offset(offset).Limit(limit).
+			Find(&batch).Error
+		if err != nil {
+			return nil, err
+		}
+		if err := r.codec.decodeAll(batch); err != nil {
+			return nil, err
+		}
+		for _, note := range batch {
+			if bodyMatches(note.Body, inBody) {
+				notes = append(notes, note)
+			}
+			if len(notes) == limit {
+				return notes, nil
+			}
+		}
+		if len(batch) < limit || len(inBody) == 0 {
+			return notes, nil
+		}
+	}
+}
+
+// bodyMatches reports whether a decoded body matches every body and tag
+// condition, as applyFilterCondition matches stored bodies
+func bodyMatches(body string, conds []models.FilterCondition) bool {
+	for _, cond := range conds {
+		switch cond.Field {
+		case models.FilterFieldBody:
+			if !strings.Contains(strings.ToLower(body), strings.ToLower(cond.Value)) {
+				return false
+			}
+		case models.FilterFieldTag:
+			if !tagPattern(cond.Value).MatchString(body) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// tagPattern matches #tag as a whole word, ignoring case
+func tagPattern(tag string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)` + tagExpr(tag))
+}
+
+func tagExpr(tag string) string {
+	return `(^|[^[:alnum:]_])` + regexp.QuoteMeta("#"+strings.TrimPrefix(tag, "#")) + `([^[:alnum:]_]|$)`
+}
+
+// FindFolders returns folders visible to userID that match every condition of expr
+func (r *SavedFilterRepository) FindFolders(ctx context.Context, userID uuid.UUID, expr models.FilterExpression, limit int) ([]models.Folder, error) {
+	query := database.ReadReplica(r.db.WithContext(ctx)).Model(&models.Folder{}).
+		Where("(folders.owner_id = ? OR folders.id IN (?))", userID,
+			r.db.Model(&models.FolderShare{}).Select("folder_id").Where("user_id = ?", userID))
+
+	for _, cond := range expr.Conditions {
+		query = applyFilterCondition(r.db, query, "folders", cond)
+	}
+
+	var folders []models.Folder
+	err := query.Preload("Owner").
+		Order("folders.updated_at DESC").Limit(limit).
+		Find(&folders).Error
+	return folders, err
+}
+
+// applyFilterCondition adds a validated condition to query. Column names
+// come from the fixed set in models, never from user input.
+func applyFilterCondition(db, query *gorm.DB, table string, cond models.FilterCondition) *gorm.DB {
+	column := table + "." + cond.Field
+
+	switch cond.Field {
+	case models.FilterFieldTitle:
+		column = table + ".title"
+		if table == "folders" {
+			column = "folders.name"
+		}
+		if cond.Op == models.FilterOpEq {
+			return query.Where(column+" = ?", cond.Value)
+		}
+		return query.Where(column+" "+database.ILike(db)+" ?", likePattern(cond.Value))
+	case models.FilterFieldBody:
+		return query.Where(column+" "+database.ILike(db)+" ?", likePattern(cond.Value))
+	case models.FilterFieldTag:
+		return query.Where(column+" "+database.IRegexp(db)+" ?", tagExpr(cond.Value))
+	case models.FilterFieldOwnerID, models.FilterFieldFolderID:
+		return query.Where(column+" = ?", cond.Value)
+	case models.FilterFieldTeamID:
+		return query.Where(table+".owner_id IN (?)",
+			db.Model(&models.TeamMembership{}).Select("user_id").Where("team_id = ?", cond.Value))
+	case models.FilterFieldCreatedAt, models.FilterFieldUpdatedAt:
+		switch cond.Op {
+		case models.FilterOpWithin:
+			window, _ := models.ParseFilterDuration(cond.Value)
+			return query.Where(column+" >= ?", time.Now().Add(-window))
+		case models.FilterOpAfter:
+			return query.Where(column+" > ?", cond.Value)
+		case models.FilterOpBefore:
+			return query.Where(column+" < ?", cond.Value)
+		}
+	}
+	return query
+}
+
+func likePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return "%" + replacer.Replace(value) + "%"
+}
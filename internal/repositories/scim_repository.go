@@ -0,0 +1,256 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// SCIMUserFilter narrows a listing of SCIM users. Empty fields match
+// every user.
+type SCIMUserFilter struct {
+	UserName   string
+	Email      string
+	ExternalID string
+}
+
+// SCIMRepository stores the SCIM connections of organizations and the user
+// and team changes identity providers make through them
+type SCIMRepository struct {
+	db *gorm.DB
+}
+
+func NewSCIMRepository(db *gorm.DB) *SCIMRepository {
+	return &SCIMRepository{db: db}
+}
+
+// GetConnection returns the SCIM connection of orgID
+func (r *SCIMRepository) GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SCIMConnection, error) {
+	var conn models.SCIMConnection
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).First(&conn).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("SCIM connection not found")
+		}
+		return nil, err
+	}
+	return &conn, nil
+}
+
+// GetConnectionByTokenHash returns the SCIM connection whose token hashes
+// to tokenHash
+func (r *SCIMRepository) GetConnectionByTokenHash(ctx context.Context, tokenHash string) (*models.SCIMConnection, error) {
+	var conn models.SCIMConnection
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&conn).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("SCIM connection not found")
+		}
+		return nil, err
+	}
+	return &conn, nil
+}
+
+// SaveConnection creates the SCIM connection of its organization or
+// replaces the existing one
+func (r *SCIMRepository) SaveConnection(ctx context.Context, conn *models.SCIMConnection) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []models.SCIMConnection
+		if err := tx.Where("organization_id = ?", conn.OrganizationID).Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			return tx.Create(conn).Error
+		}
+		conn.ID = existing[0].ID
+		conn.CreatedAt = existing[0].CreatedAt
+		return tx.Save(conn).Error
+	})
+}
+
+// DeleteConnection deletes the SCIM connection of orgID, revoking its token
+func (r *SCIMRepository) DeleteConnection(ctx context.Context, orgID uuid.UUID) error {
+	result := r.db.WithContext(ctx).Where("organization_id = ?", orgID).Delete(&models.SCIMConnection{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("SCIM connection not found")
+	}
+	return nil
+}
+
+// ListUsers returns one page of the users of orgID matching filter,
+// including deactivated ones, together with how many match
+func (r *SCIMRepository) ListUsers(ctx context.Context, orgID uuid.UUID, filter SCIMUserFilter, page Page) ([]models.User, int64, error) {
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("organization_id = ?", orgID)
+	if filter.UserName != "" {
+		query = query.Where("LOWER(username) = LOWER(?)", filter.UserName)
+	}
+	if filter.Email != "" {
+		query = query.Where("LOWER(email) = LOWER(?)", filter.Email)
+	}
+	if filter.ExternalID != "" {
+		query = query.Where("id IN (?)", r.db.Model(&models.SCIMUser{}).Select("user_id").
+			Where("organization_id = ? AND external_id = ?", orgID, filter.ExternalID))
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = query.Order("created_at, id")
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	if page.Offset > 0 {
+		query = query.Offset(page.Offset)
+	}
+	var users []models.User
+	if err := query.Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// GetUser returns the user of orgID with id, including a deactivated one
+func (r *SCIMRepository) GetUser(ctx context.Context, orgID, id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ? AND organization_id = ?", id, orgID).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserByEmail returns the user with email, including a deleted one
+func (r *SCIMRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findUser(ctx, "LOWER(email) = LOWER(?)", email)
+}
+
+// GetUserByUsername returns the user with username, including a deleted
+// one
+func (r *SCIMRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.findUser(ctx, "LOWER(username) = LOWER(?)", username)
+}
+
+func (r *SCIMRepository) findUser(ctx context.Context, query string, value string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Unscoped().Where(query, value).First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("user not found")
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetExternalIDs returns the identity provider's IDs of the users with
+// one, by user ID
+func (r *SCIMRepository) GetExternalIDs(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	externalIDs := make(map[uuid.UUID]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return externalIDs, nil
+	}
+	var links []models.SCIMUser
+	if err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		externalIDs[link.UserID] = link.ExternalID
+	}
+	return externalIDs, nil
+}
+
+// LinkUser marks a user as provisioned through SCIM, replacing the
+// external ID it had
+func (r *SCIMRepository) LinkUser(ctx context.Context, link *models.SCIMUser) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"organization_id", "external_id", "updated_at"}),
+	}).Create(link).Error
+}
+
+// UpdateUser changes the username and email of userID
+func (r *SCIMRepository) UpdateUser(ctx context.Context, userID uuid.UUID, username, email string) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{"username": username, "email": email})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("user not found")
+	}
+	return nil
+}
+
+// DeactivateUser soft deletes userID, so they can no longer sign in, and
+// revokes their devices, so the tokens they hold are rejected as well
+func (r *SCIMRepository) DeactivateUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", userID).Delete(&models.User{}).Error; err != nil {
+			return err
+		}
+		return revokeUserDevices(tx, userID, time.Now())
+	})
+}
+
+// RestoreUser undoes the soft deletion of userID
+func (r *SCIMRepository) RestoreUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("id = ?", userID).
+		Update("deleted_at", nil).Error
+}
+
+// ListTeams returns one page of the teams of orgID, with their people,
+// named displayName or all of them when it is empty, together with how
+// many match
+func (r *SCIMRepository) ListTeams(ctx context.Context, orgID uuid.UUID, displayName string, page Page) ([]models.Team, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Team{}).Where("organization_id = ?", orgID)
+	if displayName != "" {
+		query = query.Where("LOWER(name) = LOWER(?)", displayName)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	query = query.Scopes(withPeople).Order("created_at, id")
+	if page.Limit > 0 {
+		query = query.Limit(page.Limit)
+	}
+	if page.Offset > 0 {
+		query = query.Offset(page.Offset)
+	}
+	var teams []models.Team
+	if err := query.Find(&teams).Error; err != nil {
+		return nil, 0, err
+	}
+	return teams, total, nil
+}
+
+// RenameTeam changes the name of teamID
+func (r *SCIMRepository) RenameTeam(ctx context.Context, teamID uuid.UUID, name string) error {
+	result := r.db.WithContext(ctx).Model(&models.Team{}).Where("id = ?", teamID).Update("name", name)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("team not found")
+	}
+	return nil
+}
+
+// DeleteTeam soft deletes teamID
+func (r *SCIMRepository) DeleteTeam(ctx context.Context, teamID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", teamID).Delete(&models.Team{}).Error
+}
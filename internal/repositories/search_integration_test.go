@@ -0,0 +1,51 @@
+//go:build integration
+
+package repositories_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/testutils"
+)
+
+func TestSearchRepository_SearchNotesVisibility(t *testing.T) {
+	db := testutils.PostgresTx(t)
+	owner := testutils.UserFactory().Create(t, db)
+	reader := testutils.UserFactory().Create(t, db)
+
+	private := testutils.FolderFactory(owner).Named("Private").Create(t, db)
+	shared := testutils.FolderFactory(owner).Named("Shared").SharedWith(reader, models.AccessRead).Create(t, db)
+	own := testutils.FolderFactory(reader).Named("Own").Create(t, db)
+
+	hidden := testutils.NoteFactory(private).Titled("Roadmap hidden").Create(t, db)
+	sharedNote := testutils.NoteFactory(private).Titled("Roadmap shared note").SharedWith(reader, models.AccessRead).Create(t, db)
+	inSharedFolder := testutils.NoteFactory(shared).Titled("Roadmap in shared folder").Create(t, db)
+	owned := testutils.NoteFactory(own).Titled("Roadmap owned").Create(t, db)
+
+	search := repositories.NewSearchRepository(db, nil)
+	notes, total, err := search.SearchNotes(context.Background(), reader.ID, "roadmap", 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	assert.ElementsMatch(t, []uuid.UUID{sharedNote.ID, inSharedFolder.ID, owned.ID}, noteIDs(notes))
+	assert.NotContains(t, noteIDs(notes), hidden.ID)
+
+	// The owner sees all of their notes but not the reader's
+	notes, total, err = search.SearchNotes(context.Background(), owner.ID, "roadmap", 10)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	assert.ElementsMatch(t, []uuid.UUID{hidden.ID, sharedNote.ID, inSharedFolder.ID}, noteIDs(notes))
+}
+
+func noteIDs(notes []models.Note) []uuid.UUID {
+	ids := make([]uuid.UUID, len(notes))
+	for i, note := range notes {
+		ids[i] = note.ID
+	}
+	return ids
+}
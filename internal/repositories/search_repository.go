@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+)
+
+// SearchRepository finds notes, folders, teams and users by text. Each
+// method returns the best limit matches, closest first, together with the
+// number of matches.
+type SearchRepository struct {
+	db    *gorm.DB
+	codec *NoteBodyCodec
+}
+
+// NewSearchRepository creates a search repository decoding the bodies of the
+// notes it finds with codec
+func NewSearchRepository(db *gorm.DB, codec *NoteBodyCodec) *SearchRepository {
+	return &SearchRepository{db: db, codec: codec}
+}
+
+// SearchNotes matches the title and body of notes userID owns, that are
+// shared with them or that are in a folder shared with them. Compressed and
+// encrypted bodies are only matched by title.
+func (r *SearchRepository) SearchNotes(ctx context.Context, userID uuid.UUID, q string, limit int) ([]models.Note, int64, error) {
+	visible := func(db *gorm.DB) *gorm.DB {
+		return db.Where("(notes.owner_id = ? OR notes.id IN (?) OR notes.folder_id IN (?))", userID,
+			r.db.Model(&models.NoteShare{}).Select("note_id").Where("user_id = ?", userID),
+			r.db.Model(&models.FolderShare{}).Select("folder_id").Where("user_id = ?", userID))
+	}
+	notes, total, err := search[models.Note](r.db.WithContext(ctx), q, limit, visible, "notes.title", "notes.body")
+	if err != nil {
+		return nil, 0, err
+	}
+	return notes, total, r.codec.decodeAll(notes)
+}
+
+// SearchFolders matches the name of folders userID owns or that are shared
+// with them
+func (r *SearchRepository) SearchFolders(ctx context.Context, userID uuid.UUID, q string, limit int) ([]models.Folder, int64, error) {
+	visible := func(db *gorm.DB) *gorm.DB {
+		return db.Where("(folders.owner_id = ? OR folders.id IN (?))", userID,
+			r.db.Model(&models.FolderShare{}).Select("folder_id").Where("user_id = ?", userID))
+	}
+	return search[models.Folder](r.db.WithContext(ctx), q, limit, visible, "folders.name")
+}
+
+// SearchTeams matches the name of the teams of orgID, or of the default
+// tenant when nil
+func (r *SearchRepository) SearchTeams(ctx context.Context, orgID *uuid.UUID, q string, limit int) ([]models.Team, int64, error) {
+	return search[models.Team](r.db.WithContext(ctx), q, limit, inOrganization(orgID), "teams.name")
+}
+
+// SearchUsers matches the username and email of the users of orgID, or of
+// the default tenant when nil
+func (r *SearchRepository) SearchUsers(ctx context.Context, orgID *uuid.UUID, q string, limit int) ([]models.User, int64, error) {
+	return search[models.User](r.db.WithContext(ctx), q, limit, inOrganization(orgID), "users.username", "users.email")
+}
+
+// search returns the rows in scope whose column or any of also contains q.
+// Rows whose column equals q come first, then those where it starts with
+// q, then the rest, most recently updated first within each group.
+func search[T any](db *gorm.DB, q string, limit int, scope func(*gorm.DB) *gorm.DB, column string, also ...string) ([]T, int64, error) {
+	pattern := likePattern(q)
+	like := " " + database.ILike(db) + " ?"
+	conditions := make([]string, 0, len(also)+1)
+	vars := make([]interface{}, 0, len(also)+1)
+	for _, c := range append([]string{column}, also...) {
+		conditions = append(conditions, c+like)
+		vars = append(vars, pattern)
+	}
+
+	query := database.ReadReplica(db).Model(new(T)).Scopes(scope).
+		Where("("+strings.Join(conditions, " OR ")+")", vars...)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := gorm.Expr("CASE WHEN LOWER("+column+") = LOWER(?) THEN 0 WHEN "+column+like+" THEN 1 ELSE 2 END, ? DESC",
+		q, prefixPattern(q), clause.Column{Table: clause.CurrentTable, Name: "updated_at"})
+	var rows []T
+	err := query.Order(clause.OrderBy{Expression: order}).Limit(limit).Find(&rows).Error
+	return rows, total, err
+}
+
+func prefixPattern(value string) string {
+	return strings.TrimPrefix(likePattern(value), "%")
+}
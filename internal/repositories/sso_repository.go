@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// SSORepository stores the SAML connections of organizations and the user
+// changes single sign-on makes
+type SSORepository struct {
+	db *gorm.DB
+}
+
+func NewSSORepository(db *gorm.DB) *SSORepository {
+	return &SSORepository{db: db}
+}
+
+// GetOrganizationBySlug returns the organization with slug
+func (r *SSORepository) GetOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	var org models.Organization
+	err := r.db.WithContext(ctx).Where("slug = ?", slug).First(&org).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("organization not found")
+		}
+		return nil, err
+	}
+	return &org, nil
+}
+
+// GetConnection returns the SAML connection of orgID
+func (r *SSORepository) GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SAMLConnection, error) {
+	var conn models.SAMLConnection
+	err := r.db.WithContext(ctx).Where("organization_id = ?", orgID).First(&conn).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.NotFound("SAML connection not found")
+		}
+		return nil, err
+	}
+	return &conn, nil
+}
+
+// SaveConnection creates the SAML connection of its organization or
+// replaces the existing one
+func (r *SSORepository) SaveConnection(ctx context.Context, conn *models.SAMLConnection) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []models.SAMLConnection
+		if err := tx.Where("organization_id = ?", conn.OrganizationID).Limit(1).Find(&existing).Error; err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			return tx.Create(conn).Error
+		}
+		conn.ID = existing[0].ID
+		conn.CreatedAt = existing[0].CreatedAt
+		return tx.Save(conn).Error
+	})
+}
+
+// SetUserRole changes the role of userID
+func (r *SSORepository) SetUserRole(ctx context.Context, userID uuid.UUID, role models.UserRole) error {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("user not found")
+	}
+	return nil
+}
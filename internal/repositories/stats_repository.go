@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"seta-training/internal/audit"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+)
+
+// StatsRepository runs the aggregate queries behind the admin statistics.
+// It reads a replica: the dashboard tolerates lag.
+type StatsRepository struct {
+	db *gorm.DB
+}
+
+func NewStatsRepository(db *gorm.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+func (r *StatsRepository) Totals(ctx context.Context) (models.StatsTotals, error) {
+	db := database.ReadReplica(r.db.WithContext(ctx))
+	var t models.StatsTotals
+	var folderShares, noteShares int64
+	counts := []struct {
+		query *gorm.DB
+		dest  *int64
+	}{
+		{db.Model(&models.User{}), &t.Users},
+		{db.Model(&models.Team{}), &t.Teams},
+		{db.Model(&models.Folder{}), &t.Folders},
+		{db.Model(&models.Note{}), &t.Notes},
+		{db.Model(&models.FolderShare{}), &folderShares},
+		{db.Model(&models.NoteShare{}), &noteShares},
+		{db.Model(&models.AuditLog{}).Where("action = ?", audit.ActionImport), &t.Imports},
+	}
+	for _, c := range counts {
+		if err := c.query.Count(c.dest).Error; err != nil {
+			return models.StatsTotals{}, err
+		}
+	}
+	t.Shares = folderShares + noteShares
+	return t, nil
+}
+
+// dayCount is one row of a per-day count
+type dayCount struct {
+	Day   time.Time
+	Count int64
+}
+
+// Daily returns one entry per day in [from, to), both of which are
+// midnights UTC
+func (r *StatsRepository) Daily(ctx context.Context, from, to time.Time) ([]models.DayStats, error) {
+	days := make([]models.DayStats, 0, int(to.Sub(from).Hours()/24))
+	index := make(map[string]int)
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		index[day.Format(time.DateOnly)] = len(days)
+		days = append(days, models.DayStats{Day: day})
+	}
+
+	db := database.ReadReplica(r.db.WithContext(ctx))
+	created := func(model interface{}) *gorm.DB {
+		return db.Model(model).
+			Select("DATE(created_at) AS day, COUNT(*) AS count").
+			Where("created_at >= ? AND created_at < ?", from, to).
+			Group("DATE(created_at)")
+	}
+	series := []struct {
+		query *gorm.DB
+		field func(*models.DayStats) *int64
+	}{
+		{created(&models.User{}), func(d *models.DayStats) *int64 { return &d.Users }},
+		{created(&models.Team{}), func(d *models.DayStats) *int64 { return &d.Teams }},
+		{created(&models.Note{}), func(d *models.DayStats) *int64 { return &d.Notes }},
+		{created(&models.FolderShare{}), func(d *models.DayStats) *int64 { return &d.Shares }},
+		{created(&models.NoteShare{}), func(d *models.DayStats) *int64 { return &d.Shares }},
+		{created(&models.AuditLog{}).Where("action = ?", audit.ActionImport), func(d *models.DayStats) *int64 { return &d.Imports }},
+		{
+			db.Model(&models.APIUsage{}).
+				Select("day, COUNT(DISTINCT user_id) AS count").
+				Where("day >= ? AND day < ?", from, to).
+				Group("day"),
+			func(d *models.DayStats) *int64 { return &d.ActiveUsers },
+		},
+	}
+	for _, s := range series {
+		var rows []dayCount
+		if err := s.query.Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if i, ok := index[row.Day.UTC().Format(time.DateOnly)]; ok {
+				*s.field(&days[i]) += row.Count
+			}
+		}
+	}
+	return days, nil
+}
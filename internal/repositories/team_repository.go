@@ -1,99 +1,247 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
 	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
 )
 
 type TeamRepository struct {
-	db *gorm.DB
+	Repository[models.Team]
 }
 
 func NewTeamRepository(db *gorm.DB) *TeamRepository {
-	return &TeamRepository{db: db}
+	return &TeamRepository{Repository: NewRepository[models.Team](db, apperrors.NotFound("team not found"))}
 }
 
-func (r *TeamRepository) Create(team *models.Team) error {
-	return r.db.Create(team).Error
+// Create inserts team and a team.created outbox event in one transaction
+func (r *TeamRepository) Create(ctx context.Context, team *models.Team) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(team).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventTeamCreated, team.ID, models.TeamCreatedEvent{
+			TeamID: team.ID,
+			Name:   team.Name,
+		})
+	})
 }
 
-func (r *TeamRepository) GetByID(id uuid.UUID) (*models.Team, error) {
+func (r *TeamRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Team, error) {
 	var team models.Team
-	err := r.db.Preload("Managers").Preload("Members").Where("id = ?", id).First(&team).Error
+	err := r.db.WithContext(ctx).Scopes(withPeople).Where("id = ?", id).First(&team).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("team not found")
+			return nil, apperrors.NotFound("team not found")
 		}
 		return nil, err
 	}
+	team.GroupMemberships()
 	return &team, nil
 }
 
-func (r *TeamRepository) GetAll() ([]models.Team, error) {
+// GetAll returns the teams of orgID, or of the default tenant when nil
+func (r *TeamRepository) GetAll(ctx context.Context, orgID *uuid.UUID) ([]models.Team, error) {
 	var teams []models.Team
-	err := r.db.Preload("Managers").Preload("Members").Find(&teams).Error
+	err := database.ReadReplica(r.db.WithContext(ctx)).Scopes(inOrganization(orgID), withPeople).Find(&teams).Error
+	for i := range teams {
+		teams[i].GroupMemberships()
+	}
 	return teams, err
 }
 
-func (r *TeamRepository) Update(team *models.Team) error {
-	return r.db.Save(team).Error
+// ListPage returns one page of the teams of orgID, or of the default tenant
+// when nil
+func (r *TeamRepository) ListPage(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.Team], error) {
+	page, err := r.ListAfter(ctx, p, func(t models.Team) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: t.CreatedAt, ID: t.ID}
+	}, inOrganization(orgID), withPeople)
+	for i := range page.Items {
+		page.Items[i].GroupMemberships()
+	}
+	return page, err
 }
 
-func (r *TeamRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Team{}, id).Error
+// withPeople preloads the memberships of teams with their users
+func withPeople(db *gorm.DB) *gorm.DB {
+	return db.Preload("Memberships", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, user_id")
+	}).Preload("Memberships.User")
 }
 
-func (r *TeamRepository) AddManager(teamID, userID uuid.UUID) error {
-	return r.db.Create(&models.TeamManager{
-		TeamID: teamID,
-		UserID: userID,
-	}).Error
+// AddMembership adds userID to the team with role, and writes a
+// team.member.added outbox event in the same transaction. Users of another
+// organization are reported as not found, and users already in the team are
+// rejected.
+func (r *TeamRepository) AddMembership(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := requireSameOrganization(tx, &models.Team{}, teamID, userID); err != nil {
+			return err
+		}
+		err := tx.Create(&models.TeamMembership{
+			TeamID: teamID,
+			UserID: userID,
+			Role:   role,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := syncTeamFolder(tx, teamID, userID); err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventTeamMemberAdded, teamID, models.TeamMemberEvent{
+			TeamID: teamID,
+			UserID: userID,
+			Role:   role,
+		})
+	})
 }
 
-func (r *TeamRepository) RemoveManager(teamID, userID uuid.UUID) error {
-	return r.db.Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&models.TeamManager{}).Error
+// SetRole changes the role of someone already in the team
+func (r *TeamRepository) SetRole(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.TeamMembership{}).Where("team_id = ? AND user_id = ?", teamID, userID).Update("role", role)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.NotFound("user is not in the team")
+		}
+		return syncTeamFolder(tx, teamID, userID)
+	})
 }
 
-func (r *TeamRepository) AddMember(teamID, userID uuid.UUID) error {
-	return r.db.Create(&models.TeamMember{
-		TeamID: teamID,
-		UserID: userID,
-	}).Error
+// RemoveMembership removes userID from the team and, if they were in it,
+// writes a team.member.removed outbox event in the same transaction
+func (r *TeamRepository) RemoveMembership(ctx context.Context, teamID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&models.TeamMembership{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := syncTeamFolder(tx, teamID, userID); err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventTeamMemberRemoved, teamID, models.TeamMemberEvent{
+			TeamID: teamID,
+			UserID: userID,
+		})
+	})
 }
 
-func (r *TeamRepository) RemoveMember(teamID, userID uuid.UUID) error {
-	return r.db.Where("team_id = ? AND user_id = ?", teamID, userID).Delete(&models.TeamMember{}).Error
+// syncTeamFolder keeps the team's shared folder, if it has one, shared with
+// userID exactly while the user is in the team: with read access for
+// viewers and write access for everyone else. It runs in the transaction of
+// each membership change. The folder's owner is left alone.
+func syncTeamFolder(tx *gorm.DB, teamID, userID uuid.UUID) error {
+	var team models.Team
+	if err := tx.Select("id", "folder_id").Where("id = ?", teamID).First(&team).Error; err != nil || team.FolderID == nil {
+		return err
+	}
+	var folders []models.Folder
+	if err := tx.Select("id", "owner_id").Where("id = ?", *team.FolderID).Limit(1).Find(&folders).Error; err != nil || len(folders) == 0 {
+		return err
+	}
+	folderID := folders[0].ID
+	if folders[0].OwnerID == userID {
+		return nil
+	}
+
+	var memberships []models.TeamMembership
+	if err := tx.Where("team_id = ? AND user_id = ?", teamID, userID).Limit(1).Find(&memberships).Error; err != nil {
+		return err
+	}
+	var shares []models.FolderShare
+	if err := tx.Where("folder_id = ? AND user_id = ?", folderID, userID).Find(&shares).Error; err != nil {
+		return err
+	}
+
+	switch {
+	case len(memberships) > 0:
+		access := models.AccessWrite
+		if memberships[0].Role == models.TeamRoleViewer {
+			access = models.AccessRead
+		}
+		if len(shares) == 0 {
+			if err := tx.Create(&models.FolderShare{FolderID: folderID, UserID: userID, Access: access}).Error; err != nil {
+				return err
+			}
+		} else if shares[0].Access != access {
+			if err := tx.Model(&models.FolderShare{}).Where("folder_id = ? AND user_id = ?", folderID, userID).Update("access", access).Error; err != nil {
+				return err
+			}
+		} else {
+			return nil
+		}
+		return enqueueEvent(tx, models.EventFolderShared, folderID, models.FolderSharedEvent{
+			FolderID: folderID,
+			UserID:   userID,
+			Access:   access,
+		})
+	case len(shares) > 0:
+		if err := tx.Where("folder_id = ? AND user_id = ?", folderID, userID).Delete(&models.FolderShare{}).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.EventFolderUnshared, folderID, models.FolderUnsharedEvent{
+			FolderID: folderID,
+			UserID:   userID,
+		})
+	}
+	return nil
 }
 
-func (r *TeamRepository) IsManager(teamID, userID uuid.UUID) (bool, error) {
-	var count int64
-	err := r.db.Model(&models.TeamManager{}).Where("team_id = ? AND user_id = ?", teamID, userID).Count(&count).Error
-	return count > 0, err
+// GetRole returns the role userID holds in the team, or an empty role when
+// they are not in it
+func (r *TeamRepository) GetRole(ctx context.Context, teamID, userID uuid.UUID) (models.TeamRole, error) {
+	var roles []models.TeamRole
+	err := r.db.WithContext(ctx).Model(&models.TeamMembership{}).Where("team_id = ? AND user_id = ?", teamID, userID).Limit(1).Pluck("role", &roles).Error
+	if err != nil || len(roles) == 0 {
+		return "", err
+	}
+	return roles[0], nil
 }
 
-func (r *TeamRepository) IsMember(teamID, userID uuid.UUID) (bool, error) {
-	var count int64
-	err := r.db.Model(&models.TeamMember{}).Where("team_id = ? AND user_id = ?", teamID, userID).Count(&count).Error
-	return count > 0, err
+// GetUserTeamRoles returns the role the user holds in each of their teams
+func (r *TeamRepository) GetUserTeamRoles(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]models.TeamRole, error) {
+	var memberships []models.TeamMembership
+	err := database.ReadReplica(r.db.WithContext(ctx)).Where("user_id = ?", userID).Find(&memberships).Error
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[uuid.UUID]models.TeamRole, len(memberships))
+	for _, membership := range memberships {
+		roles[membership.TeamID] = membership.Role
+	}
+	return roles, nil
 }
 
-func (r *TeamRepository) GetTeamsByManager(userID uuid.UUID) ([]models.Team, error) {
+// GetUserTeams returns the teams userID is in, whatever their role, without
+// their people
+func (r *TeamRepository) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]models.Team, error) {
 	var teams []models.Team
-	err := r.db.Joins("JOIN team_managers ON teams.id = team_managers.team_id").
-		Where("team_managers.user_id = ?", userID).
-		Preload("Managers").Preload("Members").
+	err := database.ReadReplica(r.db.WithContext(ctx)).
+		Where("id IN (?)", r.db.Model(&models.TeamMembership{}).Select("team_id").Where("user_id = ?", userID)).
+		Order("created_at, id").
 		Find(&teams).Error
 	return teams, err
 }
 
-func (r *TeamRepository) GetTeamsByMember(userID uuid.UUID) ([]models.Team, error) {
-	var teams []models.Team
-	err := r.db.Joins("JOIN team_members ON teams.id = team_members.team_id").
-		Where("team_members.user_id = ?", userID).
-		Preload("Managers").Preload("Members").
-		Find(&teams).Error
-	return teams, err
+// UpdateSettings replaces the settings of a team
+func (r *TeamRepository) UpdateSettings(ctx context.Context, teamID uuid.UUID, settings models.TeamSettings) error {
+	result := r.db.WithContext(ctx).Model(&models.Team{}).Where("id = ?", teamID).
+		Select("allow_external_sharing", "allow_public_links", "default_access", "updated_at").
+		Updates(&models.Team{Settings: settings})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.NotFound("team not found")
+	}
+	return nil
 }
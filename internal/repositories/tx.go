@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Stores are the repositories a unit of work can write through. Inside
+// TransactionManager.WithTx they are bound to the transaction.
+type Stores struct {
+	Users          UserRepositoryInterface
+	Teams          TeamRepositoryInterface
+	Folders        FolderRepositoryInterface
+	Notes          NoteRepositoryInterface
+	AccessRequests AccessRequestRepositoryInterface
+}
+
+// TransactionManager runs multi-step operations as one unit of work
+type TransactionManager interface {
+	// WithTx calls fn with stores bound to a new transaction, committing it
+	// when fn returns nil and rolling it back otherwise
+	WithTx(ctx context.Context, fn func(stores Stores) error) error
+}
+
+// TxManager runs units of work in database transactions. Repository writes
+// that open their own transaction become savepoints inside it. Repositories
+// run their statements with the context they are called with, so units of
+// work pass the stores the context given to WithTx.
+type TxManager struct {
+	db    *gorm.DB
+	notes *NoteRepository
+}
+
+// NewTxManager creates a transaction manager. notes supplies the note body
+// compression settings for the note store.
+func NewTxManager(db *gorm.DB, notes *NoteRepository) *TxManager {
+	return &TxManager{db: db, notes: notes}
+}
+
+func (m *TxManager) WithTx(ctx context.Context, fn func(stores Stores) error) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(Stores{
+			Users:          NewUserRepository(tx),
+			Teams:          NewTeamRepository(tx),
+			Folders:        NewFolderRepository(tx, m.notes.codec),
+			Notes:          m.notes.withDB(tx),
+			AccessRequests: NewAccessRequestRepository(tx),
+		})
+	})
+}
+
+// NoTx runs units of work directly against its stores without a
+// transaction. Services fall back to it when given no TransactionManager,
+// and tests use it to run against mocks.
+type NoTx struct {
+	Stores Stores
+}
+
+func (n NoTx) WithTx(ctx context.Context, fn func(stores Stores) error) error {
+	return fn(n.Stores)
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/models"
+)
+
+type UserPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewUserPreferenceRepository(db *gorm.DB) *UserPreferenceRepository {
+	return &UserPreferenceRepository{db: db}
+}
+
+// GetByUserID returns the user's preferences, or the defaults when they
+// have never saved any
+func (r *UserPreferenceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.UserPreference, error) {
+	var pref models.UserPreference
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&pref).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return models.DefaultUserPreference(userID), nil
+		}
+		return nil, err
+	}
+	return &pref, nil
+}
+
+// Save inserts or replaces the user's preferences
+func (r *UserPreferenceRepository) Save(ctx context.Context, pref *models.UserPreference) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		UpdateAll: true,
+	}).Create(pref).Error
+}
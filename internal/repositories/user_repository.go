@@ -1,83 +1,87 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
 	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
 )
 
 type UserRepository struct {
-	db *gorm.DB
+	Repository[models.User]
 }
 
 func NewUserRepository(db *gorm.DB) *UserRepository {
-	return &UserRepository{db: db}
+	return &UserRepository{Repository: NewRepository[models.User](db, apperrors.NotFound("user not found"))}
 }
 
-func (r *UserRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
-}
-
-func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
-	var user models.User
-	err := r.db.Where("id = ?", id).First(&user).Error
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+// Create inserts user and a user.created outbox event in one transaction
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
 		}
-		return nil, err
-	}
-	return &user, nil
+		return enqueueEvent(tx, models.EventUserCreated, user.ID, models.UserCreatedEvent{
+			UserID:   user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+			Role:     user.Role,
+		})
+	})
 }
 
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, apperrors.NotFound("user not found")
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, apperrors.NotFound("user not found")
 		}
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) GetAll() ([]models.User, error) {
+// GetAll returns the users of orgID, or of the default tenant when nil
+func (r *UserRepository) GetAll(ctx context.Context, orgID *uuid.UUID) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Find(&users).Error
+	err := database.ReadReplica(r.db.WithContext(ctx)).Scopes(inOrganization(orgID)).Find(&users).Error
 	return users, err
 }
 
-func (r *UserRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
-}
-
-func (r *UserRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.User{}, id).Error
+// ListPage returns one page of the users of orgID, or of the default tenant
+// when nil
+func (r *UserRepository) ListPage(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.User], error) {
+	return r.ListAfter(ctx, p, func(u models.User) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
+	}, inOrganization(orgID))
 }
 
-func (r *UserRepository) EmailExists(email string) (bool, error) {
+func (r *UserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count).Error
 	return count > 0, err
 }
 
-func (r *UserRepository) UsernameExists(username string) (bool, error) {
+func (r *UserRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("username = ?", username).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("username = ?", username).Count(&count).Error
 	return count > 0, err
 }
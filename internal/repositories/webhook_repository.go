@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+)
+
+type WebhookRepository struct {
+	Repository[models.Webhook]
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{Repository: NewRepository[models.Webhook](db, apperrors.NotFound("webhook not found"))}
+}
+
+func (r *WebhookRepository) GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := r.db.WithContext(ctx).Where("owner_id = ?", ownerID).Order("created_at").Find(&hooks).Error
+	return hooks, err
+}
+
+// GetSubscribed returns the active webhooks subscribed to eventType
+func (r *WebhookRepository) GetSubscribed(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	err := r.db.WithContext(ctx).Where("active = ? AND "+database.JSONArrayContains(r.db, "event_types"), true, eventType).
+		Find(&hooks).Error
+	return hooks, err
+}
+
+// Delete removes the webhook and its delivery log
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Webhook{}, id).Error
+	})
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Create(delivery).Error
+}
+
+// GetDeliveries returns the most recent deliveries to a webhook, newest first
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("webhook_id = ?", webhookID).
+		Order("created_at DESC").Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
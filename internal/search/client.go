@@ -0,0 +1,162 @@
+// Package search keeps an Elasticsearch or OpenSearch index of notes,
+// folders, teams and users up to date from domain events, and answers
+// searches from it. It is used instead of the database search when
+// search.elasticsearch_url is set.
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// indexMapping declares the fields of Document. Identifiers are keywords so
+// visibility filters match them exactly.
+var indexMapping = map[string]interface{}{
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"type":            map[string]string{"type": "keyword"},
+			"entity_id":       map[string]string{"type": "keyword"},
+			"title":           map[string]string{"type": "text"},
+			"body":            map[string]string{"type": "text"},
+			"owner_id":        map[string]string{"type": "keyword"},
+			"shared_with":     map[string]string{"type": "keyword"},
+			"folder_id":       map[string]string{"type": "keyword"},
+			"organization_id": map[string]string{"type": "keyword"},
+			"updated_at":      map[string]string{"type": "date"},
+		},
+	},
+}
+
+// Client talks to one index over the Elasticsearch REST API, which
+// OpenSearch implements too
+type Client struct {
+	baseURL  string
+	index    string
+	username string
+	password string
+	http     *http.Client
+}
+
+// NewClient creates a client for index on the cluster at baseURL. username
+// may be empty when the cluster doesn't require authentication.
+func NewClient(baseURL, index, username, password string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		index:    index,
+		username: username,
+		password: password,
+		http:     &http.Client{Timeout: timeout},
+	}
+}
+
+// Ping reports whether the cluster answers
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, http.MethodGet, "/", nil, nil)
+}
+
+// EnsureIndex creates the index with its mapping unless it exists
+func (c *Client) EnsureIndex(ctx context.Context) error {
+	err := c.do(ctx, http.MethodHead, c.path(), nil, nil)
+	if !isNotFound(err) {
+		return err
+	}
+	return c.do(ctx, http.MethodPut, c.path(), indexMapping, nil)
+}
+
+// Index creates or replaces doc
+func (c *Client) Index(ctx context.Context, doc Document) error {
+	return c.do(ctx, http.MethodPut, c.path("_doc", doc.ID()), doc, nil)
+}
+
+// Delete removes the document with id. A missing document is not an error.
+func (c *Client) Delete(ctx context.Context, id string) error {
+	err := c.do(ctx, http.MethodDelete, c.path("_doc", id), nil, nil)
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// DeleteByQuery removes every document matching query
+func (c *Client) DeleteByQuery(ctx context.Context, query map[string]interface{}) error {
+	return c.do(ctx, http.MethodPost, c.path("_delete_by_query")+"?conflicts=proceed",
+		map[string]interface{}{"query": query}, nil)
+}
+
+// Search runs the search request body and decodes the response into out
+func (c *Client) Search(ctx context.Context, body map[string]interface{}, out interface{}) error {
+	return c.do(ctx, http.MethodPost, c.path("_search"), body, out)
+}
+
+// path joins the index and segments into an escaped request path
+func (c *Client) path(segments ...string) string {
+	p := "/" + url.PathEscape(c.index)
+	for _, s := range segments {
+		p += "/" + url.PathEscape(s)
+	}
+	return p
+}
+
+// statusError is returned for responses outside 2xx
+type statusError struct {
+	status int
+	body   string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("search cluster returned %d: %s", e.status, e.body)
+}
+
+func isNotFound(err error) bool {
+	se, ok := err.(*statusError)
+	return ok && se.status == http.StatusNotFound
+}
+
+// do sends body as JSON and decodes a successful response into out when
+// both are non-nil
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode search request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("search cluster unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &statusError{status: resp.StatusCode, body: string(msg)}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode search response: %w", err)
+	}
+	return nil
+}
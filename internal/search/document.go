@@ -0,0 +1,87 @@
+package search
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/models"
+	"seta-training/internal/services"
+)
+
+// Document is the indexed form of a note, folder, team or user. Notes and
+// folders are visible to their owner and the users in SharedWith; teams and
+// users to the members of their organization.
+type Document struct {
+	Type     string    `json:"type"`
+	EntityID uuid.UUID `json:"entity_id"`
+	Title    string    `json:"title"`
+	// Body is the plain text of a note or the email of a user
+	Body           string      `json:"body,omitempty"`
+	OwnerID        *uuid.UUID  `json:"owner_id,omitempty"`
+	SharedWith     []uuid.UUID `json:"shared_with,omitempty"`
+	FolderID       *uuid.UUID  `json:"folder_id,omitempty"`
+	OrganizationID *uuid.UUID  `json:"organization_id,omitempty"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+}
+
+// ID is the document's ID in the index, e.g. "note:<uuid>"
+func (d Document) ID() string {
+	return documentID(d.Type, d.EntityID)
+}
+
+func documentID(typ string, id uuid.UUID) string {
+	return typ + ":" + id.String()
+}
+
+func noteDocument(note *models.Note, sanitizer *services.NoteSanitizer) Document {
+	shared := make([]uuid.UUID, 0, len(note.Shares))
+	for _, share := range note.Shares {
+		shared = append(shared, share.UserID)
+	}
+	return Document{
+		Type:       services.SearchTypeNote,
+		EntityID:   note.ID,
+		Title:      sanitizer.Title(note.Title),
+		Body:       sanitizer.Text(note.Body),
+		OwnerID:    &note.OwnerID,
+		SharedWith: shared,
+		FolderID:   &note.FolderID,
+		UpdatedAt:  note.UpdatedAt,
+	}
+}
+
+func folderDocument(folder *models.Folder) Document {
+	shared := make([]uuid.UUID, 0, len(folder.Shares))
+	for _, share := range folder.Shares {
+		shared = append(shared, share.UserID)
+	}
+	return Document{
+		Type:       services.SearchTypeFolder,
+		EntityID:   folder.ID,
+		Title:      folder.Name,
+		OwnerID:    &folder.OwnerID,
+		SharedWith: shared,
+		UpdatedAt:  folder.UpdatedAt,
+	}
+}
+
+func teamDocument(team *models.Team) Document {
+	return Document{
+		Type:           services.SearchTypeTeam,
+		EntityID:       team.ID,
+		Title:          team.Name,
+		OrganizationID: team.OrganizationID,
+		UpdatedAt:      team.UpdatedAt,
+	}
+}
+
+func userDocument(user *models.User) Document {
+	return Document{
+		Type:           services.SearchTypeUser,
+		EntityID:       user.ID,
+		Title:          user.Username,
+		Body:           user.Email,
+		OrganizationID: user.OrganizationID,
+		UpdatedAt:      user.UpdatedAt,
+	}
+}
@@ -0,0 +1,145 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/services"
+	"seta-training/pkg/events"
+	"seta-training/pkg/logger"
+)
+
+// EventTypes are the domain events the indexer handles. Each names the
+// indexed entity in its aggregate ID.
+var EventTypes = []string{
+	models.EventUserCreated,
+	models.EventUserOrganizationChanged,
+	models.EventTeamCreated,
+	models.EventFolderCreated,
+	models.EventFolderUpdated,
+	models.EventFolderShared,
+	models.EventFolderUnshared,
+	models.EventFolderDeleted,
+	models.EventNoteCreated,
+	models.EventNoteUpdated,
+	models.EventNoteShared,
+	models.EventNoteUnshared,
+	models.EventNoteDeleted,
+}
+
+// Indexer keeps the index in step with the database. It reloads the entity
+// an event is about and indexes its current state, so events may arrive
+// more than once or out of order.
+type Indexer struct {
+	client    *Client
+	stores    repositories.Stores
+	sanitizer *services.NoteSanitizer
+	logger    logger.Logger
+}
+
+// NewIndexer creates an indexer reading entities from stores. log may be nil.
+func NewIndexer(client *Client, stores repositories.Stores, log logger.Logger) *Indexer {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &Indexer{
+		client:    client,
+		stores:    stores,
+		sanitizer: services.NewNoteSanitizer(),
+		logger:    log,
+	}
+}
+
+// Handle indexes the entity event is about, or removes it from the index
+// when it has been deleted. It is an events.Handler.
+func (i *Indexer) Handle(ctx context.Context, event events.Event) error {
+	id := event.AggregateID
+	var err error
+	switch event.Type {
+	case models.EventUserCreated, models.EventUserOrganizationChanged:
+		err = i.indexUser(ctx, id)
+	case models.EventTeamCreated:
+		err = i.indexTeam(ctx, id)
+	case models.EventFolderDeleted:
+		err = i.deleteFolder(ctx, id)
+	case models.EventFolderCreated, models.EventFolderUpdated, models.EventFolderShared, models.EventFolderUnshared:
+		err = i.indexFolder(ctx, id)
+	case models.EventNoteDeleted:
+		err = i.client.Delete(ctx, documentID(services.SearchTypeNote, id))
+	case models.EventNoteCreated, models.EventNoteUpdated, models.EventNoteShared, models.EventNoteUnshared:
+		err = i.indexNote(ctx, id)
+	default:
+		return nil
+	}
+	if err != nil {
+		i.logger.Error("Failed to index domain event",
+			logger.String("event_id", event.ID.String()),
+			logger.String("event_type", event.Type),
+			logger.String("aggregate_id", id.String()),
+			logger.Error(err),
+		)
+		return fmt.Errorf("failed to index %s: %w", event.Type, err)
+	}
+	return nil
+}
+
+func (i *Indexer) indexUser(ctx context.Context, id uuid.UUID) error {
+	user, err := i.stores.Users.GetByID(ctx, id)
+	if err != nil {
+		return i.deleteIfMissing(ctx, services.SearchTypeUser, id, err)
+	}
+	return i.client.Index(ctx, userDocument(user))
+}
+
+func (i *Indexer) indexTeam(ctx context.Context, id uuid.UUID) error {
+	team, err := i.stores.Teams.GetByID(ctx, id)
+	if err != nil {
+		return i.deleteIfMissing(ctx, services.SearchTypeTeam, id, err)
+	}
+	return i.client.Index(ctx, teamDocument(team))
+}
+
+func (i *Indexer) indexFolder(ctx context.Context, id uuid.UUID) error {
+	folder, err := i.stores.Folders.GetByID(ctx, id)
+	if err != nil {
+		return i.deleteIfMissing(ctx, services.SearchTypeFolder, id, err)
+	}
+	return i.client.Index(ctx, folderDocument(folder))
+}
+
+func (i *Indexer) indexNote(ctx context.Context, id uuid.UUID) error {
+	note, err := i.stores.Notes.GetByID(ctx, id)
+	if err != nil {
+		return i.deleteIfMissing(ctx, services.SearchTypeNote, id, err)
+	}
+	return i.client.Index(ctx, noteDocument(note, i.sanitizer))
+}
+
+// deleteFolder removes the folder and the notes that were deleted with it
+func (i *Indexer) deleteFolder(ctx context.Context, id uuid.UUID) error {
+	if err := i.client.Delete(ctx, documentID(services.SearchTypeFolder, id)); err != nil {
+		return err
+	}
+	return i.client.DeleteByQuery(ctx, map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []interface{}{
+				term("type", services.SearchTypeNote),
+				term("folder_id", id.String()),
+			},
+		},
+	})
+}
+
+// deleteIfMissing handles a failure to load an entity: one that no longer
+// exists is removed from the index, any other error is returned
+func (i *Indexer) deleteIfMissing(ctx context.Context, typ string, id uuid.UUID, err error) error {
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return i.client.Delete(ctx, documentID(typ, id))
+	}
+	return err
+}
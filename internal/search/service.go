@@ -0,0 +1,139 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"seta-training/internal/services"
+)
+
+// Service answers searches from the index. Results are scored and ranked
+// like services.SearchService, so clients see the same order whichever
+// backend is configured.
+type Service struct {
+	client *Client
+}
+
+func NewService(client *Client) *Service {
+	return &Service{client: client}
+}
+
+// searchResponse is the part of a search response Service reads
+type searchResponse struct {
+	Hits struct {
+		Hits []struct {
+			Source Document `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		Types struct {
+			Buckets []struct {
+				Key      string `json:"key"`
+				DocCount int64  `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"types"`
+	} `json:"aggregations"`
+}
+
+// Search returns the notes and folders userID owns or has been shared, and
+// the teams and users of orgID, that match query
+func (s *Service) Search(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID, query services.SearchQuery) (*services.SearchResults, error) {
+	query = query.WithDefaults()
+	q := query.Text
+
+	var resp searchResponse
+	if err := s.client.Search(ctx, searchRequest(userID, orgID, query), &resp); err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+
+	results := services.NewSearchResults(q)
+	for _, typ := range query.Types {
+		results.Facets[typ] = 0
+	}
+	for _, bucket := range resp.Aggregations.Types.Buckets {
+		if _, ok := results.Facets[bucket.Key]; ok {
+			results.Facets[bucket.Key] = bucket.DocCount
+		}
+	}
+	for _, hit := range resp.Hits.Hits {
+		doc := hit.Source
+		snippet := doc.Body
+		if doc.Type == services.SearchTypeNote {
+			snippet = services.SearchSnippet(doc.Body, q)
+		}
+		results.Add(doc.Type, doc.EntityID, doc.Title, snippet, doc.UpdatedAt)
+	}
+	results.Rank(query.Limit)
+	return results, nil
+}
+
+// searchRequest matches query in titles and bodies of the requested types,
+// limited to what userID can open
+func searchRequest(userID uuid.UUID, orgID *uuid.UUID, query services.SearchQuery) map[string]interface{} {
+	personal := map[string]interface{}{
+		"bool": map[string]interface{}{
+			"filter": []interface{}{
+				terms("type", services.SearchTypeNote, services.SearchTypeFolder),
+			},
+			"should": []interface{}{
+				term("owner_id", userID.String()),
+				term("shared_with", userID.String()),
+			},
+			"minimum_should_match": 1,
+		},
+	}
+
+	// Teams and users without an organization belong to the default tenant
+	organization := map[string]interface{}{
+		"filter": []interface{}{terms("type", services.SearchTypeTeam, services.SearchTypeUser)},
+	}
+	if orgID != nil {
+		organization["filter"] = append(organization["filter"].([]interface{}), term("organization_id", orgID.String()))
+	} else {
+		organization["must_not"] = []interface{}{
+			map[string]interface{}{"exists": map[string]string{"field": "organization_id"}},
+		}
+	}
+
+	return map[string]interface{}{
+		"size": query.Limit,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": []interface{}{
+					map[string]interface{}{
+						"multi_match": map[string]interface{}{
+							"query":    query.Text,
+							"type":     "bool_prefix",
+							"fields":   []string{"title^3", "body"},
+							"operator": "and",
+						},
+					},
+				},
+				"filter": []interface{}{
+					terms("type", query.Types...),
+					map[string]interface{}{
+						"bool": map[string]interface{}{
+							"should":               []interface{}{personal, map[string]interface{}{"bool": organization}},
+							"minimum_should_match": 1,
+						},
+					},
+				},
+			},
+		},
+		"sort": []interface{}{"_score", map[string]string{"updated_at": "desc"}},
+		"aggs": map[string]interface{}{
+			"types": map[string]interface{}{
+				"terms": map[string]interface{}{"field": "type", "size": len(services.SearchTypes)},
+			},
+		},
+	}
+}
+
+func term(field, value string) map[string]interface{} {
+	return map[string]interface{}{"term": map[string]string{field: value}}
+}
+
+func terms(field string, values ...string) map[string]interface{} {
+	return map[string]interface{}{"terms": map[string][]string{field: values}}
+}
@@ -0,0 +1,325 @@
+// Package seed fills a database with users, teams, folders and notes
+// described in a YAML file, for local development and demo environments.
+package seed
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/services"
+	"seta-training/pkg/logger"
+)
+
+// File is the seed file layout. Users are referenced by username elsewhere.
+type File struct {
+	Users   []User   `yaml:"users"`
+	Teams   []Team   `yaml:"teams"`
+	Folders []Folder `yaml:"folders"`
+}
+
+type User struct {
+	Username string          `yaml:"username"`
+	Email    string          `yaml:"email"`
+	Password string          `yaml:"password"`
+	Role     models.UserRole `yaml:"role"`
+}
+
+type Team struct {
+	Name     string   `yaml:"name"`
+	Managers []string `yaml:"managers"`
+	Members  []string `yaml:"members"`
+}
+
+type Folder struct {
+	Name   string  `yaml:"name"`
+	Owner  string  `yaml:"owner"`
+	Shares []Share `yaml:"shares"`
+	Notes  []Note  `yaml:"notes"`
+}
+
+type Share struct {
+	User   string             `yaml:"user"`
+	Access models.AccessLevel `yaml:"access"`
+}
+
+type Note struct {
+	Title string `yaml:"title"`
+	Body  string `yaml:"body"`
+}
+
+// LoadFile reads and decodes a seed file, rejecting unknown keys
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed file: %w", err)
+	}
+
+	var file File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// Seeder applies a seed file. Running it again is safe: users are matched by
+// email, teams by name, folders by owner and name and notes by folder and
+// title, and existing records are left as they are.
+type Seeder struct {
+	db     *gorm.DB
+	codec  *repositories.NoteBodyCodec
+	logger logger.Logger
+}
+
+// NewSeeder creates a seeder storing note bodies with codec
+func NewSeeder(db *gorm.DB, codec *repositories.NoteBodyCodec, log logger.Logger) *Seeder {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &Seeder{
+		db:     db,
+		codec:  codec,
+		logger: log,
+	}
+}
+
+// Run applies file in a single transaction
+func (s *Seeder) Run(ctx context.Context, file *File) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		userRepo := repositories.NewUserRepository(tx)
+		folderRepo := repositories.NewFolderRepository(tx, s.codec)
+		noteRepo := repositories.NewNoteRepository(tx, s.codec)
+
+		run := &seedRun{
+			ctx:           ctx,
+			logger:        s.logger,
+			userRepo:      userRepo,
+			teamRepo:      repositories.NewTeamRepository(tx),
+			folderRepo:    folderRepo,
+			noteRepo:      noteRepo,
+			userService:   services.NewUserService(userRepo, nil, nil, nil, nil, nil, nil),
+			folderService: services.NewFolderService(folderRepo, noteRepo, nil, nil, nil, nil, nil),
+			noteService:   services.NewNoteService(noteRepo, folderRepo, nil, nil, nil, nil, nil, nil),
+			users:         make(map[string]uuid.UUID),
+		}
+		return run.apply(file)
+	})
+}
+
+type seedRun struct {
+	ctx           context.Context
+	logger        logger.Logger
+	userRepo      *repositories.UserRepository
+	teamRepo      *repositories.TeamRepository
+	folderRepo    *repositories.FolderRepository
+	noteRepo      *repositories.NoteRepository
+	userService   *services.UserService
+	folderService *services.FolderService
+	noteService   *services.NoteService
+
+	// users maps seed usernames to user IDs
+	users map[string]uuid.UUID
+}
+
+func (r *seedRun) apply(file *File) error {
+	for _, u := range file.Users {
+		if err := r.seedUser(u); err != nil {
+			return fmt.Errorf("user %q: %w", u.Username, err)
+		}
+	}
+	for _, t := range file.Teams {
+		if err := r.seedTeam(t); err != nil {
+			return fmt.Errorf("team %q: %w", t.Name, err)
+		}
+	}
+	for _, f := range file.Folders {
+		if err := r.seedFolder(f); err != nil {
+			return fmt.Errorf("folder %q: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *seedRun) seedUser(u User) error {
+	if existing, err := r.userRepo.GetByEmail(r.ctx, u.Email); err == nil {
+		r.users[u.Username] = existing.ID
+		r.logger.Info("Seed user already exists", logger.String("username", u.Username))
+		return nil
+	}
+
+	user, err := r.userService.CreateUser(r.ctx, &services.CreateUserInput{
+		Username: u.Username,
+		Email:    u.Email,
+		Password: u.Password,
+		Role:     u.Role,
+	})
+	if err != nil {
+		return err
+	}
+	r.users[u.Username] = user.ID
+	r.logger.Info("Seeded user", logger.String("username", u.Username), logger.String("role", string(u.Role)))
+	return nil
+}
+
+func (r *seedRun) userID(username string) (uuid.UUID, error) {
+	id, ok := r.users[username]
+	if !ok {
+		return uuid.Nil, fmt.Errorf("unknown user %q; list it under users", username)
+	}
+	return id, nil
+}
+
+func (r *seedRun) seedTeam(t Team) error {
+	teams, err := r.teamRepo.GetAll(r.ctx, nil)
+	if err != nil {
+		return err
+	}
+	var team *models.Team
+	for i := range teams {
+		if teams[i].Name == t.Name {
+			team = &teams[i]
+			break
+		}
+	}
+	if team == nil {
+		team = &models.Team{Name: t.Name}
+		if err := r.teamRepo.Create(r.ctx, team); err != nil {
+			return err
+		}
+		r.logger.Info("Seeded team", logger.String("team", t.Name))
+	}
+
+	for _, username := range t.Managers {
+		if err := r.seedMembership(team.ID, username, models.TeamRoleManager); err != nil {
+			return err
+		}
+	}
+	for _, username := range t.Members {
+		if err := r.seedMembership(team.ID, username, models.TeamRoleMember); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedMembership adds the user to the team with role unless they are
+// already in it
+func (r *seedRun) seedMembership(teamID uuid.UUID, username string, role models.TeamRole) error {
+	userID, err := r.userID(username)
+	if err != nil {
+		return err
+	}
+	current, err := r.teamRepo.GetRole(r.ctx, teamID, userID)
+	if err != nil || current != "" {
+		return err
+	}
+	return r.teamRepo.AddMembership(r.ctx, teamID, userID, role)
+}
+
+func (r *seedRun) seedFolder(f Folder) error {
+	ownerID, err := r.userID(f.Owner)
+	if err != nil {
+		return err
+	}
+
+	owned, err := r.folderRepo.GetByOwner(r.ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	var folder *models.Folder
+	for i := range owned {
+		if owned[i].Name == f.Name {
+			folder = &owned[i]
+			break
+		}
+	}
+	if folder == nil {
+		folder, err = r.folderService.CreateFolder(r.ctx, &services.CreateFolderInput{Name: f.Name}, ownerID)
+		if err != nil {
+			return err
+		}
+		r.logger.Info("Seeded folder", logger.String("folder", f.Name), logger.String("owner", f.Owner))
+	}
+
+	for _, share := range f.Shares {
+		userID, err := r.userID(share.User)
+		if err != nil {
+			return err
+		}
+		if _, err := r.folderRepo.GetUserAccess(r.ctx, folder.ID, userID); err == nil {
+			continue
+		}
+		if _, err := r.folderService.ShareFolder(r.ctx, folder.ID, &services.ShareFolderInput{
+			UserID: userID,
+			Access: share.Access,
+		}, ownerID); err != nil {
+			return err
+		}
+	}
+
+	existing, err := r.noteRepo.GetByFolder(r.ctx, folder.ID)
+	if err != nil {
+		return err
+	}
+	titles := make(map[string]bool, len(existing))
+	for _, note := range existing {
+		titles[note.Title] = true
+	}
+	for _, n := range f.Notes {
+		if titles[n.Title] {
+			continue
+		}
+		if _, err := r.noteService.CreateNote(r.ctx, folder.ID, &services.CreateNoteInput{
+			Title: n.Title,
+			Body:  n.Body,
+		}, ownerID); err != nil {
+			return fmt.Errorf("note %q: %w", n.Title, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks references and required fields before anything is written
+func (f *File) Validate() error {
+	var errs []error
+	usernames := make(map[string]bool, len(f.Users))
+	for _, u := range f.Users {
+		if u.Username == "" || u.Email == "" || u.Password == "" {
+			errs = append(errs, fmt.Errorf("user %q: username, email and password are required", u.Username))
+		}
+		if u.Role != models.RoleManager && u.Role != models.RoleMember {
+			errs = append(errs, fmt.Errorf("user %q: role must be manager or member", u.Username))
+		}
+		usernames[u.Username] = true
+	}
+
+	known := func(context, username string) {
+		if !usernames[username] {
+			errs = append(errs, fmt.Errorf("%s: unknown user %q", context, username))
+		}
+	}
+	for _, t := range f.Teams {
+		for _, username := range append(append([]string{}, t.Managers...), t.Members...) {
+			known("team "+t.Name, username)
+		}
+	}
+	for _, folder := range f.Folders {
+		known("folder "+folder.Name, folder.Owner)
+		for _, share := range folder.Shares {
+			known("folder "+folder.Name, share.User)
+			if share.Access != models.AccessRead && share.Access != models.AccessWrite {
+				errs = append(errs, fmt.Errorf("folder %s: access must be read or write", folder.Name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/i18n"
+	"seta-training/pkg/logger"
+	"seta-training/pkg/metrics"
+	"seta-training/pkg/pagination"
+)
+
+// AccessRequestService lets users ask the owner of a folder or note they
+// cannot open for access, and owners approve or deny them
+type AccessRequestService struct {
+	requestRepo      repositories.AccessRequestRepositoryInterface
+	folderRepo       repositories.FolderRepositoryInterface
+	noteRepo         repositories.NoteRepositoryInterface
+	teamRepo         repositories.TeamRepositoryInterface
+	userRepo         repositories.UserRepositoryInterface
+	notificationRepo repositories.NotificationRepositoryInterface
+	prefRepo         repositories.UserPreferenceRepositoryInterface
+	tx               repositories.TransactionManager
+	messages         *i18n.Bundle
+	audit            audit.Recorder
+	metrics          *metrics.Metrics
+	logger           logger.Logger
+}
+
+// NewAccessRequestService creates an access request service. teamRepo,
+// prefRepo, txManager, messages, auditor, m and log may be nil; without a
+// TransactionManager the share an approval makes is not written in the same
+// transaction as the decision.
+func NewAccessRequestService(requestRepo repositories.AccessRequestRepositoryInterface, folderRepo repositories.FolderRepositoryInterface, noteRepo repositories.NoteRepositoryInterface, teamRepo repositories.TeamRepositoryInterface, userRepo repositories.UserRepositoryInterface, notificationRepo repositories.NotificationRepositoryInterface, prefRepo repositories.UserPreferenceRepositoryInterface, txManager repositories.TransactionManager, messages *i18n.Bundle, auditor audit.Recorder, m *metrics.Metrics, log logger.Logger) *AccessRequestService {
+	if txManager == nil {
+		txManager = repositories.NoTx{Stores: repositories.Stores{Folders: folderRepo, Notes: noteRepo, AccessRequests: requestRepo}}
+	}
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &AccessRequestService{
+		requestRepo:      requestRepo,
+		folderRepo:       folderRepo,
+		noteRepo:         noteRepo,
+		teamRepo:         teamRepo,
+		userRepo:         userRepo,
+		notificationRepo: notificationRepo,
+		prefRepo:         prefRepo,
+		tx:               txManager,
+		messages:         messages,
+		audit:            auditor,
+		metrics:          m,
+		logger:           log,
+	}
+}
+
+type AccessRequestInput struct {
+	Access  models.AccessLevel `json:"access" binding:"required,oneof=read write"`
+	Message string             `json:"message" binding:"max=500"`
+}
+
+// resource is the folder or note an access request is about
+type resource struct {
+	ownerID uuid.UUID
+	title   string
+}
+
+// RequestAccess asks the owner of a folder or note to share it with userID.
+// It fails with a conflict when the user already has the access asked for
+// or is still waiting on an earlier request for the resource.
+func (s *AccessRequestService) RequestAccess(ctx context.Context, resourceType string, resourceID uuid.UUID, input *AccessRequestInput, userID uuid.UUID) (*models.AccessRequest, error) {
+	res, err := s.resource(ctx, resourceType, resourceID)
+	if err != nil {
+		return nil, err
+	}
+	hasAccess, level, err := s.hasAccess(ctx, resourceType, resourceID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+	if hasAccess && (level == models.AccessWrite || level == input.Access) {
+		return nil, apperrors.Conflict("you already have %s access", level)
+	}
+	pending, err := s.requestRepo.FindPending(ctx, resourceType, resourceID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check pending requests: %w", err)
+	}
+	if pending != nil {
+		return nil, apperrors.Conflict("you already asked for access and the owner has not decided yet")
+	}
+
+	request := &models.AccessRequest{
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		OwnerID:      res.ownerID,
+		RequesterID:  userID,
+		Access:       input.Access,
+		Message:      input.Message,
+		Status:       models.AccessRequestPending,
+	}
+	if err := s.requestRepo.Create(ctx, request); err != nil {
+		return nil, fmt.Errorf("failed to create access request: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetAccessRequest,
+		TargetID:   request.ID,
+		Details:    map[string]string{"resource_type": resourceType, "resource_id": resourceID.String(), "access": string(input.Access)},
+	})
+
+	requester, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.Warn("Failed to load access requester", logger.String("user_id", userID.String()), logger.Error(err))
+		return request, nil
+	}
+	s.notify(ctx, request, res.ownerID, func(localizer *i18n.Localizer) string {
+		return localizer.T("%s asked for %s access to %q", requester.Username, string(request.Access), res.title)
+	})
+	return request, nil
+}
+
+// ListPending returns one page of the pending requests for the folders and
+// notes ownerID owns, oldest first
+func (s *AccessRequestService) ListPending(ctx context.Context, ownerID uuid.UUID, p pagination.Params) (pagination.Page[models.AccessRequest], error) {
+	return s.requestRepo.ListPendingForOwner(ctx, ownerID, p)
+}
+
+// Decide approves or denies a pending request. Approving shares the
+// resource with the requester in the same transaction, replacing a read
+// share when write access was asked for. The requester is notified either
+// way.
+func (s *AccessRequestService) Decide(ctx context.Context, requestID uuid.UUID, approve bool, userID uuid.UUID) (*models.AccessRequest, error) {
+	request, err := s.requestRepo.GetByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.OwnerID != userID {
+		return nil, apperrors.Forbidden("only owner can decide access requests")
+	}
+	if request.Status != models.AccessRequestPending {
+		return nil, apperrors.Conflict("the access request was already decided")
+	}
+	res, err := s.resource(ctx, request.ResourceType, request.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	if res.ownerID != userID {
+		return nil, apperrors.Forbidden("only owner can decide access requests")
+	}
+
+	status := models.AccessRequestDenied
+	if approve {
+		status = models.AccessRequestApproved
+		// Approving shares like the owner sharing by hand would, so the
+		// owner's team sharing policy applies
+		policy, err := sharingPolicyOf(ctx, s.teamRepo, userID)
+		if err != nil {
+			return nil, err
+		}
+		if err := policy.check(ctx, request.RequesterID); err != nil {
+			return nil, err
+		}
+	}
+	actorCtx := database.WithActor(ctx, userID)
+	err = s.tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		if approve {
+			if err := s.grant(actorCtx, stores, request); err != nil {
+				return err
+			}
+		}
+		return stores.AccessRequests.Decide(actorCtx, request, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if approve {
+		s.metrics.RecordShareGranted(request.ResourceType, string(request.Access))
+		s.audit.Record(ctx, audit.Entry{
+			ActorID:    userID,
+			Action:     audit.ActionShare,
+			TargetType: request.ResourceType,
+			TargetID:   request.ResourceID,
+			Details:    shareDetails(request.RequesterID, request.Access),
+		})
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetAccessRequest,
+		TargetID:   request.ID,
+		Details:    map[string]string{"status": string(status)},
+	})
+	s.notify(ctx, request, request.RequesterID, func(localizer *i18n.Localizer) string {
+		if approve {
+			return localizer.T("Your request for %s access to %q was approved", string(request.Access), res.title)
+		}
+		// The title is only told to users who were let in
+		return localizer.T("Your request for %s access was denied", string(request.Access))
+	})
+	return request, nil
+}
+
+// grant shares the requested resource with the requester. A read share is
+// revoked first when write access was asked for; a user who meanwhile got
+// the access asked for is left as they are.
+func (s *AccessRequestService) grant(ctx context.Context, stores repositories.Stores, request *models.AccessRequest) error {
+	if request.ResourceType == models.ResourceFolder {
+		hasAccess, level, err := stores.Folders.HasAccess(ctx, request.ResourceID, request.RequesterID)
+		if err != nil {
+			return err
+		}
+		if hasAccess && (level == models.AccessWrite || level == request.Access) {
+			return nil
+		}
+		if hasAccess {
+			if err := stores.Folders.RevokeShare(ctx, request.ResourceID, request.RequesterID); err != nil {
+				return err
+			}
+		}
+		return stores.Folders.ShareFolder(ctx, request.ResourceID, request.RequesterID, request.Access)
+	}
+
+	hasAccess, level, err := stores.Notes.HasAccess(ctx, request.ResourceID, request.RequesterID)
+	if err != nil {
+		return err
+	}
+	if hasAccess && (level == models.AccessWrite || level == request.Access) {
+		return nil
+	}
+	if hasAccess {
+		if err := stores.Notes.RevokeShare(ctx, request.ResourceID, request.RequesterID); err != nil {
+			return err
+		}
+	}
+	return stores.Notes.ShareNote(ctx, request.ResourceID, request.RequesterID, request.Access)
+}
+
+// resource returns the owner and title of a folder or note
+func (s *AccessRequestService) resource(ctx context.Context, resourceType string, resourceID uuid.UUID) (*resource, error) {
+	switch resourceType {
+	case models.ResourceFolder:
+		folder, err := s.folderRepo.GetByID(ctx, resourceID)
+		if err != nil {
+			return nil, err
+		}
+		return &resource{ownerID: folder.OwnerID, title: folder.Name}, nil
+	case models.ResourceNote:
+		note, err := s.noteRepo.GetByID(ctx, resourceID)
+		if err != nil {
+			return nil, err
+		}
+		return &resource{ownerID: note.OwnerID, title: note.Title}, nil
+	}
+	return nil, apperrors.Validation("unsupported resource type %q", resourceType)
+}
+
+func (s *AccessRequestService) hasAccess(ctx context.Context, resourceType string, resourceID, userID uuid.UUID) (bool, models.AccessLevel, error) {
+	if resourceType == models.ResourceFolder {
+		return s.folderRepo.HasAccess(ctx, resourceID, userID)
+	}
+	return s.noteRepo.HasAccess(ctx, resourceID, userID)
+}
+
+// notify creates an in-app notification for userID about request, unless
+// the user muted access requests. The request stands either way, so
+// failures are only logged.
+func (s *AccessRequestService) notify(ctx context.Context, request *models.AccessRequest, userID uuid.UUID, message func(*i18n.Localizer) string) {
+	pref := preferencesOf(ctx, s.prefRepo, userID, s.logger)
+	if !pref.Wants(models.NotificationAccessRequest, models.ChannelInApp) {
+		return
+	}
+	actorID := request.RequesterID
+	if userID == request.RequesterID {
+		actorID = request.OwnerID
+	}
+	resourceID := request.ResourceID
+	notification := &models.Notification{
+		UserID:       userID,
+		Type:         models.NotificationAccessRequest,
+		ActorID:      &actorID,
+		ResourceType: request.ResourceType,
+		ResourceID:   &resourceID,
+		Message:      message(s.messages.Localizer(pref.Locale)),
+	}
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		s.logger.Warn("Failed to create access request notification",
+			logger.String("request_id", request.ID.String()),
+			logger.Error(err),
+		)
+	}
+}
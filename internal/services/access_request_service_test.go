@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/i18n"
+	"seta-training/pkg/pagination"
+)
+
+// MockAccessRequestRepository is a mock implementation of AccessRequestRepositoryInterface
+type MockAccessRequestRepository struct {
+	mock.Mock
+}
+
+func (m *MockAccessRequestRepository) Create(ctx context.Context, request *models.AccessRequest) error {
+	args := m.Called(request)
+	return args.Error(0)
+}
+
+func (m *MockAccessRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AccessRequest, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AccessRequest), args.Error(1)
+}
+
+func (m *MockAccessRequestRepository) FindPending(ctx context.Context, resourceType string, resourceID, requesterID uuid.UUID) (*models.AccessRequest, error) {
+	args := m.Called(resourceType, resourceID, requesterID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AccessRequest), args.Error(1)
+}
+
+func (m *MockAccessRequestRepository) ListPendingForOwner(ctx context.Context, ownerID uuid.UUID, p pagination.Params) (pagination.Page[models.AccessRequest], error) {
+	args := m.Called(ownerID, p)
+	return args.Get(0).(pagination.Page[models.AccessRequest]), args.Error(1)
+}
+
+func (m *MockAccessRequestRepository) Decide(ctx context.Context, request *models.AccessRequest, status models.AccessRequestStatus) error {
+	args := m.Called(request.ID, status)
+	if args.Error(0) == nil {
+		request.Status = status
+	}
+	return args.Error(0)
+}
+
+func TestAccessRequestService_RequestAccess(t *testing.T) {
+	ctx := context.Background()
+	ownerID, userID := uuid.New(), uuid.New()
+	folder := &models.Folder{ID: uuid.New(), Name: "Reports", OwnerID: ownerID}
+
+	newService := func(t *testing.T) (*AccessRequestService, *MockAccessRequestRepository, *MockFolderRepository, *MockNotificationRepository) {
+		requestRepo, folderRepo := new(MockAccessRequestRepository), new(MockFolderRepository)
+		userRepo, notificationRepo := new(MockUserRepository), new(MockNotificationRepository)
+		folderRepo.On("GetByID", folder.ID).Return(folder, nil)
+		userRepo.On("GetByID", userID).Return(&models.User{ID: userID, Username: "alice"}, nil)
+		notificationRepo.On("Create", mock.AnythingOfType("*models.Notification")).Return(nil)
+		messages, err := i18n.NewBundle()
+		require.NoError(t, err)
+		service := NewAccessRequestService(requestRepo, folderRepo, new(MockNoteRepository), nil, userRepo, notificationRepo, nil, nil, messages, nil, nil, nil)
+		return service, requestRepo, folderRepo, notificationRepo
+	}
+
+	t.Run("stores the request and notifies the owner", func(t *testing.T) {
+		service, requestRepo, folderRepo, notificationRepo := newService(t)
+		folderRepo.On("HasAccess", folder.ID, userID).Return(false, models.AccessLevel(""), nil)
+		requestRepo.On("FindPending", models.ResourceFolder, folder.ID, userID).Return(nil, nil)
+		requestRepo.On("Create", mock.AnythingOfType("*models.AccessRequest")).Return(nil)
+
+		request, err := service.RequestAccess(ctx, models.ResourceFolder, folder.ID, &AccessRequestInput{Access: models.AccessWrite}, userID)
+		require.NoError(t, err)
+		assert.Equal(t, ownerID, request.OwnerID)
+		assert.Equal(t, userID, request.RequesterID)
+		assert.Equal(t, models.AccessRequestPending, request.Status)
+
+		notification := notificationRepo.Calls[0].Arguments.Get(0).(*models.Notification)
+		assert.Equal(t, ownerID, notification.UserID)
+		assert.Equal(t, models.NotificationAccessRequest, notification.Type)
+		assert.Equal(t, `alice asked for write access to "Reports"`, notification.Message)
+	})
+
+	t.Run("lets a reader ask for write access", func(t *testing.T) {
+		service, requestRepo, folderRepo, _ := newService(t)
+		folderRepo.On("HasAccess", folder.ID, userID).Return(true, models.AccessRead, nil)
+		requestRepo.On("FindPending", models.ResourceFolder, folder.ID, userID).Return(nil, nil)
+		requestRepo.On("Create", mock.AnythingOfType("*models.AccessRequest")).Return(nil)
+
+		_, err := service.RequestAccess(ctx, models.ResourceFolder, folder.ID, &AccessRequestInput{Access: models.AccessWrite}, userID)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects users who already have the access", func(t *testing.T) {
+		service, requestRepo, folderRepo, _ := newService(t)
+		folderRepo.On("HasAccess", folder.ID, userID).Return(true, models.AccessWrite, nil)
+
+		_, err := service.RequestAccess(ctx, models.ResourceFolder, folder.ID, &AccessRequestInput{Access: models.AccessRead}, userID)
+		assert.Equal(t, apperrors.CodeConflict, apperrors.From(err).Code)
+		requestRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("rejects a second pending request", func(t *testing.T) {
+		service, requestRepo, folderRepo, _ := newService(t)
+		folderRepo.On("HasAccess", folder.ID, userID).Return(false, models.AccessLevel(""), nil)
+		requestRepo.On("FindPending", models.ResourceFolder, folder.ID, userID).Return(&models.AccessRequest{ID: uuid.New()}, nil)
+
+		_, err := service.RequestAccess(ctx, models.ResourceFolder, folder.ID, &AccessRequestInput{Access: models.AccessRead}, userID)
+		assert.Equal(t, apperrors.CodeConflict, apperrors.From(err).Code)
+		requestRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestAccessRequestService_Decide(t *testing.T) {
+	ctx := context.Background()
+	ownerID, userID := uuid.New(), uuid.New()
+	note := &models.Note{ID: uuid.New(), Title: "Budget", OwnerID: ownerID}
+
+	newService := func(t *testing.T, access models.AccessLevel) (*AccessRequestService, *MockAccessRequestRepository, *MockNoteRepository, *MockNotificationRepository, *models.AccessRequest) {
+		request := &models.AccessRequest{
+			ID:           uuid.New(),
+			ResourceType: models.ResourceNote,
+			ResourceID:   note.ID,
+			OwnerID:      ownerID,
+			RequesterID:  userID,
+			Access:       access,
+			Status:       models.AccessRequestPending,
+		}
+		requestRepo, noteRepo, notificationRepo := new(MockAccessRequestRepository), new(MockNoteRepository), new(MockNotificationRepository)
+		requestRepo.On("GetByID", request.ID).Return(request, nil)
+		noteRepo.On("GetByID", note.ID).Return(note, nil)
+		notificationRepo.On("Create", mock.AnythingOfType("*models.Notification")).Return(nil)
+		messages, err := i18n.NewBundle()
+		require.NoError(t, err)
+		service := NewAccessRequestService(requestRepo, new(MockFolderRepository), noteRepo, nil, new(MockUserRepository), notificationRepo, nil, nil, messages, nil, nil, nil)
+		return service, requestRepo, noteRepo, notificationRepo, request
+	}
+
+	t.Run("approving shares the note and notifies the requester", func(t *testing.T) {
+		service, requestRepo, noteRepo, notificationRepo, request := newService(t, models.AccessRead)
+		noteRepo.On("HasAccess", note.ID, userID).Return(false, models.AccessLevel(""), nil)
+		noteRepo.On("ShareNote", note.ID, userID, models.AccessRead).Return(nil)
+		requestRepo.On("Decide", request.ID, models.AccessRequestApproved).Return(nil)
+
+		decided, err := service.Decide(ctx, request.ID, true, ownerID)
+		require.NoError(t, err)
+		assert.Equal(t, models.AccessRequestApproved, decided.Status)
+		noteRepo.AssertCalled(t, "ShareNote", note.ID, userID, models.AccessRead)
+
+		notification := notificationRepo.Calls[0].Arguments.Get(0).(*models.Notification)
+		assert.Equal(t, userID, notification.UserID)
+		assert.Equal(t, `Your request for read access to "Budget" was approved`, notification.Message)
+	})
+
+	t.Run("approving write access replaces a read share", func(t *testing.T) {
+		service, requestRepo, noteRepo, _, request := newService(t, models.AccessWrite)
+		noteRepo.On("HasAccess", note.ID, userID).Return(true, models.AccessRead, nil)
+		noteRepo.On("RevokeShare", note.ID, userID).Return(nil)
+		noteRepo.On("ShareNote", note.ID, userID, models.AccessWrite).Return(nil)
+		requestRepo.On("Decide", request.ID, models.AccessRequestApproved).Return(nil)
+
+		_, err := service.Decide(ctx, request.ID, true, ownerID)
+		require.NoError(t, err)
+		noteRepo.AssertCalled(t, "RevokeShare", note.ID, userID)
+		noteRepo.AssertCalled(t, "ShareNote", note.ID, userID, models.AccessWrite)
+	})
+
+	t.Run("denying shares nothing and keeps the title from the requester", func(t *testing.T) {
+		service, requestRepo, noteRepo, notificationRepo, request := newService(t, models.AccessRead)
+		requestRepo.On("Decide", request.ID, models.AccessRequestDenied).Return(nil)
+
+		decided, err := service.Decide(ctx, request.ID, false, ownerID)
+		require.NoError(t, err)
+		assert.Equal(t, models.AccessRequestDenied, decided.Status)
+		noteRepo.AssertNotCalled(t, "ShareNote", mock.Anything, mock.Anything, mock.Anything)
+
+		notification := notificationRepo.Calls[0].Arguments.Get(0).(*models.Notification)
+		assert.Equal(t, "Your request for read access was denied", notification.Message)
+	})
+
+	t.Run("only the owner decides", func(t *testing.T) {
+		service, requestRepo, _, _, request := newService(t, models.AccessRead)
+
+		_, err := service.Decide(ctx, request.ID, true, userID)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		requestRepo.AssertNotCalled(t, "Decide", mock.Anything, mock.Anything)
+	})
+
+	t.Run("a decided request cannot be decided again", func(t *testing.T) {
+		service, requestRepo, _, _, request := newService(t, models.AccessRead)
+		request.Status = models.AccessRequestDenied
+
+		_, err := service.Decide(ctx, request.ID, true, ownerID)
+		assert.Equal(t, apperrors.CodeConflict, apperrors.From(err).Code)
+		requestRepo.AssertNotCalled(t, "Decide", mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+)
+
+// AnnouncementService lets admins schedule announcements and serves the
+// ones currently shown to clients
+type AnnouncementService struct {
+	announcementRepo repositories.AnnouncementRepositoryInterface
+	audit            audit.Recorder
+	now              func() time.Time
+}
+
+// NewAnnouncementService creates an announcement service. auditor may be nil.
+func NewAnnouncementService(announcementRepo repositories.AnnouncementRepositoryInterface, auditor audit.Recorder) *AnnouncementService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	return &AnnouncementService{
+		announcementRepo: announcementRepo,
+		audit:            auditor,
+		now:              time.Now,
+	}
+}
+
+// AnnouncementInput schedules an announcement. StartsAt defaults to now and
+// a nil EndsAt shows it until it is changed or deleted.
+type AnnouncementInput struct {
+	Title    string     `json:"title" binding:"required,max=200"`
+	Body     string     `json:"body" binding:"required,max=5000"`
+	Level    string     `json:"level" binding:"omitempty,oneof=info warning critical"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
+
+func (s *AnnouncementService) CreateAnnouncement(ctx context.Context, input *AnnouncementInput, actorID uuid.UUID) (*models.Announcement, error) {
+	announcement := &models.Announcement{CreatedBy: actorID}
+	if err := s.apply(announcement, input); err != nil {
+		return nil, err
+	}
+	if err := s.announcementRepo.Create(ctx, announcement); err != nil {
+		return nil, fmt.Errorf("failed to create announcement: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetAnnouncement,
+		TargetID:   announcement.ID,
+		Details:    map[string]string{"title": announcement.Title},
+	})
+	return announcement, nil
+}
+
+// GetAnnouncements returns every announcement, including scheduled and
+// expired ones
+func (s *AnnouncementService) GetAnnouncements(ctx context.Context) ([]models.Announcement, error) {
+	return s.announcementRepo.GetAll(ctx)
+}
+
+// GetActiveAnnouncements returns the announcements shown now
+func (s *AnnouncementService) GetActiveAnnouncements(ctx context.Context) ([]models.Announcement, error) {
+	return s.announcementRepo.GetActive(ctx, s.now())
+}
+
+func (s *AnnouncementService) UpdateAnnouncement(ctx context.Context, announcementID uuid.UUID, input *AnnouncementInput, actorID uuid.UUID) (*models.Announcement, error) {
+	announcement, err := s.announcementRepo.GetByID(ctx, announcementID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(announcement, input); err != nil {
+		return nil, err
+	}
+	if err := s.announcementRepo.Update(ctx, announcement); err != nil {
+		return nil, fmt.Errorf("failed to update announcement: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetAnnouncement,
+		TargetID:   announcement.ID,
+		Details:    map[string]string{"title": announcement.Title},
+	})
+	return announcement, nil
+}
+
+func (s *AnnouncementService) DeleteAnnouncement(ctx context.Context, announcementID, actorID uuid.UUID) error {
+	if _, err := s.announcementRepo.GetByID(ctx, announcementID); err != nil {
+		return err
+	}
+	if err := s.announcementRepo.Delete(ctx, announcementID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetAnnouncement,
+		TargetID:   announcementID,
+	})
+	return nil
+}
+
+// apply validates input and copies it onto announcement
+func (s *AnnouncementService) apply(announcement *models.Announcement, input *AnnouncementInput) error {
+	startsAt := s.now()
+	if input.StartsAt != nil {
+		startsAt = *input.StartsAt
+	}
+	if input.EndsAt != nil && !input.EndsAt.After(startsAt) {
+		return apperrors.ValidationFields("Invalid announcement schedule", map[string]string{"ends_at": "must be after starts_at"})
+	}
+
+	announcement.Title = input.Title
+	announcement.Body = input.Body
+	announcement.Level = input.Level
+	if announcement.Level == "" {
+		announcement.Level = models.AnnouncementInfo
+	}
+	announcement.StartsAt = startsAt.UTC()
+	announcement.EndsAt = nil
+	if input.EndsAt != nil {
+		endsAt := input.EndsAt.UTC()
+		announcement.EndsAt = &endsAt
+	}
+	return nil
+}
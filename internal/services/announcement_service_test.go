@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+)
+
+// MockAnnouncementRepository is a mock implementation of AnnouncementRepositoryInterface
+type MockAnnouncementRepository struct {
+	mock.Mock
+}
+
+func (m *MockAnnouncementRepository) Create(ctx context.Context, announcement *models.Announcement) error {
+	args := m.Called(announcement)
+	return args.Error(0)
+}
+
+func (m *MockAnnouncementRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Announcement, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Announcement), args.Error(1)
+}
+
+func (m *MockAnnouncementRepository) GetAll(ctx context.Context) ([]models.Announcement, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Announcement), args.Error(1)
+}
+
+func (m *MockAnnouncementRepository) GetActive(ctx context.Context, now time.Time) ([]models.Announcement, error) {
+	args := m.Called(now)
+	return args.Get(0).([]models.Announcement), args.Error(1)
+}
+
+func (m *MockAnnouncementRepository) Update(ctx context.Context, announcement *models.Announcement) error {
+	args := m.Called(announcement)
+	return args.Error(0)
+}
+
+func (m *MockAnnouncementRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestAnnouncementService_CreateAnnouncement(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	adminID := uuid.New()
+
+	mockRepo := new(MockAnnouncementRepository)
+	mockRepo.On("Create", mock.AnythingOfType("*models.Announcement")).Return(nil)
+	recorder := new(MockAuditRecorder)
+	recorder.On("Record", mock.AnythingOfType("audit.Entry")).Return()
+	service := NewAnnouncementService(mockRepo, recorder)
+	service.now = func() time.Time { return now }
+
+	t.Run("starts now at info level by default", func(t *testing.T) {
+		announcement, err := service.CreateAnnouncement(ctx, &AnnouncementInput{Title: "New editor", Body: "Try it out"}, adminID)
+		require.NoError(t, err)
+		assert.Equal(t, now, announcement.StartsAt)
+		assert.Nil(t, announcement.EndsAt)
+		assert.Equal(t, models.AnnouncementInfo, announcement.Level)
+		assert.Equal(t, adminID, announcement.CreatedBy)
+		assert.True(t, announcement.ActiveAt(now))
+		recorder.AssertCalled(t, "Record", mock.MatchedBy(func(entry audit.Entry) bool {
+			return entry.Action == audit.ActionCreate && entry.TargetType == audit.TargetAnnouncement && entry.Details["title"] == "New editor"
+		}))
+	})
+
+	t.Run("schedules a window", func(t *testing.T) {
+		startsAt, endsAt := now.Add(24*time.Hour), now.Add(26*time.Hour)
+		announcement, err := service.CreateAnnouncement(ctx, &AnnouncementInput{
+			Title: "Maintenance", Body: "Read-only for an hour", Level: models.AnnouncementWarning,
+			StartsAt: &startsAt, EndsAt: &endsAt,
+		}, adminID)
+		require.NoError(t, err)
+		assert.False(t, announcement.ActiveAt(now))
+		assert.True(t, announcement.ActiveAt(startsAt))
+		assert.False(t, announcement.ActiveAt(endsAt))
+	})
+
+	t.Run("rejects an end before the start", func(t *testing.T) {
+		endsAt := now.Add(-time.Minute)
+		_, err := service.CreateAnnouncement(ctx, &AnnouncementInput{Title: "Late", Body: "Too late", EndsAt: &endsAt}, adminID)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Equal(t, map[string]string{"ends_at": "must be after starts_at"}, apperrors.From(err).Fields)
+	})
+
+	mockRepo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestAnnouncementService_GetActiveAnnouncements(t *testing.T) {
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	active := []models.Announcement{{ID: uuid.New(), Title: "Hello"}}
+
+	mockRepo := new(MockAnnouncementRepository)
+	mockRepo.On("GetActive", now).Return(active, nil)
+	service := NewAnnouncementService(mockRepo, nil)
+	service.now = func() time.Time { return now }
+
+	announcements, err := service.GetActiveAnnouncements(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, active, announcements)
+}
+
+func TestAnnouncementService_DeleteAnnouncement(t *testing.T) {
+	missingID := uuid.New()
+	mockRepo := new(MockAnnouncementRepository)
+	mockRepo.On("GetByID", missingID).Return(nil, apperrors.NotFound("announcement not found"))
+	service := NewAnnouncementService(mockRepo, nil)
+
+	err := service.DeleteAnnouncement(context.Background(), missingID, uuid.New())
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/logger"
+)
+
+// noTeamName names the report row for owners in no team
+const noTeamName = "(no team)"
+
+// AssetReportService builds the asset reports of organizations on a pool of
+// background workers, like ExportService does for folder exports
+type AssetReportService struct {
+	reportRepo repositories.AssetReportRepositoryInterface
+	logger     logger.Logger
+
+	workers int
+	queue   chan uuid.UUID
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewAssetReportService creates an asset report service. Call Start to
+// begin processing queued reports.
+func NewAssetReportService(reportRepo repositories.AssetReportRepositoryInterface, log logger.Logger, workers, queueSize int) *AssetReportService {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &AssetReportService{
+		reportRepo: reportRepo,
+		logger:     log,
+		workers:    workers,
+		queue:      make(chan uuid.UUID, queueSize),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start launches the worker pool and queues again the reports left pending
+// or running when the service last stopped
+func (s *AssetReportService) Start() {
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	// Listed before any report can be requested, so none is queued twice
+	ids, err := s.reportRepo.UnfinishedIDs(s.ctx)
+	if err != nil {
+		s.logger.Error("Failed to load unfinished asset reports", logger.Error(err))
+		return
+	}
+	s.wg.Add(1)
+	go s.requeue(ids)
+}
+
+// requeue queues reportIDs as workers make room for them
+func (s *AssetReportService) requeue(reportIDs []uuid.UUID) {
+	defer s.wg.Done()
+	for _, id := range reportIDs {
+		select {
+		case s.queue <- id:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// Shutdown stops workers from picking up new reports and waits for running
+// ones to finish. Reports still queued stay pending.
+func (s *AssetReportService) Shutdown(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RequestReport queues the asset report of orgID, or of the default tenant
+// when nil, on behalf of userID. While a report of the organization is
+// pending or running, that one is returned instead of queuing another.
+func (s *AssetReportService) RequestReport(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID) (*models.AssetReport, error) {
+	unfinished, err := s.reportRepo.FindUnfinished(ctx, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find asset report: %w", err)
+	}
+	if unfinished != nil {
+		return unfinished, nil
+	}
+
+	report := &models.AssetReport{
+		OwnerID:        userID,
+		OrganizationID: orgID,
+		Status:         models.ExportStatusPending,
+	}
+	if err := s.reportRepo.Create(ctx, report); err != nil {
+		return nil, fmt.Errorf("failed to create asset report: %w", err)
+	}
+
+	select {
+	case s.queue <- report.ID:
+	default:
+		err := apperrors.Unavailable("asset report queue is full, try again later")
+		s.fail(ctx, report, err)
+		return nil, err
+	}
+	return report, nil
+}
+
+// GetReport returns a report of orgID, whichever admin requested it
+func (s *AssetReportService) GetReport(ctx context.Context, reportID uuid.UUID, orgID *uuid.UUID) (*models.AssetReport, error) {
+	report, err := s.reportRepo.GetByID(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.SameOrganization(report.OrganizationID, orgID) {
+		return nil, apperrors.NotFound("asset report not found")
+	}
+	return report, nil
+}
+
+// GetReportArtifact returns the CSV of a completed report
+func (s *AssetReportService) GetReportArtifact(ctx context.Context, reportID uuid.UUID, orgID *uuid.UUID) (*models.AssetReport, []byte, error) {
+	report, err := s.GetReport(ctx, reportID, orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if report.Status != models.ExportStatusCompleted {
+		return nil, nil, apperrors.Conflict("asset report is %s", report.Status)
+	}
+
+	data, err := s.reportRepo.GetArtifact(ctx, reportID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return report, data, nil
+}
+
+func (s *AssetReportService) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case reportID := <-s.queue:
+			// Running reports finish even while shutting down
+			s.process(context.WithoutCancel(s.ctx), reportID)
+		}
+	}
+}
+
+func (s *AssetReportService) process(ctx context.Context, reportID uuid.UUID) {
+	report, err := s.reportRepo.GetByID(ctx, reportID)
+	if err != nil {
+		s.logger.Error("Failed to load asset report", logger.String("report_id", reportID.String()), logger.Error(err))
+		return
+	}
+	if report.Status == models.ExportStatusCompleted || report.Status == models.ExportStatusFailed {
+		// Queued again at start while another instance finished it
+		return
+	}
+
+	report.Status = models.ExportStatusRunning
+	if err := s.reportRepo.Update(ctx, report); err != nil {
+		s.logger.Error("Failed to mark asset report running", logger.String("report_id", reportID.String()), logger.Error(err))
+		return
+	}
+
+	start := time.Now()
+	teams, owners, err := s.reportRepo.Inventory(ctx, report.OrganizationID)
+	if err != nil {
+		s.fail(ctx, report, err)
+		return
+	}
+	rows := summarizeTeamAssets(teams, owners)
+	data, err := renderAssetReportCSV(rows)
+	if err != nil {
+		s.fail(ctx, report, err)
+		return
+	}
+
+	now := time.Now()
+	report.Status = models.ExportStatusCompleted
+	report.CompletedAt = &now
+	report.FileName = "asset-report-" + now.UTC().Format(time.DateOnly) + ".csv"
+	report.TeamCount = len(teams)
+	report.SizeBytes = len(data)
+	report.Artifact = data
+	if err := s.reportRepo.Update(ctx, report); err != nil {
+		s.logger.Error("Failed to store asset report", logger.String("report_id", reportID.String()), logger.Error(err))
+		return
+	}
+
+	s.logger.Info("Asset report completed",
+		logger.String("report_id", reportID.String()),
+		logger.Int("teams", report.TeamCount),
+		logger.Int("size_bytes", report.SizeBytes),
+		logger.Duration("duration", time.Since(start)),
+	)
+}
+
+func (s *AssetReportService) fail(ctx context.Context, report *models.AssetReport, cause error) {
+	now := time.Now()
+	report.Status = models.ExportStatusFailed
+	report.Error = cause.Error()
+	report.Artifact = nil
+	report.CompletedAt = &now
+	if err := s.reportRepo.Update(ctx, report); err != nil {
+		s.logger.Error("Failed to mark asset report failed", logger.String("report_id", report.ID.String()), logger.Error(err))
+		return
+	}
+	s.logger.Warn("Asset report failed", logger.String("report_id", report.ID.String()), logger.Error(cause))
+}
+
+// summarizeTeamAssets returns a row per team, in the order given, then a
+// row for the owners in no team when they own anything. Someone in several
+// teams counts towards each of them. Deleted users are not counted among
+// the people of a team, but what they own is, as orphaned.
+func summarizeTeamAssets(teams []models.Team, owners []models.OwnerAssets) []models.TeamAssetSummary {
+	byID := make(map[uuid.UUID]models.OwnerAssets, len(owners))
+	for _, owner := range owners {
+		byID[owner.UserID] = owner
+	}
+
+	inTeam := make(map[uuid.UUID]bool)
+	rows := make([]models.TeamAssetSummary, 0, len(teams)+1)
+	for _, team := range teams {
+		people := make([]uuid.UUID, 0, len(team.Memberships))
+		for _, membership := range team.Memberships {
+			people = append(people, membership.UserID)
+			inTeam[membership.UserID] = true
+		}
+		row := summarizeOwners(byID, people)
+		row.TeamID = &team.ID
+		row.TeamName = team.Name
+		rows = append(rows, row)
+	}
+
+	var loners []uuid.UUID
+	for _, owner := range owners {
+		if !inTeam[owner.UserID] && owner.Folders+owner.Notes > 0 {
+			loners = append(loners, owner.UserID)
+		}
+	}
+	if len(loners) > 0 {
+		row := summarizeOwners(byID, loners)
+		row.TeamName = noTeamName
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// summarizeOwners adds up what people own. Shares with anyone but them are
+// external.
+func summarizeOwners(owners map[uuid.UUID]models.OwnerAssets, people []uuid.UUID) models.TeamAssetSummary {
+	var row models.TeamAssetSummary
+	member := make(map[uuid.UUID]bool, len(people))
+	for _, id := range people {
+		member[id] = true
+	}
+	recipients := make(map[uuid.UUID]bool)
+	for _, id := range people {
+		owner, ok := owners[id]
+		if !ok {
+			continue
+		}
+		if owner.Deleted {
+			row.OrphanedFolders += owner.Folders
+			row.OrphanedNotes += owner.Notes
+		} else {
+			row.People++
+		}
+		row.Folders += owner.Folders
+		row.Notes += owner.Notes
+		row.SharedFolders += owner.SharedFolders
+		row.SharedNotes += owner.SharedNotes
+		for _, recipient := range owner.Recipients {
+			recipients[recipient] = true
+		}
+	}
+	row.ShareRecipients = len(recipients)
+	for recipient := range recipients {
+		if !member[recipient] {
+			row.ExternalRecipients++
+		}
+	}
+	return row
+}
+
+// assetReportHeader is the first line of an asset report
+var assetReportHeader = []string{
+	"team_id", "team_name", "people", "folders", "notes", "shared_folders", "shared_notes",
+	"share_recipients", "external_recipients", "orphaned_folders", "orphaned_notes",
+}
+
+// renderAssetReportCSV writes rows as CSV. Team names that a spreadsheet
+// would run as a formula are quoted with a leading apostrophe.
+func renderAssetReportCSV(rows []models.TeamAssetSummary) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(assetReportHeader); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		teamID := ""
+		if row.TeamID != nil {
+			teamID = row.TeamID.String()
+		}
+		record := []string{teamID, spreadsheetSafe(row.TeamName), strconv.Itoa(row.People)}
+		for _, n := range []int64{row.Folders, row.Notes, row.SharedFolders, row.SharedNotes,
+			int64(row.ShareRecipients), int64(row.ExternalRecipients), row.OrphanedFolders, row.OrphanedNotes} {
+			record = append(record, strconv.FormatInt(n, 10))
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// spreadsheetSafe keeps a cell from being read as a formula
+func spreadsheetSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// MockAssetReportRepository is a mock implementation of AssetReportRepositoryInterface
+type MockAssetReportRepository struct {
+	mock.Mock
+}
+
+func (m *MockAssetReportRepository) Create(ctx context.Context, report *models.AssetReport) error {
+	args := m.Called(report)
+	if report.ID == uuid.Nil {
+		report.ID = uuid.New()
+	}
+	return args.Error(0)
+}
+
+func (m *MockAssetReportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.AssetReport, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AssetReport), args.Error(1)
+}
+
+func (m *MockAssetReportRepository) GetArtifact(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	args := m.Called(id)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockAssetReportRepository) Update(ctx context.Context, report *models.AssetReport) error {
+	args := m.Called(report)
+	return args.Error(0)
+}
+
+func (m *MockAssetReportRepository) FindUnfinished(ctx context.Context, orgID *uuid.UUID) (*models.AssetReport, error) {
+	args := m.Called(orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.AssetReport), args.Error(1)
+}
+
+func (m *MockAssetReportRepository) UnfinishedIDs(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called()
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockAssetReportRepository) Inventory(ctx context.Context, orgID *uuid.UUID) ([]models.Team, []models.OwnerAssets, error) {
+	args := m.Called(orgID)
+	return args.Get(0).([]models.Team), args.Get(1).([]models.OwnerAssets), args.Error(2)
+}
+
+func TestSummarizeTeamAssets(t *testing.T) {
+	alice, bob, carol, dave, erin := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	platform := models.Team{ID: uuid.New(), Name: "Platform", Memberships: []models.TeamMembership{
+		{UserID: alice, Role: models.TeamRoleAdmin},
+		{UserID: bob, Role: models.TeamRoleMember},
+		{UserID: dave, Role: models.TeamRoleViewer},
+	}}
+	design := models.Team{ID: uuid.New(), Name: "Design", Memberships: []models.TeamMembership{
+		{UserID: bob, Role: models.TeamRoleManager},
+	}}
+	owners := []models.OwnerAssets{
+		{UserID: alice, Folders: 2, Notes: 5, SharedFolders: 1, SharedNotes: 2, Recipients: []uuid.UUID{bob, carol}},
+		{UserID: bob, Folders: 1, Notes: 3, SharedNotes: 1, Recipients: []uuid.UUID{carol}},
+		{UserID: carol, Folders: 1, Notes: 1},
+		{UserID: dave, Deleted: true, Folders: 1, Notes: 4},
+		{UserID: erin},
+	}
+
+	rows := summarizeTeamAssets([]models.Team{platform, design}, owners)
+	require.Len(t, rows, 3)
+
+	assert.Equal(t, models.TeamAssetSummary{
+		TeamID: &platform.ID, TeamName: "Platform", People: 2,
+		Folders: 4, Notes: 12, SharedFolders: 1, SharedNotes: 3,
+		ShareRecipients: 2, ExternalRecipients: 1,
+		OrphanedFolders: 1, OrphanedNotes: 4,
+	}, rows[0])
+
+	assert.Equal(t, "Design", rows[1].TeamName)
+	assert.Equal(t, 1, rows[1].People)
+	assert.Equal(t, int64(3), rows[1].Notes)
+	assert.Equal(t, 1, rows[1].ExternalRecipients)
+
+	// Erin owns nothing, so only Carol is in the row for no team
+	assert.Nil(t, rows[2].TeamID)
+	assert.Equal(t, noTeamName, rows[2].TeamName)
+	assert.Equal(t, 1, rows[2].People)
+	assert.Equal(t, int64(1), rows[2].Folders)
+}
+
+func TestRenderAssetReportCSV(t *testing.T) {
+	teamID := uuid.New()
+	data, err := renderAssetReportCSV([]models.TeamAssetSummary{
+		{TeamID: &teamID, TeamName: "=HYPERLINK(\"x\")", People: 3, Folders: 2, ShareRecipients: 1},
+		{TeamName: noTeamName, Notes: 4},
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, strings.Join(assetReportHeader, ","), lines[0])
+	assert.Equal(t, teamID.String()+`,"'=HYPERLINK(""x"")",3,2,0,0,0,1,0,0,0`, lines[1])
+	assert.Equal(t, ",(no team),0,0,4,0,0,0,0,0,0", lines[2])
+}
+
+func TestAssetReportService(t *testing.T) {
+	ctx := context.Background()
+	orgID, adminID := uuid.New(), uuid.New()
+
+	t.Run("builds the report of the organization", func(t *testing.T) {
+		repo := new(MockAssetReportRepository)
+		service := NewAssetReportService(repo, nil, 1, 1)
+		repo.On("FindUnfinished", &orgID).Return(nil, nil)
+		repo.On("Create", mock.AnythingOfType("*models.AssetReport")).Return(nil)
+
+		report, err := service.RequestReport(ctx, adminID, &orgID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ExportStatusPending, report.Status)
+
+		team := models.Team{ID: uuid.New(), Name: "Platform", Memberships: []models.TeamMembership{{UserID: adminID}}}
+		repo.On("GetByID", report.ID).Return(report, nil)
+		repo.On("Update", report).Return(nil)
+		repo.On("Inventory", &orgID).Return([]models.Team{team}, []models.OwnerAssets{{UserID: adminID, Notes: 2}}, nil)
+
+		service.process(ctx, <-service.queue)
+		assert.Equal(t, models.ExportStatusCompleted, report.Status)
+		assert.Equal(t, 1, report.TeamCount)
+		assert.True(t, strings.HasPrefix(report.FileName, "asset-report-"))
+		assert.Contains(t, string(report.Artifact), "Platform,1,0,2")
+	})
+
+	t.Run("fails the report when the queue is full", func(t *testing.T) {
+		repo := new(MockAssetReportRepository)
+		service := NewAssetReportService(repo, nil, 1, 1)
+		repo.On("FindUnfinished", &orgID).Return(nil, nil)
+		repo.On("Create", mock.AnythingOfType("*models.AssetReport")).Return(nil)
+		repo.On("Update", mock.AnythingOfType("*models.AssetReport")).Return(nil)
+
+		_, err := service.RequestReport(ctx, adminID, &orgID)
+		require.NoError(t, err)
+		_, err = service.RequestReport(ctx, adminID, &orgID)
+		assert.ErrorIs(t, err, apperrors.ErrUnavailable)
+
+		failed := repo.Calls[len(repo.Calls)-1].Arguments.Get(0).(*models.AssetReport)
+		assert.Equal(t, models.ExportStatusFailed, failed.Status)
+	})
+
+	t.Run("returns the report already under way", func(t *testing.T) {
+		repo := new(MockAssetReportRepository)
+		pending := &models.AssetReport{ID: uuid.New(), OwnerID: uuid.New(), OrganizationID: &orgID, Status: models.ExportStatusPending}
+		repo.On("FindUnfinished", &orgID).Return(pending, nil)
+		service := NewAssetReportService(repo, nil, 1, 1)
+
+		report, err := service.RequestReport(ctx, adminID, &orgID)
+		require.NoError(t, err)
+		assert.Equal(t, pending, report)
+		repo.AssertNotCalled(t, "Create", mock.Anything)
+		assert.Empty(t, service.queue)
+	})
+
+	t.Run("queues unfinished reports again on start", func(t *testing.T) {
+		repo := new(MockAssetReportRepository)
+		running := &models.AssetReport{ID: uuid.New(), OrganizationID: &orgID, Status: models.ExportStatusRunning}
+		finished := &models.AssetReport{ID: uuid.New(), OrganizationID: &orgID, Status: models.ExportStatusCompleted}
+		pending := &models.AssetReport{ID: uuid.New(), OrganizationID: &orgID, Status: models.ExportStatusPending}
+		repo.On("UnfinishedIDs").Return([]uuid.UUID{running.ID, finished.ID, pending.ID}, nil)
+		for _, report := range []*models.AssetReport{running, finished, pending} {
+			repo.On("GetByID", report.ID).Return(report, nil)
+		}
+		done := make(chan struct{})
+		repo.On("Update", mock.AnythingOfType("*models.AssetReport")).Return(nil).Run(func(args mock.Arguments) {
+			if report := args.Get(0).(*models.AssetReport); report == pending && report.Status == models.ExportStatusCompleted {
+				close(done)
+			}
+		})
+		repo.On("Inventory", &orgID).Return([]models.Team{}, []models.OwnerAssets{}, nil)
+		// A queue of one holds the rest back until the worker makes room
+		service := NewAssetReportService(repo, nil, 1, 1)
+
+		service.Start()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("the pending report was not built")
+		}
+		require.NoError(t, service.Shutdown(ctx))
+
+		assert.Equal(t, models.ExportStatusCompleted, running.Status)
+		// Finished elsewhere after it was listed, so it is left alone
+		repo.AssertNumberOfCalls(t, "Inventory", 2)
+	})
+
+	t.Run("admins of another organization cannot read the report", func(t *testing.T) {
+		repo := new(MockAssetReportRepository)
+		report := &models.AssetReport{ID: uuid.New(), OwnerID: adminID, OrganizationID: &orgID, Status: models.ExportStatusCompleted}
+		repo.On("GetByID", report.ID).Return(report, nil)
+		service := NewAssetReportService(repo, nil, 1, 1)
+
+		_, err := service.GetReport(ctx, report.ID, &orgID)
+		require.NoError(t, err)
+		_, err = service.GetReport(ctx, report.ID, nil)
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	})
+
+	t.Run("only completed reports are downloaded", func(t *testing.T) {
+		repo := new(MockAssetReportRepository)
+		report := &models.AssetReport{ID: uuid.New(), OwnerID: adminID, OrganizationID: &orgID, Status: models.ExportStatusRunning}
+		repo.On("GetByID", report.ID).Return(report, nil)
+		service := NewAssetReportService(repo, nil, 1, 1)
+
+		_, _, err := service.GetReportArtifact(ctx, report.ID, &orgID)
+		assert.Equal(t, apperrors.CodeConflict, apperrors.From(err).Code)
+		repo.AssertNotCalled(t, "GetArtifact", mock.Anything)
+	})
+}
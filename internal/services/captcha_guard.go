@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"seta-training/internal/apperrors"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/captcha"
+	"seta-training/pkg/logger"
+)
+
+// CaptchaGuard asks for a solved CAPTCHA before logging in or signing up
+// from an IP with too many failed logins lately
+type CaptchaGuard struct {
+	verifier  captcha.Verifier
+	attempts  repositories.LoginAttemptRepositoryInterface
+	threshold int
+	window    time.Duration
+	logger    logger.Logger
+	now       func() time.Time
+}
+
+// NewCaptchaGuard creates a guard requiring a CAPTCHA once an IP had
+// threshold failed logins within window, or always when threshold is 0.
+// log may be nil.
+func NewCaptchaGuard(verifier captcha.Verifier, attempts repositories.LoginAttemptRepositoryInterface, threshold int, window time.Duration, log logger.Logger) *CaptchaGuard {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &CaptchaGuard{
+		verifier:  verifier,
+		attempts:  attempts,
+		threshold: threshold,
+		window:    window,
+		logger:    log,
+		now:       time.Now,
+	}
+}
+
+// Check verifies the CAPTCHA token sent with the request in ctx when the
+// client's IP needs one
+func (g *CaptchaGuard) Check(ctx context.Context) error {
+	client := auth.ClientFromContext(ctx)
+	if g.threshold > 0 {
+		failures, err := g.attempts.CountFailures(ctx, client.IP, g.now().Add(-g.window))
+		if err != nil {
+			return err
+		}
+		if failures < int64(g.threshold) {
+			return nil
+		}
+	}
+
+	err := g.verifier.Verify(ctx, captcha.TokenFromContext(ctx), client.IP)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, captcha.ErrMissing):
+		return apperrors.CaptchaRequired("Solve the captcha and send its token in the %s header", captcha.TokenHeader)
+	case errors.Is(err, captcha.ErrRejected):
+		return apperrors.CaptchaRequired("The captcha was not solved, try again")
+	default:
+		g.logger.Error("Failed to verify captcha", logger.String("ip", client.IP), logger.Error(err))
+		return apperrors.Unavailable("Captcha verification is unavailable, try again later")
+	}
+}
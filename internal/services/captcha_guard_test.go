@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"seta-training/internal/apperrors"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/captcha"
+)
+
+// MockCaptchaVerifier is a mock implementation of captcha.Verifier
+type MockCaptchaVerifier struct {
+	mock.Mock
+}
+
+func (m *MockCaptchaVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	args := m.Called(token, remoteIP)
+	return args.Error(0)
+}
+
+func TestCaptchaGuard_Check(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	since := now.Add(-15 * time.Minute)
+	ctx := auth.WithClient(context.Background(), auth.Client{IP: "203.0.113.7"})
+
+	newGuard := func(threshold int, failures int64) (*CaptchaGuard, *MockCaptchaVerifier) {
+		verifier, attempts := new(MockCaptchaVerifier), new(MockLoginAttemptRepository)
+		attempts.On("CountFailures", "203.0.113.7", since).Return(failures, nil)
+		guard := NewCaptchaGuard(verifier, attempts, threshold, 15*time.Minute, nil)
+		guard.now = func() time.Time { return now }
+		return guard, verifier
+	}
+
+	t.Run("lets clients with few failures through", func(t *testing.T) {
+		guard, verifier := newGuard(5, 4)
+		assert.NoError(t, guard.Check(ctx))
+		verifier.AssertNotCalled(t, "Verify", mock.Anything, mock.Anything)
+	})
+
+	t.Run("asks clients with many failures for a captcha", func(t *testing.T) {
+		guard, verifier := newGuard(5, 5)
+		verifier.On("Verify", "", "203.0.113.7").Return(captcha.ErrMissing)
+
+		err := guard.Check(ctx)
+		assert.ErrorIs(t, err, apperrors.ErrCaptcha)
+	})
+
+	t.Run("accepts a solved captcha", func(t *testing.T) {
+		guard, verifier := newGuard(5, 5)
+		verifier.On("Verify", "solved", "203.0.113.7").Return(nil)
+
+		assert.NoError(t, guard.Check(captcha.WithToken(ctx, "solved")))
+	})
+
+	t.Run("rejects a wrong solution", func(t *testing.T) {
+		guard, verifier := newGuard(5, 5)
+		verifier.On("Verify", "wrong", "203.0.113.7").Return(captcha.ErrRejected)
+
+		assert.ErrorIs(t, guard.Check(captcha.WithToken(ctx, "wrong")), apperrors.ErrCaptcha)
+	})
+
+	t.Run("always asks with a zero threshold", func(t *testing.T) {
+		verifier := new(MockCaptchaVerifier)
+		verifier.On("Verify", "", "203.0.113.7").Return(captcha.ErrMissing)
+		guard := NewCaptchaGuard(verifier, new(MockLoginAttemptRepository), 0, 15*time.Minute, nil)
+
+		assert.ErrorIs(t, guard.Check(ctx), apperrors.ErrCaptcha)
+	})
+
+	t.Run("reports an unreachable provider as unavailable", func(t *testing.T) {
+		guard, verifier := newGuard(5, 5)
+		verifier.On("Verify", "solved", "203.0.113.7").Return(errors.New("connection refused"))
+
+		assert.ErrorIs(t, guard.Check(captcha.WithToken(ctx, "solved")), apperrors.ErrUnavailable)
+	})
+}
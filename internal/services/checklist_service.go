@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+)
+
+// MaxChecklistItems bounds the items of one note's checklist
+const MaxChecklistItems = 200
+
+// ChecklistService manages the checklists of notes. Anyone who can read a
+// note can read its checklist; changing it takes write access.
+type ChecklistService struct {
+	checklistRepo repositories.ChecklistRepositoryInterface
+	noteRepo      repositories.NoteRepositoryInterface
+	sanitizer     *NoteSanitizer
+	audit         audit.Recorder
+	now           func() time.Time
+}
+
+// NewChecklistService creates a checklist service. auditor may be nil to
+// disable audit logging.
+func NewChecklistService(checklistRepo repositories.ChecklistRepositoryInterface, noteRepo repositories.NoteRepositoryInterface, auditor audit.Recorder) *ChecklistService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	return &ChecklistService{
+		checklistRepo: checklistRepo,
+		noteRepo:      noteRepo,
+		sanitizer:     NewNoteSanitizer(),
+		audit:         auditor,
+		now:           time.Now,
+	}
+}
+
+// ChecklistItemInput adds an item. Without a position it goes last.
+type ChecklistItemInput struct {
+	Text     string `json:"text" binding:"required,max=500"`
+	Done     bool   `json:"done"`
+	Position *int   `json:"position" binding:"omitempty,min=0"`
+}
+
+// UpdateChecklistItemInput changes only the fields present
+type UpdateChecklistItemInput struct {
+	Text     *string `json:"text" binding:"omitempty,max=500"`
+	Done     *bool   `json:"done"`
+	Position *int    `json:"position" binding:"omitempty,min=0"`
+}
+
+// GetChecklist returns the checklist of a note in order
+func (s *ChecklistService) GetChecklist(ctx context.Context, noteID, userID uuid.UUID) ([]models.ChecklistItem, error) {
+	if err := s.checkAccess(ctx, noteID, userID, false); err != nil {
+		return nil, err
+	}
+	return s.checklistRepo.GetByNote(ctx, noteID)
+}
+
+func (s *ChecklistService) AddItem(ctx context.Context, noteID uuid.UUID, input *ChecklistItemInput, userID uuid.UUID) (*models.ChecklistItem, error) {
+	if err := s.checkAccess(ctx, noteID, userID, true); err != nil {
+		return nil, err
+	}
+	text, err := s.text(input.Text)
+	if err != nil {
+		return nil, err
+	}
+	count, err := s.checklistRepo.CountByNote(ctx, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count checklist items: %w", err)
+	}
+	if count >= MaxChecklistItems {
+		return nil, apperrors.Validation("a checklist can have at most %d items", MaxChecklistItems)
+	}
+
+	item := &models.ChecklistItem{NoteID: noteID, Text: text}
+	s.setDone(item, input.Done)
+	position := int(count)
+	if input.Position != nil {
+		position = *input.Position
+	}
+	if err := s.checklistRepo.Insert(ctx, item, position); err != nil {
+		return nil, fmt.Errorf("failed to add checklist item: %w", err)
+	}
+	s.record(ctx, noteID, item.ID, userID)
+	return item, nil
+}
+
+// UpdateItem edits, ticks or moves an item
+func (s *ChecklistService) UpdateItem(ctx context.Context, noteID, itemID uuid.UUID, input *UpdateChecklistItemInput, userID uuid.UUID) (*models.ChecklistItem, error) {
+	item, err := s.getItem(ctx, noteID, itemID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Text != nil {
+		if item.Text, err = s.text(*input.Text); err != nil {
+			return nil, err
+		}
+	}
+	if input.Done != nil {
+		s.setDone(item, *input.Done)
+	}
+	if input.Position != nil {
+		err = s.checklistRepo.Move(ctx, item, *input.Position)
+	} else {
+		err = s.checklistRepo.Update(ctx, item)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update checklist item: %w", err)
+	}
+	s.record(ctx, noteID, item.ID, userID)
+	return item, nil
+}
+
+func (s *ChecklistService) DeleteItem(ctx context.Context, noteID, itemID, userID uuid.UUID) error {
+	if _, err := s.getItem(ctx, noteID, itemID, userID); err != nil {
+		return err
+	}
+	if err := s.checklistRepo.Delete(ctx, itemID); err != nil {
+		return fmt.Errorf("failed to delete checklist item: %w", err)
+	}
+	s.record(ctx, noteID, itemID, userID)
+	return nil
+}
+
+// getItem returns an item of the note after checking write access to it
+func (s *ChecklistService) getItem(ctx context.Context, noteID, itemID, userID uuid.UUID) (*models.ChecklistItem, error) {
+	if err := s.checkAccess(ctx, noteID, userID, true); err != nil {
+		return nil, err
+	}
+	item, err := s.checklistRepo.GetByID(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.NoteID != noteID {
+		return nil, apperrors.NotFound("checklist item not found")
+	}
+	return item, nil
+}
+
+func (s *ChecklistService) checkAccess(ctx context.Context, noteID, userID uuid.UUID, write bool) error {
+	hasAccess, access, err := s.noteRepo.HasAccess(ctx, noteID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+	if !hasAccess {
+		return apperrors.Forbidden("access denied")
+	}
+	if write && access != models.AccessWrite {
+		return apperrors.Forbidden("write access required")
+	}
+	return nil
+}
+
+// text strips markup from item text, which must not end up blank
+func (s *ChecklistService) text(text string) (string, error) {
+	text = strings.TrimSpace(s.sanitizer.Text(text))
+	if text == "" {
+		return "", apperrors.ValidationFields("Invalid checklist item", map[string]string{"text": "must not be blank"})
+	}
+	return text, nil
+}
+
+// setDone ticks or unticks an item, recording when it was done
+func (s *ChecklistService) setDone(item *models.ChecklistItem, done bool) {
+	if item.Done == done {
+		return
+	}
+	item.Done = done
+	item.DoneAt = nil
+	if done {
+		now := s.now().UTC()
+		item.DoneAt = &now
+	}
+}
+
+// record logs a checklist change as an update of its note
+func (s *ChecklistService) record(ctx context.Context, noteID, itemID, userID uuid.UUID) {
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetNote,
+		TargetID:   noteID,
+		Details:    map[string]string{"checklist_item_id": itemID.String()},
+	})
+}
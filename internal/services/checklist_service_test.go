@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+)
+
+// MockChecklistRepository is a mock implementation of ChecklistRepositoryInterface
+type MockChecklistRepository struct {
+	mock.Mock
+}
+
+func (m *MockChecklistRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ChecklistItem, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ChecklistItem), args.Error(1)
+}
+
+func (m *MockChecklistRepository) GetByNote(ctx context.Context, noteID uuid.UUID) ([]models.ChecklistItem, error) {
+	args := m.Called(noteID)
+	return args.Get(0).([]models.ChecklistItem), args.Error(1)
+}
+
+func (m *MockChecklistRepository) CountByNote(ctx context.Context, noteID uuid.UUID) (int64, error) {
+	args := m.Called(noteID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockChecklistRepository) Insert(ctx context.Context, item *models.ChecklistItem, position int) error {
+	args := m.Called(item, position)
+	return args.Error(0)
+}
+
+func (m *MockChecklistRepository) Update(ctx context.Context, item *models.ChecklistItem) error {
+	args := m.Called(item)
+	return args.Error(0)
+}
+
+func (m *MockChecklistRepository) Move(ctx context.Context, item *models.ChecklistItem, position int) error {
+	args := m.Called(item, position)
+	return args.Error(0)
+}
+
+func (m *MockChecklistRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestChecklistService_AddItem(t *testing.T) {
+	ctx := context.Background()
+	noteID, userID := uuid.New(), uuid.New()
+
+	newService := func(access models.AccessLevel) (*ChecklistService, *MockChecklistRepository, *MockAuditRecorder) {
+		checklistRepo, noteRepo := new(MockChecklistRepository), new(MockNoteRepository)
+		noteRepo.On("HasAccess", noteID, userID).Return(access != "", access, nil)
+		recorder := new(MockAuditRecorder)
+		recorder.On("Record", mock.AnythingOfType("audit.Entry")).Return()
+		return NewChecklistService(checklistRepo, noteRepo, recorder), checklistRepo, recorder
+	}
+
+	t.Run("appends without a position", func(t *testing.T) {
+		service, checklistRepo, recorder := newService(models.AccessWrite)
+		checklistRepo.On("CountByNote", noteID).Return(int64(3), nil)
+		checklistRepo.On("Insert", mock.AnythingOfType("*models.ChecklistItem"), 3).Return(nil)
+
+		item, err := service.AddItem(ctx, noteID, &ChecklistItemInput{Text: "  <b>Book</b> the venue "}, userID)
+		require.NoError(t, err)
+		assert.Equal(t, "Book the venue", item.Text)
+		assert.False(t, item.Done)
+		checklistRepo.AssertExpectations(t)
+		recorder.AssertCalled(t, "Record", mock.MatchedBy(func(entry audit.Entry) bool {
+			return entry.TargetType == audit.TargetNote && entry.TargetID == noteID && entry.Details["checklist_item_id"] == item.ID.String()
+		}))
+	})
+
+	t.Run("inserts at a position", func(t *testing.T) {
+		service, checklistRepo, _ := newService(models.AccessWrite)
+		position := 0
+		checklistRepo.On("CountByNote", noteID).Return(int64(3), nil)
+		checklistRepo.On("Insert", mock.AnythingOfType("*models.ChecklistItem"), 0).Return(nil)
+
+		item, err := service.AddItem(ctx, noteID, &ChecklistItemInput{Text: "First", Done: true, Position: &position}, userID)
+		require.NoError(t, err)
+		assert.True(t, item.Done)
+		assert.NotNil(t, item.DoneAt)
+		checklistRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects blank text", func(t *testing.T) {
+		service, checklistRepo, _ := newService(models.AccessWrite)
+
+		_, err := service.AddItem(ctx, noteID, &ChecklistItemInput{Text: "<p> </p>"}, userID)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Equal(t, map[string]string{"text": "must not be blank"}, apperrors.From(err).Fields)
+		checklistRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("caps the checklist", func(t *testing.T) {
+		service, checklistRepo, _ := newService(models.AccessWrite)
+		checklistRepo.On("CountByNote", noteID).Return(int64(MaxChecklistItems), nil)
+
+		_, err := service.AddItem(ctx, noteID, &ChecklistItemInput{Text: "One more"}, userID)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		checklistRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+
+	t.Run("requires write access", func(t *testing.T) {
+		service, checklistRepo, _ := newService(models.AccessRead)
+
+		_, err := service.AddItem(ctx, noteID, &ChecklistItemInput{Text: "Read only"}, userID)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		checklistRepo.AssertNotCalled(t, "Insert", mock.Anything, mock.Anything)
+	})
+}
+
+func TestChecklistService_UpdateItem(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	noteID, userID := uuid.New(), uuid.New()
+
+	newService := func(item *models.ChecklistItem) (*ChecklistService, *MockChecklistRepository) {
+		checklistRepo, noteRepo := new(MockChecklistRepository), new(MockNoteRepository)
+		noteRepo.On("HasAccess", noteID, userID).Return(true, models.AccessWrite, nil)
+		checklistRepo.On("GetByID", item.ID).Return(item, nil)
+		service := NewChecklistService(checklistRepo, noteRepo, nil)
+		service.now = func() time.Time { return now }
+		return service, checklistRepo
+	}
+
+	t.Run("ticks and unticks an item", func(t *testing.T) {
+		item := &models.ChecklistItem{ID: uuid.New(), NoteID: noteID, Text: "Draft", Position: 1}
+		service, checklistRepo := newService(item)
+		checklistRepo.On("Update", item).Return(nil)
+
+		done := true
+		updated, err := service.UpdateItem(ctx, noteID, item.ID, &UpdateChecklistItemInput{Done: &done}, userID)
+		require.NoError(t, err)
+		assert.True(t, updated.Done)
+		require.NotNil(t, updated.DoneAt)
+		assert.Equal(t, now, *updated.DoneAt)
+		assert.Equal(t, "Draft", updated.Text)
+
+		done = false
+		updated, err = service.UpdateItem(ctx, noteID, item.ID, &UpdateChecklistItemInput{Done: &done}, userID)
+		require.NoError(t, err)
+		assert.False(t, updated.Done)
+		assert.Nil(t, updated.DoneAt)
+		checklistRepo.AssertNotCalled(t, "Move", mock.Anything, mock.Anything)
+	})
+
+	t.Run("moves an item", func(t *testing.T) {
+		item := &models.ChecklistItem{ID: uuid.New(), NoteID: noteID, Text: "Draft", Position: 4}
+		service, checklistRepo := newService(item)
+		checklistRepo.On("Move", item, 0).Return(nil)
+
+		position := 0
+		_, err := service.UpdateItem(ctx, noteID, item.ID, &UpdateChecklistItemInput{Position: &position}, userID)
+		require.NoError(t, err)
+		checklistRepo.AssertExpectations(t)
+		checklistRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("hides items of other notes", func(t *testing.T) {
+		item := &models.ChecklistItem{ID: uuid.New(), NoteID: uuid.New()}
+		service, checklistRepo := newService(item)
+
+		err := service.DeleteItem(ctx, noteID, item.ID, userID)
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+		checklistRepo.AssertNotCalled(t, "Delete", mock.Anything)
+	})
+}
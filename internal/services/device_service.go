@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/logger"
+)
+
+// revokedRefreshInterval is how long a revocation made on another instance
+// may take to reject a device's tokens here
+const revokedRefreshInterval = 30 * time.Second
+
+var errDeviceRevoked = errors.New("device has been revoked")
+
+// DeviceService keeps track of the devices users log in with and lets them
+// revoke one, which rejects the tokens issued to it
+type DeviceService struct {
+	repo     repositories.DeviceRepositoryInterface
+	audit    audit.Recorder
+	tokenTTL time.Duration
+	logger   logger.Logger
+	now      func() time.Time
+
+	// mu guards the revoked devices, which CheckToken reads on every
+	// request; reloadMu lets one caller at a time reload them from the
+	// database without holding mu
+	mu          sync.RWMutex
+	revoked     map[uuid.UUID]struct{}
+	revokedHere map[uuid.UUID]struct{}
+	refreshed   time.Time
+	reloadMu    sync.Mutex
+}
+
+// NewDeviceService creates a device service for tokens valid for tokenTTL.
+// auditor and log may be nil.
+func NewDeviceService(repo repositories.DeviceRepositoryInterface, auditor audit.Recorder, tokenTTL time.Duration, log logger.Logger) *DeviceService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &DeviceService{
+		repo:     repo,
+		audit:    auditor,
+		tokenTTL: tokenTTL,
+		logger:   log,
+		now:      time.Now,
+	}
+}
+
+// Register returns the device user is logging in with from the client in
+// ctx, adding it when the user has not logged in with its user agent since
+// it was last revoked
+func (s *DeviceService) Register(ctx context.Context, user *models.User) (uuid.UUID, error) {
+	client := auth.ClientFromContext(ctx)
+	if len(client.UserAgent) > maxUserAgentLength {
+		client.UserAgent = client.UserAgent[:maxUserAgentLength]
+	}
+
+	device, err := s.repo.FindActive(ctx, user.ID, client.UserAgent)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to find device: %w", err)
+	}
+	if device == nil {
+		device = &models.Device{UserID: user.ID, UserAgent: client.UserAgent, LastIP: client.IP, LastLoginAt: s.now()}
+		if err := s.repo.Create(ctx, device); err != nil {
+			return uuid.Nil, fmt.Errorf("failed to add device: %w", err)
+		}
+		return device.ID, nil
+	}
+
+	device.LastIP = client.IP
+	device.LastLoginAt = s.now()
+	if err := s.repo.Update(ctx, device); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update device: %w", err)
+	}
+	return device.ID, nil
+}
+
+// GetMyDevices lists the devices userID is logged in with, most recently
+// used first, marking the one of the token in ctx as current
+func (s *DeviceService) GetMyDevices(ctx context.Context, userID uuid.UUID) ([]models.Device, error) {
+	devices, err := s.repo.GetActiveByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if claims, ok := auth.FromContext(ctx); ok && claims.DeviceID != nil {
+		for i := range devices {
+			devices[i].Current = devices[i].ID == *claims.DeviceID
+		}
+	}
+	return devices, nil
+}
+
+// RevokeDevice revokes one of userID's devices. Its tokens are rejected
+// from then on and cannot be refreshed.
+func (s *DeviceService) RevokeDevice(ctx context.Context, deviceID, userID uuid.UUID) error {
+	if err := s.repo.Revoke(ctx, deviceID, userID, s.now()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.revoked != nil {
+		s.revoked[deviceID] = struct{}{}
+	}
+	if s.revokedHere != nil {
+		s.revokedHere[deviceID] = struct{}{}
+	}
+	s.mu.Unlock()
+
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionRevokeDevice,
+		TargetType: audit.TargetDevice,
+		TargetID:   deviceID,
+	})
+	return nil
+}
+
+// CheckToken rejects tokens of revoked devices. Use it as the JWT manager's
+// token check.
+func (s *DeviceService) CheckToken(claims *auth.Claims) error {
+	if claims.DeviceID == nil {
+		return nil
+	}
+	if s.isRevoked(*claims.DeviceID) {
+		return errDeviceRevoked
+	}
+	return nil
+}
+
+// isRevoked reports whether deviceID is among the devices revoked while
+// tokens issued to them could still be valid. Those are reloaded every
+// revokedRefreshInterval; a failed reload keeps the last ones.
+func (s *DeviceService) isRevoked(deviceID uuid.UUID) bool {
+	now := s.now()
+	s.mu.RLock()
+	loaded := s.revoked != nil
+	stale := now.Sub(s.refreshed) >= revokedRefreshInterval
+	s.mu.RUnlock()
+	if !loaded || stale {
+		s.reloadRevoked(now, loaded)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.revoked[deviceID]
+	return ok
+}
+
+// reloadRevoked replaces the revoked devices with those revoked within a
+// token lifetime of now. While one caller reloads, the others check against
+// the last ones, or wait for it when none have been loaded yet.
+func (s *DeviceService) reloadRevoked(now time.Time, loaded bool) {
+	if !loaded {
+		s.reloadMu.Lock()
+	} else if !s.reloadMu.TryLock() {
+		return
+	}
+	defer s.reloadMu.Unlock()
+
+	s.mu.Lock()
+	if s.revoked != nil && now.Sub(s.refreshed) < revokedRefreshInterval {
+		// Reloaded by the caller we waited for
+		s.mu.Unlock()
+		return
+	}
+	// Devices revoked here from now on may be missing from what is loaded
+	s.revokedHere = map[uuid.UUID]struct{}{}
+	s.mu.Unlock()
+
+	ids, err := s.repo.RevokedSince(context.Background(), now.Add(-s.tokenTTL))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshed = now
+	revokedHere := s.revokedHere
+	s.revokedHere = nil
+	if err != nil {
+		s.logger.Error("Failed to load revoked devices, keeping the last ones", logger.Error(err))
+		if s.revoked == nil {
+			s.revoked = revokedHere
+		}
+		return
+	}
+	revoked := make(map[uuid.UUID]struct{}, len(ids)+len(revokedHere))
+	for _, id := range ids {
+		revoked[id] = struct{}{}
+	}
+	for id := range revokedHere {
+		revoked[id] = struct{}{}
+	}
+	s.revoked = revoked
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+// MockDeviceRepository is a mock implementation of DeviceRepositoryInterface
+type MockDeviceRepository struct {
+	mock.Mock
+}
+
+func (m *MockDeviceRepository) Create(ctx context.Context, device *models.Device) error {
+	args := m.Called(device)
+	if device.ID == uuid.Nil {
+		device.ID = uuid.New()
+	}
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) Update(ctx context.Context, device *models.Device) error {
+	args := m.Called(device)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) FindActive(ctx context.Context, userID uuid.UUID, userAgent string) (*models.Device, error) {
+	args := m.Called(userID, userAgent)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Device), args.Error(1)
+}
+
+func (m *MockDeviceRepository) GetActiveByUser(ctx context.Context, userID uuid.UUID) ([]models.Device, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Device), args.Error(1)
+}
+
+func (m *MockDeviceRepository) Revoke(ctx context.Context, id, userID uuid.UUID, at time.Time) error {
+	args := m.Called(id, userID)
+	return args.Error(0)
+}
+
+func (m *MockDeviceRepository) RevokedSince(ctx context.Context, since time.Time) ([]uuid.UUID, error) {
+	args := m.Called(since)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func TestDeviceService_Register(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+	ctx := auth.WithClient(context.Background(), auth.Client{IP: "203.0.113.7", UserAgent: "Firefox"})
+
+	t.Run("adds a device for a new user agent", func(t *testing.T) {
+		repo := new(MockDeviceRepository)
+		repo.On("FindActive", user.ID, "Firefox").Return(nil, nil)
+		repo.On("Create", mock.AnythingOfType("*models.Device")).Return(nil)
+
+		deviceID, err := NewDeviceService(repo, nil, time.Hour, nil).Register(ctx, user)
+		require.NoError(t, err)
+
+		device := repo.Calls[1].Arguments.Get(0).(*models.Device)
+		assert.Equal(t, device.ID, deviceID)
+		assert.Equal(t, user.ID, device.UserID)
+		assert.Equal(t, "203.0.113.7", device.LastIP)
+	})
+
+	t.Run("reuses the device of a known user agent", func(t *testing.T) {
+		known := &models.Device{ID: uuid.New(), UserID: user.ID, UserAgent: "Firefox", LastIP: "198.51.100.1"}
+		repo := new(MockDeviceRepository)
+		repo.On("FindActive", user.ID, "Firefox").Return(known, nil)
+		repo.On("Update", known).Return(nil)
+
+		deviceID, err := NewDeviceService(repo, nil, time.Hour, nil).Register(ctx, user)
+		require.NoError(t, err)
+		assert.Equal(t, known.ID, deviceID)
+		assert.Equal(t, "203.0.113.7", known.LastIP)
+		repo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestDeviceService_GetMyDevices(t *testing.T) {
+	userID := uuid.New()
+	devices := []models.Device{{ID: uuid.New(), UserID: userID}, {ID: uuid.New(), UserID: userID}}
+	repo := new(MockDeviceRepository)
+	repo.On("GetActiveByUser", userID).Return(devices, nil)
+	ctx := auth.NewContext(context.Background(), &auth.Claims{UserID: userID, DeviceID: &devices[1].ID})
+
+	got, err := NewDeviceService(repo, nil, time.Hour, nil).GetMyDevices(ctx, userID)
+	require.NoError(t, err)
+	assert.False(t, got[0].Current)
+	assert.True(t, got[1].Current)
+}
+
+func TestDeviceService_RevokeDevice(t *testing.T) {
+	ctx := context.Background()
+	userID, deviceID := uuid.New(), uuid.New()
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	later := start.Add(revokedRefreshInterval)
+
+	newService := func() (*DeviceService, *MockDeviceRepository, *MockAuditRecorder, *time.Time) {
+		repo, recorder := new(MockDeviceRepository), new(MockAuditRecorder)
+		repo.On("RevokedSince", start.Add(-time.Hour)).Return([]uuid.UUID{}, nil)
+		recorder.On("Record", mock.AnythingOfType("audit.Entry")).Return()
+		service := NewDeviceService(repo, recorder, time.Hour, nil)
+		now := start
+		service.now = func() time.Time { return now }
+		return service, repo, recorder, &now
+	}
+
+	t.Run("rejects the device's tokens", func(t *testing.T) {
+		service, repo, recorder, _ := newService()
+		repo.On("Revoke", deviceID, userID).Return(nil)
+		claims := &auth.Claims{UserID: userID, DeviceID: &deviceID}
+		require.NoError(t, service.CheckToken(claims))
+
+		require.NoError(t, service.RevokeDevice(ctx, deviceID, userID))
+		assert.Error(t, service.CheckToken(claims))
+		assert.NoError(t, service.CheckToken(&auth.Claims{UserID: userID}))
+
+		entry := recorder.Calls[0].Arguments.Get(0).(audit.Entry)
+		assert.Equal(t, audit.ActionRevokeDevice, entry.Action)
+		assert.Equal(t, deviceID, entry.TargetID)
+	})
+
+	t.Run("picks up revocations made elsewhere", func(t *testing.T) {
+		service, repo, _, now := newService()
+		claims := &auth.Claims{UserID: userID, DeviceID: &deviceID}
+		require.NoError(t, service.CheckToken(claims))
+
+		*now = later
+		repo.On("RevokedSince", later.Add(-time.Hour)).Return([]uuid.UUID{deviceID}, nil)
+		assert.Error(t, service.CheckToken(claims))
+	})
+
+	t.Run("keeps the last revocations when reloading fails", func(t *testing.T) {
+		service, repo, _, now := newService()
+		repo.On("Revoke", deviceID, userID).Return(nil)
+		claims := &auth.Claims{UserID: userID, DeviceID: &deviceID}
+		require.NoError(t, service.CheckToken(claims))
+		require.NoError(t, service.RevokeDevice(ctx, deviceID, userID))
+
+		*now = later
+		repo.On("RevokedSince", later.Add(-time.Hour)).Return([]uuid.UUID{}, errors.New("connection refused"))
+		assert.Error(t, service.CheckToken(claims))
+	})
+
+	t.Run("only revokes the user's own devices", func(t *testing.T) {
+		service, repo, recorder, _ := newService()
+		repo.On("Revoke", deviceID, userID).Return(apperrors.NotFound("device not found"))
+
+		err := service.RevokeDevice(ctx, deviceID, userID)
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+		recorder.AssertNotCalled(t, "Record", mock.Anything)
+	})
+}
+
+func TestDeviceService_CheckTokenWhileReloading(t *testing.T) {
+	userID, deviceID, otherID := uuid.New(), uuid.New(), uuid.New()
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	later := start.Add(revokedRefreshInterval)
+
+	repo, recorder := new(MockDeviceRepository), new(MockAuditRecorder)
+	repo.On("RevokedSince", start.Add(-time.Hour)).Return([]uuid.UUID{deviceID}, nil).Once()
+	repo.On("Revoke", otherID, userID).Return(nil)
+	recorder.On("Record", mock.AnythingOfType("audit.Entry")).Return()
+	service := NewDeviceService(repo, recorder, time.Hour, nil)
+	service.now = func() time.Time { return start }
+	claims := &auth.Claims{UserID: userID, DeviceID: &deviceID}
+	other := &auth.Claims{UserID: userID, DeviceID: &otherID}
+	require.Error(t, service.CheckToken(claims))
+
+	// The next reload hangs on the database until released. It no longer
+	// finds deviceID, and misses otherID, which is revoked meanwhile.
+	reloading, release := make(chan struct{}), make(chan struct{})
+	repo.On("RevokedSince", later.Add(-time.Hour)).Return([]uuid.UUID{}, nil).Once().
+		Run(func(mock.Arguments) {
+			close(reloading)
+			<-release
+		})
+	service.now = func() time.Time { return later }
+	reloaded := make(chan error)
+	go func() { reloaded <- service.CheckToken(claims) }()
+	<-reloading
+
+	// Other checks use the last revocations rather than wait
+	assert.Error(t, service.CheckToken(claims))
+	assert.NoError(t, service.CheckToken(other))
+	require.NoError(t, service.RevokeDevice(context.Background(), otherID, userID))
+	assert.Error(t, service.CheckToken(other))
+
+	close(release)
+	assert.NoError(t, <-reloaded)
+	assert.NoError(t, service.CheckToken(claims))
+	assert.Error(t, service.CheckToken(other), "a revocation made during the reload is kept")
+	repo.AssertExpectations(t)
+}
+
+func TestUserService_Login_BindsTokenToDevice(t *testing.T) {
+	hashedPassword, _ := auth.HashPassword("password123")
+	user := &models.User{ID: uuid.New(), Email: "test@example.com", PasswordHash: hashedPassword}
+	known := &models.Device{ID: uuid.New(), UserID: user.ID, UserAgent: "Firefox"}
+	ctx := auth.WithClient(context.Background(), auth.Client{IP: "203.0.113.7", UserAgent: "Firefox"})
+
+	userRepo, deviceRepo, jwtManager := new(MockUserRepository), new(MockDeviceRepository), new(MockJWTManager)
+	userRepo.On("GetByEmail", user.Email).Return(user, nil)
+	deviceRepo.On("FindActive", user.ID, "Firefox").Return(known, nil)
+	deviceRepo.On("Update", known).Return(nil)
+	jwtManager.On("GenerateDeviceToken", user, known.ID).Return("device-token", nil)
+	service := NewUserService(userRepo, nil, jwtManager, nil, nil, nil, NewDeviceService(deviceRepo, nil, time.Hour, nil))
+
+	response, err := service.Login(ctx, &LoginInput{Email: user.Email, Password: "password123"})
+	require.NoError(t, err)
+	assert.Equal(t, "device-token", response.Token)
+	jwtManager.AssertNotCalled(t, "GenerateToken", mock.Anything)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"seta-training/internal/models"
+)
+
+// exportTimeFormat is how the export time is printed in artifacts
+const exportTimeFormat = "2006-01-02 15:04 MST"
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// exportFileName turns a title into a safe file name stem
+func exportFileName(title string) string {
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(strings.TrimSpace(title), "-"), "-.")
+	if name == "" {
+		return "untitled"
+	}
+	if len(name) > 80 {
+		name = name[:80]
+	}
+	return name
+}
+
+// renderMarkdownZip writes each note as a markdown file plus an index.md
+// table of contents linking to them
+func renderMarkdownZip(folder *models.Folder, notes []models.Note, exportedAt time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	var index strings.Builder
+	fmt.Fprintf(&index, "# %s\n\n", folder.Name)
+	fmt.Fprintf(&index, "_Exported %s_\n\n", exportedAt.Format(exportTimeFormat))
+
+	for i, note := range notes {
+		fileName := fmt.Sprintf("%03d-%s.md", i+1, exportFileName(note.Title))
+		fmt.Fprintf(&index, "%d. [%s](%s)\n", i+1, note.Title, fileName)
+
+		w, err := zw.Create(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to archive: %w", fileName, err)
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n\n%s\n", note.Title, note.Body); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", fileName, err)
+		}
+	}
+
+	w, err := zw.Create("index.md")
+	if err != nil {
+		return nil, fmt.Errorf("failed to add index to archive: %w", err)
+	}
+	if _, err := w.Write([]byte(index.String())); err != nil {
+		return nil, fmt.Errorf("failed to write index: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderPDF concatenates the notes into one document. The first page is a
+// table of contents whose page numbers are filled in through aliases once
+// every note has been laid out.
+func renderPDF(folder *models.Folder, notes []models.Note, exportedAt time.Time) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle(tr(folder.Name), false)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 10, fmt.Sprintf("Page %d/{nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.MultiCell(0, 10, tr(folder.Name), "", "L", false)
+	pdf.SetFont("Helvetica", "I", 9)
+	pdf.CellFormat(0, 6, "Exported "+exportedAt.Format(exportTimeFormat), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+	pdf.SetFont("Helvetica", "B", 13)
+	pdf.CellFormat(0, 8, "Contents", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+
+	links := make([]int, len(notes))
+	for i, note := range notes {
+		links[i] = pdf.AddLink()
+		title := tr(fmt.Sprintf("%d. %s", i+1, note.Title))
+		pdf.CellFormat(170, 7, title, "", 0, "L", false, links[i], "")
+		pdf.CellFormat(0, 7, tocPageAlias(i), "", 1, "R", false, links[i], "")
+	}
+
+	for i, note := range notes {
+		pdf.AddPage()
+		pdf.SetLink(links[i], 0, -1)
+		pdf.RegisterAlias(tocPageAlias(i), fmt.Sprintf("%d", pdf.PageNo()))
+
+		pdf.SetFont("Helvetica", "B", 15)
+		pdf.MultiCell(0, 9, tr(note.Title), "", "L", false)
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "", 11)
+		pdf.MultiCell(0, 6, tr(note.Body), "", "L", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func tocPageAlias(i int) string {
+	return fmt.Sprintf("{toc:%d}", i)
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/logger"
+)
+
+// ExportService renders folders into downloadable artifacts on a pool of
+// background workers
+type ExportService struct {
+	jobRepo    repositories.ExportJobRepositoryInterface
+	folderRepo repositories.FolderRepositoryInterface
+	noteRepo   repositories.NoteRepositoryInterface
+	prefRepo   repositories.UserPreferenceRepositoryInterface
+	sanitizer  *NoteSanitizer
+	logger     logger.Logger
+
+	workers int
+	queue   chan uuid.UUID
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewExportService creates an export service. Call Start to begin
+// processing queued jobs. prefRepo may be nil, in which case artifacts are
+// stamped in UTC.
+func NewExportService(jobRepo repositories.ExportJobRepositoryInterface, folderRepo repositories.FolderRepositoryInterface, noteRepo repositories.NoteRepositoryInterface, prefRepo repositories.UserPreferenceRepositoryInterface, log logger.Logger, workers, queueSize int) *ExportService {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ExportService{
+		jobRepo:    jobRepo,
+		folderRepo: folderRepo,
+		noteRepo:   noteRepo,
+		prefRepo:   prefRepo,
+		sanitizer:  NewNoteSanitizer(),
+		logger:     log,
+		workers:    workers,
+		queue:      make(chan uuid.UUID, queueSize),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// ParseExportFormat validates a requested export format
+func ParseExportFormat(format string) (models.ExportFormat, error) {
+	switch models.ExportFormat(format) {
+	case models.ExportFormatZip, models.ExportFormatPDF:
+		return models.ExportFormat(format), nil
+	default:
+		return "", apperrors.Validation("unsupported export format %q: must be zip or pdf", format)
+	}
+}
+
+// Start launches the worker pool and queues again the jobs left pending or
+// running when the service last stopped
+func (s *ExportService) Start() {
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.worker()
+	}
+
+	// Listed before any export can be requested, so none is queued twice
+	ids, err := s.jobRepo.UnfinishedIDs(s.ctx)
+	if err != nil {
+		s.logger.Error("Failed to load unfinished export jobs", logger.Error(err))
+		return
+	}
+	s.wg.Add(1)
+	go s.requeue(ids)
+}
+
+// requeue queues jobIDs as workers make room for them
+func (s *ExportService) requeue(jobIDs []uuid.UUID) {
+	defer s.wg.Done()
+	for _, id := range jobIDs {
+		select {
+		case s.queue <- id:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// Shutdown stops workers from picking up new jobs and waits for running ones
+// to finish. Jobs still queued stay pending.
+func (s *ExportService) Shutdown(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RequestFolderExport queues an export of every note in folderID the user
+// can read
+func (s *ExportService) RequestFolderExport(ctx context.Context, folderID, userID uuid.UUID, format models.ExportFormat) (*models.ExportJob, error) {
+	if _, err := s.folderRepo.GetByID(ctx, folderID); err != nil {
+		return nil, err
+	}
+
+	// Access is re-checked per note when the job runs, since shares may
+	// change while it is queued
+	notes, err := s.readableNotes(ctx, folderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(notes) == 0 {
+		return nil, apperrors.Forbidden("access denied")
+	}
+
+	job := &models.ExportJob{
+		OwnerID:  userID,
+		FolderID: folderID,
+		Format:   format,
+		Status:   models.ExportStatusPending,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		return nil, fmt.Errorf("failed to create export job: %w", err)
+	}
+
+	select {
+	case s.queue <- job.ID:
+	default:
+		err := apperrors.Unavailable("export queue is full, try again later")
+		s.fail(ctx, job, err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// GetExportJob returns a job owned by userID
+func (s *ExportService) GetExportJob(ctx context.Context, jobID, userID uuid.UUID) (*models.ExportJob, error) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.OwnerID != userID {
+		return nil, apperrors.NotFound("export job not found")
+	}
+	return job, nil
+}
+
+// GetExportArtifact returns the rendered artifact of a completed job
+func (s *ExportService) GetExportArtifact(ctx context.Context, jobID, userID uuid.UUID) (*models.ExportJob, []byte, error) {
+	job, err := s.GetExportJob(ctx, jobID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.Status != models.ExportStatusCompleted {
+		return nil, nil, apperrors.Conflict("export job is %s", job.Status)
+	}
+
+	data, err := s.jobRepo.GetArtifact(ctx, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, data, nil
+}
+
+func (s *ExportService) worker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case jobID := <-s.queue:
+			// Running jobs finish even while shutting down
+			s.process(context.WithoutCancel(s.ctx), jobID)
+		}
+	}
+}
+
+func (s *ExportService) process(ctx context.Context, jobID uuid.UUID) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		s.logger.Error("Failed to load export job", logger.String("job_id", jobID.String()), logger.Error(err))
+		return
+	}
+	if job.Status == models.ExportStatusCompleted || job.Status == models.ExportStatusFailed {
+		// Queued again at start while another instance finished it
+		return
+	}
+
+	job.Status = models.ExportStatusRunning
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		s.logger.Error("Failed to mark export job running", logger.String("job_id", jobID.String()), logger.Error(err))
+		return
+	}
+
+	start := time.Now()
+	if err := s.render(ctx, job); err != nil {
+		s.fail(ctx, job, err)
+		return
+	}
+
+	now := time.Now()
+	job.Status = models.ExportStatusCompleted
+	job.CompletedAt = &now
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		s.logger.Error("Failed to store export artifact", logger.String("job_id", jobID.String()), logger.Error(err))
+		return
+	}
+
+	s.logger.Info("Export job completed",
+		logger.String("job_id", jobID.String()),
+		logger.String("format", string(job.Format)),
+		logger.Int("notes", job.NoteCount),
+		logger.Int("size_bytes", job.SizeBytes),
+		logger.Duration("duration", time.Since(start)),
+	)
+}
+
+func (s *ExportService) render(ctx context.Context, job *models.ExportJob) error {
+	folder, err := s.folderRepo.GetByID(ctx, job.FolderID)
+	if err != nil {
+		return err
+	}
+
+	notes, err := s.readableNotes(ctx, job.FolderID, job.OwnerID)
+	if err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		return errors.New("no notes in this folder are readable by the requester")
+	}
+
+	// Stamped in the requester's time zone, since they are the one reading it
+	exportedAt := time.Now().In(preferencesOf(ctx, s.prefRepo, job.OwnerID, s.logger).Location())
+
+	var data []byte
+	switch job.Format {
+	case models.ExportFormatZip:
+		// Markdown is usually rendered as HTML by whatever opens it
+		s.sanitizer.Notes(notes)
+		data, err = renderMarkdownZip(folder, notes, exportedAt)
+		job.ContentType = "application/zip"
+	case models.ExportFormatPDF:
+		data, err = renderPDF(folder, notes, exportedAt)
+		job.ContentType = "application/pdf"
+	default:
+		err = fmt.Errorf("unsupported export format %q", job.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	job.FileName = exportFileName(folder.Name) + "." + string(job.Format)
+	job.NoteCount = len(notes)
+	job.SizeBytes = len(data)
+	job.Artifact = data
+	return nil
+}
+
+// readableNotes returns the folder's notes the user may read: all of them
+// with folder access, otherwise only those shared with the user directly
+func (s *ExportService) readableNotes(ctx context.Context, folderID, userID uuid.UUID) ([]models.Note, error) {
+	notes, err := s.noteRepo.GetByFolder(ctx, folderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+
+	folderAccess, _, err := s.folderRepo.HasAccess(ctx, folderID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check folder access: %w", err)
+	}
+	if folderAccess {
+		return notes, nil
+	}
+
+	readable := make([]models.Note, 0, len(notes))
+	for _, note := range notes {
+		hasAccess, _, err := s.noteRepo.HasAccess(ctx, note.ID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check note access: %w", err)
+		}
+		if hasAccess {
+			readable = append(readable, note)
+		}
+	}
+	return readable, nil
+}
+
+func (s *ExportService) fail(ctx context.Context, job *models.ExportJob, cause error) {
+	now := time.Now()
+	job.Status = models.ExportStatusFailed
+	job.Error = cause.Error()
+	job.Artifact = nil
+	job.CompletedAt = &now
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		s.logger.Error("Failed to mark export job failed", logger.String("job_id", job.ID.String()), logger.Error(err))
+		return
+	}
+	s.logger.Warn("Export job failed", logger.String("job_id", job.ID.String()), logger.Error(cause))
+}
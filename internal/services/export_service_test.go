@@ -0,0 +1,150 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/models"
+)
+
+// MockExportJobRepository is a mock implementation of ExportJobRepositoryInterface
+type MockExportJobRepository struct {
+	mock.Mock
+}
+
+func (m *MockExportJobRepository) Create(ctx context.Context, job *models.ExportJob) error {
+	args := m.Called(job)
+	if job.ID == uuid.Nil {
+		job.ID = uuid.New()
+	}
+	return args.Error(0)
+}
+
+func (m *MockExportJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ExportJob, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ExportJob), args.Error(1)
+}
+
+func (m *MockExportJobRepository) GetArtifact(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	args := m.Called(id)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockExportJobRepository) Update(ctx context.Context, job *models.ExportJob) error {
+	args := m.Called(job)
+	return args.Error(0)
+}
+
+func (m *MockExportJobRepository) UnfinishedIDs(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called()
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func exportTestNotes() (*models.Folder, []models.Note) {
+	folder := &models.Folder{Name: "Project Plans"}
+	notes := []models.Note{
+		{Title: "Kick-off", Body: "Agenda and owners"},
+		{Title: "Q3 / Roadmap", Body: "Ship exports — with résumé support"},
+	}
+	return folder, notes
+}
+
+func TestRenderMarkdownZip(t *testing.T) {
+	folder, notes := exportTestNotes()
+
+	loc, err := time.LoadLocation("Asia/Ho_Chi_Minh")
+	require.NoError(t, err)
+	exportedAt := time.Date(2024, 3, 1, 2, 30, 0, 0, time.UTC).In(loc)
+
+	data, err := renderMarkdownZip(folder, notes, exportedAt)
+	require.NoError(t, err)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		require.NoError(t, err)
+		files[f.Name] = string(content)
+	}
+
+	assert.Len(t, files, 3)
+	assert.Equal(t, "# Kick-off\n\nAgenda and owners\n", files["001-Kick-off.md"])
+	assert.Contains(t, files, "002-Q3-Roadmap.md")
+	assert.Contains(t, files["index.md"], "# Project Plans")
+	assert.Contains(t, files["index.md"], "2. [Q3 / Roadmap](002-Q3-Roadmap.md)")
+	assert.Contains(t, files["index.md"], "_Exported 2024-03-01 09:30 +07_")
+}
+
+func TestRenderPDF(t *testing.T) {
+	folder, notes := exportTestNotes()
+
+	data, err := renderPDF(folder, notes, time.Now())
+	require.NoError(t, err)
+
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF-")))
+}
+
+func TestParseExportFormat(t *testing.T) {
+	format, err := ParseExportFormat("pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, models.ExportFormatPDF, format)
+
+	_, err = ParseExportFormat("docx")
+	assert.Error(t, err)
+}
+
+func TestExportService_ResumesUnfinishedJobs(t *testing.T) {
+	ownerID := uuid.New()
+	folder, notes := exportTestNotes()
+	folder.ID = uuid.New()
+	running := &models.ExportJob{ID: uuid.New(), OwnerID: ownerID, FolderID: folder.ID, Format: models.ExportFormatZip, Status: models.ExportStatusRunning}
+	finished := &models.ExportJob{ID: uuid.New(), OwnerID: ownerID, FolderID: folder.ID, Format: models.ExportFormatZip, Status: models.ExportStatusCompleted}
+	pending := &models.ExportJob{ID: uuid.New(), OwnerID: ownerID, FolderID: folder.ID, Format: models.ExportFormatPDF, Status: models.ExportStatusPending}
+
+	jobRepo, folderRepo, noteRepo := new(MockExportJobRepository), new(MockFolderRepository), new(MockNoteRepository)
+	jobRepo.On("UnfinishedIDs").Return([]uuid.UUID{running.ID, finished.ID, pending.ID}, nil)
+	for _, job := range []*models.ExportJob{running, finished, pending} {
+		jobRepo.On("GetByID", job.ID).Return(job, nil)
+	}
+	done := make(chan struct{})
+	jobRepo.On("Update", mock.AnythingOfType("*models.ExportJob")).Return(nil).Run(func(args mock.Arguments) {
+		if job := args.Get(0).(*models.ExportJob); job == pending && job.Status == models.ExportStatusCompleted {
+			close(done)
+		}
+	})
+	folderRepo.On("GetByID", folder.ID).Return(folder, nil)
+	folderRepo.On("HasAccess", folder.ID, ownerID).Return(true, models.AccessWrite, nil)
+	noteRepo.On("GetByFolder", folder.ID).Return(notes, nil)
+	// A queue of one holds the rest back until the worker makes room
+	service := NewExportService(jobRepo, folderRepo, noteRepo, nil, nil, 1, 1)
+
+	service.Start()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the pending job was not rendered")
+	}
+	require.NoError(t, service.Shutdown(context.Background()))
+
+	assert.Equal(t, models.ExportStatusCompleted, running.Status)
+	assert.Equal(t, "application/zip", running.ContentType)
+	assert.Equal(t, "application/pdf", pending.ContentType)
+	// Finished elsewhere after it was listed, so it is left alone
+	folderRepo.AssertNumberOfCalls(t, "GetByID", 2)
+}
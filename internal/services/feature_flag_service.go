@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/featureflags"
+)
+
+// featureFlagName is the form of a flag name, which also keys it in the
+// config file and FEATURE_FLAGS
+var featureFlagName = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// FeatureFlagService lets admins manage the flags stored in the database and
+// tells callers which features are on for them. Stored flags override those
+// of the config file.
+type FeatureFlagService struct {
+	flagRepo repositories.FeatureFlagRepositoryInterface
+	flags    *featureflags.Client
+	audit    audit.Recorder
+}
+
+// NewFeatureFlagService creates a feature flag service. flags is invalidated
+// after every change so this instance sees it at once; auditor may be nil.
+func NewFeatureFlagService(flagRepo repositories.FeatureFlagRepositoryInterface, flags *featureflags.Client, auditor audit.Recorder) *FeatureFlagService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	return &FeatureFlagService{
+		flagRepo: flagRepo,
+		flags:    flags,
+		audit:    auditor,
+	}
+}
+
+type FeatureFlagInput struct {
+	Description string      `json:"description" binding:"max=500"`
+	Enabled     bool        `json:"enabled"`
+	Users       []uuid.UUID `json:"users"`
+	Teams       []uuid.UUID `json:"teams"`
+	Percentage  int         `json:"percentage" binding:"min=0,max=100"`
+}
+
+func (s *FeatureFlagService) ListFlags(ctx context.Context) ([]models.FeatureFlag, error) {
+	return s.flagRepo.List(ctx)
+}
+
+// SaveFlag creates or replaces the named flag
+func (s *FeatureFlagService) SaveFlag(ctx context.Context, name string, input *FeatureFlagInput, actorID uuid.UUID) (*models.FeatureFlag, error) {
+	if !featureFlagName.MatchString(name) {
+		return nil, apperrors.Validation("invalid feature flag name %q: use up to 64 lowercase letters, digits, '.', '_' or '-'", name)
+	}
+	if input.Percentage < 0 || input.Percentage > 100 {
+		return nil, apperrors.Validation("percentage must be between 0 and 100")
+	}
+
+	flag := &models.FeatureFlag{
+		Name:        name,
+		Description: input.Description,
+		Enabled:     input.Enabled,
+		Users:       input.Users,
+		Teams:       input.Teams,
+		Percentage:  input.Percentage,
+		UpdatedAt:   time.Now(),
+		UpdatedBy:   &actorID,
+	}
+	if flag.Users == nil {
+		flag.Users = []uuid.UUID{}
+	}
+	if flag.Teams == nil {
+		flag.Teams = []uuid.UUID{}
+	}
+	if err := s.flagRepo.Save(ctx, flag); err != nil {
+		return nil, fmt.Errorf("failed to save feature flag: %w", err)
+	}
+	s.invalidate()
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetFeatureFlag,
+		Details: map[string]string{
+			"name":       name,
+			"enabled":    strconv.FormatBool(flag.Enabled),
+			"users":      strconv.Itoa(len(flag.Users)),
+			"teams":      strconv.Itoa(len(flag.Teams)),
+			"percentage": strconv.Itoa(flag.Percentage),
+		},
+	})
+	return flag, nil
+}
+
+// DeleteFlag removes the named flag, so the config file decides it again
+func (s *FeatureFlagService) DeleteFlag(ctx context.Context, name string, actorID uuid.UUID) error {
+	if err := s.flagRepo.Delete(ctx, name); err != nil {
+		return err
+	}
+	s.invalidate()
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetFeatureFlag,
+		Details:    map[string]string{"name": name},
+	})
+	return nil
+}
+
+// EnabledFeatures returns the names of the flags that are on for subject
+func (s *FeatureFlagService) EnabledFeatures(ctx context.Context, subject featureflags.Subject) []string {
+	if s.flags == nil {
+		return []string{}
+	}
+	return s.flags.Evaluate(ctx, subject)
+}
+
+func (s *FeatureFlagService) invalidate() {
+	if s.flags != nil {
+		s.flags.Invalidate()
+	}
+}
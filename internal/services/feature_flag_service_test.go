@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/pkg/featureflags"
+)
+
+// MockFeatureFlagRepository is a mock implementation of
+// FeatureFlagRepositoryInterface. Saved flags are kept so it can serve them
+// as a featureflags.Source.
+type MockFeatureFlagRepository struct {
+	mock.Mock
+	saved map[string]featureflags.Flag
+}
+
+func (m *MockFeatureFlagRepository) List(ctx context.Context) ([]models.FeatureFlag, error) {
+	args := m.Called()
+	return args.Get(0).([]models.FeatureFlag), args.Error(1)
+}
+
+func (m *MockFeatureFlagRepository) Save(ctx context.Context, flag *models.FeatureFlag) error {
+	args := m.Called(flag)
+	if args.Error(0) == nil {
+		if m.saved == nil {
+			m.saved = make(map[string]featureflags.Flag)
+		}
+		m.saved[flag.Name] = featureflags.Flag{Name: flag.Name, Enabled: flag.Enabled, Users: flag.Users, Teams: flag.Teams, Percentage: flag.Percentage}
+	}
+	return args.Error(0)
+}
+
+func (m *MockFeatureFlagRepository) Delete(ctx context.Context, name string) error {
+	args := m.Called(name)
+	if args.Error(0) == nil {
+		delete(m.saved, name)
+	}
+	return args.Error(0)
+}
+
+func (m *MockFeatureFlagRepository) Flags(ctx context.Context) (map[string]featureflags.Flag, error) {
+	return m.saved, nil
+}
+
+func TestFeatureFlagService_SaveFlag(t *testing.T) {
+	ctx := context.Background()
+	adminID := uuid.New()
+	userID := uuid.New()
+	config := map[string]bool{"public-links": true}
+
+	mockRepo := new(MockFeatureFlagRepository)
+	mockRepo.On("Save", mock.AnythingOfType("*models.FeatureFlag")).Return(nil)
+	mockRepo.On("Delete", "public-links").Return(nil)
+	mockRepo.On("Delete", "unknown").Return(apperrors.NotFound("feature flag %q not found", "unknown"))
+	recorder := new(MockAuditRecorder)
+	recorder.On("Record", mock.AnythingOfType("audit.Entry")).Return()
+
+	client := featureflags.NewClient(time.Hour, nil, featureflags.Static(func() map[string]bool { return config }), mockRepo)
+	service := NewFeatureFlagService(mockRepo, client, recorder)
+	user := featureflags.Subject{UserID: userID}
+
+	t.Run("rejects invalid names and percentages", func(t *testing.T) {
+		for _, name := range []string{"", "Public-Links", "-links", "public links"} {
+			_, err := service.SaveFlag(ctx, name, &FeatureFlagInput{}, adminID)
+			assert.ErrorIs(t, err, apperrors.ErrValidation, name)
+		}
+		_, err := service.SaveFlag(ctx, "public-links", &FeatureFlagInput{Percentage: 101}, adminID)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("a stored flag overrides the config at once", func(t *testing.T) {
+		assert.Equal(t, []string{"public-links"}, service.EnabledFeatures(ctx, user))
+
+		flag, err := service.SaveFlag(ctx, "public-links", &FeatureFlagInput{Teams: []uuid.UUID{uuid.New()}}, adminID)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{}, flag.Users)
+		assert.Equal(t, &adminID, flag.UpdatedBy)
+		assert.Empty(t, service.EnabledFeatures(ctx, user))
+		recorder.AssertCalled(t, "Record", mock.MatchedBy(func(entry audit.Entry) bool {
+			return entry.Action == audit.ActionUpdate && entry.TargetType == audit.TargetFeatureFlag && entry.Details["name"] == "public-links"
+		}))
+	})
+
+	t.Run("deleting hands the flag back to the config", func(t *testing.T) {
+		require.NoError(t, service.DeleteFlag(ctx, "public-links", adminID))
+		assert.Equal(t, []string{"public-links"}, service.EnabledFeatures(ctx, user))
+
+		err := service.DeleteFlag(ctx, "unknown", adminID)
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	})
+}
+
+func TestFeatureFlag_On(t *testing.T) {
+	userID, teamID := uuid.New(), uuid.New()
+
+	assert.True(t, featureflags.Flag{Enabled: true}.On(featureflags.Subject{}))
+	assert.False(t, featureflags.Flag{Users: []uuid.UUID{uuid.Nil}, Percentage: 100}.On(featureflags.Subject{}))
+	assert.True(t, featureflags.Flag{Users: []uuid.UUID{userID}}.On(featureflags.Subject{UserID: userID}))
+	assert.True(t, featureflags.Flag{Teams: []uuid.UUID{teamID}}.On(featureflags.Subject{UserID: userID, Teams: []uuid.UUID{teamID}}))
+	assert.False(t, featureflags.Flag{Teams: []uuid.UUID{teamID}}.On(featureflags.Subject{UserID: userID}))
+
+	// Percentage rollout is stable and grows monotonically
+	on := func(percentage int) map[int]bool {
+		users := make(map[int]bool)
+		for i := 0; i < 1000; i++ {
+			subject := featureflags.Subject{UserID: uuid.NewSHA1(uuid.Nil, []byte(fmt.Sprint(i)))}
+			users[i] = featureflags.Flag{Name: "rollout", Percentage: percentage}.On(subject)
+		}
+		return users
+	}
+	ten, fifty := on(10), on(50)
+	count := 0
+	for i, enabled := range ten {
+		if enabled {
+			count++
+			assert.True(t, fifty[i], "user %d lost the feature as the rollout grew", i)
+		}
+	}
+	assert.InDelta(t, 100, count, 40)
+	assert.Equal(t, ten, on(10))
+}
+
+func TestFeatureFlagClient_KeepsFlagsOfFailingSource(t *testing.T) {
+	ctx := context.Background()
+	fail := false
+	source := featureflags.SourceFunc(func(context.Context) (map[string]featureflags.Flag, error) {
+		if fail {
+			return nil, errors.New("database unavailable")
+		}
+		return map[string]featureflags.Flag{"beta": {Name: "beta", Enabled: true}}, nil
+	})
+	client := featureflags.NewClient(time.Hour, nil, source)
+
+	assert.True(t, client.EnabledFor(ctx, "beta", featureflags.Subject{}))
+	fail = true
+	client.Invalidate()
+	assert.True(t, client.EnabledFor(ctx, "beta", featureflags.Subject{}))
+	assert.False(t, client.EnabledFor(ctx, "unknown", featureflags.Subject{}))
+}
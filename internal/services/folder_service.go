@@ -1,148 +1,489 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/database"
 	"seta-training/internal/models"
 	"seta-training/internal/repositories"
+	"seta-training/pkg/metrics"
+	"seta-training/pkg/pagination"
 )
 
 type FolderService struct {
 	folderRepo repositories.FolderRepositoryInterface
 	noteRepo   repositories.NoteRepositoryInterface
+	teamRepo   repositories.TeamRepositoryInterface
+	tx         repositories.TransactionManager
+	quota      QuotaChecker
+	sanitizer  *NoteSanitizer
+	audit      audit.Recorder
+	metrics    *metrics.Metrics
 }
 
-func NewFolderService(folderRepo repositories.FolderRepositoryInterface, noteRepo repositories.NoteRepositoryInterface) *FolderService {
+// NewFolderService creates a folder service. teamRepo may be nil to ignore
+// team sharing policies, txManager may be nil to write through the given
+// repositories without a transaction, quota may be nil to disable quotas,
+// auditor may be nil to disable audit logging and m may be nil to use a
+// private registry.
+func NewFolderService(folderRepo repositories.FolderRepositoryInterface, noteRepo repositories.NoteRepositoryInterface, teamRepo repositories.TeamRepositoryInterface, txManager repositories.TransactionManager, quota QuotaChecker, auditor audit.Recorder, m *metrics.Metrics) *FolderService {
+	if txManager == nil {
+		txManager = repositories.NoTx{Stores: repositories.Stores{Folders: folderRepo, Notes: noteRepo}}
+	}
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
 	return &FolderService{
 		folderRepo: folderRepo,
 		noteRepo:   noteRepo,
+		teamRepo:   teamRepo,
+		tx:         txManager,
+		quota:      quota,
+		sanitizer:  NewNoteSanitizer(),
+		audit:      auditor,
+		metrics:    m,
 	}
 }
 
 type CreateFolderInput struct {
-	Name string `json:"name" binding:"required,min=1,max=100"`
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Color       string `json:"color,omitempty" binding:"max=7"`
+	Icon        string `json:"icon,omitempty" binding:"max=32"`
+	Description string `json:"description,omitempty" binding:"max=500"`
 }
 
+// UpdateFolderInput replaces a folder's name. Color, Icon and Description
+// are kept when nil and cleared when empty.
 type UpdateFolderInput struct {
-	Name string `json:"name" binding:"required,min=1,max=100"`
+	Name        string  `json:"name" binding:"required,min=1,max=100"`
+	Color       *string `json:"color,omitempty" binding:"omitempty,max=7"`
+	Icon        *string `json:"icon,omitempty" binding:"omitempty,max=32"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=500"`
+}
+
+var (
+	folderColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+	folderIconPattern  = regexp.MustCompile(`^[a-z0-9-]{1,32}$`)
+)
+
+// folderMetadata normalizes the optional color, icon and description of a
+// folder. Empty values are allowed and clear the field.
+func (s *FolderService) folderMetadata(color, icon, description string) (string, string, string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	icon = strings.TrimSpace(icon)
+	description = strings.TrimSpace(s.sanitizer.Text(description))
+
+	fields := map[string]string{}
+	if color != "" && !folderColorPattern.MatchString(color) {
+		fields["color"] = "must be a hex color such as #3b82f6"
+	}
+	if icon != "" && !folderIconPattern.MatchString(icon) {
+		fields["icon"] = "must be up to 32 lowercase letters, digits or '-'"
+	}
+	if len(fields) > 0 {
+		return "", "", "", apperrors.ValidationFields("Invalid folder", fields)
+	}
+	return color, icon, description, nil
 }
 
+// ShareFolderInput shares a folder with one user, given by UserID and
+// Access, or with several at once, given as Shares
 type ShareFolderInput struct {
-	UserID uuid.UUID           `json:"userId" binding:"required"`
-	Access models.AccessLevel  `json:"access" binding:"required,oneof=read write"`
+	UserID uuid.UUID           `json:"userId"`
+	Access models.AccessLevel  `json:"access" binding:"omitempty,oneof=read write"`
+	Shares []ShareEntry        `json:"shares" binding:"omitempty,max=100,dive"`
 }
 
-func (s *FolderService) CreateFolder(input *CreateFolderInput, ownerID uuid.UUID) (*models.Folder, error) {
+func (s *FolderService) CreateFolder(ctx context.Context, input *CreateFolderInput, ownerID uuid.UUID) (*models.Folder, error) {
+	color, icon, description, err := s.folderMetadata(input.Color, input.Icon, input.Description)
+	if err != nil {
+		return nil, err
+	}
+	if s.quota != nil {
+		if err := s.quota.CheckFolder(ctx, ownerID); err != nil {
+			return nil, err
+		}
+	}
+
 	folder := &models.Folder{
-		Name:    input.Name,
-		OwnerID: ownerID,
+		Name:        input.Name,
+		Color:       color,
+		Icon:        icon,
+		Description: description,
+		OwnerID:     ownerID,
 	}
 
-	if err := s.folderRepo.Create(folder); err != nil {
+	actorCtx := database.WithActor(ctx, ownerID)
+	err = s.tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		return stores.Folders.Create(actorCtx, folder)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create folder: %w", err)
 	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    ownerID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetFolder,
+		TargetID:   folder.ID,
+		Details:    map[string]string{"name": folder.Name},
+	})
 
-	return s.folderRepo.GetByID(folder.ID)
+	return s.folderRepo.GetByID(ctx, folder.ID)
 }
 
-func (s *FolderService) GetFolder(folderID, userID uuid.UUID) (*models.Folder, error) {
+func (s *FolderService) GetFolder(ctx context.Context, folderID, userID uuid.UUID) (*models.Folder, error) {
 	// Check if user has access to the folder
-	hasAccess, _, err := s.folderRepo.HasAccess(folderID, userID)
+	hasAccess, _, err := s.folderRepo.HasAccess(ctx, folderID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check access: %w", err)
 	}
 	if !hasAccess {
-		return nil, errors.New("access denied")
+		return nil, apperrors.Forbidden("access denied")
 	}
 
-	return s.folderRepo.GetByID(folderID)
+	folder, err := s.folderRepo.GetByID(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	s.sanitizer.Notes(folder.Notes)
+	return folder, nil
 }
 
-func (s *FolderService) UpdateFolder(folderID uuid.UUID, input *UpdateFolderInput, userID uuid.UUID) (*models.Folder, error) {
+func (s *FolderService) UpdateFolder(ctx context.Context, folderID uuid.UUID, input *UpdateFolderInput, userID uuid.UUID) (*models.Folder, error) {
 	// Check if user has write access
-	hasAccess, access, err := s.folderRepo.HasAccess(folderID, userID)
+	hasAccess, access, err := s.folderRepo.HasAccess(ctx, folderID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check access: %w", err)
 	}
 	if !hasAccess || access != models.AccessWrite {
-		return nil, errors.New("write access required")
+		return nil, apperrors.Forbidden("write access required")
 	}
 
-	folder, err := s.folderRepo.GetByID(folderID)
+	folder, err := s.folderRepo.GetByID(ctx, folderID)
 	if err != nil {
 		return nil, err
 	}
 
+	color, icon, description := folder.Color, folder.Icon, folder.Description
+	if input.Color != nil {
+		color = *input.Color
+	}
+	if input.Icon != nil {
+		icon = *input.Icon
+	}
+	if input.Description != nil {
+		description = *input.Description
+	}
+	color, icon, description, err = s.folderMetadata(color, icon, description)
+	if err != nil {
+		return nil, err
+	}
 	folder.Name = input.Name
-	if err := s.folderRepo.Update(folder); err != nil {
+	folder.Color, folder.Icon, folder.Description = color, icon, description
+	actorCtx := database.WithActor(ctx, userID)
+	err = s.tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		return stores.Folders.Update(actorCtx, folder)
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to update folder: %w", err)
 	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetFolder,
+		TargetID:   folder.ID,
+		Details:    map[string]string{"name": folder.Name},
+	})
 
 	return folder, nil
 }
 
-func (s *FolderService) DeleteFolder(folderID, userID uuid.UUID) error {
+func (s *FolderService) DeleteFolder(ctx context.Context, folderID, userID uuid.UUID) error {
 	// Only owner can delete folder
-	folder, err := s.folderRepo.GetByID(folderID)
+	folder, err := s.folderRepo.GetByID(ctx, folderID)
 	if err != nil {
 		return err
 	}
 	if folder.OwnerID != userID {
-		return errors.New("only owner can delete folder")
+		return apperrors.Forbidden("only owner can delete folder")
 	}
 
-	// Delete all notes in the folder first
-	notes, err := s.noteRepo.GetByFolder(folderID)
+	// Delete the folder with its notes and shares in bulk rather than one
+	// note at a time
+	var notesDeleted int64
+	actorCtx := database.WithActor(ctx, userID)
+	err = s.tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		notesDeleted, err = stores.Folders.DeleteWithContents(actorCtx, folderID)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get notes: %w", err)
+		return fmt.Errorf("failed to delete folder: %w", err)
 	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetFolder,
+		TargetID:   folderID,
+		Details:    map[string]string{"notes_deleted": strconv.FormatInt(notesDeleted, 10)},
+	})
+	return nil
+}
 
-	for _, note := range notes {
-		if err := s.noteRepo.Delete(note.ID); err != nil {
-			return fmt.Errorf("failed to delete note: %w", err)
+// DuplicateFolderInput names the copy of a folder, "<name> (copy)" by
+// default. IncludeShares copies the shares of the folder and its notes too,
+// which only the owner may ask for.
+type DuplicateFolderInput struct {
+	Name          string `json:"name" binding:"max=100"`
+	IncludeShares bool   `json:"include_shares"`
+}
+
+// DuplicateFolder copies a folder the user can read, with all its notes, into
+// a new folder the user owns. progress, which may be nil, is called as the
+// notes are copied.
+func (s *FolderService) DuplicateFolder(ctx context.Context, folderID uuid.UUID, input *DuplicateFolderInput, userID uuid.UUID, progress func(copied, total int)) (*models.Folder, error) {
+	hasAccess, _, err := s.folderRepo.HasAccess(ctx, folderID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+	if !hasAccess {
+		return nil, apperrors.Forbidden("access denied")
+	}
+	source, err := s.folderRepo.GetByID(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+	if input.IncludeShares && source.OwnerID != userID {
+		return nil, apperrors.Forbidden("only owner can copy folder shares")
+	}
+	if s.quota != nil {
+		if err := s.quota.CheckFolder(ctx, userID); err != nil {
+			return nil, err
 		}
+		if len(source.Notes) > 0 {
+			if err := s.quota.CheckNote(ctx, userID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		name = source.Name + " (copy)"
+	}
+	folder := &models.Folder{
+		Name:        name,
+		Color:       source.Color,
+		Icon:        source.Icon,
+		Description: source.Description,
+		OwnerID:     userID,
+	}
+
+	var copied int
+	actorCtx := database.WithActor(ctx, userID)
+	err = s.tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		copied, err = stores.Folders.Duplicate(actorCtx, folderID, folder, input.IncludeShares, progress)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to duplicate folder: %w", err)
 	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetFolder,
+		TargetID:   folder.ID,
+		Details: map[string]string{
+			"name":            folder.Name,
+			"duplicated_from": folderID.String(),
+			"notes_copied":    strconv.Itoa(copied),
+		},
+	})
 
-	return s.folderRepo.Delete(folderID)
+	return s.folderRepo.GetByID(ctx, folder.ID)
 }
 
-func (s *FolderService) ShareFolder(folderID uuid.UUID, input *ShareFolderInput, ownerID uuid.UUID) error {
-	// Only owner can share folder
-	folder, err := s.folderRepo.GetByID(folderID)
+// MergeFolderInput names the folder to merge into another
+type MergeFolderInput struct {
+	SourceID uuid.UUID `json:"source_id" binding:"required"`
+}
+
+// MergeFolder moves every note of the source folder into the target and
+// trashes the source. Notes whose titles are already taken in the target are
+// renamed. Only the owner of both folders may merge them.
+func (s *FolderService) MergeFolder(ctx context.Context, targetID uuid.UUID, input *MergeFolderInput, userID uuid.UUID) (*models.Folder, error) {
+	if input.SourceID == targetID {
+		return nil, apperrors.Validation("a folder cannot be merged into itself")
+	}
+	target, err := s.folderRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	source, err := s.folderRepo.GetByID(ctx, input.SourceID)
 	if err != nil {
+		return nil, err
+	}
+	if target.OwnerID != userID || source.OwnerID != userID {
+		return nil, apperrors.Forbidden("only owner can merge folders")
+	}
+
+	var moved int64
+	actorCtx := database.WithActor(ctx, userID)
+	err = s.tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		moved, err = stores.Folders.Merge(actorCtx, source.ID, target.ID, mergedTitles(target.Notes, source.Notes))
 		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge folders: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetFolder,
+		TargetID:   target.ID,
+		Details:    map[string]string{"merged_from": source.ID.String(), "notes_moved": strconv.FormatInt(moved, 10)},
+	})
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetFolder,
+		TargetID:   source.ID,
+		Details:    map[string]string{"merged_into": target.ID.String()},
+	})
+
+	return s.folderRepo.GetByID(ctx, target.ID)
+}
+
+// mergedTitles renames the notes of source whose titles, ignoring case, are
+// taken in target or by an older note of source to "<title> (2)",
+// "<title> (3)" and so on. It returns the new titles by note ID.
+func mergedTitles(target, source []models.Note) map[uuid.UUID]string {
+	taken := make(map[string]bool, len(target)+len(source))
+	for _, note := range target {
+		taken[strings.ToLower(note.Title)] = true
+	}
+
+	source = slices.Clone(source)
+	sort.SliceStable(source, func(i, j int) bool {
+		return source[i].CreatedAt.Before(source[j].CreatedAt)
+	})
+	titles := make(map[uuid.UUID]string)
+	for _, note := range source {
+		title := note.Title
+		for n := 2; taken[strings.ToLower(title)]; n++ {
+			title = fmt.Sprintf("%s (%d)", note.Title, n)
+		}
+		taken[strings.ToLower(title)] = true
+		if title != note.Title {
+			titles[note.ID] = title
+		}
+	}
+	return titles
+}
+
+// ShareFolder shares a folder with every user the input names, all or none
+func (s *FolderService) ShareFolder(ctx context.Context, folderID uuid.UUID, input *ShareFolderInput, ownerID uuid.UUID) ([]ShareResult, error) {
+	entries, err := shareEntries(input.UserID, input.Access, input.Shares)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only owner can share folder
+	folder, err := s.folderRepo.GetByID(ctx, folderID)
+	if err != nil {
+		return nil, err
 	}
 	if folder.OwnerID != ownerID {
-		return errors.New("only owner can share folder")
+		return nil, apperrors.Forbidden("only owner can share folder")
+	}
+
+	policy, err := sharingPolicyOf(ctx, s.teamRepo, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err = withDefaultAccess(entries, len(input.Shares) > 0, policy.defaultAccess)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.folderRepo.ShareFolder(folderID, input.UserID, input.Access)
+	results, err := shareAll(ctx, s.tx, ownerID, entries, len(input.Shares) > 0, func(ctx context.Context, stores repositories.Stores, entry ShareEntry) error {
+		if err := policy.check(ctx, entry.UserID); err != nil {
+			return err
+		}
+		return stores.Folders.ShareFolder(ctx, folderID, entry.UserID, entry.Access)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		s.metrics.RecordShareGranted("folder", string(entry.Access))
+		s.audit.Record(ctx, audit.Entry{
+			ActorID:    ownerID,
+			Action:     audit.ActionShare,
+			TargetType: audit.TargetFolder,
+			TargetID:   folderID,
+			Details:    shareDetails(entry.UserID, entry.Access),
+		})
+	}
+	return results, nil
 }
 
-func (s *FolderService) RevokeShare(folderID, targetUserID, ownerID uuid.UUID) error {
+func (s *FolderService) RevokeShare(ctx context.Context, folderID, targetUserID, ownerID uuid.UUID) error {
 	// Only owner can revoke sharing
-	folder, err := s.folderRepo.GetByID(folderID)
+	folder, err := s.folderRepo.GetByID(ctx, folderID)
 	if err != nil {
 		return err
 	}
 	if folder.OwnerID != ownerID {
-		return errors.New("only owner can revoke sharing")
+		return apperrors.Forbidden("only owner can revoke sharing")
 	}
 
-	return s.folderRepo.RevokeShare(folderID, targetUserID)
+	if err := s.folderRepo.RevokeShare(ctx, folderID, targetUserID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    ownerID,
+		Action:     audit.ActionRevokeShare,
+		TargetType: audit.TargetFolder,
+		TargetID:   folderID,
+		Details:    map[string]string{"user_id": targetUserID.String()},
+	})
+	return nil
+}
+
+// ListShares returns one page of the users a folder is shared with. Only the
+// owner may list them.
+func (s *FolderService) ListShares(ctx context.Context, folderID, ownerID uuid.UUID, p pagination.Params) (pagination.Page[models.FolderShare], error) {
+	folder, err := s.folderRepo.GetByID(ctx, folderID)
+	if err != nil {
+		return pagination.Page[models.FolderShare]{}, err
+	}
+	if folder.OwnerID != ownerID {
+		return pagination.Page[models.FolderShare]{}, apperrors.Forbidden("only owner can list shares")
+	}
+	return s.folderRepo.ListShares(ctx, folderID, p)
 }
 
-func (s *FolderService) GetUserFolders(userID uuid.UUID) ([]models.Folder, error) {
+func (s *FolderService) GetUserFolders(ctx context.Context, userID uuid.UUID) ([]models.Folder, error) {
 	// Get owned folders
-	ownedFolders, err := s.folderRepo.GetByOwner(userID)
+	ownedFolders, err := s.folderRepo.GetByOwner(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get owned folders: %w", err)
 	}
 
 	// Get shared folders
-	sharedFolders, err := s.folderRepo.GetSharedFolders(userID)
+	sharedFolders, err := s.folderRepo.GetSharedFolders(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get shared folders: %w", err)
 	}
@@ -151,3 +492,26 @@ func (s *FolderService) GetUserFolders(userID uuid.UUID) ([]models.Folder, error
 	allFolders := append(ownedFolders, sharedFolders...)
 	return allFolders, nil
 }
+
+// GetFoldersByUsers returns the folders each of userIDs owns or has been
+// shared, keyed by user. It runs the same queries however many users are
+// asked for.
+func (s *FolderService) GetFoldersByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]models.Folder, error) {
+	owned, err := s.folderRepo.GetFoldersByOwners(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owned folders: %w", err)
+	}
+	shares, err := s.folderRepo.GetSharesByUsers(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shared folders: %w", err)
+	}
+
+	byUser := make(map[uuid.UUID][]models.Folder, len(userIDs))
+	for _, folder := range owned {
+		byUser[folder.OwnerID] = append(byUser[folder.OwnerID], folder)
+	}
+	for _, share := range shares {
+		byUser[share.UserID] = append(byUser[share.UserID], share.Folder)
+	}
+	return byUser, nil
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/pkg/pagination"
+)
+
+func TestFolderService_DeleteFolder_DeletesContentsInBulk(t *testing.T) {
+	// Setup
+	folderRepo := new(MockFolderRepository)
+	noteRepo := new(MockNoteRepository)
+	recorder := new(MockAuditRecorder)
+	service := NewFolderService(folderRepo, noteRepo, nil, nil, nil, recorder, nil)
+
+	folderID := uuid.New()
+	ownerID := uuid.New()
+	folderRepo.On("GetByID", folderID).Return(&models.Folder{ID: folderID, OwnerID: ownerID}, nil)
+	folderRepo.On("DeleteWithContents", folderID).Return(int64(1000), nil)
+	recorder.On("Record", audit.Entry{
+		ActorID:    ownerID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetFolder,
+		TargetID:   folderID,
+		Details:    map[string]string{"notes_deleted": "1000"},
+	}).Once()
+
+	// Test
+	err := service.DeleteFolder(context.Background(), folderID, ownerID)
+
+	// Assert
+	assert.NoError(t, err)
+	folderRepo.AssertExpectations(t)
+	recorder.AssertExpectations(t)
+	noteRepo.AssertNotCalled(t, "Delete", mock.Anything)
+}
+
+func TestFolderService_DeleteFolder_NotOwner(t *testing.T) {
+	// Setup
+	folderRepo := new(MockFolderRepository)
+	service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, nil, nil)
+
+	folderID := uuid.New()
+	folderRepo.On("GetByID", folderID).Return(&models.Folder{ID: folderID, OwnerID: uuid.New()}, nil)
+
+	// Test
+	err := service.DeleteFolder(context.Background(), folderID, uuid.New())
+
+	// Assert
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	folderRepo.AssertNotCalled(t, "DeleteWithContents", mock.Anything)
+}
+
+func TestFolderService_ListShares_NotOwner(t *testing.T) {
+	// Setup
+	folderRepo := new(MockFolderRepository)
+	service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, nil, nil)
+
+	folderID := uuid.New()
+	folderRepo.On("GetByID", folderID).Return(&models.Folder{ID: folderID, OwnerID: uuid.New()}, nil)
+
+	// Test
+	_, err := service.ListShares(context.Background(), folderID, uuid.New(), pagination.Params{})
+
+	// Assert
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	folderRepo.AssertNotCalled(t, "ListShares", mock.Anything, mock.Anything)
+}
+
+func TestFolderService_CreateFolder_Metadata(t *testing.T) {
+	// Setup
+	folderRepo := new(MockFolderRepository)
+	service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, nil, nil)
+
+	ownerID := uuid.New()
+	folderRepo.On("Create", mock.MatchedBy(func(f *models.Folder) bool {
+		return f.Color == "#3b82f6" && f.Icon == "briefcase" && f.Description == "Client work"
+	})).Return(nil)
+	folderRepo.On("GetByID", mock.Anything).Return(&models.Folder{}, nil)
+
+	// Test
+	_, err := service.CreateFolder(context.Background(), &CreateFolderInput{
+		Name:        "Projects",
+		Color:       " #3B82F6 ",
+		Icon:        "briefcase",
+		Description: "<b>Client work</b>",
+	}, ownerID)
+
+	// Assert
+	assert.NoError(t, err)
+	folderRepo.AssertExpectations(t)
+}
+
+func TestFolderService_CreateFolder_InvalidMetadata(t *testing.T) {
+	// Setup
+	folderRepo := new(MockFolderRepository)
+	service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, nil, nil)
+
+	// Test
+	_, err := service.CreateFolder(context.Background(), &CreateFolderInput{
+		Name:  "Projects",
+		Color: "blue",
+		Icon:  "Brief Case",
+	}, uuid.New())
+
+	// Assert
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+	assert.Equal(t, map[string]string{
+		"color": "must be a hex color such as #3b82f6",
+		"icon":  "must be up to 32 lowercase letters, digits or '-'",
+	}, apperrors.From(err).Fields)
+	folderRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestFolderService_UpdateFolder_KeepsOrClearsMetadata(t *testing.T) {
+	// Setup
+	folderRepo := new(MockFolderRepository)
+	service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, nil, nil)
+
+	folderID := uuid.New()
+	userID := uuid.New()
+	folderRepo.On("HasAccess", folderID, userID).Return(true, models.AccessWrite, nil)
+	folderRepo.On("GetByID", folderID).Return(&models.Folder{
+		ID:          folderID,
+		OwnerID:     userID,
+		Color:       "#3b82f6",
+		Icon:        "briefcase",
+		Description: "Client work",
+	}, nil)
+	folderRepo.On("Update", mock.Anything).Return(nil)
+
+	// Test
+	empty := ""
+	folder, err := service.UpdateFolder(context.Background(), folderID, &UpdateFolderInput{Name: "Renamed", Icon: &empty}, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Renamed", folder.Name)
+	assert.Equal(t, "#3b82f6", folder.Color)
+	assert.Equal(t, "", folder.Icon)
+	assert.Equal(t, "Client work", folder.Description)
+}
+
+func TestFolderService_DuplicateFolder(t *testing.T) {
+	// Setup
+	folderRepo := new(MockFolderRepository)
+	recorder := new(MockAuditRecorder)
+	service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, recorder, nil)
+
+	folderID := uuid.New()
+	userID := uuid.New()
+	folderRepo.On("HasAccess", folderID, userID).Return(true, models.AccessRead, nil)
+	folderRepo.On("GetByID", folderID).Return(&models.Folder{ID: folderID, OwnerID: uuid.New(), Name: "Projects", Color: "#3b82f6"}, nil).Once()
+	folderRepo.On("Duplicate", folderID, mock.MatchedBy(func(f *models.Folder) bool {
+		return f.Name == "Projects (copy)" && f.OwnerID == userID && f.Color == "#3b82f6"
+	}), false).Return(250, nil)
+	folderRepo.On("GetByID", mock.Anything).Return(&models.Folder{Name: "Projects (copy)"}, nil)
+	recorder.On("Record", mock.MatchedBy(func(e audit.Entry) bool {
+		return e.Action == audit.ActionCreate && e.Details["duplicated_from"] == folderID.String() && e.Details["notes_copied"] == "250"
+	})).Once()
+
+	// Test
+	var reported []int
+	folder, err := service.DuplicateFolder(context.Background(), folderID, &DuplicateFolderInput{}, userID, func(copied, total int) {
+		reported = append(reported, copied, total)
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Projects (copy)", folder.Name)
+	assert.Equal(t, []int{250, 250}, reported)
+	folderRepo.AssertExpectations(t)
+	recorder.AssertExpectations(t)
+}
+
+func TestFolderService_DuplicateFolder_SharesNeedOwner(t *testing.T) {
+	// Setup
+	folderRepo := new(MockFolderRepository)
+	service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, nil, nil)
+
+	folderID := uuid.New()
+	userID := uuid.New()
+	folderRepo.On("HasAccess", folderID, userID).Return(true, models.AccessWrite, nil)
+	folderRepo.On("GetByID", folderID).Return(&models.Folder{ID: folderID, OwnerID: uuid.New()}, nil)
+
+	// Test
+	_, err := service.DuplicateFolder(context.Background(), folderID, &DuplicateFolderInput{IncludeShares: true}, userID, nil)
+
+	// Assert
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	folderRepo.AssertNotCalled(t, "Duplicate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestFolderService_MergeFolder_RenamesCollidingTitles(t *testing.T) {
+	// Setup
+	folderRepo := new(MockFolderRepository)
+	recorder := new(MockAuditRecorder)
+	service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, recorder, nil)
+
+	ownerID := uuid.New()
+	targetID := uuid.New()
+	sourceID := uuid.New()
+	now := time.Now()
+	plan := models.Note{ID: uuid.New(), Title: "Plan", CreatedAt: now}
+	planAgain := models.Note{ID: uuid.New(), Title: "plan", CreatedAt: now.Add(time.Minute)}
+	ideas := models.Note{ID: uuid.New(), Title: "Ideas", CreatedAt: now}
+	folderRepo.On("GetByID", targetID).Return(&models.Folder{ID: targetID, OwnerID: ownerID, Notes: []models.Note{
+		{ID: uuid.New(), Title: "Plan"},
+		{ID: uuid.New(), Title: "Plan (2)"},
+	}}, nil)
+	folderRepo.On("GetByID", sourceID).Return(&models.Folder{ID: sourceID, OwnerID: ownerID, Notes: []models.Note{planAgain, ideas, plan}}, nil)
+	folderRepo.On("Merge", sourceID, targetID, map[uuid.UUID]string{
+		plan.ID:      "Plan (3)",
+		planAgain.ID: "plan (4)",
+	}).Return(int64(3), nil)
+	recorder.On("Record", mock.Anything).Twice()
+
+	// Test
+	_, err := service.MergeFolder(context.Background(), targetID, &MergeFolderInput{SourceID: sourceID}, ownerID)
+
+	// Assert
+	assert.NoError(t, err)
+	folderRepo.AssertExpectations(t)
+	recorder.AssertExpectations(t)
+}
+
+func TestFolderService_MergeFolder_Rejects(t *testing.T) {
+	ownerID := uuid.New()
+	targetID := uuid.New()
+	sourceID := uuid.New()
+
+	t.Run("the folder itself", func(t *testing.T) {
+		folderRepo := new(MockFolderRepository)
+		service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, nil, nil)
+
+		_, err := service.MergeFolder(context.Background(), targetID, &MergeFolderInput{SourceID: targetID}, ownerID)
+
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		folderRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("a source owned by someone else", func(t *testing.T) {
+		folderRepo := new(MockFolderRepository)
+		service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, nil, nil, nil)
+		folderRepo.On("GetByID", targetID).Return(&models.Folder{ID: targetID, OwnerID: ownerID}, nil)
+		folderRepo.On("GetByID", sourceID).Return(&models.Folder{ID: sourceID, OwnerID: uuid.New()}, nil)
+
+		_, err := service.MergeFolder(context.Background(), targetID, &MergeFolderInput{SourceID: sourceID}, ownerID)
+
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		folderRepo.AssertNotCalled(t, "Merge", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestFolderService_ShareFolder_TeamPolicy(t *testing.T) {
+	ownerID := uuid.New()
+	folderID := uuid.New()
+	teammate, outsider := uuid.New(), uuid.New()
+	team := models.Team{ID: uuid.New(), Settings: models.DefaultTeamSettings()}
+	team.Settings.AllowExternalSharing = false
+	team.Settings.DefaultAccess = models.AccessWrite
+
+	newService := func() (*FolderService, *MockFolderRepository) {
+		folderRepo := new(MockFolderRepository)
+		teamRepo := new(MockTeamRepository)
+		folderRepo.On("GetByID", folderID).Return(&models.Folder{ID: folderID, OwnerID: ownerID}, nil)
+		teamRepo.On("GetUserTeams", ownerID).Return([]models.Team{team}, nil)
+		teamRepo.On("GetUserTeamRoles", teammate).Return(map[uuid.UUID]models.TeamRole{team.ID: models.TeamRoleMember}, nil)
+		teamRepo.On("GetUserTeamRoles", outsider).Return(map[uuid.UUID]models.TeamRole{}, nil)
+		return NewFolderService(folderRepo, new(MockNoteRepository), teamRepo, nil, nil, nil, nil), folderRepo
+	}
+
+	t.Run("gives teammates the default access", func(t *testing.T) {
+		service, folderRepo := newService()
+		folderRepo.On("ShareFolder", folderID, teammate, models.AccessWrite).Return(nil)
+
+		results, err := service.ShareFolder(context.Background(), folderID, &ShareFolderInput{UserID: teammate}, ownerID)
+
+		require.NoError(t, err)
+		assert.Equal(t, models.AccessWrite, results[0].Access)
+	})
+
+	t.Run("rejects users outside the team", func(t *testing.T) {
+		service, folderRepo := newService()
+
+		_, err := service.ShareFolder(context.Background(), folderID, &ShareFolderInput{UserID: outsider, Access: models.AccessRead}, ownerID)
+
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		folderRepo.AssertNotCalled(t, "ShareFolder", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("reports outsiders among several users", func(t *testing.T) {
+		service, folderRepo := newService()
+		folderRepo.On("ShareFolder", folderID, teammate, models.AccessRead).Return(nil)
+
+		_, err := service.ShareFolder(context.Background(), folderID, &ShareFolderInput{Shares: []ShareEntry{
+			{UserID: teammate, Access: models.AccessRead},
+			{UserID: outsider},
+		}}, ownerID)
+
+		assert.Equal(t, map[string]string{"shares[1]": "your team only allows sharing with its members"}, apperrors.From(err).Fields)
+	})
+
+	t.Run("requires an access level from owners without a team", func(t *testing.T) {
+		folderRepo := new(MockFolderRepository)
+		teamRepo := new(MockTeamRepository)
+		folderRepo.On("GetByID", folderID).Return(&models.Folder{ID: folderID, OwnerID: ownerID}, nil)
+		teamRepo.On("GetUserTeams", ownerID).Return([]models.Team{}, nil)
+		service := NewFolderService(folderRepo, new(MockNoteRepository), teamRepo, nil, nil, nil, nil)
+
+		_, err := service.ShareFolder(context.Background(), folderID, &ShareFolderInput{UserID: teammate}, ownerID)
+
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		folderRepo.AssertNotCalled(t, "ShareFolder", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+func TestImpersonationService_Impersonate(t *testing.T) {
+	ctx := context.Background()
+	adminID := uuid.New()
+	user := &models.User{ID: uuid.New(), Username: "ops", Email: "ops@example.com", Role: models.RoleManager}
+
+	newService := func() (*ImpersonationService, *auth.JWTManager, *MockAuditRecorder) {
+		userRepo, recorder := new(MockUserRepository), new(MockAuditRecorder)
+		userRepo.On("GetByID", user.ID).Return(user, nil)
+		recorder.On("Record", mock.AnythingOfType("audit.Entry"))
+		jwtManager := auth.NewJWTManager("secret", 24)
+		// The user is an admin too, which the token must not pass on
+		jwtManager.SetClaimsBuilder(auth.AdminScope([]string{"ops"}))
+		return NewImpersonationService(userRepo, jwtManager, recorder, 30*time.Minute), jwtManager, recorder
+	}
+
+	t.Run("issues a short-lived token flagged with the admin", func(t *testing.T) {
+		service, jwtManager, recorder := newService()
+
+		issued, err := service.Impersonate(ctx, user.ID, &ImpersonateInput{Reason: "ticket 42", Minutes: 10}, adminID)
+		require.NoError(t, err)
+		assert.Equal(t, user, issued.User)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), issued.ExpiresAt, time.Second)
+
+		claims, err := jwtManager.ValidateToken(issued.Token)
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, claims.UserID)
+		assert.Equal(t, &adminID, claims.ImpersonatorID)
+		assert.WithinDuration(t, issued.ExpiresAt, claims.ExpiresAt.Time, time.Second)
+		assert.True(t, claims.HasScope(auth.ScopeTeamsManage))
+		assert.False(t, claims.HasScope(auth.ScopeAdmin))
+
+		_, err = jwtManager.RefreshToken(issued.Token)
+		assert.Error(t, err)
+
+		entry := recorder.Calls[0].Arguments.Get(0).(audit.Entry)
+		assert.Equal(t, adminID, entry.ActorID)
+		assert.Equal(t, audit.ActionImpersonate, entry.Action)
+		assert.Equal(t, user.ID, entry.TargetID)
+		assert.Equal(t, "ticket 42", entry.Details["reason"])
+	})
+
+	t.Run("defaults to the longest time allowed", func(t *testing.T) {
+		service, _, _ := newService()
+
+		issued, err := service.Impersonate(ctx, user.ID, &ImpersonateInput{Reason: "ticket 42"}, adminID)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(30*time.Minute), issued.ExpiresAt, time.Second)
+	})
+
+	t.Run("rejects more than the longest time allowed", func(t *testing.T) {
+		service, _, recorder := newService()
+
+		_, err := service.Impersonate(ctx, user.ID, &ImpersonateInput{Reason: "ticket 42", Minutes: 31}, adminID)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Contains(t, apperrors.From(err).Fields, "minutes")
+		recorder.AssertNotCalled(t, "Record", mock.Anything)
+	})
+
+	t.Run("rejects impersonating oneself", func(t *testing.T) {
+		service, _, _ := newService()
+
+		_, err := service.Impersonate(ctx, adminID, &ImpersonateInput{Reason: "ticket 42"}, adminID)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+	})
+}
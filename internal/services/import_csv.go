@@ -0,0 +1,91 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"slices"
+	"strings"
+
+	"seta-training/internal/apperrors"
+)
+
+// ImportDelimiters are the field delimiters an import file may use, by the
+// name a request gives them
+var ImportDelimiters = map[string]rune{
+	"comma":     ',',
+	"semicolon": ';',
+	"tab":       '\t',
+}
+
+// detectableDelimiters are tried in order, so comma wins a tie
+var detectableDelimiters = []rune{',', ';', '\t'}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ParseImportDelimiter resolves a requested delimiter, given by name or as
+// the character itself. "" and "auto" return 0, which detects the delimiter
+// from the header.
+func ParseImportDelimiter(s string) (rune, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "" || name == "auto" {
+		return 0, nil
+	}
+	if d, ok := ImportDelimiters[name]; ok {
+		return d, nil
+	}
+	if s == `\t` {
+		return '\t', nil
+	}
+	if r := []rune(s); len(r) == 1 && slices.Contains(detectableDelimiters, r[0]) {
+		return r[0], nil
+	}
+	return 0, apperrors.Validation("unsupported delimiter %q. Must be one of comma, semicolon, tab or auto", s)
+}
+
+// newCSVReader returns a reader over r with any UTF-8 byte order mark
+// skipped. A zero delimiter is detected from the header line.
+func newCSVReader(r io.Reader, delimiter rune) *csv.Reader {
+	br := bufio.NewReader(r)
+	if prefix, _ := br.Peek(len(utf8BOM)); bytes.Equal(prefix, utf8BOM) {
+		br.Discard(len(utf8BOM))
+	}
+	if delimiter == 0 {
+		delimiter = detectDelimiter(br)
+	}
+
+	reader := csv.NewReader(br)
+	reader.Comma = delimiter
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	return reader
+}
+
+// detectDelimiter picks the candidate occurring most often outside quotes in
+// the first line of br, without consuming it. It falls back to a comma.
+func detectDelimiter(br *bufio.Reader) rune {
+	buf, _ := br.Peek(br.Size())
+	counts := make(map[rune]int, len(detectableDelimiters))
+	quoted := false
+scan:
+	for _, c := range string(buf) {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '\n' || c == '\r':
+			break scan
+		default:
+			counts[c]++
+		}
+	}
+
+	best := ','
+	for _, d := range detectableDelimiters {
+		if counts[d] > counts[best] {
+			best = d
+		}
+	}
+	return best
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/audit"
+	"seta-training/pkg/webhook"
+)
+
+// Import webhook event names
+const (
+	ImportEventCompleted = "import.completed"
+	ImportEventFailed    = "import.failed"
+)
+
+// ImportEvent describes a finished import job
+type ImportEvent struct {
+	Event      string         `json:"event"`
+	ManagerID  string         `json:"manager_id"`
+	Filename   string         `json:"filename"`
+	Summary    *ImportSummary `json:"summary,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	FinishedAt time.Time      `json:"finished_at"`
+}
+
+// ImportNotifier is told about import jobs once they complete or fail
+type ImportNotifier interface {
+	ImportFinished(ctx context.Context, event ImportEvent)
+}
+
+// ImportNotifiers tells each of its notifiers
+type ImportNotifiers []ImportNotifier
+
+func (n ImportNotifiers) ImportFinished(ctx context.Context, event ImportEvent) {
+	for _, notifier := range n {
+		notifier.ImportFinished(ctx, event)
+	}
+}
+
+// WebhookImportNotifier posts import events to a configured webhook endpoint
+type WebhookImportNotifier struct {
+	sender   *webhook.Sender
+	endpoint webhook.Endpoint
+}
+
+// NewWebhookImportNotifier creates a notifier delivering to endpoint
+func NewWebhookImportNotifier(sender *webhook.Sender, endpoint webhook.Endpoint) *WebhookImportNotifier {
+	return &WebhookImportNotifier{
+		sender:   sender,
+		endpoint: endpoint,
+	}
+}
+
+// ImportFinished delivers the event in the background so the import response
+// isn't held up by a slow receiver
+func (n *WebhookImportNotifier) ImportFinished(ctx context.Context, event ImportEvent) {
+	n.sender.SendAsync(n.endpoint, event.Event, event)
+}
+
+// AuditImportNotifier records each finished import in the audit log, which
+// is what the admin statistics count imports from
+type AuditImportNotifier struct {
+	audit audit.Recorder
+}
+
+func NewAuditImportNotifier(auditor audit.Recorder) *AuditImportNotifier {
+	return &AuditImportNotifier{audit: auditor}
+}
+
+func (n *AuditImportNotifier) ImportFinished(ctx context.Context, event ImportEvent) {
+	managerID, err := uuid.Parse(event.ManagerID)
+	if err != nil {
+		return
+	}
+	details := map[string]string{
+		"event":    event.Event,
+		"filename": event.Filename,
+	}
+	if event.Summary != nil {
+		details["succeeded"] = strconv.Itoa(event.Summary.SuccessCount)
+		details["failed"] = strconv.Itoa(event.Summary.FailureCount)
+	}
+	n.audit.Record(ctx, audit.Entry{
+		ActorID:    managerID,
+		Action:     audit.ActionImport,
+		TargetType: audit.TargetUser,
+		Details:    details,
+	})
+}
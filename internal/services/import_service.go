@@ -3,27 +3,44 @@ package services
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/models"
 	"seta-training/pkg/logger"
+	"seta-training/pkg/metrics"
 )
 
+// MaxImportFileSize is the largest CSV file an import accepts
+const MaxImportFileSize = 5 << 20
+
 // ImportService handles CSV user imports with concurrent processing
 type ImportService struct {
 	userService UserServiceInterface
 	logger      logger.Logger
+	metrics     *metrics.Metrics
 }
 
-// NewImportService creates a new import service
-func NewImportService(userService UserServiceInterface, logger logger.Logger) *ImportService {
+// NewImportService creates a new import service. A nil logger discards
+// output and a nil m uses a private registry.
+func NewImportService(userService UserServiceInterface, log logger.Logger, m *metrics.Metrics) *ImportService {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
 	return &ImportService{
 		userService: userService,
-		logger:      logger,
+		logger:      log,
+		metrics:     m,
 	}
 }
 
@@ -34,6 +51,8 @@ type UserImportRecord struct {
 	Password string `csv:"password"`
 	Role     string `csv:"role"`
 	LineNum  int    `csv:"-"` // Track line number for error reporting
+	// OrganizationID is the organization the user is created in
+	OrganizationID *uuid.UUID `csv:"-" json:"-"`
 }
 
 // ImportResult represents the result of importing a single user
@@ -46,27 +65,50 @@ type ImportResult struct {
 
 // ImportSummary represents the overall import summary
 type ImportSummary struct {
-	TotalRecords    int            `json:"total_records"`
-	SuccessCount    int            `json:"success_count"`
-	FailureCount    int            `json:"failure_count"`
-	ProcessingTime  string         `json:"processing_time"`
-	Results         []ImportResult `json:"results"`
-	Errors          []string       `json:"errors,omitempty"`
+	TotalRecords   int            `json:"total_records"`
+	SuccessCount   int            `json:"success_count"`
+	FailureCount   int            `json:"failure_count"`
+	ProcessingTime string         `json:"processing_time"`
+	Results        []ImportResult `json:"results"`
+	// Errors describes the rows that could not be read, by line
+	Errors []string `json:"errors,omitempty"`
 }
 
 // ImportConfig holds configuration for the import process
 type ImportConfig struct {
-	WorkerCount     int           `json:"worker_count"`
-	BatchSize       int           `json:"batch_size"`
-	Timeout         time.Duration `json:"timeout"`
-	MaxRecords      int           `json:"max_records"`
-	SkipDuplicates  bool          `json:"skip_duplicates"`
+	WorkerCount    int           `json:"worker_count"`
+	BatchSize      int           `json:"batch_size"`
+	Timeout        time.Duration `json:"timeout"`
+	MaxRecords     int           `json:"max_records"`
+	SkipDuplicates bool          `json:"skip_duplicates"`
+	// ColumnMapping maps CSV header names to import fields, e.g.
+	// {"full_name": "username", "mail": "email"}. Unmapped headers are
+	// matched against the field names directly.
+	ColumnMapping map[string]string `json:"column_mapping,omitempty"`
+	// DefaultRole is used for rows whose role column is missing or empty
+	DefaultRole string `json:"default_role,omitempty"`
+	// Delimiter separates fields. Zero detects it from the header line.
+	Delimiter rune `json:"delimiter,omitempty"`
+	// OrganizationID is the importing manager's organization, which all
+	// imported users join
+	OrganizationID *uuid.UUID `json:"-"`
 }
 
+// Import fields that CSV columns can be mapped to
+const (
+	ImportFieldUsername = "username"
+	ImportFieldEmail    = "email"
+	ImportFieldPassword = "password"
+	ImportFieldRole     = "role"
+)
+
+// ImportFields lists the fields a CSV column can be mapped to
+var ImportFields = []string{ImportFieldUsername, ImportFieldEmail, ImportFieldPassword, ImportFieldRole}
+
 // DefaultImportConfig returns default configuration
 func DefaultImportConfig() ImportConfig {
 	return ImportConfig{
-		WorkerCount:    5,  // Number of concurrent workers
+		WorkerCount:    5,   // Number of concurrent workers
 		BatchSize:      100, // Records per batch
 		Timeout:        30 * time.Second,
 		MaxRecords:     1000, // Maximum records to process
@@ -76,18 +118,20 @@ func DefaultImportConfig() ImportConfig {
 
 // ImportUsersFromCSV processes CSV data concurrently using worker pools
 func (s *ImportService) ImportUsersFromCSV(ctx context.Context, csvReader io.Reader, config ImportConfig) (*ImportSummary, error) {
+	log := logger.FromContext(ctx, s.logger)
 	startTime := time.Now()
-	
-	s.logger.Info("Starting CSV user import",
+	defer s.metrics.TrackImport()()
+
+	log.Info("Starting CSV user import",
 		logger.Int("worker_count", config.WorkerCount),
 		logger.Int("batch_size", config.BatchSize),
 		logger.Int("max_records", config.MaxRecords),
 	)
 
 	// Parse CSV records
-	records, err := s.parseCSVRecords(csvReader, config.MaxRecords)
+	records, parseErrors, err := s.parseCSVRecords(log, csvReader, config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		return nil, apperrors.Wrap(apperrors.CodeValidation, err, "failed to parse CSV")
 	}
 
 	if len(records) == 0 {
@@ -97,13 +141,16 @@ func (s *ImportService) ImportUsersFromCSV(ctx context.Context, csvReader io.Rea
 			FailureCount:   0,
 			ProcessingTime: time.Since(startTime).String(),
 			Results:        []ImportResult{},
+			Errors:         parseErrors,
 		}, nil
 	}
 
-	s.logger.Info("Parsed CSV records", logger.Int("count", len(records)))
+	log.Info("Parsed CSV records", logger.Int("count", len(records)))
 
-	// Create channels for worker communication
-	recordChan := make(chan UserImportRecord, config.BatchSize)
+	// Create channels for worker communication. Each batch is created in
+	// one transaction.
+	batchSize := max(config.BatchSize, 1)
+	batchChan := make(chan []UserImportRecord, config.WorkerCount)
 	resultChan := make(chan ImportResult, len(records))
 
 	// Create context with timeout
@@ -114,25 +161,33 @@ func (s *ImportService) ImportUsersFromCSV(ctx context.Context, csvReader io.Rea
 	var wg sync.WaitGroup
 	for i := 0; i < config.WorkerCount; i++ {
 		wg.Add(1)
-		go s.worker(ctx, i+1, recordChan, resultChan, &wg)
+		go s.worker(ctx, i+1, batchChan, resultChan, &wg)
 	}
 
 	// Send records to workers
 	go func() {
-		defer close(recordChan)
-		for _, record := range records {
+		defer close(batchChan)
+		for batch := range slices.Chunk(records, batchSize) {
 			select {
-			case recordChan <- record:
+			case batchChan <- batch:
+				s.metrics.AddImportQueueDepth(len(batch))
 			case <-ctx.Done():
-				s.logger.Warn("Context cancelled while sending records")
+				log.Warn("Context cancelled while sending records")
 				return
 			}
 		}
 	}()
 
-	// Wait for all workers to complete
+	// Wait for all workers to complete. Records still queued when the
+	// import timed out are never processed.
 	go func() {
 		wg.Wait()
+		for batch := range batchChan {
+			s.metrics.AddImportQueueDepth(-len(batch))
+			for range batch {
+				s.metrics.RecordImportRow("skipped", "timeout")
+			}
+		}
 		close(resultChan)
 	}()
 
@@ -151,8 +206,8 @@ func (s *ImportService) ImportUsersFromCSV(ctx context.Context, csvReader io.Rea
 	}
 
 	processingTime := time.Since(startTime)
-	
-	s.logger.Info("CSV import completed",
+
+	log.Info("CSV import completed",
 		logger.Int("total", len(records)),
 		logger.Int("success", successCount),
 		logger.Int("failed", failureCount),
@@ -165,32 +220,45 @@ func (s *ImportService) ImportUsersFromCSV(ctx context.Context, csvReader io.Rea
 		FailureCount:   failureCount,
 		ProcessingTime: processingTime.String(),
 		Results:        results,
+		Errors:         parseErrors,
 	}, nil
 }
 
-// parseCSVRecords parses CSV data into UserImportRecord structs
-func (s *ImportService) parseCSVRecords(reader io.Reader, maxRecords int) ([]UserImportRecord, error) {
-	csvReader := csv.NewReader(reader)
-	csvReader.TrimLeadingSpace = true
+// parseCSVRecords parses CSV data into UserImportRecord structs. Rows that
+// cannot be read or lack required fields are skipped and described, by the
+// line they start on, in the returned messages.
+func (s *ImportService) parseCSVRecords(log logger.Logger, reader io.Reader, config ImportConfig) ([]UserImportRecord, []string, error) {
+	csvReader := newCSVReader(reader, config.Delimiter)
 
 	// Read header
 	header, err := csvReader.Read()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		return nil, nil, apperrors.Wrap(apperrors.CodeValidation, err, "failed to read CSV header")
 	}
 
-	// Validate header
-	expectedHeaders := []string{"username", "email", "password", "role"}
-	if !s.validateHeader(header, expectedHeaders) {
-		return nil, fmt.Errorf("invalid CSV header. Expected: %v, Got: %v", expectedHeaders, header)
+	// Resolve column positions, applying the configured mapping
+	columns, err := s.resolveColumns(header, config)
+	if err != nil {
+		return nil, nil, apperrors.Wrap(apperrors.CodeValidation, err, "invalid CSV header")
+	}
+	// Rows must reach every resolved column; a missing role column is
+	// covered by the default role
+	width := 0
+	for _, idx := range columns {
+		width = max(width, idx+1)
 	}
 
 	var records []UserImportRecord
-	lineNum := 2 // Start from line 2 (after header)
+	var parseErrors []string
+	skip := func(lineNum int, reason, msg string, fields ...logger.Field) {
+		log.Warn("Skipping CSV row", append([]logger.Field{logger.Int("line", lineNum), logger.String("problem", msg)}, fields...)...)
+		s.metrics.RecordImportRow("skipped", reason)
+		parseErrors = append(parseErrors, fmt.Sprintf("line %d: %s", lineNum, msg))
+	}
 
 	for {
-		if maxRecords > 0 && len(records) >= maxRecords {
-			s.logger.Warn("Reached maximum record limit", logger.Int("max_records", maxRecords))
+		if config.MaxRecords > 0 && len(records) >= config.MaxRecords {
+			log.Warn("Reached maximum record limit", logger.Int("max_records", config.MaxRecords))
 			break
 		}
 
@@ -198,99 +266,232 @@ func (s *ImportService) parseCSVRecords(reader io.Reader, maxRecords int) ([]Use
 		if err == io.EOF {
 			break
 		}
-		if err != nil {
-			s.logger.Error("Error reading CSV row", 
-				logger.Int("line", lineNum),
-				logger.Error(err),
-			)
-			lineNum++
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			skip(parseErr.StartLine, "malformed_row", parseErr.Err.Error(), logger.Error(err))
 			continue
 		}
+		if err != nil {
+			return nil, nil, apperrors.Wrap(apperrors.CodeValidation, err, "failed to read CSV")
+		}
 
-		if len(row) < 4 {
-			s.logger.Warn("Skipping incomplete row", 
-				logger.Int("line", lineNum),
-				logger.Int("columns", len(row)),
-			)
-			lineNum++
+		// Quoted fields may span lines, so a record starts where its first
+		// field does
+		lineNum, _ := csvReader.FieldPos(0)
+		if len(row) < width {
+			skip(lineNum, "malformed_row", fmt.Sprintf("expected %d fields, got %d", width, len(row)))
 			continue
 		}
 
+		field := func(name string) string {
+			idx, ok := columns[name]
+			if !ok {
+				return ""
+			}
+			return strings.TrimSpace(row[idx])
+		}
+
 		record := UserImportRecord{
-			Username: strings.TrimSpace(row[0]),
-			Email:    strings.TrimSpace(row[1]),
-			Password: strings.TrimSpace(row[2]),
-			Role:     strings.TrimSpace(row[3]),
+			Username: field(ImportFieldUsername),
+			Email:    field(ImportFieldEmail),
+			Password: field(ImportFieldPassword),
+			Role:     field(ImportFieldRole),
 			LineNum:  lineNum,
+
+			OrganizationID: config.OrganizationID,
+		}
+		if record.Role == "" {
+			record.Role = config.DefaultRole
 		}
 
 		// Basic validation
-		if record.Username == "" || record.Email == "" || record.Password == "" {
-			s.logger.Warn("Skipping row with empty required fields", logger.Int("line", lineNum))
-			lineNum++
+		var missing []string
+		for name, value := range map[string]string{
+			ImportFieldUsername: record.Username,
+			ImportFieldEmail:    record.Email,
+			ImportFieldPassword: record.Password,
+		} {
+			if value == "" {
+				missing = append(missing, name)
+			}
+		}
+		if len(missing) > 0 {
+			slices.Sort(missing)
+			skip(lineNum, "missing_fields", fmt.Sprintf("empty required fields %v", missing))
 			continue
 		}
 
 		records = append(records, record)
-		lineNum++
 	}
 
-	return records, nil
+	return records, parseErrors, nil
 }
 
-// validateHeader checks if CSV header matches expected format
-func (s *ImportService) validateHeader(header, expected []string) bool {
-	if len(header) < len(expected) {
-		return false
+// resolveColumns maps each import field to its column index in header. The
+// role column is optional when a default role is configured.
+func (s *ImportService) resolveColumns(header []string, config ImportConfig) (map[string]int, error) {
+	mapping := make(map[string]string, len(config.ColumnMapping))
+	for source, target := range config.ColumnMapping {
+		target = strings.ToLower(strings.TrimSpace(target))
+		if !slices.Contains(ImportFields, target) {
+			return nil, apperrors.Validation("column %q is mapped to unknown field %q", source, target)
+		}
+		mapping[strings.ToLower(strings.TrimSpace(source))] = target
 	}
-	
-	for i, expectedCol := range expected {
-		if strings.ToLower(strings.TrimSpace(header[i])) != expectedCol {
-			return false
+
+	columns := make(map[string]int, len(ImportFields))
+	for i, col := range header {
+		name := strings.ToLower(strings.TrimSpace(col))
+		if target, ok := mapping[name]; ok {
+			name = target
+		}
+		if !slices.Contains(ImportFields, name) {
+			continue
+		}
+		if _, dup := columns[name]; dup {
+			return nil, apperrors.Validation("more than one column maps to %q", name)
 		}
+		columns[name] = i
 	}
-	return true
+
+	var missing []string
+	for _, field := range ImportFields {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		if field == ImportFieldRole && config.DefaultRole != "" {
+			continue
+		}
+		missing = append(missing, field)
+	}
+	if len(missing) > 0 {
+		return nil, apperrors.Validation("missing columns %v, got %v", missing, header)
+	}
+
+	return columns, nil
 }
 
-// worker processes user import records concurrently
-func (s *ImportService) worker(ctx context.Context, workerID int, recordChan <-chan UserImportRecord, resultChan chan<- ImportResult, wg *sync.WaitGroup) {
+// worker processes batches of user import records concurrently
+func (s *ImportService) worker(ctx context.Context, workerID int, batchChan <-chan []UserImportRecord, resultChan chan<- ImportResult, wg *sync.WaitGroup) {
+	log := logger.FromContext(ctx, s.logger)
 	defer wg.Done()
-	
-	s.logger.Debug("Worker started", logger.Int("worker_id", workerID))
-	
+
+	start := time.Now()
+	defer func() {
+		s.metrics.RecordImportWorker(workerID, time.Since(start))
+	}()
+
+	log.Debug("Worker started", logger.Int("worker_id", workerID))
+
 	for {
 		select {
-		case record, ok := <-recordChan:
+		case batch, ok := <-batchChan:
 			if !ok {
-				s.logger.Debug("Worker finished - channel closed", logger.Int("worker_id", workerID))
+				log.Debug("Worker finished - channel closed", logger.Int("worker_id", workerID))
 				return
 			}
-			
-			result := s.processUserRecord(ctx, record, workerID)
-			
-			select {
-			case resultChan <- result:
-			case <-ctx.Done():
-				s.logger.Warn("Context cancelled while sending result", logger.Int("worker_id", workerID))
-				return
+			s.metrics.AddImportQueueDepth(-len(batch))
+
+			for _, result := range s.processBatch(ctx, batch, workerID) {
+				select {
+				case resultChan <- result:
+				case <-ctx.Done():
+					log.Warn("Context cancelled while sending result", logger.Int("worker_id", workerID))
+					return
+				}
 			}
-			
+
 		case <-ctx.Done():
-			s.logger.Warn("Worker cancelled by context", logger.Int("worker_id", workerID))
+			log.Warn("Worker cancelled by context", logger.Int("worker_id", workerID))
 			return
 		}
 	}
 }
 
-// processUserRecord processes a single user record
-func (s *ImportService) processUserRecord(ctx context.Context, record UserImportRecord, workerID int) ImportResult {
-	s.logger.Debug("Processing user record",
-		logger.Int("worker_id", workerID),
-		logger.Int("line", record.LineNum),
-		logger.String("username", record.Username),
-		logger.String("email", record.Email),
-	)
+// processBatch creates the users in batch in one transaction. A rejected
+// record fails on its own; if the transaction fails, so does every record
+// in the batch.
+func (s *ImportService) processBatch(ctx context.Context, batch []UserImportRecord, workerID int) []ImportResult {
+	log := logger.FromContext(ctx, s.logger)
+
+	results := make([]ImportResult, 0, len(batch))
+	pending := make([]UserImportRecord, 0, len(batch))
+	inputs := make([]*CreateUserInput, 0, len(batch))
+	for _, record := range batch {
+		log.Debug("Processing user record",
+			logger.Int("worker_id", workerID),
+			logger.Int("line", record.LineNum),
+			logger.String("username", record.Username),
+			logger.String("email", record.Email),
+		)
+
+		input, err := userInputOf(record)
+		if err != nil {
+			s.metrics.RecordImportRow("failure", "invalid_role")
+			results = append(results, ImportResult{
+				Record:  record,
+				Success: false,
+				Error:   err.Error(),
+			})
+			continue
+		}
+		pending = append(pending, record)
+		inputs = append(inputs, input)
+	}
+	if len(inputs) == 0 {
+		return results
+	}
+
+	// Create users via GraphQL mutation (through service)
+	users, errs, err := s.userService.CreateUsers(ctx, inputs)
+	if err != nil {
+		log.Error("Failed to create user batch",
+			logger.Int("worker_id", workerID),
+			logger.Int("records", len(inputs)),
+			logger.Error(err),
+		)
+		errs = make([]error, len(inputs))
+		for i := range errs {
+			errs[i] = err
+		}
+	}
+
+	for i, record := range pending {
+		if errs[i] != nil {
+			log.Error("Failed to create user",
+				logger.Int("worker_id", workerID),
+				logger.Int("line", record.LineNum),
+				logger.String("email", record.Email),
+				logger.Error(errs[i]),
+			)
+			s.metrics.RecordImportRow("failure", importFailureReason(errs[i]))
+			results = append(results, ImportResult{
+				Record:  record,
+				Success: false,
+				Error:   errs[i].Error(),
+			})
+			continue
+		}
+		s.metrics.RecordImportRow("success", "")
 
+		user := users[i]
+		log.Debug("User created successfully",
+			logger.Int("worker_id", workerID),
+			logger.Int("line", record.LineNum),
+			logger.String("user_id", user.ID.String()),
+			logger.String("email", user.Email),
+		)
+		results = append(results, ImportResult{
+			Record:  record,
+			Success: true,
+			UserID:  user.ID.String(),
+		})
+	}
+	return results
+}
+
+// userInputOf converts record into the input for creating its user
+func userInputOf(record UserImportRecord) (*CreateUserInput, error) {
 	// Validate role
 	var role models.UserRole
 	switch strings.ToLower(record.Role) {
@@ -299,48 +500,26 @@ func (s *ImportService) processUserRecord(ctx context.Context, record UserImport
 	case "member":
 		role = models.RoleMember
 	default:
-		return ImportResult{
-			Record:  record,
-			Success: false,
-			Error:   fmt.Sprintf("invalid role '%s'. Must be 'manager' or 'member'", record.Role),
-		}
+		return nil, fmt.Errorf("invalid role '%s'. Must be 'manager' or 'member'", record.Role)
 	}
 
-	// Create user input
-	input := &CreateUserInput{
+	return &CreateUserInput{
 		Username: record.Username,
 		Email:    record.Email,
 		Password: record.Password,
 		Role:     role,
-	}
 
-	// Create user via GraphQL mutation (through service)
-	user, err := s.userService.CreateUser(input)
-	if err != nil {
-		s.logger.Error("Failed to create user",
-			logger.Int("worker_id", workerID),
-			logger.Int("line", record.LineNum),
-			logger.String("email", record.Email),
-			logger.Error(err),
-		)
-		
-		return ImportResult{
-			Record:  record,
-			Success: false,
-			Error:   err.Error(),
-		}
-	}
-
-	s.logger.Debug("User created successfully",
-		logger.Int("worker_id", workerID),
-		logger.Int("line", record.LineNum),
-		logger.String("user_id", user.ID.String()),
-		logger.String("email", user.Email),
-	)
+		OrganizationID: record.OrganizationID,
+	}, nil
+}
 
-	return ImportResult{
-		Record:  record,
-		Success: true,
-		UserID:  user.ID.String(),
+// importFailureReason classifies a user creation error for metrics
+func importFailureReason(err error) string {
+	switch apperrors.From(err).Code {
+	case apperrors.CodeConflict:
+		return "duplicate"
+	case apperrors.CodeValidation:
+		return "validation"
 	}
+	return "error"
 }
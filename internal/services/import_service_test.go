@@ -1,17 +1,22 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/models"
 	"seta-training/pkg/auth"
 	"seta-training/pkg/logger"
+	"seta-training/pkg/metrics"
 )
 
 // MockUserService is a mock implementation of UserServiceInterface for import testing
@@ -19,7 +24,7 @@ type MockUserService struct {
 	mock.Mock
 }
 
-func (m *MockUserService) CreateUser(input *CreateUserInput) (*models.User, error) {
+func (m *MockUserService) CreateUser(ctx context.Context, input *CreateUserInput) (*models.User, error) {
 	args := m.Called(input)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -27,7 +32,17 @@ func (m *MockUserService) CreateUser(input *CreateUserInput) (*models.User, erro
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) Login(input *LoginInput) (*LoginResponse, error) {
+// CreateUsers delegates to CreateUser so tests set expectations per record
+func (m *MockUserService) CreateUsers(ctx context.Context, inputs []*CreateUserInput) ([]*models.User, []error, error) {
+	users := make([]*models.User, len(inputs))
+	errs := make([]error, len(inputs))
+	for i, input := range inputs {
+		users[i], errs[i] = m.CreateUser(ctx, input)
+	}
+	return users, errs, nil
+}
+
+func (m *MockUserService) Login(ctx context.Context, input *LoginInput) (*LoginResponse, error) {
 	args := m.Called(input)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -35,7 +50,15 @@ func (m *MockUserService) Login(input *LoginInput) (*LoginResponse, error) {
 	return args.Get(0).(*LoginResponse), args.Error(1)
 }
 
-func (m *MockUserService) GetUserByID(id uuid.UUID) (*models.User, error) {
+func (m *MockUserService) IssueLogin(ctx context.Context, user *models.User) (*LoginResponse, error) {
+	args := m.Called(user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*LoginResponse), args.Error(1)
+}
+
+func (m *MockUserService) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -43,8 +66,8 @@ func (m *MockUserService) GetUserByID(id uuid.UUID) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserService) GetAllUsers() ([]models.User, error) {
-	args := m.Called()
+func (m *MockUserService) GetAllUsers(ctx context.Context, orgID *uuid.UUID) ([]models.User, error) {
+	args := m.Called(orgID)
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
@@ -93,7 +116,7 @@ func TestImportService_ImportUsersFromCSV_Success(t *testing.T) {
 	// Setup
 	mockUserService := new(MockUserService)
 	mockLogger := new(MockImportLogger)
-	service := NewImportService(mockUserService, mockLogger)
+	service := NewImportService(mockUserService, mockLogger, nil)
 
 	// CSV data with multiple users
 	csvData := `username,email,password,role
@@ -166,7 +189,7 @@ func TestImportService_ImportUsersFromCSV_PartialFailure(t *testing.T) {
 	// Setup
 	mockUserService := new(MockUserService)
 	mockLogger := new(MockImportLogger)
-	service := NewImportService(mockUserService, mockLogger)
+	service := NewImportService(mockUserService, mockLogger, nil)
 
 	// CSV data with one invalid role
 	csvData := `username,email,password,role
@@ -233,7 +256,7 @@ func TestImportService_ImportUsersFromCSV_InvalidHeader(t *testing.T) {
 	// Setup
 	mockUserService := new(MockUserService)
 	mockLogger := new(MockImportLogger)
-	service := NewImportService(mockUserService, mockLogger)
+	service := NewImportService(mockUserService, mockLogger, nil)
 
 	// CSV data with invalid header
 	csvData := `name,email,pass,type
@@ -256,7 +279,7 @@ func TestImportService_ImportUsersFromCSV_EmptyFile(t *testing.T) {
 	// Setup
 	mockUserService := new(MockUserService)
 	mockLogger := new(MockImportLogger)
-	service := NewImportService(mockUserService, mockLogger)
+	service := NewImportService(mockUserService, mockLogger, nil)
 
 	// CSV data with only header
 	csvData := `username,email,password,role`
@@ -283,7 +306,7 @@ func TestImportService_ImportUsersFromCSV_MaxRecordsLimit(t *testing.T) {
 	// Setup
 	mockUserService := new(MockUserService)
 	mockLogger := new(MockImportLogger)
-	service := NewImportService(mockUserService, mockLogger)
+	service := NewImportService(mockUserService, mockLogger, nil)
 
 	// CSV data with 3 users
 	csvData := `username,email,password,role
@@ -335,3 +358,257 @@ bob.wilson,bob.wilson@example.com,password789,member`
 
 	mockUserService.AssertExpectations(t)
 }
+
+func TestImportService_ImportUsersFromCSV_ColumnMapping(t *testing.T) {
+	// Setup
+	mockUserService := new(MockUserService)
+	mockLogger := new(MockImportLogger)
+	service := NewImportService(mockUserService, mockLogger, nil)
+
+	// Third-party export with different headers, column order and no role
+	csvData := `mail,full_name,department,secret
+john.doe@example.com,john.doe,Sales,password123`
+
+	mockUserService.On("CreateUser", mock.MatchedBy(func(input *CreateUserInput) bool {
+		return input.Username == "john.doe" &&
+			input.Email == "john.doe@example.com" &&
+			input.Password == "password123" &&
+			input.Role == models.RoleMember
+	})).Return(&models.User{
+		ID:       uuid.New(),
+		Username: "john.doe",
+		Email:    "john.doe@example.com",
+		Role:     models.RoleMember,
+	}, nil)
+
+	config := DefaultImportConfig()
+	config.ColumnMapping = map[string]string{
+		"full_name": "username",
+		"mail":      "email",
+		"Secret":    "password",
+	}
+	config.DefaultRole = "member"
+
+	// Test
+	summary, err := service.ImportUsersFromCSV(context.Background(), strings.NewReader(csvData), config)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, summary.TotalRecords)
+	assert.Equal(t, 1, summary.SuccessCount)
+	mockUserService.AssertExpectations(t)
+}
+
+func TestImportService_ImportUsersFromCSV_MissingRoleWithoutDefault(t *testing.T) {
+	// Setup
+	mockUserService := new(MockUserService)
+	mockLogger := new(MockImportLogger)
+	service := NewImportService(mockUserService, mockLogger, nil)
+
+	csvData := `username,email,password
+john.doe,john.doe@example.com,password123`
+
+	// Test
+	summary, err := service.ImportUsersFromCSV(context.Background(), strings.NewReader(csvData), DefaultImportConfig())
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, summary)
+	assert.Contains(t, err.Error(), "missing columns [role]")
+}
+
+func TestImportService_ImportUsersFromCSV_UnknownMappingTarget(t *testing.T) {
+	// Setup
+	mockUserService := new(MockUserService)
+	mockLogger := new(MockImportLogger)
+	service := NewImportService(mockUserService, mockLogger, nil)
+
+	csvData := `username,email,password,role
+john.doe,john.doe@example.com,password123,manager`
+
+	config := DefaultImportConfig()
+	config.ColumnMapping = map[string]string{"username": "login"}
+
+	// Test
+	summary, err := service.ImportUsersFromCSV(context.Background(), strings.NewReader(csvData), config)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, summary)
+	assert.Contains(t, err.Error(), "unknown field")
+}
+
+func TestImportService_ImportUsersFromCSV_Metrics(t *testing.T) {
+	mockUserService := new(MockUserService)
+	m := metrics.NewIsolatedMetrics()
+	service := NewImportService(mockUserService, nil, m)
+
+	csvData := `username,email,password,role
+alice,alice@example.com,password123,member
+bob,bob@example.com,password123,member
+carol,carol@example.com,password123,admin
+dave,,password123,member`
+
+	mockUserService.On("CreateUser", mock.MatchedBy(func(input *CreateUserInput) bool {
+		return input.Username == "alice"
+	})).Return(&models.User{ID: uuid.New(), Username: "alice"}, nil)
+	mockUserService.On("CreateUser", mock.MatchedBy(func(input *CreateUserInput) bool {
+		return input.Username == "bob"
+	})).Return(nil, apperrors.Conflict("email already exists"))
+
+	config := DefaultImportConfig()
+	config.WorkerCount = 2
+	_, err := service.ImportUsersFromCSV(context.Background(), strings.NewReader(csvData), config)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.ImportRowsTotal.WithLabelValues("success")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.ImportRowsTotal.WithLabelValues("failure")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.ImportRowsTotal.WithLabelValues("skipped")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.ImportFailuresTotal.WithLabelValues("duplicate")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.ImportFailuresTotal.WithLabelValues("invalid_role")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.ImportFailuresTotal.WithLabelValues("missing_fields")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.ImportWorkerDuration))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.ImportQueueDepth))
+	assert.Equal(t, 0.0, testutil.ToFloat64(m.ImportJobsActive))
+}
+
+func TestImportService_ImportUsersFromCSV_ComponentLogLevels(t *testing.T) {
+	csvData := `username,email,password,role
+alice,alice@example.com,password123,member
+bob,bob@example.com,password123,member
+carol,carol@example.com,password123,member`
+
+	run := func(t *testing.T, configure func(*logger.LogrusLogger)) string {
+		var buf bytes.Buffer
+		base := logger.NewLogger("debug", "json", &buf).(*logger.LogrusLogger)
+		configure(base)
+
+		mockUserService := new(MockUserService)
+		mockUserService.On("CreateUser", mock.Anything).Return(&models.User{ID: uuid.New()}, nil)
+		service := NewImportService(mockUserService, logger.ForComponent(base, logger.ComponentImport), nil)
+
+		// The request logger must keep the import component's level
+		ctx := logger.NewContext(context.Background(), base.WithFields(logger.String("request_id", "req-1")))
+		_, err := service.ImportUsersFromCSV(ctx, strings.NewReader(csvData), DefaultImportConfig())
+		assert.NoError(t, err)
+		return buf.String()
+	}
+
+	t.Run("component level overrides base level", func(t *testing.T) {
+		out := run(t, func(l *logger.LogrusLogger) {
+			assert.NoError(t, l.SetComponentLevels(map[string]string{logger.ComponentImport: "warn"}))
+		})
+		assert.NotContains(t, out, "Processing user record")
+		assert.NotContains(t, out, "CSV import completed")
+	})
+
+	t.Run("debug lines are sampled per message", func(t *testing.T) {
+		out := run(t, func(l *logger.LogrusLogger) {
+			l.SetSampling(1, 0)
+		})
+		assert.Equal(t, 1, strings.Count(out, "Processing user record"))
+		assert.Contains(t, out, `"component":"import"`)
+		assert.Contains(t, out, "CSV import completed")
+	})
+
+	t.Run("invalid level is rejected", func(t *testing.T) {
+		run(t, func(l *logger.LogrusLogger) {
+			assert.Error(t, l.SetComponentLevels(map[string]string{logger.ComponentImport: "loud"}))
+		})
+	})
+}
+
+func TestImportService_ImportUsersFromCSV_MalformedRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		csvData    string
+		delimiter  rune
+		wantUsers  []string
+		wantLines  []int
+		wantErrors []string
+	}{
+		{
+			name:      "byte order mark",
+			csvData:   "\ufeffusername,email,password,role\nalice,alice@example.com,password123,member",
+			wantUsers: []string{"alice"},
+			wantLines: []int{2},
+		},
+		{
+			name:      "detected semicolons",
+			csvData:   "username;email;password;role\nalice;alice@example.com;pass,word;member",
+			wantUsers: []string{"alice"},
+			wantLines: []int{2},
+		},
+		{
+			name:      "detected tabs",
+			csvData:   "username\temail\tpassword\trole\nalice\talice@example.com\tpassword123\tmember",
+			wantUsers: []string{"alice"},
+			wantLines: []int{2},
+		},
+		{
+			name:      "configured delimiter",
+			csvData:   "username;email;password;role;notes,a,b,c,d,e\nalice;alice@example.com;password123;member;x",
+			delimiter: ';',
+			wantUsers: []string{"alice"},
+			wantLines: []int{2},
+		},
+		{
+			name: "quoted fields spanning lines",
+			csvData: "username,email,password,role\n" +
+				"\"alice\",alice@example.com,\"pass\nword\",member\n" +
+				"bob,bob@example.com,\"say \"\"hi\"\"\",member",
+			wantUsers: []string{"alice", "bob"},
+			wantLines: []int{2, 4},
+		},
+		{
+			name: "rows with errors are reported",
+			csvData: "username,email,password,role\n" +
+				"alice,alice@example.com,password123,member,extra\n" +
+				"bob,bob@example.com\n" +
+				"carol,ca\"rol@example.com,password123,member\n" +
+				"dave,,,member\n" +
+				"erin,erin@example.com,password123,member",
+			wantUsers: []string{"alice", "erin"},
+			wantLines: []int{2, 6},
+			wantErrors: []string{
+				"line 3: expected 4 fields, got 2",
+				`line 4: bare " in non-quoted-field`,
+				"line 5: empty required fields [email password]",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserService := new(MockUserService)
+			mockUserService.On("CreateUser", mock.Anything).Return(&models.User{ID: uuid.New()}, nil)
+			service := NewImportService(mockUserService, nil, nil)
+
+			config := DefaultImportConfig()
+			config.Delimiter = tt.delimiter
+			summary, err := service.ImportUsersFromCSV(context.Background(), strings.NewReader(tt.csvData), config)
+
+			require.NoError(t, err)
+			var users []string
+			var lines []int
+			for _, result := range summary.Results {
+				users = append(users, result.Record.Username)
+				lines = append(lines, result.Record.LineNum)
+			}
+			assert.ElementsMatch(t, tt.wantUsers, users)
+			assert.ElementsMatch(t, tt.wantLines, lines)
+			assert.Equal(t, tt.wantErrors, summary.Errors)
+		})
+	}
+}
+
+func TestParseImportDelimiter(t *testing.T) {
+	for input, want := range map[string]rune{"": 0, "auto": 0, "comma": ',', "Semicolon": ';', "tab": '\t', `\t`: '\t', ";": ';'} {
+		got, err := ParseImportDelimiter(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	_, err := ParseImportDelimiter("|")
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
+}
@@ -6,51 +6,281 @@ import (
 	"github.com/google/uuid"
 	"seta-training/internal/models"
 	"seta-training/pkg/auth"
+	"seta-training/pkg/featureflags"
+	"seta-training/pkg/pagination"
+	"seta-training/pkg/scim"
 )
 
 // UserServiceInterface defines the interface for user service
 type UserServiceInterface interface {
-	CreateUser(input *CreateUserInput) (*models.User, error)
-	Login(input *LoginInput) (*LoginResponse, error)
-	GetUserByID(id uuid.UUID) (*models.User, error)
-	GetAllUsers() ([]models.User, error)
+	CreateUser(ctx context.Context, input *CreateUserInput) (*models.User, error)
+	CreateUsers(ctx context.Context, inputs []*CreateUserInput) ([]*models.User, []error, error)
+	Login(ctx context.Context, input *LoginInput) (*LoginResponse, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetAllUsers(ctx context.Context, orgID *uuid.UUID) ([]models.User, error)
 	ValidateToken(tokenString string) (*auth.Claims, error)
 }
 
 // TeamServiceInterface defines the interface for team service
 type TeamServiceInterface interface {
-	CreateTeam(input *CreateTeamInput, creatorID uuid.UUID) (*models.Team, error)
-	AddMember(teamID, userID, managerID uuid.UUID) error
-	RemoveMember(teamID, userID, managerID uuid.UUID) error
-	AddManager(teamID, userID, requestorID uuid.UUID) error
-	RemoveManager(teamID, userID, requestorID uuid.UUID) error
-	GetTeam(teamID uuid.UUID) (*models.Team, error)
-	GetAllTeams() ([]models.Team, error)
+	CreateTeam(ctx context.Context, input *CreateTeamInput, creatorID uuid.UUID) (*models.Team, error)
+	AddMember(ctx context.Context, teamID, userID, managerID uuid.UUID) error
+	RemoveMember(ctx context.Context, teamID, userID, managerID uuid.UUID) error
+	AddManager(ctx context.Context, teamID, userID, requestorID uuid.UUID) error
+	RemoveManager(ctx context.Context, teamID, userID, requestorID uuid.UUID) error
+	SetRole(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole, requesterID uuid.UUID) error
+	GetTeam(ctx context.Context, teamID uuid.UUID, orgID *uuid.UUID) (*models.Team, error)
+	GetAllTeams(ctx context.Context, orgID *uuid.UUID) ([]models.Team, error)
+	ListTeams(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.Team], error)
+	UpdateSettings(ctx context.Context, teamID uuid.UUID, input *TeamSettingsInput, managerID uuid.UUID) (*models.Team, error)
+}
+
+// OrganizationServiceInterface defines the interface for organization service
+type OrganizationServiceInterface interface {
+	CreateOrganization(ctx context.Context, input *CreateOrganizationInput, actorID uuid.UUID) (*models.Organization, error)
+	GetOrganization(ctx context.Context, orgID uuid.UUID) (*models.Organization, error)
+	GetAllOrganizations(ctx context.Context) ([]models.Organization, error)
+	GetOrganizationUsers(ctx context.Context, orgID uuid.UUID) ([]models.User, error)
+	ListOrganizationUsers(ctx context.Context, orgID uuid.UUID, p pagination.Params) (pagination.Page[models.User], error)
+	AddUser(ctx context.Context, orgID, userID, actorID uuid.UUID) error
 }
 
 // FolderServiceInterface defines the interface for folder service
 type FolderServiceInterface interface {
-	CreateFolder(input *CreateFolderInput, ownerID uuid.UUID) (*models.Folder, error)
-	GetFolder(folderID, userID uuid.UUID) (*models.Folder, error)
-	UpdateFolder(folderID uuid.UUID, input *UpdateFolderInput, userID uuid.UUID) (*models.Folder, error)
-	DeleteFolder(folderID, userID uuid.UUID) error
-	ShareFolder(folderID uuid.UUID, input *ShareFolderInput, ownerID uuid.UUID) error
-	RevokeShare(folderID, targetUserID, ownerID uuid.UUID) error
-	GetUserFolders(userID uuid.UUID) ([]models.Folder, error)
+	CreateFolder(ctx context.Context, input *CreateFolderInput, ownerID uuid.UUID) (*models.Folder, error)
+	GetFolder(ctx context.Context, folderID, userID uuid.UUID) (*models.Folder, error)
+	UpdateFolder(ctx context.Context, folderID uuid.UUID, input *UpdateFolderInput, userID uuid.UUID) (*models.Folder, error)
+	DeleteFolder(ctx context.Context, folderID, userID uuid.UUID) error
+	DuplicateFolder(ctx context.Context, folderID uuid.UUID, input *DuplicateFolderInput, userID uuid.UUID, progress func(copied, total int)) (*models.Folder, error)
+	MergeFolder(ctx context.Context, targetID uuid.UUID, input *MergeFolderInput, userID uuid.UUID) (*models.Folder, error)
+	ShareFolder(ctx context.Context, folderID uuid.UUID, input *ShareFolderInput, ownerID uuid.UUID) ([]ShareResult, error)
+	RevokeShare(ctx context.Context, folderID, targetUserID, ownerID uuid.UUID) error
+	ListShares(ctx context.Context, folderID, ownerID uuid.UUID, p pagination.Params) (pagination.Page[models.FolderShare], error)
+	GetUserFolders(ctx context.Context, userID uuid.UUID) ([]models.Folder, error)
+	GetFoldersByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]models.Folder, error)
 }
 
 // NoteServiceInterface defines the interface for note service
 type NoteServiceInterface interface {
-	CreateNote(folderID uuid.UUID, input *CreateNoteInput, userID uuid.UUID) (*models.Note, error)
-	GetNote(noteID, userID uuid.UUID) (*models.Note, error)
-	UpdateNote(noteID uuid.UUID, input *UpdateNoteInput, userID uuid.UUID) (*models.Note, error)
-	DeleteNote(noteID, userID uuid.UUID) error
-	ShareNote(noteID uuid.UUID, input *ShareNoteInput, ownerID uuid.UUID) error
-	RevokeShare(noteID, targetUserID, ownerID uuid.UUID) error
-	GetUserNotes(userID uuid.UUID) ([]models.Note, error)
+	CreateNote(ctx context.Context, folderID uuid.UUID, input *CreateNoteInput, userID uuid.UUID) (*models.Note, error)
+	GetNote(ctx context.Context, noteID, userID uuid.UUID) (*models.Note, error)
+	UpdateNote(ctx context.Context, noteID uuid.UUID, input *UpdateNoteInput, userID uuid.UUID) (*models.Note, error)
+	DeleteNote(ctx context.Context, noteID, userID uuid.UUID) error
+	ChangeStatus(ctx context.Context, noteID uuid.UUID, input *NoteStatusInput, userID uuid.UUID) (*models.Note, error)
+	GetBacklinks(ctx context.Context, noteID, userID uuid.UUID) ([]models.Backlink, error)
+	ShareNote(ctx context.Context, noteID uuid.UUID, input *ShareNoteInput, ownerID uuid.UUID) ([]ShareResult, error)
+	RevokeShare(ctx context.Context, noteID, targetUserID, ownerID uuid.UUID) error
+	ListShares(ctx context.Context, noteID, ownerID uuid.UUID, p pagination.Params) (pagination.Page[models.NoteShare], error)
+	ListOwnedNotes(ctx context.Context, userID uuid.UUID, filter models.NoteFilter, p pagination.Params) (pagination.Page[models.Note], error)
+	GetUserNotes(ctx context.Context, userID uuid.UUID) ([]models.Note, error)
+	GetNotesByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]models.Note, error)
 }
 
 // ImportServiceInterface defines the interface for import service
 type ImportServiceInterface interface {
 	ImportUsersFromCSV(ctx context.Context, csvReader io.Reader, config ImportConfig) (*ImportSummary, error)
 }
+
+// SavedFilterServiceInterface defines the interface for saved filter service
+type SavedFilterServiceInterface interface {
+	CreateSavedFilter(ctx context.Context, input *SavedFilterInput, ownerID uuid.UUID) (*models.SavedFilter, error)
+	GetSavedFilter(ctx context.Context, filterID, userID uuid.UUID) (*models.SavedFilter, error)
+	GetUserSavedFilters(ctx context.Context, userID uuid.UUID) ([]models.SavedFilter, error)
+	UpdateSavedFilter(ctx context.Context, filterID uuid.UUID, input *SavedFilterInput, userID uuid.UUID) (*models.SavedFilter, error)
+	DeleteSavedFilter(ctx context.Context, filterID, userID uuid.UUID) error
+	ExecuteSavedFilter(ctx context.Context, filterID, userID uuid.UUID, limit int) (*FilterResults, error)
+	GetHome(ctx context.Context, userID uuid.UUID) (*HomePayload, error)
+}
+
+// SearchServiceInterface defines the interface for search service
+type SearchServiceInterface interface {
+	Search(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID, query SearchQuery) (*SearchResults, error)
+}
+
+// ChecklistServiceInterface defines the interface for checklist service
+type ChecklistServiceInterface interface {
+	GetChecklist(ctx context.Context, noteID, userID uuid.UUID) ([]models.ChecklistItem, error)
+	AddItem(ctx context.Context, noteID uuid.UUID, input *ChecklistItemInput, userID uuid.UUID) (*models.ChecklistItem, error)
+	UpdateItem(ctx context.Context, noteID, itemID uuid.UUID, input *UpdateChecklistItemInput, userID uuid.UUID) (*models.ChecklistItem, error)
+	DeleteItem(ctx context.Context, noteID, itemID, userID uuid.UUID) error
+}
+
+// ReminderServiceInterface defines the interface for reminder service
+type ReminderServiceInterface interface {
+	CreateReminder(ctx context.Context, noteID uuid.UUID, input *ReminderInput, userID uuid.UUID) (*models.Reminder, error)
+	GetNoteReminders(ctx context.Context, noteID, userID uuid.UUID) ([]models.Reminder, error)
+	UpdateReminder(ctx context.Context, noteID, reminderID uuid.UUID, input *ReminderInput, userID uuid.UUID) (*models.Reminder, error)
+	DeleteReminder(ctx context.Context, noteID, reminderID, userID uuid.UUID) error
+}
+
+// AccessRequestServiceInterface defines the interface for access request service
+type AccessRequestServiceInterface interface {
+	RequestAccess(ctx context.Context, resourceType string, resourceID uuid.UUID, input *AccessRequestInput, userID uuid.UUID) (*models.AccessRequest, error)
+	ListPending(ctx context.Context, ownerID uuid.UUID, p pagination.Params) (pagination.Page[models.AccessRequest], error)
+	Decide(ctx context.Context, requestID uuid.UUID, approve bool, userID uuid.UUID) (*models.AccessRequest, error)
+}
+
+// AnnouncementServiceInterface defines the interface for announcement service
+type AnnouncementServiceInterface interface {
+	CreateAnnouncement(ctx context.Context, input *AnnouncementInput, actorID uuid.UUID) (*models.Announcement, error)
+	GetAnnouncements(ctx context.Context) ([]models.Announcement, error)
+	GetActiveAnnouncements(ctx context.Context) ([]models.Announcement, error)
+	UpdateAnnouncement(ctx context.Context, announcementID uuid.UUID, input *AnnouncementInput, actorID uuid.UUID) (*models.Announcement, error)
+	DeleteAnnouncement(ctx context.Context, announcementID, actorID uuid.UUID) error
+}
+
+// FeatureFlagServiceInterface defines the interface for feature flag service
+type FeatureFlagServiceInterface interface {
+	ListFlags(ctx context.Context) ([]models.FeatureFlag, error)
+	SaveFlag(ctx context.Context, name string, input *FeatureFlagInput, actorID uuid.UUID) (*models.FeatureFlag, error)
+	DeleteFlag(ctx context.Context, name string, actorID uuid.UUID) error
+	EnabledFeatures(ctx context.Context, subject featureflags.Subject) []string
+}
+
+// WebhookServiceInterface defines the interface for webhook service
+type WebhookServiceInterface interface {
+	CreateWebhook(ctx context.Context, input *WebhookInput, ownerID uuid.UUID) (*WebhookWithSecret, error)
+	GetWebhook(ctx context.Context, webhookID, userID uuid.UUID) (*models.Webhook, error)
+	GetUserWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhookID uuid.UUID, input *WebhookInput, userID uuid.UUID) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID, userID uuid.UUID) error
+	GetDeliveries(ctx context.Context, webhookID, userID uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+}
+
+// ExportServiceInterface defines the interface for export service
+type ExportServiceInterface interface {
+	RequestFolderExport(ctx context.Context, folderID, userID uuid.UUID, format models.ExportFormat) (*models.ExportJob, error)
+	GetExportJob(ctx context.Context, jobID, userID uuid.UUID) (*models.ExportJob, error)
+	GetExportArtifact(ctx context.Context, jobID, userID uuid.UUID) (*models.ExportJob, []byte, error)
+}
+
+// ImpersonationServiceInterface defines the interface for impersonation service
+type ImpersonationServiceInterface interface {
+	Impersonate(ctx context.Context, userID uuid.UUID, input *ImpersonateInput, adminID uuid.UUID) (*ImpersonationToken, error)
+}
+
+// AssetReportServiceInterface defines the interface for asset report service
+type AssetReportServiceInterface interface {
+	RequestReport(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID) (*models.AssetReport, error)
+	GetReport(ctx context.Context, reportID uuid.UUID, orgID *uuid.UUID) (*models.AssetReport, error)
+	GetReportArtifact(ctx context.Context, reportID uuid.UUID, orgID *uuid.UUID) (*models.AssetReport, []byte, error)
+}
+
+// LoginRecorder is told about every attempt to log in. user is nil when the
+// email matches no one.
+type LoginRecorder interface {
+	RecordLogin(ctx context.Context, email string, user *models.User, outcome models.LoginOutcome)
+}
+
+// LoginHistoryServiceInterface defines the interface for login history service
+type LoginHistoryServiceInterface interface {
+	LoginRecorder
+	GetMyHistory(ctx context.Context, userID uuid.UUID, limit int) ([]models.LoginAttempt, error)
+	GetTeamHistory(ctx context.Context, teamID uuid.UUID, memberID *uuid.UUID, limit int, requesterID uuid.UUID) ([]models.LoginAttempt, error)
+}
+
+// CaptchaChecker rejects logins and signups that need a solved CAPTCHA
+// and came without one
+type CaptchaChecker interface {
+	Check(ctx context.Context) error
+}
+
+// DeviceRegistrar returns the device a user logs in with, for binding their
+// token to it
+type DeviceRegistrar interface {
+	Register(ctx context.Context, user *models.User) (uuid.UUID, error)
+}
+
+// DeviceServiceInterface defines the interface for device service
+type DeviceServiceInterface interface {
+	DeviceRegistrar
+	GetMyDevices(ctx context.Context, userID uuid.UUID) ([]models.Device, error)
+	RevokeDevice(ctx context.Context, deviceID, userID uuid.UUID) error
+}
+
+// SSOUserService creates and logs in the users single sign-on vouches for
+type SSOUserService interface {
+	CreateUser(ctx context.Context, input *CreateUserInput) (*models.User, error)
+	IssueLogin(ctx context.Context, user *models.User) (*LoginResponse, error)
+}
+
+// SSOServiceInterface defines the interface for SSO service
+type SSOServiceInterface interface {
+	GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SAMLConnection, error)
+	SaveConnection(ctx context.Context, orgID uuid.UUID, input *SAMLConnectionInput, actorID uuid.UUID) (*models.SAMLConnection, error)
+	Metadata(ctx context.Context, slug string) ([]byte, error)
+	LoginURL(ctx context.Context, slug, relayState string) (string, string, error)
+	CompleteLogin(ctx context.Context, slug, samlResponse string, requestIDs []string) (*LoginResponse, error)
+}
+
+// DirectoryUserService creates the users directory syncs find
+type DirectoryUserService interface {
+	CreateUser(ctx context.Context, input *CreateUserInput) (*models.User, error)
+}
+
+// LDAPSyncServiceInterface defines the interface for LDAP sync service
+type LDAPSyncServiceInterface interface {
+	GetConnection(ctx context.Context, orgID uuid.UUID) (*models.LDAPConnection, error)
+	SaveConnection(ctx context.Context, orgID uuid.UUID, input *LDAPConnectionInput, actorID uuid.UUID) (*models.LDAPConnection, error)
+	Sync(ctx context.Context, orgID uuid.UUID, dryRun bool, actorID uuid.UUID) (*LDAPSyncReport, error)
+}
+
+// SCIMServiceInterface defines the interface for SCIM service
+type SCIMServiceInterface interface {
+	GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SCIMConnection, error)
+	CreateToken(ctx context.Context, orgID uuid.UUID, actorID uuid.UUID) (*SCIMToken, error)
+	DeleteConnection(ctx context.Context, orgID uuid.UUID, actorID uuid.UUID) error
+	Authenticate(ctx context.Context, token string) (uuid.UUID, error)
+	ListUsers(ctx context.Context, orgID uuid.UUID, filter string, startIndex, count int) (*scim.ListResponse, error)
+	GetUser(ctx context.Context, orgID, id uuid.UUID) (*scim.User, error)
+	CreateUser(ctx context.Context, orgID uuid.UUID, resource *scim.User) (*scim.User, error)
+	ReplaceUser(ctx context.Context, orgID, id uuid.UUID, resource *scim.User) (*scim.User, error)
+	PatchUser(ctx context.Context, orgID, id uuid.UUID, patch *scim.PatchRequest) (*scim.User, error)
+	DeactivateUser(ctx context.Context, orgID, id uuid.UUID) error
+	ListGroups(ctx context.Context, orgID uuid.UUID, filter string, startIndex, count int) (*scim.ListResponse, error)
+	GetGroup(ctx context.Context, orgID, id uuid.UUID) (*scim.Group, error)
+	CreateGroup(ctx context.Context, orgID uuid.UUID, resource *scim.Group) (*scim.Group, error)
+	ReplaceGroup(ctx context.Context, orgID, id uuid.UUID, resource *scim.Group) (*scim.Group, error)
+	PatchGroup(ctx context.Context, orgID, id uuid.UUID, patch *scim.PatchRequest) (*scim.Group, error)
+	DeleteGroup(ctx context.Context, orgID, id uuid.UUID) error
+}
+
+// NoteMentionProcessor is notified whenever a note body is saved
+type NoteMentionProcessor interface {
+	ProcessNoteMentions(ctx context.Context, note *models.Note, authorID uuid.UUID)
+}
+
+// MentionServiceInterface defines the interface for mention service
+type MentionServiceInterface interface {
+	NoteMentionProcessor
+	GetUserMentions(ctx context.Context, userID uuid.UUID, limit int) ([]MentionView, error)
+}
+
+// QuotaChecker rejects creating a note or folder that would exceed a quota
+type QuotaChecker interface {
+	CheckNote(ctx context.Context, userID uuid.UUID) error
+	CheckFolder(ctx context.Context, userID uuid.UUID) error
+}
+
+// QuotaServiceInterface defines the interface for quota service
+type QuotaServiceInterface interface {
+	QuotaChecker
+	GetQuota(ctx context.Context, userID uuid.UUID) (*Quota, error)
+}
+
+// StatsServiceInterface defines the interface for stats service
+type StatsServiceInterface interface {
+	GetStats(ctx context.Context, days int) (*Stats, error)
+}
+
+// UserPreferenceServiceInterface defines the interface for user preference service
+type UserPreferenceServiceInterface interface {
+	GetPreferences(ctx context.Context, userID uuid.UUID) (*models.UserPreference, error)
+	UpdatePreferences(ctx context.Context, userID uuid.UUID, input *UpdatePreferencesInput) (*models.UserPreference, error)
+}
+
+// NotificationServiceInterface defines the interface for notification service
+type NotificationServiceInterface interface {
+	GetUserNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]models.Notification, error)
+	MarkRead(ctx context.Context, notificationID, userID uuid.UUID) error
+}
@@ -0,0 +1,734 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/directory"
+	"seta-training/pkg/logger"
+)
+
+// Directory is a connection to an organization's LDAP server
+type Directory interface {
+	Search(baseDN, filter string, attributes []string) ([]directory.Entry, error)
+	Entry(dn string, attributes []string) (*directory.Entry, error)
+	Close() error
+}
+
+// DirectoryDialer connects to a directory
+type DirectoryDialer func(config directory.Config) (Directory, error)
+
+// DialDirectory connects to an LDAP server with the directory package
+func DialDirectory(config directory.Config) (Directory, error) {
+	conn, err := directory.Dial(config)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// LDAPSyncService keeps the users and teams of organizations in line with
+// their LDAP or Active Directory server
+type LDAPSyncService struct {
+	repo     repositories.LDAPRepositoryInterface
+	orgRepo  repositories.OrganizationRepositoryInterface
+	userRepo repositories.UserRepositoryInterface
+	teamRepo repositories.TeamRepositoryInterface
+	users    DirectoryUserService
+	dial     DirectoryDialer
+	timeout  time.Duration
+	audit    audit.Recorder
+	logger   logger.Logger
+}
+
+// NewLDAPSyncService creates an LDAP sync service giving up on directories
+// that do not answer within timeout. dial defaults to DialDirectory;
+// auditor and log may be nil.
+func NewLDAPSyncService(repo repositories.LDAPRepositoryInterface, orgRepo repositories.OrganizationRepositoryInterface, userRepo repositories.UserRepositoryInterface, teamRepo repositories.TeamRepositoryInterface, users DirectoryUserService, dial DirectoryDialer, timeout time.Duration, auditor audit.Recorder, log logger.Logger) *LDAPSyncService {
+	if dial == nil {
+		dial = DialDirectory
+	}
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &LDAPSyncService{
+		repo:     repo,
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+		teamRepo: teamRepo,
+		users:    users,
+		dial:     dial,
+		timeout:  timeout,
+		audit:    auditor,
+		logger:   log,
+	}
+}
+
+// LDAPConnectionInput configures an organization's directory. BindPassword
+// may be left out to keep the current one, and Enabled defaults to true.
+type LDAPConnectionInput struct {
+	URL               string                     `json:"url" binding:"required,max=255"`
+	StartTLS          bool                       `json:"start_tls"`
+	BindDN            string                     `json:"bind_dn" binding:"required,max=255"`
+	BindPassword      string                     `json:"bind_password" binding:"max=255"`
+	UserBaseDN        string                     `json:"user_base_dn" binding:"required,max=255"`
+	UserFilter        string                     `json:"user_filter" binding:"max=1024"`
+	IDAttribute       string                     `json:"id_attribute" binding:"max=255"`
+	EmailAttribute    string                     `json:"email_attribute" binding:"max=255"`
+	UsernameAttribute string                     `json:"username_attribute" binding:"max=255"`
+	MemberAttribute   string                     `json:"member_attribute" binding:"max=255"`
+	RoleMapping       map[string]models.UserRole `json:"role_mapping"`
+	DefaultRole       models.UserRole            `json:"default_role" binding:"omitempty,oneof=manager member"`
+	TeamMapping       []models.LDAPTeamMapping   `json:"team_mapping" binding:"dive"`
+	Enabled           *bool                      `json:"enabled"`
+}
+
+// LDAPSyncReport lists the changes a sync made, or would make on a dry run
+type LDAPSyncReport struct {
+	OrganizationID     uuid.UUID              `json:"organization_id"`
+	DryRun             bool                   `json:"dry_run"`
+	Created            []LDAPSyncedUser       `json:"created"`
+	Updated            []LDAPSyncedUser       `json:"updated"`
+	Deactivated        []LDAPSyncedUser       `json:"deactivated"`
+	MembershipsAdded   []LDAPSyncedMembership `json:"memberships_added"`
+	MembershipsUpdated []LDAPSyncedMembership `json:"memberships_updated"`
+	MembershipsRemoved []LDAPSyncedMembership `json:"memberships_removed"`
+	// Skipped lists the directory users that could not be synced
+	Skipped []LDAPSkippedEntry `json:"skipped"`
+}
+
+// LDAPSyncedUser is a user in a sync report. UserID is nil for users a dry
+// run would create. Changes names what was updated: email, role,
+// reactivated or linked, for existing users now managed by the directory.
+type LDAPSyncedUser struct {
+	UserID   *uuid.UUID      `json:"user_id,omitempty"`
+	Email    string          `json:"email"`
+	Username string          `json:"username,omitempty"`
+	Role     models.UserRole `json:"role"`
+	Changes  []string        `json:"changes,omitempty"`
+}
+
+// LDAPSyncedMembership is a team membership in a sync report
+type LDAPSyncedMembership struct {
+	TeamID   uuid.UUID       `json:"team_id"`
+	TeamName string          `json:"team_name"`
+	UserID   *uuid.UUID      `json:"user_id,omitempty"`
+	Email    string          `json:"email"`
+	Role     models.TeamRole `json:"role,omitempty"`
+}
+
+// LDAPSkippedEntry is a directory user a sync left alone, and why
+type LDAPSkippedEntry struct {
+	DN     string `json:"dn"`
+	Reason string `json:"reason"`
+}
+
+// GetConnection returns the LDAP connection of an organization
+func (s *LDAPSyncService) GetConnection(ctx context.Context, orgID uuid.UUID) (*models.LDAPConnection, error) {
+	return s.repo.GetConnection(ctx, orgID)
+}
+
+// SaveConnection sets up or replaces the LDAP connection of an organization
+func (s *LDAPSyncService) SaveConnection(ctx context.Context, orgID uuid.UUID, input *LDAPConnectionInput, actorID uuid.UUID) (*models.LDAPConnection, error) {
+	if _, err := s.orgRepo.GetByID(ctx, orgID); err != nil {
+		return nil, err
+	}
+	conn := &models.LDAPConnection{
+		OrganizationID:    orgID,
+		URL:               input.URL,
+		StartTLS:          input.StartTLS,
+		BindDN:            input.BindDN,
+		BindPassword:      input.BindPassword,
+		UserBaseDN:        input.UserBaseDN,
+		UserFilter:        input.UserFilter,
+		IDAttribute:       input.IDAttribute,
+		EmailAttribute:    input.EmailAttribute,
+		UsernameAttribute: input.UsernameAttribute,
+		MemberAttribute:   input.MemberAttribute,
+		RoleMapping:       input.RoleMapping,
+		DefaultRole:       input.DefaultRole,
+		TeamMapping:       input.TeamMapping,
+		Enabled:           input.Enabled == nil || *input.Enabled,
+	}
+	setLDAPDefaults(conn)
+	if conn.BindPassword == "" {
+		existing, err := s.repo.GetConnection(ctx, orgID)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get LDAP connection: %w", err)
+		}
+		if existing != nil {
+			conn.BindPassword = existing.BindPassword
+		}
+	}
+	if err := s.validateConnection(ctx, conn); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveConnection(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to save LDAP connection: %w", err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetLDAPConnection,
+		TargetID:   conn.ID,
+		Details: map[string]string{
+			"organization_id": orgID.String(),
+			"enabled":         fmt.Sprint(conn.Enabled),
+		},
+	})
+	return conn, nil
+}
+
+// setLDAPDefaults fills in what the input left out with the attributes of
+// OpenLDAP's inetOrgPerson and groupOfNames
+func setLDAPDefaults(conn *models.LDAPConnection) {
+	if conn.UserFilter == "" {
+		conn.UserFilter = "(objectClass=person)"
+	}
+	if conn.EmailAttribute == "" {
+		conn.EmailAttribute = "mail"
+	}
+	if conn.UsernameAttribute == "" {
+		conn.UsernameAttribute = "uid"
+	}
+	if conn.MemberAttribute == "" {
+		conn.MemberAttribute = "member"
+	}
+	if conn.RoleMapping == nil {
+		conn.RoleMapping = map[string]models.UserRole{}
+	}
+	if conn.DefaultRole == "" {
+		conn.DefaultRole = models.RoleMember
+	}
+	if conn.TeamMapping == nil {
+		conn.TeamMapping = []models.LDAPTeamMapping{}
+	}
+	for i := range conn.TeamMapping {
+		if conn.TeamMapping[i].Role == "" {
+			conn.TeamMapping[i].Role = models.TeamRoleMember
+		}
+	}
+}
+
+// validateConnection checks a connection before it is saved. Mapped teams
+// must belong to the connection's organization.
+func (s *LDAPSyncService) validateConnection(ctx context.Context, conn *models.LDAPConnection) error {
+	fields := map[string]string{}
+	if directory.ValidateURL(conn.URL) != nil {
+		fields["url"] = "must be an ldap:// or ldaps:// URL"
+	}
+	if conn.BindPassword == "" {
+		fields["bind_password"] = "is required"
+	}
+	if directory.ValidateDN(conn.BindDN) != nil {
+		fields["bind_dn"] = "must be a distinguished name"
+	}
+	if directory.ValidateDN(conn.UserBaseDN) != nil {
+		fields["user_base_dn"] = "must be a distinguished name"
+	}
+	if directory.ValidateFilter(conn.UserFilter) != nil {
+		fields["user_filter"] = "must be an LDAP search filter"
+	}
+	for group, role := range conn.RoleMapping {
+		if directory.ValidateDN(group) != nil || (role != models.RoleManager && role != models.RoleMember) {
+			fields["role_mapping"] = "must map group DNs to manager or member"
+		}
+	}
+	for _, mapping := range conn.TeamMapping {
+		if directory.ValidateDN(mapping.Group) != nil {
+			fields["team_mapping"] = "groups must be distinguished names"
+			break
+		}
+		team, err := s.teamRepo.GetByID(ctx, mapping.TeamID)
+		if errors.Is(err, apperrors.ErrNotFound) || (err == nil && !models.SameOrganization(team.OrganizationID, &conn.OrganizationID)) {
+			fields["team_mapping"] = "teams must belong to the organization"
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get team: %w", err)
+		}
+	}
+	if len(fields) > 0 {
+		return apperrors.ValidationFields("Invalid LDAP connection", fields)
+	}
+	return nil
+}
+
+// Sync brings the users and teams of an organization in line with its
+// directory, or with dryRun only reports what that would change. Dry runs
+// also work for disabled connections, to preview them.
+func (s *LDAPSyncService) Sync(ctx context.Context, orgID uuid.UUID, dryRun bool, actorID uuid.UUID) (*LDAPSyncReport, error) {
+	conn, err := s.repo.GetConnection(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun && !conn.Enabled {
+		return nil, apperrors.Forbidden("LDAP sync is disabled for this organization")
+	}
+	if dryRun {
+		return s.sync(ctx, conn, true)
+	}
+	return s.run(ctx, conn, actorID)
+}
+
+// SyncAll syncs every enabled connection. It is run on a schedule; a
+// failing directory does not stop the others from being synced.
+func (s *LDAPSyncService) SyncAll(ctx context.Context) error {
+	conns, err := s.repo.GetEnabledConnections(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list LDAP connections: %w", err)
+	}
+	var errs []error
+	for i := range conns {
+		if _, err := s.run(ctx, &conns[i], uuid.Nil); err != nil {
+			errs = append(errs, fmt.Errorf("organization %s: %w", conns[i].OrganizationID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// run syncs conn, records the outcome on it and audits the changes.
+// actorID is nil for scheduled syncs.
+func (s *LDAPSyncService) run(ctx context.Context, conn *models.LDAPConnection, actorID uuid.UUID) (*LDAPSyncReport, error) {
+	report, syncErr := s.sync(ctx, conn, false)
+	message := ""
+	if syncErr != nil {
+		message = syncErr.Error()
+		s.logger.Error("LDAP sync failed",
+			logger.String("organization_id", conn.OrganizationID.String()),
+			logger.Error(syncErr),
+		)
+	}
+	if err := s.repo.RecordSync(ctx, conn.ID, time.Now().UTC(), message); err != nil {
+		s.logger.Warn("Failed to record LDAP sync", logger.String("organization_id", conn.OrganizationID.String()), logger.Error(err))
+	}
+	if report == nil {
+		return nil, syncErr
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionSync,
+		TargetType: audit.TargetLDAPConnection,
+		TargetID:   conn.ID,
+		Details: map[string]string{
+			"organization_id":     conn.OrganizationID.String(),
+			"created":             fmt.Sprint(len(report.Created)),
+			"updated":             fmt.Sprint(len(report.Updated)),
+			"deactivated":         fmt.Sprint(len(report.Deactivated)),
+			"memberships_added":   fmt.Sprint(len(report.MembershipsAdded)),
+			"memberships_updated": fmt.Sprint(len(report.MembershipsUpdated)),
+			"memberships_removed": fmt.Sprint(len(report.MembershipsRemoved)),
+			"skipped":             fmt.Sprint(len(report.Skipped)),
+		},
+	})
+	if syncErr != nil {
+		return nil, syncErr
+	}
+	s.logger.Info("Synced users from LDAP",
+		logger.String("organization_id", conn.OrganizationID.String()),
+		logger.Int("created", len(report.Created)),
+		logger.Int("updated", len(report.Updated)),
+		logger.Int("deactivated", len(report.Deactivated)),
+		logger.Int("skipped", len(report.Skipped)),
+	)
+	return report, nil
+}
+
+// syncedUser is a directory user matched to an account, which has no ID
+// yet when a dry run would create it
+type syncedUser struct {
+	id    *uuid.UUID
+	email string
+}
+
+// key identifies the user in maps of directory users
+func (u syncedUser) key() string {
+	if u.id != nil {
+		return u.id.String()
+	}
+	return u.email
+}
+
+// sync reads the directory of conn and applies the differences, or on a
+// dry run only reports them. A report is returned with the changes made so
+// far when applying them fails.
+func (s *LDAPSyncService) sync(ctx context.Context, conn *models.LDAPConnection, dryRun bool) (*LDAPSyncReport, error) {
+	dir, err := s.dial(directory.Config{
+		URL:          conn.URL,
+		StartTLS:     conn.StartTLS,
+		BindDN:       conn.BindDN,
+		BindPassword: conn.BindPassword,
+		Timeout:      s.timeout,
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeUnavailable, err, "LDAP directory unavailable")
+	}
+	defer dir.Close()
+
+	attributes := []string{conn.EmailAttribute, conn.UsernameAttribute}
+	if conn.IDAttribute != "" {
+		attributes = append(attributes, conn.IDAttribute)
+	}
+	entries, err := dir.Search(conn.UserBaseDN, conn.UserFilter, attributes)
+	if err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeUnavailable, err, "LDAP user search failed")
+	}
+	groups, err := s.readGroups(dir, conn)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.repo.GetLinkedUsers(ctx, conn.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get synced users: %w", err)
+	}
+	if len(entries) == 0 && len(links) > 0 {
+		return nil, apperrors.Validation("LDAP user search returned no users, refusing to deactivate every synced user")
+	}
+
+	report := &LDAPSyncReport{
+		OrganizationID:     conn.OrganizationID,
+		DryRun:             dryRun,
+		Created:            []LDAPSyncedUser{},
+		Updated:            []LDAPSyncedUser{},
+		Deactivated:        []LDAPSyncedUser{},
+		MembershipsAdded:   []LDAPSyncedMembership{},
+		MembershipsUpdated: []LDAPSyncedMembership{},
+		MembershipsRemoved: []LDAPSyncedMembership{},
+		Skipped:            []LDAPSkippedEntry{},
+	}
+	synced, err := s.syncUsers(ctx, conn, entries, groups, links, report)
+	if err != nil {
+		return report, err
+	}
+	if err := s.syncMemberships(ctx, conn, groups, links, synced, report); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// readGroups returns the normalized member DNs of every mapped group by
+// the group's normalized DN. Missing groups fail the sync, since their
+// members would otherwise lose their roles and teams.
+func (s *LDAPSyncService) readGroups(dir Directory, conn *models.LDAPConnection) (map[string]map[string]bool, error) {
+	dns := make([]string, 0, len(conn.RoleMapping)+len(conn.TeamMapping))
+	for group := range conn.RoleMapping {
+		dns = append(dns, group)
+	}
+	for _, mapping := range conn.TeamMapping {
+		dns = append(dns, mapping.Group)
+	}
+
+	groups := make(map[string]map[string]bool, len(dns))
+	for _, dn := range dns {
+		key := directory.NormalizeDN(dn)
+		if _, ok := groups[key]; ok {
+			continue
+		}
+		entry, err := dir.Entry(dn, []string{conn.MemberAttribute})
+		if errors.Is(err, directory.ErrNotFound) {
+			return nil, apperrors.Validation("LDAP group %q not found", dn)
+		}
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.CodeUnavailable, err, "LDAP group search failed")
+		}
+		members := map[string]bool{}
+		for _, member := range entry.Values(conn.MemberAttribute) {
+			members[directory.NormalizeDN(member)] = true
+		}
+		groups[key] = members
+	}
+	return groups, nil
+}
+
+// syncUsers creates, updates and deactivates the users of the directory's
+// organization. It returns the synced users by their normalized DN.
+func (s *LDAPSyncService) syncUsers(ctx context.Context, conn *models.LDAPConnection, entries []directory.Entry, groups map[string]map[string]bool, links []models.LDAPUser, report *LDAPSyncReport) (map[string]syncedUser, error) {
+	linksByExternalID := make(map[string]*models.LDAPUser, len(links))
+	for i := range links {
+		linksByExternalID[links[i].ExternalID] = &links[i]
+	}
+
+	synced := make(map[string]syncedUser, len(entries))
+	seenIDs := map[string]bool{}
+	seenEmails := map[string]bool{}
+	seenUsers := map[uuid.UUID]bool{}
+	for i := range entries {
+		entry := &entries[i]
+		externalID := entry.DN
+		if conn.IDAttribute != "" {
+			externalID = entry.Attribute(conn.IDAttribute)
+		}
+		if externalID == "" {
+			report.Skipped = append(report.Skipped, LDAPSkippedEntry{DN: entry.DN, Reason: "no " + conn.IDAttribute})
+			continue
+		}
+		address, err := mail.ParseAddress(entry.Attribute(conn.EmailAttribute))
+		if err != nil {
+			report.Skipped = append(report.Skipped, LDAPSkippedEntry{DN: entry.DN, Reason: "no valid " + conn.EmailAttribute})
+			continue
+		}
+		email := address.Address
+		if seenIDs[externalID] || seenEmails[email] {
+			report.Skipped = append(report.Skipped, LDAPSkippedEntry{DN: entry.DN, Reason: "duplicate of another entry"})
+			continue
+		}
+		seenIDs[externalID] = true
+		seenEmails[email] = true
+
+		role := ldapRole(conn, groups, entry.DN)
+		var user *models.User
+		if link, ok := linksByExternalID[externalID]; ok {
+			user = &link.User
+		}
+
+		var result syncedUser
+		var skip string
+		if user == nil {
+			result, skip, err = s.syncNewEntry(ctx, conn, entry, externalID, email, role, report)
+		} else {
+			result, skip, err = s.syncLinkedUser(ctx, conn, user, email, role, report)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if skip != "" {
+			report.Skipped = append(report.Skipped, LDAPSkippedEntry{DN: entry.DN, Reason: skip})
+			continue
+		}
+		synced[directory.NormalizeDN(entry.DN)] = result
+		if result.id != nil {
+			seenUsers[*result.id] = true
+		}
+	}
+
+	for i := range links {
+		user := &links[i].User
+		if seenUsers[links[i].UserID] || user.DeletedAt.Valid {
+			continue
+		}
+		if !report.DryRun {
+			if err := s.repo.DeactivateUser(ctx, user.ID); err != nil {
+				return nil, fmt.Errorf("failed to deactivate user: %w", err)
+			}
+		}
+		id := user.ID
+		report.Deactivated = append(report.Deactivated, LDAPSyncedUser{UserID: &id, Email: user.Email, Username: user.Username, Role: user.Role})
+	}
+	return synced, nil
+}
+
+// ldapRole returns the role the directory gives the user with dn. Managers
+// win over members.
+func ldapRole(conn *models.LDAPConnection, groups map[string]map[string]bool, dn string) models.UserRole {
+	member := directory.NormalizeDN(dn)
+	role, mapped := conn.DefaultRole, false
+	for group, groupRole := range conn.RoleMapping {
+		if groups[directory.NormalizeDN(group)][member] && (!mapped || groupRole == models.RoleManager) {
+			role, mapped = groupRole, true
+		}
+	}
+	return role
+}
+
+// syncNewEntry links a directory user with no link yet to the account with
+// their email, or creates one. It returns why the user was skipped, if
+// they were.
+func (s *LDAPSyncService) syncNewEntry(ctx context.Context, conn *models.LDAPConnection, entry *directory.Entry, externalID, email string, role models.UserRole, report *LDAPSyncReport) (syncedUser, string, error) {
+	user, err := s.repo.GetUserByEmail(ctx, email)
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+	case err != nil:
+		return syncedUser{}, "", fmt.Errorf("failed to get user: %w", err)
+	case !models.SameOrganization(user.OrganizationID, &conn.OrganizationID):
+		return syncedUser{}, "email is used by a user of another organization", nil
+	default:
+		if !report.DryRun {
+			if err := s.repo.LinkUser(ctx, &models.LDAPUser{UserID: user.ID, OrganizationID: conn.OrganizationID, ExternalID: externalID}); err != nil {
+				return syncedUser{}, "", fmt.Errorf("failed to link user: %w", err)
+			}
+		}
+		return s.syncLinkedUser(ctx, conn, user, email, role, report, "linked")
+	}
+
+	username, err := uniqueUsername(ctx, s.userRepo, entry.Attribute(conn.UsernameAttribute), email)
+	if err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return syncedUser{}, "no free username", nil
+		}
+		return syncedUser{}, "", err
+	}
+	if report.DryRun {
+		report.Created = append(report.Created, LDAPSyncedUser{Email: email, Username: username, Role: role})
+		return syncedUser{email: email}, "", nil
+	}
+	password, err := randomHex(32)
+	if err != nil {
+		return syncedUser{}, "", err
+	}
+	user, err = s.users.CreateUser(ctx, &CreateUserInput{
+		Username:       username,
+		Email:          email,
+		Password:       password,
+		Role:           role,
+		OrganizationID: &conn.OrganizationID,
+	})
+	if err != nil {
+		if appErr := apperrors.From(err); appErr.Code == apperrors.CodeValidation || appErr.Code == apperrors.CodeConflict {
+			return syncedUser{}, appErr.Message, nil
+		}
+		return syncedUser{}, "", err
+	}
+	if err := s.repo.LinkUser(ctx, &models.LDAPUser{UserID: user.ID, OrganizationID: conn.OrganizationID, ExternalID: externalID}); err != nil {
+		return syncedUser{}, "", fmt.Errorf("failed to link user: %w", err)
+	}
+	report.Created = append(report.Created, LDAPSyncedUser{UserID: &user.ID, Email: email, Username: username, Role: role})
+	return syncedUser{id: &user.ID, email: email}, "", nil
+}
+
+// syncLinkedUser updates the email and role of a user managed by the
+// directory and reactivates them. Roles are only managed with a role
+// mapping. changes names changes already made.
+func (s *LDAPSyncService) syncLinkedUser(ctx context.Context, conn *models.LDAPConnection, user *models.User, email string, role models.UserRole, report *LDAPSyncReport, changes ...string) (syncedUser, string, error) {
+	if len(conn.RoleMapping) == 0 {
+		role = user.Role
+	}
+	if user.Email != email {
+		other, err := s.repo.GetUserByEmail(ctx, email)
+		if err == nil && other.ID != user.ID {
+			return syncedUser{}, "email is used by another user", nil
+		}
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return syncedUser{}, "", fmt.Errorf("failed to get user: %w", err)
+		}
+		changes = append(changes, "email")
+	}
+	if user.Role != role {
+		changes = append(changes, "role")
+	}
+	if user.DeletedAt.Valid {
+		changes = append(changes, "reactivated")
+	}
+
+	id := user.ID
+	if len(changes) == 0 {
+		return syncedUser{id: &id, email: email}, "", nil
+	}
+	if !report.DryRun {
+		if user.Email != email || user.Role != role {
+			if err := s.repo.UpdateUser(ctx, user.ID, email, role); err != nil {
+				return syncedUser{}, "", fmt.Errorf("failed to update user: %w", err)
+			}
+		}
+		if user.DeletedAt.Valid {
+			if err := s.repo.RestoreUser(ctx, user.ID); err != nil {
+				return syncedUser{}, "", fmt.Errorf("failed to reactivate user: %w", err)
+			}
+		}
+	}
+	report.Updated = append(report.Updated, LDAPSyncedUser{UserID: &id, Email: email, Username: user.Username, Role: role, Changes: changes})
+	return syncedUser{id: &id, email: email}, "", nil
+}
+
+// syncMemberships puts the synced members of each mapped group into its
+// team with the mapped role, the most privileged one when several groups
+// map to the same team, and takes the directory's users who are in none
+// out of it. People added to teams by hand are left alone.
+func (s *LDAPSyncService) syncMemberships(ctx context.Context, conn *models.LDAPConnection, groups map[string]map[string]bool, links []models.LDAPUser, synced map[string]syncedUser, report *LDAPSyncReport) error {
+	// Directory users are keyed by their ID, or by their email when a dry
+	// run would create them
+	managed := make(map[string]string, len(links)+len(synced))
+	for _, link := range links {
+		managed[link.UserID.String()] = link.User.Email
+	}
+	users := make(map[string]syncedUser, len(synced))
+	for _, user := range synced {
+		users[user.key()] = user
+		managed[user.key()] = user.email
+	}
+
+	var teamIDs []uuid.UUID
+	wanted := map[uuid.UUID]map[string]models.TeamRole{}
+	for _, mapping := range conn.TeamMapping {
+		if _, ok := wanted[mapping.TeamID]; !ok {
+			teamIDs = append(teamIDs, mapping.TeamID)
+			wanted[mapping.TeamID] = map[string]models.TeamRole{}
+		}
+		for member := range groups[directory.NormalizeDN(mapping.Group)] {
+			user, ok := synced[member]
+			if !ok {
+				continue
+			}
+			current, ok := wanted[mapping.TeamID][user.key()]
+			if !ok || slices.Index(models.TeamRoles, mapping.Role) < slices.Index(models.TeamRoles, current) {
+				wanted[mapping.TeamID][user.key()] = mapping.Role
+			}
+		}
+	}
+
+	for _, teamID := range teamIDs {
+		team, err := s.teamRepo.GetByID(ctx, teamID)
+		if err != nil {
+			return fmt.Errorf("failed to get team %s: %w", teamID, err)
+		}
+		held := map[string]models.TeamRole{}
+		for _, membership := range team.Memberships {
+			userID := membership.UserID
+			email, ok := managed[userID.String()]
+			if !ok {
+				continue
+			}
+			if _, ok := wanted[teamID][userID.String()]; ok {
+				held[userID.String()] = membership.Role
+				continue
+			}
+			if !report.DryRun {
+				if err := s.teamRepo.RemoveMembership(ctx, teamID, userID); err != nil {
+					return fmt.Errorf("failed to remove team member: %w", err)
+				}
+			}
+			report.MembershipsRemoved = append(report.MembershipsRemoved, LDAPSyncedMembership{TeamID: teamID, TeamName: team.Name, UserID: &userID, Email: email, Role: membership.Role})
+		}
+
+		keys := make([]string, 0, len(wanted[teamID]))
+		for key := range wanted[teamID] {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			role, user := wanted[teamID][key], users[key]
+			membership := LDAPSyncedMembership{TeamID: teamID, TeamName: team.Name, UserID: user.id, Email: user.email, Role: role}
+			current, ok := held[key]
+			switch {
+			case !ok:
+				if !report.DryRun {
+					if err := s.teamRepo.AddMembership(ctx, teamID, *user.id, role); err != nil {
+						return fmt.Errorf("failed to add team member: %w", err)
+					}
+				}
+				report.MembershipsAdded = append(report.MembershipsAdded, membership)
+			case current != role:
+				if !report.DryRun {
+					if err := s.teamRepo.SetRole(ctx, teamID, *user.id, role); err != nil {
+						return fmt.Errorf("failed to change team role: %w", err)
+					}
+				}
+				report.MembershipsUpdated = append(report.MembershipsUpdated, membership)
+			}
+		}
+	}
+	return nil
+}
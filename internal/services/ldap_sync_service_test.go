@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/directory"
+)
+
+// MockLDAPRepository is a mock implementation of LDAPRepositoryInterface
+type MockLDAPRepository struct {
+	mock.Mock
+}
+
+func (m *MockLDAPRepository) GetConnection(ctx context.Context, orgID uuid.UUID) (*models.LDAPConnection, error) {
+	args := m.Called(orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LDAPConnection), args.Error(1)
+}
+
+func (m *MockLDAPRepository) GetEnabledConnections(ctx context.Context) ([]models.LDAPConnection, error) {
+	args := m.Called()
+	return args.Get(0).([]models.LDAPConnection), args.Error(1)
+}
+
+func (m *MockLDAPRepository) SaveConnection(ctx context.Context, conn *models.LDAPConnection) error {
+	args := m.Called(conn)
+	return args.Error(0)
+}
+
+func (m *MockLDAPRepository) RecordSync(ctx context.Context, connID uuid.UUID, at time.Time, syncErr string) error {
+	args := m.Called(connID, syncErr)
+	return args.Error(0)
+}
+
+func (m *MockLDAPRepository) GetLinkedUsers(ctx context.Context, orgID uuid.UUID) ([]models.LDAPUser, error) {
+	args := m.Called(orgID)
+	return args.Get(0).([]models.LDAPUser), args.Error(1)
+}
+
+func (m *MockLDAPRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockLDAPRepository) LinkUser(ctx context.Context, link *models.LDAPUser) error {
+	args := m.Called(link)
+	return args.Error(0)
+}
+
+func (m *MockLDAPRepository) UpdateUser(ctx context.Context, userID uuid.UUID, email string, role models.UserRole) error {
+	args := m.Called(userID, email, role)
+	return args.Error(0)
+}
+
+func (m *MockLDAPRepository) DeactivateUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockLDAPRepository) RestoreUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+// fakeDirectory serves users and groups from memory
+type fakeDirectory struct {
+	users  []directory.Entry
+	groups map[string]*directory.Entry
+}
+
+func (d *fakeDirectory) Search(baseDN, filter string, attributes []string) ([]directory.Entry, error) {
+	return d.users, nil
+}
+
+func (d *fakeDirectory) Entry(dn string, attributes []string) (*directory.Entry, error) {
+	if group, ok := d.groups[dn]; ok {
+		return group, nil
+	}
+	return nil, directory.ErrNotFound
+}
+
+func (d *fakeDirectory) Close() error {
+	return nil
+}
+
+func newTestLDAPSyncService(repo *MockLDAPRepository, orgRepo *MockOrganizationRepository, userRepo *MockUserRepository, teamRepo *MockTeamRepository, users *MockUserService, dir *fakeDirectory) *LDAPSyncService {
+	dial := func(directory.Config) (Directory, error) { return dir, nil }
+	return NewLDAPSyncService(repo, orgRepo, userRepo, teamRepo, users, dial, time.Second, nil, nil)
+}
+
+func TestLDAPSyncService_SaveConnection(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	teamID := uuid.New()
+	validInput := func() *LDAPConnectionInput {
+		return &LDAPConnectionInput{
+			URL:        "ldaps://ldap.acme.com",
+			BindDN:     "cn=sync,dc=acme,dc=com",
+			UserBaseDN: "ou=people,dc=acme,dc=com",
+			TeamMapping: []models.LDAPTeamMapping{
+				{Group: "cn=engineering,ou=groups,dc=acme,dc=com", TeamID: teamID},
+			},
+		}
+	}
+
+	newService := func(teamOrg uuid.UUID) (*LDAPSyncService, *MockLDAPRepository) {
+		repo, orgRepo, teamRepo := new(MockLDAPRepository), new(MockOrganizationRepository), new(MockTeamRepository)
+		orgRepo.On("GetByID", orgID).Return(&models.Organization{ID: orgID}, nil)
+		teamRepo.On("GetByID", teamID).Return(&models.Team{ID: teamID, OrganizationID: &teamOrg}, nil)
+		return newTestLDAPSyncService(repo, orgRepo, new(MockUserRepository), teamRepo, new(MockUserService), nil), repo
+	}
+
+	t.Run("saves the connection with defaults", func(t *testing.T) {
+		service, repo := newService(orgID)
+		repo.On("SaveConnection", mock.AnythingOfType("*models.LDAPConnection")).Return(nil)
+
+		input := validInput()
+		input.BindPassword = "secret"
+		conn, err := service.SaveConnection(ctx, orgID, input, uuid.New())
+		require.NoError(t, err)
+		assert.Equal(t, "(objectClass=person)", conn.UserFilter)
+		assert.Equal(t, "mail", conn.EmailAttribute)
+		assert.Equal(t, "uid", conn.UsernameAttribute)
+		assert.Equal(t, "member", conn.MemberAttribute)
+		assert.Equal(t, models.RoleMember, conn.DefaultRole)
+		assert.Equal(t, models.TeamRoleMember, conn.TeamMapping[0].Role)
+		assert.True(t, conn.Enabled)
+	})
+
+	t.Run("keeps the bind password when none is given", func(t *testing.T) {
+		service, repo := newService(orgID)
+		repo.On("GetConnection", orgID).Return(&models.LDAPConnection{OrganizationID: orgID, BindPassword: "secret"}, nil)
+		repo.On("SaveConnection", mock.AnythingOfType("*models.LDAPConnection")).Return(nil)
+
+		conn, err := service.SaveConnection(ctx, orgID, validInput(), uuid.New())
+		require.NoError(t, err)
+		assert.Equal(t, "secret", conn.BindPassword)
+	})
+
+	t.Run("requires a bind password for new connections", func(t *testing.T) {
+		service, repo := newService(orgID)
+		repo.On("GetConnection", orgID).Return(nil, apperrors.NotFound("LDAP connection not found"))
+
+		_, err := service.SaveConnection(ctx, orgID, validInput(), uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Contains(t, apperrors.From(err).Fields, "bind_password")
+		repo.AssertNotCalled(t, "SaveConnection", mock.Anything)
+	})
+
+	t.Run("rejects invalid URLs and filters", func(t *testing.T) {
+		service, repo := newService(orgID)
+
+		input := validInput()
+		input.BindPassword = "secret"
+		input.URL = "https://ldap.acme.com"
+		input.UserFilter = "(objectClass=person"
+		_, err := service.SaveConnection(ctx, orgID, input, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Contains(t, apperrors.From(err).Fields, "url")
+		assert.Contains(t, apperrors.From(err).Fields, "user_filter")
+		repo.AssertNotCalled(t, "SaveConnection", mock.Anything)
+	})
+
+	t.Run("rejects teams of another organization", func(t *testing.T) {
+		service, repo := newService(uuid.New())
+
+		input := validInput()
+		input.BindPassword = "secret"
+		_, err := service.SaveConnection(ctx, orgID, input, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Contains(t, apperrors.From(err).Fields, "team_mapping")
+		repo.AssertNotCalled(t, "SaveConnection", mock.Anything)
+	})
+}
+
+func TestLDAPSyncService_Sync(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	teamID := uuid.New()
+	conn := &models.LDAPConnection{
+		ID:                uuid.New(),
+		OrganizationID:    orgID,
+		UserBaseDN:        "ou=people,dc=acme,dc=com",
+		UserFilter:        "(objectClass=person)",
+		EmailAttribute:    "mail",
+		UsernameAttribute: "uid",
+		MemberAttribute:   "member",
+		RoleMapping:       map[string]models.UserRole{"cn=leads,ou=groups,dc=acme,dc=com": models.RoleManager},
+		DefaultRole:       models.RoleMember,
+		TeamMapping: []models.LDAPTeamMapping{
+			{Group: "cn=engineering,ou=groups,dc=acme,dc=com", TeamID: teamID, Role: models.TeamRoleMember},
+			{Group: "cn=leads,ou=groups,dc=acme,dc=com", TeamID: teamID, Role: models.TeamRoleManager},
+		},
+		Enabled: true,
+	}
+
+	// jane is new, bob is synced and now leads, carol left the directory
+	bob := models.User{ID: uuid.New(), Email: "bob@acme.com", Username: "bob", Role: models.RoleMember, OrganizationID: &orgID}
+	carol := models.User{ID: uuid.New(), Email: "carol@acme.com", Username: "carol", Role: models.RoleMember, OrganizationID: &orgID}
+	links := []models.LDAPUser{
+		{UserID: bob.ID, OrganizationID: orgID, ExternalID: "uid=bob,ou=people,dc=acme,dc=com", User: bob},
+		{UserID: carol.ID, OrganizationID: orgID, ExternalID: "uid=carol,ou=people,dc=acme,dc=com", User: carol},
+	}
+	team := &models.Team{ID: teamID, Name: "Engineering", OrganizationID: &orgID, Memberships: []models.TeamMembership{
+		{TeamID: teamID, UserID: bob.ID, Role: models.TeamRoleMember, User: bob},
+		{TeamID: teamID, UserID: carol.ID, Role: models.TeamRoleMember, User: carol},
+	}}
+	dir := &fakeDirectory{
+		users: []directory.Entry{
+			{DN: "uid=jane,ou=people,dc=acme,dc=com", Attributes: map[string][]string{"mail": {"jane@acme.com"}, "uid": {"jane"}}},
+			{DN: "uid=bob,ou=people,dc=acme,dc=com", Attributes: map[string][]string{"mail": {"bob@acme.com"}, "uid": {"bob"}}},
+			{DN: "uid=nomail,ou=people,dc=acme,dc=com", Attributes: map[string][]string{"uid": {"nomail"}}},
+		},
+		groups: map[string]*directory.Entry{
+			"cn=engineering,ou=groups,dc=acme,dc=com": {Attributes: map[string][]string{"member": {
+				"uid=jane,ou=people,dc=acme,dc=com",
+				"uid=bob,ou=people,dc=acme,dc=com",
+			}}},
+			"cn=leads,ou=groups,dc=acme,dc=com": {Attributes: map[string][]string{"member": {
+				"UID=Bob,OU=People,DC=acme,DC=com",
+			}}},
+		},
+	}
+
+	newService := func(dir *fakeDirectory) (*LDAPSyncService, *MockLDAPRepository, *MockTeamRepository, *MockUserService) {
+		repo, userRepo, teamRepo, users := new(MockLDAPRepository), new(MockUserRepository), new(MockTeamRepository), new(MockUserService)
+		repo.On("GetConnection", orgID).Return(conn, nil)
+		repo.On("GetLinkedUsers", orgID).Return(links, nil)
+		repo.On("GetUserByEmail", "jane@acme.com").Return(nil, apperrors.NotFound("user not found"))
+		userRepo.On("UsernameExists", "jane").Return(false, nil)
+		teamRepo.On("GetByID", teamID).Return(team, nil)
+		return newTestLDAPSyncService(repo, new(MockOrganizationRepository), userRepo, teamRepo, users, dir), repo, teamRepo, users
+	}
+
+	t.Run("a dry run reports the changes without making them", func(t *testing.T) {
+		service, repo, teamRepo, users := newService(dir)
+
+		report, err := service.Sync(ctx, orgID, true, uuid.New())
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		require.Len(t, report.Created, 1)
+		assert.Equal(t, LDAPSyncedUser{Email: "jane@acme.com", Username: "jane", Role: models.RoleMember}, report.Created[0])
+		require.Len(t, report.Updated, 1)
+		assert.Equal(t, []string{"role"}, report.Updated[0].Changes)
+		assert.Equal(t, models.RoleManager, report.Updated[0].Role)
+		require.Len(t, report.Deactivated, 1)
+		assert.Equal(t, "carol@acme.com", report.Deactivated[0].Email)
+		require.Len(t, report.Skipped, 1)
+		assert.Equal(t, "uid=nomail,ou=people,dc=acme,dc=com", report.Skipped[0].DN)
+
+		require.Len(t, report.MembershipsAdded, 1)
+		assert.Equal(t, "jane@acme.com", report.MembershipsAdded[0].Email)
+		assert.Nil(t, report.MembershipsAdded[0].UserID)
+		require.Len(t, report.MembershipsUpdated, 1)
+		assert.Equal(t, models.TeamRoleManager, report.MembershipsUpdated[0].Role)
+		require.Len(t, report.MembershipsRemoved, 1)
+		assert.Equal(t, &carol.ID, report.MembershipsRemoved[0].UserID)
+
+		users.AssertNotCalled(t, "CreateUser", mock.Anything)
+		repo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+		repo.AssertNotCalled(t, "DeactivateUser", mock.Anything)
+		repo.AssertNotCalled(t, "RecordSync", mock.Anything, mock.Anything)
+		teamRepo.AssertNotCalled(t, "AddMembership", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("applies the changes", func(t *testing.T) {
+		service, repo, teamRepo, users := newService(dir)
+		jane := &models.User{ID: uuid.New(), Email: "jane@acme.com", Username: "jane", Role: models.RoleMember, OrganizationID: &orgID}
+		users.On("CreateUser", mock.AnythingOfType("*services.CreateUserInput")).Return(jane, nil)
+		repo.On("LinkUser", mock.AnythingOfType("*models.LDAPUser")).Return(nil)
+		repo.On("UpdateUser", bob.ID, "bob@acme.com", models.RoleManager).Return(nil)
+		repo.On("DeactivateUser", carol.ID).Return(nil)
+		repo.On("RecordSync", conn.ID, "").Return(nil)
+		teamRepo.On("AddMembership", teamID, jane.ID, models.TeamRoleMember).Return(nil)
+		teamRepo.On("SetRole", teamID, bob.ID, models.TeamRoleManager).Return(nil)
+		teamRepo.On("RemoveMembership", teamID, carol.ID).Return(nil)
+
+		report, err := service.Sync(ctx, orgID, false, uuid.New())
+		require.NoError(t, err)
+		assert.Equal(t, &jane.ID, report.Created[0].UserID)
+
+		input := users.Calls[0].Arguments.Get(0).(*CreateUserInput)
+		assert.Equal(t, &orgID, input.OrganizationID)
+		assert.NotEmpty(t, input.Password)
+		repo.AssertCalled(t, "LinkUser", &models.LDAPUser{UserID: jane.ID, OrganizationID: orgID, ExternalID: "uid=jane,ou=people,dc=acme,dc=com"})
+		repo.AssertExpectations(t)
+		teamRepo.AssertExpectations(t)
+	})
+
+	t.Run("reactivates users back in the directory", func(t *testing.T) {
+		deleted := carol
+		deleted.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		service, repo, _, _ := newService(&fakeDirectory{
+			users: []directory.Entry{
+				{DN: "uid=carol,ou=people,dc=acme,dc=com", Attributes: map[string][]string{"mail": {"carol@acme.com"}}},
+			},
+		})
+		repo.ExpectedCalls = nil
+		repo.On("GetConnection", orgID).Return(&models.LDAPConnection{
+			OrganizationID:  orgID,
+			UserBaseDN:      conn.UserBaseDN,
+			EmailAttribute:  "mail",
+			MemberAttribute: "member",
+			DefaultRole:     models.RoleMember,
+		}, nil)
+		repo.On("GetLinkedUsers", orgID).Return([]models.LDAPUser{
+			{UserID: carol.ID, OrganizationID: orgID, ExternalID: "uid=carol,ou=people,dc=acme,dc=com", User: deleted},
+		}, nil)
+
+		report, err := service.Sync(ctx, orgID, true, uuid.New())
+		require.NoError(t, err)
+		require.Len(t, report.Updated, 1)
+		assert.Equal(t, []string{"reactivated"}, report.Updated[0].Changes)
+		assert.Empty(t, report.Deactivated)
+	})
+
+	t.Run("refuses to deactivate everyone when the directory returns no users", func(t *testing.T) {
+		service, repo, _, _ := newService(&fakeDirectory{groups: dir.groups})
+		repo.On("RecordSync", conn.ID, mock.AnythingOfType("string")).Return(nil)
+
+		_, err := service.Sync(ctx, orgID, false, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		repo.AssertNotCalled(t, "DeactivateUser", mock.Anything)
+		repo.AssertCalled(t, "RecordSync", conn.ID, mock.AnythingOfType("string"))
+	})
+
+	t.Run("fails when a mapped group is missing", func(t *testing.T) {
+		service, _, _, _ := newService(&fakeDirectory{users: dir.users})
+
+		_, err := service.Sync(ctx, orgID, true, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+	})
+
+	t.Run("refuses to sync disabled connections", func(t *testing.T) {
+		service, repo, _, _ := newService(dir)
+		repo.ExpectedCalls = nil
+		repo.On("GetConnection", orgID).Return(&models.LDAPConnection{OrganizationID: orgID}, nil)
+
+		_, err := service.Sync(ctx, orgID, false, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	})
+}
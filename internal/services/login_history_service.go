@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/i18n"
+	"seta-training/pkg/logger"
+)
+
+const (
+	DefaultLoginHistoryLimit = 50
+	MaxLoginHistoryLimit     = 200
+
+	// maxUserAgentLength is the size of the user_agent column
+	maxUserAgentLength = 512
+)
+
+// LoginHistoryService records login attempts, tells users about logins from
+// new devices and lists the attempts to users and their team managers
+type LoginHistoryService struct {
+	attemptRepo      repositories.LoginAttemptRepositoryInterface
+	teamRepo         repositories.TeamRepositoryInterface
+	notificationRepo repositories.NotificationRepositoryInterface
+	prefRepo         repositories.UserPreferenceRepositoryInterface
+	messages         *i18n.Bundle
+	logger           logger.Logger
+}
+
+// NewLoginHistoryService creates a login history service. prefRepo,
+// messages and log may be nil.
+func NewLoginHistoryService(attemptRepo repositories.LoginAttemptRepositoryInterface, teamRepo repositories.TeamRepositoryInterface, notificationRepo repositories.NotificationRepositoryInterface, prefRepo repositories.UserPreferenceRepositoryInterface, messages *i18n.Bundle, log logger.Logger) *LoginHistoryService {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &LoginHistoryService{
+		attemptRepo:      attemptRepo,
+		teamRepo:         teamRepo,
+		notificationRepo: notificationRepo,
+		prefRepo:         prefRepo,
+		messages:         messages,
+		logger:           log,
+	}
+}
+
+// RecordLogin stores an attempt to log in with email, made by the client in
+// ctx. user is nil when the email matches no one. A successful login with a
+// user agent the user has not logged in with before notifies them, unless it
+// is their first login. Failures are logged rather than failing the login.
+func (s *LoginHistoryService) RecordLogin(ctx context.Context, email string, user *models.User, outcome models.LoginOutcome) {
+	client := auth.ClientFromContext(ctx)
+	if len(client.UserAgent) > maxUserAgentLength {
+		client.UserAgent = client.UserAgent[:maxUserAgentLength]
+	}
+	attempt := &models.LoginAttempt{
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Outcome:   outcome,
+	}
+	if user != nil {
+		attempt.UserID = &user.ID
+	}
+
+	if user != nil && outcome == models.LoginSucceeded {
+		loggedIn, fromDevice, err := s.attemptRepo.PriorLogins(ctx, user.ID, attempt.UserAgent)
+		if err != nil {
+			s.logger.Warn("Failed to check previous logins", logger.String("user_id", user.ID.String()), logger.Error(err))
+		}
+		attempt.NewDevice = err == nil && loggedIn && !fromDevice
+	}
+
+	if err := s.attemptRepo.Create(ctx, attempt); err != nil {
+		s.logger.Warn("Failed to record login attempt", logger.String("outcome", string(outcome)), logger.Error(err))
+	}
+	if attempt.NewDevice {
+		s.notifyNewDevice(ctx, user.ID, attempt)
+	}
+}
+
+func (s *LoginHistoryService) notifyNewDevice(ctx context.Context, userID uuid.UUID, attempt *models.LoginAttempt) {
+	pref := preferencesOf(ctx, s.prefRepo, userID, s.logger)
+	if !pref.Wants(models.NotificationNewDevice, models.ChannelInApp) {
+		return
+	}
+	device := attempt.UserAgent
+	if device == "" {
+		device = "an unknown device"
+	}
+	localizer := s.messages.Localizer(pref.Locale)
+	notification := &models.Notification{
+		UserID:  userID,
+		Type:    models.NotificationNewDevice,
+		Message: localizer.T("New login to your account from %s at %s", localizer.T(device), attempt.IP),
+	}
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		s.logger.Warn("Failed to create new device notification", logger.String("user_id", userID.String()), logger.Error(err))
+	}
+}
+
+// GetMyHistory lists the latest attempts to log in as userID, newest first,
+// with timestamps in their time zone
+func (s *LoginHistoryService) GetMyHistory(ctx context.Context, userID uuid.UUID, limit int) ([]models.LoginAttempt, error) {
+	return s.history(ctx, []uuid.UUID{userID}, limit, userID)
+}
+
+// GetTeamHistory lists the latest attempts to log in as the people of a
+// team, or only as memberID when not nil. Only the team's admins and
+// managers may see it.
+func (s *LoginHistoryService) GetTeamHistory(ctx context.Context, teamID uuid.UUID, memberID *uuid.UUID, limit int, requesterID uuid.UUID) ([]models.LoginAttempt, error) {
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if role, _ := team.RoleOf(requesterID); !role.Manages() {
+		return nil, apperrors.Forbidden("insufficient permissions: user is not a manager of this team")
+	}
+
+	var userIDs []uuid.UUID
+	for _, person := range team.People() {
+		if memberID == nil || person.ID == *memberID {
+			userIDs = append(userIDs, person.ID)
+		}
+	}
+	if memberID != nil && len(userIDs) == 0 {
+		return nil, apperrors.ValidationFields("Invalid login history query", map[string]string{
+			"member_id": "must be in the team",
+		})
+	}
+	return s.history(ctx, userIDs, limit, requesterID)
+}
+
+// history lists the attempts of userIDs as viewerID sees them
+func (s *LoginHistoryService) history(ctx context.Context, userIDs []uuid.UUID, limit int, viewerID uuid.UUID) ([]models.LoginAttempt, error) {
+	attempts, err := s.attemptRepo.GetByUsers(ctx, userIDs, clampLimit(limit, DefaultLoginHistoryLimit, MaxLoginHistoryLimit))
+	if err != nil {
+		return nil, err
+	}
+	loc, err := userLocation(ctx, s.prefRepo, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attempts {
+		attempts[i].CreatedAt = attempts[i].CreatedAt.In(loc)
+	}
+	return attempts, nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+// MockLoginAttemptRepository is a mock implementation of LoginAttemptRepositoryInterface
+type MockLoginAttemptRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginAttemptRepository) Create(ctx context.Context, attempt *models.LoginAttempt) error {
+	args := m.Called(attempt)
+	return args.Error(0)
+}
+
+func (m *MockLoginAttemptRepository) GetByUsers(ctx context.Context, userIDs []uuid.UUID, limit int) ([]models.LoginAttempt, error) {
+	args := m.Called(userIDs, limit)
+	return args.Get(0).([]models.LoginAttempt), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) CountFailures(ctx context.Context, ip string, since time.Time) (int64, error) {
+	args := m.Called(ip, since)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockLoginAttemptRepository) PriorLogins(ctx context.Context, userID uuid.UUID, userAgent string) (bool, bool, error) {
+	args := m.Called(userID, userAgent)
+	return args.Bool(0), args.Bool(1), args.Error(2)
+}
+
+func TestLoginHistoryService_RecordLogin(t *testing.T) {
+	user := &models.User{ID: uuid.New(), Email: "alice@example.com"}
+	ctx := auth.WithClient(context.Background(), auth.Client{IP: "203.0.113.7", UserAgent: "Firefox"})
+
+	newService := func() (*LoginHistoryService, *MockLoginAttemptRepository, *MockNotificationRepository) {
+		attemptRepo, notificationRepo := new(MockLoginAttemptRepository), new(MockNotificationRepository)
+		attemptRepo.On("Create", mock.AnythingOfType("*models.LoginAttempt")).Return(nil)
+		notificationRepo.On("Create", mock.AnythingOfType("*models.Notification")).Return(nil)
+		return NewLoginHistoryService(attemptRepo, new(MockTeamRepository), notificationRepo, nil, nil, nil), attemptRepo, notificationRepo
+	}
+
+	t.Run("records who tried from where", func(t *testing.T) {
+		service, attemptRepo, notificationRepo := newService()
+
+		service.RecordLogin(ctx, " Alice@Example.com", user, models.LoginInvalidPassword)
+
+		attempt := attemptRepo.Calls[0].Arguments.Get(0).(*models.LoginAttempt)
+		assert.Equal(t, &user.ID, attempt.UserID)
+		assert.Equal(t, "alice@example.com", attempt.Email)
+		assert.Equal(t, "203.0.113.7", attempt.IP)
+		assert.Equal(t, "Firefox", attempt.UserAgent)
+		assert.Equal(t, models.LoginInvalidPassword, attempt.Outcome)
+		attemptRepo.AssertNotCalled(t, "PriorLogins", mock.Anything, mock.Anything)
+		notificationRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("records unknown emails without a user", func(t *testing.T) {
+		service, attemptRepo, _ := newService()
+
+		service.RecordLogin(ctx, "nobody@example.com", nil, models.LoginUnknownEmail)
+
+		attempt := attemptRepo.Calls[0].Arguments.Get(0).(*models.LoginAttempt)
+		assert.Nil(t, attempt.UserID)
+		assert.Equal(t, models.LoginUnknownEmail, attempt.Outcome)
+	})
+
+	t.Run("notifies a login from a new device", func(t *testing.T) {
+		service, attemptRepo, notificationRepo := newService()
+		attemptRepo.On("PriorLogins", user.ID, "Firefox").Return(true, false, nil)
+
+		service.RecordLogin(ctx, user.Email, user, models.LoginSucceeded)
+
+		attempt := attemptRepo.Calls[1].Arguments.Get(0).(*models.LoginAttempt)
+		assert.True(t, attempt.NewDevice)
+		notification := notificationRepo.Calls[0].Arguments.Get(0).(*models.Notification)
+		assert.Equal(t, user.ID, notification.UserID)
+		assert.Equal(t, models.NotificationNewDevice, notification.Type)
+		assert.Equal(t, "New login to your account from Firefox at 203.0.113.7", notification.Message)
+	})
+
+	known := map[string][2]bool{
+		"a known device":  {true, true},
+		"the first login": {false, false},
+	}
+	for name, prior := range known {
+		t.Run("does not notify "+name, func(t *testing.T) {
+			service, attemptRepo, notificationRepo := newService()
+			attemptRepo.On("PriorLogins", user.ID, "Firefox").Return(prior[0], prior[1], nil)
+
+			service.RecordLogin(ctx, user.Email, user, models.LoginSucceeded)
+
+			attempt := attemptRepo.Calls[1].Arguments.Get(0).(*models.LoginAttempt)
+			assert.False(t, attempt.NewDevice)
+			notificationRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+
+	t.Run("still records when the device check fails", func(t *testing.T) {
+		service, attemptRepo, notificationRepo := newService()
+		attemptRepo.On("PriorLogins", user.ID, "Firefox").Return(false, false, errors.New("connection refused"))
+
+		service.RecordLogin(ctx, user.Email, user, models.LoginSucceeded)
+
+		attemptRepo.AssertCalled(t, "Create", mock.AnythingOfType("*models.LoginAttempt"))
+		notificationRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestLoginHistoryService_GetTeamHistory(t *testing.T) {
+	ctx := context.Background()
+	manager, member, outsider := uuid.New(), uuid.New(), uuid.New()
+	team := &models.Team{
+		ID:       uuid.New(),
+		Managers: []models.User{{ID: manager}},
+		Members:  []models.User{{ID: member}},
+	}
+
+	newService := func() (*LoginHistoryService, *MockLoginAttemptRepository) {
+		attemptRepo, teamRepo := new(MockLoginAttemptRepository), new(MockTeamRepository)
+		teamRepo.On("GetByID", team.ID).Return(team, nil)
+		return NewLoginHistoryService(attemptRepo, teamRepo, new(MockNotificationRepository), nil, nil, nil), attemptRepo
+	}
+
+	t.Run("lists the attempts of everyone in the team", func(t *testing.T) {
+		service, attemptRepo := newService()
+		attempts := []models.LoginAttempt{{ID: uuid.New(), UserID: &member}}
+		attemptRepo.On("GetByUsers", []uuid.UUID{manager, member}, DefaultLoginHistoryLimit).Return(attempts, nil)
+
+		got, err := service.GetTeamHistory(ctx, team.ID, nil, 0, manager)
+		require.NoError(t, err)
+		assert.Equal(t, attempts[0].ID, got[0].ID)
+	})
+
+	t.Run("lists one member's attempts", func(t *testing.T) {
+		service, attemptRepo := newService()
+		attemptRepo.On("GetByUsers", []uuid.UUID{member}, MaxLoginHistoryLimit).Return([]models.LoginAttempt{}, nil)
+
+		_, err := service.GetTeamHistory(ctx, team.ID, &member, 1000, manager)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects people outside the team", func(t *testing.T) {
+		service, attemptRepo := newService()
+
+		_, err := service.GetTeamHistory(ctx, team.ID, &outsider, 0, manager)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Contains(t, apperrors.From(err).Fields, "member_id")
+		attemptRepo.AssertNotCalled(t, "GetByUsers", mock.Anything, mock.Anything)
+	})
+
+	t.Run("only team managers may see it", func(t *testing.T) {
+		service, attemptRepo := newService()
+
+		_, err := service.GetTeamHistory(ctx, team.ID, nil, 0, member)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		attemptRepo.AssertNotCalled(t, "GetByUsers", mock.Anything, mock.Anything)
+	})
+}
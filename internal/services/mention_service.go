@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/i18n"
+	"seta-training/pkg/logger"
+)
+
+const (
+	// MaxMentionsPerNote bounds how many distinct users one note can notify
+	MaxMentionsPerNote = 50
+
+	DefaultMentionLimit = 50
+	MaxMentionLimit     = 200
+
+	mentionExcerptRadius = 60
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@])@([A-Za-z0-9][A-Za-z0-9._-]*)`)
+
+// ParseMentions returns the distinct usernames referenced as @username in
+// text, in order of first appearance. Trailing sentence punctuation is not
+// part of the username, and e-mail addresses are not mentions.
+func ParseMentions(text string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := strings.TrimRight(match[1], ".-")
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == MaxMentionsPerNote {
+			break
+		}
+	}
+	return usernames
+}
+
+// MentionView is a mention as shown to the mentioned user
+type MentionView struct {
+	ID        uuid.UUID `json:"id"`
+	NoteID    uuid.UUID `json:"note_id"`
+	NoteTitle string    `json:"note_title"`
+	Excerpt   string    `json:"excerpt"`
+	AuthorID  uuid.UUID `json:"author_id"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MentionService tracks @mentions in notes and notifies mentioned users
+type MentionService struct {
+	mentionRepo      repositories.MentionRepositoryInterface
+	notificationRepo repositories.NotificationRepositoryInterface
+	noteRepo         repositories.NoteRepositoryInterface
+	folderRepo       repositories.FolderRepositoryInterface
+	prefRepo         repositories.UserPreferenceRepositoryInterface
+	messages         *i18n.Bundle
+	logger           logger.Logger
+}
+
+// NewMentionService creates a mention service. prefRepo and messages may be
+// nil, in which case every mention is notified in English and UTC.
+func NewMentionService(mentionRepo repositories.MentionRepositoryInterface, notificationRepo repositories.NotificationRepositoryInterface, noteRepo repositories.NoteRepositoryInterface, folderRepo repositories.FolderRepositoryInterface, prefRepo repositories.UserPreferenceRepositoryInterface, messages *i18n.Bundle, log logger.Logger) *MentionService {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &MentionService{
+		mentionRepo:      mentionRepo,
+		notificationRepo: notificationRepo,
+		noteRepo:         noteRepo,
+		folderRepo:       folderRepo,
+		prefRepo:         prefRepo,
+		messages:         messages,
+		logger:           log,
+	}
+}
+
+// ProcessNoteMentions syncs the mentions stored for note with its body and
+// notifies newly mentioned users who can read it, in their own language
+// and unless they have muted mentions. Failures are logged rather
+// than returned so that they never block saving the note.
+func (s *MentionService) ProcessNoteMentions(ctx context.Context, note *models.Note, authorID uuid.UUID) {
+	if err := s.processNoteMentions(ctx, note, authorID); err != nil {
+		s.logger.Error("Failed to process note mentions",
+			logger.String("note_id", note.ID.String()),
+			logger.Error(err),
+		)
+	}
+}
+
+func (s *MentionService) processNoteMentions(ctx context.Context, note *models.Note, authorID uuid.UUID) error {
+	users, err := s.mentionRepo.ResolveVisibleUsers(ctx, authorID, ParseMentions(note.Body))
+	if err != nil {
+		return fmt.Errorf("failed to resolve mentioned users: %w", err)
+	}
+
+	userIDs := make([]uuid.UUID, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+
+	added, err := s.mentionRepo.ReplaceNoteMentions(ctx, note.ID, authorID, userIDs)
+	if err != nil {
+		return fmt.Errorf("failed to store mentions: %w", err)
+	}
+
+	for _, userID := range added {
+		// Users who cannot open the note are recorded but never told about
+		// it, so the notification cannot leak its title
+		canRead, err := s.canReadNote(ctx, note.ID, note.FolderID, userID)
+		if err != nil {
+			return err
+		}
+		if !canRead {
+			continue
+		}
+		pref := preferencesOf(ctx, s.prefRepo, userID, s.logger)
+		if !pref.Wants(models.NotificationMention, models.ChannelInApp) {
+			continue
+		}
+
+		actorID, noteID := authorID, note.ID
+		notification := &models.Notification{
+			UserID:       userID,
+			Type:         models.NotificationMention,
+			ActorID:      &actorID,
+			ResourceType: "note",
+			ResourceID:   &noteID,
+			Message:      s.messages.Localizer(pref.Locale).T("You were mentioned in %q", note.Title),
+		}
+		if err := s.notificationRepo.Create(ctx, notification); err != nil {
+			return fmt.Errorf("failed to create mention notification: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetUserMentions lists where userID has been mentioned, skipping notes the
+// user can no longer read. Timestamps are in the user's time zone.
+func (s *MentionService) GetUserMentions(ctx context.Context, userID uuid.UUID, limit int) ([]MentionView, error) {
+	mentions, err := s.mentionRepo.GetByMentionedUser(ctx, userID, clampLimit(limit, DefaultMentionLimit, MaxMentionLimit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mentions: %w", err)
+	}
+	loc, err := userLocation(ctx, s.prefRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	views := make([]MentionView, 0, len(mentions))
+	for _, mention := range mentions {
+		canRead, err := s.canReadNote(ctx, mention.NoteID, mention.Note.FolderID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !canRead {
+			continue
+		}
+
+		views = append(views, MentionView{
+			ID:        mention.ID,
+			NoteID:    mention.NoteID,
+			NoteTitle: mention.Note.Title,
+			Excerpt:   mentionExcerpt(mention.Note.Body, "@"+mention.MentionedUser.Username),
+			AuthorID:  mention.AuthorID,
+			Author:    mention.Author.Username,
+			CreatedAt: mention.CreatedAt.In(loc),
+		})
+	}
+	return views, nil
+}
+
+func (s *MentionService) canReadNote(ctx context.Context, noteID, folderID, userID uuid.UUID) (bool, error) {
+	hasAccess, _, err := s.noteRepo.HasAccess(ctx, noteID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check note access: %w", err)
+	}
+	if hasAccess {
+		return true, nil
+	}
+
+	hasAccess, _, err = s.folderRepo.HasAccess(ctx, folderID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check folder access: %w", err)
+	}
+	return hasAccess, nil
+}
+
+// mentionExcerpt returns the text surrounding the first occurrence of token
+func mentionExcerpt(body, token string) string {
+	return excerptAround(body, strings.Index(body, token), len(token), mentionExcerptRadius)
+}
+
+// excerptAround returns up to radius runes either side of the n bytes at
+// byte offset idx of text, or the start of text when idx is negative
+func excerptAround(text string, idx, n, radius int) string {
+	runes := []rune(text)
+	if idx < 0 {
+		if len(runes) > 2*radius {
+			return string(runes[:2*radius]) + "…"
+		}
+		return text
+	}
+
+	pos := len([]rune(text[:idx]))
+	start := max(pos-radius, 0)
+	end := min(pos+len([]rune(text[idx:idx+n]))+radius, len(runes))
+
+	excerpt := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		excerpt = "…" + excerpt
+	}
+	if end < len(runes) {
+		excerpt += "…"
+	}
+	return excerpt
+}
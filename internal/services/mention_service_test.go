@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"start of text", "@alice please review", []string{"alice"}},
+		{"dotted usernames", "cc @john.doe and @jane_smith.", []string{"john.doe", "jane_smith"}},
+		{"duplicates collapse", "@bob, @bob again", []string{"bob"}},
+		{"email is not a mention", "mail bob@example.com", nil},
+		{"bare at sign", "meet @ noon", nil},
+		{"in parentheses", "(thanks @carol)", []string{"carol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseMentions(tt.text))
+		})
+	}
+}
+
+func TestMentionExcerpt(t *testing.T) {
+	assert.Equal(t, "hi @bob", mentionExcerpt("hi @bob", "@bob"))
+
+	long := "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa @bob tail"
+	excerpt := mentionExcerpt(long, "@bob")
+	assert.Contains(t, excerpt, "@bob tail")
+	assert.True(t, len([]rune(excerpt)) < len([]rune(long)))
+	assert.Equal(t, "…", string([]rune(excerpt)[0]))
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+// MaxLinksPerNote bounds how many notes one note's links are stored for
+const MaxLinksPerNote = 100
+
+const uuidPattern = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+
+// noteLinkPattern matches [[note-id]] and Markdown or HTML links whose
+// target path ends in notes/<note-id>, such as [plan](/api/v1/notes/<id>)
+var noteLinkPattern = regexp.MustCompile(`\[\[(` + uuidPattern + `)\]\]|(?:\]\(|href=")[^()"\s]*notes/(` + uuidPattern + `)[)"?#]`)
+
+// ParseNoteLinks returns the distinct notes text links to, in order of
+// first appearance
+func ParseNoteLinks(text string) []uuid.UUID {
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, match := range noteLinkPattern.FindAllStringSubmatch(text, -1) {
+		id, err := uuid.Parse(match[1] + match[2])
+		if err != nil || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+		if len(ids) == MaxLinksPerNote {
+			break
+		}
+	}
+	return ids
+}
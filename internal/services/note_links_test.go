@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNoteLinks(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name string
+		text string
+		want []uuid.UUID
+	}{
+		{"wiki link", "see [[" + a.String() + "]]", []uuid.UUID{a}},
+		{"markdown link", "[plan](/api/v1/notes/" + a.String() + ") and [[" + b.String() + "]]", []uuid.UUID{a, b}},
+		{"absolute URL with anchor", "[x](https://app.example.com/notes/" + b.String() + "#intro)", []uuid.UUID{b}},
+		{"HTML link", `<a href="/notes/` + a.String() + `">plan</a>`, []uuid.UUID{a}},
+		{"duplicates collapse", "[[" + a.String() + "]] [[" + a.String() + "]]", []uuid.UUID{a}},
+		{"bare ID is not a link", "id " + a.String(), nil},
+		{"other paths are not links", "[x](/folders/" + a.String() + ")", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseNoteLinks(tt.text))
+		})
+	}
+}
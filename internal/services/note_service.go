@@ -1,29 +1,64 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"fmt"
 
 	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/database"
 	"seta-training/internal/models"
 	"seta-training/internal/repositories"
+	"seta-training/pkg/metrics"
+	"seta-training/pkg/pagination"
 )
 
 type NoteService struct {
 	noteRepo   repositories.NoteRepositoryInterface
 	folderRepo repositories.FolderRepositoryInterface
+	teamRepo   repositories.TeamRepositoryInterface
+	tx         repositories.TransactionManager
+	mentions   NoteMentionProcessor
+	quota      QuotaChecker
+	sanitizer  *NoteSanitizer
+	audit      audit.Recorder
+	metrics    *metrics.Metrics
 }
 
-func NewNoteService(noteRepo repositories.NoteRepositoryInterface, folderRepo repositories.FolderRepositoryInterface) *NoteService {
+// NewNoteService creates a note service. teamRepo may be nil to ignore team
+// sharing policies, txManager may be nil to write through the given
+// repositories without a transaction, mentions may be nil to disable
+// @mention processing, quota may be nil to disable quotas, auditor may be
+// nil to disable audit logging and m may be nil to use a private registry.
+func NewNoteService(noteRepo repositories.NoteRepositoryInterface, folderRepo repositories.FolderRepositoryInterface, teamRepo repositories.TeamRepositoryInterface, txManager repositories.TransactionManager, mentions NoteMentionProcessor, quota QuotaChecker, auditor audit.Recorder, m *metrics.Metrics) *NoteService {
+	if txManager == nil {
+		txManager = repositories.NoTx{Stores: repositories.Stores{Folders: folderRepo, Notes: noteRepo}}
+	}
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
 	return &NoteService{
 		noteRepo:   noteRepo,
 		folderRepo: folderRepo,
+		teamRepo:   teamRepo,
+		tx:         txManager,
+		mentions:   mentions,
+		quota:      quota,
+		sanitizer:  NewNoteSanitizer(),
+		audit:      auditor,
+		metrics:    m,
 	}
 }
 
 type CreateNoteInput struct {
 	Title string `json:"title" binding:"required,min=1,max=200"`
 	Body  string `json:"body"`
+	// Status puts the note in the publishing workflow; empty leaves it out
+	Status models.NoteStatus `json:"status" binding:"omitempty,oneof=draft in_review published archived"`
 }
 
 type UpdateNoteInput struct {
@@ -31,19 +66,31 @@ type UpdateNoteInput struct {
 	Body  string `json:"body"`
 }
 
+type NoteStatusInput struct {
+	Status models.NoteStatus `json:"status" binding:"required,oneof=draft in_review published archived"`
+}
+
+// ShareNoteInput shares a note with one user, given by UserID and Access,
+// or with several at once, given as Shares
 type ShareNoteInput struct {
-	UserID uuid.UUID          `json:"userId" binding:"required"`
-	Access models.AccessLevel `json:"access" binding:"required,oneof=read write"`
+	UserID uuid.UUID          `json:"userId"`
+	Access models.AccessLevel `json:"access" binding:"omitempty,oneof=read write"`
+	Shares []ShareEntry       `json:"shares" binding:"omitempty,max=100,dive"`
 }
 
-func (s *NoteService) CreateNote(folderID uuid.UUID, input *CreateNoteInput, userID uuid.UUID) (*models.Note, error) {
+func (s *NoteService) CreateNote(ctx context.Context, folderID uuid.UUID, input *CreateNoteInput, userID uuid.UUID) (*models.Note, error) {
 	// Check if user has write access to the folder
-	hasAccess, access, err := s.folderRepo.HasAccess(folderID, userID)
+	hasAccess, access, err := s.folderRepo.HasAccess(ctx, folderID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check folder access: %w", err)
 	}
 	if !hasAccess || access != models.AccessWrite {
-		return nil, errors.New("write access to folder required")
+		return nil, apperrors.Forbidden("write access to folder required")
+	}
+	if s.quota != nil {
+		if err := s.quota.CheckNote(ctx, userID); err != nil {
+			return nil, err
+		}
 	}
 
 	note := &models.Note{
@@ -51,105 +98,317 @@ func (s *NoteService) CreateNote(folderID uuid.UUID, input *CreateNoteInput, use
 		Body:     input.Body,
 		FolderID: folderID,
 		OwnerID:  userID,
+		Status:   input.Status,
 	}
+	s.sanitizer.Note(note)
 
-	if err := s.noteRepo.Create(note); err != nil {
+	actorCtx := database.WithActor(ctx, userID)
+	err = s.tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		if err := stores.Notes.Create(actorCtx, note); err != nil {
+			return err
+		}
+		if links := ParseNoteLinks(note.Body); len(links) > 0 {
+			return stores.Notes.ReplaceLinks(actorCtx, note.ID, links)
+		}
+		return nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to create note: %w", err)
 	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetNote,
+		TargetID:   note.ID,
+		Details:    map[string]string{"folder_id": folderID.String()},
+	})
+	s.metrics.RecordNoteCreated()
+	s.processMentions(ctx, note, userID)
 
-	return s.noteRepo.GetByID(note.ID)
+	return s.noteRepo.GetByID(ctx, note.ID)
 }
 
-func (s *NoteService) GetNote(noteID, userID uuid.UUID) (*models.Note, error) {
+func (s *NoteService) GetNote(ctx context.Context, noteID, userID uuid.UUID) (*models.Note, error) {
 	// Check if user has access to the note
-	hasAccess, _, err := s.noteRepo.HasAccess(noteID, userID)
+	hasAccess, _, err := s.noteRepo.HasAccess(ctx, noteID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check access: %w", err)
 	}
 	if !hasAccess {
-		return nil, errors.New("access denied")
+		return nil, apperrors.Forbidden("access denied")
 	}
 
-	return s.noteRepo.GetByID(noteID)
+	note, err := s.noteRepo.GetByID(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	s.sanitizer.Note(note)
+	return note, nil
 }
 
-func (s *NoteService) UpdateNote(noteID uuid.UUID, input *UpdateNoteInput, userID uuid.UUID) (*models.Note, error) {
+func (s *NoteService) UpdateNote(ctx context.Context, noteID uuid.UUID, input *UpdateNoteInput, userID uuid.UUID) (*models.Note, error) {
 	// Check if user has write access
-	hasAccess, access, err := s.noteRepo.HasAccess(noteID, userID)
+	hasAccess, access, err := s.noteRepo.HasAccess(ctx, noteID, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check access: %w", err)
 	}
 	if !hasAccess || access != models.AccessWrite {
-		return nil, errors.New("write access required")
+		return nil, apperrors.Forbidden("write access required")
 	}
 
-	note, err := s.noteRepo.GetByID(noteID)
+	note, err := s.noteRepo.GetByID(ctx, noteID)
 	if err != nil {
 		return nil, err
 	}
 
 	note.Title = input.Title
 	note.Body = input.Body
-	if err := s.noteRepo.Update(note); err != nil {
+	s.sanitizer.Note(note)
+	actorCtx := database.WithActor(ctx, userID)
+	err = s.tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		if err := stores.Notes.Update(actorCtx, note); err != nil {
+			return err
+		}
+		return stores.Notes.ReplaceLinks(actorCtx, note.ID, ParseNoteLinks(note.Body))
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to update note: %w", err)
 	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetNote,
+		TargetID:   note.ID,
+	})
+	s.processMentions(ctx, note, userID)
+
+	return note, nil
+}
+
+// ChangeStatus moves a note along the publishing workflow. It takes write
+// access, and the owner and the users the note is shared with are notified.
+func (s *NoteService) ChangeStatus(ctx context.Context, noteID uuid.UUID, input *NoteStatusInput, userID uuid.UUID) (*models.Note, error) {
+	hasAccess, access, err := s.noteRepo.HasAccess(ctx, noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+	if !hasAccess || access != models.AccessWrite {
+		return nil, apperrors.Forbidden("write access required")
+	}
 
+	note, err := s.noteRepo.GetByID(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	from := note.Status
+	if from != input.Status {
+		if !from.CanBecome(input.Status) {
+			return nil, apperrors.Validation("a %s note cannot become %s", from, input.Status)
+		}
+		if err := s.noteRepo.ChangeStatus(database.WithActor(ctx, userID), noteID, from, input.Status, userID); err != nil {
+			return nil, err
+		}
+		note.Status = input.Status
+		s.audit.Record(ctx, audit.Entry{
+			ActorID:    userID,
+			Action:     audit.ActionUpdate,
+			TargetType: audit.TargetNote,
+			TargetID:   noteID,
+			Details:    map[string]string{"status": string(input.Status), "previous_status": string(from)},
+		})
+	}
+	s.sanitizer.Note(note)
 	return note, nil
 }
 
-func (s *NoteService) DeleteNote(noteID, userID uuid.UUID) error {
+func (s *NoteService) DeleteNote(ctx context.Context, noteID, userID uuid.UUID) error {
 	// Only owner can delete note
-	note, err := s.noteRepo.GetByID(noteID)
+	note, err := s.noteRepo.GetByID(ctx, noteID)
 	if err != nil {
 		return err
 	}
 	if note.OwnerID != userID {
-		return errors.New("only owner can delete note")
+		return apperrors.Forbidden("only owner can delete note")
 	}
 
-	return s.noteRepo.Delete(noteID)
+	if err := s.noteRepo.Delete(ctx, noteID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetNote,
+		TargetID:   noteID,
+		Details:    map[string]string{"folder_id": note.FolderID.String()},
+	})
+	return nil
 }
 
-func (s *NoteService) ShareNote(noteID uuid.UUID, input *ShareNoteInput, ownerID uuid.UUID) error {
+// ShareNote shares a note with every user the input names, all or none
+func (s *NoteService) ShareNote(ctx context.Context, noteID uuid.UUID, input *ShareNoteInput, ownerID uuid.UUID) ([]ShareResult, error) {
+	entries, err := shareEntries(input.UserID, input.Access, input.Shares)
+	if err != nil {
+		return nil, err
+	}
+
 	// Only owner can share note
-	note, err := s.noteRepo.GetByID(noteID)
+	note, err := s.noteRepo.GetByID(ctx, noteID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if note.OwnerID != ownerID {
-		return errors.New("only owner can share note")
+		return nil, apperrors.Forbidden("only owner can share note")
+	}
+
+	policy, err := sharingPolicyOf(ctx, s.teamRepo, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err = withDefaultAccess(entries, len(input.Shares) > 0, policy.defaultAccess)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.noteRepo.ShareNote(noteID, input.UserID, input.Access)
+	results, err := shareAll(ctx, s.tx, ownerID, entries, len(input.Shares) > 0, func(ctx context.Context, stores repositories.Stores, entry ShareEntry) error {
+		if err := policy.check(ctx, entry.UserID); err != nil {
+			return err
+		}
+		return stores.Notes.ShareNote(ctx, noteID, entry.UserID, entry.Access)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		s.metrics.RecordShareGranted("note", string(entry.Access))
+		s.audit.Record(ctx, audit.Entry{
+			ActorID:    ownerID,
+			Action:     audit.ActionShare,
+			TargetType: audit.TargetNote,
+			TargetID:   noteID,
+			Details:    shareDetails(entry.UserID, entry.Access),
+		})
+	}
+	return results, nil
 }
 
-func (s *NoteService) RevokeShare(noteID, targetUserID, ownerID uuid.UUID) error {
+func (s *NoteService) RevokeShare(ctx context.Context, noteID, targetUserID, ownerID uuid.UUID) error {
 	// Only owner can revoke sharing
-	note, err := s.noteRepo.GetByID(noteID)
+	note, err := s.noteRepo.GetByID(ctx, noteID)
 	if err != nil {
 		return err
 	}
 	if note.OwnerID != ownerID {
-		return errors.New("only owner can revoke sharing")
+		return apperrors.Forbidden("only owner can revoke sharing")
+	}
+
+	if err := s.noteRepo.RevokeShare(ctx, noteID, targetUserID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    ownerID,
+		Action:     audit.ActionRevokeShare,
+		TargetType: audit.TargetNote,
+		TargetID:   noteID,
+		Details:    map[string]string{"user_id": targetUserID.String()},
+	})
+	return nil
+}
+
+// GetBacklinks lists the notes linking to a note that the user can read
+func (s *NoteService) GetBacklinks(ctx context.Context, noteID, userID uuid.UUID) ([]models.Backlink, error) {
+	hasAccess, _, err := s.noteRepo.HasAccess(ctx, noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check access: %w", err)
+	}
+	if !hasAccess {
+		return nil, apperrors.Forbidden("access denied")
+	}
+
+	backlinks, err := s.noteRepo.GetBacklinks(ctx, noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backlinks: %w", err)
+	}
+	for i := range backlinks {
+		backlinks[i].Title = s.sanitizer.Title(backlinks[i].Title)
+	}
+	return backlinks, nil
+}
+
+// ListShares returns one page of the users a note is shared with. Only the
+// owner may list them.
+func (s *NoteService) ListShares(ctx context.Context, noteID, ownerID uuid.UUID, p pagination.Params) (pagination.Page[models.NoteShare], error) {
+	note, err := s.noteRepo.GetByID(ctx, noteID)
+	if err != nil {
+		return pagination.Page[models.NoteShare]{}, err
+	}
+	if note.OwnerID != ownerID {
+		return pagination.Page[models.NoteShare]{}, apperrors.Forbidden("only owner can list shares")
 	}
+	return s.noteRepo.ListShares(ctx, noteID, p)
+}
 
-	return s.noteRepo.RevokeShare(noteID, targetUserID)
+// ListOwnedNotes returns one page of the notes userID owns that match filter
+func (s *NoteService) ListOwnedNotes(ctx context.Context, userID uuid.UUID, filter models.NoteFilter, p pagination.Params) (pagination.Page[models.Note], error) {
+	page, err := s.noteRepo.ListByOwner(ctx, userID, filter, p)
+	if err != nil {
+		return page, fmt.Errorf("failed to get owned notes: %w", err)
+	}
+	s.sanitizer.Notes(page.Items)
+	return page, nil
 }
 
-func (s *NoteService) GetUserNotes(userID uuid.UUID) ([]models.Note, error) {
+func (s *NoteService) GetUserNotes(ctx context.Context, userID uuid.UUID) ([]models.Note, error) {
 	// Get owned notes
-	ownedNotes, err := s.noteRepo.GetByOwner(userID)
+	ownedNotes, err := s.noteRepo.GetByOwner(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get owned notes: %w", err)
 	}
 
 	// Get shared notes
-	sharedNotes, err := s.noteRepo.GetSharedNotes(userID)
+	sharedNotes, err := s.noteRepo.GetSharedNotes(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get shared notes: %w", err)
 	}
 
 	// Combine and return
 	allNotes := append(ownedNotes, sharedNotes...)
+	s.sanitizer.Notes(allNotes)
 	return allNotes, nil
 }
+
+// GetNotesByUsers returns the notes each of userIDs owns or has been
+// shared, keyed by user. It runs the same queries however many users are
+// asked for.
+func (s *NoteService) GetNotesByUsers(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID][]models.Note, error) {
+	owned, err := s.noteRepo.GetNotesByOwners(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get owned notes: %w", err)
+	}
+	shares, err := s.noteRepo.GetSharesByUsers(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shared notes: %w", err)
+	}
+
+	byUser := make(map[uuid.UUID][]models.Note, len(userIDs))
+	for _, note := range owned {
+		byUser[note.OwnerID] = append(byUser[note.OwnerID], note)
+	}
+	for _, share := range shares {
+		byUser[share.UserID] = append(byUser[share.UserID], share.Note)
+	}
+	for userID := range byUser {
+		s.sanitizer.Notes(byUser[userID])
+	}
+	return byUser, nil
+}
+
+// shareDetails describes a folder or note share in the audit log
+func shareDetails(userID uuid.UUID, access models.AccessLevel) map[string]string {
+	return map[string]string{"user_id": userID.String(), "access": string(access)}
+}
+
+func (s *NoteService) processMentions(ctx context.Context, note *models.Note, authorID uuid.UUID) {
+	if s.mentions != nil {
+		s.mentions.ProcessNoteMentions(ctx, note, authorID)
+	}
+}
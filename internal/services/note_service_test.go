@@ -0,0 +1,487 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/pagination"
+)
+
+// MockNoteRepository is a mock implementation of NoteRepositoryInterface
+type MockNoteRepository struct {
+	mock.Mock
+}
+
+func (m *MockNoteRepository) Create(ctx context.Context, note *models.Note) error {
+	args := m.Called(note)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Note, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Note, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]models.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) GetByFolder(ctx context.Context, folderID uuid.UUID) ([]models.Note, error) {
+	args := m.Called(folderID)
+	return args.Get(0).([]models.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) Update(ctx context.Context, note *models.Note) error {
+	args := m.Called(note)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) ShareNote(ctx context.Context, noteID, userID uuid.UUID, access models.AccessLevel) error {
+	args := m.Called(noteID, userID, access)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) ListByOwner(ctx context.Context, ownerID uuid.UUID, filter models.NoteFilter, p pagination.Params) (pagination.Page[models.Note], error) {
+	args := m.Called(ownerID, filter, p)
+	return args.Get(0).(pagination.Page[models.Note]), args.Error(1)
+}
+
+func (m *MockNoteRepository) ReplaceLinks(ctx context.Context, sourceID uuid.UUID, targetIDs []uuid.UUID) error {
+	args := m.Called(sourceID, targetIDs)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) GetBacklinks(ctx context.Context, targetID, userID uuid.UUID) ([]models.Backlink, error) {
+	args := m.Called(targetID, userID)
+	return args.Get(0).([]models.Backlink), args.Error(1)
+}
+
+func (m *MockNoteRepository) ChangeStatus(ctx context.Context, noteID uuid.UUID, from, to models.NoteStatus, changedBy uuid.UUID) error {
+	args := m.Called(noteID, from, to, changedBy)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) ListShares(ctx context.Context, noteID uuid.UUID, p pagination.Params) (pagination.Page[models.NoteShare], error) {
+	args := m.Called(noteID, p)
+	return args.Get(0).(pagination.Page[models.NoteShare]), args.Error(1)
+}
+
+func (m *MockNoteRepository) RevokeShare(ctx context.Context, noteID, userID uuid.UUID) error {
+	args := m.Called(noteID, userID)
+	return args.Error(0)
+}
+
+func (m *MockNoteRepository) HasAccess(ctx context.Context, noteID, userID uuid.UUID) (bool, models.AccessLevel, error) {
+	args := m.Called(noteID, userID)
+	return args.Bool(0), args.Get(1).(models.AccessLevel), args.Error(2)
+}
+
+func (m *MockNoteRepository) GetSharedNotes(ctx context.Context, userID uuid.UUID) ([]models.Note, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) GetNotesByOwners(ctx context.Context, ownerIDs []uuid.UUID) ([]models.Note, error) {
+	args := m.Called(ownerIDs)
+	return args.Get(0).([]models.Note), args.Error(1)
+}
+
+func (m *MockNoteRepository) GetSharesByUsers(ctx context.Context, userIDs []uuid.UUID) ([]models.NoteShare, error) {
+	args := m.Called(userIDs)
+	return args.Get(0).([]models.NoteShare), args.Error(1)
+}
+
+// MockFolderRepository is a mock implementation of FolderRepositoryInterface
+type MockFolderRepository struct {
+	mock.Mock
+}
+
+func (m *MockFolderRepository) Create(ctx context.Context, folder *models.Folder) error {
+	args := m.Called(folder)
+	return args.Error(0)
+}
+
+func (m *MockFolderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Folder, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Folder), args.Error(1)
+}
+
+func (m *MockFolderRepository) GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Folder, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]models.Folder), args.Error(1)
+}
+
+func (m *MockFolderRepository) Update(ctx context.Context, folder *models.Folder) error {
+	args := m.Called(folder)
+	return args.Error(0)
+}
+
+func (m *MockFolderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockFolderRepository) DeleteWithContents(ctx context.Context, id uuid.UUID) (int64, error) {
+	args := m.Called(id)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockFolderRepository) Duplicate(ctx context.Context, sourceID uuid.UUID, folder *models.Folder, withShares bool, progress func(copied, total int)) (int, error) {
+	args := m.Called(sourceID, folder, withShares)
+	// Report every note copied in one batch
+	if progress != nil && args.Error(1) == nil {
+		progress(args.Int(0), args.Int(0))
+	}
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockFolderRepository) Merge(ctx context.Context, sourceID, targetID uuid.UUID, titles map[uuid.UUID]string) (int64, error) {
+	args := m.Called(sourceID, targetID, titles)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockFolderRepository) ShareFolder(ctx context.Context, folderID, userID uuid.UUID, access models.AccessLevel) error {
+	args := m.Called(folderID, userID, access)
+	return args.Error(0)
+}
+
+func (m *MockFolderRepository) ListShares(ctx context.Context, folderID uuid.UUID, p pagination.Params) (pagination.Page[models.FolderShare], error) {
+	args := m.Called(folderID, p)
+	return args.Get(0).(pagination.Page[models.FolderShare]), args.Error(1)
+}
+
+func (m *MockFolderRepository) RevokeShare(ctx context.Context, folderID, userID uuid.UUID) error {
+	args := m.Called(folderID, userID)
+	return args.Error(0)
+}
+
+func (m *MockFolderRepository) HasAccess(ctx context.Context, folderID, userID uuid.UUID) (bool, models.AccessLevel, error) {
+	args := m.Called(folderID, userID)
+	return args.Bool(0), args.Get(1).(models.AccessLevel), args.Error(2)
+}
+
+func (m *MockFolderRepository) GetSharedFolders(ctx context.Context, userID uuid.UUID) ([]models.Folder, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Folder), args.Error(1)
+}
+
+func (m *MockFolderRepository) GetFoldersByOwners(ctx context.Context, ownerIDs []uuid.UUID) ([]models.Folder, error) {
+	args := m.Called(ownerIDs)
+	return args.Get(0).([]models.Folder), args.Error(1)
+}
+
+func (m *MockFolderRepository) GetSharesByUsers(ctx context.Context, userIDs []uuid.UUID) ([]models.FolderShare, error) {
+	args := m.Called(userIDs)
+	return args.Get(0).([]models.FolderShare), args.Error(1)
+}
+
+// MockAuditRecorder is a mock implementation of audit.Recorder
+type MockAuditRecorder struct {
+	mock.Mock
+}
+
+func (m *MockAuditRecorder) Record(ctx context.Context, entry audit.Entry) {
+	m.Called(entry)
+}
+
+func TestNoteService_CreateNote_SanitizesContent(t *testing.T) {
+	// Setup
+	noteRepo := new(MockNoteRepository)
+	folderRepo := new(MockFolderRepository)
+	service := NewNoteService(noteRepo, folderRepo, nil, nil, nil, nil, nil, nil)
+
+	folderID := uuid.New()
+	userID := uuid.New()
+	folderRepo.On("HasAccess", folderID, userID).Return(true, models.AccessWrite, nil)
+
+	var stored *models.Note
+	noteRepo.On("Create", mock.AnythingOfType("*models.Note")).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*models.Note)
+	}).Return(nil)
+	noteRepo.On("GetByID", mock.Anything).Return(&models.Note{}, nil)
+
+	// Test
+	_, err := service.CreateNote(context.Background(), folderID, &CreateNoteInput{
+		Title: `Plan <img src=x onerror="alert(1)">`,
+		Body:  `<p onclick="steal()">Hi <b>team</b></p><script>alert(1)</script><a href="javascript:alert(1)">link</a>`,
+	}, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Plan ", stored.Title)
+	assert.Equal(t, "<p>Hi <b>team</b></p>link", stored.Body)
+	noteRepo.AssertExpectations(t)
+}
+
+func TestNoteService_UpdateNote_RecordsActor(t *testing.T) {
+	// Setup
+	noteRepo := new(MockNoteRepository)
+	tx := &recordingTx{stores: repositories.Stores{Notes: noteRepo}}
+	service := NewNoteService(noteRepo, new(MockFolderRepository), nil, tx, nil, nil, nil, nil)
+
+	noteID := uuid.New()
+	userID := uuid.New()
+	noteRepo.On("HasAccess", noteID, userID).Return(true, models.AccessWrite, nil)
+	noteRepo.On("GetByID", noteID).Return(&models.Note{ID: noteID, OwnerID: uuid.New()}, nil)
+	noteRepo.On("Update", mock.AnythingOfType("*models.Note")).Return(nil)
+	noteRepo.On("ReplaceLinks", noteID, []uuid.UUID(nil)).Return(nil)
+
+	// Test
+	_, err := service.UpdateNote(context.Background(), noteID, &UpdateNoteInput{Title: "Plan"}, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, tx.committed)
+	actor, ok := database.ActorFromContext(tx.ctx)
+	assert.True(t, ok)
+	assert.Equal(t, userID, actor)
+	noteRepo.AssertExpectations(t)
+}
+
+func TestNoteService_ChangeStatus(t *testing.T) {
+	noteID, userID := uuid.New(), uuid.New()
+
+	newService := func(status models.NoteStatus) (*NoteService, *MockNoteRepository, *MockAuditRecorder) {
+		noteRepo := new(MockNoteRepository)
+		noteRepo.On("HasAccess", noteID, userID).Return(true, models.AccessWrite, nil)
+		noteRepo.On("GetByID", noteID).Return(&models.Note{ID: noteID, OwnerID: uuid.New(), Status: status}, nil)
+		recorder := new(MockAuditRecorder)
+		recorder.On("Record", mock.AnythingOfType("audit.Entry")).Return()
+		return NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, recorder, nil), noteRepo, recorder
+	}
+
+	t.Run("follows an allowed transition", func(t *testing.T) {
+		service, noteRepo, recorder := newService(models.NoteStatusDraft)
+		noteRepo.On("ChangeStatus", noteID, models.NoteStatusDraft, models.NoteStatusInReview, userID).Return(nil)
+
+		note, err := service.ChangeStatus(context.Background(), noteID, &NoteStatusInput{Status: models.NoteStatusInReview}, userID)
+		require.NoError(t, err)
+		assert.Equal(t, models.NoteStatusInReview, note.Status)
+		noteRepo.AssertExpectations(t)
+		recorder.AssertCalled(t, "Record", mock.MatchedBy(func(entry audit.Entry) bool {
+			return entry.Details["status"] == "in_review" && entry.Details["previous_status"] == "draft"
+		}))
+	})
+
+	t.Run("lets notes without a status enter anywhere", func(t *testing.T) {
+		service, noteRepo, _ := newService("")
+		noteRepo.On("ChangeStatus", noteID, models.NoteStatus(""), models.NoteStatusPublished, userID).Return(nil)
+
+		_, err := service.ChangeStatus(context.Background(), noteID, &NoteStatusInput{Status: models.NoteStatusPublished}, userID)
+		require.NoError(t, err)
+		noteRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a forbidden transition", func(t *testing.T) {
+		service, noteRepo, _ := newService(models.NoteStatusArchived)
+
+		_, err := service.ChangeStatus(context.Background(), noteID, &NoteStatusInput{Status: models.NoteStatusPublished}, userID)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		noteRepo.AssertNotCalled(t, "ChangeStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("does nothing when the status is unchanged", func(t *testing.T) {
+		service, noteRepo, recorder := newService(models.NoteStatusPublished)
+
+		_, err := service.ChangeStatus(context.Background(), noteID, &NoteStatusInput{Status: models.NoteStatusPublished}, userID)
+		require.NoError(t, err)
+		noteRepo.AssertNotCalled(t, "ChangeStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		recorder.AssertNotCalled(t, "Record", mock.Anything)
+	})
+}
+
+func TestNoteService_UpdateNote_StoresLinks(t *testing.T) {
+	noteRepo := new(MockNoteRepository)
+	service := NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, nil, nil)
+
+	noteID, userID, linked := uuid.New(), uuid.New(), uuid.New()
+	noteRepo.On("HasAccess", noteID, userID).Return(true, models.AccessWrite, nil)
+	noteRepo.On("GetByID", noteID).Return(&models.Note{ID: noteID, OwnerID: userID}, nil)
+	noteRepo.On("Update", mock.AnythingOfType("*models.Note")).Return(nil)
+	noteRepo.On("ReplaceLinks", noteID, []uuid.UUID{linked}).Return(nil)
+
+	_, err := service.UpdateNote(context.Background(), noteID, &UpdateNoteInput{Title: "Plan", Body: "Follows [[" + linked.String() + "]]"}, userID)
+	require.NoError(t, err)
+	noteRepo.AssertExpectations(t)
+}
+
+func TestNoteService_GetBacklinks_RequiresAccess(t *testing.T) {
+	noteRepo := new(MockNoteRepository)
+	service := NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, nil, nil)
+
+	noteID, userID := uuid.New(), uuid.New()
+	noteRepo.On("HasAccess", noteID, userID).Return(false, models.AccessLevel(""), nil)
+
+	_, err := service.GetBacklinks(context.Background(), noteID, userID)
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	noteRepo.AssertNotCalled(t, "GetBacklinks", mock.Anything, mock.Anything)
+}
+
+func TestNoteService_GetNote_SanitizesStoredContent(t *testing.T) {
+	// Setup
+	noteRepo := new(MockNoteRepository)
+	service := NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, nil, nil)
+
+	noteID := uuid.New()
+	userID := uuid.New()
+	noteRepo.On("HasAccess", noteID, userID).Return(true, models.AccessRead, nil)
+	noteRepo.On("GetByID", noteID).Return(&models.Note{
+		ID:    noteID,
+		Title: "Legacy",
+		Body:  `<iframe src="https://evil.example"></iframe>Kept &amp; escaped`,
+	}, nil)
+
+	// Test
+	note, err := service.GetNote(context.Background(), noteID, userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "Kept &amp; escaped", note.Body)
+	noteRepo.AssertExpectations(t)
+}
+
+func TestNoteService_ShareNote_RecordsAudit(t *testing.T) {
+	// Setup
+	noteRepo := new(MockNoteRepository)
+	recorder := new(MockAuditRecorder)
+	service := NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, recorder, nil)
+
+	noteID := uuid.New()
+	ownerID := uuid.New()
+	targetID := uuid.New()
+	noteRepo.On("GetByID", noteID).Return(&models.Note{ID: noteID, OwnerID: ownerID}, nil)
+	noteRepo.On("ShareNote", noteID, targetID, models.AccessRead).Return(nil)
+	recorder.On("Record", audit.Entry{
+		ActorID:    ownerID,
+		Action:     audit.ActionShare,
+		TargetType: audit.TargetNote,
+		TargetID:   noteID,
+		Details:    map[string]string{"user_id": targetID.String(), "access": "read"},
+	}).Once()
+
+	// Test
+	_, err := service.ShareNote(context.Background(), noteID, &ShareNoteInput{UserID: targetID, Access: models.AccessRead}, ownerID)
+
+	// Assert
+	assert.NoError(t, err)
+	noteRepo.AssertExpectations(t)
+	recorder.AssertExpectations(t)
+}
+
+func TestNoteService_ShareNote_NotOwnerIsNotAudited(t *testing.T) {
+	// Setup
+	noteRepo := new(MockNoteRepository)
+	recorder := new(MockAuditRecorder)
+	service := NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, recorder, nil)
+
+	noteID := uuid.New()
+	noteRepo.On("GetByID", noteID).Return(&models.Note{ID: noteID, OwnerID: uuid.New()}, nil)
+
+	// Test
+	_, err := service.ShareNote(context.Background(), noteID, &ShareNoteInput{UserID: uuid.New(), Access: models.AccessRead}, uuid.New())
+
+	// Assert
+	assert.Error(t, err)
+	recorder.AssertNotCalled(t, "Record", mock.Anything)
+}
+
+func TestNoteService_GetNotesByUsers_GroupsOwnedAndShared(t *testing.T) {
+	// Setup
+	noteRepo := new(MockNoteRepository)
+	service := NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, nil, nil)
+
+	alice, bob := uuid.New(), uuid.New()
+	userIDs := []uuid.UUID{alice, bob}
+	aliceNote := models.Note{ID: uuid.New(), OwnerID: alice, Title: "Mine", Body: "<script>x</script>ok"}
+	noteRepo.On("GetNotesByOwners", userIDs).Return([]models.Note{aliceNote}, nil).Once()
+	noteRepo.On("GetSharesByUsers", userIDs).Return([]models.NoteShare{{UserID: bob, NoteID: aliceNote.ID, Note: aliceNote}}, nil).Once()
+
+	// Test
+	byUser, err := service.GetNotesByUsers(context.Background(), userIDs)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, byUser[alice], 1)
+	assert.Len(t, byUser[bob], 1)
+	assert.Equal(t, alice, byUser[bob][0].OwnerID)
+	assert.Equal(t, "ok", byUser[bob][0].Body)
+	noteRepo.AssertExpectations(t)
+}
+
+func TestNoteService_ShareNote_Bulk(t *testing.T) {
+	ownerID := uuid.New()
+	noteID := uuid.New()
+	alice, bob := uuid.New(), uuid.New()
+	input := &ShareNoteInput{Shares: []ShareEntry{
+		{UserID: alice, Access: models.AccessRead},
+		{UserID: bob, Access: models.AccessWrite},
+	}}
+
+	t.Run("shares with everyone", func(t *testing.T) {
+		noteRepo := new(MockNoteRepository)
+		recorder := new(MockAuditRecorder)
+		service := NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, recorder, nil)
+		noteRepo.On("GetByID", noteID).Return(&models.Note{ID: noteID, OwnerID: ownerID}, nil)
+		noteRepo.On("ShareNote", noteID, alice, models.AccessRead).Return(nil)
+		noteRepo.On("ShareNote", noteID, bob, models.AccessWrite).Return(nil)
+		recorder.On("Record", mock.Anything).Twice()
+
+		results, err := service.ShareNote(context.Background(), noteID, input, ownerID)
+
+		require.NoError(t, err)
+		assert.Equal(t, []ShareResult{
+			{UserID: alice, Access: models.AccessRead, Status: ShareStatusShared},
+			{UserID: bob, Access: models.AccessWrite, Status: ShareStatusShared},
+		}, results)
+		recorder.AssertExpectations(t)
+	})
+
+	t.Run("reports each failed entry", func(t *testing.T) {
+		noteRepo := new(MockNoteRepository)
+		recorder := new(MockAuditRecorder)
+		service := NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, recorder, nil)
+		noteRepo.On("GetByID", noteID).Return(&models.Note{ID: noteID, OwnerID: ownerID}, nil)
+		noteRepo.On("ShareNote", noteID, alice, models.AccessRead).Return(nil)
+		noteRepo.On("ShareNote", noteID, bob, models.AccessWrite).Return(apperrors.NotFound("user not found"))
+
+		_, err := service.ShareNote(context.Background(), noteID, input, ownerID)
+
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Equal(t, map[string]string{"shares[1]": "user not found"}, apperrors.From(err).Fields)
+		recorder.AssertNotCalled(t, "Record", mock.Anything)
+	})
+
+	t.Run("rejects a user listed twice", func(t *testing.T) {
+		noteRepo := new(MockNoteRepository)
+		service := NewNoteService(noteRepo, new(MockFolderRepository), nil, nil, nil, nil, nil, nil)
+
+		_, err := service.ShareNote(context.Background(), noteID, &ShareNoteInput{Shares: []ShareEntry{
+			{UserID: alice, Access: models.AccessRead},
+			{UserID: alice, Access: models.AccessWrite},
+		}}, ownerID)
+
+		assert.Equal(t, map[string]string{"shares[1].userId": "is listed more than once"}, apperrors.From(err).Fields)
+		noteRepo.AssertNotCalled(t, "ShareNote", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+)
+
+const (
+	DefaultNotificationLimit = 50
+	MaxNotificationLimit     = 200
+)
+
+type NotificationService struct {
+	notificationRepo repositories.NotificationRepositoryInterface
+	prefRepo         repositories.UserPreferenceRepositoryInterface
+}
+
+// NewNotificationService creates a notification service. prefRepo may be
+// nil, in which case timestamps are returned in UTC.
+func NewNotificationService(notificationRepo repositories.NotificationRepositoryInterface, prefRepo repositories.UserPreferenceRepositoryInterface) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		prefRepo:         prefRepo,
+	}
+}
+
+// GetUserNotifications lists the user's notifications with timestamps in
+// their time zone
+func (s *NotificationService) GetUserNotifications(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]models.Notification, error) {
+	notifications, err := s.notificationRepo.GetByUser(ctx, userID, unreadOnly, clampLimit(limit, DefaultNotificationLimit, MaxNotificationLimit))
+	if err != nil {
+		return nil, err
+	}
+	loc, err := userLocation(ctx, s.prefRepo, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range notifications {
+		notifications[i].CreatedAt = notifications[i].CreatedAt.In(loc)
+		if readAt := notifications[i].ReadAt; readAt != nil {
+			local := readAt.In(loc)
+			notifications[i].ReadAt = &local
+		}
+	}
+	return notifications, nil
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, notificationID, userID uuid.UUID) error {
+	return s.notificationRepo.MarkRead(ctx, notificationID, userID)
+}
+
+// userLocation is the time zone timestamps are shown to userID in
+func userLocation(ctx context.Context, prefRepo repositories.UserPreferenceRepositoryInterface, userID uuid.UUID) (*time.Location, error) {
+	if prefRepo == nil {
+		return time.UTC, nil
+	}
+	pref, err := prefRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return pref.Location(), nil
+}
+
+// clampLimit applies a default to non-positive limits and caps them at max
+func clampLimit(limit, def, max int) int {
+	if limit <= 0 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
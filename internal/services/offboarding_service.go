@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/logger"
+)
+
+// OffboardingService hands the folders and notes of a user who leaves over
+// to a manager, so nothing they owned is lost when the account goes
+type OffboardingService struct {
+	repo   repositories.OffboardingRepositoryInterface
+	audit  audit.Recorder
+	logger logger.Logger
+}
+
+// NewOffboardingService creates an offboarding service. auditor and log may
+// be nil.
+func NewOffboardingService(repo repositories.OffboardingRepositoryInterface, auditor audit.Recorder, log logger.Logger) *OffboardingService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &OffboardingService{
+		repo:   repo,
+		audit:  auditor,
+		logger: log,
+	}
+}
+
+// OffboardInput names the departing user and the manager taking over their
+// assets. Archive also moves the notes to the archived status; DryRun only
+// reports what would be handed over. ActorID is the admin recorded in the
+// audit log for the handover, if known.
+type OffboardInput struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ManagerID uuid.UUID `json:"manager_id"`
+	Archive   bool      `json:"archive"`
+	DryRun    bool      `json:"dry_run"`
+	ActorID   uuid.UUID `json:"-"`
+}
+
+// OffboardedAsset is a folder or note in an offboarding report
+type OffboardedAsset struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// OffboardReport lists the folders and notes handed over, or that would be
+// on a dry run
+type OffboardReport struct {
+	UserID    uuid.UUID         `json:"user_id"`
+	ManagerID uuid.UUID         `json:"manager_id"`
+	Archive   bool              `json:"archive"`
+	DryRun    bool              `json:"dry_run"`
+	Folders   []OffboardedAsset `json:"folders"`
+	Notes     []OffboardedAsset `json:"notes"`
+}
+
+// Offboard makes the manager the owner of every folder and note the user
+// owns. The user may already have been deleted; the manager must be a
+// manager of the same organization.
+func (s *OffboardingService) Offboard(ctx context.Context, input *OffboardInput) (*OffboardReport, error) {
+	if input.UserID == input.ManagerID {
+		return nil, apperrors.ValidationFields("Invalid offboarding", map[string]string{"manager_id": "must be another user"})
+	}
+	user, err := s.repo.GetUser(ctx, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+	manager, err := s.repo.GetUser(ctx, input.ManagerID)
+	if err != nil {
+		return nil, err
+	}
+	if manager.DeletedAt.Valid || !manager.IsManager() {
+		return nil, apperrors.ValidationFields("Invalid offboarding", map[string]string{"manager_id": "must be an active manager"})
+	}
+	if !models.SameOrganization(user.OrganizationID, manager.OrganizationID) {
+		return nil, apperrors.ValidationFields("Invalid offboarding", map[string]string{"manager_id": "must be in the user's organization"})
+	}
+
+	var folders []models.Folder
+	var notes []models.Note
+	if input.DryRun {
+		folders, notes, err = s.repo.Assets(ctx, user.ID)
+	} else {
+		folders, notes, err = s.repo.TransferAssets(ctx, user.ID, manager.ID, input.Archive)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to hand over assets: %w", err)
+	}
+
+	report := &OffboardReport{
+		UserID:    user.ID,
+		ManagerID: manager.ID,
+		Archive:   input.Archive,
+		DryRun:    input.DryRun,
+		Folders:   make([]OffboardedAsset, len(folders)),
+		Notes:     make([]OffboardedAsset, len(notes)),
+	}
+	for i, folder := range folders {
+		report.Folders[i] = OffboardedAsset{ID: folder.ID, Name: folder.Name}
+	}
+	for i, note := range notes {
+		report.Notes[i] = OffboardedAsset{ID: note.ID, Name: note.Title}
+	}
+	if input.DryRun {
+		return report, nil
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    input.ActorID,
+		Action:     audit.ActionOffboard,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Details: map[string]string{
+			"manager_id": manager.ID.String(),
+			"folders":    fmt.Sprint(len(folders)),
+			"notes":      fmt.Sprint(len(notes)),
+			"archived":   fmt.Sprint(input.Archive),
+		},
+	})
+	s.logger.Info("Offboarded user",
+		logger.String("user_id", user.ID.String()),
+		logger.String("manager_id", manager.ID.String()),
+		logger.Int("folders", len(folders)),
+		logger.Int("notes", len(notes)),
+		logger.Any("archived", input.Archive),
+	)
+	return report, nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+)
+
+// MockOffboardingRepository is a mock implementation of OffboardingRepositoryInterface
+type MockOffboardingRepository struct {
+	mock.Mock
+}
+
+func (m *MockOffboardingRepository) GetUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockOffboardingRepository) Assets(ctx context.Context, userID uuid.UUID) ([]models.Folder, []models.Note, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Folder), args.Get(1).([]models.Note), args.Error(2)
+}
+
+func (m *MockOffboardingRepository) TransferAssets(ctx context.Context, fromID, toID uuid.UUID, archive bool) ([]models.Folder, []models.Note, error) {
+	args := m.Called(fromID, toID, archive)
+	return args.Get(0).([]models.Folder), args.Get(1).([]models.Note), args.Error(2)
+}
+
+func TestOffboardingService_Offboard(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	user := &models.User{ID: uuid.New(), Role: models.RoleMember, OrganizationID: &orgID}
+	manager := &models.User{ID: uuid.New(), Role: models.RoleManager, OrganizationID: &orgID}
+	folders := []models.Folder{{ID: uuid.New(), Name: "Projects"}}
+	notes := []models.Note{{ID: uuid.New(), Title: "Roadmap"}, {ID: uuid.New(), Title: "Budget"}}
+
+	newService := func() (*OffboardingService, *MockOffboardingRepository) {
+		repo := new(MockOffboardingRepository)
+		repo.On("GetUser", user.ID).Return(user, nil)
+		repo.On("GetUser", manager.ID).Return(manager, nil)
+		return NewOffboardingService(repo, nil, nil), repo
+	}
+
+	t.Run("dry run reports without handing over", func(t *testing.T) {
+		repo, recorder := new(MockOffboardingRepository), new(MockAuditRecorder)
+		repo.On("GetUser", user.ID).Return(user, nil)
+		repo.On("GetUser", manager.ID).Return(manager, nil)
+		repo.On("Assets", user.ID).Return(folders, notes, nil)
+		service := NewOffboardingService(repo, recorder, nil)
+
+		report, err := service.Offboard(ctx, &OffboardInput{UserID: user.ID, ManagerID: manager.ID, DryRun: true})
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, []OffboardedAsset{{ID: folders[0].ID, Name: "Projects"}}, report.Folders)
+		assert.Len(t, report.Notes, 2)
+		repo.AssertNotCalled(t, "TransferAssets", mock.Anything, mock.Anything, mock.Anything)
+		recorder.AssertNotCalled(t, "Record", mock.Anything)
+	})
+
+	t.Run("hands the assets over to the manager", func(t *testing.T) {
+		repo, recorder := new(MockOffboardingRepository), new(MockAuditRecorder)
+		repo.On("GetUser", user.ID).Return(user, nil)
+		repo.On("GetUser", manager.ID).Return(manager, nil)
+		repo.On("TransferAssets", user.ID, manager.ID, true).Return(folders, notes, nil)
+		adminID := uuid.New()
+		recorder.On("Record", audit.Entry{
+			ActorID:    adminID,
+			Action:     audit.ActionOffboard,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Details:    map[string]string{"manager_id": manager.ID.String(), "folders": "1", "notes": "2", "archived": "true"},
+		}).Return()
+		service := NewOffboardingService(repo, recorder, nil)
+
+		report, err := service.Offboard(ctx, &OffboardInput{UserID: user.ID, ManagerID: manager.ID, Archive: true, ActorID: adminID})
+		require.NoError(t, err)
+		assert.False(t, report.DryRun)
+		assert.True(t, report.Archive)
+		assert.Equal(t, "Budget", report.Notes[1].Name)
+		recorder.AssertExpectations(t)
+	})
+
+	t.Run("offboards deleted users", func(t *testing.T) {
+		deleted := &models.User{ID: uuid.New(), Role: models.RoleMember, OrganizationID: &orgID, DeletedAt: gorm.DeletedAt{Valid: true}}
+		service, repo := newService()
+		repo.On("GetUser", deleted.ID).Return(deleted, nil)
+		repo.On("TransferAssets", deleted.ID, manager.ID, false).Return(folders, []models.Note{}, nil)
+
+		_, err := service.Offboard(ctx, &OffboardInput{UserID: deleted.ID, ManagerID: manager.ID})
+		require.NoError(t, err)
+	})
+
+	rejected := map[string]*models.User{
+		"members":                    {ID: uuid.New(), Role: models.RoleMember, OrganizationID: &orgID},
+		"deleted managers":           {ID: uuid.New(), Role: models.RoleManager, OrganizationID: &orgID, DeletedAt: gorm.DeletedAt{Valid: true}},
+		"managers of another tenant": {ID: uuid.New(), Role: models.RoleManager},
+	}
+	for name, to := range rejected {
+		t.Run("rejects "+name, func(t *testing.T) {
+			service, repo := newService()
+			repo.On("GetUser", to.ID).Return(to, nil)
+
+			_, err := service.Offboard(ctx, &OffboardInput{UserID: user.ID, ManagerID: to.ID})
+			assert.ErrorIs(t, err, apperrors.ErrValidation)
+			assert.Contains(t, apperrors.From(err).Fields, "manager_id")
+			repo.AssertNotCalled(t, "TransferAssets", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("rejects handing over to the user themselves", func(t *testing.T) {
+		service, _ := newService()
+		_, err := service.Offboard(ctx, &OffboardInput{UserID: user.ID, ManagerID: user.ID})
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+	})
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/pagination"
+)
+
+// slugPattern allows lowercase letters, digits and inner hyphens
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// OrganizationService manages tenants. It is used by platform admins; users
+// only ever see data of their own organization.
+type OrganizationService struct {
+	orgRepo  repositories.OrganizationRepositoryInterface
+	userRepo repositories.UserRepositoryInterface
+	audit    audit.Recorder
+}
+
+// NewOrganizationService creates an organization service. auditor may be nil
+// to disable audit logging.
+func NewOrganizationService(orgRepo repositories.OrganizationRepositoryInterface, userRepo repositories.UserRepositoryInterface, auditor audit.Recorder) *OrganizationService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	return &OrganizationService{
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+		audit:    auditor,
+	}
+}
+
+type CreateOrganizationInput struct {
+	Name string `json:"name" binding:"required,max=100"`
+	Slug string `json:"slug" binding:"required,min=2,max=50"`
+}
+
+func (s *OrganizationService) CreateOrganization(ctx context.Context, input *CreateOrganizationInput, actorID uuid.UUID) (*models.Organization, error) {
+	if !slugPattern.MatchString(input.Slug) {
+		return nil, apperrors.ValidationFields("invalid organization", map[string]string{
+			"slug": "must be lowercase letters, digits and hyphens",
+		})
+	}
+	if exists, err := s.orgRepo.SlugExists(ctx, input.Slug); err != nil {
+		return nil, fmt.Errorf("failed to check slug existence: %w", err)
+	} else if exists {
+		return nil, apperrors.Conflict("slug already exists")
+	}
+
+	org := &models.Organization{
+		Name: input.Name,
+		Slug: input.Slug,
+	}
+	if err := s.orgRepo.Create(ctx, org); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetOrg,
+		TargetID:   org.ID,
+		Details:    map[string]string{"slug": org.Slug},
+	})
+	return org, nil
+}
+
+func (s *OrganizationService) GetOrganization(ctx context.Context, orgID uuid.UUID) (*models.Organization, error) {
+	return s.orgRepo.GetByID(ctx, orgID)
+}
+
+func (s *OrganizationService) GetAllOrganizations(ctx context.Context) ([]models.Organization, error) {
+	return s.orgRepo.GetAll(ctx)
+}
+
+// GetOrganizationUsers lists the users of an organization
+func (s *OrganizationService) GetOrganizationUsers(ctx context.Context, orgID uuid.UUID) ([]models.User, error) {
+	if _, err := s.orgRepo.GetByID(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return s.userRepo.GetAll(ctx, &orgID)
+}
+
+// ListOrganizationUsers returns one page of the users of an organization
+func (s *OrganizationService) ListOrganizationUsers(ctx context.Context, orgID uuid.UUID, p pagination.Params) (pagination.Page[models.User], error) {
+	if _, err := s.orgRepo.GetByID(ctx, orgID); err != nil {
+		return pagination.Page[models.User]{}, err
+	}
+	return s.userRepo.ListPage(ctx, &orgID, p)
+}
+
+// AddUser moves a user, with their folders and notes, into an organization.
+// The user's existing tokens keep the old organization until they log in
+// again.
+func (s *OrganizationService) AddUser(ctx context.Context, orgID, userID, actorID uuid.UUID) error {
+	if _, err := s.orgRepo.GetByID(ctx, orgID); err != nil {
+		return err
+	}
+	if err := s.orgRepo.AssignUser(ctx, orgID, userID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionAddUser,
+		TargetType: audit.TargetOrg,
+		TargetID:   orgID,
+		Details:    map[string]string{"user_id": userID.String()},
+	})
+	return nil
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// MockOrganizationRepository is a mock implementation of OrganizationRepositoryInterface
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationRepository) Create(ctx context.Context, org *models.Organization) error {
+	args := m.Called(org)
+	if org.ID == uuid.Nil {
+		org.ID = uuid.New()
+	}
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Organization, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) GetAll(ctx context.Context) ([]models.Organization, error) {
+	args := m.Called()
+	return args.Get(0).([]models.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) SlugExists(ctx context.Context, slug string) (bool, error) {
+	args := m.Called(slug)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) AssignUser(ctx context.Context, orgID, userID uuid.UUID) error {
+	args := m.Called(orgID, userID)
+	return args.Error(0)
+}
+
+func TestOrganizationService_CreateOrganization(t *testing.T) {
+	t.Run("creates organization", func(t *testing.T) {
+		mockOrgRepo := new(MockOrganizationRepository)
+		service := NewOrganizationService(mockOrgRepo, new(MockUserRepository), nil)
+
+		mockOrgRepo.On("SlugExists", "acme").Return(false, nil)
+		mockOrgRepo.On("Create", mock.MatchedBy(func(org *models.Organization) bool {
+			return org.Name == "Acme" && org.Slug == "acme"
+		})).Return(nil)
+
+		org, err := service.CreateOrganization(context.Background(), &CreateOrganizationInput{Name: "Acme", Slug: "acme"}, uuid.New())
+		assert.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, org.ID)
+		mockOrgRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid slug", func(t *testing.T) {
+		service := NewOrganizationService(new(MockOrganizationRepository), new(MockUserRepository), nil)
+
+		_, err := service.CreateOrganization(context.Background(), &CreateOrganizationInput{Name: "Acme", Slug: "Acme Corp"}, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+	})
+
+	t.Run("rejects duplicate slug", func(t *testing.T) {
+		mockOrgRepo := new(MockOrganizationRepository)
+		service := NewOrganizationService(mockOrgRepo, new(MockUserRepository), nil)
+		mockOrgRepo.On("SlugExists", "acme").Return(true, nil)
+
+		_, err := service.CreateOrganization(context.Background(), &CreateOrganizationInput{Name: "Acme", Slug: "acme"}, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrConflict)
+		mockOrgRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+}
+
+func TestOrganizationService_Users(t *testing.T) {
+	orgID, userID := uuid.New(), uuid.New()
+
+	t.Run("lists only the organization's users", func(t *testing.T) {
+		mockOrgRepo := new(MockOrganizationRepository)
+		mockUserRepo := new(MockUserRepository)
+		service := NewOrganizationService(mockOrgRepo, mockUserRepo, nil)
+		mockOrgRepo.On("GetByID", orgID).Return(&models.Organization{ID: orgID}, nil)
+		mockUserRepo.On("GetAll", &orgID).Return([]models.User{{ID: userID, OrganizationID: &orgID}}, nil)
+
+		users, err := service.GetOrganizationUsers(context.Background(), orgID)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("adds user", func(t *testing.T) {
+		mockOrgRepo := new(MockOrganizationRepository)
+		service := NewOrganizationService(mockOrgRepo, new(MockUserRepository), nil)
+		mockOrgRepo.On("GetByID", orgID).Return(&models.Organization{ID: orgID}, nil)
+		mockOrgRepo.On("AssignUser", orgID, userID).Return(nil)
+
+		assert.NoError(t, service.AddUser(context.Background(), orgID, userID, uuid.New()))
+		mockOrgRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown organization", func(t *testing.T) {
+		mockOrgRepo := new(MockOrganizationRepository)
+		service := NewOrganizationService(mockOrgRepo, new(MockUserRepository), nil)
+		mockOrgRepo.On("GetByID", orgID).Return(nil, apperrors.NotFound("organization not found"))
+
+		err := service.AddUser(context.Background(), orgID, userID, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+		mockOrgRepo.AssertNotCalled(t, "AssignUser", mock.Anything, mock.Anything)
+	})
+}
+
+func TestTeamService_CreateTeam_InheritsOrganization(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewTeamService(mockTeamRepo, mockUserRepo, nil, nil)
+
+	orgID, creatorID := uuid.New(), uuid.New()
+	mockUserRepo.On("GetByID", creatorID).Return(&models.User{ID: creatorID, Role: models.RoleManager, OrganizationID: &orgID}, nil)
+	mockTeamRepo.On("Create", mock.MatchedBy(func(team *models.Team) bool {
+		return team.OrganizationID != nil && *team.OrganizationID == orgID
+	})).Return(nil)
+	mockTeamRepo.On("AddMembership", mock.Anything, creatorID, models.TeamRoleAdmin).Return(nil)
+	mockTeamRepo.On("GetByID", mock.Anything).Return(&models.Team{Name: "Platform", OrganizationID: &orgID}, nil)
+
+	_, err := service.CreateTeam(context.Background(), &CreateTeamInput{Name: "Platform"}, creatorID)
+	assert.NoError(t, err)
+	mockTeamRepo.AssertExpectations(t)
+}
+
+func TestSameOrganization(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	same := a
+	assert.True(t, models.SameOrganization(nil, nil))
+	assert.True(t, models.SameOrganization(&a, &same))
+	assert.False(t, models.SameOrganization(&a, &b))
+	assert.False(t, models.SameOrganization(&a, nil))
+}
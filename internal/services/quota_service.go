@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+)
+
+// QuotaLimits caps how many notes and folders a user, and the people of a
+// team together, may own. Zero means unlimited.
+type QuotaLimits struct {
+	NotesPerUser   int64
+	FoldersPerUser int64
+	NotesPerTeam   int64
+	FoldersPerTeam int64
+}
+
+// QuotaService enforces QuotaLimits and reports usage against them
+type QuotaService struct {
+	quotaRepo repositories.QuotaRepositoryInterface
+	teamRepo  repositories.TeamRepositoryInterface
+	limits    QuotaLimits
+}
+
+func NewQuotaService(quotaRepo repositories.QuotaRepositoryInterface, teamRepo repositories.TeamRepositoryInterface, limits QuotaLimits) *QuotaService {
+	return &QuotaService{
+		quotaRepo: quotaRepo,
+		teamRepo:  teamRepo,
+		limits:    limits,
+	}
+}
+
+// QuotaLimit is usage of one resource against its limit. A nil Limit is
+// unlimited.
+type QuotaLimit struct {
+	Used  int64  `json:"used"`
+	Limit *int64 `json:"limit"`
+}
+
+// QuotaUsage is the usage of each resource a quota covers
+type QuotaUsage struct {
+	Notes   QuotaLimit `json:"notes"`
+	Folders QuotaLimit `json:"folders"`
+}
+
+// TeamQuota is the usage of one of the user's teams
+type TeamQuota struct {
+	TeamID uuid.UUID `json:"team_id"`
+	QuotaUsage
+}
+
+// Quota is what GET /me/quota returns: the user's own usage and that of
+// each of their teams
+type Quota struct {
+	User  QuotaUsage  `json:"user"`
+	Teams []TeamQuota `json:"teams"`
+}
+
+// CheckNote returns a quota exceeded error when userID may not create
+// another note
+func (s *QuotaService) CheckNote(ctx context.Context, userID uuid.UUID) error {
+	if s.limits.NotesPerUser == 0 && s.limits.NotesPerTeam == 0 {
+		return nil
+	}
+	return s.check(ctx, userID, func(u models.Usage) int64 { return u.Notes },
+		s.limits.NotesPerUser, "note quota of %d reached",
+		s.limits.NotesPerTeam, "team note quota of %d reached")
+}
+
+// CheckFolder returns a quota exceeded error when userID may not create
+// another folder
+func (s *QuotaService) CheckFolder(ctx context.Context, userID uuid.UUID) error {
+	if s.limits.FoldersPerUser == 0 && s.limits.FoldersPerTeam == 0 {
+		return nil
+	}
+	return s.check(ctx, userID, func(u models.Usage) int64 { return u.Folders },
+		s.limits.FoldersPerUser, "folder quota of %d reached",
+		s.limits.FoldersPerTeam, "team folder quota of %d reached")
+}
+
+// check compares one resource of the user's usage, then that of each of
+// their teams, against the limits
+func (s *QuotaService) check(ctx context.Context, userID uuid.UUID, used func(models.Usage) int64, userLimit int64, userMsg string, teamLimit int64, teamMsg string) error {
+	if userLimit > 0 {
+		usage, err := s.quotaRepo.UserUsage(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get usage: %w", err)
+		}
+		if used(usage) >= userLimit {
+			return apperrors.QuotaExceeded(userMsg, userLimit)
+		}
+	}
+	if teamLimit > 0 {
+		teams, err := s.teamRepo.GetUserTeamRoles(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get teams: %w", err)
+		}
+		for teamID := range teams {
+			usage, err := s.quotaRepo.TeamUsage(ctx, teamID)
+			if err != nil {
+				return fmt.Errorf("failed to get team usage: %w", err)
+			}
+			if used(usage) >= teamLimit {
+				return apperrors.QuotaExceeded(teamMsg, teamLimit)
+			}
+		}
+	}
+	return nil
+}
+
+// GetQuota returns the usage of userID and their teams against the limits
+func (s *QuotaService) GetQuota(ctx context.Context, userID uuid.UUID) (*Quota, error) {
+	usage, err := s.quotaRepo.UserUsage(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	teams, err := s.teamRepo.GetUserTeamRoles(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get teams: %w", err)
+	}
+
+	quota := &Quota{
+		User:  quotaUsage(usage, s.limits.NotesPerUser, s.limits.FoldersPerUser),
+		Teams: make([]TeamQuota, 0, len(teams)),
+	}
+	for teamID := range teams {
+		usage, err := s.quotaRepo.TeamUsage(ctx, teamID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get team usage: %w", err)
+		}
+		quota.Teams = append(quota.Teams, TeamQuota{
+			TeamID:     teamID,
+			QuotaUsage: quotaUsage(usage, s.limits.NotesPerTeam, s.limits.FoldersPerTeam),
+		})
+	}
+	sort.Slice(quota.Teams, func(i, j int) bool {
+		return quota.Teams[i].TeamID.String() < quota.Teams[j].TeamID.String()
+	})
+	return quota, nil
+}
+
+func quotaUsage(usage models.Usage, notes, folders int64) QuotaUsage {
+	return QuotaUsage{
+		Notes:   QuotaLimit{Used: usage.Notes, Limit: limitOf(notes)},
+		Folders: QuotaLimit{Used: usage.Folders, Limit: limitOf(folders)},
+	}
+}
+
+// limitOf reports a zero limit as unlimited
+func limitOf(limit int64) *int64 {
+	if limit == 0 {
+		return nil
+	}
+	return &limit
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// MockQuotaRepository is a mock implementation of QuotaRepositoryInterface
+type MockQuotaRepository struct {
+	mock.Mock
+}
+
+func (m *MockQuotaRepository) UserUsage(ctx context.Context, userID uuid.UUID) (models.Usage, error) {
+	args := m.Called(userID)
+	return args.Get(0).(models.Usage), args.Error(1)
+}
+
+func (m *MockQuotaRepository) TeamUsage(ctx context.Context, teamID uuid.UUID) (models.Usage, error) {
+	args := m.Called(teamID)
+	return args.Get(0).(models.Usage), args.Error(1)
+}
+
+func TestQuotaService_CheckNote(t *testing.T) {
+	userID := uuid.New()
+	teamID := uuid.New()
+
+	tests := []struct {
+		name    string
+		limits  QuotaLimits
+		user    models.Usage
+		team    models.Usage
+		wantErr bool
+	}{
+		{name: "unlimited", limits: QuotaLimits{}},
+		{name: "under the user limit", limits: QuotaLimits{NotesPerUser: 10}, user: models.Usage{Notes: 9}},
+		{name: "at the user limit", limits: QuotaLimits{NotesPerUser: 10}, user: models.Usage{Notes: 10}, wantErr: true},
+		{name: "folders do not count", limits: QuotaLimits{NotesPerUser: 10}, user: models.Usage{Folders: 10}},
+		{name: "at a team limit", limits: QuotaLimits{NotesPerTeam: 100}, team: models.Usage{Notes: 100}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotaRepo := new(MockQuotaRepository)
+			teamRepo := new(MockTeamRepository)
+			quotaRepo.On("UserUsage", userID).Return(tt.user, nil)
+			quotaRepo.On("TeamUsage", teamID).Return(tt.team, nil)
+			teamRepo.On("GetUserTeamRoles", userID).Return(map[uuid.UUID]models.TeamRole{teamID: models.TeamRoleMember}, nil)
+			service := NewQuotaService(quotaRepo, teamRepo, tt.limits)
+
+			err := service.CheckNote(context.Background(), userID)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrQuotaExceeded)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.limits == (QuotaLimits{}) {
+				quotaRepo.AssertNotCalled(t, "UserUsage", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestQuotaService_GetQuota(t *testing.T) {
+	quotaRepo := new(MockQuotaRepository)
+	teamRepo := new(MockTeamRepository)
+	service := NewQuotaService(quotaRepo, teamRepo, QuotaLimits{NotesPerUser: 10, FoldersPerTeam: 5})
+
+	userID := uuid.New()
+	teamID := uuid.New()
+	quotaRepo.On("UserUsage", userID).Return(models.Usage{Notes: 4, Folders: 2}, nil)
+	quotaRepo.On("TeamUsage", teamID).Return(models.Usage{Notes: 7, Folders: 3}, nil)
+	teamRepo.On("GetUserTeamRoles", userID).Return(map[uuid.UUID]models.TeamRole{teamID: models.TeamRoleManager}, nil)
+
+	quota, err := service.GetQuota(context.Background(), userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), quota.User.Notes.Used)
+	assert.Equal(t, int64(10), *quota.User.Notes.Limit)
+	assert.Nil(t, quota.User.Folders.Limit)
+	require.Len(t, quota.Teams, 1)
+	assert.Equal(t, teamID, quota.Teams[0].TeamID)
+	assert.Nil(t, quota.Teams[0].Notes.Limit)
+	assert.Equal(t, int64(3), quota.Teams[0].Folders.Used)
+	assert.Equal(t, int64(5), *quota.Teams[0].Folders.Limit)
+}
+
+func TestFolderService_CreateFolder_QuotaExceeded(t *testing.T) {
+	folderRepo := new(MockFolderRepository)
+	quotaRepo := new(MockQuotaRepository)
+	quota := NewQuotaService(quotaRepo, new(MockTeamRepository), QuotaLimits{FoldersPerUser: 3})
+	service := NewFolderService(folderRepo, new(MockNoteRepository), nil, nil, quota, nil, nil)
+
+	ownerID := uuid.New()
+	quotaRepo.On("UserUsage", ownerID).Return(models.Usage{Folders: 3}, nil)
+
+	_, err := service.CreateFolder(context.Background(), &CreateFolderInput{Name: "Over"}, ownerID)
+
+	assert.ErrorIs(t, err, apperrors.ErrQuotaExceeded)
+	folderRepo.AssertNotCalled(t, "Create", mock.Anything)
+}
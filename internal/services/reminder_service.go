@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/events"
+	"seta-training/pkg/i18n"
+	"seta-training/pkg/logger"
+)
+
+const (
+	// MaxPendingRemindersPerNote bounds the unsent reminders a user may
+	// hold on one note
+	MaxPendingRemindersPerNote = 20
+	// reminderBatchSize is how many due reminders are sent per query
+	reminderBatchSize = 100
+)
+
+// ReminderEventDue is published when a reminder is sent, so the user's
+// open notification streams on any instance receive it
+const ReminderEventDue = "reminder.due"
+
+// ReminderEvent is the payload of ReminderEventDue
+type ReminderEvent struct {
+	UserID     uuid.UUID `json:"user_id"`
+	ReminderID uuid.UUID `json:"reminder_id"`
+	NoteID     uuid.UUID `json:"note_id"`
+	NoteTitle  string    `json:"note_title"`
+	Message    string    `json:"message,omitempty"`
+	DueAt      time.Time `json:"due_at"`
+}
+
+// ReminderService manages the reminders users set on notes they can read
+// and sends them once due
+type ReminderService struct {
+	reminderRepo     repositories.ReminderRepositoryInterface
+	noteRepo         repositories.NoteRepositoryInterface
+	notificationRepo repositories.NotificationRepositoryInterface
+	prefRepo         repositories.UserPreferenceRepositoryInterface
+	bus              events.Publisher
+	messages         *i18n.Bundle
+	logger           logger.Logger
+	now              func() time.Time
+}
+
+// NewReminderService creates a reminder service. prefRepo, bus, messages and
+// log may be nil; without a bus reminders only appear in the notification
+// list.
+func NewReminderService(reminderRepo repositories.ReminderRepositoryInterface, noteRepo repositories.NoteRepositoryInterface, notificationRepo repositories.NotificationRepositoryInterface, prefRepo repositories.UserPreferenceRepositoryInterface, bus events.Publisher, messages *i18n.Bundle, log logger.Logger) *ReminderService {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &ReminderService{
+		reminderRepo:     reminderRepo,
+		noteRepo:         noteRepo,
+		notificationRepo: notificationRepo,
+		prefRepo:         prefRepo,
+		bus:              bus,
+		messages:         messages,
+		logger:           log,
+		now:              time.Now,
+	}
+}
+
+type ReminderInput struct {
+	DueAt   time.Time `json:"due_at" binding:"required"`
+	Message string    `json:"message" binding:"max=500"`
+}
+
+func (s *ReminderService) CreateReminder(ctx context.Context, noteID uuid.UUID, input *ReminderInput, userID uuid.UUID) (*models.Reminder, error) {
+	if err := s.checkAccess(ctx, noteID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.validate(input); err != nil {
+		return nil, err
+	}
+	pending, err := s.reminderRepo.CountPending(ctx, noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count reminders: %w", err)
+	}
+	if pending >= MaxPendingRemindersPerNote {
+		return nil, apperrors.Validation("a note can have at most %d pending reminders", MaxPendingRemindersPerNote)
+	}
+
+	reminder := &models.Reminder{
+		NoteID:  noteID,
+		UserID:  userID,
+		DueAt:   input.DueAt.UTC(),
+		Message: input.Message,
+	}
+	if err := s.reminderRepo.Create(ctx, reminder); err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+	return reminder, nil
+}
+
+// GetNoteReminders lists the user's own reminders on a note
+func (s *ReminderService) GetNoteReminders(ctx context.Context, noteID, userID uuid.UUID) ([]models.Reminder, error) {
+	if err := s.checkAccess(ctx, noteID, userID); err != nil {
+		return nil, err
+	}
+	return s.reminderRepo.GetByNoteAndUser(ctx, noteID, userID)
+}
+
+// UpdateReminder reschedules a reminder. A sent reminder is sent again at
+// its new time.
+func (s *ReminderService) UpdateReminder(ctx context.Context, noteID, reminderID uuid.UUID, input *ReminderInput, userID uuid.UUID) (*models.Reminder, error) {
+	reminder, err := s.getReminder(ctx, noteID, reminderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(input); err != nil {
+		return nil, err
+	}
+
+	reminder.DueAt = input.DueAt.UTC()
+	reminder.Message = input.Message
+	reminder.SentAt = nil
+	if err := s.reminderRepo.Update(ctx, reminder); err != nil {
+		return nil, fmt.Errorf("failed to update reminder: %w", err)
+	}
+	return reminder, nil
+}
+
+func (s *ReminderService) DeleteReminder(ctx context.Context, noteID, reminderID, userID uuid.UUID) error {
+	if _, err := s.getReminder(ctx, noteID, reminderID, userID); err != nil {
+		return err
+	}
+	return s.reminderRepo.Delete(ctx, reminderID)
+}
+
+// SendDue notifies the users of every reminder that has come due. It runs
+// as a scheduled job and stops early when ctx is done; the rest is picked
+// up by the next run. Reminders on notes the user can no longer read are
+// marked sent without a notification.
+func (s *ReminderService) SendDue(ctx context.Context) error {
+	var sent int
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		due, err := s.reminderRepo.GetDue(ctx, s.now(), reminderBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to get due reminders: %w", err)
+		}
+		for i := range due {
+			if err := s.send(ctx, &due[i]); err != nil {
+				return err
+			}
+			sent++
+		}
+		if len(due) < reminderBatchSize {
+			break
+		}
+	}
+	if sent > 0 {
+		s.logger.Info("Sent due reminders", logger.Int("count", sent))
+	}
+	return nil
+}
+
+func (s *ReminderService) send(ctx context.Context, reminder *models.Reminder) error {
+	note, err := s.readableNote(ctx, reminder.NoteID, reminder.UserID)
+	if err != nil {
+		return err
+	}
+	if note != nil {
+		if err := s.notify(ctx, reminder, note); err != nil {
+			return err
+		}
+	}
+	if err := s.reminderRepo.MarkSent(ctx, reminder.ID, s.now()); err != nil {
+		return fmt.Errorf("failed to mark reminder sent: %w", err)
+	}
+	return nil
+}
+
+// readableNote returns the reminder's note, or nil when it is gone or the
+// user lost access to it
+func (s *ReminderService) readableNote(ctx context.Context, noteID, userID uuid.UUID) (*models.Note, error) {
+	hasAccess, _, err := s.noteRepo.HasAccess(ctx, noteID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check note access: %w", err)
+	}
+	if !hasAccess {
+		return nil, nil
+	}
+	note, err := s.noteRepo.GetByID(ctx, noteID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return nil, nil
+	}
+	return note, err
+}
+
+// notify creates the in-app notification and pushes it to the user's open
+// streams, unless the user muted reminders
+func (s *ReminderService) notify(ctx context.Context, reminder *models.Reminder, note *models.Note) error {
+	pref := preferencesOf(ctx, s.prefRepo, reminder.UserID, s.logger)
+	if !pref.Wants(models.NotificationReminder, models.ChannelInApp) {
+		return nil
+	}
+
+	localizer := s.messages.Localizer(pref.Locale)
+	message := localizer.T("Reminder: %q", note.Title)
+	if reminder.Message != "" {
+		message = localizer.T("Reminder: %q: %s", note.Title, reminder.Message)
+	}
+	noteID := note.ID
+	notification := &models.Notification{
+		UserID:       reminder.UserID,
+		Type:         models.NotificationReminder,
+		ResourceType: "note",
+		ResourceID:   &noteID,
+		Message:      message,
+	}
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		return fmt.Errorf("failed to create reminder notification: %w", err)
+	}
+
+	if s.bus == nil {
+		return nil
+	}
+	data, err := json.Marshal(ReminderEvent{
+		UserID:     reminder.UserID,
+		ReminderID: reminder.ID,
+		NoteID:     note.ID,
+		NoteTitle:  note.Title,
+		Message:    reminder.Message,
+		DueAt:      reminder.DueAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode reminder event: %w", err)
+	}
+	// A lost event only costs a live update; the notification is stored
+	if err := s.bus.Publish(ctx, events.Event{
+		ID:          uuid.New(),
+		Type:        ReminderEventDue,
+		AggregateID: reminder.ID,
+		OccurredAt:  s.now().UTC(),
+		Data:        data,
+	}); err != nil {
+		s.logger.Warn("Failed to publish reminder", logger.String("reminder_id", reminder.ID.String()), logger.Error(err))
+	}
+	return nil
+}
+
+func (s *ReminderService) checkAccess(ctx context.Context, noteID, userID uuid.UUID) error {
+	hasAccess, _, err := s.noteRepo.HasAccess(ctx, noteID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to check access: %w", err)
+	}
+	if !hasAccess {
+		return apperrors.Forbidden("access denied")
+	}
+	return nil
+}
+
+// getReminder returns one of the user's reminders on a note
+func (s *ReminderService) getReminder(ctx context.Context, noteID, reminderID, userID uuid.UUID) (*models.Reminder, error) {
+	reminder, err := s.reminderRepo.GetByID(ctx, reminderID)
+	if err != nil {
+		return nil, err
+	}
+	if reminder.NoteID != noteID || reminder.UserID != userID {
+		return nil, apperrors.NotFound("reminder not found")
+	}
+	return reminder, nil
+}
+
+func (s *ReminderService) validate(input *ReminderInput) error {
+	if !input.DueAt.After(s.now()) {
+		return apperrors.ValidationFields("Invalid reminder", map[string]string{"due_at": "must be in the future"})
+	}
+	return nil
+}
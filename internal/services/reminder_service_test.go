@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/events"
+	"seta-training/pkg/i18n"
+)
+
+// MockReminderRepository is a mock implementation of ReminderRepositoryInterface
+type MockReminderRepository struct {
+	mock.Mock
+}
+
+func (m *MockReminderRepository) Create(ctx context.Context, reminder *models.Reminder) error {
+	args := m.Called(reminder)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Reminder, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Reminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) GetByNoteAndUser(ctx context.Context, noteID, userID uuid.UUID) ([]models.Reminder, error) {
+	args := m.Called(noteID, userID)
+	return args.Get(0).([]models.Reminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) CountPending(ctx context.Context, noteID, userID uuid.UUID) (int64, error) {
+	args := m.Called(noteID, userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockReminderRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]models.Reminder, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]models.Reminder), args.Error(1)
+}
+
+func (m *MockReminderRepository) MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	args := m.Called(id, sentAt)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) Update(ctx context.Context, reminder *models.Reminder) error {
+	args := m.Called(reminder)
+	return args.Error(0)
+}
+
+func (m *MockReminderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func TestReminderService_CreateReminder(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	noteID, userID := uuid.New(), uuid.New()
+
+	newService := func() (*ReminderService, *MockReminderRepository, *MockNoteRepository) {
+		reminderRepo, noteRepo := new(MockReminderRepository), new(MockNoteRepository)
+		service := NewReminderService(reminderRepo, noteRepo, nil, nil, nil, nil, nil)
+		service.now = func() time.Time { return now }
+		return service, reminderRepo, noteRepo
+	}
+
+	t.Run("schedules a reminder on a readable note", func(t *testing.T) {
+		service, reminderRepo, noteRepo := newService()
+		noteRepo.On("HasAccess", noteID, userID).Return(true, models.AccessRead, nil)
+		reminderRepo.On("CountPending", noteID, userID).Return(int64(0), nil)
+		reminderRepo.On("Create", mock.AnythingOfType("*models.Reminder")).Return(nil)
+
+		reminder, err := service.CreateReminder(ctx, noteID, &ReminderInput{DueAt: now.Add(time.Hour), Message: "review"}, userID)
+		require.NoError(t, err)
+		assert.Equal(t, noteID, reminder.NoteID)
+		assert.Equal(t, userID, reminder.UserID)
+		assert.Equal(t, now.Add(time.Hour), reminder.DueAt)
+		assert.Nil(t, reminder.SentAt)
+		reminderRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects a due time in the past", func(t *testing.T) {
+		service, reminderRepo, noteRepo := newService()
+		noteRepo.On("HasAccess", noteID, userID).Return(true, models.AccessRead, nil)
+
+		_, err := service.CreateReminder(ctx, noteID, &ReminderInput{DueAt: now.Add(-time.Minute)}, userID)
+		var appErr *apperrors.Error
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperrors.CodeValidation, appErr.Code)
+		assert.Contains(t, appErr.Fields, "due_at")
+		reminderRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("caps pending reminders per note", func(t *testing.T) {
+		service, reminderRepo, noteRepo := newService()
+		noteRepo.On("HasAccess", noteID, userID).Return(true, models.AccessRead, nil)
+		reminderRepo.On("CountPending", noteID, userID).Return(int64(MaxPendingRemindersPerNote), nil)
+
+		_, err := service.CreateReminder(ctx, noteID, &ReminderInput{DueAt: now.Add(time.Hour)}, userID)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		reminderRepo.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("requires access to the note", func(t *testing.T) {
+		service, _, noteRepo := newService()
+		noteRepo.On("HasAccess", noteID, userID).Return(false, models.AccessLevel(""), nil)
+
+		_, err := service.CreateReminder(ctx, noteID, &ReminderInput{DueAt: now.Add(time.Hour)}, userID)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	})
+}
+
+func TestReminderService_UpdateReminder_OtherUsersReminder(t *testing.T) {
+	reminderRepo := new(MockReminderRepository)
+	service := NewReminderService(reminderRepo, new(MockNoteRepository), nil, nil, nil, nil, nil)
+
+	noteID := uuid.New()
+	reminder := &models.Reminder{ID: uuid.New(), NoteID: noteID, UserID: uuid.New()}
+	reminderRepo.On("GetByID", reminder.ID).Return(reminder, nil)
+
+	_, err := service.UpdateReminder(context.Background(), noteID, reminder.ID, &ReminderInput{DueAt: time.Now().Add(time.Hour)}, uuid.New())
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	reminderRepo.AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestReminderService_SendDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	userID := uuid.New()
+	note := &models.Note{Title: "Plan"}
+	note.ID = uuid.New()
+	lost := uuid.New()
+
+	due := []models.Reminder{
+		{ID: uuid.New(), NoteID: note.ID, UserID: userID, DueAt: now.Add(-time.Minute), Message: "ship it"},
+		{ID: uuid.New(), NoteID: lost, UserID: userID, DueAt: now.Add(-time.Minute)},
+	}
+
+	reminderRepo, noteRepo := new(MockReminderRepository), new(MockNoteRepository)
+	notificationRepo, prefRepo := new(MockNotificationRepository), new(MockUserPreferenceRepository)
+	reminderRepo.On("GetDue", now, reminderBatchSize).Return(due, nil)
+	reminderRepo.On("MarkSent", mock.Anything, now).Return(nil)
+	noteRepo.On("HasAccess", note.ID, userID).Return(true, models.AccessRead, nil)
+	noteRepo.On("HasAccess", lost, userID).Return(false, models.AccessLevel(""), nil)
+	noteRepo.On("GetByID", note.ID).Return(note, nil)
+	prefRepo.On("GetByUserID", userID).Return(models.DefaultUserPreference(userID), nil)
+	notificationRepo.On("Create", mock.AnythingOfType("*models.Notification")).Return(nil)
+
+	bus := events.NewMemoryBus(nil)
+	var published []ReminderEvent
+	_, err := bus.Subscribe(ReminderEventDue, func(ctx context.Context, event events.Event) error {
+		var payload ReminderEvent
+		require.NoError(t, json.Unmarshal(event.Data, &payload))
+		published = append(published, payload)
+		return nil
+	})
+	require.NoError(t, err)
+
+	messages, err := i18n.NewBundle()
+	require.NoError(t, err)
+	service := NewReminderService(reminderRepo, noteRepo, notificationRepo, prefRepo, bus, messages, nil)
+	service.now = func() time.Time { return now }
+
+	require.NoError(t, service.SendDue(ctx))
+
+	// Both are marked sent, but only the readable note notifies
+	reminderRepo.AssertCalled(t, "MarkSent", due[0].ID, now)
+	reminderRepo.AssertCalled(t, "MarkSent", due[1].ID, now)
+	notificationRepo.AssertNumberOfCalls(t, "Create", 1)
+	notification := notificationRepo.Calls[0].Arguments.Get(0).(*models.Notification)
+	assert.Equal(t, models.NotificationReminder, notification.Type)
+	assert.Equal(t, `Reminder: "Plan": ship it`, notification.Message)
+
+	require.Len(t, published, 1)
+	assert.Equal(t, due[0].ID, published[0].ReminderID)
+	assert.Equal(t, userID, published[0].UserID)
+}
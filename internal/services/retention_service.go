@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"seta-training/internal/repositories"
+	"seta-training/pkg/logger"
+	"seta-training/pkg/metrics"
+)
+
+// RetentionService permanently deletes records that were soft-deleted longer
+// ago than the retention window
+type RetentionService struct {
+	repo      repositories.RetentionRepositoryInterface
+	retention time.Duration
+	batchSize int
+	metrics   *metrics.Metrics
+	logger    logger.Logger
+}
+
+// NewRetentionService creates a retention service deleting batchSize records
+// per statement. log and m may be nil.
+func NewRetentionService(repo repositories.RetentionRepositoryInterface, retention time.Duration, batchSize int, log logger.Logger, m *metrics.Metrics) *RetentionService {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &RetentionService{
+		repo:      repo,
+		retention: retention,
+		batchSize: batchSize,
+		metrics:   m,
+		logger:    log,
+	}
+}
+
+// PurgeDeleted deletes expired notes, folders, teams and users, in that
+// order so that content goes before its owners. It runs as a scheduled job
+// and stops early when ctx is done; the rest is picked up by the next run.
+func (s *RetentionService) PurgeDeleted(ctx context.Context) error {
+	cutoff := time.Now().Add(-s.retention)
+	for _, table := range []struct {
+		name  string
+		purge func(context.Context, time.Time, int) (int64, error)
+	}{
+		{"notes", s.repo.PurgeNotes},
+		{"folders", s.repo.PurgeFolders},
+		{"teams", s.repo.PurgeTeams},
+		{"users", s.repo.PurgeUsers},
+	} {
+		var total int64
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			purged, err := table.purge(ctx, cutoff, s.batchSize)
+			if err != nil {
+				return fmt.Errorf("failed to purge deleted %s: %w", table.name, err)
+			}
+			total += purged
+			s.metrics.RecordPurged(table.name, purged)
+			if purged < int64(s.batchSize) {
+				break
+			}
+		}
+		if total > 0 {
+			s.logger.Info("Purged soft-deleted records",
+				logger.String("table", table.name),
+				logger.Int("count", int(total)),
+			)
+		}
+	}
+	return nil
+}
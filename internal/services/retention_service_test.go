@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"seta-training/pkg/metrics"
+)
+
+// MockRetentionRepository is a mock implementation of RetentionRepositoryInterface
+type MockRetentionRepository struct {
+	mock.Mock
+}
+
+func (m *MockRetentionRepository) PurgeNotes(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRetentionRepository) PurgeFolders(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRetentionRepository) PurgeTeams(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRetentionRepository) PurgeUsers(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestRetentionService_PurgeDeleted_BatchesUntilDone(t *testing.T) {
+	// Setup
+	mockRepo := new(MockRetentionRepository)
+	m := metrics.NewIsolatedMetrics()
+	service := NewRetentionService(mockRepo, 30*24*time.Hour, 2, nil, m)
+
+	cutoff := mock.MatchedBy(func(before time.Time) bool {
+		return time.Since(before) > 30*24*time.Hour-time.Minute
+	})
+	mockRepo.On("PurgeNotes", cutoff, 2).Return(int64(2), nil).Twice()
+	mockRepo.On("PurgeNotes", cutoff, 2).Return(int64(1), nil).Once()
+	mockRepo.On("PurgeFolders", cutoff, 2).Return(int64(0), nil).Once()
+	mockRepo.On("PurgeTeams", cutoff, 2).Return(int64(2), nil).Once()
+	mockRepo.On("PurgeTeams", cutoff, 2).Return(int64(0), nil).Once()
+	mockRepo.On("PurgeUsers", cutoff, 2).Return(int64(1), nil).Once()
+
+	// Execute
+	err := service.PurgeDeleted(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	assert.Equal(t, 5.0, testutil.ToFloat64(m.RecordsPurgedTotal.WithLabelValues("notes")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.RecordsPurgedTotal.WithLabelValues("teams")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.RecordsPurgedTotal.WithLabelValues("users")))
+}
+
+func TestRetentionService_PurgeDeleted_StopsOnError(t *testing.T) {
+	// Setup
+	mockRepo := new(MockRetentionRepository)
+	service := NewRetentionService(mockRepo, time.Hour, 100, nil, nil)
+
+	mockRepo.On("PurgeNotes", mock.Anything, 100).Return(int64(0), nil)
+	mockRepo.On("PurgeFolders", mock.Anything, 100).Return(int64(0), errors.New("foreign key violation"))
+
+	// Execute
+	err := service.PurgeDeleted(context.Background())
+
+	// Assert
+	assert.ErrorContains(t, err, "failed to purge deleted folders")
+	mockRepo.AssertNotCalled(t, "PurgeTeams", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "PurgeUsers", mock.Anything, mock.Anything)
+}
+
+func TestRetentionService_PurgeDeleted_StopsWhenCancelled(t *testing.T) {
+	// Setup
+	mockRepo := new(MockRetentionRepository)
+	service := NewRetentionService(mockRepo, time.Hour, 1, nil, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	mockRepo.On("PurgeNotes", mock.Anything, 1).Return(int64(1), nil).Run(func(mock.Arguments) {
+		cancel()
+	})
+
+	// Execute
+	err := service.PurgeDeleted(ctx)
+
+	// Assert
+	assert.ErrorIs(t, err, context.Canceled)
+	mockRepo.AssertNumberOfCalls(t, "PurgeNotes", 1)
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"github.com/microcosm-cc/bluemonday"
+	"seta-training/internal/models"
+)
+
+// NoteSanitizer removes markup that could run script from note content.
+// Bodies keep the formatting a user-generated-content policy allows; titles
+// keep no markup at all. Policies are safe for concurrent use.
+type NoteSanitizer struct {
+	body  *bluemonday.Policy
+	title *bluemonday.Policy
+}
+
+func NewNoteSanitizer() *NoteSanitizer {
+	return &NoteSanitizer{
+		body:  bluemonday.UGCPolicy(),
+		title: bluemonday.StrictPolicy(),
+	}
+}
+
+func (s *NoteSanitizer) Body(body string) string {
+	return s.body.Sanitize(body)
+}
+
+func (s *NoteSanitizer) Title(title string) string {
+	return s.title.Sanitize(title)
+}
+
+// Text removes all markup, for showing note content as plain text
+func (s *NoteSanitizer) Text(text string) string {
+	return s.title.Sanitize(text)
+}
+
+// Note sanitizes a note in place. Sanitizing is idempotent, so notes stored
+// before sanitization was introduced are safe to pass through again on read.
+func (s *NoteSanitizer) Note(note *models.Note) {
+	note.Title = s.Title(note.Title)
+	note.Body = s.Body(note.Body)
+}
+
+// Notes sanitizes every note in place
+func (s *NoteSanitizer) Notes(notes []models.Note) {
+	for i := range notes {
+		s.Note(&notes[i])
+	}
+}
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+)
+
+const (
+	// DefaultFilterResultLimit caps results when the caller doesn't ask for a limit
+	DefaultFilterResultLimit = 50
+	// MaxFilterResultLimit is the largest page a filter execution may return
+	MaxFilterResultLimit = 200
+	// homeFilterResultLimit is the number of results shown per pinned filter
+	homeFilterResultLimit = 10
+)
+
+type SavedFilterService struct {
+	filterRepo repositories.SavedFilterRepositoryInterface
+	sanitizer  *NoteSanitizer
+	audit      audit.Recorder
+}
+
+// NewSavedFilterService creates a saved filter service. auditor may be nil
+// to disable audit logging.
+func NewSavedFilterService(filterRepo repositories.SavedFilterRepositoryInterface, auditor audit.Recorder) *SavedFilterService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	return &SavedFilterService{
+		filterRepo: filterRepo,
+		sanitizer:  NewNoteSanitizer(),
+		audit:      auditor,
+	}
+}
+
+type SavedFilterInput struct {
+	Name       string                  `json:"name" binding:"required,min=1,max=100"`
+	Expression models.FilterExpression `json:"expression" binding:"required"`
+	Pinned     bool                    `json:"pinned"`
+}
+
+// FilterResults holds the assets matched by a filter
+type FilterResults struct {
+	Folders []models.Folder `json:"folders"`
+	Notes   []models.Note   `json:"notes"`
+}
+
+// PinnedFilter is a pinned saved filter together with its current results
+type PinnedFilter struct {
+	Filter  models.SavedFilter `json:"filter"`
+	Results *FilterResults     `json:"results"`
+}
+
+// HomePayload is the data shown on a user's home screen
+type HomePayload struct {
+	PinnedFilters []PinnedFilter `json:"pinned_filters"`
+}
+
+func (s *SavedFilterService) CreateSavedFilter(ctx context.Context, input *SavedFilterInput, ownerID uuid.UUID) (*models.SavedFilter, error) {
+	if err := input.Expression.Validate(); err != nil {
+		return nil, apperrors.ValidationFields("invalid filter expression: "+err.Error(), map[string]string{"expression": err.Error()})
+	}
+
+	filter := &models.SavedFilter{
+		Name:       input.Name,
+		OwnerID:    ownerID,
+		Expression: input.Expression,
+		Pinned:     input.Pinned,
+	}
+
+	if err := s.filterRepo.Create(ctx, filter); err != nil {
+		return nil, fmt.Errorf("failed to create saved filter: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    ownerID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetSavedFilter,
+		TargetID:   filter.ID,
+		Details:    map[string]string{"name": filter.Name},
+	})
+
+	return filter, nil
+}
+
+func (s *SavedFilterService) GetSavedFilter(ctx context.Context, filterID, userID uuid.UUID) (*models.SavedFilter, error) {
+	filter, err := s.filterRepo.GetByID(ctx, filterID)
+	if err != nil {
+		return nil, err
+	}
+	if filter.OwnerID != userID {
+		return nil, apperrors.Forbidden("access denied")
+	}
+	return filter, nil
+}
+
+func (s *SavedFilterService) GetUserSavedFilters(ctx context.Context, userID uuid.UUID) ([]models.SavedFilter, error) {
+	return s.filterRepo.GetByOwner(ctx, userID)
+}
+
+func (s *SavedFilterService) UpdateSavedFilter(ctx context.Context, filterID uuid.UUID, input *SavedFilterInput, userID uuid.UUID) (*models.SavedFilter, error) {
+	filter, err := s.GetSavedFilter(ctx, filterID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := input.Expression.Validate(); err != nil {
+		return nil, apperrors.ValidationFields("invalid filter expression: "+err.Error(), map[string]string{"expression": err.Error()})
+	}
+
+	filter.Name = input.Name
+	filter.Expression = input.Expression
+	filter.Pinned = input.Pinned
+	if err := s.filterRepo.Update(ctx, filter); err != nil {
+		return nil, fmt.Errorf("failed to update saved filter: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetSavedFilter,
+		TargetID:   filter.ID,
+		Details:    map[string]string{"name": filter.Name},
+	})
+
+	return filter, nil
+}
+
+func (s *SavedFilterService) DeleteSavedFilter(ctx context.Context, filterID, userID uuid.UUID) error {
+	if _, err := s.GetSavedFilter(ctx, filterID, userID); err != nil {
+		return err
+	}
+	if err := s.filterRepo.Delete(ctx, filterID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetSavedFilter,
+		TargetID:   filterID,
+	})
+	return nil
+}
+
+func (s *SavedFilterService) ExecuteSavedFilter(ctx context.Context, filterID, userID uuid.UUID, limit int) (*FilterResults, error) {
+	filter, err := s.GetSavedFilter(ctx, filterID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultFilterResultLimit
+	}
+	if limit > MaxFilterResultLimit {
+		limit = MaxFilterResultLimit
+	}
+
+	return s.execute(ctx, filter.Expression, userID, limit)
+}
+
+func (s *SavedFilterService) GetHome(ctx context.Context, userID uuid.UUID) (*HomePayload, error) {
+	filters, err := s.filterRepo.GetPinned(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pinned filters: %w", err)
+	}
+
+	pinned := make([]PinnedFilter, 0, len(filters))
+	for _, filter := range filters {
+		results, err := s.execute(ctx, filter.Expression, userID, homeFilterResultLimit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute filter %q: %w", filter.Name, err)
+		}
+		pinned = append(pinned, PinnedFilter{Filter: filter, Results: results})
+	}
+
+	return &HomePayload{PinnedFilters: pinned}, nil
+}
+
+// execute runs a stored expression. Expressions are validated again because
+// stored filters may predate a change to the allowed fields.
+func (s *SavedFilterService) execute(ctx context.Context, expr models.FilterExpression, userID uuid.UUID, limit int) (*FilterResults, error) {
+	if err := expr.Validate(); err != nil {
+		return nil, apperrors.ValidationFields("invalid filter expression: "+err.Error(), map[string]string{"expression": err.Error()})
+	}
+
+	results := &FilterResults{
+		Folders: []models.Folder{},
+		Notes:   []models.Note{},
+	}
+
+	if expr.IncludesFolders() {
+		folders, err := s.filterRepo.FindFolders(ctx, userID, expr, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find folders: %w", err)
+		}
+		results.Folders = folders
+	}
+
+	if expr.IncludesNotes() {
+		notes, err := s.filterRepo.FindNotes(ctx, userID, expr, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find notes: %w", err)
+		}
+		s.sanitizer.Notes(notes)
+		results.Notes = notes
+	}
+
+	return results, nil
+}
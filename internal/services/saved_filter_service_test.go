@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// MockSavedFilterRepository is a mock implementation of SavedFilterRepositoryInterface
+type MockSavedFilterRepository struct {
+	mock.Mock
+}
+
+func (m *MockSavedFilterRepository) Create(ctx context.Context, filter *models.SavedFilter) error {
+	args := m.Called(filter)
+	return args.Error(0)
+}
+
+func (m *MockSavedFilterRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.SavedFilter, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SavedFilter), args.Error(1)
+}
+
+func (m *MockSavedFilterRepository) GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.SavedFilter, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]models.SavedFilter), args.Error(1)
+}
+
+func (m *MockSavedFilterRepository) GetPinned(ctx context.Context, ownerID uuid.UUID) ([]models.SavedFilter, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]models.SavedFilter), args.Error(1)
+}
+
+func (m *MockSavedFilterRepository) Update(ctx context.Context, filter *models.SavedFilter) error {
+	args := m.Called(filter)
+	return args.Error(0)
+}
+
+func (m *MockSavedFilterRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockSavedFilterRepository) FindNotes(ctx context.Context, userID uuid.UUID, expr models.FilterExpression, limit int) ([]models.Note, error) {
+	args := m.Called(userID, expr, limit)
+	return args.Get(0).([]models.Note), args.Error(1)
+}
+
+func (m *MockSavedFilterRepository) FindFolders(ctx context.Context, userID uuid.UUID, expr models.FilterExpression, limit int) ([]models.Folder, error) {
+	args := m.Called(userID, expr, limit)
+	return args.Get(0).([]models.Folder), args.Error(1)
+}
+
+func TestSavedFilterService_CreateSavedFilter_Success(t *testing.T) {
+	// Setup
+	mockRepo := new(MockSavedFilterRepository)
+	service := NewSavedFilterService(mockRepo, nil)
+
+	ownerID := uuid.New()
+	input := &SavedFilterInput{
+		Name: "Specs this week",
+		Expression: models.FilterExpression{
+			Target: models.FilterTargetNotes,
+			Conditions: []models.FilterCondition{
+				{Field: models.FilterFieldTag, Op: models.FilterOpEq, Value: "spec"},
+				{Field: models.FilterFieldTeamID, Op: models.FilterOpEq, Value: uuid.New().String()},
+				{Field: models.FilterFieldUpdatedAt, Op: models.FilterOpWithin, Value: "7d"},
+			},
+		},
+		Pinned: true,
+	}
+
+	// Mock expectations
+	mockRepo.On("Create", mock.AnythingOfType("*models.SavedFilter")).Return(nil)
+
+	// Test
+	filter, err := service.CreateSavedFilter(context.Background(), input, ownerID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, ownerID, filter.OwnerID)
+	assert.True(t, filter.Pinned)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSavedFilterService_CreateSavedFilter_InvalidExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression models.FilterExpression
+		errorText  string
+	}{
+		{
+			name:       "unknown target",
+			expression: models.FilterExpression{Target: "users"},
+			errorText:  "invalid target",
+		},
+		{
+			name: "unknown field",
+			expression: models.FilterExpression{
+				Target:     models.FilterTargetNotes,
+				Conditions: []models.FilterCondition{{Field: "password_hash", Op: models.FilterOpEq, Value: "x"}},
+			},
+			errorText: "unknown field",
+		},
+		{
+			name: "unsupported operator",
+			expression: models.FilterExpression{
+				Target:     models.FilterTargetNotes,
+				Conditions: []models.FilterCondition{{Field: models.FilterFieldBody, Op: models.FilterOpEq, Value: "x"}},
+			},
+			errorText: "not supported",
+		},
+		{
+			name: "note field on folders",
+			expression: models.FilterExpression{
+				Target:     models.FilterTargetFolders,
+				Conditions: []models.FilterCondition{{Field: models.FilterFieldTag, Op: models.FilterOpEq, Value: "spec"}},
+			},
+			errorText: "only applies to notes",
+		},
+		{
+			name: "bad duration",
+			expression: models.FilterExpression{
+				Target:     models.FilterTargetNotes,
+				Conditions: []models.FilterCondition{{Field: models.FilterFieldUpdatedAt, Op: models.FilterOpWithin, Value: "7y"}},
+			},
+			errorText: "invalid duration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockSavedFilterRepository)
+			service := NewSavedFilterService(mockRepo, nil)
+
+			filter, err := service.CreateSavedFilter(context.Background(), &SavedFilterInput{Name: "bad", Expression: tt.expression}, uuid.New())
+
+			assert.Error(t, err)
+			assert.Nil(t, filter)
+			assert.Contains(t, err.Error(), tt.errorText)
+			assert.ErrorIs(t, err, apperrors.ErrValidation)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything)
+		})
+	}
+}
+
+func TestSavedFilterService_GetSavedFilter_NotOwner(t *testing.T) {
+	// Setup
+	mockRepo := new(MockSavedFilterRepository)
+	service := NewSavedFilterService(mockRepo, nil)
+
+	filterID := uuid.New()
+	mockRepo.On("GetByID", filterID).Return(&models.SavedFilter{ID: filterID, OwnerID: uuid.New()}, nil)
+
+	// Test
+	filter, err := service.GetSavedFilter(context.Background(), filterID, uuid.New())
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, filter)
+	assert.Contains(t, err.Error(), "access denied")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSavedFilterService_ExecuteSavedFilter_FoldersOnly(t *testing.T) {
+	// Setup
+	mockRepo := new(MockSavedFilterRepository)
+	service := NewSavedFilterService(mockRepo, nil)
+
+	userID := uuid.New()
+	filter := &models.SavedFilter{
+		ID:         uuid.New(),
+		OwnerID:    userID,
+		Expression: models.FilterExpression{Target: models.FilterTargetFolders},
+	}
+	folders := []models.Folder{{ID: uuid.New(), Name: "Specs"}}
+
+	mockRepo.On("GetByID", filter.ID).Return(filter, nil)
+	mockRepo.On("FindFolders", userID, filter.Expression, MaxFilterResultLimit).Return(folders, nil)
+
+	// Test
+	results, err := service.ExecuteSavedFilter(context.Background(), filter.ID, userID, 1000)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, folders, results.Folders)
+	assert.Empty(t, results.Notes)
+	mockRepo.AssertNotCalled(t, "FindNotes", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSavedFilterService_GetHome(t *testing.T) {
+	// Setup
+	mockRepo := new(MockSavedFilterRepository)
+	service := NewSavedFilterService(mockRepo, nil)
+
+	userID := uuid.New()
+	pinned := models.SavedFilter{
+		ID:         uuid.New(),
+		OwnerID:    userID,
+		Name:       "Recent notes",
+		Expression: models.FilterExpression{Target: models.FilterTargetNotes},
+		Pinned:     true,
+	}
+	notes := []models.Note{{ID: uuid.New(), Title: "Spec"}}
+
+	mockRepo.On("GetPinned", userID).Return([]models.SavedFilter{pinned}, nil)
+	mockRepo.On("FindNotes", userID, pinned.Expression, homeFilterResultLimit).Return(notes, nil)
+
+	// Test
+	home, err := service.GetHome(context.Background(), userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, home.PinnedFilters, 1)
+	assert.Equal(t, notes, home.PinnedFilters[0].Results.Notes)
+	mockRepo.AssertExpectations(t)
+}
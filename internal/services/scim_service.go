@@ -0,0 +1,652 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/scim"
+)
+
+// scimTokenPrefix starts every SCIM token, so that leaked ones are easy
+// to recognize
+const scimTokenPrefix = "scim_"
+
+// SCIMService provisions the users and teams of organizations for their
+// identity providers. SCIM users are the users of the organization,
+// deactivated by soft deleting them, and SCIM groups are its teams.
+type SCIMService struct {
+	repo     repositories.SCIMRepositoryInterface
+	orgRepo  repositories.OrganizationRepositoryInterface
+	teamRepo repositories.TeamRepositoryInterface
+	users    DirectoryUserService
+	audit    audit.Recorder
+}
+
+// NewSCIMService creates a SCIM service. auditor may be nil.
+func NewSCIMService(repo repositories.SCIMRepositoryInterface, orgRepo repositories.OrganizationRepositoryInterface, teamRepo repositories.TeamRepositoryInterface, users DirectoryUserService, auditor audit.Recorder) *SCIMService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	return &SCIMService{
+		repo:     repo,
+		orgRepo:  orgRepo,
+		teamRepo: teamRepo,
+		users:    users,
+		audit:    auditor,
+	}
+}
+
+// SCIMToken is a new SCIM connection with its token, which is not shown
+// again
+type SCIMToken struct {
+	models.SCIMConnection
+	Token string `json:"token"`
+}
+
+// GetConnection returns the SCIM connection of an organization
+func (s *SCIMService) GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SCIMConnection, error) {
+	return s.repo.GetConnection(ctx, orgID)
+}
+
+// CreateToken sets up the SCIM connection of an organization with a new
+// token, revoking the one it had
+func (s *SCIMService) CreateToken(ctx context.Context, orgID uuid.UUID, actorID uuid.UUID) (*SCIMToken, error) {
+	if _, err := s.orgRepo.GetByID(ctx, orgID); err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	token := scimTokenPrefix + secret
+	conn := &models.SCIMConnection{OrganizationID: orgID, TokenHash: hashSCIMToken(token)}
+	if err := s.repo.SaveConnection(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to save SCIM connection: %w", err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetSCIMConnection,
+		TargetID:   conn.ID,
+		Details:    map[string]string{"organization_id": orgID.String()},
+	})
+	return &SCIMToken{SCIMConnection: *conn, Token: token}, nil
+}
+
+// DeleteConnection deletes the SCIM connection of an organization,
+// revoking its token
+func (s *SCIMService) DeleteConnection(ctx context.Context, orgID uuid.UUID, actorID uuid.UUID) error {
+	conn, err := s.repo.GetConnection(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteConnection(ctx, orgID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetSCIMConnection,
+		TargetID:   conn.ID,
+		Details:    map[string]string{"organization_id": orgID.String()},
+	})
+	return nil
+}
+
+// Authenticate returns the organization token belongs to
+func (s *SCIMService) Authenticate(ctx context.Context, token string) (uuid.UUID, error) {
+	if !strings.HasPrefix(token, scimTokenPrefix) {
+		return uuid.Nil, apperrors.Unauthorized("Invalid SCIM token")
+	}
+	conn, err := s.repo.GetConnectionByTokenHash(ctx, hashSCIMToken(token))
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return uuid.Nil, apperrors.Unauthorized("Invalid SCIM token")
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to get SCIM connection: %w", err)
+	}
+	return conn.OrganizationID, nil
+}
+
+// hashSCIMToken returns the hash SCIM tokens are stored by. The tokens
+// are random, so they need no salt.
+func hashSCIMToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ListUsers returns one page of the users of an organization matching
+// filter. startIndex is 1-based.
+func (s *SCIMService) ListUsers(ctx context.Context, orgID uuid.UUID, filter string, startIndex, count int) (*scim.ListResponse, error) {
+	var userFilter repositories.SCIMUserFilter
+	if filter != "" {
+		f, err := scim.ParseFilter(filter)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.CodeValidation, err, "Invalid SCIM filter")
+		}
+		switch {
+		case f.Is("userName"):
+			userFilter.UserName = f.Value
+		case f.Is("emails"), f.Is("emails.value"):
+			userFilter.Email = f.Value
+		case f.Is("externalId"):
+			userFilter.ExternalID = f.Value
+		default:
+			return nil, apperrors.Validation("Filtering SCIM users by %s is not supported", f.Attribute)
+		}
+	}
+
+	startIndex, count = scimPage(startIndex, count)
+	users, total, err := s.repo.ListUsers(ctx, orgID, userFilter, repositories.Page{Limit: max(count, 1), Offset: startIndex - 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	if count == 0 {
+		users = nil
+	}
+	ids := make([]uuid.UUID, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	externalIDs, err := s.repo.GetExternalIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get external IDs: %w", err)
+	}
+
+	resources := make([]*scim.User, len(users))
+	for i := range users {
+		resources[i] = toSCIMUser(&users[i], externalIDs[users[i].ID])
+	}
+	return scimList(total, startIndex, len(resources), resources), nil
+}
+
+// GetUser returns a user of an organization
+func (s *SCIMService) GetUser(ctx context.Context, orgID, id uuid.UUID) (*scim.User, error) {
+	user, externalID, err := s.getUser(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toSCIMUser(user, externalID), nil
+}
+
+// CreateUser provisions a user in an organization, with the member role
+// and a random password they sign in without, through single sign-on
+func (s *SCIMService) CreateUser(ctx context.Context, orgID uuid.UUID, resource *scim.User) (*scim.User, error) {
+	username, email, active, err := scimUserFields(resource)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnique(ctx, uuid.Nil, username, email); err != nil {
+		return nil, err
+	}
+	password, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.CreateUser(ctx, &CreateUserInput{
+		Username:       username,
+		Email:          email,
+		Password:       password,
+		Role:           models.RoleMember,
+		OrganizationID: &orgID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.LinkUser(ctx, &models.SCIMUser{UserID: user.ID, OrganizationID: orgID, ExternalID: resource.ExternalID}); err != nil {
+		return nil, fmt.Errorf("failed to link user: %w", err)
+	}
+	if !active {
+		if err := s.repo.DeactivateUser(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("failed to deactivate user: %w", err)
+		}
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now().UTC(), Valid: true}
+	}
+	s.audit.Record(ctx, audit.Entry{
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Details: map[string]string{
+			"organization_id": orgID.String(),
+			"source":          "scim",
+			"active":          fmt.Sprint(active),
+		},
+	})
+	return toSCIMUser(user, resource.ExternalID), nil
+}
+
+// ReplaceUser replaces the attributes of a user with those of resource
+func (s *SCIMService) ReplaceUser(ctx context.Context, orgID, id uuid.UUID, resource *scim.User) (*scim.User, error) {
+	user, externalID, err := s.getUser(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateUser(ctx, orgID, user, externalID, resource)
+}
+
+// PatchUser applies the operations of patch to a user
+func (s *SCIMService) PatchUser(ctx context.Context, orgID, id uuid.UUID, patch *scim.PatchRequest) (*scim.User, error) {
+	user, externalID, err := s.getUser(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	resource := toSCIMUser(user, externalID)
+	if err := resource.Apply(patch.Operations); err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeValidation, err, "Invalid SCIM patch")
+	}
+	return s.updateUser(ctx, orgID, user, externalID, resource)
+}
+
+// DeactivateUser deactivates a user, as identity providers do when
+// deleting them
+func (s *SCIMService) DeactivateUser(ctx context.Context, orgID, id uuid.UUID) error {
+	user, _, err := s.getUser(ctx, orgID, id)
+	if err != nil {
+		return err
+	}
+	if user.DeletedAt.Valid {
+		return nil
+	}
+	if err := s.repo.DeactivateUser(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to deactivate user: %w", err)
+	}
+	s.recordUserUpdate(ctx, orgID, user.ID, []string{"deactivated"})
+	return nil
+}
+
+// getUser returns a user of orgID with their external ID
+func (s *SCIMService) getUser(ctx context.Context, orgID, id uuid.UUID) (*models.User, string, error) {
+	user, err := s.repo.GetUser(ctx, orgID, id)
+	if err != nil {
+		return nil, "", err
+	}
+	externalIDs, err := s.repo.GetExternalIDs(ctx, []uuid.UUID{id})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get external IDs: %w", err)
+	}
+	return user, externalIDs[id], nil
+}
+
+// updateUser brings user and their external ID in line with resource
+func (s *SCIMService) updateUser(ctx context.Context, orgID uuid.UUID, user *models.User, externalID string, resource *scim.User) (*scim.User, error) {
+	username, email, active, err := scimUserFields(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []string
+	if username != user.Username || email != user.Email {
+		if err := s.checkUnique(ctx, user.ID, username, email); err != nil {
+			return nil, err
+		}
+		if err := s.repo.UpdateUser(ctx, user.ID, username, email); err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+		if username != user.Username {
+			changes = append(changes, "username")
+		}
+		if email != user.Email {
+			changes = append(changes, "email")
+		}
+		user.Username, user.Email = username, email
+	}
+	if resource.ExternalID != externalID {
+		if err := s.repo.LinkUser(ctx, &models.SCIMUser{UserID: user.ID, OrganizationID: orgID, ExternalID: resource.ExternalID}); err != nil {
+			return nil, fmt.Errorf("failed to link user: %w", err)
+		}
+		externalID = resource.ExternalID
+		changes = append(changes, "external_id")
+	}
+	switch {
+	case active && user.DeletedAt.Valid:
+		if err := s.repo.RestoreUser(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("failed to reactivate user: %w", err)
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+		changes = append(changes, "reactivated")
+	case !active && !user.DeletedAt.Valid:
+		if err := s.repo.DeactivateUser(ctx, user.ID); err != nil {
+			return nil, fmt.Errorf("failed to deactivate user: %w", err)
+		}
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now().UTC(), Valid: true}
+		changes = append(changes, "deactivated")
+	}
+
+	if len(changes) > 0 {
+		user.UpdatedAt = time.Now().UTC()
+		s.recordUserUpdate(ctx, orgID, user.ID, changes)
+	}
+	return toSCIMUser(user, externalID), nil
+}
+
+// checkUnique returns a conflict when another user than userID, in any
+// organization and deactivated or not, has username or email
+func (s *SCIMService) checkUnique(ctx context.Context, userID uuid.UUID, username, email string) error {
+	other, err := s.repo.GetUserByUsername(ctx, username)
+	if err == nil && other.ID != userID {
+		return apperrors.Conflict("username already exists")
+	}
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	other, err = s.repo.GetUserByEmail(ctx, email)
+	if err == nil && other.ID != userID {
+		return apperrors.Conflict("email already exists")
+	}
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	return nil
+}
+
+func (s *SCIMService) recordUserUpdate(ctx context.Context, orgID, userID uuid.UUID, changes []string) {
+	s.audit.Record(ctx, audit.Entry{
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetUser,
+		TargetID:   userID,
+		Details: map[string]string{
+			"organization_id": orgID.String(),
+			"source":          "scim",
+			"changes":         strings.Join(changes, ","),
+		},
+	})
+}
+
+// scimUserFields reads the username, email and state of a user resource.
+// The email defaults to the user name, which identity providers often set
+// to it.
+func scimUserFields(resource *scim.User) (username, email string, active bool, err error) {
+	fields := map[string]string{}
+	username = strings.TrimSpace(resource.UserName)
+	if n := utf8.RuneCountInString(username); n < 3 || n > 50 {
+		fields["userName"] = "must be 3 to 50 characters"
+	}
+	email = strings.TrimSpace(resource.PrimaryEmail())
+	if email == "" {
+		email = username
+	}
+	if address, parseErr := mail.ParseAddress(email); parseErr != nil || address.Address != email {
+		fields["emails"] = "must include a valid email address"
+	}
+	if len(fields) > 0 {
+		return "", "", false, apperrors.ValidationFields("Invalid SCIM user", fields)
+	}
+	return username, email, resource.Active == nil || *resource.Active, nil
+}
+
+// toSCIMUser renders user as a SCIM resource
+func toSCIMUser(user *models.User, externalID string) *scim.User {
+	active := !user.DeletedAt.Valid
+	return &scim.User{
+		Schemas:    []string{scim.UserSchema},
+		ID:         user.ID.String(),
+		ExternalID: externalID,
+		UserName:   user.Username,
+		Emails:     []scim.Email{{Value: user.Email, Type: "work", Primary: true}},
+		Active:     &active,
+		Meta:       &scim.Meta{ResourceType: "User", Created: user.CreatedAt, LastModified: user.UpdatedAt},
+	}
+}
+
+// ListGroups returns one page of the teams of an organization matching
+// filter. startIndex is 1-based.
+func (s *SCIMService) ListGroups(ctx context.Context, orgID uuid.UUID, filter string, startIndex, count int) (*scim.ListResponse, error) {
+	var displayName string
+	if filter != "" {
+		f, err := scim.ParseFilter(filter)
+		if err != nil {
+			return nil, apperrors.Wrap(apperrors.CodeValidation, err, "Invalid SCIM filter")
+		}
+		if !f.Is("displayName") {
+			return nil, apperrors.Validation("Filtering SCIM groups by %s is not supported", f.Attribute)
+		}
+		displayName = f.Value
+	}
+
+	startIndex, count = scimPage(startIndex, count)
+	teams, total, err := s.repo.ListTeams(ctx, orgID, displayName, repositories.Page{Limit: max(count, 1), Offset: startIndex - 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	if count == 0 {
+		teams = nil
+	}
+	resources := make([]*scim.Group, len(teams))
+	for i := range teams {
+		resources[i] = toSCIMGroup(&teams[i])
+	}
+	return scimList(total, startIndex, len(resources), resources), nil
+}
+
+// GetGroup returns a team of an organization
+func (s *SCIMService) GetGroup(ctx context.Context, orgID, id uuid.UUID) (*scim.Group, error) {
+	team, err := s.getTeam(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	return toSCIMGroup(team), nil
+}
+
+// CreateGroup creates a team in an organization with the members of
+// resource, who join it as members
+func (s *SCIMService) CreateGroup(ctx context.Context, orgID uuid.UUID, resource *scim.Group) (*scim.Group, error) {
+	name, members, err := s.scimGroupFields(ctx, orgID, resource)
+	if err != nil {
+		return nil, err
+	}
+	team := &models.Team{
+		Name:           name,
+		OrganizationID: &orgID,
+		Settings:       models.DefaultTeamSettings(),
+	}
+	if err := s.teamRepo.Create(ctx, team); err != nil {
+		return nil, fmt.Errorf("failed to create team: %w", err)
+	}
+	s.recordTeamChange(ctx, orgID, audit.ActionCreate, team.ID, map[string]string{"name": name})
+
+	for _, userID := range members {
+		if err := s.addMember(ctx, orgID, team.ID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetGroup(ctx, orgID, team.ID)
+}
+
+// ReplaceGroup renames a team and replaces its people with the members of
+// resource. Those who stay keep their roles.
+func (s *SCIMService) ReplaceGroup(ctx context.Context, orgID, id uuid.UUID, resource *scim.Group) (*scim.Group, error) {
+	team, err := s.getTeam(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.updateGroup(ctx, orgID, team, resource)
+}
+
+// PatchGroup applies the operations of patch to a team
+func (s *SCIMService) PatchGroup(ctx context.Context, orgID, id uuid.UUID, patch *scim.PatchRequest) (*scim.Group, error) {
+	team, err := s.getTeam(ctx, orgID, id)
+	if err != nil {
+		return nil, err
+	}
+	resource := toSCIMGroup(team)
+	if err := resource.Apply(patch.Operations); err != nil {
+		return nil, apperrors.Wrap(apperrors.CodeValidation, err, "Invalid SCIM patch")
+	}
+	return s.updateGroup(ctx, orgID, team, resource)
+}
+
+// DeleteGroup takes everyone out of a team and deletes it
+func (s *SCIMService) DeleteGroup(ctx context.Context, orgID, id uuid.UUID) error {
+	team, err := s.getTeam(ctx, orgID, id)
+	if err != nil {
+		return err
+	}
+	for _, membership := range team.Memberships {
+		if err := s.teamRepo.RemoveMembership(ctx, team.ID, membership.UserID); err != nil {
+			return fmt.Errorf("failed to remove team member: %w", err)
+		}
+	}
+	if err := s.repo.DeleteTeam(ctx, team.ID); err != nil {
+		return fmt.Errorf("failed to delete team: %w", err)
+	}
+	s.recordTeamChange(ctx, orgID, audit.ActionDelete, team.ID, map[string]string{"name": team.Name})
+	return nil
+}
+
+// getTeam returns a team of orgID with its people
+func (s *SCIMService) getTeam(ctx context.Context, orgID, id uuid.UUID) (*models.Team, error) {
+	team, err := s.teamRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !models.SameOrganization(team.OrganizationID, &orgID) {
+		return nil, apperrors.NotFound("team not found")
+	}
+	return team, nil
+}
+
+// updateGroup brings the name and people of team in line with resource.
+// Memberships of deactivated users, which groups do not list, are kept.
+func (s *SCIMService) updateGroup(ctx context.Context, orgID uuid.UUID, team *models.Team, resource *scim.Group) (*scim.Group, error) {
+	name, members, err := s.scimGroupFields(ctx, orgID, resource)
+	if err != nil {
+		return nil, err
+	}
+	if name != team.Name {
+		if err := s.repo.RenameTeam(ctx, team.ID, name); err != nil {
+			return nil, fmt.Errorf("failed to rename team: %w", err)
+		}
+		s.recordTeamChange(ctx, orgID, audit.ActionUpdate, team.ID, map[string]string{"name": name})
+	}
+
+	wanted := make(map[uuid.UUID]bool, len(members))
+	for _, userID := range members {
+		wanted[userID] = true
+	}
+	current := map[uuid.UUID]bool{}
+	for _, membership := range team.Memberships {
+		if membership.User.ID == uuid.Nil {
+			continue
+		}
+		current[membership.UserID] = true
+		if wanted[membership.UserID] {
+			continue
+		}
+		if err := s.teamRepo.RemoveMembership(ctx, team.ID, membership.UserID); err != nil {
+			return nil, fmt.Errorf("failed to remove team member: %w", err)
+		}
+		s.recordTeamChange(ctx, orgID, audit.ActionRemoveMember, team.ID, map[string]string{"user_id": membership.UserID.String()})
+	}
+	for _, userID := range members {
+		if current[userID] {
+			continue
+		}
+		if err := s.addMember(ctx, orgID, team.ID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return s.GetGroup(ctx, orgID, team.ID)
+}
+
+func (s *SCIMService) addMember(ctx context.Context, orgID, teamID, userID uuid.UUID) error {
+	if err := s.teamRepo.AddMembership(ctx, teamID, userID, models.TeamRoleMember); err != nil {
+		return fmt.Errorf("failed to add team member: %w", err)
+	}
+	s.recordTeamChange(ctx, orgID, audit.ActionAddMember, teamID, map[string]string{"user_id": userID.String()})
+	return nil
+}
+
+// scimGroupFields reads the team name and the IDs of the members of a
+// group resource, who must be active users of orgID
+func (s *SCIMService) scimGroupFields(ctx context.Context, orgID uuid.UUID, resource *scim.Group) (string, []uuid.UUID, error) {
+	fields := map[string]string{}
+	name := strings.TrimSpace(resource.DisplayName)
+	if n := utf8.RuneCountInString(name); n < 3 || n > 100 {
+		fields["displayName"] = "must be 3 to 100 characters"
+	}
+	members := make([]uuid.UUID, 0, len(resource.Members))
+	seen := map[uuid.UUID]bool{}
+	for _, member := range resource.Members {
+		userID, err := uuid.Parse(member.Value)
+		if err != nil {
+			fields["members"] = "must be active users of the organization"
+			break
+		}
+		if seen[userID] {
+			continue
+		}
+		user, err := s.repo.GetUser(ctx, orgID, userID)
+		if errors.Is(err, apperrors.ErrNotFound) || (err == nil && user.DeletedAt.Valid) {
+			fields["members"] = "must be active users of the organization"
+			break
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to get user: %w", err)
+		}
+		seen[userID] = true
+		members = append(members, userID)
+	}
+	if len(fields) > 0 {
+		return "", nil, apperrors.ValidationFields("Invalid SCIM group", fields)
+	}
+	return name, members, nil
+}
+
+func (s *SCIMService) recordTeamChange(ctx context.Context, orgID uuid.UUID, action string, teamID uuid.UUID, details map[string]string) {
+	details["organization_id"] = orgID.String()
+	details["source"] = "scim"
+	s.audit.Record(ctx, audit.Entry{
+		Action:     action,
+		TargetType: audit.TargetTeam,
+		TargetID:   teamID,
+		Details:    details,
+	})
+}
+
+// toSCIMGroup renders team, loaded with its people, as a SCIM resource
+func toSCIMGroup(team *models.Team) *scim.Group {
+	members := make([]scim.Member, 0, len(team.Memberships))
+	for _, membership := range team.Memberships {
+		if membership.User.ID == uuid.Nil {
+			continue
+		}
+		members = append(members, scim.Member{Value: membership.UserID.String(), Display: membership.User.Username})
+	}
+	return &scim.Group{
+		Schemas:     []string{scim.GroupSchema},
+		ID:          team.ID.String(),
+		DisplayName: team.Name,
+		Members:     members,
+		Meta:        &scim.Meta{ResourceType: "Group", Created: team.CreatedAt, LastModified: team.UpdatedAt},
+	}
+}
+
+// scimPage clamps the 1-based start index and count of a listing
+func scimPage(startIndex, count int) (int, int) {
+	return max(startIndex, 1), min(max(count, 0), scim.MaxResults)
+}
+
+func scimList(total int64, startIndex, itemsPerPage int, resources interface{}) *scim.ListResponse {
+	return &scim.ListResponse{
+		Schemas:      []string{scim.ListResponseSchema},
+		TotalResults: int(total),
+		StartIndex:   startIndex,
+		ItemsPerPage: itemsPerPage,
+		Resources:    resources,
+	}
+}
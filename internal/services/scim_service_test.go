@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/scim"
+)
+
+// MockSCIMRepository is a mock implementation of SCIMRepositoryInterface
+type MockSCIMRepository struct {
+	mock.Mock
+}
+
+func (m *MockSCIMRepository) GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SCIMConnection, error) {
+	args := m.Called(orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SCIMConnection), args.Error(1)
+}
+
+func (m *MockSCIMRepository) GetConnectionByTokenHash(ctx context.Context, tokenHash string) (*models.SCIMConnection, error) {
+	args := m.Called(tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SCIMConnection), args.Error(1)
+}
+
+func (m *MockSCIMRepository) SaveConnection(ctx context.Context, conn *models.SCIMConnection) error {
+	args := m.Called(conn)
+	return args.Error(0)
+}
+
+func (m *MockSCIMRepository) DeleteConnection(ctx context.Context, orgID uuid.UUID) error {
+	args := m.Called(orgID)
+	return args.Error(0)
+}
+
+func (m *MockSCIMRepository) ListUsers(ctx context.Context, orgID uuid.UUID, filter repositories.SCIMUserFilter, page repositories.Page) ([]models.User, int64, error) {
+	args := m.Called(orgID, filter, page)
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockSCIMRepository) GetUser(ctx context.Context, orgID, id uuid.UUID) (*models.User, error) {
+	args := m.Called(orgID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockSCIMRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockSCIMRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
+	args := m.Called(username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockSCIMRepository) GetExternalIDs(ctx context.Context, userIDs []uuid.UUID) (map[uuid.UUID]string, error) {
+	args := m.Called(userIDs)
+	return args.Get(0).(map[uuid.UUID]string), args.Error(1)
+}
+
+func (m *MockSCIMRepository) LinkUser(ctx context.Context, link *models.SCIMUser) error {
+	args := m.Called(link)
+	return args.Error(0)
+}
+
+func (m *MockSCIMRepository) UpdateUser(ctx context.Context, userID uuid.UUID, username, email string) error {
+	args := m.Called(userID, username, email)
+	return args.Error(0)
+}
+
+func (m *MockSCIMRepository) DeactivateUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockSCIMRepository) RestoreUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(userID)
+	return args.Error(0)
+}
+
+func (m *MockSCIMRepository) ListTeams(ctx context.Context, orgID uuid.UUID, displayName string, page repositories.Page) ([]models.Team, int64, error) {
+	args := m.Called(orgID, displayName, page)
+	return args.Get(0).([]models.Team), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockSCIMRepository) RenameTeam(ctx context.Context, teamID uuid.UUID, name string) error {
+	args := m.Called(teamID, name)
+	return args.Error(0)
+}
+
+func (m *MockSCIMRepository) DeleteTeam(ctx context.Context, teamID uuid.UUID) error {
+	args := m.Called(teamID)
+	return args.Error(0)
+}
+
+func newTestSCIMService() (*SCIMService, *MockSCIMRepository, *MockTeamRepository, *MockUserService) {
+	repo, teamRepo, users := new(MockSCIMRepository), new(MockTeamRepository), new(MockUserService)
+	return NewSCIMService(repo, new(MockOrganizationRepository), teamRepo, users, nil), repo, teamRepo, users
+}
+
+func TestSCIMService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+
+	t.Run("returns the organization of the token", func(t *testing.T) {
+		service, repo, _, _ := newTestSCIMService()
+		repo.On("GetConnectionByTokenHash", hashSCIMToken("scim_secret")).Return(&models.SCIMConnection{OrganizationID: orgID}, nil)
+
+		got, err := service.Authenticate(ctx, "scim_secret")
+		require.NoError(t, err)
+		assert.Equal(t, orgID, got)
+	})
+
+	t.Run("rejects unknown tokens", func(t *testing.T) {
+		service, repo, _, _ := newTestSCIMService()
+		repo.On("GetConnectionByTokenHash", hashSCIMToken("scim_revoked")).Return(nil, apperrors.NotFound("SCIM connection not found"))
+
+		_, err := service.Authenticate(ctx, "scim_revoked")
+		assert.ErrorIs(t, err, apperrors.ErrUnauthorized)
+	})
+
+	t.Run("rejects other tokens without looking them up", func(t *testing.T) {
+		service, repo, _, _ := newTestSCIMService()
+
+		_, err := service.Authenticate(ctx, "eyJhbGciOiJIUzI1NiJ9.e30.sig")
+		assert.ErrorIs(t, err, apperrors.ErrUnauthorized)
+		repo.AssertNotCalled(t, "GetConnectionByTokenHash", mock.Anything)
+	})
+}
+
+func TestSCIMService_CreateToken(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	repo, orgRepo := new(MockSCIMRepository), new(MockOrganizationRepository)
+	service := NewSCIMService(repo, orgRepo, new(MockTeamRepository), new(MockUserService), nil)
+	orgRepo.On("GetByID", orgID).Return(&models.Organization{ID: orgID}, nil)
+	repo.On("SaveConnection", mock.AnythingOfType("*models.SCIMConnection")).Return(nil)
+
+	token, err := service.CreateToken(ctx, orgID, uuid.New())
+	require.NoError(t, err)
+	assert.True(t, len(token.Token) > len(scimTokenPrefix)+32)
+	// Only the hash of the token is stored
+	saved := repo.Calls[0].Arguments.Get(0).(*models.SCIMConnection)
+	assert.Equal(t, hashSCIMToken(token.Token), saved.TokenHash)
+	assert.NotContains(t, saved.TokenHash, token.Token)
+}
+
+func TestSCIMService_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	alice := models.User{ID: uuid.New(), Username: "alice", Email: "alice@acme.com"}
+	bob := models.User{ID: uuid.New(), Username: "bob", Email: "bob@acme.com", DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+
+	t.Run("pages users with their external IDs", func(t *testing.T) {
+		service, repo, _, _ := newTestSCIMService()
+		repo.On("ListUsers", orgID, repositories.SCIMUserFilter{}, repositories.Page{Limit: 2, Offset: 2}).Return([]models.User{alice, bob}, int64(5), nil)
+		repo.On("GetExternalIDs", []uuid.UUID{alice.ID, bob.ID}).Return(map[uuid.UUID]string{alice.ID: "00u1"}, nil)
+
+		list, err := service.ListUsers(ctx, orgID, "", 3, 2)
+		require.NoError(t, err)
+		assert.Equal(t, 5, list.TotalResults)
+		assert.Equal(t, 3, list.StartIndex)
+		assert.Equal(t, 2, list.ItemsPerPage)
+		users := list.Resources.([]*scim.User)
+		assert.Equal(t, "00u1", users[0].ExternalID)
+		assert.True(t, *users[0].Active)
+		assert.False(t, *users[1].Active)
+	})
+
+	t.Run("filters by user name", func(t *testing.T) {
+		service, repo, _, _ := newTestSCIMService()
+		repo.On("ListUsers", orgID, repositories.SCIMUserFilter{UserName: "alice@acme.com"}, repositories.Page{Limit: scim.MaxResults}).Return([]models.User{}, int64(0), nil)
+		repo.On("GetExternalIDs", []uuid.UUID{}).Return(map[uuid.UUID]string{}, nil)
+
+		list, err := service.ListUsers(ctx, orgID, `userName eq "alice@acme.com"`, 0, 1000)
+		require.NoError(t, err)
+		assert.Equal(t, 0, list.TotalResults)
+		assert.Empty(t, list.Resources)
+	})
+
+	t.Run("rejects unsupported filters", func(t *testing.T) {
+		service, repo, _, _ := newTestSCIMService()
+
+		_, err := service.ListUsers(ctx, orgID, `title eq "CEO"`, 1, 10)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		_, err = service.ListUsers(ctx, orgID, `userName sw "a"`, 1, 10)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		repo.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSCIMService_CreateUser(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	notFound := apperrors.NotFound("user not found")
+
+	t.Run("creates a member of the organization", func(t *testing.T) {
+		service, repo, _, users := newTestSCIMService()
+		repo.On("GetUserByUsername", "alice@acme.com").Return(nil, notFound)
+		repo.On("GetUserByEmail", "alice@acme.com").Return(nil, notFound)
+		created := &models.User{ID: uuid.New(), Username: "alice@acme.com", Email: "alice@acme.com", Role: models.RoleMember}
+		users.On("CreateUser", mock.MatchedBy(func(input *CreateUserInput) bool {
+			return input.Username == "alice@acme.com" && input.Email == "alice@acme.com" &&
+				input.Role == models.RoleMember && *input.OrganizationID == orgID && input.Password != ""
+		})).Return(created, nil)
+		repo.On("LinkUser", &models.SCIMUser{UserID: created.ID, OrganizationID: orgID, ExternalID: "00u1"}).Return(nil)
+
+		// The email defaults to the user name
+		user, err := service.CreateUser(ctx, orgID, &scim.User{UserName: "alice@acme.com", ExternalID: "00u1"})
+		require.NoError(t, err)
+		assert.Equal(t, created.ID.String(), user.ID)
+		assert.Equal(t, "alice@acme.com", user.PrimaryEmail())
+		assert.True(t, *user.Active)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects taken emails, even of deactivated users", func(t *testing.T) {
+		service, repo, _, users := newTestSCIMService()
+		repo.On("GetUserByUsername", "alice").Return(nil, notFound)
+		repo.On("GetUserByEmail", "alice@acme.com").Return(&models.User{ID: uuid.New(), DeletedAt: gorm.DeletedAt{Valid: true}}, nil)
+
+		_, err := service.CreateUser(ctx, orgID, &scim.User{UserName: "alice", Emails: []scim.Email{{Value: "alice@acme.com"}}})
+		assert.ErrorIs(t, err, apperrors.ErrConflict)
+		users.AssertNotCalled(t, "CreateUser", mock.Anything)
+	})
+
+	t.Run("requires an email", func(t *testing.T) {
+		service, _, _, users := newTestSCIMService()
+
+		_, err := service.CreateUser(ctx, orgID, &scim.User{UserName: "alice"})
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Contains(t, apperrors.From(err).Fields, "emails")
+		users.AssertNotCalled(t, "CreateUser", mock.Anything)
+	})
+}
+
+func TestSCIMService_PatchUser(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	newUser := func() *models.User {
+		return &models.User{ID: uuid.MustParse("5a0e3c6e-8f1e-4d8e-9f39-5d3c7a1f0b11"), Username: "alice", Email: "alice@acme.com"}
+	}
+
+	t.Run("deactivates users", func(t *testing.T) {
+		service, repo, _, _ := newTestSCIMService()
+		user := newUser()
+		repo.On("GetUser", orgID, user.ID).Return(user, nil)
+		repo.On("GetExternalIDs", []uuid.UUID{user.ID}).Return(map[uuid.UUID]string{}, nil)
+		repo.On("DeactivateUser", user.ID).Return(nil)
+
+		// As Okta sends it
+		patch := &scim.PatchRequest{Operations: []scim.Operation{{Op: "replace", Value: json.RawMessage(`{"active":false}`)}}}
+		resource, err := service.PatchUser(ctx, orgID, user.ID, patch)
+		require.NoError(t, err)
+		assert.False(t, *resource.Active)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("reactivates users and changes their email", func(t *testing.T) {
+		service, repo, _, _ := newTestSCIMService()
+		user := newUser()
+		user.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		repo.On("GetUser", orgID, user.ID).Return(user, nil)
+		repo.On("GetExternalIDs", []uuid.UUID{user.ID}).Return(map[uuid.UUID]string{}, nil)
+		repo.On("GetUserByUsername", "alice").Return(user, nil)
+		repo.On("GetUserByEmail", "alice@example.com").Return(nil, apperrors.NotFound("user not found"))
+		repo.On("UpdateUser", user.ID, "alice", "alice@example.com").Return(nil)
+		repo.On("RestoreUser", user.ID).Return(nil)
+
+		// As Azure AD sends it
+		patch := &scim.PatchRequest{Operations: []scim.Operation{
+			{Op: "Replace", Path: "active", Value: json.RawMessage(`"True"`)},
+			{Op: "Replace", Path: `emails[type eq "work"].value`, Value: json.RawMessage(`"alice@example.com"`)},
+			{Op: "Add", Path: "name.givenName", Value: json.RawMessage(`"Alice"`)},
+		}}
+		resource, err := service.PatchUser(ctx, orgID, user.ID, patch)
+		require.NoError(t, err)
+		assert.True(t, *resource.Active)
+		assert.Equal(t, "alice@example.com", resource.PrimaryEmail())
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects unknown operations", func(t *testing.T) {
+		service, repo, _, _ := newTestSCIMService()
+		user := newUser()
+		repo.On("GetUser", orgID, user.ID).Return(user, nil)
+		repo.On("GetExternalIDs", []uuid.UUID{user.ID}).Return(map[uuid.UUID]string{}, nil)
+
+		patch := &scim.PatchRequest{Operations: []scim.Operation{{Op: "move", Path: "active"}}}
+		_, err := service.PatchUser(ctx, orgID, user.ID, patch)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		repo.AssertNotCalled(t, "DeactivateUser", mock.Anything)
+	})
+}
+
+func TestSCIMService_PatchGroup(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+	alice := models.User{ID: uuid.New(), Username: "alice"}
+	bob := models.User{ID: uuid.New(), Username: "bob"}
+	carol := models.User{ID: uuid.New(), Username: "carol"}
+	team := &models.Team{ID: uuid.New(), Name: "Engineering", OrganizationID: &orgID, Memberships: []models.TeamMembership{
+		{UserID: alice.ID, Role: models.TeamRoleAdmin, User: alice},
+		{UserID: bob.ID, Role: models.TeamRoleMember, User: bob},
+	}}
+
+	t.Run("adds and removes members", func(t *testing.T) {
+		service, repo, teamRepo, _ := newTestSCIMService()
+		teamRepo.On("GetByID", team.ID).Return(team, nil)
+		repo.On("GetUser", orgID, alice.ID).Return(&alice, nil)
+		repo.On("GetUser", orgID, carol.ID).Return(&carol, nil)
+		teamRepo.On("RemoveMembership", team.ID, bob.ID).Return(nil)
+		teamRepo.On("AddMembership", team.ID, carol.ID, models.TeamRoleMember).Return(nil)
+
+		patch := &scim.PatchRequest{Operations: []scim.Operation{
+			{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"` + carol.ID.String() + `"}]`)},
+			{Op: "remove", Path: `members[value eq "` + bob.ID.String() + `"]`},
+		}}
+		_, err := service.PatchGroup(ctx, orgID, team.ID, patch)
+		require.NoError(t, err)
+		// alice stays an admin
+		teamRepo.AssertNotCalled(t, "RemoveMembership", team.ID, alice.ID)
+		teamRepo.AssertNotCalled(t, "AddMembership", team.ID, alice.ID, mock.Anything)
+		repo.AssertNotCalled(t, "RenameTeam", mock.Anything, mock.Anything)
+		teamRepo.AssertExpectations(t)
+	})
+
+	t.Run("renames the team", func(t *testing.T) {
+		service, repo, teamRepo, _ := newTestSCIMService()
+		teamRepo.On("GetByID", team.ID).Return(team, nil)
+		repo.On("GetUser", orgID, alice.ID).Return(&alice, nil)
+		repo.On("GetUser", orgID, bob.ID).Return(&bob, nil)
+		repo.On("RenameTeam", team.ID, "Platform").Return(nil)
+
+		patch := &scim.PatchRequest{Operations: []scim.Operation{{Op: "replace", Value: json.RawMessage(`{"displayName":"Platform"}`)}}}
+		_, err := service.PatchGroup(ctx, orgID, team.ID, patch)
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rejects users of another organization", func(t *testing.T) {
+		service, repo, teamRepo, _ := newTestSCIMService()
+		teamRepo.On("GetByID", team.ID).Return(team, nil)
+		repo.On("GetUser", orgID, alice.ID).Return(&alice, nil)
+		repo.On("GetUser", orgID, bob.ID).Return(&bob, nil)
+		outsider := uuid.New()
+		repo.On("GetUser", orgID, outsider).Return(nil, apperrors.NotFound("user not found"))
+
+		patch := &scim.PatchRequest{Operations: []scim.Operation{{Op: "add", Path: "members", Value: json.RawMessage(`[{"value":"` + outsider.String() + `"}]`)}}}
+		_, err := service.PatchGroup(ctx, orgID, team.ID, patch)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		teamRepo.AssertNotCalled(t, "AddMembership", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("hides teams of other organizations", func(t *testing.T) {
+		service, _, teamRepo, _ := newTestSCIMService()
+		otherOrg := uuid.New()
+		teamRepo.On("GetByID", team.ID).Return(&models.Team{ID: team.ID, OrganizationID: &otherOrg}, nil)
+
+		_, err := service.PatchGroup(ctx, orgID, team.ID, &scim.PatchRequest{})
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	})
+}
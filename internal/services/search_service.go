@@ -0,0 +1,226 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/repositories"
+)
+
+// Types of search results
+const (
+	SearchTypeNote   = "note"
+	SearchTypeFolder = "folder"
+	SearchTypeTeam   = "team"
+	SearchTypeUser   = "user"
+)
+
+// SearchTypes lists every searchable type
+var SearchTypes = []string{SearchTypeNote, SearchTypeFolder, SearchTypeTeam, SearchTypeUser}
+
+const (
+	// DefaultSearchLimit caps results when the caller doesn't ask for a limit
+	DefaultSearchLimit = 20
+	// MaxSearchLimit is the largest number of results a search may return
+	MaxSearchLimit = 100
+	// MinSearchQueryLength and MaxSearchQueryLength bound the search text
+	MinSearchQueryLength = 2
+	MaxSearchQueryLength = 100
+	// searchSnippetRadius is the context shown either side of a body match
+	searchSnippetRadius = 60
+)
+
+// SearchQuery selects what a search matches. Empty Types searches
+// everything.
+type SearchQuery struct {
+	Text  string
+	Types []string
+	Limit int
+}
+
+// WithDefaults returns the query with the default limit when it has none,
+// limits above MaxSearchLimit lowered to it and every type when it names
+// none
+func (q SearchQuery) WithDefaults() SearchQuery {
+	if q.Limit <= 0 {
+		q.Limit = DefaultSearchLimit
+	}
+	q.Limit = min(q.Limit, MaxSearchLimit)
+	if len(q.Types) == 0 {
+		q.Types = SearchTypes
+	}
+	return q
+}
+
+// SearchResult is one match of a search. Score ranks it against every
+// other result: 3 when the title is the search text, 2 when the title
+// starts with it, 1 when the title contains it and 0 when only the note
+// body or user email does.
+type SearchResult struct {
+	Type      string    `json:"type"`
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	Snippet   string    `json:"snippet,omitempty"`
+	Score     int       `json:"score"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SearchResults holds the best matches of every searched type, best first.
+// Facets counts all matches of each searched type, including those beyond
+// the limit.
+type SearchResults struct {
+	Query   string           `json:"query"`
+	Results []SearchResult   `json:"results"`
+	Facets  map[string]int64 `json:"facets"`
+}
+
+// NewSearchResults returns empty results for the search text q
+func NewSearchResults(q string) *SearchResults {
+	return &SearchResults{Query: q, Results: []SearchResult{}, Facets: make(map[string]int64)}
+}
+
+// SearchService searches notes, folders, teams and users at once, limited
+// to what the caller can open
+type SearchService struct {
+	searchRepo repositories.SearchRepositoryInterface
+	sanitizer  *NoteSanitizer
+}
+
+func NewSearchService(searchRepo repositories.SearchRepositoryInterface) *SearchService {
+	return &SearchService{
+		searchRepo: searchRepo,
+		sanitizer:  NewNoteSanitizer(),
+	}
+}
+
+// Search returns the notes and folders userID owns or has been shared, and
+// the teams and users of orgID, that match query
+func (s *SearchService) Search(ctx context.Context, userID uuid.UUID, orgID *uuid.UUID, query SearchQuery) (*SearchResults, error) {
+	query = query.WithDefaults()
+	q, limit := query.Text, query.Limit
+	results := NewSearchResults(q)
+	for _, typ := range query.Types {
+		var total int64
+		var err error
+		switch typ {
+		case SearchTypeNote:
+			total, err = s.searchNotes(ctx, results, userID, q, limit)
+		case SearchTypeFolder:
+			total, err = s.searchFolders(ctx, results, userID, q, limit)
+		case SearchTypeTeam:
+			total, err = s.searchTeams(ctx, results, orgID, q, limit)
+		case SearchTypeUser:
+			total, err = s.searchUsers(ctx, results, orgID, q, limit)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %ss: %w", typ, err)
+		}
+		results.Facets[typ] = total
+	}
+
+	results.Rank(limit)
+	return results, nil
+}
+
+// searchNotes adds the notes matching q, with the body around the match as
+// snippet, and returns the number of matches
+func (s *SearchService) searchNotes(ctx context.Context, results *SearchResults, userID uuid.UUID, q string, limit int) (int64, error) {
+	notes, total, err := s.searchRepo.SearchNotes(ctx, userID, q, limit)
+	if err != nil {
+		return 0, err
+	}
+	for _, note := range notes {
+		title := s.sanitizer.Title(note.Title)
+		body := s.sanitizer.Text(note.Body)
+		results.Add(SearchTypeNote, note.ID, title, SearchSnippet(body, q), note.UpdatedAt)
+	}
+	return total, nil
+}
+
+func (s *SearchService) searchFolders(ctx context.Context, results *SearchResults, userID uuid.UUID, q string, limit int) (int64, error) {
+	folders, total, err := s.searchRepo.SearchFolders(ctx, userID, q, limit)
+	for _, folder := range folders {
+		results.Add(SearchTypeFolder, folder.ID, folder.Name, "", folder.UpdatedAt)
+	}
+	return total, err
+}
+
+func (s *SearchService) searchTeams(ctx context.Context, results *SearchResults, orgID *uuid.UUID, q string, limit int) (int64, error) {
+	teams, total, err := s.searchRepo.SearchTeams(ctx, orgID, q, limit)
+	for _, team := range teams {
+		results.Add(SearchTypeTeam, team.ID, team.Name, "", team.UpdatedAt)
+	}
+	return total, err
+}
+
+// searchUsers adds the users matching q, with their email as snippet
+func (s *SearchService) searchUsers(ctx context.Context, results *SearchResults, orgID *uuid.UUID, q string, limit int) (int64, error) {
+	users, total, err := s.searchRepo.SearchUsers(ctx, orgID, q, limit)
+	for _, user := range users {
+		results.Add(SearchTypeUser, user.ID, user.Username, user.Email, user.UpdatedAt)
+	}
+	return total, err
+}
+
+// Add appends a result, scored by how closely title matches the query
+func (r *SearchResults) Add(typ string, id uuid.UUID, title, snippet string, updatedAt time.Time) {
+	r.Results = append(r.Results, SearchResult{
+		Type:      typ,
+		ID:        id,
+		Title:     title,
+		Snippet:   snippet,
+		Score:     searchScore(title, r.Query),
+		UpdatedAt: updatedAt,
+	})
+}
+
+// Rank orders the results best match first, most recently updated first
+// among equals, and keeps the first limit
+func (r *SearchResults) Rank(limit int) {
+	slices.SortStableFunc(r.Results, func(a, b SearchResult) int {
+		if a.Score != b.Score {
+			return cmp.Compare(b.Score, a.Score)
+		}
+		return b.UpdatedAt.Compare(a.UpdatedAt)
+	})
+	if len(r.Results) > limit {
+		r.Results = r.Results[:limit]
+	}
+}
+
+// searchScore ranks how closely title matches q, ignoring case
+func searchScore(title, q string) int {
+	title, q = strings.ToLower(title), strings.ToLower(q)
+	switch {
+	case title == q:
+		return 3
+	case strings.HasPrefix(title, q):
+		return 2
+	case strings.Contains(title, q):
+		return 1
+	}
+	return 0
+}
+
+// SearchSnippet returns the text surrounding the first match of q, ignoring
+// case, or the start of text if it has none
+func SearchSnippet(text, q string) string {
+	if text == "" {
+		return ""
+	}
+	// Offsets in the lowered text only carry over when lowering kept every
+	// byte length
+	q = strings.ToLower(q)
+	idx := -1
+	if lower := strings.ToLower(text); len(lower) == len(text) {
+		idx = strings.Index(lower, q)
+	}
+	return excerptAround(text, idx, len(q), searchSnippetRadius)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/models"
+)
+
+// MockSearchRepository is a mock implementation of SearchRepositoryInterface
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+func (m *MockSearchRepository) SearchNotes(ctx context.Context, userID uuid.UUID, q string, limit int) ([]models.Note, int64, error) {
+	args := m.Called(userID, q, limit)
+	return args.Get(0).([]models.Note), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockSearchRepository) SearchFolders(ctx context.Context, userID uuid.UUID, q string, limit int) ([]models.Folder, int64, error) {
+	args := m.Called(userID, q, limit)
+	return args.Get(0).([]models.Folder), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockSearchRepository) SearchTeams(ctx context.Context, orgID *uuid.UUID, q string, limit int) ([]models.Team, int64, error) {
+	args := m.Called(orgID, q, limit)
+	return args.Get(0).([]models.Team), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockSearchRepository) SearchUsers(ctx context.Context, orgID *uuid.UUID, q string, limit int) ([]models.User, int64, error) {
+	args := m.Called(orgID, q, limit)
+	return args.Get(0).([]models.User), args.Get(1).(int64), args.Error(2)
+}
+
+func TestSearchService_Search_RanksAcrossTypes(t *testing.T) {
+	// Setup
+	repo := new(MockSearchRepository)
+	service := NewSearchService(repo)
+
+	userID := uuid.New()
+	orgID := uuid.New()
+	now := time.Now()
+	repo.On("SearchNotes", userID, "roadmap", 2).Return([]models.Note{
+		{ID: uuid.New(), Title: "Weekly sync", Body: "<p>We discussed the <b>roadmap</b> for Q3</p>", UpdatedAt: now},
+	}, int64(4), nil)
+	repo.On("SearchFolders", userID, "roadmap", 2).Return([]models.Folder{
+		{ID: uuid.New(), Name: "Roadmap 2027", UpdatedAt: now.Add(-time.Hour)},
+	}, int64(1), nil)
+	repo.On("SearchTeams", &orgID, "roadmap", 2).Return([]models.Team{
+		{ID: uuid.New(), Name: "Roadmap", UpdatedAt: now.Add(-2 * time.Hour)},
+	}, int64(1), nil)
+	repo.On("SearchUsers", &orgID, "roadmap", 2).Return([]models.User{}, int64(0), nil)
+
+	// Test
+	results, err := service.Search(context.Background(), userID, &orgID, SearchQuery{Text: "roadmap", Limit: 2})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, results.Results, 2)
+	assert.Equal(t, SearchTypeTeam, results.Results[0].Type)
+	assert.Equal(t, 3, results.Results[0].Score)
+	assert.Equal(t, SearchTypeFolder, results.Results[1].Type)
+	assert.Equal(t, 2, results.Results[1].Score)
+	assert.Equal(t, map[string]int64{
+		SearchTypeNote: 4, SearchTypeFolder: 1, SearchTypeTeam: 1, SearchTypeUser: 0,
+	}, results.Facets)
+	repo.AssertExpectations(t)
+}
+
+func TestSearchService_Search_OnlyRequestedTypes(t *testing.T) {
+	// Setup
+	repo := new(MockSearchRepository)
+	service := NewSearchService(repo)
+
+	userID := uuid.New()
+	repo.On("SearchNotes", userID, "sync", DefaultSearchLimit).Return([]models.Note{
+		{ID: uuid.New(), Title: "Plan", Body: "<p>Weekly <b>sync</b> notes</p>"},
+	}, int64(1), nil)
+
+	// Test
+	results, err := service.Search(context.Background(), userID, nil, SearchQuery{Text: "sync", Types: []string{SearchTypeNote}})
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, results.Results, 1)
+	assert.Equal(t, 0, results.Results[0].Score)
+	assert.Equal(t, "Weekly sync notes", results.Results[0].Snippet)
+	assert.Equal(t, map[string]int64{SearchTypeNote: 1}, results.Facets)
+	repo.AssertNotCalled(t, "SearchUsers", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+)
+
+// MaxSharesPerRequest caps how many users one share request may name
+const MaxSharesPerRequest = 100
+
+// ShareEntry is a user to share with and the access they get. Access
+// defaults to the default access of the owner's teams.
+type ShareEntry struct {
+	UserID uuid.UUID          `json:"userId" binding:"required"`
+	Access models.AccessLevel `json:"access" binding:"omitempty,oneof=read write"`
+}
+
+// ShareResult reports a user a folder or note was shared with
+type ShareResult struct {
+	UserID uuid.UUID          `json:"userId"`
+	Access models.AccessLevel `json:"access"`
+	Status string             `json:"status"`
+}
+
+// ShareStatusShared is the status of a share that was made
+const ShareStatusShared = "shared"
+
+// shareEntries returns the users a share request names: userID with access
+// for one user, or shares for several. The access level may be left out,
+// to be filled in by withDefaultAccess.
+func shareEntries(userID uuid.UUID, access models.AccessLevel, shares []ShareEntry) ([]ShareEntry, error) {
+	if len(shares) == 0 {
+		if userID == uuid.Nil {
+			return nil, apperrors.ValidationFields("Invalid share", map[string]string{"shares": "give either userId and access, or shares"})
+		}
+		return []ShareEntry{{UserID: userID, Access: access}}, nil
+	}
+	if userID != uuid.Nil {
+		return nil, apperrors.ValidationFields("Invalid share", map[string]string{"shares": "give either userId and access, or shares"})
+	}
+
+	fields := make(map[string]string)
+	seen := make(map[uuid.UUID]bool, len(shares))
+	for i, entry := range shares {
+		if seen[entry.UserID] {
+			fields[fmt.Sprintf("shares[%d].userId", i)] = "is listed more than once"
+		}
+		seen[entry.UserID] = true
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.ValidationFields("Invalid share", fields)
+	}
+	return shares, nil
+}
+
+// withDefaultAccess returns entries with access given to those that leave it
+// out, rejecting them when access is empty
+func withDefaultAccess(entries []ShareEntry, bulk bool, access models.AccessLevel) ([]ShareEntry, error) {
+	fields := make(map[string]string)
+	filled := make([]ShareEntry, len(entries))
+	for i, entry := range entries {
+		if entry.Access == "" {
+			entry.Access = access
+		}
+		if entry.Access == "" && !bulk {
+			fields["shares"] = "give either userId and access, or shares"
+		} else if entry.Access == "" {
+			fields[fmt.Sprintf("shares[%d].access", i)] = "is required"
+		}
+		filled[i] = entry
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.ValidationFields("Invalid share", fields)
+	}
+	return filled, nil
+}
+
+// sharingPolicy is the combined sharing policy of the teams of the owner of
+// a folder or note
+type sharingPolicy struct {
+	teams repositories.TeamRepositoryInterface
+	// restricted are the owner's teams that do not allow sharing outside
+	// the team
+	restricted []uuid.UUID
+	// defaultAccess is write only when every team of the owner defaults to
+	// it, and empty when the owner is in no team
+	defaultAccess models.AccessLevel
+}
+
+// sharingPolicyOf returns the sharing policy over ownerID's folders and
+// notes. With no team repository, nothing is restricted.
+func sharingPolicyOf(ctx context.Context, teams repositories.TeamRepositoryInterface, ownerID uuid.UUID) (*sharingPolicy, error) {
+	policy := &sharingPolicy{teams: teams}
+	if teams == nil {
+		return policy, nil
+	}
+	owned, err := teams.GetUserTeams(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team settings: %w", err)
+	}
+	for i, team := range owned {
+		if !team.Settings.AllowExternalSharing {
+			policy.restricted = append(policy.restricted, team.ID)
+		}
+		if i == 0 || team.Settings.DefaultAccess == models.AccessRead {
+			policy.defaultAccess = team.Settings.DefaultAccess
+		}
+	}
+	return policy, nil
+}
+
+// check rejects sharing with userID when the owner's teams only allow
+// sharing within the team and userID is in none of them
+func (p *sharingPolicy) check(ctx context.Context, userID uuid.UUID) error {
+	if len(p.restricted) == 0 {
+		return nil
+	}
+	roles, err := p.teams.GetUserTeamRoles(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get teams: %w", err)
+	}
+	for _, id := range p.restricted {
+		if _, ok := roles[id]; ok {
+			return nil
+		}
+	}
+	return apperrors.Forbidden("your team only allows sharing with its members")
+}
+
+// shareAll shares with every entry through share in one transaction, so
+// that either all of them are shared or none are. share is given ctx
+// carrying actorID, for its writes to record. Every entry is tried; if
+// any fail, the error lists each failed entry by its index, except for
+// internal errors, which are returned as they are. A request naming one
+// user through userId gets that entry's error unchanged.
+func shareAll(ctx context.Context, tx repositories.TransactionManager, actorID uuid.UUID, entries []ShareEntry, bulk bool, share func(ctx context.Context, stores repositories.Stores, entry ShareEntry) error) ([]ShareResult, error) {
+	results := make([]ShareResult, len(entries))
+	actorCtx := database.WithActor(ctx, actorID)
+	err := tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		fields := make(map[string]string)
+		for i, entry := range entries {
+			err := share(actorCtx, stores, entry)
+			if err == nil {
+				results[i] = ShareResult{UserID: entry.UserID, Access: entry.Access, Status: ShareStatusShared}
+				continue
+			}
+			appErr := apperrors.From(err)
+			if !bulk || appErr.Code == apperrors.CodeInternal {
+				return err
+			}
+			fields[fmt.Sprintf("shares[%d]", i)] = appErr.Message
+		}
+		if len(fields) > 0 {
+			return apperrors.ValidationFields("No shares were made", fields)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/logger"
+	"seta-training/pkg/sso"
+)
+
+// usernameDisallowed matches what provisioned usernames may not contain
+var usernameDisallowed = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// SSOService signs users in through their organization's SAML identity
+// provider, creating their account on the first login
+type SSOService struct {
+	repo     repositories.SSORepositoryInterface
+	orgRepo  repositories.OrganizationRepositoryInterface
+	userRepo repositories.UserRepositoryInterface
+	users    SSOUserService
+	config   *sso.Config
+	audit    audit.Recorder
+	logger   logger.Logger
+}
+
+// NewSSOService creates an SSO service serving service providers built from
+// config. auditor and log may be nil.
+func NewSSOService(repo repositories.SSORepositoryInterface, orgRepo repositories.OrganizationRepositoryInterface, userRepo repositories.UserRepositoryInterface, users SSOUserService, config *sso.Config, auditor audit.Recorder, log logger.Logger) *SSOService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &SSOService{
+		repo:     repo,
+		orgRepo:  orgRepo,
+		userRepo: userRepo,
+		users:    users,
+		config:   config,
+		audit:    auditor,
+		logger:   log,
+	}
+}
+
+// SAMLConnectionInput configures an organization's identity provider.
+// Enabled defaults to true.
+type SAMLConnectionInput struct {
+	IDPMetadata       string                     `json:"idp_metadata" binding:"required"`
+	EmailAttribute    string                     `json:"email_attribute" binding:"max=255"`
+	UsernameAttribute string                     `json:"username_attribute" binding:"max=255"`
+	RoleAttribute     string                     `json:"role_attribute" binding:"max=255"`
+	RoleMapping       map[string]models.UserRole `json:"role_mapping"`
+	DefaultRole       models.UserRole            `json:"default_role" binding:"omitempty,oneof=manager member"`
+	Enabled           *bool                      `json:"enabled"`
+}
+
+// GetConnection returns the SAML connection of an organization
+func (s *SSOService) GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SAMLConnection, error) {
+	return s.repo.GetConnection(ctx, orgID)
+}
+
+// SaveConnection sets up or replaces the SAML connection of an organization
+func (s *SSOService) SaveConnection(ctx context.Context, orgID uuid.UUID, input *SAMLConnectionInput, actorID uuid.UUID) (*models.SAMLConnection, error) {
+	if _, err := s.orgRepo.GetByID(ctx, orgID); err != nil {
+		return nil, err
+	}
+	if _, err := sso.ParseMetadata(input.IDPMetadata); err != nil {
+		return nil, apperrors.ValidationFields("Invalid SAML connection", map[string]string{
+			"idp_metadata": "must be SAML identity provider metadata",
+		})
+	}
+	for _, role := range input.RoleMapping {
+		if role != models.RoleManager && role != models.RoleMember {
+			return nil, apperrors.ValidationFields("Invalid SAML connection", map[string]string{
+				"role_mapping": "roles must be manager or member",
+			})
+		}
+	}
+
+	conn := &models.SAMLConnection{
+		OrganizationID:    orgID,
+		IDPMetadata:       input.IDPMetadata,
+		EmailAttribute:    input.EmailAttribute,
+		UsernameAttribute: input.UsernameAttribute,
+		RoleAttribute:     input.RoleAttribute,
+		RoleMapping:       input.RoleMapping,
+		DefaultRole:       input.DefaultRole,
+		Enabled:           input.Enabled == nil || *input.Enabled,
+	}
+	if conn.RoleMapping == nil {
+		conn.RoleMapping = map[string]models.UserRole{}
+	}
+	if conn.DefaultRole == "" {
+		conn.DefaultRole = models.RoleMember
+	}
+	if err := s.repo.SaveConnection(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to save SAML connection: %w", err)
+	}
+
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetSAMLConnection,
+		TargetID:   conn.ID,
+		Details: map[string]string{
+			"organization_id": orgID.String(),
+			"enabled":         fmt.Sprint(conn.Enabled),
+		},
+	})
+	return conn, nil
+}
+
+// Metadata returns the service provider metadata of the organization with
+// slug, for registering it with the identity provider
+func (s *SSOService) Metadata(ctx context.Context, slug string) ([]byte, error) {
+	_, _, sp, err := s.serviceProvider(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return sp.Metadata()
+}
+
+// LoginURL starts a login with the identity provider of the organization
+// with slug. It returns the URL to send the user to and the ID of the
+// request, which CompleteLogin must be given. relayState comes back with
+// the response.
+func (s *SSOService) LoginURL(ctx context.Context, slug, relayState string) (string, string, error) {
+	_, conn, sp, err := s.serviceProvider(ctx, slug)
+	if err != nil {
+		return "", "", err
+	}
+	if !conn.Enabled {
+		return "", "", apperrors.Forbidden("single sign-on is disabled for this organization")
+	}
+	redirect, requestID, err := sp.AuthnRequest(relayState)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create authentication request: %w", err)
+	}
+	return redirect.String(), requestID, nil
+}
+
+// CompleteLogin checks the SAMLResponse the identity provider posted, which
+// must answer one of requestIDs, and logs its user in
+func (s *SSOService) CompleteLogin(ctx context.Context, slug, samlResponse string, requestIDs []string) (*LoginResponse, error) {
+	org, conn, sp, err := s.serviceProvider(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if !conn.Enabled {
+		return nil, apperrors.Forbidden("single sign-on is disabled for this organization")
+	}
+	assertion, err := sp.ParseResponse(samlResponse, requestIDs)
+	if err != nil {
+		if errors.Is(err, sso.ErrInvalidResponse) {
+			s.logger.Warn("Rejected SAML response", logger.String("organization", slug), logger.Error(err))
+			return nil, apperrors.Unauthorized("invalid SAML response")
+		}
+		return nil, fmt.Errorf("failed to check SAML response: %w", err)
+	}
+	return s.signIn(ctx, org, conn, assertion)
+}
+
+// serviceProvider returns the organization with slug, its connection and
+// the service provider trusting its identity provider
+func (s *SSOService) serviceProvider(ctx context.Context, slug string) (*models.Organization, *models.SAMLConnection, *sso.ServiceProvider, error) {
+	org, err := s.repo.GetOrganizationBySlug(ctx, slug)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conn, err := s.repo.GetConnection(ctx, org.ID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	idp, err := sso.ParseMetadata(conn.IDPMetadata)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse identity provider metadata: %w", err)
+	}
+	return org, conn, s.config.ServiceProvider(org.Slug, idp), nil
+}
+
+// signIn logs in the user the assertion is about, creating them in org when
+// they have no account yet. Their role follows the connection's role
+// mapping when it has a role attribute.
+func (s *SSOService) signIn(ctx context.Context, org *models.Organization, conn *models.SAMLConnection, assertion *sso.Assertion) (*LoginResponse, error) {
+	email := assertion.NameID
+	if conn.EmailAttribute != "" {
+		email = assertion.Attribute(conn.EmailAttribute)
+	}
+	address, err := mail.ParseAddress(email)
+	if err != nil {
+		return nil, apperrors.Unauthorized("SAML response has no valid email")
+	}
+	email = address.Address
+	role := conn.DefaultRole
+	for _, value := range assertion.Attributes[conn.RoleAttribute] {
+		if mapped, ok := conn.RoleMapping[value]; ok {
+			role = mapped
+			if mapped == models.RoleManager {
+				break
+			}
+		}
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		user, err = s.provision(ctx, org, conn, assertion, email, role)
+		if err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	case !models.SameOrganization(user.OrganizationID, &org.ID):
+		return nil, apperrors.Forbidden("user belongs to another organization")
+	case conn.RoleAttribute != "" && user.Role != role:
+		if err := s.repo.SetUserRole(ctx, user.ID, role); err != nil {
+			return nil, fmt.Errorf("failed to update role: %w", err)
+		}
+		s.audit.Record(ctx, audit.Entry{
+			ActorID:    user.ID,
+			Action:     audit.ActionSetRole,
+			TargetType: audit.TargetUser,
+			TargetID:   user.ID,
+			Details:    map[string]string{"from": string(user.Role), "to": string(role), "source": "saml"},
+		})
+		user.Role = role
+	}
+	return s.users.IssueLogin(ctx, user)
+}
+
+// provision creates the user of a first SAML login. They get a random
+// password, so they can only sign in through single sign-on.
+func (s *SSOService) provision(ctx context.Context, org *models.Organization, conn *models.SAMLConnection, assertion *sso.Assertion, email string, role models.UserRole) (*models.User, error) {
+	username, err := uniqueUsername(ctx, s.userRepo, assertion.Attribute(conn.UsernameAttribute), email)
+	if err != nil {
+		return nil, err
+	}
+	password, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.users.CreateUser(ctx, &CreateUserInput{
+		Username:       username,
+		Email:          email,
+		Password:       password,
+		Role:           role,
+		OrganizationID: &org.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Provisioned user from SAML login",
+		logger.String("user_id", user.ID.String()),
+		logger.String("organization_id", org.ID.String()),
+	)
+	return user, nil
+}
+
+// uniqueUsername returns name, or the local part of email when name is
+// empty, made valid and suffixed when already taken
+func uniqueUsername(ctx context.Context, userRepo repositories.UserRepositoryInterface, name, email string) (string, error) {
+	if name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	base := usernameDisallowed.ReplaceAllString(name, "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if len(base) < 3 {
+		base = strings.TrimPrefix(base+"-user", "-")
+	}
+	username := base
+	for attempt := 0; ; attempt++ {
+		exists, err := userRepo.UsernameExists(ctx, username)
+		if err != nil {
+			return "", fmt.Errorf("failed to check username existence: %w", err)
+		}
+		if !exists {
+			return username, nil
+		}
+		if attempt == 5 {
+			return "", apperrors.Conflict("username already exists")
+		}
+		suffix, err := randomHex(2)
+		if err != nil {
+			return "", err
+		}
+		username = base + "-" + suffix
+	}
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
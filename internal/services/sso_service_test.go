@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/sso"
+)
+
+const testIDPMetadata = `<EntityDescriptor xmlns="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.com/metadata">
+  <IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso"/>
+  </IDPSSODescriptor>
+</EntityDescriptor>`
+
+// MockSSORepository is a mock implementation of SSORepositoryInterface
+type MockSSORepository struct {
+	mock.Mock
+}
+
+func (m *MockSSORepository) GetOrganizationBySlug(ctx context.Context, slug string) (*models.Organization, error) {
+	args := m.Called(slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Organization), args.Error(1)
+}
+
+func (m *MockSSORepository) GetConnection(ctx context.Context, orgID uuid.UUID) (*models.SAMLConnection, error) {
+	args := m.Called(orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SAMLConnection), args.Error(1)
+}
+
+func (m *MockSSORepository) SaveConnection(ctx context.Context, conn *models.SAMLConnection) error {
+	args := m.Called(conn)
+	return args.Error(0)
+}
+
+func (m *MockSSORepository) SetUserRole(ctx context.Context, userID uuid.UUID, role models.UserRole) error {
+	args := m.Called(userID, role)
+	return args.Error(0)
+}
+
+func newTestSSOService(repo *MockSSORepository, orgRepo *MockOrganizationRepository, userRepo *MockUserRepository, users *MockUserService) *SSOService {
+	baseURL, _ := url.Parse("https://api.example.com")
+	return NewSSOService(repo, orgRepo, userRepo, users, &sso.Config{BaseURL: *baseURL}, nil, nil)
+}
+
+func TestSSOService_SaveConnection(t *testing.T) {
+	ctx := context.Background()
+	orgID := uuid.New()
+
+	newService := func() (*SSOService, *MockSSORepository) {
+		repo, orgRepo := new(MockSSORepository), new(MockOrganizationRepository)
+		orgRepo.On("GetByID", orgID).Return(&models.Organization{ID: orgID, Slug: "acme"}, nil)
+		return newTestSSOService(repo, orgRepo, new(MockUserRepository), new(MockUserService)), repo
+	}
+
+	t.Run("saves the connection with defaults", func(t *testing.T) {
+		service, repo := newService()
+		repo.On("SaveConnection", mock.AnythingOfType("*models.SAMLConnection")).Return(nil)
+
+		conn, err := service.SaveConnection(ctx, orgID, &SAMLConnectionInput{IDPMetadata: testIDPMetadata}, uuid.New())
+		require.NoError(t, err)
+		assert.Equal(t, orgID, conn.OrganizationID)
+		assert.Equal(t, models.RoleMember, conn.DefaultRole)
+		assert.True(t, conn.Enabled)
+		assert.NotNil(t, conn.RoleMapping)
+	})
+
+	t.Run("rejects metadata that is not an identity provider's", func(t *testing.T) {
+		service, repo := newService()
+
+		_, err := service.SaveConnection(ctx, orgID, &SAMLConnectionInput{IDPMetadata: "<html/>"}, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Contains(t, apperrors.From(err).Fields, "idp_metadata")
+		repo.AssertNotCalled(t, "SaveConnection", mock.Anything)
+	})
+
+	t.Run("rejects unknown roles in the mapping", func(t *testing.T) {
+		service, repo := newService()
+
+		_, err := service.SaveConnection(ctx, orgID, &SAMLConnectionInput{
+			IDPMetadata: testIDPMetadata,
+			RoleMapping: map[string]models.UserRole{"admins": "admin"},
+		}, uuid.New())
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+		assert.Contains(t, apperrors.From(err).Fields, "role_mapping")
+		repo.AssertNotCalled(t, "SaveConnection", mock.Anything)
+	})
+}
+
+func TestSSOService_LoginURL(t *testing.T) {
+	ctx := context.Background()
+	org := &models.Organization{ID: uuid.New(), Slug: "acme"}
+
+	newService := func(enabled bool) *SSOService {
+		repo := new(MockSSORepository)
+		repo.On("GetOrganizationBySlug", "acme").Return(org, nil)
+		repo.On("GetConnection", org.ID).Return(&models.SAMLConnection{OrganizationID: org.ID, IDPMetadata: testIDPMetadata, Enabled: enabled}, nil)
+		return newTestSSOService(repo, new(MockOrganizationRepository), new(MockUserRepository), new(MockUserService))
+	}
+
+	t.Run("redirects to the identity provider", func(t *testing.T) {
+		loginURL, requestID, err := newService(true).LoginURL(ctx, "acme", "https://app.example.com/home")
+		require.NoError(t, err)
+		assert.NotEmpty(t, requestID)
+
+		u, err := url.Parse(loginURL)
+		require.NoError(t, err)
+		assert.Equal(t, "idp.example.com", u.Host)
+		assert.NotEmpty(t, u.Query().Get("SAMLRequest"))
+		assert.Equal(t, "https://app.example.com/home", u.Query().Get("RelayState"))
+	})
+
+	t.Run("refuses disabled connections", func(t *testing.T) {
+		_, _, err := newService(false).LoginURL(ctx, "acme", "")
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	})
+
+	t.Run("serves metadata with the organization's endpoints", func(t *testing.T) {
+		metadata, err := newService(false).Metadata(ctx, "acme")
+		require.NoError(t, err)
+		assert.Contains(t, string(metadata), `entityID="https://api.example.com/sso/saml/acme/metadata"`)
+		assert.Contains(t, string(metadata), `Location="https://api.example.com/sso/saml/acme/acs"`)
+	})
+}
+
+func TestSSOService_SignIn(t *testing.T) {
+	ctx := context.Background()
+	org := &models.Organization{ID: uuid.New(), Slug: "acme"}
+	conn := &models.SAMLConnection{
+		OrganizationID: org.ID,
+		RoleAttribute:  "groups",
+		RoleMapping:    map[string]models.UserRole{"leads": models.RoleManager, "staff": models.RoleMember},
+		DefaultRole:    models.RoleMember,
+		Enabled:        true,
+	}
+	assertion := &sso.Assertion{
+		NameID:     "jane.doe@acme.com",
+		Attributes: map[string][]string{"groups": {"staff", "leads"}},
+	}
+
+	newService := func() (*SSOService, *MockSSORepository, *MockUserRepository, *MockUserService) {
+		repo, userRepo, users := new(MockSSORepository), new(MockUserRepository), new(MockUserService)
+		users.On("IssueLogin", mock.AnythingOfType("*models.User")).Return(&LoginResponse{Token: "token"}, nil)
+		return newTestSSOService(repo, new(MockOrganizationRepository), userRepo, users), repo, userRepo, users
+	}
+
+	t.Run("creates users on their first login", func(t *testing.T) {
+		service, _, userRepo, users := newService()
+		userRepo.On("GetByEmail", "jane.doe@acme.com").Return(nil, apperrors.NotFound("user not found"))
+		userRepo.On("UsernameExists", "jane.doe").Return(true, nil).Once()
+		userRepo.On("UsernameExists", mock.AnythingOfType("string")).Return(false, nil)
+		users.On("CreateUser", mock.AnythingOfType("*services.CreateUserInput")).Return(&models.User{ID: uuid.New()}, nil)
+
+		response, err := service.signIn(ctx, org, conn, assertion)
+		require.NoError(t, err)
+		assert.Equal(t, "token", response.Token)
+
+		input := users.Calls[0].Arguments.Get(0).(*CreateUserInput)
+		assert.Equal(t, "jane.doe@acme.com", input.Email)
+		assert.Regexp(t, `^jane\.doe-[0-9a-f]{4}$`, input.Username)
+		assert.Equal(t, models.RoleManager, input.Role)
+		assert.Equal(t, &org.ID, input.OrganizationID)
+		assert.NotEmpty(t, input.Password)
+	})
+
+	t.Run("updates the role of existing users", func(t *testing.T) {
+		service, repo, userRepo, _ := newService()
+		user := &models.User{ID: uuid.New(), Email: "jane.doe@acme.com", Role: models.RoleMember, OrganizationID: &org.ID}
+		userRepo.On("GetByEmail", "jane.doe@acme.com").Return(user, nil)
+		repo.On("SetUserRole", user.ID, models.RoleManager).Return(nil)
+
+		_, err := service.signIn(ctx, org, conn, assertion)
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleManager, user.Role)
+	})
+
+	t.Run("refuses users of another organization", func(t *testing.T) {
+		service, _, userRepo, users := newService()
+		userRepo.On("GetByEmail", "jane.doe@acme.com").Return(&models.User{ID: uuid.New(), Email: "jane.doe@acme.com"}, nil)
+
+		_, err := service.signIn(ctx, org, conn, assertion)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		users.AssertNotCalled(t, "IssueLogin", mock.Anything)
+	})
+
+	t.Run("refuses assertions without an email", func(t *testing.T) {
+		service, _, userRepo, _ := newService()
+
+		_, err := service.signIn(ctx, org, conn, &sso.Assertion{NameID: "jane"})
+		assert.ErrorIs(t, err, apperrors.ErrUnauthorized)
+		userRepo.AssertNotCalled(t, "GetByEmail", mock.Anything)
+	})
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+)
+
+// MaxStatsDays is the longest series GetStats returns
+const MaxStatsDays = 365
+
+// StatsService computes the admin dashboard statistics
+type StatsService struct {
+	statsRepo repositories.StatsRepositoryInterface
+	now       func() time.Time
+}
+
+func NewStatsService(statsRepo repositories.StatsRepositoryInterface) *StatsService {
+	return &StatsService{
+		statsRepo: statsRepo,
+		now:       time.Now,
+	}
+}
+
+// Stats is what GET /admin/stats returns
+type Stats struct {
+	Totals models.StatsTotals `json:"totals"`
+	Series []models.DayStats  `json:"series"`
+}
+
+// GetStats returns the totals and one entry for each of the last days
+// days, today included
+func (s *StatsService) GetStats(ctx context.Context, days int) (*Stats, error) {
+	totals, err := s.statsRepo.Totals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count totals: %w", err)
+	}
+
+	to := s.now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	series, err := s.statsRepo.Daily(ctx, to.AddDate(0, 0, -days), to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count daily statistics: %w", err)
+	}
+	return &Stats{
+		Totals: totals,
+		Series: series,
+	}, nil
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+)
+
+// MockStatsRepository is a mock implementation of StatsRepositoryInterface
+type MockStatsRepository struct {
+	mock.Mock
+}
+
+func (m *MockStatsRepository) Totals(ctx context.Context) (models.StatsTotals, error) {
+	args := m.Called()
+	return args.Get(0).(models.StatsTotals), args.Error(1)
+}
+
+func (m *MockStatsRepository) Daily(ctx context.Context, from, to time.Time) ([]models.DayStats, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]models.DayStats), args.Error(1)
+}
+
+func TestStatsService_GetStats_CoversTheLastDays(t *testing.T) {
+	// Setup
+	statsRepo := new(MockStatsRepository)
+	service := NewStatsService(statsRepo)
+	service.now = func() time.Time { return time.Date(2026, 10, 16, 23, 30, 0, 0, time.UTC) }
+
+	totals := models.StatsTotals{Users: 12, Notes: 40, Shares: 3}
+	series := []models.DayStats{{Day: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)}, {Day: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)}}
+	statsRepo.On("Totals").Return(totals, nil)
+	statsRepo.On("Daily", time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)).Return(series, nil)
+
+	// Test
+	stats, err := service.GetStats(context.Background(), 2)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, totals, stats.Totals)
+	assert.Equal(t, series, stats.Series)
+	statsRepo.AssertExpectations(t)
+}
+
+func TestAuditImportNotifier_RecordsImports(t *testing.T) {
+	recorder := new(MockAuditRecorder)
+	notifier := NewAuditImportNotifier(recorder)
+
+	managerID := "6f1c1a8e-3c4b-4b7a-9d7e-2f0a8b3c5d6e"
+	recorder.On("Record", mock.MatchedBy(func(entry audit.Entry) bool {
+		return entry.ActorID.String() == managerID &&
+			entry.Action == audit.ActionImport &&
+			entry.Details["succeeded"] == "8" &&
+			entry.Details["failed"] == "2"
+	})).Once()
+
+	notifier.ImportFinished(context.Background(), ImportEvent{
+		Event:     ImportEventCompleted,
+		ManagerID: managerID,
+		Filename:  "users.csv",
+		Summary:   &ImportSummary{SuccessCount: 8, FailureCount: 2},
+	})
+
+	recorder.AssertExpectations(t)
+}
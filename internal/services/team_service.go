@@ -1,23 +1,44 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 
 	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/database"
 	"seta-training/internal/models"
 	"seta-training/internal/repositories"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/pagination"
 )
 
 type TeamService struct {
 	teamRepo repositories.TeamRepositoryInterface
 	userRepo repositories.UserRepositoryInterface
+	tx       repositories.TransactionManager
+	audit    audit.Recorder
 }
 
-func NewTeamService(teamRepo repositories.TeamRepositoryInterface, userRepo repositories.UserRepositoryInterface) *TeamService {
+// NewTeamService creates a team service. txManager may be nil to write
+// through the given repositories without a transaction, and auditor may be
+// nil to disable audit logging.
+func NewTeamService(teamRepo repositories.TeamRepositoryInterface, userRepo repositories.UserRepositoryInterface, txManager repositories.TransactionManager, auditor audit.Recorder) *TeamService {
+	if txManager == nil {
+		txManager = repositories.NoTx{Stores: repositories.Stores{Users: userRepo, Teams: teamRepo}}
+	}
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
 	return &TeamService{
 		teamRepo: teamRepo,
 		userRepo: userRepo,
+		tx:       txManager,
+		audit:    auditor,
 	}
 }
 
@@ -25,6 +46,9 @@ type CreateTeamInput struct {
 	Name     string                `json:"teamName" binding:"required,min=3,max=100"`
 	Managers []TeamMemberInput     `json:"managers"`
 	Members  []TeamMemberInput     `json:"members"`
+	// SharedFolder provisions a folder owned by the creator and shared
+	// with everyone in the team
+	SharedFolder bool `json:"sharedFolder"`
 }
 
 type TeamMemberInput struct {
@@ -32,121 +56,335 @@ type TeamMemberInput struct {
 	Name string    `json:"managerName,omitempty"`
 }
 
-func (s *TeamService) CreateTeam(input *CreateTeamInput, creatorID uuid.UUID) (*models.Team, error) {
+func (s *TeamService) CreateTeam(ctx context.Context, input *CreateTeamInput, creatorID uuid.UUID) (*models.Team, error) {
 	// Verify creator is a manager
-	creator, err := s.userRepo.GetByID(creatorID)
+	creator, err := s.userRepo.GetByID(ctx, creatorID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get creator: %w", err)
 	}
 	if !creator.IsManager() {
-		return nil, errors.New("only managers can create teams")
+		return nil, apperrors.Forbidden("only managers can create teams")
 	}
 
 	// Create team
 	team := &models.Team{
-		Name: input.Name,
+		Name:           input.Name,
+		OrganizationID: creator.OrganizationID,
+		Settings:       models.DefaultTeamSettings(),
 	}
 
-	if err := s.teamRepo.Create(team); err != nil {
-		return nil, fmt.Errorf("failed to create team: %w", err)
-	}
+	// The team and its memberships are written together so a failure never
+	// leaves a partially staffed team behind
+	actorCtx := database.WithActor(ctx, creatorID)
+	err = s.tx.WithTx(actorCtx, func(stores repositories.Stores) error {
+		// The membership hooks share the team folder as people are added
+		if input.SharedFolder {
+			folder := &models.Folder{Name: input.Name, OwnerID: creatorID}
+			if err := stores.Folders.Create(actorCtx, folder); err != nil {
+				return fmt.Errorf("failed to create team folder: %w", err)
+			}
+			team.FolderID = &folder.ID
+		}
+		if err := stores.Teams.Create(actorCtx, team); err != nil {
+			return fmt.Errorf("failed to create team: %w", err)
+		}
 
-	// Add creator as manager
-	if err := s.teamRepo.AddManager(team.ID, creatorID); err != nil {
-		return nil, fmt.Errorf("failed to add creator as manager: %w", err)
-	}
+		// Add creator as admin
+		if err := stores.Teams.AddMembership(actorCtx, team.ID, creatorID, models.TeamRoleAdmin); err != nil {
+			return fmt.Errorf("failed to add creator as admin: %w", err)
+		}
 
-	// Add additional managers
-	for _, manager := range input.Managers {
-		if manager.ID != creatorID { // Don't add creator twice
+		// Add additional managers
+		for _, manager := range input.Managers {
+			if manager.ID == creatorID { // Don't add creator twice
+				continue
+			}
 			// Verify user exists and is a manager
-			user, err := s.userRepo.GetByID(manager.ID)
+			user, err := stores.Users.GetByID(actorCtx, manager.ID)
+			if errors.Is(err, apperrors.ErrNotFound) {
+				continue // Skip unknown users
+			}
 			if err != nil {
-				continue // Skip invalid users
+				return fmt.Errorf("failed to get manager: %w", err)
 			}
-			if user.IsManager() {
-				s.teamRepo.AddManager(team.ID, manager.ID)
+			if !user.IsManager() {
+				continue
+			}
+			if err := stores.Teams.AddMembership(actorCtx, team.ID, manager.ID, models.TeamRoleManager); err != nil {
+				return fmt.Errorf("failed to add manager: %w", err)
 			}
 		}
-	}
 
-	// Add members
-	for _, member := range input.Members {
-		// Verify user exists
-		if _, err := s.userRepo.GetByID(member.ID); err == nil {
-			s.teamRepo.AddMember(team.ID, member.ID)
+		// Add members
+		for _, member := range input.Members {
+			// Verify user exists
+			_, err := stores.Users.GetByID(actorCtx, member.ID)
+			if errors.Is(err, apperrors.ErrNotFound) {
+				continue // Skip unknown users
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get member: %w", err)
+			}
+			if err := stores.Teams.AddMembership(actorCtx, team.ID, member.ID, models.TeamRoleMember); err != nil {
+				return fmt.Errorf("failed to add member: %w", err)
+			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    creatorID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetTeam,
+		TargetID:   team.ID,
+		Details:    map[string]string{"name": team.Name},
+	})
 
 	// Return team with relationships loaded
-	return s.teamRepo.GetByID(team.ID)
+	return s.teamRepo.GetByID(ctx, team.ID)
 }
 
-func (s *TeamService) AddMember(teamID, userID, managerID uuid.UUID) error {
-	// Verify manager has permission
-	if err := s.verifyManagerPermission(teamID, managerID); err != nil {
+// AddMember adds a user to a team as a member
+func (s *TeamService) AddMember(ctx context.Context, teamID, userID, managerID uuid.UUID) error {
+	if err := s.join(ctx, teamID, userID, models.TeamRoleMember, managerID); err != nil {
 		return err
 	}
+	s.recordMembership(ctx, managerID, audit.ActionAddMember, teamID, userID)
+	return nil
+}
 
-	// Verify user exists
-	if _, err := s.userRepo.GetByID(userID); err != nil {
-		return errors.New("user not found")
+// RemoveMember takes a user out of a team, whatever their role
+func (s *TeamService) RemoveMember(ctx context.Context, teamID, userID, managerID uuid.UUID) error {
+	if err := s.leave(ctx, teamID, userID, managerID); err != nil {
+		return err
 	}
+	s.recordMembership(ctx, managerID, audit.ActionRemoveMember, teamID, userID)
+	return nil
+}
 
-	return s.teamRepo.AddMember(teamID, userID)
+// AddManager adds a user, who must be a manager, to a team as a manager
+func (s *TeamService) AddManager(ctx context.Context, teamID, userID, requestorID uuid.UUID) error {
+	if err := s.join(ctx, teamID, userID, models.TeamRoleManager, requestorID); err != nil {
+		return err
+	}
+	s.recordMembership(ctx, requestorID, audit.ActionAddManager, teamID, userID)
+	return nil
 }
 
-func (s *TeamService) RemoveMember(teamID, userID, managerID uuid.UUID) error {
-	// Verify manager has permission
-	if err := s.verifyManagerPermission(teamID, managerID); err != nil {
+// RemoveManager takes a user out of a team, whatever their role
+func (s *TeamService) RemoveManager(ctx context.Context, teamID, userID, requestorID uuid.UUID) error {
+	if err := s.leave(ctx, teamID, userID, requestorID); err != nil {
 		return err
 	}
+	s.recordMembership(ctx, requestorID, audit.ActionRemoveManager, teamID, userID)
+	return nil
+}
 
-	return s.teamRepo.RemoveMember(teamID, userID)
+// SetRoleInput is the role to give a user in a team
+type SetRoleInput struct {
+	Role models.TeamRole `json:"role" binding:"required,oneof=admin manager member viewer"`
 }
 
-func (s *TeamService) AddManager(teamID, userID, requestorID uuid.UUID) error {
-	// Verify requestor has permission
-	if err := s.verifyManagerPermission(teamID, requestorID); err != nil {
+// SetRole gives a user a role in a team, adding them to it if needed.
+// Managers may give and take away any role but admin; admins, any role.
+// Only users who are managers may be given the admin and manager roles.
+func (s *TeamService) SetRole(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole, requesterID uuid.UUID) error {
+	current, err := s.authorizeRole(ctx, teamID, userID, role, requesterID)
+	if err != nil {
 		return err
 	}
+	switch current {
+	case role:
+		return nil
+	case "":
+		err = s.teamRepo.AddMembership(ctx, teamID, userID, role)
+	default:
+		err = s.teamRepo.SetRole(ctx, teamID, userID, role)
+	}
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    requesterID,
+		Action:     audit.ActionSetRole,
+		TargetType: audit.TargetTeam,
+		TargetID:   teamID,
+		Details:    map[string]string{"user_id": userID.String(), "role": string(role)},
+	})
+	return nil
+}
 
-	// Verify user exists and is a manager
-	user, err := s.userRepo.GetByID(userID)
+// join adds a user who is not in the team yet with role
+func (s *TeamService) join(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole, requesterID uuid.UUID) error {
+	current, err := s.authorizeRole(ctx, teamID, userID, role, requesterID)
 	if err != nil {
-		return errors.New("user not found")
+		return err
 	}
-	if !user.IsManager() {
-		return errors.New("user must be a manager")
+	if current != "" {
+		return apperrors.Conflict("user is already in the team as %s", current)
 	}
-
-	return s.teamRepo.AddManager(teamID, userID)
+	return s.teamRepo.AddMembership(ctx, teamID, userID, role)
 }
 
-func (s *TeamService) RemoveManager(teamID, userID, requestorID uuid.UUID) error {
-	// Verify requestor has permission
-	if err := s.verifyManagerPermission(teamID, requestorID); err != nil {
+// leave takes a user out of the team, if they are in it
+func (s *TeamService) leave(ctx context.Context, teamID, userID, requesterID uuid.UUID) error {
+	requesterRole, err := s.managerRole(ctx, teamID, requesterID)
+	if err != nil {
 		return err
 	}
+	current, err := s.teamRepo.GetRole(ctx, teamID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get team role: %w", err)
+	}
+	if current == "" {
+		return nil
+	}
+	if !requesterRole.CanAssign(current) {
+		return apperrors.Forbidden("insufficient permissions: only team admins can change admins")
+	}
+	return s.teamRepo.RemoveMembership(ctx, teamID, userID)
+}
+
+// authorizeRole checks that requesterID may give role to userID in the team
+// and returns the role userID holds now, if any
+func (s *TeamService) authorizeRole(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole, requesterID uuid.UUID) (models.TeamRole, error) {
+	requesterRole, err := s.managerRole(ctx, teamID, requesterID)
+	if err != nil {
+		return "", err
+	}
+
+	// Verify user exists, and is a manager for the roles that manage
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", apperrors.NotFound("user not found")
+	}
+	if role.Manages() && !user.IsManager() {
+		return "", apperrors.Validation("user must be a manager")
+	}
 
-	return s.teamRepo.RemoveManager(teamID, userID)
+	current, err := s.teamRepo.GetRole(ctx, teamID, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get team role: %w", err)
+	}
+	if !requesterRole.CanAssign(role) || (current != "" && !requesterRole.CanAssign(current)) {
+		return "", apperrors.Forbidden("insufficient permissions: only team admins can change admins")
+	}
+	return current, nil
 }
 
-func (s *TeamService) GetTeam(teamID uuid.UUID) (*models.Team, error) {
-	return s.teamRepo.GetByID(teamID)
+// TeamSettingsInput changes the given team settings and leaves omitted ones
+// as they are
+type TeamSettingsInput struct {
+	AllowExternalSharing *bool               `json:"allowExternalSharing"`
+	AllowPublicLinks     *bool               `json:"allowPublicLinks"`
+	DefaultAccess        *models.AccessLevel `json:"defaultAccess" binding:"omitempty,oneof=read write"`
 }
 
-func (s *TeamService) GetAllTeams() ([]models.Team, error) {
-	return s.teamRepo.GetAll()
+// UpdateSettings changes the sharing policy of a team. Only its admins and
+// managers may change it.
+func (s *TeamService) UpdateSettings(ctx context.Context, teamID uuid.UUID, input *TeamSettingsInput, managerID uuid.UUID) (*models.Team, error) {
+	if err := s.verifyManagerPermission(ctx, teamID, managerID); err != nil {
+		return nil, err
+	}
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.AllowExternalSharing != nil {
+		team.Settings.AllowExternalSharing = *input.AllowExternalSharing
+	}
+	if input.AllowPublicLinks != nil {
+		team.Settings.AllowPublicLinks = *input.AllowPublicLinks
+	}
+	if input.DefaultAccess != nil {
+		team.Settings.DefaultAccess = *input.DefaultAccess
+	}
+	if err := s.teamRepo.UpdateSettings(ctx, teamID, team.Settings); err != nil {
+		return nil, fmt.Errorf("failed to update team settings: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    managerID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetTeam,
+		TargetID:   teamID,
+		Details: map[string]string{
+			"allow_external_sharing": strconv.FormatBool(team.Settings.AllowExternalSharing),
+			"allow_public_links":     strconv.FormatBool(team.Settings.AllowPublicLinks),
+			"default_access":         string(team.Settings.DefaultAccess),
+		},
+	})
+	return team, nil
+}
+
+func (s *TeamService) recordMembership(ctx context.Context, actorID uuid.UUID, action string, teamID, userID uuid.UUID) {
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: audit.TargetTeam,
+		TargetID:   teamID,
+		Details:    map[string]string{"user_id": userID.String()},
+	})
 }
 
-func (s *TeamService) verifyManagerPermission(teamID, userID uuid.UUID) error {
-	isManager, err := s.teamRepo.IsManager(teamID, userID)
+// GetTeam returns a team of orgID, or of the default tenant when nil. Teams
+// of other organizations are reported as not found.
+func (s *TeamService) GetTeam(ctx context.Context, teamID uuid.UUID, orgID *uuid.UUID) (*models.Team, error) {
+	team, err := s.teamRepo.GetByID(ctx, teamID)
 	if err != nil {
-		return fmt.Errorf("failed to check manager status: %w", err)
+		return nil, err
 	}
-	if !isManager {
-		return errors.New("insufficient permissions: user is not a manager of this team")
+	if !models.SameOrganization(team.OrganizationID, orgID) {
+		return nil, apperrors.NotFound("team not found")
 	}
+	return team, nil
+}
+
+// GetAllTeams returns the teams of orgID, or of the default tenant when nil
+func (s *TeamService) GetAllTeams(ctx context.Context, orgID *uuid.UUID) ([]models.Team, error) {
+	return s.teamRepo.GetAll(ctx, orgID)
+}
+
+// ListTeams returns one page of the teams of orgID, or of the default
+// tenant when nil
+func (s *TeamService) ListTeams(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.Team], error) {
+	return s.teamRepo.ListPage(ctx, orgID, p)
+}
+
+// BuildClaims adds the user's team memberships to a token. It is the JWT
+// manager's claims builder, so team-scoped routes can be authorized from
+// the token alone.
+func (s *TeamService) BuildClaims(claims *auth.Claims) error {
+	roles, err := s.teamRepo.GetUserTeamRoles(context.Background(), claims.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load team memberships: %w", err)
+	}
+	claims.Teams = make([]auth.TeamClaim, 0, len(roles))
+	for teamID, role := range roles {
+		claims.Teams = append(claims.Teams, auth.TeamClaim{ID: teamID, Role: role})
+	}
+	sort.Slice(claims.Teams, func(i, j int) bool {
+		return claims.Teams[i].ID.String() < claims.Teams[j].ID.String()
+	})
 	return nil
 }
+
+func (s *TeamService) verifyManagerPermission(ctx context.Context, teamID, userID uuid.UUID) error {
+	_, err := s.managerRole(ctx, teamID, userID)
+	return err
+}
+
+// managerRole returns the role of userID in the team, which must be one
+// that manages it
+func (s *TeamService) managerRole(ctx context.Context, teamID, userID uuid.UUID) (models.TeamRole, error) {
+	role, err := s.teamRepo.GetRole(ctx, teamID, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to check manager status: %w", err)
+	}
+	if !role.Manages() {
+		return "", apperrors.Forbidden("insufficient permissions: user is not a manager of this team")
+	}
+	return role, nil
+}
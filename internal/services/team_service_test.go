@@ -1,12 +1,20 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/internal/testutils"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/pagination"
 )
 
 // MockTeamRepository is a mock implementation of TeamRepositoryInterface
@@ -14,12 +22,12 @@ type MockTeamRepository struct {
 	mock.Mock
 }
 
-func (m *MockTeamRepository) Create(team *models.Team) error {
+func (m *MockTeamRepository) Create(ctx context.Context, team *models.Team) error {
 	args := m.Called(team)
 	return args.Error(0)
 }
 
-func (m *MockTeamRepository) GetByID(id uuid.UUID) (*models.Team, error) {
+func (m *MockTeamRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Team, error) {
 	args := m.Called(id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -27,47 +35,81 @@ func (m *MockTeamRepository) GetByID(id uuid.UUID) (*models.Team, error) {
 	return args.Get(0).(*models.Team), args.Error(1)
 }
 
-func (m *MockTeamRepository) GetAll() ([]models.Team, error) {
-	args := m.Called()
+func (m *MockTeamRepository) GetAll(ctx context.Context, orgID *uuid.UUID) ([]models.Team, error) {
+	args := m.Called(orgID)
 	return args.Get(0).([]models.Team), args.Error(1)
 }
 
-func (m *MockTeamRepository) AddManager(teamID, userID uuid.UUID) error {
-	args := m.Called(teamID, userID)
+func (m *MockTeamRepository) ListPage(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.Team], error) {
+	args := m.Called(orgID, p)
+	return args.Get(0).(pagination.Page[models.Team]), args.Error(1)
+}
+
+func (m *MockTeamRepository) AddMembership(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole) error {
+	args := m.Called(teamID, userID, role)
 	return args.Error(0)
 }
 
-func (m *MockTeamRepository) RemoveManager(teamID, userID uuid.UUID) error {
-	args := m.Called(teamID, userID)
+func (m *MockTeamRepository) SetRole(ctx context.Context, teamID, userID uuid.UUID, role models.TeamRole) error {
+	args := m.Called(teamID, userID, role)
 	return args.Error(0)
 }
 
-func (m *MockTeamRepository) AddMember(teamID, userID uuid.UUID) error {
+func (m *MockTeamRepository) RemoveMembership(ctx context.Context, teamID, userID uuid.UUID) error {
 	args := m.Called(teamID, userID)
 	return args.Error(0)
 }
 
-func (m *MockTeamRepository) RemoveMember(teamID, userID uuid.UUID) error {
+func (m *MockTeamRepository) GetRole(ctx context.Context, teamID, userID uuid.UUID) (models.TeamRole, error) {
 	args := m.Called(teamID, userID)
+	return args.Get(0).(models.TeamRole), args.Error(1)
+}
+
+func (m *MockTeamRepository) GetUserTeams(ctx context.Context, userID uuid.UUID) ([]models.Team, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.Team), args.Error(1)
+}
+
+func (m *MockTeamRepository) UpdateSettings(ctx context.Context, teamID uuid.UUID, settings models.TeamSettings) error {
+	args := m.Called(teamID, settings)
 	return args.Error(0)
 }
 
-func (m *MockTeamRepository) IsManager(teamID, userID uuid.UUID) (bool, error) {
-	args := m.Called(teamID, userID)
-	return args.Bool(0), args.Error(1)
+func (m *MockTeamRepository) GetUserTeamRoles(ctx context.Context, userID uuid.UUID) (map[uuid.UUID]models.TeamRole, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(map[uuid.UUID]models.TeamRole), args.Error(1)
+}
+
+// recordingTx runs units of work against mock stores and records whether
+// they were committed or rolled back. commitErr fails the commit of an
+// otherwise successful unit of work, and ctx is the context of the last one.
+type recordingTx struct {
+	stores     repositories.Stores
+	ctx        context.Context
+	commitErr  error
+	committed  bool
+	rolledBack bool
+}
+
+func (r *recordingTx) WithTx(ctx context.Context, fn func(stores repositories.Stores) error) error {
+	r.ctx = ctx
+	err := fn(r.stores)
+	if err == nil {
+		err = r.commitErr
+	}
+	r.committed = err == nil
+	r.rolledBack = err != nil
+	return err
 }
 
 func TestTeamService_CreateTeam_Success(t *testing.T) {
 	// Setup
 	mockTeamRepo := new(MockTeamRepository)
 	mockUserRepo := new(MockUserRepository)
-	service := NewTeamService(mockTeamRepo, mockUserRepo)
-
-	creatorID := uuid.New()
-	creator := &models.User{
-		ID:   creatorID,
-		Role: models.RoleManager,
-	}
+	service := NewTeamService(mockTeamRepo, mockUserRepo, nil, nil)
 
 	input := &CreateTeamInput{
 		Name:     "Test Team",
@@ -75,21 +117,18 @@ func TestTeamService_CreateTeam_Success(t *testing.T) {
 		Members:  []TeamMemberInput{},
 	}
 
-	expectedTeam := &models.Team{
-		ID:   uuid.New(),
-		Name: input.Name,
-		Managers: []models.User{*creator},
-		Members:  []models.User{},
-	}
+	expectedTeam := testutils.TeamFactory().Named(input.Name).Build()
+	creator := testutils.UserFactory().Manager().InTeam(expectedTeam).Build()
+	creatorID := creator.ID
 
 	// Mock expectations
 	mockUserRepo.On("GetByID", creatorID).Return(creator, nil)
 	mockTeamRepo.On("Create", mock.AnythingOfType("*models.Team")).Return(nil)
-	mockTeamRepo.On("AddManager", mock.AnythingOfType("uuid.UUID"), creatorID).Return(nil)
+	mockTeamRepo.On("AddMembership", mock.AnythingOfType("uuid.UUID"), creatorID, models.TeamRoleAdmin).Return(nil)
 	mockTeamRepo.On("GetByID", mock.AnythingOfType("uuid.UUID")).Return(expectedTeam, nil)
 
 	// Test
-	team, err := service.CreateTeam(input, creatorID)
+	team, err := service.CreateTeam(context.Background(), input, creatorID)
 
 	// Assert
 	assert.NoError(t, err)
@@ -103,7 +142,7 @@ func TestTeamService_CreateTeam_NonManagerCreator(t *testing.T) {
 	// Setup
 	mockTeamRepo := new(MockTeamRepository)
 	mockUserRepo := new(MockUserRepository)
-	service := NewTeamService(mockTeamRepo, mockUserRepo)
+	service := NewTeamService(mockTeamRepo, mockUserRepo, nil, nil)
 
 	creatorID := uuid.New()
 	creator := &models.User{
@@ -121,7 +160,7 @@ func TestTeamService_CreateTeam_NonManagerCreator(t *testing.T) {
 	mockUserRepo.On("GetByID", creatorID).Return(creator, nil)
 
 	// Test
-	team, err := service.CreateTeam(input, creatorID)
+	team, err := service.CreateTeam(context.Background(), input, creatorID)
 
 	// Assert
 	assert.Error(t, err)
@@ -134,24 +173,21 @@ func TestTeamService_AddMember_Success(t *testing.T) {
 	// Setup
 	mockTeamRepo := new(MockTeamRepository)
 	mockUserRepo := new(MockUserRepository)
-	service := NewTeamService(mockTeamRepo, mockUserRepo)
+	service := NewTeamService(mockTeamRepo, mockUserRepo, nil, nil)
 
 	teamID := uuid.New()
-	userID := uuid.New()
 	managerID := uuid.New()
-
-	user := &models.User{
-		ID:   userID,
-		Role: models.RoleMember,
-	}
+	user := testutils.UserFactory().Member().Build()
+	userID := user.ID
 
 	// Mock expectations
-	mockTeamRepo.On("IsManager", teamID, managerID).Return(true, nil)
+	mockTeamRepo.On("GetRole", teamID, managerID).Return(models.TeamRoleManager, nil)
 	mockUserRepo.On("GetByID", userID).Return(user, nil)
-	mockTeamRepo.On("AddMember", teamID, userID).Return(nil)
+	mockTeamRepo.On("GetRole", teamID, userID).Return(models.TeamRole(""), nil)
+	mockTeamRepo.On("AddMembership", teamID, userID, models.TeamRoleMember).Return(nil)
 
 	// Test
-	err := service.AddMember(teamID, userID, managerID)
+	err := service.AddMember(context.Background(), teamID, userID, managerID)
 
 	// Assert
 	assert.NoError(t, err)
@@ -163,17 +199,17 @@ func TestTeamService_AddMember_NotManager(t *testing.T) {
 	// Setup
 	mockTeamRepo := new(MockTeamRepository)
 	mockUserRepo := new(MockUserRepository)
-	service := NewTeamService(mockTeamRepo, mockUserRepo)
+	service := NewTeamService(mockTeamRepo, mockUserRepo, nil, nil)
 
 	teamID := uuid.New()
 	userID := uuid.New()
 	managerID := uuid.New()
 
 	// Mock expectations
-	mockTeamRepo.On("IsManager", teamID, managerID).Return(false, nil)
+	mockTeamRepo.On("GetRole", teamID, managerID).Return(models.TeamRole(""), nil)
 
 	// Test
-	err := service.AddMember(teamID, userID, managerID)
+	err := service.AddMember(context.Background(), teamID, userID, managerID)
 
 	// Assert
 	assert.Error(t, err)
@@ -185,24 +221,21 @@ func TestTeamService_AddManager_Success(t *testing.T) {
 	// Setup
 	mockTeamRepo := new(MockTeamRepository)
 	mockUserRepo := new(MockUserRepository)
-	service := NewTeamService(mockTeamRepo, mockUserRepo)
+	service := NewTeamService(mockTeamRepo, mockUserRepo, nil, nil)
 
 	teamID := uuid.New()
-	userID := uuid.New()
 	requestorID := uuid.New()
-
-	user := &models.User{
-		ID:   userID,
-		Role: models.RoleManager,
-	}
+	user := testutils.UserFactory().Manager().Build()
+	userID := user.ID
 
 	// Mock expectations
-	mockTeamRepo.On("IsManager", teamID, requestorID).Return(true, nil)
+	mockTeamRepo.On("GetRole", teamID, requestorID).Return(models.TeamRoleManager, nil)
 	mockUserRepo.On("GetByID", userID).Return(user, nil)
-	mockTeamRepo.On("AddManager", teamID, userID).Return(nil)
+	mockTeamRepo.On("GetRole", teamID, userID).Return(models.TeamRole(""), nil)
+	mockTeamRepo.On("AddMembership", teamID, userID, models.TeamRoleManager).Return(nil)
 
 	// Test
-	err := service.AddManager(teamID, userID, requestorID)
+	err := service.AddManager(context.Background(), teamID, userID, requestorID)
 
 	// Assert
 	assert.NoError(t, err)
@@ -214,7 +247,7 @@ func TestTeamService_AddManager_UserNotManager(t *testing.T) {
 	// Setup
 	mockTeamRepo := new(MockTeamRepository)
 	mockUserRepo := new(MockUserRepository)
-	service := NewTeamService(mockTeamRepo, mockUserRepo)
+	service := NewTeamService(mockTeamRepo, mockUserRepo, nil, nil)
 
 	teamID := uuid.New()
 	userID := uuid.New()
@@ -226,11 +259,11 @@ func TestTeamService_AddManager_UserNotManager(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockTeamRepo.On("IsManager", teamID, requestorID).Return(true, nil)
+	mockTeamRepo.On("GetRole", teamID, requestorID).Return(models.TeamRoleManager, nil)
 	mockUserRepo.On("GetByID", userID).Return(user, nil)
 
 	// Test
-	err := service.AddManager(teamID, userID, requestorID)
+	err := service.AddManager(context.Background(), teamID, userID, requestorID)
 
 	// Assert
 	assert.Error(t, err)
@@ -243,7 +276,7 @@ func TestTeamService_GetTeam(t *testing.T) {
 	// Setup
 	mockTeamRepo := new(MockTeamRepository)
 	mockUserRepo := new(MockUserRepository)
-	service := NewTeamService(mockTeamRepo, mockUserRepo)
+	service := NewTeamService(mockTeamRepo, mockUserRepo, nil, nil)
 
 	teamID := uuid.New()
 	expectedTeam := &models.Team{
@@ -255,10 +288,273 @@ func TestTeamService_GetTeam(t *testing.T) {
 	mockTeamRepo.On("GetByID", teamID).Return(expectedTeam, nil)
 
 	// Test
-	team, err := service.GetTeam(teamID)
+	team, err := service.GetTeam(context.Background(), teamID, nil)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.Equal(t, expectedTeam, team)
 	mockTeamRepo.AssertExpectations(t)
 }
+
+func TestTeamService_GetTeam_OtherOrganization(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	service := NewTeamService(mockTeamRepo, new(MockUserRepository), nil, nil)
+
+	orgID, otherOrgID := uuid.New(), uuid.New()
+	team := &models.Team{ID: uuid.New(), Name: "Test Team", OrganizationID: &otherOrgID}
+	mockTeamRepo.On("GetByID", team.ID).Return(team, nil)
+
+	// Teams of another tenant, or of the default tenant, are not found
+	for _, caller := range []*uuid.UUID{&orgID, nil} {
+		_, err := service.GetTeam(context.Background(), team.ID, caller)
+		assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	}
+
+	got, err := service.GetTeam(context.Background(), team.ID, &otherOrgID)
+	assert.NoError(t, err)
+	assert.Equal(t, team, got)
+}
+
+func TestTeamService_BuildClaims(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	service := NewTeamService(mockTeamRepo, new(MockUserRepository), nil, nil)
+
+	user := &models.User{ID: uuid.New(), Username: "alice", Role: models.RoleManager}
+	managed, joined := uuid.New(), uuid.New()
+	mockTeamRepo.On("GetUserTeamRoles", user.ID).Return(map[uuid.UUID]models.TeamRole{
+		managed: models.TeamRoleManager,
+		joined:  models.TeamRoleMember,
+	}, nil)
+
+	jwtManager := auth.NewJWTManager("secret", 1)
+	jwtManager.SetClaimsBuilder(service.BuildClaims)
+	token, err := jwtManager.GenerateToken(user)
+	assert.NoError(t, err)
+
+	claims, err := jwtManager.ValidateToken(token)
+	assert.NoError(t, err)
+	assert.Len(t, claims.Teams, 2)
+	role, ok := claims.TeamRole(managed)
+	assert.True(t, ok)
+	assert.Equal(t, models.TeamRoleManager, role)
+	role, ok = claims.TeamRole(joined)
+	assert.True(t, ok)
+	assert.Equal(t, models.TeamRoleMember, role)
+	_, ok = claims.TeamRole(uuid.New())
+	assert.False(t, ok)
+	assert.True(t, claims.HasScope(auth.ScopeTeamsManage))
+
+	// Refreshing reloads memberships instead of copying them
+	mockTeamRepo.ExpectedCalls = nil
+	mockTeamRepo.On("GetUserTeamRoles", user.ID).Return(map[uuid.UUID]models.TeamRole{}, nil)
+	refreshed, err := jwtManager.RefreshToken(token)
+	assert.NoError(t, err)
+	claims, err = jwtManager.ValidateToken(refreshed)
+	assert.NoError(t, err)
+	assert.NotNil(t, claims.Teams)
+	assert.Empty(t, claims.Teams)
+	mockTeamRepo.AssertExpectations(t)
+}
+
+func TestTeamService_CreateTeam_RollsBackOnFailure(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	mockUserRepo := new(MockUserRepository)
+	tx := &recordingTx{stores: repositories.Stores{Users: mockUserRepo, Teams: mockTeamRepo}}
+	service := NewTeamService(mockTeamRepo, mockUserRepo, tx, nil)
+
+	creator := &models.User{ID: uuid.New(), Role: models.RoleManager}
+	unknownID, memberID := uuid.New(), uuid.New()
+	input := &CreateTeamInput{
+		Name:    "Test Team",
+		Members: []TeamMemberInput{{ID: unknownID}, {ID: memberID}},
+	}
+
+	mockUserRepo.On("GetByID", creator.ID).Return(creator, nil)
+	mockUserRepo.On("GetByID", unknownID).Return((*models.User)(nil), apperrors.NotFound("user not found"))
+	mockUserRepo.On("GetByID", memberID).Return(&models.User{ID: memberID, Role: models.RoleMember}, nil)
+	mockTeamRepo.On("Create", mock.AnythingOfType("*models.Team")).Return(nil)
+	mockTeamRepo.On("AddMembership", mock.AnythingOfType("uuid.UUID"), creator.ID, models.TeamRoleAdmin).Return(nil)
+	mockTeamRepo.On("AddMembership", mock.AnythingOfType("uuid.UUID"), memberID, models.TeamRoleMember).Return(errors.New("connection reset"))
+
+	team, err := service.CreateTeam(context.Background(), input, creator.ID)
+
+	require.Error(t, err)
+	assert.Nil(t, team)
+	assert.Contains(t, err.Error(), "failed to add member")
+	assert.True(t, tx.rolledBack)
+	mockTeamRepo.AssertNotCalled(t, "AddMembership", mock.Anything, unknownID, mock.Anything)
+	mockTeamRepo.AssertNotCalled(t, "GetByID", mock.Anything)
+}
+
+func TestTeamService_CreateTeam_SharedFolder(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	mockUserRepo := new(MockUserRepository)
+	mockFolderRepo := new(MockFolderRepository)
+	tx := &recordingTx{stores: repositories.Stores{Users: mockUserRepo, Teams: mockTeamRepo, Folders: mockFolderRepo}}
+	service := NewTeamService(mockTeamRepo, mockUserRepo, tx, nil)
+
+	creator := &models.User{ID: uuid.New(), Role: models.RoleManager}
+	folderID := uuid.New()
+	input := &CreateTeamInput{Name: "Test Team", SharedFolder: true}
+
+	mockUserRepo.On("GetByID", creator.ID).Return(creator, nil)
+	mockFolderRepo.On("Create", mock.AnythingOfType("*models.Folder")).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.Folder).ID = folderID
+	}).Return(nil)
+	mockTeamRepo.On("Create", mock.AnythingOfType("*models.Team")).Return(nil)
+	mockTeamRepo.On("AddMembership", mock.AnythingOfType("uuid.UUID"), creator.ID, models.TeamRoleAdmin).Return(nil)
+	mockTeamRepo.On("GetByID", mock.AnythingOfType("uuid.UUID")).Return(&models.Team{Name: input.Name, FolderID: &folderID}, nil)
+
+	_, err := service.CreateTeam(context.Background(), input, creator.ID)
+
+	require.NoError(t, err)
+	folder := mockFolderRepo.Calls[0].Arguments.Get(0).(*models.Folder)
+	assert.Equal(t, "Test Team", folder.Name)
+	assert.Equal(t, creator.ID, folder.OwnerID)
+	team := mockTeamRepo.Calls[0].Arguments.Get(0).(*models.Team)
+	require.NotNil(t, team.FolderID)
+	assert.Equal(t, folderID, *team.FolderID)
+}
+
+func TestTeamService_AddMember_AlreadyInTeam(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	mockUserRepo := new(MockUserRepository)
+	service := NewTeamService(mockTeamRepo, mockUserRepo, nil, nil)
+
+	teamID, managerID := uuid.New(), uuid.New()
+	user := testutils.UserFactory().Member().Build()
+	mockTeamRepo.On("GetRole", teamID, managerID).Return(models.TeamRoleManager, nil)
+	mockTeamRepo.On("GetRole", teamID, user.ID).Return(models.TeamRoleViewer, nil)
+	mockUserRepo.On("GetByID", user.ID).Return(user, nil)
+
+	err := service.AddMember(context.Background(), teamID, user.ID, managerID)
+
+	assert.Equal(t, apperrors.CodeConflict, apperrors.From(err).Code)
+	mockTeamRepo.AssertNotCalled(t, "AddMembership", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTeamService_SetRole(t *testing.T) {
+	ctx := context.Background()
+	teamID, requesterID := uuid.New(), uuid.New()
+	manager := testutils.UserFactory().Manager().Build()
+	member := testutils.UserFactory().Member().Build()
+
+	newService := func(requesterRole models.TeamRole, user *models.User, current models.TeamRole) (*TeamService, *MockTeamRepository) {
+		mockTeamRepo := new(MockTeamRepository)
+		mockUserRepo := new(MockUserRepository)
+		mockTeamRepo.On("GetRole", teamID, requesterID).Return(requesterRole, nil)
+		mockTeamRepo.On("GetRole", teamID, user.ID).Return(current, nil)
+		mockUserRepo.On("GetByID", user.ID).Return(user, nil)
+		return NewTeamService(mockTeamRepo, mockUserRepo, nil, nil), mockTeamRepo
+	}
+
+	t.Run("adds users who are not in the team", func(t *testing.T) {
+		service, mockTeamRepo := newService(models.TeamRoleManager, member, "")
+		mockTeamRepo.On("AddMembership", teamID, member.ID, models.TeamRoleViewer).Return(nil)
+
+		require.NoError(t, service.SetRole(ctx, teamID, member.ID, models.TeamRoleViewer, requesterID))
+		mockTeamRepo.AssertExpectations(t)
+	})
+
+	t.Run("admins promote managers to admin", func(t *testing.T) {
+		service, mockTeamRepo := newService(models.TeamRoleAdmin, manager, models.TeamRoleManager)
+		mockTeamRepo.On("SetRole", teamID, manager.ID, models.TeamRoleAdmin).Return(nil)
+
+		require.NoError(t, service.SetRole(ctx, teamID, manager.ID, models.TeamRoleAdmin, requesterID))
+		mockTeamRepo.AssertExpectations(t)
+	})
+
+	t.Run("managers cannot make admins", func(t *testing.T) {
+		service, mockTeamRepo := newService(models.TeamRoleManager, manager, models.TeamRoleMember)
+
+		err := service.SetRole(ctx, teamID, manager.ID, models.TeamRoleAdmin, requesterID)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		mockTeamRepo.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("managers cannot demote admins", func(t *testing.T) {
+		service, mockTeamRepo := newService(models.TeamRoleManager, manager, models.TeamRoleAdmin)
+
+		err := service.SetRole(ctx, teamID, manager.ID, models.TeamRoleViewer, requesterID)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		mockTeamRepo.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("viewers cannot change roles", func(t *testing.T) {
+		service, _ := newService(models.TeamRoleViewer, member, "")
+
+		err := service.SetRole(ctx, teamID, member.ID, models.TeamRoleMember, requesterID)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	})
+
+	t.Run("only managers may manage the team", func(t *testing.T) {
+		service, _ := newService(models.TeamRoleAdmin, member, models.TeamRoleMember)
+
+		err := service.SetRole(ctx, teamID, member.ID, models.TeamRoleManager, requesterID)
+		assert.ErrorIs(t, err, apperrors.ErrValidation)
+	})
+}
+
+func TestTeamService_RemoveMember_Admin(t *testing.T) {
+	ctx := context.Background()
+	teamID, requesterID, adminID := uuid.New(), uuid.New(), uuid.New()
+
+	t.Run("managers cannot remove admins", func(t *testing.T) {
+		mockTeamRepo := new(MockTeamRepository)
+		service := NewTeamService(mockTeamRepo, new(MockUserRepository), nil, nil)
+		mockTeamRepo.On("GetRole", teamID, requesterID).Return(models.TeamRoleManager, nil)
+		mockTeamRepo.On("GetRole", teamID, adminID).Return(models.TeamRoleAdmin, nil)
+
+		err := service.RemoveMember(ctx, teamID, adminID, requesterID)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+		mockTeamRepo.AssertNotCalled(t, "RemoveMembership", mock.Anything, mock.Anything)
+	})
+
+	t.Run("admins remove admins", func(t *testing.T) {
+		mockTeamRepo := new(MockTeamRepository)
+		service := NewTeamService(mockTeamRepo, new(MockUserRepository), nil, nil)
+		mockTeamRepo.On("GetRole", teamID, requesterID).Return(models.TeamRoleAdmin, nil)
+		mockTeamRepo.On("GetRole", teamID, adminID).Return(models.TeamRoleAdmin, nil)
+		mockTeamRepo.On("RemoveMembership", teamID, adminID).Return(nil)
+
+		require.NoError(t, service.RemoveManager(ctx, teamID, adminID, requesterID))
+		mockTeamRepo.AssertExpectations(t)
+	})
+}
+
+func TestTeamService_UpdateSettings(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	service := NewTeamService(mockTeamRepo, new(MockUserRepository), nil, nil)
+
+	teamID, managerID := uuid.New(), uuid.New()
+	team := &models.Team{ID: teamID, Settings: models.DefaultTeamSettings()}
+	restricted := false
+	input := &TeamSettingsInput{AllowExternalSharing: &restricted}
+
+	mockTeamRepo.On("GetRole", teamID, managerID).Return(models.TeamRoleManager, nil)
+	mockTeamRepo.On("GetByID", teamID).Return(team, nil)
+	mockTeamRepo.On("UpdateSettings", teamID, models.TeamSettings{
+		AllowExternalSharing: false,
+		AllowPublicLinks:     true,
+		DefaultAccess:        models.AccessRead,
+	}).Return(nil)
+
+	updated, err := service.UpdateSettings(context.Background(), teamID, input, managerID)
+
+	require.NoError(t, err)
+	assert.False(t, updated.Settings.AllowExternalSharing)
+	mockTeamRepo.AssertExpectations(t)
+}
+
+func TestTeamService_UpdateSettings_NotManager(t *testing.T) {
+	mockTeamRepo := new(MockTeamRepository)
+	service := NewTeamService(mockTeamRepo, new(MockUserRepository), nil, nil)
+
+	teamID, userID := uuid.New(), uuid.New()
+	mockTeamRepo.On("GetRole", teamID, userID).Return(models.TeamRole(""), nil)
+
+	_, err := service.UpdateSettings(context.Background(), teamID, &TeamSettingsInput{}, userID)
+
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	mockTeamRepo.AssertNotCalled(t, "UpdateSettings", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/logger"
+)
+
+// UserPreferenceService reads and updates the settings other services use
+// to format timestamps, pick a language and route notifications
+type UserPreferenceService struct {
+	prefRepo repositories.UserPreferenceRepositoryInterface
+	locales  []string
+}
+
+// NewUserPreferenceService creates a preference service. locales are the
+// languages users may choose; English is always allowed.
+func NewUserPreferenceService(prefRepo repositories.UserPreferenceRepositoryInterface, locales []string) *UserPreferenceService {
+	if !slices.Contains(locales, models.DefaultLocale) {
+		locales = append([]string{models.DefaultLocale}, locales...)
+	}
+	return &UserPreferenceService{
+		prefRepo: prefRepo,
+		locales:  locales,
+	}
+}
+
+// UpdatePreferencesInput changes the given settings and leaves omitted ones
+// as they are
+type UpdatePreferencesInput struct {
+	Timezone             *string                                                  `json:"timezone"`
+	Locale               *string                                                  `json:"locale"`
+	NotificationChannels map[models.NotificationType][]models.NotificationChannel `json:"notification_channels"`
+}
+
+func (s *UserPreferenceService) GetPreferences(ctx context.Context, userID uuid.UUID) (*models.UserPreference, error) {
+	pref, err := s.prefRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return pref, nil
+}
+
+func (s *UserPreferenceService) UpdatePreferences(ctx context.Context, userID uuid.UUID, input *UpdatePreferencesInput) (*models.UserPreference, error) {
+	pref, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	if input.Timezone != nil {
+		if _, err := time.LoadLocation(*input.Timezone); err != nil || *input.Timezone == "" {
+			fields["timezone"] = "must be an IANA time zone such as Asia/Ho_Chi_Minh"
+		} else {
+			pref.Timezone = *input.Timezone
+		}
+	}
+	if input.Locale != nil {
+		locale := strings.ToLower(*input.Locale)
+		if !slices.Contains(s.locales, locale) {
+			fields["locale"] = "must be one of: " + strings.Join(s.locales, ", ")
+		} else {
+			pref.Locale = locale
+		}
+	}
+	if pref.NotificationChannels == nil {
+		pref.NotificationChannels = make(map[models.NotificationType][]models.NotificationChannel)
+	}
+	for notificationType, channels := range input.NotificationChannels {
+		if !slices.Contains(models.NotificationTypes, notificationType) {
+			fields["notification_channels"] = fmt.Sprintf("unknown notification type %q", notificationType)
+			continue
+		}
+		for _, channel := range channels {
+			if !slices.Contains(models.NotificationChannels, channel) {
+				fields["notification_channels"] = fmt.Sprintf("unknown channel %q", channel)
+			}
+		}
+		channels = slices.Compact(slices.Sorted(slices.Values(channels)))
+		if channels == nil {
+			channels = []models.NotificationChannel{}
+		}
+		pref.NotificationChannels[notificationType] = channels
+	}
+	if len(fields) > 0 {
+		return nil, apperrors.ValidationFields("Invalid preferences", fields)
+	}
+
+	if err := s.prefRepo.Save(ctx, pref); err != nil {
+		return nil, fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return pref, nil
+}
+
+// preferencesOf returns userID's preferences, falling back to the defaults
+// when they cannot be loaded so a failed lookup never blocks the caller
+func preferencesOf(ctx context.Context, prefRepo repositories.UserPreferenceRepositoryInterface, userID uuid.UUID, log logger.Logger) *models.UserPreference {
+	if prefRepo == nil {
+		return models.DefaultUserPreference(userID)
+	}
+	pref, err := prefRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		log.Warn("Failed to load user preferences, using defaults",
+			logger.String("user_id", userID.String()),
+			logger.Error(err),
+		)
+		return models.DefaultUserPreference(userID)
+	}
+	return pref
+}
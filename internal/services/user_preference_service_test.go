@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+)
+
+// MockUserPreferenceRepository is a mock implementation of UserPreferenceRepositoryInterface
+type MockUserPreferenceRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserPreferenceRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*models.UserPreference, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserPreference), args.Error(1)
+}
+
+func (m *MockUserPreferenceRepository) Save(ctx context.Context, pref *models.UserPreference) error {
+	args := m.Called(pref)
+	return args.Error(0)
+}
+
+// MockNotificationRepository is a mock implementation of NotificationRepositoryInterface
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	args := m.Called(notification)
+	return args.Error(0)
+}
+
+func (m *MockNotificationRepository) GetByUser(ctx context.Context, userID uuid.UUID, unreadOnly bool, limit int) ([]models.Notification, error) {
+	args := m.Called(userID, unreadOnly, limit)
+	return args.Get(0).([]models.Notification), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkRead(ctx context.Context, id, userID uuid.UUID) error {
+	args := m.Called(id, userID)
+	return args.Error(0)
+}
+
+func TestUserPreferenceService_UpdatePreferences(t *testing.T) {
+	userID := uuid.New()
+	timezone, locale := "Asia/Ho_Chi_Minh", "VI"
+
+	t.Run("changes only the given settings", func(t *testing.T) {
+		mockPrefRepo := new(MockUserPreferenceRepository)
+		service := NewUserPreferenceService(mockPrefRepo, []string{"en", "vi"})
+
+		mockPrefRepo.On("GetByUserID", userID).Return(models.DefaultUserPreference(userID), nil)
+		mockPrefRepo.On("Save", mock.AnythingOfType("*models.UserPreference")).Return(nil)
+
+		pref, err := service.UpdatePreferences(context.Background(), userID, &UpdatePreferencesInput{
+			Timezone: &timezone,
+			Locale:   &locale,
+			NotificationChannels: map[models.NotificationType][]models.NotificationChannel{
+				models.NotificationMention: {},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Asia/Ho_Chi_Minh", pref.Timezone)
+		assert.Equal(t, "vi", pref.Locale)
+		assert.False(t, pref.Wants(models.NotificationMention, models.ChannelInApp))
+		mockPrefRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects unknown values", func(t *testing.T) {
+		mockPrefRepo := new(MockUserPreferenceRepository)
+		service := NewUserPreferenceService(mockPrefRepo, nil)
+		badTimezone, badLocale := "Mars/Olympus_Mons", "vi"
+
+		mockPrefRepo.On("GetByUserID", userID).Return(models.DefaultUserPreference(userID), nil)
+
+		_, err := service.UpdatePreferences(context.Background(), userID, &UpdatePreferencesInput{
+			Timezone: &badTimezone,
+			Locale:   &badLocale,
+			NotificationChannels: map[models.NotificationType][]models.NotificationChannel{
+				models.NotificationMention: {"carrier_pigeon"},
+			},
+		})
+		var appErr *apperrors.Error
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperrors.CodeValidation, appErr.Code)
+		assert.Contains(t, appErr.Fields, "timezone")
+		assert.Equal(t, "must be one of: en", appErr.Fields["locale"])
+		assert.Contains(t, appErr.Fields, "notification_channels")
+		mockPrefRepo.AssertNotCalled(t, "Save", mock.Anything)
+	})
+}
+
+func TestNotificationService_GetUserNotifications_UsesTimezone(t *testing.T) {
+	userID := uuid.New()
+	mockNotificationRepo := new(MockNotificationRepository)
+	mockPrefRepo := new(MockUserPreferenceRepository)
+	service := NewNotificationService(mockNotificationRepo, mockPrefRepo)
+
+	createdAt := time.Date(2024, 3, 1, 2, 30, 0, 0, time.UTC)
+	mockNotificationRepo.On("GetByUser", userID, false, DefaultNotificationLimit).
+		Return([]models.Notification{{UserID: userID, CreatedAt: createdAt}}, nil)
+	pref := models.DefaultUserPreference(userID)
+	pref.Timezone = "Asia/Ho_Chi_Minh"
+	mockPrefRepo.On("GetByUserID", userID).Return(pref, nil)
+
+	notifications, err := service.GetUserNotifications(context.Background(), userID, false, 0)
+	require.NoError(t, err)
+	require.Len(t, notifications, 1)
+	assert.True(t, notifications[0].CreatedAt.Equal(createdAt))
+	assert.Equal(t, "2024-03-01T09:30:00+07:00", notifications[0].CreatedAt.Format(time.RFC3339))
+}
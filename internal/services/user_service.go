@@ -1,24 +1,51 @@
 package services
 
 import (
-	"errors"
+	"context"
 	"fmt"
 
 	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
 	"seta-training/internal/models"
 	"seta-training/internal/repositories"
 	"seta-training/pkg/auth"
+	"seta-training/pkg/metrics"
 )
 
 type UserService struct {
 	userRepo   repositories.UserRepositoryInterface
+	tx         repositories.TransactionManager
 	jwtManager auth.JWTManagerInterface
+	audit      audit.Recorder
+	metrics    *metrics.Metrics
+	logins     LoginRecorder
+	devices    DeviceRegistrar
 }
 
-func NewUserService(userRepo repositories.UserRepositoryInterface, jwtManager auth.JWTManagerInterface) *UserService {
+// NewUserService creates a user service. txManager may be nil to write
+// through userRepo without a transaction, auditor may be nil to disable
+// audit logging, m may be nil to use a private registry, logins may be nil
+// to keep no login history and devices may be nil to issue tokens bound to
+// no device.
+func NewUserService(userRepo repositories.UserRepositoryInterface, txManager repositories.TransactionManager, jwtManager auth.JWTManagerInterface, auditor audit.Recorder, m *metrics.Metrics, logins LoginRecorder, devices DeviceRegistrar) *UserService {
+	if txManager == nil {
+		txManager = repositories.NoTx{Stores: repositories.Stores{Users: userRepo}}
+	}
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	if m == nil {
+		m = metrics.NewIsolatedMetrics()
+	}
 	return &UserService{
 		userRepo:   userRepo,
+		tx:         txManager,
 		jwtManager: jwtManager,
+		audit:      auditor,
+		metrics:    m,
+		logins:     logins,
+		devices:    devices,
 	}
 }
 
@@ -27,6 +54,8 @@ type CreateUserInput struct {
 	Email    string          `json:"email" binding:"required,email"`
 	Password string          `json:"password" binding:"required,min=6"`
 	Role     models.UserRole `json:"role" binding:"required,oneof=manager member"`
+	// OrganizationID is set by the caller, never bound from the request
+	OrganizationID *uuid.UUID `json:"-"`
 }
 
 type LoginInput struct {
@@ -39,19 +68,57 @@ type LoginResponse struct {
 	Token string       `json:"token"`
 }
 
-func (s *UserService) CreateUser(input *CreateUserInput) (*models.User, error) {
+func (s *UserService) CreateUser(ctx context.Context, input *CreateUserInput) (*models.User, error) {
+	user, err := createUser(ctx, s.userRepo, input)
+	if err != nil {
+		return nil, err
+	}
+	s.recordCreated(ctx, user)
+	return user, nil
+}
+
+// CreateUsers creates a batch of users in one transaction. errs[i] reports
+// why inputs[i] was rejected, which does not affect the rest of the batch.
+// If the transaction itself fails no user is created and err is returned.
+func (s *UserService) CreateUsers(ctx context.Context, inputs []*CreateUserInput) (users []*models.User, errs []error, err error) {
+	err = s.tx.WithTx(ctx, func(stores repositories.Stores) error {
+		users = make([]*models.User, len(inputs))
+		errs = make([]error, len(inputs))
+		for i, input := range inputs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			users[i], errs[i] = createUser(ctx, stores.Users, input)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create users: %w", err)
+	}
+	for _, user := range users {
+		if user != nil {
+			s.recordCreated(ctx, user)
+		}
+	}
+	return users, errs, nil
+}
+
+// createUser validates input and inserts the user through userRepo. A
+// failed insert rolls back only its own savepoint when userRepo is bound
+// to a transaction.
+func createUser(ctx context.Context, userRepo repositories.UserRepositoryInterface, input *CreateUserInput) (*models.User, error) {
 	// Check if email already exists
-	if exists, err := s.userRepo.EmailExists(input.Email); err != nil {
+	if exists, err := userRepo.EmailExists(ctx, input.Email); err != nil {
 		return nil, fmt.Errorf("failed to check email existence: %w", err)
 	} else if exists {
-		return nil, errors.New("email already exists")
+		return nil, apperrors.Conflict("email already exists")
 	}
 
 	// Check if username already exists
-	if exists, err := s.userRepo.UsernameExists(input.Username); err != nil {
+	if exists, err := userRepo.UsernameExists(ctx, input.Username); err != nil {
 		return nil, fmt.Errorf("failed to check username existence: %w", err)
 	} else if exists {
-		return nil, errors.New("username already exists")
+		return nil, apperrors.Conflict("username already exists")
 	}
 
 	// Hash password
@@ -66,32 +133,58 @@ func (s *UserService) CreateUser(input *CreateUserInput) (*models.User, error) {
 		Email:        input.Email,
 		PasswordHash: hashedPassword,
 		Role:         input.Role,
+
+		OrganizationID: input.OrganizationID,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	if err := userRepo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
-
 	return user, nil
 }
 
-func (s *UserService) Login(input *LoginInput) (*LoginResponse, error) {
+// recordCreated audits and counts a committed sign-up
+func (s *UserService) recordCreated(ctx context.Context, user *models.User) {
+	// Sign-up is unauthenticated, so the new user is recorded as the actor
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    user.ID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetUser,
+		TargetID:   user.ID,
+		Details:    map[string]string{"role": string(user.Role)},
+	})
+	s.metrics.RecordUserCreated(string(user.Role))
+}
+
+func (s *UserService) Login(ctx context.Context, input *LoginInput) (*LoginResponse, error) {
 	// Get user by email
-	user, err := s.userRepo.GetByEmail(input.Email)
+	user, err := s.userRepo.GetByEmail(ctx, input.Email)
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		s.metrics.RecordLogin(false)
+		s.recordLogin(ctx, input.Email, nil, models.LoginUnknownEmail)
+		return nil, apperrors.Unauthorized("invalid email or password")
 	}
 
 	// Check password
 	if err := auth.CheckPassword(user.PasswordHash, input.Password); err != nil {
-		return nil, errors.New("invalid email or password")
+		s.metrics.RecordLogin(false)
+		s.recordLogin(ctx, input.Email, user, models.LoginInvalidPassword)
+		return nil, apperrors.Unauthorized("invalid email or password")
 	}
 
+	return s.IssueLogin(ctx, user)
+}
+
+// IssueLogin logs in a user whose identity was already checked, by password
+// or single sign-on: it issues their token and records the login
+func (s *UserService) IssueLogin(ctx context.Context, user *models.User) (*LoginResponse, error) {
 	// Generate JWT token
-	token, err := s.jwtManager.GenerateToken(user)
+	token, err := s.issueToken(ctx, user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
+	s.metrics.RecordLogin(true)
+	s.recordLogin(ctx, user.Email, user, models.LoginSucceeded)
 
 	return &LoginResponse{
 		User:  user,
@@ -99,12 +192,32 @@ func (s *UserService) Login(input *LoginInput) (*LoginResponse, error) {
 	}, nil
 }
 
-func (s *UserService) GetUserByID(id uuid.UUID) (*models.User, error) {
-	return s.userRepo.GetByID(id)
+// issueToken issues a login token, bound to the caller's device when devices
+// are tracked
+func (s *UserService) issueToken(ctx context.Context, user *models.User) (string, error) {
+	if s.devices == nil {
+		return s.jwtManager.GenerateToken(user)
+	}
+	deviceID, err := s.devices.Register(ctx, user)
+	if err != nil {
+		return "", err
+	}
+	return s.jwtManager.GenerateDeviceToken(user, deviceID)
+}
+
+func (s *UserService) recordLogin(ctx context.Context, email string, user *models.User, outcome models.LoginOutcome) {
+	if s.logins != nil {
+		s.logins.RecordLogin(ctx, email, user, outcome)
+	}
+}
+
+func (s *UserService) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return s.userRepo.GetByID(ctx, id)
 }
 
-func (s *UserService) GetAllUsers() ([]models.User, error) {
-	return s.userRepo.GetAll()
+// GetAllUsers returns the users of orgID, or of the default tenant when nil
+func (s *UserService) GetAllUsers(ctx context.Context, orgID *uuid.UUID) ([]models.User, error) {
+	return s.userRepo.GetAll(ctx, orgID)
 }
 
 func (s *UserService) ValidateToken(tokenString string) (*auth.Claims, error) {
@@ -1,13 +1,22 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
 	"seta-training/internal/models"
+	"seta-training/internal/repositories"
 	"seta-training/pkg/auth"
+	"seta-training/pkg/metrics"
+	"seta-training/pkg/pagination"
 )
 
 // MockUserRepository is a mock implementation of UserRepositoryInterface
@@ -15,17 +24,17 @@ type MockUserRepository struct {
 	mock.Mock
 }
 
-func (m *MockUserRepository) Create(user *models.User) error {
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) GetByID(id uuid.UUID) (*models.User, error) {
+func (m *MockUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	args := m.Called(id)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetByEmail(email string) (*models.User, error) {
+func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	args := m.Called(email)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -33,17 +42,22 @@ func (m *MockUserRepository) GetByEmail(email string) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetAll() ([]models.User, error) {
-	args := m.Called()
+func (m *MockUserRepository) GetAll(ctx context.Context, orgID *uuid.UUID) ([]models.User, error) {
+	args := m.Called(orgID)
 	return args.Get(0).([]models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) EmailExists(email string) (bool, error) {
+func (m *MockUserRepository) ListPage(ctx context.Context, orgID *uuid.UUID, p pagination.Params) (pagination.Page[models.User], error) {
+	args := m.Called(orgID, p)
+	return args.Get(0).(pagination.Page[models.User]), args.Error(1)
+}
+
+func (m *MockUserRepository) EmailExists(ctx context.Context, email string) (bool, error) {
 	args := m.Called(email)
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockUserRepository) UsernameExists(username string) (bool, error) {
+func (m *MockUserRepository) UsernameExists(ctx context.Context, username string) (bool, error) {
 	args := m.Called(username)
 	return args.Bool(0), args.Error(1)
 }
@@ -58,6 +72,16 @@ func (m *MockJWTManager) GenerateToken(user *models.User) (string, error) {
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockJWTManager) GenerateDeviceToken(user *models.User, deviceID uuid.UUID) (string, error) {
+	args := m.Called(user, deviceID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTManager) Impersonate(user *models.User, impersonatorID uuid.UUID, ttl time.Duration) (string, error) {
+	args := m.Called(user, impersonatorID, ttl)
+	return args.String(0), args.Error(1)
+}
+
 func (m *MockJWTManager) ValidateToken(tokenString string) (*auth.Claims, error) {
 	args := m.Called(tokenString)
 	if args.Get(0) == nil {
@@ -75,7 +99,7 @@ func TestUserService_CreateUser(t *testing.T) {
 	// Setup
 	mockRepo := new(MockUserRepository)
 	mockJWT := new(MockJWTManager)
-	service := NewUserService(mockRepo, mockJWT)
+	service := NewUserService(mockRepo, nil, mockJWT, nil, nil, nil, nil)
 
 	input := &CreateUserInput{
 		Username: "testuser",
@@ -90,7 +114,7 @@ func TestUserService_CreateUser(t *testing.T) {
 	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
 
 	// Test
-	user, err := service.CreateUser(input)
+	user, err := service.CreateUser(context.Background(), input)
 
 	// Assert
 	assert.NoError(t, err)
@@ -106,7 +130,7 @@ func TestUserService_CreateUser_EmailExists(t *testing.T) {
 	// Setup
 	mockRepo := new(MockUserRepository)
 	mockJWT := new(MockJWTManager)
-	service := NewUserService(mockRepo, mockJWT)
+	service := NewUserService(mockRepo, nil, mockJWT, nil, nil, nil, nil)
 
 	input := &CreateUserInput{
 		Username: "testuser",
@@ -119,7 +143,7 @@ func TestUserService_CreateUser_EmailExists(t *testing.T) {
 	mockRepo.On("EmailExists", input.Email).Return(true, nil)
 
 	// Test
-	user, err := service.CreateUser(input)
+	user, err := service.CreateUser(context.Background(), input)
 
 	// Assert
 	assert.Error(t, err)
@@ -132,7 +156,7 @@ func TestUserService_Login_Success(t *testing.T) {
 	// Setup
 	mockRepo := new(MockUserRepository)
 	mockJWT := new(MockJWTManager)
-	service := NewUserService(mockRepo, mockJWT)
+	service := NewUserService(mockRepo, nil, mockJWT, nil, nil, nil, nil)
 
 	hashedPassword, _ := auth.HashPassword("password123")
 	user := &models.User{
@@ -153,7 +177,7 @@ func TestUserService_Login_Success(t *testing.T) {
 	mockJWT.On("GenerateToken", user).Return("mock-jwt-token", nil)
 
 	// Test
-	response, err := service.Login(input)
+	response, err := service.Login(context.Background(), input)
 
 	// Assert
 	assert.NoError(t, err)
@@ -168,7 +192,7 @@ func TestUserService_Login_InvalidPassword(t *testing.T) {
 	// Setup
 	mockRepo := new(MockUserRepository)
 	mockJWT := new(MockJWTManager)
-	service := NewUserService(mockRepo, mockJWT)
+	service := NewUserService(mockRepo, nil, mockJWT, nil, nil, nil, nil)
 
 	hashedPassword, _ := auth.HashPassword("correctpassword")
 	user := &models.User{
@@ -188,7 +212,7 @@ func TestUserService_Login_InvalidPassword(t *testing.T) {
 	mockRepo.On("GetByEmail", input.Email).Return(user, nil)
 
 	// Test
-	response, err := service.Login(input)
+	response, err := service.Login(context.Background(), input)
 
 	// Assert
 	assert.Error(t, err)
@@ -201,7 +225,7 @@ func TestUserService_GetAllUsers(t *testing.T) {
 	// Setup
 	mockRepo := new(MockUserRepository)
 	mockJWT := new(MockJWTManager)
-	service := NewUserService(mockRepo, mockJWT)
+	service := NewUserService(mockRepo, nil, mockJWT, nil, nil, nil, nil)
 
 	expectedUsers := []models.User{
 		{
@@ -219,13 +243,87 @@ func TestUserService_GetAllUsers(t *testing.T) {
 	}
 
 	// Mock expectations
-	mockRepo.On("GetAll").Return(expectedUsers, nil)
+	orgID := uuid.New()
+	mockRepo.On("GetAll", &orgID).Return(expectedUsers, nil)
 
 	// Test
-	users, err := service.GetAllUsers()
+	users, err := service.GetAllUsers(context.Background(), &orgID)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.Equal(t, expectedUsers, users)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestUserService_Metrics(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockJWT := new(MockJWTManager)
+	m := metrics.NewIsolatedMetrics()
+	service := NewUserService(mockRepo, nil, mockJWT, nil, m, nil, nil)
+
+	input := &CreateUserInput{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "password123",
+		Role:     models.RoleMember,
+	}
+	mockRepo.On("EmailExists", input.Email).Return(false, nil)
+	mockRepo.On("UsernameExists", input.Username).Return(false, nil)
+	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+	user, err := service.CreateUser(context.Background(), input)
+	assert.NoError(t, err)
+
+	mockRepo.On("GetByEmail", input.Email).Return(user, nil)
+	mockJWT.On("GenerateToken", user).Return("mock-jwt-token", nil)
+	_, err = service.Login(context.Background(), &LoginInput{Email: input.Email, Password: input.Password})
+	assert.NoError(t, err)
+	_, err = service.Login(context.Background(), &LoginInput{Email: input.Email, Password: "wrong"})
+	assert.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.UsersCreatedTotal.WithLabelValues("member")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.LoginsTotal.WithLabelValues("success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.LoginsTotal.WithLabelValues("failure")))
+}
+
+func TestUserService_CreateUsers(t *testing.T) {
+	inputs := []*CreateUserInput{
+		{Username: "alice", Email: "alice@example.com", Password: "password123", Role: models.RoleMember},
+		{Username: "bob", Email: "bob@example.com", Password: "password123", Role: models.RoleManager},
+	}
+	setup := func(commitErr error) (*UserService, *recordingTx, *metrics.Metrics) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("EmailExists", "alice@example.com").Return(false, nil)
+		mockRepo.On("EmailExists", "bob@example.com").Return(true, nil)
+		mockRepo.On("UsernameExists", "alice").Return(false, nil)
+		mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+		tx := &recordingTx{stores: repositories.Stores{Users: mockRepo}, commitErr: commitErr}
+		m := metrics.NewIsolatedMetrics()
+		return NewUserService(mockRepo, tx, new(MockJWTManager), nil, m, nil, nil), tx, m
+	}
+
+	t.Run("rejected inputs do not affect the batch", func(t *testing.T) {
+		service, tx, m := setup(nil)
+
+		users, errs, err := service.CreateUsers(context.Background(), inputs)
+
+		require.NoError(t, err)
+		assert.True(t, tx.committed)
+		require.NotNil(t, users[0])
+		assert.Equal(t, "alice", users[0].Username)
+		assert.NoError(t, errs[0])
+		assert.Nil(t, users[1])
+		assert.ErrorIs(t, errs[1], apperrors.ErrConflict)
+		assert.Equal(t, 1.0, testutil.ToFloat64(m.UsersCreatedTotal.WithLabelValues("member")))
+	})
+
+	t.Run("failed commit creates no user", func(t *testing.T) {
+		service, _, m := setup(errors.New("connection reset"))
+
+		users, errs, err := service.CreateUsers(context.Background(), inputs)
+
+		require.Error(t, err)
+		assert.Nil(t, users)
+		assert.Nil(t, errs)
+		assert.Equal(t, 0, testutil.CollectAndCount(m.UsersCreatedTotal))
+	})
+}
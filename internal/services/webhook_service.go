@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/audit"
+	"seta-training/internal/models"
+	"seta-training/internal/repositories"
+	"seta-training/pkg/events"
+	"seta-training/pkg/logger"
+	"seta-training/pkg/webhook"
+)
+
+const (
+	// DefaultWebhookDeliveryLimit is the number of deliveries listed when the
+	// caller doesn't ask for a limit
+	DefaultWebhookDeliveryLimit = 50
+	// MaxWebhookDeliveryLimit is the most deliveries listed at once
+	MaxWebhookDeliveryLimit = 200
+)
+
+// WebhookService manages webhook subscriptions and delivers domain events to
+// them. Deliveries go through the shared webhook.Sender, which signs each
+// request and retries failures with backoff; every outcome is recorded in
+// the delivery log.
+type WebhookService struct {
+	webhookRepo repositories.WebhookRepositoryInterface
+	sender      *webhook.Sender
+	audit       audit.Recorder
+	logger      logger.Logger
+}
+
+// NewWebhookService creates a webhook service. auditor and log may be nil.
+func NewWebhookService(webhookRepo repositories.WebhookRepositoryInterface, sender *webhook.Sender, auditor audit.Recorder, log logger.Logger) *WebhookService {
+	if auditor == nil {
+		auditor = audit.Nop{}
+	}
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		sender:      sender,
+		audit:       auditor,
+		logger:      log,
+	}
+}
+
+type WebhookInput struct {
+	URL        string   `json:"url" binding:"required,url,max=2048"`
+	EventTypes []string `json:"event_types" binding:"required,min=1"`
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+// WebhookWithSecret is returned when a webhook is created; it is the only
+// time the signing secret is shown
+type WebhookWithSecret struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+func (s *WebhookService) CreateWebhook(ctx context.Context, input *WebhookInput, ownerID uuid.UUID) (*WebhookWithSecret, error) {
+	if err := validateWebhookInput(input); err != nil {
+		return nil, err
+	}
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	hook := &models.Webhook{
+		OwnerID:    ownerID,
+		URL:        input.URL,
+		Secret:     secret,
+		EventTypes: input.EventTypes,
+		Active:     input.Active == nil || *input.Active,
+	}
+	if err := s.webhookRepo.Create(ctx, hook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    ownerID,
+		Action:     audit.ActionCreate,
+		TargetType: audit.TargetWebhook,
+		TargetID:   hook.ID,
+		Details:    map[string]string{"url": hook.URL},
+	})
+
+	return &WebhookWithSecret{Webhook: *hook, Secret: secret}, nil
+}
+
+func (s *WebhookService) GetWebhook(ctx context.Context, webhookID, userID uuid.UUID) (*models.Webhook, error) {
+	hook, err := s.webhookRepo.GetByID(ctx, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if hook.OwnerID != userID {
+		return nil, apperrors.Forbidden("access denied")
+	}
+	return hook, nil
+}
+
+func (s *WebhookService) GetUserWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	return s.webhookRepo.GetByOwner(ctx, userID)
+}
+
+func (s *WebhookService) UpdateWebhook(ctx context.Context, webhookID uuid.UUID, input *WebhookInput, userID uuid.UUID) (*models.Webhook, error) {
+	hook, err := s.GetWebhook(ctx, webhookID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateWebhookInput(input); err != nil {
+		return nil, err
+	}
+
+	hook.URL = input.URL
+	hook.EventTypes = input.EventTypes
+	if input.Active != nil {
+		hook.Active = *input.Active
+	}
+	if err := s.webhookRepo.Update(ctx, hook); err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionUpdate,
+		TargetType: audit.TargetWebhook,
+		TargetID:   hook.ID,
+		Details:    map[string]string{"url": hook.URL},
+	})
+
+	return hook, nil
+}
+
+func (s *WebhookService) DeleteWebhook(ctx context.Context, webhookID, userID uuid.UUID) error {
+	if _, err := s.GetWebhook(ctx, webhookID, userID); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.Delete(ctx, webhookID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, audit.Entry{
+		ActorID:    userID,
+		Action:     audit.ActionDelete,
+		TargetType: audit.TargetWebhook,
+		TargetID:   webhookID,
+	})
+	return nil
+}
+
+func (s *WebhookService) GetDeliveries(ctx context.Context, webhookID, userID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetWebhook(ctx, webhookID, userID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = DefaultWebhookDeliveryLimit
+	}
+	if limit > MaxWebhookDeliveryLimit {
+		limit = MaxWebhookDeliveryLimit
+	}
+	return s.webhookRepo.GetDeliveries(ctx, webhookID, limit)
+}
+
+// Dispatch sends event to every webhook subscribed to its type. It is
+// registered as an event bus handler; deliveries run in the background.
+func (s *WebhookService) Dispatch(ctx context.Context, event events.Event) error {
+	hooks, err := s.webhookRepo.GetSubscribed(ctx, event.Type)
+	if err != nil {
+		return fmt.Errorf("failed to find webhooks for %s: %w", event.Type, err)
+	}
+
+	for _, hook := range hooks {
+		webhookID := hook.ID
+		endpoint := webhook.Endpoint{URL: hook.URL, Secret: hook.Secret}
+		s.sender.SendAsyncFunc(endpoint, event.Type, event, func(result *webhook.Result, err error) {
+			s.recordDelivery(ctx, webhookID, event, result, err)
+		})
+	}
+	return nil
+}
+
+func (s *WebhookService) recordDelivery(ctx context.Context, webhookID uuid.UUID, event events.Event, result *webhook.Result, err error) {
+	delivery := &models.WebhookDelivery{
+		WebhookID: webhookID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Success:   err == nil,
+		CreatedAt: time.Now(),
+	}
+	if result != nil {
+		delivery.StatusCode = result.StatusCode
+		delivery.Attempts = result.Attempts
+		delivery.DurationMs = result.Duration.Milliseconds()
+	}
+	if err != nil {
+		delivery.Error = err.Error()
+	}
+
+	if err := s.webhookRepo.CreateDelivery(ctx, delivery); err != nil {
+		s.logger.Error("Failed to record webhook delivery",
+			logger.String("webhook_id", webhookID.String()),
+			logger.String("event_id", event.ID.String()),
+			logger.Error(err),
+		)
+	}
+}
+
+func validateWebhookInput(input *WebhookInput) error {
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return apperrors.ValidationFields("Invalid input", map[string]string{
+			"url": "must be an absolute http or https URL",
+		})
+	}
+	for _, eventType := range input.EventTypes {
+		if !models.IsWebhookEventType(eventType) {
+			return apperrors.ValidationFields("Invalid input", map[string]string{
+				"event_types": fmt.Sprintf("unknown event type %q", eventType),
+			})
+		}
+	}
+	return nil
+}
+
+// newWebhookSecret returns a random signing secret
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"seta-training/internal/apperrors"
+	"seta-training/internal/models"
+	"seta-training/pkg/events"
+	"seta-training/pkg/webhook"
+)
+
+// MockWebhookRepository is a mock implementation of WebhookRepositoryInterface
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) Create(ctx context.Context, hook *models.Webhook) error {
+	args := m.Called(hook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetByOwner(ctx context.Context, ownerID uuid.UUID) ([]models.Webhook, error) {
+	args := m.Called(ownerID)
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) GetSubscribed(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	args := m.Called(eventType)
+	return args.Get(0).([]models.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepository) Update(ctx context.Context, hook *models.Webhook) error {
+	args := m.Called(hook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepository) GetDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(webhookID, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func TestWebhookService_CreateWebhook_Success(t *testing.T) {
+	// Setup
+	mockRepo := new(MockWebhookRepository)
+	service := NewWebhookService(mockRepo, nil, nil, nil)
+
+	ownerID := uuid.New()
+	input := &WebhookInput{
+		URL:        "https://hooks.example.com/seta",
+		EventTypes: []string{models.EventUserCreated, models.EventNoteShared},
+	}
+	mockRepo.On("Create", mock.AnythingOfType("*models.Webhook")).Return(nil)
+
+	// Execute
+	hook, err := service.CreateWebhook(context.Background(), input, ownerID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, ownerID, hook.OwnerID)
+	assert.True(t, hook.Active)
+	assert.Regexp(t, `^whsec_[0-9a-f]{64}$`, hook.Secret)
+	assert.Equal(t, hook.Secret, hook.Webhook.Secret)
+
+	body, err := json.Marshal(hook.Webhook)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), hook.Secret)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_CreateWebhook_Invalid(t *testing.T) {
+	service := NewWebhookService(new(MockWebhookRepository), nil, nil, nil)
+
+	tests := []struct {
+		name  string
+		input WebhookInput
+		field string
+	}{
+		{"unknown event", WebhookInput{URL: "https://example.com", EventTypes: []string{"user.deleted"}}, "event_types"},
+		{"non-http scheme", WebhookInput{URL: "ftp://example.com/hook", EventTypes: []string{models.EventUserCreated}}, "url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateWebhook(context.Background(), &tt.input, uuid.New())
+
+			assert.ErrorIs(t, err, apperrors.ErrValidation)
+			var appErr *apperrors.Error
+			require.ErrorAs(t, err, &appErr)
+			assert.Contains(t, appErr.Fields, tt.field)
+		})
+	}
+}
+
+func TestWebhookService_GetDeliveries_NotOwner(t *testing.T) {
+	// Setup
+	mockRepo := new(MockWebhookRepository)
+	service := NewWebhookService(mockRepo, nil, nil, nil)
+
+	webhookID := uuid.New()
+	mockRepo.On("GetByID", webhookID).Return(&models.Webhook{ID: webhookID, OwnerID: uuid.New()}, nil)
+
+	// Execute
+	_, err := service.GetDeliveries(context.Background(), webhookID, uuid.New(), 0)
+
+	// Assert
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	mockRepo.AssertNotCalled(t, "GetDeliveries", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_Dispatch_SignsAndRecordsDelivery(t *testing.T) {
+	const secret = "whsec_test"
+	received := make(chan *http.Request, 1)
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// Setup
+	mockRepo := new(MockWebhookRepository)
+	sender := webhook.NewSender(time.Second, 0, time.Millisecond, nil)
+	service := NewWebhookService(mockRepo, sender, nil, nil)
+
+	hook := models.Webhook{ID: uuid.New(), URL: server.URL, Secret: secret, Active: true}
+	event := events.Event{
+		ID:          uuid.New(),
+		Type:        models.EventUserCreated,
+		AggregateID: uuid.New(),
+		OccurredAt:  time.Now().UTC(),
+		Data:        json.RawMessage(`{"email":"new@example.com"}`),
+	}
+	mockRepo.On("GetSubscribed", models.EventUserCreated).Return([]models.Webhook{hook}, nil)
+	mockRepo.On("CreateDelivery", mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+		return d.WebhookID == hook.ID && d.EventID == event.ID && d.Success &&
+			d.StatusCode == http.StatusNoContent && d.Attempts == 1
+	})).Return(nil)
+
+	// Execute
+	require.NoError(t, service.Dispatch(context.Background(), event))
+	sender.Wait()
+
+	// Assert
+	req := <-received
+	assert.Equal(t, models.EventUserCreated, req.Header.Get(webhook.EventHeader))
+	timestamp, err := strconv.ParseInt(req.Header.Get(webhook.TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.Sign(secret, timestamp, body), req.Header.Get(webhook.SignatureHeader))
+
+	var delivered events.Event
+	require.NoError(t, json.Unmarshal(body, &delivered))
+	assert.Equal(t, event.ID, delivered.ID)
+	assert.JSONEq(t, string(event.Data), string(delivered.Data))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestWebhookService_Dispatch_RecordsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// Setup
+	mockRepo := new(MockWebhookRepository)
+	sender := webhook.NewSender(time.Second, 2, time.Millisecond, nil)
+	service := NewWebhookService(mockRepo, sender, nil, nil)
+
+	hook := models.Webhook{ID: uuid.New(), URL: server.URL, Secret: "s", Active: true}
+	mockRepo.On("GetSubscribed", models.EventNoteShared).Return([]models.Webhook{hook}, nil)
+	mockRepo.On("CreateDelivery", mock.MatchedBy(func(d *models.WebhookDelivery) bool {
+		return !d.Success && d.StatusCode == http.StatusServiceUnavailable && d.Attempts == 3 && d.Error != ""
+	})).Return(nil)
+
+	// Execute
+	require.NoError(t, service.Dispatch(context.Background(), events.Event{ID: uuid.New(), Type: models.EventNoteShared}))
+	sender.Wait()
+
+	// Assert
+	mockRepo.AssertExpectations(t)
+}
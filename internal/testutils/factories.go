@@ -0,0 +1,498 @@
+package testutils
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/models"
+	"seta-training/pkg/auth"
+)
+
+// DefaultPassword is the password of users built without one
+const DefaultPassword = "password123"
+
+// factorySeq numbers built entities so that their names are unique
+var factorySeq atomic.Int64
+
+func nextSeq() int64 {
+	return factorySeq.Add(1)
+}
+
+var (
+	defaultHashOnce sync.Once
+	defaultHash     string
+)
+
+// defaultPasswordHash hashes DefaultPassword once for all users, since
+// bcrypt would dominate the time spent building large datasets
+func defaultPasswordHash() string {
+	defaultHashOnce.Do(func() {
+		hash, err := auth.HashPassword(DefaultPassword)
+		if err != nil {
+			panic(err)
+		}
+		defaultHash = hash
+	})
+	return defaultHash
+}
+
+// UserBuilder builds a user. Start one with UserFactory.
+type UserBuilder struct {
+	user     models.User
+	password string
+	teams    []*models.Team
+}
+
+// UserFactory starts a member with a unique username and email and the
+// password DefaultPassword
+func UserFactory() *UserBuilder {
+	n := nextSeq()
+	return &UserBuilder{user: models.User{
+		ID:       uuid.New(),
+		Username: fmt.Sprintf("user%d", n),
+		Email:    fmt.Sprintf("user%d@example.com", n),
+		Role:     models.RoleMember,
+	}}
+}
+
+func (b *UserBuilder) Manager() *UserBuilder {
+	b.user.Role = models.RoleManager
+	return b
+}
+
+func (b *UserBuilder) Member() *UserBuilder {
+	b.user.Role = models.RoleMember
+	return b
+}
+
+// Named sets the username and derives the email from it
+func (b *UserBuilder) Named(username string) *UserBuilder {
+	b.user.Username = username
+	b.user.Email = username + "@example.com"
+	return b
+}
+
+func (b *UserBuilder) Password(password string) *UserBuilder {
+	b.password = password
+	return b
+}
+
+func (b *UserBuilder) InOrganization(orgID uuid.UUID) *UserBuilder {
+	b.user.OrganizationID = &orgID
+	return b
+}
+
+// InTeam adds the user to team, as a manager if the user is one and as a
+// member otherwise
+func (b *UserBuilder) InTeam(team *models.Team) *UserBuilder {
+	b.teams = append(b.teams, team)
+	return b
+}
+
+// Build returns the user and adds it to the Managers or Members of its teams
+func (b *UserBuilder) Build() *models.User {
+	user := b.user
+	user.PasswordHash = defaultPasswordHash()
+	if b.password != "" {
+		hash, err := auth.HashPassword(b.password)
+		if err != nil {
+			panic(err)
+		}
+		user.PasswordHash = hash
+	}
+	for _, team := range b.teams {
+		if user.IsManager() {
+			team.Managers = append(team.Managers, user)
+		} else {
+			team.Members = append(team.Members, user)
+		}
+	}
+	return &user
+}
+
+// Create builds the user and inserts it and its team memberships into db.
+// The teams must already exist.
+func (b *UserBuilder) Create(tb testing.TB, db *gorm.DB) *models.User {
+	tb.Helper()
+	user := b.Build()
+	insert(tb, db, user)
+	for _, team := range b.teams {
+		insert(tb, db, membership(team.ID, user))
+	}
+	return user
+}
+
+// membership returns the row adding user to a team, as a manager when the
+// user is one and as a member otherwise
+func membership(teamID uuid.UUID, user *models.User) *models.TeamMembership {
+	role := models.TeamRoleMember
+	if user.IsManager() {
+		role = models.TeamRoleManager
+	}
+	return &models.TeamMembership{TeamID: teamID, UserID: user.ID, Role: role}
+}
+
+// TeamBuilder builds a team. Start one with TeamFactory and add people with
+// UserBuilder.InTeam.
+type TeamBuilder struct {
+	team models.Team
+}
+
+// TeamFactory starts a team with a unique name
+func TeamFactory() *TeamBuilder {
+	return &TeamBuilder{team: models.Team{
+		ID:   uuid.New(),
+		Name: fmt.Sprintf("Team %d", nextSeq()),
+	}}
+}
+
+func (b *TeamBuilder) Named(name string) *TeamBuilder {
+	b.team.Name = name
+	return b
+}
+
+func (b *TeamBuilder) InOrganization(orgID uuid.UUID) *TeamBuilder {
+	b.team.OrganizationID = &orgID
+	return b
+}
+
+func (b *TeamBuilder) Build() *models.Team {
+	team := b.team
+	return &team
+}
+
+// Create builds the team and inserts it into db
+func (b *TeamBuilder) Create(tb testing.TB, db *gorm.DB) *models.Team {
+	tb.Helper()
+	team := b.Build()
+	insert(tb, db, team)
+	return team
+}
+
+// share is a user a folder or note is shared with
+type share struct {
+	userID uuid.UUID
+	access models.AccessLevel
+}
+
+// FolderBuilder builds a folder, optionally with notes and shares. Start
+// one with FolderFactory.
+type FolderBuilder struct {
+	folder models.Folder
+	notes  int
+	shares []share
+}
+
+// FolderFactory starts a folder with a unique name owned by owner, in the
+// owner's organization
+func FolderFactory(owner *models.User) *FolderBuilder {
+	return &FolderBuilder{folder: models.Folder{
+		ID:             uuid.New(),
+		Name:           fmt.Sprintf("Folder %d", nextSeq()),
+		OwnerID:        owner.ID,
+		OrganizationID: owner.OrganizationID,
+	}}
+}
+
+func (b *FolderBuilder) Named(name string) *FolderBuilder {
+	b.folder.Name = name
+	return b
+}
+
+// WithNotes fills the folder with n notes of its owner
+func (b *FolderBuilder) WithNotes(n int) *FolderBuilder {
+	b.notes = n
+	return b
+}
+
+func (b *FolderBuilder) SharedWith(user *models.User, access models.AccessLevel) *FolderBuilder {
+	b.shares = append(b.shares, share{userID: user.ID, access: access})
+	return b
+}
+
+// Build returns the folder with its Notes and Shares filled in
+func (b *FolderBuilder) Build() *models.Folder {
+	folder := b.folder
+	folder.Notes = make([]models.Note, 0, b.notes)
+	for i := 0; i < b.notes; i++ {
+		folder.Notes = append(folder.Notes, *NoteFactory(&folder).Build())
+	}
+	folder.Shares = make([]models.FolderShare, 0, len(b.shares))
+	for _, s := range b.shares {
+		folder.Shares = append(folder.Shares, models.FolderShare{
+			ID:       uuid.New(),
+			FolderID: folder.ID,
+			UserID:   s.userID,
+			Access:   s.access,
+		})
+	}
+	return &folder
+}
+
+// Create builds the folder and inserts it, its notes and its shares into
+// db. The owner and the users it is shared with must already exist.
+func (b *FolderBuilder) Create(tb testing.TB, db *gorm.DB) *models.Folder {
+	tb.Helper()
+	folder := b.Build()
+	insert(tb, db, folder, &folder.Notes, &folder.Shares)
+	return folder
+}
+
+// NoteBuilder builds a note. Start one with NoteFactory.
+type NoteBuilder struct {
+	note   models.Note
+	shares []share
+}
+
+// NoteFactory starts a note in folder, owned by the folder's owner
+func NoteFactory(folder *models.Folder) *NoteBuilder {
+	n := nextSeq()
+	return &NoteBuilder{note: models.Note{
+		ID:             uuid.New(),
+		Title:          fmt.Sprintf("Note %d", n),
+		Body:           fmt.Sprintf("Content of note %d", n),
+		FolderID:       folder.ID,
+		OwnerID:        folder.OwnerID,
+		OrganizationID: folder.OrganizationID,
+	}}
+}
+
+func (b *NoteBuilder) Titled(title string) *NoteBuilder {
+	b.note.Title = title
+	return b
+}
+
+func (b *NoteBuilder) WithBody(body string) *NoteBuilder {
+	b.note.Body = body
+	return b
+}
+
+func (b *NoteBuilder) OwnedBy(user *models.User) *NoteBuilder {
+	b.note.OwnerID = user.ID
+	return b
+}
+
+func (b *NoteBuilder) SharedWith(user *models.User, access models.AccessLevel) *NoteBuilder {
+	b.shares = append(b.shares, share{userID: user.ID, access: access})
+	return b
+}
+
+// Build returns the note with its Shares filled in
+func (b *NoteBuilder) Build() *models.Note {
+	note := b.note
+	for _, s := range b.shares {
+		note.Shares = append(note.Shares, models.NoteShare{
+			ID:     uuid.New(),
+			NoteID: note.ID,
+			UserID: s.userID,
+			Access: s.access,
+		})
+	}
+	return &note
+}
+
+// Create builds the note and inserts it and its shares into db
+func (b *NoteBuilder) Create(tb testing.TB, db *gorm.DB) *models.Note {
+	tb.Helper()
+	note := b.Build()
+	insert(tb, db, note, &note.Shares)
+	return note
+}
+
+// Dataset is a generated organization: teams of managers and members who
+// own folders of notes, some shared with their teammates
+type Dataset struct {
+	Teams        []*models.Team
+	Users        []*models.User
+	Folders      []*models.Folder
+	Notes        []*models.Note
+	FolderShares []*models.FolderShare
+	NoteShares   []*models.NoteShare
+}
+
+// DatasetBuilder generates a Dataset. Start one with DatasetFactory.
+type DatasetBuilder struct {
+	teams, managers, members int
+	folders, notes           int
+	shareRate                float64
+	seed                     int64
+	orgID                    *uuid.UUID
+}
+
+// DatasetFactory starts a small dataset: 2 teams of 1 manager and 4
+// members, each owning 2 folders of 5 notes, with a fifth of folders and
+// notes shared. The same settings always produce the same share graph.
+func DatasetFactory() *DatasetBuilder {
+	return &DatasetBuilder{
+		teams:     2,
+		managers:  1,
+		members:   4,
+		folders:   2,
+		notes:     5,
+		shareRate: 0.2,
+		seed:      1,
+	}
+}
+
+func (b *DatasetBuilder) Teams(n int) *DatasetBuilder {
+	b.teams = n
+	return b
+}
+
+// ManagersPerTeam and MembersPerTeam set the size of each team
+func (b *DatasetBuilder) ManagersPerTeam(n int) *DatasetBuilder {
+	b.managers = n
+	return b
+}
+
+func (b *DatasetBuilder) MembersPerTeam(n int) *DatasetBuilder {
+	b.members = n
+	return b
+}
+
+// FoldersPerUser and NotesPerFolder set how much content each user owns
+func (b *DatasetBuilder) FoldersPerUser(n int) *DatasetBuilder {
+	b.folders = n
+	return b
+}
+
+func (b *DatasetBuilder) NotesPerFolder(n int) *DatasetBuilder {
+	b.notes = n
+	return b
+}
+
+// ShareRate is the chance, between 0 and 1, that a folder or note is shared
+// with one of its owner's teammates
+func (b *DatasetBuilder) ShareRate(rate float64) *DatasetBuilder {
+	b.shareRate = rate
+	return b
+}
+
+// Seed picks another share graph
+func (b *DatasetBuilder) Seed(seed int64) *DatasetBuilder {
+	b.seed = seed
+	return b
+}
+
+func (b *DatasetBuilder) InOrganization(orgID uuid.UUID) *DatasetBuilder {
+	b.orgID = &orgID
+	return b
+}
+
+// Build generates the dataset without touching a database
+func (b *DatasetBuilder) Build() *Dataset {
+	rng := rand.New(rand.NewSource(b.seed))
+	accesses := []models.AccessLevel{models.AccessRead, models.AccessWrite}
+	data := &Dataset{}
+
+	for t := 0; t < b.teams; t++ {
+		teamBuilder := TeamFactory()
+		if b.orgID != nil {
+			teamBuilder.InOrganization(*b.orgID)
+		}
+		team := teamBuilder.Build()
+		data.Teams = append(data.Teams, team)
+
+		var teammates []*models.User
+		for i := 0; i < b.managers+b.members; i++ {
+			userBuilder := UserFactory().InTeam(team)
+			if i < b.managers {
+				userBuilder.Manager()
+			}
+			if b.orgID != nil {
+				userBuilder.InOrganization(*b.orgID)
+			}
+			teammates = append(teammates, userBuilder.Build())
+		}
+		data.Users = append(data.Users, teammates...)
+
+		// pickTeammate returns a random teammate other than owner, or nil
+		// when the rate says not to share
+		pickTeammate := func(owner uuid.UUID) *models.User {
+			if len(teammates) < 2 || rng.Float64() >= b.shareRate {
+				return nil
+			}
+			for {
+				if user := teammates[rng.Intn(len(teammates))]; user.ID != owner {
+					return user
+				}
+			}
+		}
+
+		for _, owner := range teammates {
+			for f := 0; f < b.folders; f++ {
+				folderBuilder := FolderFactory(owner).WithNotes(b.notes)
+				if user := pickTeammate(owner.ID); user != nil {
+					folderBuilder.SharedWith(user, accesses[rng.Intn(len(accesses))])
+				}
+				folder := folderBuilder.Build()
+				data.Folders = append(data.Folders, folder)
+				for i := range folder.Shares {
+					data.FolderShares = append(data.FolderShares, &folder.Shares[i])
+				}
+
+				for i := range folder.Notes {
+					note := &folder.Notes[i]
+					if user := pickTeammate(owner.ID); user != nil {
+						note.Shares = append(note.Shares, models.NoteShare{
+							ID:     uuid.New(),
+							NoteID: note.ID,
+							UserID: user.ID,
+							Access: accesses[rng.Intn(len(accesses))],
+						})
+						data.NoteShares = append(data.NoteShares, &note.Shares[0])
+					}
+					data.Notes = append(data.Notes, note)
+				}
+			}
+		}
+	}
+	return data
+}
+
+// Create generates the dataset and inserts it into db in batches
+func (b *DatasetBuilder) Create(tb testing.TB, db *gorm.DB) *Dataset {
+	tb.Helper()
+	data := b.Build()
+
+	var memberships []models.TeamMembership
+	for _, team := range data.Teams {
+		for _, user := range team.Managers {
+			memberships = append(memberships, models.TeamMembership{TeamID: team.ID, UserID: user.ID, Role: models.TeamRoleManager})
+		}
+		for _, user := range team.Members {
+			memberships = append(memberships, models.TeamMembership{TeamID: team.ID, UserID: user.ID, Role: models.TeamRoleMember})
+		}
+	}
+
+	insert(tb, db, &data.Teams, &data.Users, &memberships,
+		&data.Folders, &data.Notes, &data.FolderShares, &data.NoteShares)
+	return data
+}
+
+// insertBatchSize keeps each INSERT of a dataset well below the Postgres
+// limit on bind parameters
+const insertBatchSize = 500
+
+// insert creates each value, a record or a pointer to a slice of records,
+// failing tb on error. Associations are left to the caller and hooks are
+// skipped, since factories already set what the hooks would.
+func insert(tb testing.TB, db *gorm.DB, values ...interface{}) {
+	tb.Helper()
+	session := db.Session(&gorm.Session{SkipHooks: true}).Omit(clause.Associations)
+	for _, value := range values {
+		if v := reflect.Indirect(reflect.ValueOf(value)); v.Kind() == reflect.Slice && v.Len() == 0 {
+			continue
+		}
+		if err := session.CreateInBatches(value, insertBatchSize).Error; err != nil {
+			tb.Fatalf("failed to insert %T: %v", value, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package usage
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/internal/models"
+	"seta-training/pkg/logger"
+)
+
+// Recorder counts requests in memory and adds them to a Store every flush
+// interval, so that requests do not wait on the database
+type Recorder struct {
+	store    Store
+	interval time.Duration
+	logger   logger.Logger
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pending map[rollupKey]*models.APIUsage
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+type rollupKey struct {
+	userID uuid.UUID
+	route  string
+	day    time.Time
+}
+
+func NewRecorder(store Store, interval time.Duration, log logger.Logger) *Recorder {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &Recorder{
+		store:    store,
+		interval: interval,
+		logger:   log,
+		now:      time.Now,
+		pending:  make(map[rollupKey]*models.APIUsage),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Record counts one request by userID to route
+func (r *Recorder) Record(userID uuid.UUID, route string, status int, latency time.Duration) {
+	key := rollupKey{
+		userID: userID,
+		route:  route,
+		day:    r.now().UTC().Truncate(24 * time.Hour),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row, ok := r.pending[key]
+	if !ok {
+		row = &models.APIUsage{UserID: userID, Route: route, Day: key.day}
+		r.pending[key] = row
+	}
+	row.Requests++
+	if status >= http.StatusBadRequest {
+		row.Errors++
+	}
+	row.LatencyMillis += latency.Milliseconds()
+}
+
+// Start launches the background flush
+func (r *Recorder) Start() {
+	go r.run()
+}
+
+// Shutdown stops the background flush and writes what is still pending
+func (r *Recorder) Shutdown(ctx context.Context) error {
+	r.once.Do(func() { close(r.stop) })
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return r.Flush(ctx)
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Flush(context.Background()); err != nil {
+				r.logger.Error("Failed to write API usage", logger.Error(err))
+			}
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+// Flush adds the pending counts to the store. Counts that fail to be
+// written are dropped rather than retried, so a database outage cannot grow
+// the recorder without bound.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	r.pending = make(map[rollupKey]*models.APIUsage, len(pending))
+	r.mu.Unlock()
+
+	rows := make([]models.APIUsage, 0, len(pending))
+	for _, row := range pending {
+		rows = append(rows, *row)
+	}
+	return r.store.Add(ctx, rows)
+}
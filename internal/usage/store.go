@@ -0,0 +1,117 @@
+// Package usage rolls API requests up per user, route and day, so that
+// admins can see who uses the API most.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"seta-training/internal/database"
+	"seta-training/internal/models"
+)
+
+// Ways a usage report can be grouped
+const (
+	GroupByUser  = "user"
+	GroupByTeam  = "team"
+	GroupByRoute = "route"
+)
+
+// GroupBys lists the valid groupings
+var GroupBys = []string{GroupByUser, GroupByTeam, GroupByRoute}
+
+// Filter selects the days [From, To) of a usage report
+type Filter struct {
+	GroupBy string
+	From    time.Time
+	To      time.Time
+	Limit   int
+}
+
+// Summary is one row of a usage report. Key is the user ID, team ID or
+// route and Name the username or team name.
+type Summary struct {
+	Key              string  `json:"key" gorm:"column:group_key"`
+	Name             string  `json:"name,omitempty"`
+	Requests         int64   `json:"requests"`
+	Errors           int64   `json:"errors"`
+	AvgLatencyMillis float64 `json:"avg_latency_millis"`
+	LatencyMillis    int64   `json:"-"`
+}
+
+// Store persists and queries usage rollups
+type Store interface {
+	// Add adds the counts of rows to those already stored
+	Add(ctx context.Context, rows []models.APIUsage) error
+	// Summarize returns the busiest groups first
+	Summarize(ctx context.Context, filter Filter) ([]Summary, error)
+}
+
+// GormStore keeps usage in the api_usages table
+type GormStore struct {
+	db *gorm.DB
+}
+
+func NewGormStore(db *gorm.DB) *GormStore {
+	return &GormStore{db: db}
+}
+
+func (s *GormStore) Add(ctx context.Context, rows []models.APIUsage) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	db := s.db.WithContext(ctx)
+	add := func(column string) clause.Assignment {
+		return clause.Assignment{
+			Column: clause.Column{Name: column},
+			Value:  gorm.Expr("api_usages." + column + " + " + database.Excluded(db, column)),
+		}
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "route"}, {Name: "day"}},
+		DoUpdates: clause.Set{add("requests"), add("errors"), add("latency_millis")},
+	}).Create(&rows).Error
+	if err != nil {
+		return fmt.Errorf("failed to record API usage: %w", err)
+	}
+	return nil
+}
+
+// Summarize reads a replica: reports tolerate lag. A user in several teams
+// counts towards each of them.
+func (s *GormStore) Summarize(ctx context.Context, filter Filter) ([]Summary, error) {
+	query := database.ReadReplica(s.db.WithContext(ctx)).Table("api_usages").
+		Where("api_usages.day >= ? AND api_usages.day < ?", filter.From, filter.To)
+
+	switch filter.GroupBy {
+	case GroupByTeam:
+		query = query.
+			Select("teams.id AS group_key, teams.name AS name, " + totals).
+			Joins("JOIN team_memberships memberships ON memberships.user_id = api_usages.user_id").
+			Joins("JOIN teams ON teams.id = memberships.team_id").
+			Group("teams.id, teams.name")
+	case GroupByRoute:
+		query = query.Select("api_usages.route AS group_key, " + totals).Group("api_usages.route")
+	default:
+		query = query.
+			Select("users.id AS group_key, users.username AS name, " + totals).
+			Joins("JOIN users ON users.id = api_usages.user_id").
+			Group("users.id, users.username")
+	}
+
+	var summaries []Summary
+	if err := query.Order("requests DESC").Limit(filter.Limit).Scan(&summaries).Error; err != nil {
+		return nil, fmt.Errorf("failed to query API usage: %w", err)
+	}
+	for i := range summaries {
+		if summaries[i].Requests > 0 {
+			summaries[i].AvgLatencyMillis = float64(summaries[i].LatencyMillis) / float64(summaries[i].Requests)
+		}
+	}
+	return summaries, nil
+}
+
+const totals = "SUM(api_usages.requests) AS requests, SUM(api_usages.errors) AS errors, SUM(api_usages.latency_millis) AS latency_millis"
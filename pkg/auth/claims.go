@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"seta-training/internal/models"
+)
+
+// Scopes name what a token may be used for
+const (
+	ScopeTeamsManage    = "teams:manage"
+	ScopeUsersImport    = "users:import"
+	ScopeAuditRead      = "audit:read"
+	ScopeWebhooksManage = "webhooks:manage"
+	// ScopeAdmin is granted to configured users rather than by role
+	ScopeAdmin = "admin"
+)
+
+var roleScopes = map[models.UserRole][]string{
+	models.RoleManager: {ScopeTeamsManage, ScopeUsersImport, ScopeAuditRead, ScopeWebhooksManage},
+}
+
+// ScopesForRole returns the scopes granted to users with role
+func ScopesForRole(role models.UserRole) []string {
+	return slices.Clone(roleScopes[role])
+}
+
+// TeamClaim is a team the user belongs to and their role in it
+type TeamClaim struct {
+	ID   uuid.UUID       `json:"id"`
+	Role models.TeamRole `json:"role"`
+}
+
+// ClaimsBuilder adds claims to a token before it is signed. It runs when a
+// token is issued and again when it is refreshed, so it should derive
+// claims from claims.UserID rather than copy them from the old token.
+type ClaimsBuilder func(claims *Claims) error
+
+// ChainClaimsBuilders runs builders in order, stopping at the first error
+func ChainClaimsBuilders(builders ...ClaimsBuilder) ClaimsBuilder {
+	return func(claims *Claims) error {
+		for _, build := range builders {
+			if err := build(claims); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// AdminScope grants ScopeAdmin to the users named in admins, matched by
+// username or case-insensitively by email
+func AdminScope(admins []string) ClaimsBuilder {
+	return func(claims *Claims) error {
+		for _, admin := range admins {
+			if admin == claims.Username || strings.EqualFold(admin, claims.Email) {
+				claims.Scopes = append(claims.Scopes, ScopeAdmin)
+				break
+			}
+		}
+		return nil
+	}
+}
+
+// HasScope reports whether the token grants scope. Tokens issued before
+// scopes existed get the scopes of their role.
+func (c *Claims) HasScope(scope string) bool {
+	if c.Scopes == nil {
+		return slices.Contains(roleScopes[c.Role], scope)
+	}
+	return slices.Contains(c.Scopes, scope)
+}
+
+// TeamRole returns the user's role in team and whether they belong to it
+func (c *Claims) TeamRole(teamID uuid.UUID) (models.TeamRole, bool) {
+	for _, team := range c.Teams {
+		if team.ID == teamID {
+			return team.Role, true
+		}
+	}
+	return "", false
+}
+
+type claimsContextKey struct{}
+
+// NewContext returns a copy of ctx carrying the caller's claims, for code
+// that only sees the request context, such as GraphQL resolvers
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsContextKey{}, claims)
+}
+
+// FromContext returns the claims stored in ctx by NewContext
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*Claims)
+	return claims, ok
+}
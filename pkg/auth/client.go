@@ -0,0 +1,23 @@
+package auth
+
+import "context"
+
+// Client is where a request comes from, as recorded in login history
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+type clientContextKey struct{}
+
+// WithClient returns a copy of ctx carrying the client making the request
+func WithClient(ctx context.Context, client Client) context.Context {
+	return context.WithValue(ctx, clientContextKey{}, client)
+}
+
+// ClientFromContext returns the client stored by WithClient, or the zero
+// Client outside of a request
+func ClientFromContext(ctx context.Context) Client {
+	client, _ := ctx.Value(clientContextKey{}).(Client)
+	return client
+}
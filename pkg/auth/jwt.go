@@ -2,6 +2,9 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"slices"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -12,6 +15,8 @@ import (
 // JWTManagerInterface defines the interface for JWT management
 type JWTManagerInterface interface {
 	GenerateToken(user *models.User) (string, error)
+	GenerateDeviceToken(user *models.User, deviceID uuid.UUID) (string, error)
+	Impersonate(user *models.User, impersonatorID uuid.UUID, ttl time.Duration) (string, error)
 	ValidateToken(tokenString string) (*Claims, error)
 	RefreshToken(tokenString string) (string, error)
 }
@@ -21,47 +26,173 @@ type Claims struct {
 	Username string          `json:"username"`
 	Email    string          `json:"email"`
 	Role     models.UserRole `json:"role"`
+	Scopes   []string        `json:"scopes,omitempty"`
+	// OrgID is the user's organization; nil for the default tenant
+	OrgID *uuid.UUID `json:"org_id,omitempty"`
+	// Teams is set by the claims builder; nil when memberships are unknown
+	Teams []TeamClaim `json:"teams"`
+	// ImpersonatorID is the admin acting as the user, on impersonation
+	// tokens only
+	ImpersonatorID *uuid.UUID `json:"impersonator_id,omitempty"`
+	// DeviceID is the device the user logged in with; revoking it rejects
+	// the token and the tokens it is refreshed into
+	DeviceID *uuid.UUID `json:"device_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// JWTManager issues and validates tokens. Without a key set tokens are
+// signed with the shared secret (HS256). With one they are signed with the
+// active key and carry its ID in the kid header, so services holding only
+// the public keys can verify them. While a secret is configured, HS256
+// tokens issued before the switch stay valid until they expire.
 type JWTManager struct {
 	secretKey   string
 	expiryHours int
+	keys        atomic.Pointer[KeySet]
+	builder     ClaimsBuilder
+	check       TokenCheck
 }
 
+// TokenCheck rejects otherwise valid tokens, e.g. those of revoked devices
+type TokenCheck func(claims *Claims) error
+
 func NewJWTManager(secretKey string, expiryHours int) *JWTManager {
-	return &JWTManager{
+	return NewJWTManagerWithKeys(secretKey, expiryHours, nil)
+}
+
+// NewJWTManagerWithKeys creates a manager signing with keys; keys may be nil
+func NewJWTManagerWithKeys(secretKey string, expiryHours int, keys *KeySet) *JWTManager {
+	j := &JWTManager{
 		secretKey:   secretKey,
 		expiryHours: expiryHours,
 	}
+	j.keys.Store(keys)
+	return j
+}
+
+// SetKeys replaces the key set, e.g. after a key is added or retired
+func (j *JWTManager) SetKeys(keys *KeySet) {
+	j.keys.Store(keys)
+}
+
+// SetClaimsBuilder sets a hook that adds claims such as team memberships to
+// every token issued or refreshed. Call it before the manager is used.
+func (j *JWTManager) SetClaimsBuilder(builder ClaimsBuilder) {
+	j.builder = builder
+}
+
+// SetTokenCheck sets a hook that every validated token must pass. Call it
+// before the manager is used.
+func (j *JWTManager) SetTokenCheck(check TokenCheck) {
+	j.check = check
+}
+
+// JWKS returns the public keys tokens may be signed with. It is empty when
+// tokens are signed with the shared secret.
+func (j *JWTManager) JWKS() JWKS {
+	if keys := j.keys.Load(); keys != nil {
+		return keys.JWKS()
+	}
+	return JWKS{Keys: []JWK{}}
+}
+
+func (j *JWTManager) sign(claims *Claims) (string, error) {
+	keys := j.keys.Load()
+	if keys == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(j.secretKey))
+	}
+
+	active := keys.Active()
+	token := jwt.NewWithClaims(active.Method, claims)
+	token.Header["kid"] = active.ID
+	return token.SignedString(active.private)
+}
+
+// verificationKey picks the key to check token's signature with
+func (j *JWTManager) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if j.secretKey == "" {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(j.secretKey), nil
+	}
+
+	keys := j.keys.Load()
+	kid, _ := token.Header["kid"].(string)
+	if keys == nil || kid == "" {
+		return nil, errors.New("unexpected signing method")
+	}
+	key, ok := keys.Lookup(kid)
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.public, nil
 }
 
 func (j *JWTManager) GenerateToken(user *models.User) (string, error) {
-	claims := &Claims{
+	return j.issue(&Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		OrgID:    user.OrganizationID,
+	}, time.Duration(j.expiryHours)*time.Hour)
+}
+
+// GenerateDeviceToken issues a token for user bound to deviceID
+func (j *JWTManager) GenerateDeviceToken(user *models.User, deviceID uuid.UUID) (string, error) {
+	return j.issue(&Claims{
 		UserID:   user.ID,
 		Username: user.Username,
 		Email:    user.Email,
 		Role:     user.Role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(j.expiryHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "seta-training",
-			Subject:   user.ID.String(),
-		},
+		OrgID:    user.OrganizationID,
+		DeviceID: &deviceID,
+	}, time.Duration(j.expiryHours)*time.Hour)
+}
+
+// Impersonate issues a token that lets impersonatorID act as user for ttl.
+// It carries the user's scopes and teams but never the admin scope, so it
+// cannot be used to impersonate someone else, and it cannot be refreshed.
+func (j *JWTManager) Impersonate(user *models.User, impersonatorID uuid.UUID, ttl time.Duration) (string, error) {
+	return j.issue(&Claims{
+		UserID:         user.ID,
+		Username:       user.Username,
+		Email:          user.Email,
+		Role:           user.Role,
+		OrgID:          user.OrganizationID,
+		ImpersonatorID: &impersonatorID,
+	}, ttl)
+}
+
+// issue fills in scopes, builder claims and expiry, then signs claims
+func (j *JWTManager) issue(claims *Claims, ttl time.Duration) (string, error) {
+	claims.Scopes = ScopesForRole(claims.Role)
+	if j.builder != nil {
+		if err := j.builder(claims); err != nil {
+			return "", fmt.Errorf("failed to build token claims: %w", err)
+		}
+	}
+	if claims.ImpersonatorID != nil {
+		claims.Scopes = slices.DeleteFunc(claims.Scopes, func(scope string) bool { return scope == ScopeAdmin })
+	}
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		NotBefore: jwt.NewNumericDate(time.Now()),
+		Issuer:    "seta-training",
+		Subject:   claims.UserID.String(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.secretKey))
+	return j.sign(claims)
 }
 
 func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(j.secretKey), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, j.verificationKey)
 
 	if err != nil {
 		return nil, err
@@ -72,6 +203,11 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 		if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
 			return nil, errors.New("token has expired")
 		}
+		if j.check != nil {
+			if err := j.check(claims); err != nil {
+				return nil, err
+			}
+		}
 		return claims, nil
 	}
 
@@ -83,22 +219,17 @@ func (j *JWTManager) RefreshToken(tokenString string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if claims.ImpersonatorID != nil {
+		return "", errors.New("impersonation tokens cannot be refreshed")
+	}
 
-	// Create new token with extended expiry
-	newClaims := &Claims{
+	// Create new token with extended expiry and current memberships
+	return j.issue(&Claims{
 		UserID:   claims.UserID,
 		Username: claims.Username,
 		Email:    claims.Email,
 		Role:     claims.Role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(j.expiryHours) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "seta-training",
-			Subject:   claims.UserID.String(),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims)
-	return token.SignedString([]byte(j.secretKey))
+		OrgID:    claims.OrgID,
+		DeviceID: claims.DeviceID,
+	}, time.Duration(j.expiryHours)*time.Hour)
 }
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey is an asymmetric key identified by the kid header of the tokens
+// it signs. Keys loaded from a public key file can only verify tokens.
+type SigningKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// CanSign reports whether the key has a private part
+func (k *SigningKey) CanSign() bool {
+	return k.private != nil
+}
+
+// KeySet is the set of keys tokens are signed and verified with. New tokens
+// are signed with the active key; tokens signed with any other key in the
+// set stay valid until they expire, so keys can be rotated without signing
+// everyone out.
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// Algorithms lists the signing algorithms JWTManager supports. HS256 signs
+// with the shared secret; the others sign with a key from a KeySet.
+var Algorithms = []string{"HS256", "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// LoadKeySet reads every *.pem file in dir. The file name without extension
+// is the key ID. Files may hold a private key (PKCS#1, PKCS#8 or SEC 1) or
+// a public key (PKIX) for keys that are retired but still verify tokens.
+// activeID picks the signing key; it may be empty when dir holds exactly one
+// private key. The active key must suit algorithm; other keys use algorithm
+// when they can, so keys of a previous type keep verifying their tokens.
+func LoadKeySet(dir, activeID, algorithm string) (*KeySet, error) {
+	method := jwt.GetSigningMethod(algorithm)
+	if method == nil || algorithm == "HS256" {
+		return nil, fmt.Errorf("%q is not an asymmetric signing algorithm", algorithm)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	set := &KeySet{keys: make(map[string]*SigningKey, len(paths))}
+	var signers []*SigningKey
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		id := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := ParseSigningKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if key.accepts(method) {
+			key.Method = method
+		}
+		set.keys[id] = key
+		if key.CanSign() {
+			signers = append(signers, key)
+		}
+	}
+
+	switch {
+	case activeID != "":
+		set.active = set.keys[activeID]
+		if set.active == nil || !set.active.CanSign() {
+			return nil, fmt.Errorf("active key %q has no private key in %s", activeID, dir)
+		}
+	case len(signers) == 1:
+		set.active = signers[0]
+	default:
+		return nil, fmt.Errorf("%s holds %d private keys; set the active key ID", dir, len(signers))
+	}
+	if set.active.Method != method {
+		return nil, fmt.Errorf("active key %q cannot sign %s", set.active.ID, algorithm)
+	}
+	return set, nil
+}
+
+// ParseSigningKey parses a PEM encoded RSA, ECDSA or Ed25519 key. Method is
+// the key type's default algorithm: RS256, ES256/384/512 or EdDSA.
+func ParseSigningKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{ID: id}
+	if signer, ok := parsed.(crypto.Signer); ok {
+		key.private = signer
+		key.public = signer.Public()
+	} else {
+		key.public = parsed
+	}
+
+	switch pub := key.public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.Method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		switch pub.Curve {
+		case elliptic.P256():
+			key.Method = jwt.SigningMethodES256
+		case elliptic.P384():
+			key.Method = jwt.SigningMethodES384
+		case elliptic.P521():
+			key.Method = jwt.SigningMethodES512
+		default:
+			return nil, errors.New("unsupported elliptic curve")
+		}
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key.public)
+	}
+	return key, nil
+}
+
+// accepts reports whether the key's type can be used with method
+func (k *SigningKey) accepts(method jwt.SigningMethod) bool {
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok := k.public.(*rsa.PublicKey)
+		return ok
+	case *jwt.SigningMethodECDSA:
+		// The curve fixes the algorithm, e.g. P-256 is ES256 only
+		return k.Method == method
+	case *jwt.SigningMethodEd25519:
+		_, ok := k.public.(ed25519.PublicKey)
+		return ok
+	}
+	return false
+}
+
+// Active returns the key new tokens are signed with
+func (s *KeySet) Active() *SigningKey {
+	return s.active
+}
+
+// Lookup returns the key with the given ID
+func (s *KeySet) Lookup(id string) (*SigningKey, bool) {
+	key, ok := s.keys[id]
+	return key, ok
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// EC and OKP (Ed25519)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+// JWKS is the document served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public part of every key in the set, sorted by ID
+func (s *KeySet) JWKS() JWKS {
+	doc := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		doc.Keys = append(doc.Keys, key.jwk())
+	}
+	sort.Slice(doc.Keys, func(i, j int) bool { return doc.Keys[i].KeyID < doc.Keys[j].KeyID })
+	return doc
+}
+
+func (k *SigningKey) jwk() JWK {
+	jwk := JWK{KeyID: k.ID, Use: "sig", Algorithm: k.Method.Alg()}
+	switch pub := k.public.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.KeyType = "EC"
+		jwk.Curve = pub.Curve.Params().Name
+		jwk.X = b64(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = b64(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = b64(pub)
+	}
+	return jwk
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
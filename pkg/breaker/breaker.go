@@ -0,0 +1,178 @@
+// Package breaker implements a circuit breaker. After enough consecutive
+// failures it opens and rejects calls outright, so callers fail fast instead
+// of queueing behind a dependency that is down. Once a cooldown has passed it
+// lets a few probe calls through, closing again if they all succeed.
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by Allow while the breaker rejects calls
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the position of a breaker
+type State int
+
+const (
+	// Closed lets every call through
+	Closed State = iota
+	// HalfOpen lets a limited number of probe calls through
+	HalfOpen
+	// Open rejects every call until the cooldown has passed
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half_open"
+	case Open:
+		return "open"
+	}
+	return "unknown"
+}
+
+// Outcome is how a call let through by Allow went
+type Outcome int
+
+const (
+	Succeeded Outcome = iota
+	Failed
+	// Ignored calls, such as ones the caller gave up on, say nothing about
+	// the health of the dependency
+	Ignored
+)
+
+// Breaker tracks the outcome of calls to one dependency. It is safe for
+// concurrent use.
+type Breaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	probes           int
+	onStateChange    func(from, to State)
+	now              func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// inFlight and successes count the probes of the current half-open spell
+	inFlight  int
+	successes int
+	// generation changes with every state change, so outcomes of calls let
+	// through in an earlier state are disregarded
+	generation uint64
+}
+
+// New creates a closed breaker that opens after failureThreshold consecutive
+// failures and stays open for openTimeout. It then lets up to probes calls
+// through and closes once that many have succeeded. onStateChange, which may
+// be nil, is called on every state change while the breaker is locked, so it
+// must not call back into it.
+func New(failureThreshold int, openTimeout time.Duration, probes int, onStateChange func(from, to State)) *Breaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	if probes < 1 {
+		probes = 1
+	}
+	return &Breaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		probes:           probes,
+		onStateChange:    onStateChange,
+		now:              time.Now,
+	}
+}
+
+// State returns the current state of the breaker
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireOpen()
+	return b.state
+}
+
+// Allow reports whether a call may go ahead, returning ErrOpen if not. The
+// returned done func must be called exactly once with the outcome of an
+// allowed call.
+func (b *Breaker) Allow() (done func(Outcome), err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expireOpen()
+	switch b.state {
+	case Open:
+		return nil, ErrOpen
+	case HalfOpen:
+		if b.inFlight+b.successes >= b.probes {
+			return nil, ErrOpen
+		}
+		b.inFlight++
+	}
+
+	generation := b.generation
+	var once sync.Once
+	return func(outcome Outcome) {
+		once.Do(func() { b.done(generation, outcome) })
+	}, nil
+}
+
+func (b *Breaker) done(generation uint64, outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case Closed:
+		switch outcome {
+		case Succeeded:
+			b.failures = 0
+		case Failed:
+			b.failures++
+			if b.failures >= b.failureThreshold {
+				b.setState(Open)
+			}
+		}
+	case HalfOpen:
+		b.inFlight--
+		switch outcome {
+		case Succeeded:
+			b.successes++
+			if b.successes >= b.probes {
+				b.setState(Closed)
+			}
+		case Failed:
+			b.setState(Open)
+		}
+	}
+}
+
+// expireOpen moves an open breaker whose cooldown has passed to half-open
+func (b *Breaker) expireOpen() {
+	if b.state == Open && b.now().Sub(b.openedAt) >= b.openTimeout {
+		b.setState(HalfOpen)
+	}
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.inFlight = 0
+	b.successes = 0
+	if state == Open {
+		b.openedAt = b.now()
+	}
+	if b.onStateChange != nil {
+		b.onStateChange(from, state)
+	}
+}
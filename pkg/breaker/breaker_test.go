@@ -0,0 +1,175 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOpenTimeout = 30 * time.Second
+
+// testBreaker returns a breaker on a clock tests move by hand, and the
+// state changes it makes
+func testBreaker(failureThreshold, probes int) (*Breaker, *time.Time, *[][2]State) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	var changes [][2]State
+	b := New(failureThreshold, testOpenTimeout, probes, func(from, to State) {
+		changes = append(changes, [2]State{from, to})
+	})
+	b.now = func() time.Time { return now }
+	return b, &now, &changes
+}
+
+func TestBreaker_Transitions(t *testing.T) {
+	// Steps are "ok", "fail" and "ignore" for a call with that outcome,
+	// "reject" for a call Allow refuses, and "wait" and "almost" to move the
+	// clock by the open timeout or just short of it
+	tests := []struct {
+		name             string
+		failureThreshold int
+		probes           int
+		steps            []string
+		want             []State
+	}{
+		{
+			name:             "opens after consecutive failures",
+			failureThreshold: 3,
+			probes:           1,
+			steps:            []string{"fail", "fail", "fail", "reject"},
+			want:             []State{Closed, Closed, Open, Open},
+		},
+		{
+			name:             "a success resets the failure count",
+			failureThreshold: 3,
+			probes:           1,
+			steps:            []string{"fail", "fail", "ok", "fail", "fail", "fail"},
+			want:             []State{Closed, Closed, Closed, Closed, Closed, Open},
+		},
+		{
+			name:             "ignored calls do not count",
+			failureThreshold: 2,
+			probes:           1,
+			steps:            []string{"fail", "ignore", "ignore", "fail"},
+			want:             []State{Closed, Closed, Closed, Open},
+		},
+		{
+			name:             "stays open until the timeout has passed",
+			failureThreshold: 1,
+			probes:           1,
+			steps:            []string{"fail", "almost", "reject", "wait"},
+			want:             []State{Open, Open, Open, HalfOpen},
+		},
+		{
+			name:             "closes once every probe succeeds",
+			failureThreshold: 1,
+			probes:           2,
+			steps:            []string{"fail", "wait", "ok", "ok", "fail"},
+			want:             []State{Open, HalfOpen, HalfOpen, Closed, Open},
+		},
+		{
+			name:             "a failed probe opens it again",
+			failureThreshold: 1,
+			probes:           2,
+			steps:            []string{"fail", "wait", "ok", "fail", "reject", "wait", "ok", "ok"},
+			want:             []State{Open, HalfOpen, HalfOpen, Open, Open, HalfOpen, HalfOpen, Closed},
+		},
+		{
+			name:             "an ignored probe frees its slot",
+			failureThreshold: 1,
+			probes:           1,
+			steps:            []string{"fail", "wait", "ignore", "ok"},
+			want:             []State{Open, HalfOpen, HalfOpen, Closed},
+		},
+		{
+			name:             "thresholds below one act as one",
+			failureThreshold: 0,
+			probes:           0,
+			steps:            []string{"fail", "wait", "ok"},
+			want:             []State{Open, HalfOpen, Closed},
+		},
+	}
+	outcomes := map[string]Outcome{"ok": Succeeded, "fail": Failed, "ignore": Ignored}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Len(t, tt.want, len(tt.steps))
+			b, now, _ := testBreaker(tt.failureThreshold, tt.probes)
+			for i, step := range tt.steps {
+				switch step {
+				case "wait":
+					*now = now.Add(testOpenTimeout)
+				case "almost":
+					*now = now.Add(testOpenTimeout - time.Second)
+				case "reject":
+					_, err := b.Allow()
+					assert.ErrorIs(t, err, ErrOpen, "step %d", i)
+				default:
+					done, err := b.Allow()
+					require.NoError(t, err, "step %d", i)
+					done(outcomes[step])
+				}
+				assert.Equal(t, tt.want[i], b.State(), "state after step %d (%s)", i, step)
+			}
+		})
+	}
+}
+
+func TestBreaker_LimitsProbes(t *testing.T) {
+	b, now, _ := testBreaker(1, 2)
+	done, err := b.Allow()
+	require.NoError(t, err)
+	done(Failed)
+	*now = now.Add(testOpenTimeout)
+
+	first, err := b.Allow()
+	require.NoError(t, err)
+	second, err := b.Allow()
+	require.NoError(t, err)
+	// Both probes are in flight
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen)
+
+	first(Succeeded)
+	_, err = b.Allow()
+	assert.ErrorIs(t, err, ErrOpen, "a succeeded probe still counts towards the limit")
+	second(Succeeded)
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestBreaker_DisregardsStaleOutcomes(t *testing.T) {
+	b, now, _ := testBreaker(1, 1)
+	slow, err := b.Allow()
+	require.NoError(t, err)
+	done, err := b.Allow()
+	require.NoError(t, err)
+	done(Failed)
+	require.Equal(t, Open, b.State())
+
+	*now = now.Add(testOpenTimeout)
+	probe, err := b.Allow()
+	require.NoError(t, err)
+	// A call let through before the breaker opened says nothing about now
+	slow(Succeeded)
+	assert.Equal(t, HalfOpen, b.State())
+
+	probe(Succeeded)
+	// done runs at most once
+	probe(Failed)
+	assert.Equal(t, Closed, b.State())
+}
+
+func TestBreaker_ReportsStateChanges(t *testing.T) {
+	b, now, changes := testBreaker(1, 1)
+	done, err := b.Allow()
+	require.NoError(t, err)
+	done(Failed)
+	*now = now.Add(testOpenTimeout)
+	done, err = b.Allow()
+	require.NoError(t, err)
+	done(Succeeded)
+
+	assert.Equal(t, [][2]State{{Closed, Open}, {Open, HalfOpen}, {HalfOpen, Closed}}, *changes)
+	assert.Equal(t, "half_open", HalfOpen.String())
+}
@@ -0,0 +1,116 @@
+package compression
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// Algorithm identifies how a payload was compressed. The value is stored next
+// to the compressed data so it can be decoded without any configuration.
+type Algorithm string
+
+const (
+	AlgorithmNone Algorithm = ""
+	AlgorithmGzip Algorithm = "gzip"
+	AlgorithmZlib Algorithm = "zlib"
+)
+
+// ParseAlgorithm converts a configuration value into an Algorithm
+func ParseAlgorithm(name string) (Algorithm, error) {
+	switch name {
+	case "", "none":
+		return AlgorithmNone, nil
+	case string(AlgorithmGzip):
+		return AlgorithmGzip, nil
+	case string(AlgorithmZlib):
+		return AlgorithmZlib, nil
+	default:
+		return AlgorithmNone, fmt.Errorf("unsupported compression algorithm %q", name)
+	}
+}
+
+// Compressor compresses payloads that are larger than a configured threshold
+type Compressor struct {
+	algorithm Algorithm
+	threshold int
+}
+
+// NewCompressor creates a compressor for the given algorithm. Payloads shorter
+// than threshold bytes are left untouched.
+func NewCompressor(algorithm string, threshold int) (*Compressor, error) {
+	algo, err := ParseAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if threshold < 0 {
+		return nil, fmt.Errorf("compression threshold must not be negative, got %d", threshold)
+	}
+	return &Compressor{
+		algorithm: algo,
+		threshold: threshold,
+	}, nil
+}
+
+// Algorithm returns the configured algorithm
+func (c *Compressor) Algorithm() Algorithm {
+	return c.algorithm
+}
+
+// Compress returns the compressed payload and the algorithm used. When the
+// payload is below the threshold, compression is disabled, or compressing does
+// not make the payload smaller, it returns nil and AlgorithmNone.
+func (c *Compressor) Compress(data []byte) ([]byte, Algorithm, error) {
+	if c == nil || c.algorithm == AlgorithmNone || len(data) < c.threshold {
+		return nil, AlgorithmNone, nil
+	}
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch c.algorithm {
+	case AlgorithmGzip:
+		w = gzip.NewWriter(&buf)
+	case AlgorithmZlib:
+		w = zlib.NewWriter(&buf)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, AlgorithmNone, fmt.Errorf("failed to compress payload: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, AlgorithmNone, fmt.Errorf("failed to compress payload: %w", err)
+	}
+
+	if buf.Len() >= len(data) {
+		return nil, AlgorithmNone, nil
+	}
+	return buf.Bytes(), c.algorithm, nil
+}
+
+// Decompress decodes a payload produced by Compress
+func Decompress(data []byte, algorithm Algorithm) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch algorithm {
+	case AlgorithmNone:
+		return data, nil
+	case AlgorithmGzip:
+		r, err = gzip.NewReader(bytes.NewReader(data))
+	case AlgorithmZlib:
+		r, err = zlib.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("unsupported compression algorithm %q", algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open compressed payload: %w", err)
+	}
+	defer r.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress payload: %w", err)
+	}
+	return out, nil
+}
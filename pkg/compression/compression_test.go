@@ -0,0 +1,104 @@
+package compression
+
+import (
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressor_RoundTrip(t *testing.T) {
+	long := []byte(strings.Repeat("Notes compress well when they repeat themselves. ", 40))
+	for _, algorithm := range []string{"gzip", "zlib"} {
+		t.Run(algorithm, func(t *testing.T) {
+			c, err := NewCompressor(algorithm, 64)
+			require.NoError(t, err)
+
+			data, got, err := c.Compress(long)
+			require.NoError(t, err)
+			assert.Equal(t, Algorithm(algorithm), got)
+			assert.Less(t, len(data), len(long))
+
+			out, err := Decompress(data, got)
+			require.NoError(t, err)
+			assert.Equal(t, long, out)
+		})
+	}
+}
+
+func TestCompressor_LeavesPayloadsAlone(t *testing.T) {
+	random := make([]byte, 4096)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+
+	gz, err := NewCompressor("gzip", 64)
+	require.NoError(t, err)
+	none, err := NewCompressor("none", 0)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		compressor *Compressor
+		payload    []byte
+	}{
+		{name: "below the threshold", compressor: gz, payload: []byte("short note")},
+		{name: "that do not shrink", compressor: gz, payload: random},
+		{name: "when disabled", compressor: none, payload: []byte(strings.Repeat("a", 1024))},
+		{name: "on a nil compressor", compressor: nil, payload: []byte(strings.Repeat("a", 1024))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, algorithm, err := tt.compressor.Compress(tt.payload)
+			require.NoError(t, err)
+			assert.Nil(t, data)
+			assert.Equal(t, AlgorithmNone, algorithm)
+
+			// Uncompressed payloads decode as they are
+			out, err := Decompress(tt.payload, algorithm)
+			require.NoError(t, err)
+			assert.Equal(t, tt.payload, out)
+		})
+	}
+}
+
+func TestDecompress_CorruptInput(t *testing.T) {
+	c, err := NewCompressor("gzip", 0)
+	require.NoError(t, err)
+	valid, _, err := c.Compress([]byte(strings.Repeat("checksummed body ", 20)))
+	require.NoError(t, err)
+	corrupt := append([]byte(nil), valid...)
+	// Flip a bit of the CRC-32 in the trailer
+	corrupt[len(corrupt)-5] ^= 1
+
+	tests := []struct {
+		name      string
+		data      []byte
+		algorithm Algorithm
+		wantErr   string
+	}{
+		{name: "not gzip", data: []byte("plain text"), algorithm: AlgorithmGzip, wantErr: "failed to open compressed payload"},
+		{name: "not zlib", data: []byte("plain text"), algorithm: AlgorithmZlib, wantErr: "failed to open compressed payload"},
+		{name: "truncated", data: valid[:len(valid)/2], algorithm: AlgorithmGzip, wantErr: "failed to decompress payload"},
+		{name: "bad checksum", data: corrupt, algorithm: AlgorithmGzip, wantErr: "failed to decompress payload"},
+		{name: "unknown algorithm", data: valid, algorithm: "brotli", wantErr: `unsupported compression algorithm "brotli"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decompress(tt.data, tt.algorithm)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNewCompressor(t *testing.T) {
+	_, err := NewCompressor("brotli", 0)
+	assert.ErrorContains(t, err, "unsupported compression algorithm")
+	_, err = NewCompressor("gzip", -1)
+	assert.ErrorContains(t, err, "must not be negative")
+
+	c, err := NewCompressor("", 0)
+	require.NoError(t, err)
+	assert.Equal(t, AlgorithmNone, c.Algorithm())
+}
@@ -0,0 +1,142 @@
+// Package crypto encrypts payloads at rest with envelope encryption: each
+// payload is sealed with its own random data key, and the data key is
+// stored next to it wrapped by a master key held by a KeySource. Rotating
+// the master key only needs new data keys to be wrapped with the new one.
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// dataKeySize is the size of data keys, for AES-256
+const dataKeySize = 32
+
+// maxCachedKeys bounds the unwrapped data keys an Envelope keeps, so reading
+// a note again does not call the key source
+const maxCachedKeys = 1024
+
+// KeySource wraps data keys with a master key and unwraps them again
+type KeySource interface {
+	// WrapKey encrypts dataKey with the current master key and returns the
+	// master key's ID along with the wrapped key
+	WrapKey(dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey decrypts a data key wrapped with the master key keyID
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Sealed is an encrypted payload with the wrapped data key needed to open it
+type Sealed struct {
+	KeyID      string
+	WrappedKey []byte
+	// Ciphertext is the AES-GCM nonce followed by the sealed payload
+	Ciphertext []byte
+}
+
+// Envelope seals and opens payloads with data keys wrapped by keys
+type Envelope struct {
+	keys KeySource
+
+	mu    sync.Mutex
+	cache map[string][]byte
+}
+
+func NewEnvelope(keys KeySource) *Envelope {
+	return &Envelope{
+		keys:  keys,
+		cache: make(map[string][]byte),
+	}
+}
+
+// Seal encrypts plaintext with a new data key. additionalData is
+// authenticated but not encrypted; the same value must be given to Open,
+// which binds the ciphertext to, for example, the row it is stored in.
+func (e *Envelope) Seal(plaintext, additionalData []byte) (Sealed, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Sealed{}, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	keyID, wrapped, err := e.keys.WrapKey(dataKey)
+	if err != nil {
+		return Sealed{}, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return Sealed{}, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	e.remember(keyID, wrapped, dataKey)
+
+	return Sealed{
+		KeyID:      keyID,
+		WrappedKey: wrapped,
+		Ciphertext: aead.Seal(nonce, nonce, plaintext, additionalData),
+	}, nil
+}
+
+// Open decrypts a payload produced by Seal
+func (e *Envelope) Open(sealed Sealed, additionalData []byte) ([]byte, error) {
+	dataKey, err := e.dataKey(sealed.KeyID, sealed.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed.Ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed.Ciphertext[:aead.NonceSize()], sealed.Ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+func (e *Envelope) dataKey(keyID string, wrapped []byte) ([]byte, error) {
+	e.mu.Lock()
+	dataKey, ok := e.cache[cacheKey(keyID, wrapped)]
+	e.mu.Unlock()
+	if ok {
+		return dataKey, nil
+	}
+
+	dataKey, err := e.keys.UnwrapKey(keyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	e.remember(keyID, wrapped, dataKey)
+	return dataKey, nil
+}
+
+// remember caches dataKey, starting over once the cache is full
+func (e *Envelope) remember(keyID string, wrapped, dataKey []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.cache) >= maxCachedKeys {
+		clear(e.cache)
+	}
+	e.cache[cacheKey(keyID, wrapped)] = dataKey
+}
+
+func cacheKey(keyID string, wrapped []byte) string {
+	return keyID + "\x00" + string(wrapped)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,149 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMasterKey(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, dataKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func newLocalEnvelope(t *testing.T, keys map[string]string, activeID string) *Envelope {
+	t.Helper()
+	source, err := NewLocalKeySource(keys, activeID)
+	require.NoError(t, err)
+	return NewEnvelope(source)
+}
+
+func TestEnvelope_SealOpen(t *testing.T) {
+	envelope := newLocalEnvelope(t, map[string]string{"k1": newMasterKey(t)}, "")
+	plaintext := []byte("meeting notes")
+
+	sealed, err := envelope.Seal(plaintext, []byte("note-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "k1", sealed.KeyID)
+	assert.NotContains(t, string(sealed.Ciphertext), string(plaintext))
+
+	opened, err := envelope.Open(sealed, []byte("note-1"))
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	// Every payload gets its own data key and nonce
+	again, err := envelope.Seal(plaintext, []byte("note-1"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed.WrappedKey, again.WrappedKey)
+	assert.NotEqual(t, sealed.Ciphertext, again.Ciphertext)
+}
+
+func TestEnvelope_OpenFails(t *testing.T) {
+	masterKey := newMasterKey(t)
+	envelope := newLocalEnvelope(t, map[string]string{"k1": masterKey}, "")
+	sealed, err := envelope.Seal([]byte("meeting notes"), []byte("note-1"))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		sealed         func() Sealed
+		additionalData []byte
+	}{
+		{
+			name:           "additional data mismatch",
+			sealed:         func() Sealed { return sealed },
+			additionalData: []byte("note-2"),
+		},
+		{
+			name: "tampered ciphertext",
+			sealed: func() Sealed {
+				tampered := sealed
+				tampered.Ciphertext = append([]byte(nil), sealed.Ciphertext...)
+				tampered.Ciphertext[len(tampered.Ciphertext)-1] ^= 1
+				return tampered
+			},
+			additionalData: []byte("note-1"),
+		},
+		{
+			name: "truncated ciphertext",
+			sealed: func() Sealed {
+				truncated := sealed
+				truncated.Ciphertext = sealed.Ciphertext[:4]
+				return truncated
+			},
+			additionalData: []byte("note-1"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A fresh envelope unwraps the data key rather than using its cache
+			fresh := newLocalEnvelope(t, map[string]string{"k1": masterKey}, "")
+			_, err := fresh.Open(tt.sealed(), tt.additionalData)
+			assert.Error(t, err)
+		})
+	}
+
+	t.Run("unknown master key", func(t *testing.T) {
+		other := newLocalEnvelope(t, map[string]string{"k2": newMasterKey(t)}, "")
+		_, err := other.Open(sealed, []byte("note-1"))
+		assert.ErrorContains(t, err, `unknown master key "k1"`)
+	})
+}
+
+func TestEnvelope_KeyRotation(t *testing.T) {
+	oldKey, newKey := newMasterKey(t), newMasterKey(t)
+	before := newLocalEnvelope(t, map[string]string{"k1": oldKey}, "")
+	sealedBefore, err := before.Seal([]byte("written before"), []byte("note-1"))
+	require.NoError(t, err)
+
+	// After rotating, new payloads use k2 and old ones still open with k1
+	after := newLocalEnvelope(t, map[string]string{"k1": oldKey, "k2": newKey}, "k2")
+	opened, err := after.Open(sealedBefore, []byte("note-1"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("written before"), opened)
+
+	sealedAfter, err := after.Seal([]byte("written after"), []byte("note-2"))
+	require.NoError(t, err)
+	assert.Equal(t, "k2", sealedAfter.KeyID)
+
+	// Once k1 is retired only payloads sealed with k2 open
+	retired := newLocalEnvelope(t, map[string]string{"k2": newKey}, "")
+	opened, err = retired.Open(sealedAfter, []byte("note-2"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("written after"), opened)
+	_, err = retired.Open(sealedBefore, []byte("note-1"))
+	assert.Error(t, err)
+}
+
+func TestNewLocalKeySource(t *testing.T) {
+	key := newMasterKey(t)
+	tests := []struct {
+		name     string
+		keys     map[string]string
+		activeID string
+		wantErr  string
+	}{
+		{name: "no keys", keys: nil, wantErr: "no master keys given"},
+		{name: "not base64", keys: map[string]string{"k1": "not base64!"}, wantErr: "not valid base64"},
+		{name: "wrong size", keys: map[string]string{"k1": base64.StdEncoding.EncodeToString([]byte("short"))}, wantErr: "must be 32 bytes"},
+		{name: "several keys without an active one", keys: map[string]string{"k1": key, "k2": key}, wantErr: "an active key is required"},
+		{name: "unknown active key", keys: map[string]string{"k1": key}, activeID: "k2", wantErr: `active key "k2"`},
+		{name: "single key is active", keys: map[string]string{"k1": key}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLocalKeySource(tt.keys, tt.activeID)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// LocalKeySource wraps data keys with AES-256 master keys held in memory,
+// typically loaded from configuration. Data keys are wrapped with the active
+// key; the others only unwrap the keys they wrapped before a rotation.
+type LocalKeySource struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// NewLocalKeySource parses keys, master key IDs mapped to base64-encoded
+// 32-byte keys. activeID picks the key new data keys are wrapped with; it
+// may be empty when there is exactly one key.
+func NewLocalKeySource(keys map[string]string, activeID string) (*LocalKeySource, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no master keys given")
+	}
+	s := &LocalKeySource{activeID: activeID, keys: make(map[string][]byte, len(keys))}
+	for id, encoded := range keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %q is not valid base64: %w", id, err)
+		}
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("master key %q must be %d bytes, got %d", id, dataKeySize, len(key))
+		}
+		s.keys[id] = key
+	}
+
+	if s.activeID == "" {
+		if len(s.keys) > 1 {
+			ids := make([]string, 0, len(s.keys))
+			for id := range s.keys {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			return nil, fmt.Errorf("an active key is required to choose between %v", ids)
+		}
+		for id := range s.keys {
+			s.activeID = id
+		}
+	}
+	if _, ok := s.keys[s.activeID]; !ok {
+		return nil, fmt.Errorf("active key %q is not one of the master keys", s.activeID)
+	}
+	return s, nil
+}
+
+func (s *LocalKeySource) WrapKey(dataKey []byte) (string, []byte, error) {
+	aead, err := newGCM(s.keys[s.activeID])
+	if err != nil {
+		return "", nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return s.activeID, aead.Seal(nonce, nonce, dataKey, []byte(s.activeID)), nil
+}
+
+func (s *LocalKeySource) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := s.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %q", keyID)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	return aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(keyID))
+}
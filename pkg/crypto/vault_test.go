@@ -0,0 +1,64 @@
+package crypto
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTransit mimics Vault's transit engine, "encrypting" by prefixing the
+// key version the way Vault tags its ciphertexts
+func fakeTransit() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			http.Error(w, `{"errors":["permission denied"]}`, http.StatusForbidden)
+			return
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/notes":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"ciphertext": "vault:v1:" + body["plaintext"]}})
+		case "/v1/transit/decrypt/notes":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"plaintext": body["ciphertext"][len("vault:v1:"):]}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestVaultKeySource(t *testing.T) {
+	server := fakeTransit()
+	defer server.Close()
+
+	t.Run("wraps data keys through the transit engine", func(t *testing.T) {
+		envelope := NewEnvelope(NewVaultKeySource(server.URL+"/", "token", "", "notes", time.Second))
+		sealed, err := envelope.Seal([]byte("meeting notes"), []byte("note-1"))
+		require.NoError(t, err)
+		assert.Equal(t, "notes", sealed.KeyID)
+		assert.Contains(t, string(sealed.WrappedKey), "vault:v1:")
+
+		fresh := NewEnvelope(NewVaultKeySource(server.URL, "token", "transit", "notes", time.Second))
+		opened, err := fresh.Open(sealed, []byte("note-1"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("meeting notes"), opened)
+	})
+
+	t.Run("reports Vault errors", func(t *testing.T) {
+		source := NewVaultKeySource(server.URL, "wrong", "", "notes", time.Second)
+		_, _, err := source.WrapKey([]byte("key"))
+		assert.ErrorContains(t, err, "vault returned 403")
+
+		_, err = source.UnwrapKey("notes", []byte("vault:v1:"+base64.StdEncoding.EncodeToString([]byte("key"))))
+		assert.ErrorContains(t, err, "vault returned 403")
+	})
+}
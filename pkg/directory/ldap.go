@@ -0,0 +1,184 @@
+// Package directory reads users and groups from LDAP and Active Directory
+// servers, built on github.com/go-ldap/ldap.
+package directory
+
+import (
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// pageSize is how many entries searches ask for at a time, below the 1000
+// entries Active Directory returns at most
+const pageSize = 500
+
+// ErrNotFound is returned for an entry that does not exist
+var ErrNotFound = errors.New("no such entry")
+
+// Config says where a directory is and how to sign in to it
+type Config struct {
+	// URL is the ldap:// or ldaps:// address of the server
+	URL string
+	// StartTLS upgrades ldap:// connections before binding
+	StartTLS     bool
+	BindDN       string
+	BindPassword string
+	Timeout      time.Duration
+}
+
+// Entry is a directory entry with the attributes that were asked for
+type Entry struct {
+	DN string
+	// Attributes holds the values of every attribute by its lowercased
+	// name, since attribute names are case insensitive
+	Attributes map[string][]string
+}
+
+// Values returns the values of the attribute name
+func (e *Entry) Values(name string) []string {
+	return e.Attributes[strings.ToLower(name)]
+}
+
+// Attribute returns the first value of the attribute name, or ""
+func (e *Entry) Attribute(name string) string {
+	if values := e.Values(name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ValidateURL checks that raw is an ldap:// or ldaps:// URL
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		return fmt.Errorf("%q is not an ldap:// or ldaps:// URL", raw)
+	}
+	return nil
+}
+
+// ValidateFilter checks that filter is a valid search filter
+func ValidateFilter(filter string) error {
+	_, err := ldap.CompileFilter(filter)
+	return err
+}
+
+// ValidateDN checks that dn is a valid distinguished name
+func ValidateDN(dn string) error {
+	_, err := ldap.ParseDN(dn)
+	return err
+}
+
+// Conn is a connection bound to a directory
+type Conn struct {
+	conn *ldap.Conn
+}
+
+// Dial connects to the directory of config and binds as its BindDN
+func Dial(config Config) (*Conn, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := ldap.DialURL(config.URL, ldap.DialWithDialer(&net.Dialer{Timeout: config.Timeout}))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(config.Timeout)
+	if config.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(&tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("StartTLS failed: %w", err)
+		}
+	}
+	if err := conn.Bind(config.BindDN, config.BindPassword); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("bind failed: %w", err)
+	}
+	return &Conn{conn: conn}, nil
+}
+
+// Search returns the entries under baseDN that match filter, with the
+// attributes named by attributes
+func (c *Conn) Search(baseDN, filter string, attributes []string) ([]Entry, error) {
+	request := ldap.NewSearchRequest(baseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases,
+		0, 0, false, filter, attributes, nil)
+	result, err := c.conn.SearchWithPaging(request, pageSize)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, len(result.Entries))
+	for i, entry := range result.Entries {
+		entries[i] = convert(entry)
+	}
+	return entries, nil
+}
+
+// Entry returns the entry with dn, with the attributes named by
+// attributes, or ErrNotFound
+func (c *Conn) Entry(dn string, attributes []string) (*Entry, error) {
+	request := ldap.NewSearchRequest(dn, ldap.ScopeBaseObject, ldap.NeverDerefAliases,
+		1, 0, false, "(objectClass=*)", attributes, nil)
+	result, err := c.conn.Search(request)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, dn)
+		}
+		return nil, err
+	}
+	if len(result.Entries) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, dn)
+	}
+	entry := convert(result.Entries[0])
+	return &entry, nil
+}
+
+// Close closes the connection
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// NormalizeDN returns dn in a form where equal DNs compare equal, or dn
+// lowercased when it does not parse
+func NormalizeDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(dn))
+	}
+	rdns := make([]string, len(parsed.RDNs))
+	for i, rdn := range parsed.RDNs {
+		attrs := make([]string, len(rdn.Attributes))
+		for j, attr := range rdn.Attributes {
+			attrs[j] = strings.ToLower(attr.Type) + "=" + strings.ToLower(attr.Value)
+		}
+		rdns[i] = strings.Join(attrs, "+")
+	}
+	return strings.Join(rdns, ",")
+}
+
+// convert copies entry, hex encoding binary values such as objectGUID
+func convert(entry *ldap.Entry) Entry {
+	result := Entry{DN: entry.DN, Attributes: make(map[string][]string, len(entry.Attributes))}
+	for _, attr := range entry.Attributes {
+		values := make([]string, len(attr.ByteValues))
+		for i, value := range attr.ByteValues {
+			if utf8.Valid(value) {
+				values[i] = string(value)
+			} else {
+				values[i] = hex.EncodeToString(value)
+			}
+		}
+		result.Attributes[strings.ToLower(attr.Name)] = values
+	}
+	return result
+}
@@ -0,0 +1,61 @@
+// Package events carries domain events between the API and other systems
+// over a message bus. Events are addressed by type, e.g. "user.created";
+// subscribing to All receives every event.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// All subscribes to every event type
+const All = ">"
+
+// Event is a domain event as carried on the bus
+type Event struct {
+	ID          uuid.UUID       `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID uuid.UUID       `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Handler processes an event delivered to a subscription
+type Handler func(ctx context.Context, event Event) error
+
+// Publisher sends events to the bus. A nil error means the bus accepted
+// the event.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Subscriber registers handlers for an event type, or All
+type Subscriber interface {
+	Subscribe(eventType string, handler Handler) (Subscription, error)
+}
+
+// Broadcaster registers handlers that every instance runs for each event,
+// for handlers acting on state local to the instance such as open
+// connections. Subscribe delivers each event to one instance only.
+type Broadcaster interface {
+	SubscribeBroadcast(eventType string, handler Handler) (Subscription, error)
+}
+
+// Subscription stops delivery to its handler when unsubscribed
+type Subscription interface {
+	Unsubscribe() error
+}
+
+// Bus publishes and subscribes. Close stops delivery and releases the
+// connection, waiting for handlers that are running. Ping reports whether
+// the bus can currently deliver events.
+type Bus interface {
+	Publisher
+	Subscriber
+	Broadcaster
+	Ping(ctx context.Context) error
+	Close() error
+}
@@ -0,0 +1,88 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"seta-training/pkg/logger"
+)
+
+// MemoryBus delivers events to subscribers in this process. Publish runs
+// the handlers before returning; handler errors are logged, not returned.
+// Events are lost if the process stops, so it suits development and a
+// single instance.
+type MemoryBus struct {
+	mu       sync.RWMutex
+	handlers map[*memorySubscription]struct{}
+	logger   logger.Logger
+}
+
+func NewMemoryBus(log logger.Logger) *MemoryBus {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &MemoryBus{
+		handlers: make(map[*memorySubscription]struct{}),
+		logger:   log,
+	}
+}
+
+type memorySubscription struct {
+	bus       *MemoryBus
+	eventType string
+	handler   Handler
+}
+
+func (s *memorySubscription) Unsubscribe() error {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	delete(s.bus.handlers, s)
+	return nil
+}
+
+func (b *MemoryBus) Subscribe(eventType string, handler Handler) (Subscription, error) {
+	sub := &memorySubscription{bus: b, eventType: eventType, handler: handler}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[sub] = struct{}{}
+	return sub, nil
+}
+
+// SubscribeBroadcast is Subscribe; this process is the only instance
+func (b *MemoryBus) SubscribeBroadcast(eventType string, handler Handler) (Subscription, error) {
+	return b.Subscribe(eventType, handler)
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	var matched []*memorySubscription
+	for sub := range b.handlers {
+		if sub.eventType == All || sub.eventType == event.Type {
+			matched = append(matched, sub)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, sub := range matched {
+		if err := sub.handler(ctx, event); err != nil {
+			b.logger.Error("Event handler failed",
+				logger.String("event_id", event.ID.String()),
+				logger.String("event_type", event.Type),
+				logger.Error(err),
+			)
+		}
+	}
+	return nil
+}
+
+// Ping always succeeds; the in-process bus has no connection to lose
+func (b *MemoryBus) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = make(map[*memorySubscription]struct{})
+	return nil
+}
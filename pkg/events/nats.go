@@ -0,0 +1,118 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"seta-training/pkg/logger"
+)
+
+// NATSBus publishes each event on the subject "<prefix>.<type>". Subscribers
+// join a queue group, so each event is handled by one instance of a service
+// rather than all of them. Core NATS does not store events; configure a
+// JetStream stream on "<prefix>.>" if consumers must not miss events while
+// they are down.
+type NATSBus struct {
+	conn   *nats.Conn
+	prefix string
+	queue  string
+	logger logger.Logger
+}
+
+// NewNATSBus connects to url. Subscriptions made through the bus share the
+// queue group named queue.
+func NewNATSBus(url, prefix, queue string, log logger.Logger) (*NATSBus, error) {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	conn, err := nats.Connect(url,
+		nats.Name(queue),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(2*time.Second),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Warn("Disconnected from NATS", logger.Error(err))
+			}
+		}),
+		nats.ReconnectHandler(func(c *nats.Conn) {
+			log.Info("Reconnected to NATS", logger.String("url", c.ConnectedUrl()))
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return &NATSBus{
+		conn:   conn,
+		prefix: prefix,
+		queue:  queue,
+		logger: log,
+	}, nil
+}
+
+func (b *NATSBus) subject(eventType string) string {
+	return b.prefix + "." + eventType
+}
+
+// Publish sends event and waits for the server to acknowledge it, so that
+// an error is returned if the event did not reach NATS
+func (b *NATSBus) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	if err := b.conn.Publish(b.subject(event.Type), data); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	if err := b.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush event to NATS: %w", err)
+	}
+	return nil
+}
+
+func (b *NATSBus) Subscribe(eventType string, handler Handler) (Subscription, error) {
+	return b.conn.QueueSubscribe(b.subject(eventType), b.queue, b.deliver(handler))
+}
+
+// SubscribeBroadcast subscribes outside the queue group, so every instance
+// receives each event
+func (b *NATSBus) SubscribeBroadcast(eventType string, handler Handler) (Subscription, error) {
+	return b.conn.Subscribe(b.subject(eventType), b.deliver(handler))
+}
+
+// deliver decodes messages into events for handler
+func (b *NATSBus) deliver(handler Handler) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		var event Event
+		if err := json.Unmarshal(msg.Data, &event); err != nil {
+			b.logger.Error("Discarding malformed event",
+				logger.String("subject", msg.Subject),
+				logger.Error(err),
+			)
+			return
+		}
+		if err := handler(context.Background(), event); err != nil {
+			b.logger.Error("Event handler failed",
+				logger.String("event_id", event.ID.String()),
+				logger.String("event_type", event.Type),
+				logger.Error(err),
+			)
+		}
+	}
+}
+
+// Ping round-trips to the NATS server
+func (b *NATSBus) Ping(ctx context.Context) error {
+	if !b.conn.IsConnected() {
+		return fmt.Errorf("not connected to NATS (%s)", b.conn.Status())
+	}
+	return b.conn.FlushWithContext(ctx)
+}
+
+// Close drains subscriptions, letting running handlers finish, then closes
+// the connection
+func (b *NATSBus) Close() error {
+	return b.conn.Drain()
+}
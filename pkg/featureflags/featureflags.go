@@ -0,0 +1,201 @@
+// Package featureflags decides whether optional features are on for a
+// caller, so they can be rolled out gradually. Flags come from sources such
+// as the config file and the database; a flag can be on for everyone, for
+// listed users and teams, or for a stable percentage of users.
+package featureflags
+
+import (
+	"context"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/pkg/auth"
+	"seta-training/pkg/logger"
+)
+
+// Flag is the rollout state of one feature
+type Flag struct {
+	Name string `json:"name"`
+	// Enabled switches the feature on for everyone
+	Enabled bool `json:"enabled"`
+	// Users and Teams switch it on for the listed users and the members and
+	// managers of the listed teams
+	Users []uuid.UUID `json:"users,omitempty"`
+	Teams []uuid.UUID `json:"teams,omitempty"`
+	// Percentage switches it on for that share of users, picked by a hash
+	// of the flag name and user ID so each user keeps their answer as the
+	// percentage grows
+	Percentage int `json:"percentage,omitempty"`
+}
+
+// Subject is who a flag is evaluated for
+type Subject struct {
+	UserID uuid.UUID
+	Teams  []uuid.UUID
+}
+
+// SubjectFromContext returns the caller of the request ctx belongs to. The
+// zero Subject, for anonymous callers, only gets flags enabled for everyone.
+func SubjectFromContext(ctx context.Context) Subject {
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return Subject{}
+	}
+	return SubjectOf(claims)
+}
+
+// SubjectOf returns the user and teams of claims
+func SubjectOf(claims *auth.Claims) Subject {
+	subject := Subject{UserID: claims.UserID}
+	for _, team := range claims.Teams {
+		subject.Teams = append(subject.Teams, team.ID)
+	}
+	return subject
+}
+
+// On reports whether the flag is on for subject
+func (f Flag) On(subject Subject) bool {
+	if f.Enabled {
+		return true
+	}
+	if subject.UserID == uuid.Nil {
+		return false
+	}
+	if slices.Contains(f.Users, subject.UserID) {
+		return true
+	}
+	for _, team := range subject.Teams {
+		if slices.Contains(f.Teams, team) {
+			return true
+		}
+	}
+	return f.Percentage > 0 && bucket(f.Name, subject.UserID) < f.Percentage
+}
+
+// bucket places a user in one of 100 buckets per flag
+func bucket(name string, userID uuid.UUID) int {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write(userID[:])
+	return int(h.Sum32() % 100)
+}
+
+// Source supplies flags by name
+type Source interface {
+	Flags(ctx context.Context) (map[string]Flag, error)
+}
+
+// SourceFunc adapts a function to a Source
+type SourceFunc func(ctx context.Context) (map[string]Flag, error)
+
+func (f SourceFunc) Flags(ctx context.Context) (map[string]Flag, error) {
+	return f(ctx)
+}
+
+// Static returns a Source of on/off flags, such as those of the config
+// file. current is called on every refresh, so it may follow reloads.
+func Static(current func() map[string]bool) Source {
+	return SourceFunc(func(context.Context) (map[string]Flag, error) {
+		switches := current()
+		flags := make(map[string]Flag, len(switches))
+		for name, enabled := range switches {
+			flags[name] = Flag{Name: name, Enabled: enabled}
+		}
+		return flags, nil
+	})
+}
+
+// Checker reports whether a feature is on for the caller of ctx
+type Checker interface {
+	Enabled(ctx context.Context, name string) bool
+}
+
+// Client merges its sources, later ones overriding earlier ones flag by
+// flag, and caches the result for a TTL so checks do not hit the database.
+// A source that fails keeps its flags from the last refresh. It is safe for
+// concurrent use.
+type Client struct {
+	sources []Source
+	ttl     time.Duration
+	logger  logger.Logger
+	now     func() time.Time
+
+	mu        sync.Mutex
+	flags     map[string]Flag
+	bySource  []map[string]Flag
+	refreshed time.Time
+}
+
+// NewClient creates a client over sources, refreshed at most every ttl
+func NewClient(ttl time.Duration, log logger.Logger, sources ...Source) *Client {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	return &Client{
+		sources:  sources,
+		ttl:      ttl,
+		logger:   log,
+		now:      time.Now,
+		bySource: make([]map[string]Flag, len(sources)),
+	}
+}
+
+// Enabled reports whether the named flag is on for the caller of ctx.
+// Unknown flags are off.
+func (c *Client) Enabled(ctx context.Context, name string) bool {
+	return c.EnabledFor(ctx, name, SubjectFromContext(ctx))
+}
+
+// EnabledFor reports whether the named flag is on for subject
+func (c *Client) EnabledFor(ctx context.Context, name string, subject Subject) bool {
+	flag, ok := c.current(ctx)[name]
+	return ok && flag.On(subject)
+}
+
+// Evaluate returns the names of the flags that are on for subject, sorted
+func (c *Client) Evaluate(ctx context.Context, subject Subject) []string {
+	on := []string{}
+	for name, flag := range c.current(ctx) {
+		if flag.On(subject) {
+			on = append(on, name)
+		}
+	}
+	sort.Strings(on)
+	return on
+}
+
+// Invalidate makes the next check refresh the flags, for use after they
+// were changed
+func (c *Client) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshed = time.Time{}
+}
+
+func (c *Client) current(ctx context.Context) map[string]Flag {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flags != nil && c.now().Sub(c.refreshed) < c.ttl {
+		return c.flags
+	}
+
+	merged := make(map[string]Flag)
+	for i, source := range c.sources {
+		flags, err := source.Flags(ctx)
+		if err != nil {
+			c.logger.Error("Failed to load feature flags, keeping the last ones", logger.Error(err))
+		} else {
+			c.bySource[i] = flags
+		}
+		for name, flag := range c.bySource[i] {
+			merged[name] = flag
+		}
+	}
+	c.flags = merged
+	c.refreshed = c.now()
+	return merged
+}
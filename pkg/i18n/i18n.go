@@ -0,0 +1,181 @@
+// Package i18n translates client-facing messages. A catalog maps an English
+// format string to its translation, so code keeps writing messages in
+// English and anything missing from a catalog falls back to the original.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is the language messages are written in. It needs no
+// catalog and is used when no requested language is supported.
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Bundle holds the catalogs of every supported language. It is built at
+// startup and only read afterwards.
+type Bundle struct {
+	catalogs map[string]map[string]string
+}
+
+// NewBundle loads the catalogs embedded under locales/, one <lang>.json
+// file per language
+func NewBundle() (*Bundle, error) {
+	b := &Bundle{catalogs: map[string]map[string]string{DefaultLanguage: {}}}
+	files, err := locales.ReadDir("locales")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message catalogs: %w", err)
+	}
+	for _, file := range files {
+		data, err := locales.ReadFile(path.Join("locales", file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message catalog %s: %w", file.Name(), err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("invalid message catalog %s: %w", file.Name(), err)
+		}
+		b.AddCatalog(strings.TrimSuffix(file.Name(), path.Ext(file.Name())), messages)
+	}
+	return b, nil
+}
+
+// AddCatalog adds messages to the catalog for lang, creating it if needed
+func (b *Bundle) AddCatalog(lang string, messages map[string]string) {
+	lang = normalize(lang)
+	catalog, ok := b.catalogs[lang]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		b.catalogs[lang] = catalog
+	}
+	for format, translated := range messages {
+		catalog[format] = translated
+	}
+}
+
+// Languages lists the supported languages, the default first
+func (b *Bundle) Languages() []string {
+	langs := make([]string, 0, len(b.catalogs))
+	for lang := range b.catalogs {
+		if lang != DefaultLanguage {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Strings(langs)
+	return append([]string{DefaultLanguage}, langs...)
+}
+
+// Match picks the supported language that best fits an Accept-Language
+// header such as "vi-VN,vi;q=0.9,en;q=0.8". Tags are tried by quality, a
+// regional tag also matches its base language, and the default language is
+// returned when nothing matches.
+func (b *Bundle) Match(acceptLanguage string) string {
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = normalize(tag)
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{tag, quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].quality > candidates[j].quality })
+
+	for _, c := range candidates {
+		if c.tag == "*" {
+			return DefaultLanguage
+		}
+		if _, ok := b.catalogs[c.tag]; ok {
+			return c.tag
+		}
+		base, _, _ := strings.Cut(c.tag, "-")
+		if _, ok := b.catalogs[base]; ok {
+			return base
+		}
+	}
+	return DefaultLanguage
+}
+
+// Localizer returns a localizer for lang, or for the default language when
+// lang is not supported. A nil Bundle returns a nil Localizer, which
+// leaves messages untranslated.
+func (b *Bundle) Localizer(lang string) *Localizer {
+	if b == nil {
+		return nil
+	}
+	lang = normalize(lang)
+	if _, ok := b.catalogs[lang]; !ok {
+		lang = DefaultLanguage
+	}
+	return &Localizer{lang: lang, messages: b.catalogs[lang]}
+}
+
+func normalize(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// Localizer translates messages into one language
+type Localizer struct {
+	lang     string
+	messages map[string]string
+}
+
+// Language is the language messages are translated into
+func (l *Localizer) Language() string {
+	if l == nil {
+		return DefaultLanguage
+	}
+	return l.lang
+}
+
+// T translates format and formats it with args. Formats missing from the
+// catalog are used as is, and a message with no args is returned without
+// formatting so literal % signs survive. A nil Localizer only formats.
+func (l *Localizer) T(format string, args ...interface{}) string {
+	if l != nil {
+		if translated, ok := l.messages[format]; ok {
+			format = translated
+		}
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+type localizerContextKey struct{}
+
+// NewContext returns a copy of ctx carrying l
+func NewContext(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerContextKey{}, l)
+}
+
+// FromContext returns the localizer stored in ctx by NewContext, or nil,
+// which translates nothing
+func FromContext(ctx context.Context) *Localizer {
+	l, _ := ctx.Value(localizerContextKey{}).(*Localizer)
+	return l
+}
@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// levels holds the base level and per-component overrides shared by a
+// logger and everything derived from it. The underlying logrus logger is
+// kept at the most verbose of them; filtering happens in enabled.
+type levels struct {
+	logger *logrus.Logger
+
+	mu         sync.RWMutex
+	base       logrus.Level
+	components map[string]logrus.Level
+}
+
+func newLevels(logger *logrus.Logger, base logrus.Level) *levels {
+	l := &levels{logger: logger, base: base}
+	l.apply()
+	return l
+}
+
+func (l *levels) setBase(level logrus.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.base = level
+	l.apply()
+}
+
+func (l *levels) setComponents(components map[string]logrus.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components = components
+	l.apply()
+}
+
+// apply sets the logrus level to the most verbose level in use. The caller
+// holds mu.
+func (l *levels) apply() {
+	verbose := l.base
+	for _, level := range l.components {
+		if level > verbose {
+			verbose = level
+		}
+	}
+	l.logger.SetLevel(verbose)
+}
+
+func (l *levels) enabled(component string, level logrus.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if override, ok := l.components[component]; ok && component != "" {
+		return level <= override
+	}
+	return level <= l.base
+}
+
+// sampler lets through the first initial occurrences of each message per
+// second and every thereafter-th occurrence after that
+type sampler struct {
+	mu         sync.Mutex
+	initial    int
+	thereafter int
+	counts     map[string]int
+	resetAt    time.Time
+}
+
+func (s *sampler) configure(initial, thereafter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.initial = initial
+	s.thereafter = thereafter
+	s.counts = nil
+}
+
+func (s *sampler) allow(msg string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.initial <= 0 {
+		return true
+	}
+
+	now := time.Now()
+	if s.counts == nil || !now.Before(s.resetAt) {
+		s.counts = make(map[string]int)
+		s.resetAt = now.Add(time.Second)
+	}
+	s.counts[msg]++
+	n := s.counts[msg]
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
@@ -2,8 +2,10 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -20,6 +22,23 @@ type Logger interface {
 	WithFields(fields ...Field) Logger
 }
 
+// LevelSetter is implemented by loggers whose level can be changed at
+// runtime. Loggers derived with WithFields or WithContext share the level.
+type LevelSetter interface {
+	SetLevel(level string) error
+}
+
+// ComponentLevelSetter is implemented by loggers that support per-component
+// levels, which override the base level for loggers from ForComponent
+type ComponentLevelSetter interface {
+	SetComponentLevels(levels map[string]string) error
+}
+
+// SamplingSetter is implemented by loggers that can sample debug lines
+type SamplingSetter interface {
+	SetSampling(initial, thereafter int)
+}
+
 // Field represents a key-value pair for structured logging
 type Field struct {
 	Key   string
@@ -53,8 +72,11 @@ func Any(key string, value interface{}) Field {
 
 // LogrusLogger is a logrus-based implementation of Logger
 type LogrusLogger struct {
-	logger *logrus.Logger
-	entry  *logrus.Entry
+	logger    *logrus.Logger
+	entry     *logrus.Entry
+	levels    *levels
+	sampler   *sampler
+	component string
 }
 
 // NewLogger creates a new structured logger
@@ -69,17 +91,18 @@ func NewLogger(level string, format string, output io.Writer) Logger {
 	}
 	
 	// Set level
+	var base logrus.Level
 	switch level {
 	case "debug":
-		logger.SetLevel(logrus.DebugLevel)
+		base = logrus.DebugLevel
 	case "info":
-		logger.SetLevel(logrus.InfoLevel)
+		base = logrus.InfoLevel
 	case "warn":
-		logger.SetLevel(logrus.WarnLevel)
+		base = logrus.WarnLevel
 	case "error":
-		logger.SetLevel(logrus.ErrorLevel)
+		base = logrus.ErrorLevel
 	default:
-		logger.SetLevel(logrus.InfoLevel)
+		base = logrus.InfoLevel
 	}
 	
 	// Set format
@@ -95,8 +118,69 @@ func NewLogger(level string, format string, output io.Writer) Logger {
 	}
 	
 	return &LogrusLogger{
-		logger: logger,
-		entry:  logrus.NewEntry(logger),
+		logger:  logger,
+		entry:   logrus.NewEntry(logger),
+		levels:  newLevels(logger, base),
+		sampler: &sampler{},
+	}
+}
+
+// SetLevel changes the minimum level logged by l and every logger derived
+// from it, except components with their own level
+func (l *LogrusLogger) SetLevel(level string) error {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.levels.setBase(parsed)
+	return nil
+}
+
+// SetComponentLevels replaces the per-component level overrides, e.g.
+// {"import": "warn"}. Components not listed use the base level.
+func (l *LogrusLogger) SetComponentLevels(components map[string]string) error {
+	parsed := make(map[string]logrus.Level, len(components))
+	for component, level := range components {
+		lvl, err := logrus.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("component %q: %w", component, err)
+		}
+		parsed[component] = lvl
+	}
+	l.levels.setComponents(parsed)
+	return nil
+}
+
+// SetSampling limits each distinct debug message to initial lines per
+// second, then logs every thereafter-th line. initial 0 disables sampling.
+func (l *LogrusLogger) SetSampling(initial, thereafter int) {
+	l.sampler.configure(initial, thereafter)
+}
+
+// log writes msg if level is enabled for l's component and, for debug
+// lines, the sampler lets it through
+func (l *LogrusLogger) log(level logrus.Level, msg string, fields []Field) {
+	if !l.levels.enabled(l.component, level) {
+		return
+	}
+	if level == logrus.DebugLevel && !l.sampler.allow(msg) {
+		return
+	}
+	if len(fields) > 0 {
+		l.entry.WithFields(l.fieldsToLogrus(fields)).Log(level, msg)
+	} else {
+		l.entry.Log(level, msg)
+	}
+}
+
+// derive returns a copy of l writing through entry
+func (l *LogrusLogger) derive(entry *logrus.Entry) *LogrusLogger {
+	return &LogrusLogger{
+		logger:    l.logger,
+		entry:     entry,
+		levels:    l.levels,
+		sampler:   l.sampler,
+		component: l.component,
 	}
 }
 
@@ -109,35 +193,19 @@ func (l *LogrusLogger) fieldsToLogrus(fields []Field) logrus.Fields {
 }
 
 func (l *LogrusLogger) Debug(msg string, fields ...Field) {
-	if len(fields) > 0 {
-		l.entry.WithFields(l.fieldsToLogrus(fields)).Debug(msg)
-	} else {
-		l.entry.Debug(msg)
-	}
+	l.log(logrus.DebugLevel, msg, fields)
 }
 
 func (l *LogrusLogger) Info(msg string, fields ...Field) {
-	if len(fields) > 0 {
-		l.entry.WithFields(l.fieldsToLogrus(fields)).Info(msg)
-	} else {
-		l.entry.Info(msg)
-	}
+	l.log(logrus.InfoLevel, msg, fields)
 }
 
 func (l *LogrusLogger) Warn(msg string, fields ...Field) {
-	if len(fields) > 0 {
-		l.entry.WithFields(l.fieldsToLogrus(fields)).Warn(msg)
-	} else {
-		l.entry.Warn(msg)
-	}
+	l.log(logrus.WarnLevel, msg, fields)
 }
 
 func (l *LogrusLogger) Error(msg string, fields ...Field) {
-	if len(fields) > 0 {
-		l.entry.WithFields(l.fieldsToLogrus(fields)).Error(msg)
-	} else {
-		l.entry.Error(msg)
-	}
+	l.log(logrus.ErrorLevel, msg, fields)
 }
 
 func (l *LogrusLogger) Fatal(msg string, fields ...Field) {
@@ -148,34 +216,144 @@ func (l *LogrusLogger) Fatal(msg string, fields ...Field) {
 	}
 }
 
+// WithContext returns l with the fields attached to ctx by ContextWithFields,
+// such as the request ID and user ID
 func (l *LogrusLogger) WithContext(ctx context.Context) Logger {
-	return &LogrusLogger{
-		logger: l.logger,
-		entry:  l.entry.WithContext(ctx),
+	entry := l.entry.WithContext(ctx)
+	if fields := FieldsFromContext(ctx); len(fields) > 0 {
+		entry = entry.WithFields(l.fieldsToLogrus(fields))
 	}
+	return l.derive(entry)
 }
 
 func (l *LogrusLogger) WithFields(fields ...Field) Logger {
-	return &LogrusLogger{
-		logger: l.logger,
-		entry:  l.entry.WithFields(l.fieldsToLogrus(fields)),
+	return l.derive(l.entry.WithFields(l.fieldsToLogrus(fields)))
+}
+
+// Components loggers can be tagged with; each can have its own level
+const (
+	ComponentHandler = "handler"
+	ComponentService = "service"
+	ComponentRepo    = "repo"
+	ComponentImport  = "import"
+)
+
+// componentLogger is implemented by the loggers that keep per-component levels
+type componentLogger interface {
+	forComponent(component string) Logger
+	componentName() string
+}
+
+// ForComponent returns l tagged with a component field. Its level is the
+// component's override, if one is set, instead of the base level.
+func ForComponent(l Logger, component string) Logger {
+	cl, ok := l.(componentLogger)
+	if !ok {
+		return l.WithFields(String("component", component))
 	}
+	return cl.forComponent(component)
 }
 
-// Global logger instance
-var globalLogger Logger
+// ComponentOf returns the component l was tagged with by ForComponent, if any
+func ComponentOf(l Logger) string {
+	if cl, ok := l.(componentLogger); ok {
+		return cl.componentName()
+	}
+	return ""
+}
+
+func (l *LogrusLogger) forComponent(component string) Logger {
+	derived := l.derive(l.entry.WithField("component", component))
+	derived.component = component
+	return derived
+}
+
+func (l *LogrusLogger) componentName() string {
+	return l.component
+}
+
+type contextKey struct{}
+
+type fieldsContextKey struct{}
+
+// ContextWithFields returns a copy of ctx carrying fields in addition to
+// those already attached. Loggers pick them up through WithContext.
+func ContextWithFields(ctx context.Context, fields ...Field) context.Context {
+	existing := FieldsFromContext(ctx)
+	merged := make([]Field, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	merged = append(merged, fields...)
+	return context.WithValue(ctx, fieldsContextKey{}, merged)
+}
+
+// FieldsFromContext returns the fields attached to ctx by ContextWithFields
+func FieldsFromContext(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsContextKey{}).([]Field)
+	return fields
+}
+
+// NewContext returns a copy of ctx carrying l, typically a request-scoped
+// logger with correlation fields already attached
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or fallback if there is
+// none. The context logger is tagged with fallback's component, so request
+// logging keeps the caller's component level.
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+			if component := ComponentOf(fallback); component != "" {
+				return ForComponent(l, component)
+			}
+			return l
+		}
+	}
+	return fallback
+}
+
+// NewNopLogger returns a logger that discards all output, for tests and embedded usage
+func NewNopLogger() Logger {
+	return NewLogger("error", "json", io.Discard)
+}
+
+// Global logger shim, kept for code that has not been handed a Logger explicitly
+var (
+	globalMu          sync.RWMutex
+	globalLogger      Logger
+	defaultLoggerOnce sync.Once
+	defaultLogger     Logger
+)
 
 // InitGlobalLogger initializes the global logger
 func InitGlobalLogger(level string, format string, output io.Writer) {
-	globalLogger = NewLogger(level, format, output)
+	SetGlobalLogger(NewLogger(level, format, output))
+}
+
+// SetGlobalLogger replaces the global logger instance
+func SetGlobalLogger(l Logger) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	globalLogger = l
 }
 
-// GetLogger returns the global logger instance
+// GetLogger returns the global logger instance, falling back to a lazily built default
 func GetLogger() Logger {
-	if globalLogger == nil {
-		globalLogger = NewLogger("info", "json", nil)
+	globalMu.RLock()
+	l := globalLogger
+	globalMu.RUnlock()
+	if l != nil {
+		return l
 	}
-	return globalLogger
+
+	defaultLoggerOnce.Do(func() {
+		defaultLogger = NewLogger("info", "json", nil)
+	})
+	return defaultLogger
 }
 
 // Convenience functions using global logger
@@ -0,0 +1,199 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the timestamp added to rotated file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// OutputConfig selects where logs are written. With a File set, logs go to
+// that file, rotated by size, and also to stdout when Stdout is true.
+type OutputConfig struct {
+	Stdout bool
+	File   string
+	// MaxSizeMB is the size at which the file is rotated
+	MaxSizeMB int
+	// MaxAgeDays and MaxBackups limit the rotated files kept; 0 keeps all
+	MaxAgeDays int
+	MaxBackups int
+}
+
+// OpenOutput opens the writer described by cfg. The returned closer must be
+// called on shutdown to flush and close the log file.
+func OpenOutput(cfg OutputConfig) (io.Writer, io.Closer, error) {
+	if cfg.File == "" {
+		return os.Stdout, io.NopCloser(nil), nil
+	}
+	file, err := NewRotatingFile(cfg.File, cfg.MaxSizeMB, cfg.MaxAgeDays, cfg.MaxBackups)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg.Stdout {
+		return io.MultiWriter(os.Stdout, file), file, nil
+	}
+	return file, file, nil
+}
+
+// RotatingFile is a log file that is renamed to <name>-<timestamp><ext> and
+// reopened once it grows past its size limit. Old backups are removed by age
+// and count after each rotation.
+type RotatingFile struct {
+	path       string
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotatingFile opens path for appending, creating its directory if needed
+func NewRotatingFile(path string, maxSizeMB, maxAgeDays, maxBackups int) (*RotatingFile, error) {
+	if maxSizeMB <= 0 {
+		return nil, errors.New("log file max size must be positive")
+	}
+	r := &RotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) << 20,
+		maxAge:     time.Duration(maxAgeDays) * 24 * time.Hour,
+		maxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first when p would take the file past its limit.
+// A single write larger than the limit is written to a fresh file.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate starts a new file now, e.g. in response to a signal
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotate()
+}
+
+func (r *RotatingFile) rotate() error {
+	if r.file != nil {
+		if err := r.file.Close(); err != nil {
+			return fmt.Errorf("failed to close log file: %w", err)
+		}
+		r.file = nil
+	}
+	if err := os.Rename(r.path, r.backupName(time.Now())); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	return r.removeOldBackups()
+}
+
+func (r *RotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(r.path)
+	return strings.TrimSuffix(r.path, ext) + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+// removeOldBackups deletes backups older than maxAge and all but the newest
+// maxBackups
+func (r *RotatingFile) removeOldBackups() error {
+	if r.maxAge <= 0 && r.maxBackups <= 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(r.path)
+	prefix := filepath.Base(strings.TrimSuffix(r.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(r.path))
+	if err != nil {
+		return fmt.Errorf("failed to list log backups: %w", err)
+	}
+
+	type backup struct {
+		path string
+		at   time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext)
+		at, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{path: filepath.Join(filepath.Dir(r.path), name), at: at})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].at.After(backups[j].at) })
+
+	var errs []error
+	cutoff := time.Now().Add(-r.maxAge)
+	for i, b := range backups {
+		expired := r.maxAge > 0 && b.at.Before(cutoff)
+		excess := r.maxBackups > 0 && i >= r.maxBackups
+		if expired || excess {
+			if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the current file
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
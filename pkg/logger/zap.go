@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Backends NewBackend can build a logger on
+const (
+	BackendLogrus = "logrus"
+	BackendZap    = "zap"
+)
+
+// NewBackend creates a logger on the named backend. zap allocates far less
+// per line than logrus and suits high-throughput deployments; anything else
+// gets the logrus logger from NewLogger.
+func NewBackend(backend, level, format string, output io.Writer) Logger {
+	if backend == BackendZap {
+		return NewZapLogger(level, format, output)
+	}
+	return NewLogger(level, format, output)
+}
+
+// ZapLogger is a zap-based implementation of Logger. It writes the same
+// time, level and msg keys as LogrusLogger, so log pipelines work with both.
+type ZapLogger struct {
+	logger    *zap.Logger
+	levels    *zapLevels
+	sampler   *sampler
+	component string
+}
+
+// NewZapLogger creates a new structured logger backed by zap
+func NewZapLogger(level string, format string, output io.Writer) Logger {
+	if output == nil {
+		output = os.Stdout
+	}
+
+	base, err := zapcore.ParseLevel(level)
+	if err != nil {
+		base = zapcore.InfoLevel
+	}
+
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		MessageKey:     "msg",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeTime:     zapcore.TimeEncoderOfLayout(time.RFC3339),
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeDuration: zapcore.StringDurationEncoder,
+	}
+	var encoder zapcore.Encoder
+	if format == "json" {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
+
+	// The core lets everything through; levels filters per component
+	core := zapcore.NewCore(encoder, zapcore.Lock(zapcore.AddSync(output)), zapcore.DebugLevel)
+	return &ZapLogger{
+		logger:  zap.New(core),
+		levels:  &zapLevels{base: base},
+		sampler: &sampler{},
+	}
+}
+
+// SetLevel changes the minimum level logged by l and every logger derived
+// from it, except components with their own level
+func (l *ZapLogger) SetLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	l.levels.setBase(parsed)
+	return nil
+}
+
+// SetComponentLevels replaces the per-component level overrides, e.g.
+// {"import": "warn"}. Components not listed use the base level.
+func (l *ZapLogger) SetComponentLevels(components map[string]string) error {
+	parsed := make(map[string]zapcore.Level, len(components))
+	for component, level := range components {
+		lvl, err := zapcore.ParseLevel(level)
+		if err != nil {
+			return fmt.Errorf("component %q: %w", component, err)
+		}
+		parsed[component] = lvl
+	}
+	l.levels.setComponents(parsed)
+	return nil
+}
+
+// SetSampling limits each distinct debug message to initial lines per
+// second, then logs every thereafter-th line. initial 0 disables sampling.
+func (l *ZapLogger) SetSampling(initial, thereafter int) {
+	l.sampler.configure(initial, thereafter)
+}
+
+// log writes msg if level is enabled for l's component and, for debug
+// lines, the sampler lets it through. Fields are only converted for lines
+// that are written.
+func (l *ZapLogger) log(level zapcore.Level, msg string, fields []Field) {
+	if !l.levels.enabled(l.component, level) {
+		return
+	}
+	if level == zapcore.DebugLevel && !l.sampler.allow(msg) {
+		return
+	}
+	if entry := l.logger.Check(level, msg); entry != nil {
+		entry.Write(fieldsToZap(fields)...)
+	}
+}
+
+// derive returns a copy of l writing through logger
+func (l *ZapLogger) derive(logger *zap.Logger) *ZapLogger {
+	return &ZapLogger{
+		logger:    logger,
+		levels:    l.levels,
+		sampler:   l.sampler,
+		component: l.component,
+	}
+}
+
+func fieldsToZap(fields []Field) []zap.Field {
+	zapFields := make([]zap.Field, len(fields))
+	for i, field := range fields {
+		zapFields[i] = zap.Any(field.Key, field.Value)
+	}
+	return zapFields
+}
+
+func (l *ZapLogger) Debug(msg string, fields ...Field) {
+	l.log(zapcore.DebugLevel, msg, fields)
+}
+
+func (l *ZapLogger) Info(msg string, fields ...Field) {
+	l.log(zapcore.InfoLevel, msg, fields)
+}
+
+func (l *ZapLogger) Warn(msg string, fields ...Field) {
+	l.log(zapcore.WarnLevel, msg, fields)
+}
+
+func (l *ZapLogger) Error(msg string, fields ...Field) {
+	l.log(zapcore.ErrorLevel, msg, fields)
+}
+
+func (l *ZapLogger) Fatal(msg string, fields ...Field) {
+	l.logger.Fatal(msg, fieldsToZap(fields)...)
+}
+
+// WithContext returns l with the fields attached to ctx by ContextWithFields,
+// such as the request ID and user ID
+func (l *ZapLogger) WithContext(ctx context.Context) Logger {
+	fields := FieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.derive(l.logger.With(fieldsToZap(fields)...))
+}
+
+func (l *ZapLogger) WithFields(fields ...Field) Logger {
+	return l.derive(l.logger.With(fieldsToZap(fields)...))
+}
+
+func (l *ZapLogger) forComponent(component string) Logger {
+	derived := l.derive(l.logger.With(zap.String("component", component)))
+	derived.component = component
+	return derived
+}
+
+func (l *ZapLogger) componentName() string {
+	return l.component
+}
+
+// zapLevels is levels for ZapLogger: the base level and per-component
+// overrides shared by a logger and everything derived from it
+type zapLevels struct {
+	mu         sync.RWMutex
+	base       zapcore.Level
+	components map[string]zapcore.Level
+}
+
+func (l *zapLevels) setBase(level zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.base = level
+}
+
+func (l *zapLevels) setComponents(components map[string]zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.components = components
+}
+
+func (l *zapLevels) enabled(component string, level zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if override, ok := l.components[component]; ok && component != "" {
+		return level >= override
+	}
+	return level >= l.base
+}
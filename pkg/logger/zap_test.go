@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeLines returns each JSON line written to buf
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var decoded map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded), line)
+		lines = append(lines, decoded)
+	}
+	return lines
+}
+
+func TestZapLogger_WritesLogrusKeys(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLogger("info", "json", &buf)
+	ctx := ContextWithFields(context.Background(), String("request_id", "req-1"))
+
+	l.WithContext(ctx).WithFields(Int("attempt", 2)).Info("Note saved", Duration("took", 1500*time.Millisecond))
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "info", lines[0]["level"])
+	assert.Equal(t, "Note saved", lines[0]["msg"])
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+	assert.EqualValues(t, 2, lines[0]["attempt"])
+	assert.Equal(t, "1.5s", lines[0]["took"])
+	_, err := time.Parse(time.RFC3339, lines[0]["time"].(string))
+	assert.NoError(t, err)
+}
+
+func TestZapLogger_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLogger("warn", "json", &buf)
+	require.NoError(t, l.(ComponentLevelSetter).SetComponentLevels(map[string]string{ComponentImport: "debug"}))
+	service := ForComponent(l, ComponentService)
+	imports := ForComponent(l, ComponentImport)
+
+	service.Info("dropped")
+	service.Warn("service warning")
+	imports.Debug("import row")
+	assert.Equal(t, ComponentImport, ComponentOf(imports))
+
+	// The base level is shared with the loggers derived before the change
+	require.NoError(t, l.(LevelSetter).SetLevel("info"))
+	service.Info("service info")
+
+	var msgs []string
+	for _, line := range decodeLines(t, &buf) {
+		msgs = append(msgs, line["msg"].(string))
+	}
+	assert.Equal(t, []string{"service warning", "import row", "service info"}, msgs)
+	assert.Error(t, l.(LevelSetter).SetLevel("loud"))
+}
+
+func TestZapLogger_SamplesDebugLines(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLogger("debug", "json", &buf)
+	l.(SamplingSetter).SetSampling(2, 3)
+
+	for i := 0; i < 8; i++ {
+		l.Debug("cache miss")
+	}
+	l.Info("not sampled")
+
+	// Lines 1 and 2, then every third after them: 5 and 8
+	assert.Len(t, decodeLines(t, &buf), 5)
+}
+
+func TestNewBackend(t *testing.T) {
+	assert.IsType(t, &ZapLogger{}, NewBackend(BackendZap, "info", "json", nil))
+	assert.IsType(t, &LogrusLogger{}, NewBackend(BackendLogrus, "info", "json", nil))
+}
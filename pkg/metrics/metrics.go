@@ -1,27 +1,68 @@
 package metrics
 
 import (
+	"database/sql"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"seta-training/pkg/breaker"
 )
 
 // Metrics holds all the prometheus metrics
 type Metrics struct {
-	RequestsTotal     *prometheus.CounterVec
-	RequestDuration   *prometheus.HistogramVec
-	ActiveConnections prometheus.Gauge
-	DatabaseQueries   *prometheus.CounterVec
-	ErrorsTotal       *prometheus.CounterVec
+	RequestsTotal         *prometheus.CounterVec
+	RequestDuration       *prometheus.HistogramVec
+	ActiveConnections     prometheus.Gauge
+	DatabaseQueries       *prometheus.CounterVec
+	DatabaseQueryDuration *prometheus.HistogramVec
+	DatabaseQueryErrors   *prometheus.CounterVec
+	ErrorsTotal           *prometheus.CounterVec
+	NoteCompressionRatio  *prometheus.HistogramVec
+	NoteBodyBytes         *prometheus.CounterVec
+	JobRunsTotal          *prometheus.CounterVec
+	JobDuration           *prometheus.HistogramVec
+	RecordsPurgedTotal    *prometheus.CounterVec
+	UsersCreatedTotal     *prometheus.CounterVec
+	LoginsTotal           *prometheus.CounterVec
+	NotesCreatedTotal     prometheus.Counter
+	SharesGrantedTotal    *prometheus.CounterVec
+	ImportJobsActive      prometheus.Gauge
+	ImportRowsTotal       *prometheus.CounterVec
+	ImportFailuresTotal   *prometheus.CounterVec
+	ImportWorkerDuration  *prometheus.HistogramVec
+	ImportQueueDepth      prometheus.Gauge
+	NotificationStreams   *prometheus.GaugeVec
+	BreakerState          *prometheus.GaugeVec
+	BreakerTransitions    *prometheus.CounterVec
+	BreakerRejections     *prometheus.CounterVec
+
+	registerer prometheus.Registerer
+	gatherer   prometheus.Gatherer
 }
 
-// NewMetrics creates a new metrics instance
+// NewMetrics creates a new metrics instance registered with the default prometheus registry.
+// It panics if called twice in the same process; use NewMetricsWithRegistry for isolated instances.
 func NewMetrics() *Metrics {
+	return NewMetricsWithRegistry(prometheus.DefaultRegisterer, prometheus.DefaultGatherer)
+}
+
+// NewIsolatedMetrics creates a metrics instance backed by its own private registry
+func NewIsolatedMetrics() *Metrics {
+	reg := prometheus.NewRegistry()
+	return NewMetricsWithRegistry(reg, reg)
+}
+
+// NewMetricsWithRegistry creates a new metrics instance registered with the given registry
+func NewMetricsWithRegistry(registerer prometheus.Registerer, gatherer prometheus.Gatherer) *Metrics {
 	m := &Metrics{
+		registerer: registerer,
+		gatherer:   gatherer,
 		RequestsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "http_requests_total",
@@ -50,6 +91,21 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"operation", "table"},
 		),
+		DatabaseQueryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "database_query_duration_seconds",
+				Help:    "Duration of database queries in seconds",
+				Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+			},
+			[]string{"operation", "table"},
+		),
+		DatabaseQueryErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "database_query_errors_total",
+				Help: "Total number of failed database queries",
+			},
+			[]string{"operation", "table"},
+		),
 		ErrorsTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "errors_total",
@@ -57,25 +113,177 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"type", "component"},
 		),
+		NoteCompressionRatio: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "note_compression_ratio",
+				Help:    "Ratio of stored to original size for compressed note bodies",
+				Buckets: prometheus.LinearBuckets(0.1, 0.1, 10),
+			},
+			[]string{"algorithm"},
+		),
+		NoteBodyBytes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "note_body_bytes_total",
+				Help: "Total note body bytes written, before and after compression",
+			},
+			[]string{"stage"},
+		),
+		JobRunsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "job_runs_total",
+				Help: "Scheduled job runs by outcome (success, failure, skipped, lock_error)",
+			},
+			[]string{"job", "status"},
+		),
+		JobDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "job_duration_seconds",
+				Help:    "Duration of scheduled job runs in seconds",
+				Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+			},
+			[]string{"job"},
+		),
+		RecordsPurgedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "records_purged_total",
+				Help: "Soft-deleted records permanently deleted by the retention job",
+			},
+			[]string{"table"},
+		),
+		UsersCreatedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "users_created_total",
+				Help: "Users created, by role",
+			},
+			[]string{"role"},
+		),
+		LoginsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "logins_total",
+				Help: "Login attempts by result (success, failure)",
+			},
+			[]string{"result"},
+		),
+		NotesCreatedTotal: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "notes_created_total",
+				Help: "Notes created",
+			},
+		),
+		SharesGrantedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "shares_granted_total",
+				Help: "Folder and note shares granted, by resource and access level",
+			},
+			[]string{"resource", "access"},
+		),
+		ImportJobsActive: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "import_jobs_active",
+				Help: "CSV user imports currently running",
+			},
+		),
+		ImportRowsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "import_rows_processed_total",
+				Help: "CSV import rows processed by result (success, failure, skipped)",
+			},
+			[]string{"result"},
+		),
+		ImportFailuresTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "import_failures_total",
+				Help: "CSV import rows that were skipped or failed, by reason",
+			},
+			[]string{"reason"},
+		),
+		ImportWorkerDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "import_worker_duration_seconds",
+				Help:    "Time each import worker spent processing one import",
+				Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+			},
+			[]string{"worker"},
+		),
+		ImportQueueDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "import_queue_depth",
+				Help: "Parsed CSV import rows waiting for a worker",
+			},
+		),
+		NotificationStreams: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "notification_streams",
+				Help: "Open notification streams by transport (websocket, sse)",
+			},
+			[]string{"transport"},
+		),
+		BreakerState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "circuit_breaker_state",
+				Help: "Circuit breaker state (0 closed, 1 half-open, 2 open)",
+			},
+			[]string{"breaker"},
+		),
+		BreakerTransitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "circuit_breaker_transitions_total",
+				Help: "Circuit breaker state changes, by the state entered",
+			},
+			[]string{"breaker", "state"},
+		),
+		BreakerRejections: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "circuit_breaker_rejections_total",
+				Help: "Calls rejected by an open circuit breaker",
+			},
+			[]string{"breaker"},
+		),
 	}
 
 	// Register metrics with prometheus
-	prometheus.MustRegister(
+	registerer.MustRegister(
 		m.RequestsTotal,
 		m.RequestDuration,
 		m.ActiveConnections,
 		m.DatabaseQueries,
+		m.DatabaseQueryDuration,
+		m.DatabaseQueryErrors,
 		m.ErrorsTotal,
+		m.NoteCompressionRatio,
+		m.NoteBodyBytes,
+		m.JobRunsTotal,
+		m.JobDuration,
+		m.RecordsPurgedTotal,
+		m.UsersCreatedTotal,
+		m.LoginsTotal,
+		m.NotesCreatedTotal,
+		m.SharesGrantedTotal,
+		m.ImportJobsActive,
+		m.ImportRowsTotal,
+		m.ImportFailuresTotal,
+		m.ImportWorkerDuration,
+		m.ImportQueueDepth,
+		m.NotificationStreams,
+		m.BreakerState,
+		m.BreakerTransitions,
+		m.BreakerRejections,
 	)
 
 	return m
 }
 
+// RegisterDBStats exports connection pool statistics of db (open, in use and
+// idle connections, waits, closures) as go_sql_* metrics labelled with name
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) error {
+	return m.registerer.Register(collectors.NewDBStatsCollector(db, name))
+}
+
 // PrometheusMiddleware creates a Gin middleware for prometheus metrics
 func (m *Metrics) PrometheusMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		
+
 		// Increment active connections
 		m.ActiveConnections.Inc()
 		defer m.ActiveConnections.Dec()
@@ -86,13 +294,13 @@ func (m *Metrics) PrometheusMiddleware() gin.HandlerFunc {
 		// Record metrics
 		duration := time.Since(start).Seconds()
 		statusCode := strconv.Itoa(c.Writer.Status())
-		
+
 		m.RequestsTotal.WithLabelValues(
 			c.Request.Method,
 			c.FullPath(),
 			statusCode,
 		).Inc()
-		
+
 		m.RequestDuration.WithLabelValues(
 			c.Request.Method,
 			c.FullPath(),
@@ -100,43 +308,160 @@ func (m *Metrics) PrometheusMiddleware() gin.HandlerFunc {
 	}
 }
 
-// RecordDatabaseQuery records a database query metric
-func (m *Metrics) RecordDatabaseQuery(operation, table string) {
-	m.DatabaseQueries.WithLabelValues(operation, table).Inc()
-}
-
 // RecordError records an error metric
 func (m *Metrics) RecordError(errorType, component string) {
 	m.ErrorsTotal.WithLabelValues(errorType, component).Inc()
 }
 
+// RecordNoteCompression records the outcome of compressing a note body
+func (m *Metrics) RecordNoteCompression(algorithm string, originalBytes, storedBytes int) {
+	m.NoteBodyBytes.WithLabelValues("original").Add(float64(originalBytes))
+	m.NoteBodyBytes.WithLabelValues("stored").Add(float64(storedBytes))
+	if originalBytes > 0 {
+		m.NoteCompressionRatio.WithLabelValues(algorithm).Observe(float64(storedBytes) / float64(originalBytes))
+	}
+}
+
+// RecordJobRun records the outcome of a scheduled job run. duration is only
+// observed for runs that actually executed.
+func (m *Metrics) RecordJobRun(job, status string, duration time.Duration) {
+	m.JobRunsTotal.WithLabelValues(job, status).Inc()
+	if status == "success" || status == "failure" {
+		m.JobDuration.WithLabelValues(job).Observe(duration.Seconds())
+	}
+}
+
+// RecordPurged records records permanently deleted from table
+func (m *Metrics) RecordPurged(table string, count int64) {
+	m.RecordsPurgedTotal.WithLabelValues(table).Add(float64(count))
+}
+
+// RecordUserCreated records a new user account
+func (m *Metrics) RecordUserCreated(role string) {
+	m.UsersCreatedTotal.WithLabelValues(role).Inc()
+}
+
+// RecordLogin records a login attempt
+func (m *Metrics) RecordLogin(success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	m.LoginsTotal.WithLabelValues(result).Inc()
+}
+
+// RecordNoteCreated records a new note
+func (m *Metrics) RecordNoteCreated() {
+	m.NotesCreatedTotal.Inc()
+}
+
+// RecordShareGranted records a folder or note shared with a user
+func (m *Metrics) RecordShareGranted(resource, access string) {
+	m.SharesGrantedTotal.WithLabelValues(resource, access).Inc()
+}
+
+// TrackImport counts an import as active until the returned func is called
+func (m *Metrics) TrackImport() func() {
+	m.ImportJobsActive.Inc()
+	return m.ImportJobsActive.Dec
+}
+
+// RecordImportRow records the outcome of one CSV import row. reason is
+// ignored for successful rows.
+func (m *Metrics) RecordImportRow(result, reason string) {
+	m.ImportRowsTotal.WithLabelValues(result).Inc()
+	if result != "success" {
+		m.ImportFailuresTotal.WithLabelValues(reason).Inc()
+	}
+}
+
+// RecordImportWorker records how long an import worker ran
+func (m *Metrics) RecordImportWorker(worker int, duration time.Duration) {
+	m.ImportWorkerDuration.WithLabelValues(strconv.Itoa(worker)).Observe(duration.Seconds())
+}
+
+// AddImportQueueDepth adjusts the number of import rows waiting for a worker
+func (m *Metrics) AddImportQueueDepth(delta int) {
+	m.ImportQueueDepth.Add(float64(delta))
+}
+
+// TrackNotificationStream counts a notification stream over transport as
+// open until the returned func is called
+func (m *Metrics) TrackNotificationStream(transport string) func() {
+	gauge := m.NotificationStreams.WithLabelValues(transport)
+	gauge.Inc()
+	return gauge.Dec
+}
+
+// SetBreakerState records the state of the named circuit breaker. changed
+// counts it as a transition rather than the initial state.
+func (m *Metrics) SetBreakerState(name string, state breaker.State, changed bool) {
+	m.BreakerState.WithLabelValues(name).Set(float64(state))
+	if changed {
+		m.BreakerTransitions.WithLabelValues(name, state.String()).Inc()
+	}
+}
+
+// RecordBreakerRejection records a call rejected by the named circuit breaker
+func (m *Metrics) RecordBreakerRejection(name string) {
+	m.BreakerRejections.WithLabelValues(name).Inc()
+}
+
 // Handler returns the prometheus metrics handler
 func (m *Metrics) Handler() http.Handler {
-	return promhttp.Handler()
+	if m.gatherer == nil || m.gatherer == prometheus.DefaultGatherer {
+		return promhttp.Handler()
+	}
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{})
 }
 
-// Global metrics instance
-var globalMetrics *Metrics
+// Global metrics shim, kept for code that has not been handed a *Metrics explicitly
+var (
+	globalMu           sync.RWMutex
+	globalMetrics      *Metrics
+	defaultMetricsOnce sync.Once
+	defaultMetrics     *Metrics
+)
+
+// Default returns the process-wide instance registered with the default prometheus registry.
+// It is built at most once, so it is safe to call from parallel tests.
+func Default() *Metrics {
+	defaultMetricsOnce.Do(func() {
+		defaultMetrics = NewMetrics()
+	})
+	return defaultMetrics
+}
 
 // InitGlobalMetrics initializes the global metrics instance
 func InitGlobalMetrics() *Metrics {
-	globalMetrics = NewMetrics()
-	return globalMetrics
+	m := Default()
+	SetGlobalMetrics(m)
+	return m
+}
+
+// SetGlobalMetrics replaces the global metrics instance
+func SetGlobalMetrics(m *Metrics) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+	globalMetrics = m
 }
 
 // GetMetrics returns the global metrics instance
 func GetMetrics() *Metrics {
-	if globalMetrics == nil {
-		globalMetrics = NewMetrics()
+	globalMu.RLock()
+	m := globalMetrics
+	globalMu.RUnlock()
+	if m != nil {
+		return m
 	}
-	return globalMetrics
+	return Default()
 }
 
 // Convenience functions using global metrics
-func RecordDatabaseQuery(operation, table string) {
-	GetMetrics().RecordDatabaseQuery(operation, table)
-}
-
 func RecordError(errorType, component string) {
 	GetMetrics().RecordError(errorType, component)
 }
+
+func RecordNoteCompression(algorithm string, originalBytes, storedBytes int) {
+	GetMetrics().RecordNoteCompression(algorithm, originalBytes, storedBytes)
+}
@@ -0,0 +1,85 @@
+// Package pagination implements keyset (cursor) pagination. Listings are
+// ordered by creation time then ID, and each page continues after the last
+// row of the previous one, so a page costs the same however deep it is and
+// rows inserted meanwhile never shift pages.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultLimit is the page size used when none is requested
+	DefaultLimit = 50
+	// MaxLimit is the largest page size a client may request
+	MaxLimit = 200
+)
+
+// ErrInvalidCursor is returned for a cursor that was not produced by Encode
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is the position of a row in a listing
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+}
+
+// Encode returns the opaque, URL-safe form of c handed to clients
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode parses a cursor returned by Encode
+func Decode(s string) (Cursor, error) {
+	var c Cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.ID == uuid.Nil || c.CreatedAt.IsZero() {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Params selects a page: up to Limit rows after the row at After, or from
+// the start when After is nil
+type Params struct {
+	Limit int
+	After *Cursor
+}
+
+// Size is the page size, DefaultLimit when Limit is not positive and at
+// most MaxLimit
+func (p Params) Size() int {
+	if p.Limit <= 0 {
+		return DefaultLimit
+	}
+	return min(p.Limit, MaxLimit)
+}
+
+// Page is one page of a listing. Next is the cursor of the following page
+// and is empty on the last one.
+type Page[T any] struct {
+	Items []T
+	Next  string
+}
+
+// NewPage builds a page from rows fetched with a limit of size+1; the extra
+// row only tells that another page follows. cursorOf gives a row's position.
+func NewPage[T any](rows []T, size int, cursorOf func(T) Cursor) Page[T] {
+	if rows == nil {
+		rows = []T{}
+	}
+	if len(rows) <= size {
+		return Page[T]{Items: rows}
+	}
+	rows = rows[:size]
+	return Page[T]{Items: rows, Next: cursorOf(rows[size-1]).Encode()}
+}
@@ -0,0 +1,347 @@
+// Package scim holds the resources and messages of SCIM 2.0 (RFC 7643 and
+// RFC 7644) that identity providers such as Okta and Azure AD use to
+// provision users and groups: filters of the form `attribute eq "value"`
+// and PATCH operations on the attributes this server stores.
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema URIs and the media type of SCIM messages
+const (
+	UserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	GroupSchema        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	PatchOpSchema      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ContentType        = "application/scim+json"
+)
+
+// MaxResults is the most resources a list returns at once
+const MaxResults = 100
+
+// Meta describes a resource
+type Meta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// Email is one of the email addresses of a user
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// User is a user resource. Active is nil when a request leaves it out.
+type User struct {
+	Schemas    []string `json:"schemas"`
+	ID         string   `json:"id,omitempty"`
+	ExternalID string   `json:"externalId,omitempty"`
+	UserName   string   `json:"userName"`
+	Emails     []Email  `json:"emails,omitempty"`
+	Active     *bool    `json:"active,omitempty"`
+	Meta       *Meta    `json:"meta,omitempty"`
+}
+
+// PrimaryEmail returns the address of the primary email, or of the first
+// one when none is marked primary
+func (u *User) PrimaryEmail() string {
+	for _, email := range u.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// Member is a user in a group
+type Member struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+// Group is a group resource
+type Group struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id,omitempty"`
+	DisplayName string   `json:"displayName"`
+	Members     []Member `json:"members"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// ListResponse is a page of the resources matching a query.
+// StartIndex is 1-based.
+type ListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// PatchRequest changes a resource one operation at a time
+type PatchRequest struct {
+	Schemas    []string    `json:"schemas"`
+	Operations []Operation `json:"Operations"`
+}
+
+// Operation adds, replaces or removes the attribute at Path, or with no
+// Path, the attributes in Value
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Error is the body of error responses. Status is the HTTP status code.
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// NewError returns the error response for status
+func NewError(status int, scimType, detail string) Error {
+	return Error{
+		Schemas:  []string{ErrorSchema},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   detail,
+	}
+}
+
+// Filter matches the resources whose Attribute equals Value
+type Filter struct {
+	Attribute string
+	Value     string
+}
+
+// Is reports whether the filter is on attribute, which is case insensitive
+func (f *Filter) Is(attribute string) bool {
+	return strings.EqualFold(f.Attribute, attribute)
+}
+
+var filterPattern = regexp.MustCompile(`^\s*([A-Za-z][\w.:-]*)\s+(?i:eq)\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// ParseFilter parses a filter of the form `attribute eq "value"`, the only
+// form identity providers send when looking for existing resources
+func ParseFilter(filter string) (*Filter, error) {
+	match := filterPattern.FindStringSubmatch(filter)
+	if match == nil {
+		return nil, fmt.Errorf("unsupported filter %q", filter)
+	}
+	var value string
+	if err := json.Unmarshal([]byte(match[2]), &value); err != nil {
+		return nil, fmt.Errorf("invalid filter value: %w", err)
+	}
+	return &Filter{Attribute: attributeName(match[1]), Value: value}, nil
+}
+
+// attributeName strips the core schema URN from a fully qualified
+// attribute name
+func attributeName(path string) string {
+	for _, schema := range []string{UserSchema, GroupSchema} {
+		if len(path) > len(schema) && strings.EqualFold(path[:len(schema)+1], schema+":") {
+			return path[len(schema)+1:]
+		}
+	}
+	return path
+}
+
+// Apply applies the operations to the user in order. Attributes this
+// server does not store, such as names, are ignored.
+func (u *User) Apply(ops []Operation) error {
+	return apply(ops, u.set, u.remove)
+}
+
+func (u *User) set(op, path string, value json.RawMessage) error {
+	switch {
+	case strings.EqualFold(path, "active"):
+		active, err := parseBool(value)
+		if err != nil {
+			return fmt.Errorf("active: %w", err)
+		}
+		u.Active = &active
+	case strings.EqualFold(path, "userName"):
+		return decode("userName", value, &u.UserName)
+	case strings.EqualFold(path, "externalId"):
+		return decode("externalId", value, &u.ExternalID)
+	case strings.EqualFold(path, "emails"):
+		var emails []Email
+		if err := decode("emails", value, &emails); err != nil {
+			return err
+		}
+		if op == "add" {
+			emails = append(emails, u.Emails...)
+		}
+		u.Emails = emails
+	case len(path) > len("emails") && strings.EqualFold(path[:len("emails")+1], "emails[") &&
+		strings.HasSuffix(strings.ToLower(path), ".value"):
+		// Users have one email, whichever of theirs is addressed
+		var email string
+		if err := decode("emails", value, &email); err != nil {
+			return err
+		}
+		u.Emails = []Email{{Value: email, Type: "work", Primary: true}}
+	}
+	return nil
+}
+
+func (u *User) remove(path string, value json.RawMessage) error {
+	switch {
+	case strings.EqualFold(path, "externalId"):
+		u.ExternalID = ""
+	case strings.EqualFold(path, "emails"):
+		u.Emails = nil
+	}
+	return nil
+}
+
+// Apply applies the operations to the group in order
+func (g *Group) Apply(ops []Operation) error {
+	return apply(ops, g.set, g.remove)
+}
+
+func (g *Group) set(op, path string, value json.RawMessage) error {
+	switch {
+	case strings.EqualFold(path, "displayName"):
+		return decode("displayName", value, &g.DisplayName)
+	case strings.EqualFold(path, "members"):
+		var members []Member
+		if err := decode("members", value, &members); err != nil {
+			return err
+		}
+		if op == "replace" {
+			g.Members = nil
+		}
+		for _, member := range members {
+			if !g.hasMember(member.Value) {
+				g.Members = append(g.Members, member)
+			}
+		}
+	}
+	return nil
+}
+
+var memberPathPattern = regexp.MustCompile(`^(?i:members)\[\s*(?i:value)\s+(?i:eq)\s+("(?:[^"\\]|\\.)*")\s*\]$`)
+
+func (g *Group) remove(path string, value json.RawMessage) error {
+	if strings.EqualFold(path, "members") {
+		if len(value) == 0 || string(value) == "null" {
+			g.Members = nil
+			return nil
+		}
+		var members []Member
+		if err := decode("members", value, &members); err != nil {
+			return err
+		}
+		for _, member := range members {
+			g.removeMember(member.Value)
+		}
+		return nil
+	}
+	if match := memberPathPattern.FindStringSubmatch(path); match != nil {
+		var id string
+		if err := json.Unmarshal([]byte(match[1]), &id); err != nil {
+			return fmt.Errorf("invalid path %q", path)
+		}
+		g.removeMember(id)
+	}
+	return nil
+}
+
+func (g *Group) hasMember(id string) bool {
+	for _, member := range g.Members {
+		if strings.EqualFold(member.Value, id) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Group) removeMember(id string) {
+	members := g.Members[:0]
+	for _, member := range g.Members {
+		if !strings.EqualFold(member.Value, id) {
+			members = append(members, member)
+		}
+	}
+	g.Members = members
+}
+
+// apply runs each operation through set or remove. Operations without a
+// path set every attribute of their value, which must be an object.
+func apply(ops []Operation, set func(op, path string, value json.RawMessage) error, remove func(path string, value json.RawMessage) error) error {
+	for _, operation := range ops {
+		// Azure AD capitalizes operations
+		op := strings.ToLower(operation.Op)
+		path := attributeName(operation.Path)
+		switch op {
+		case "add", "replace":
+			if path != "" {
+				if err := set(op, path, operation.Value); err != nil {
+					return err
+				}
+				continue
+			}
+			var values map[string]json.RawMessage
+			if err := json.Unmarshal(operation.Value, &values); err != nil {
+				return errors.New("value must be an object when there is no path")
+			}
+			for name, value := range values {
+				if err := set(op, attributeName(name), value); err != nil {
+					return err
+				}
+			}
+		case "remove":
+			if path == "" {
+				return errors.New("remove needs a path")
+			}
+			if err := remove(path, operation.Value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported op %q", operation.Op)
+		}
+	}
+	return nil
+}
+
+// decode unmarshals the value of attribute into v
+func decode(attribute string, value json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(value, v); err != nil {
+		return fmt.Errorf("invalid %s", attribute)
+	}
+	return nil
+}
+
+// parseBool reads a JSON boolean, or a string such as "False" as Azure AD
+// sends them
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, errors.New("must be a boolean")
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, errors.New("must be a boolean")
+	}
+	return b, nil
+}
@@ -0,0 +1,175 @@
+// Package sso is the service provider side of SAML 2.0 single sign-on, built
+// on github.com/crewjam/saml. Every organization gets a service provider of
+// its own under <base URL>/sso/saml/<slug>, trusting its identity provider.
+package sso
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+// ErrInvalidResponse is returned for a SAML response that fails validation
+var ErrInvalidResponse = errors.New("invalid SAML response")
+
+// Config is shared by the service providers of all organizations. Key and
+// Certificate are optional; with them, authentication requests are signed
+// and encrypted assertions accepted.
+type Config struct {
+	BaseURL     url.URL
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+}
+
+// LoadKeyPair reads a PEM encoded certificate and its private key
+func LoadKeyPair(certFile, keyFile string) (*x509.Certificate, crypto.Signer, error) {
+	pair, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	key, ok := pair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported key type %T", pair.PrivateKey)
+	}
+	return cert, key, nil
+}
+
+// ParseMetadata parses identity provider metadata, an EntityDescriptor or
+// an EntitiesDescriptor containing one. The provider must offer the
+// HTTP-Redirect binding, which authentication requests are sent with.
+func ParseMetadata(data string) (*saml.EntityDescriptor, error) {
+	idp, err := samlsp.ParseMetadata([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	for _, descriptor := range idp.IDPSSODescriptors {
+		for _, service := range descriptor.SingleSignOnServices {
+			if service.Binding == saml.HTTPRedirectBinding {
+				return idp, nil
+			}
+		}
+	}
+	return nil, errors.New("no SingleSignOnService with the HTTP-Redirect binding")
+}
+
+// Assertion is what a validated SAML response says about the user
+type Assertion struct {
+	NameID string
+	// Attributes holds the values of every attribute by its name and, when
+	// it has one, its friendly name
+	Attributes map[string][]string
+}
+
+// Attribute returns the first value of the attribute name, or ""
+func (a *Assertion) Attribute(name string) string {
+	if values := a.Attributes[name]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// ServiceProvider is the service provider of one organization
+type ServiceProvider struct {
+	sp *saml.ServiceProvider
+}
+
+// ServiceProvider returns the service provider of the organization with
+// slug, trusting idp
+func (c *Config) ServiceProvider(slug string, idp *saml.EntityDescriptor) *ServiceProvider {
+	base := c.BaseURL.JoinPath("sso", "saml", slug)
+	sp := &saml.ServiceProvider{
+		Key:               c.Key,
+		Certificate:       c.Certificate,
+		MetadataURL:       *base.JoinPath("metadata"),
+		AcsURL:            *base.JoinPath("acs"),
+		IDPMetadata:       idp,
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+	}
+	switch c.Key.(type) {
+	case *rsa.PrivateKey:
+		sp.SignatureMethod = dsig.RSASHA256SignatureMethod
+	case *ecdsa.PrivateKey:
+		sp.SignatureMethod = dsig.ECDSASHA256SignatureMethod
+	}
+	return &ServiceProvider{sp: sp}
+}
+
+// EntityID identifies the service provider to identity providers
+func (p *ServiceProvider) EntityID() string {
+	return p.sp.MetadataURL.String()
+}
+
+// Metadata returns the service provider's metadata XML
+func (p *ServiceProvider) Metadata() ([]byte, error) {
+	data, err := xml.MarshalIndent(p.sp.Metadata(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// AuthnRequest returns the identity provider URL that asks the user to sign
+// in, and the ID of the request the response must answer. relayState comes
+// back with the response.
+func (p *ServiceProvider) AuthnRequest(relayState string) (*url.URL, string, error) {
+	idpURL := p.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding)
+	req, err := p.sp.MakeAuthenticationRequest(idpURL, saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return nil, "", err
+	}
+	redirect, err := req.Redirect(url.QueryEscape(relayState), p.sp)
+	if err != nil {
+		return nil, "", err
+	}
+	return redirect, req.ID, nil
+}
+
+// ParseResponse validates the base64 encoded SAMLResponse posted to the
+// assertion consumer service, which must answer one of requestIDs, and
+// returns its assertion. Errors other than ErrInvalidResponse mean the
+// response could not be checked.
+func (p *ServiceProvider) ParseResponse(samlResponse string, requestIDs []string) (*Assertion, error) {
+	data, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	assertion, err := p.sp.ParseXMLResponse(data, requestIDs, p.sp.AcsURL)
+	if err != nil {
+		var invalid *saml.InvalidResponseError
+		if errors.As(err, &invalid) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidResponse, invalid.PrivateErr)
+		}
+		return nil, err
+	}
+
+	result := &Assertion{Attributes: map[string][]string{}}
+	if assertion.Subject != nil && assertion.Subject.NameID != nil {
+		result.NameID = assertion.Subject.NameID.Value
+	}
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			for _, value := range attr.Values {
+				result.Attributes[attr.Name] = append(result.Attributes[attr.Name], value.Value)
+				if attr.FriendlyName != "" && attr.FriendlyName != attr.Name {
+					result.Attributes[attr.FriendlyName] = append(result.Attributes[attr.FriendlyName], value.Value)
+				}
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,206 @@
+// Package upload checks uploaded files by their content rather than the name
+// and Content-Type the client sent, which are trivially forged.
+package upload
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"seta-training/internal/apperrors"
+)
+
+// Content types a Validator can accept. Text formats such as CSV have no
+// magic bytes, so TypeCSV accepts any file that is valid UTF-8 text.
+const (
+	TypeCSV  = "text/csv"
+	TypeText = "text/plain"
+	TypeZIP  = "application/zip"
+	TypeGzip = "application/x-gzip"
+	TypePDF  = "application/pdf"
+	TypePNG  = "image/png"
+	TypeJPEG = "image/jpeg"
+	TypeGIF  = "image/gif"
+	TypeWebP = "image/webp"
+)
+
+// Limits on what an archive may expand to, so that a small upload cannot
+// exhaust memory or disk once it is unpacked
+const (
+	MaxExpandedSize     = 100 << 20
+	MaxCompressionRatio = 100
+	MaxArchiveEntries   = 1000
+)
+
+// sniffLen is how much of a file http.DetectContentType looks at
+const sniffLen = 512
+
+var (
+	// ErrType reports a file whose content is not of an accepted type
+	ErrType = errors.New("file content does not match an accepted type")
+	// ErrArchiveBomb reports an archive that expands beyond the limits
+	ErrArchiveBomb = errors.New("archive expands beyond the allowed size")
+)
+
+// Validator accepts files of the given types up to a maximum size
+type Validator struct {
+	maxSize int64
+	types   []string
+}
+
+// NewValidator creates a validator for files of at most maxSize bytes whose
+// content is one of types
+func NewValidator(maxSize int64, types ...string) *Validator {
+	return &Validator{
+		maxSize: maxSize,
+		types:   types,
+	}
+}
+
+// Validate reads f, which the client declared to be size bytes, and returns
+// its detected content type. f is rewound afterwards so the caller can read
+// it from the start. Oversized files are reported as too large, and files of
+// another type, including binaries disguised as text and archives that
+// expand beyond the limits, as invalid.
+func (v *Validator) Validate(f io.ReadSeeker, size int64) (string, error) {
+	if size > v.maxSize {
+		return "", apperrors.PayloadTooLarge("File size too large. Maximum allowed: %d MB", v.maxSize>>20)
+	}
+
+	// Read one byte past the limit so a client understating the size is
+	// caught too
+	data, err := io.ReadAll(io.LimitReader(f, v.maxSize+1))
+	if err != nil {
+		return "", apperrors.Wrap(apperrors.CodeValidation, err, "Failed to read uploaded file")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", apperrors.Internal(err)
+	}
+	if int64(len(data)) > v.maxSize {
+		return "", apperrors.PayloadTooLarge("File size too large. Maximum allowed: %d MB", v.maxSize>>20)
+	}
+
+	detected := Detect(data)
+	if !v.accepts(detected) {
+		appErr := apperrors.Validation("File content is not an accepted type. Allowed: %s", strings.Join(v.types, ", "))
+		appErr.Err = ErrType
+		return detected, appErr
+	}
+
+	if err := checkArchive(detected, data); err != nil {
+		appErr := apperrors.Validation("Archive is malformed or expands beyond the allowed size")
+		appErr.Err = err
+		return detected, appErr
+	}
+	return detected, nil
+}
+
+// accepts reports whether detected satisfies one of the validator's types
+func (v *Validator) accepts(detected string) bool {
+	if slices.Contains(v.types, detected) {
+		return true
+	}
+	return detected == TypeText && slices.Contains(v.types, TypeCSV)
+}
+
+// Detect returns the content type of data from its magic bytes. Files that
+// http.DetectContentType takes for text are only reported as TypeText when
+// all of data is valid UTF-8 without NUL bytes, so a binary cannot hide
+// behind a text prefix.
+func Detect(data []byte) string {
+	detected, _, _ := strings.Cut(http.DetectContentType(data[:min(len(data), sniffLen)]), ";")
+	if detected != TypeText {
+		return detected
+	}
+	// This also rules out UTF-16 text, which is sniffed as text but cannot
+	// be read as UTF-8
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return "application/octet-stream"
+	}
+	return TypeText
+}
+
+// checkArchive rejects ZIP and gzip data that expands beyond the limits.
+// Other types pass.
+func checkArchive(detected string, data []byte) error {
+	switch detected {
+	case TypeZIP:
+		return checkZIP(data)
+	case TypeGzip:
+		return checkGzip(data)
+	}
+	return nil
+}
+
+// checkZIP adds up the sizes the entries declare. Each entry is then read
+// up to its declared size plus one byte, since the headers can lie.
+func checkZIP(data []byte) error {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return err
+	}
+	if len(r.File) > MaxArchiveEntries {
+		return ErrArchiveBomb
+	}
+
+	limit := expansionLimit(len(data))
+	var total uint64
+	for _, file := range r.File {
+		total += file.UncompressedSize64
+		if total > uint64(limit) {
+			return ErrArchiveBomb
+		}
+	}
+
+	var read int64
+	for _, file := range r.File {
+		rc, err := file.Open()
+		if err != nil {
+			return err
+		}
+		n, err := io.Copy(io.Discard, io.LimitReader(rc, int64(file.UncompressedSize64)+1))
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		if uint64(n) > file.UncompressedSize64 {
+			return ErrArchiveBomb
+		}
+		read += n
+	}
+	if read > limit {
+		return ErrArchiveBomb
+	}
+	return nil
+}
+
+// checkGzip decompresses data up to the limit
+func checkGzip(data []byte) error {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	limit := expansionLimit(len(data))
+	n, err := io.Copy(io.Discard, io.LimitReader(r, limit+1))
+	if err != nil {
+		return err
+	}
+	if n > limit {
+		return ErrArchiveBomb
+	}
+	return nil
+}
+
+// expansionLimit is the most a compressed file of size bytes may expand to.
+// The ratio is not held against archives small enough to expand to 1 MB.
+func expansionLimit(size int) int64 {
+	return min(max(int64(size)*MaxCompressionRatio, 1<<20), MaxExpandedSize)
+}
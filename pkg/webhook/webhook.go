@@ -0,0 +1,227 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"seta-training/pkg/logger"
+)
+
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// Endpoint is a webhook destination and the secret used to sign requests to it
+type Endpoint struct {
+	URL    string
+	Secret string
+}
+
+// Result describes the outcome of a delivery
+type Result struct {
+	DeliveryID string
+	Attempts   int
+	StatusCode int
+	Duration   time.Duration
+}
+
+// Sender delivers signed JSON payloads, retrying with exponential backoff
+// when the receiver is unavailable
+type Sender struct {
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+	logger     logger.Logger
+	wg         sync.WaitGroup
+
+	// baseCtx bounds background deliveries; it is cancelled when Shutdown
+	// runs out of time
+	baseCtx context.Context
+	cancel  context.CancelFunc
+}
+
+// NewSender creates a webhook sender
+func NewSender(timeout time.Duration, maxRetries int, backoff time.Duration, log logger.Logger) *Sender {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Sender{
+		client:     &http.Client{Timeout: timeout},
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		logger:     log,
+		baseCtx:    ctx,
+		cancel:     cancel,
+	}
+}
+
+// Sign computes the signature of a payload sent at timestamp. Receivers
+// recompute it over "<timestamp>.<body>" and compare with SignatureHeader.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send delivers payload to endpoint, retrying on network errors, 429 and 5xx
+// responses until maxRetries is exhausted or ctx is done
+func (s *Sender) Send(ctx context.Context, endpoint Endpoint, event string, payload interface{}) (*Result, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	start := time.Now()
+	result := &Result{DeliveryID: uuid.New().String()}
+	var lastErr error
+
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := s.backoff << (attempt - 1)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				result.Duration = time.Since(start)
+				return result, fmt.Errorf("webhook delivery cancelled: %w", ctx.Err())
+			}
+		}
+
+		result.Attempts = attempt + 1
+		statusCode, err := s.post(ctx, endpoint, event, result.DeliveryID, body)
+		result.StatusCode = statusCode
+		if err == nil {
+			result.Duration = time.Since(start)
+			return result, nil
+		}
+
+		lastErr = err
+		if !retryable(statusCode) {
+			break
+		}
+
+		s.logger.Warn("Webhook delivery attempt failed",
+			logger.String("event", event),
+			logger.String("delivery_id", result.DeliveryID),
+			logger.Int("attempt", result.Attempts),
+			logger.Error(err),
+		)
+	}
+
+	result.Duration = time.Since(start)
+	return result, lastErr
+}
+
+// SendAsync delivers payload in the background. Failures are logged; use
+// Wait to block until in-flight deliveries have finished.
+func (s *Sender) SendAsync(endpoint Endpoint, event string, payload interface{}) {
+	s.SendAsyncFunc(endpoint, event, payload, nil)
+}
+
+// SendAsyncFunc is SendAsync that also passes the outcome to done, if set,
+// once the delivery has succeeded or given up
+func (s *Sender) SendAsyncFunc(endpoint Endpoint, event string, payload interface{}, done func(*Result, error)) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		result, err := s.Send(s.baseCtx, endpoint, event, payload)
+		if done != nil {
+			defer done(result, err)
+		}
+		if err != nil {
+			attempts := 0
+			if result != nil {
+				attempts = result.Attempts
+			}
+			s.logger.Error("Webhook delivery failed",
+				logger.String("event", event),
+				logger.String("url", endpoint.URL),
+				logger.Int("attempts", attempts),
+				logger.Error(err),
+			)
+			return
+		}
+
+		s.logger.Info("Webhook delivered",
+			logger.String("event", event),
+			logger.String("delivery_id", result.DeliveryID),
+			logger.Int("attempts", result.Attempts),
+			logger.Int("status_code", result.StatusCode),
+		)
+	}()
+}
+
+// Wait blocks until all background deliveries have finished
+func (s *Sender) Wait() {
+	s.wg.Wait()
+}
+
+// Shutdown waits for background deliveries to finish. If ctx expires first,
+// pending retries are cancelled and ctx's error is returned.
+func (s *Sender) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+func (s *Sender) post(ctx context.Context, endpoint Endpoint, event, deliveryID string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "seta-training-webhook/1.0")
+	req.Header.Set(EventHeader, event)
+	req.Header.Set(DeliveryHeader, deliveryID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	if endpoint.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(endpoint.Secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// retryable reports whether a failed attempt with statusCode is worth
+// retrying. Status 0 means the request never got a response.
+func retryable(statusCode int) bool {
+	return statusCode == 0 || statusCode == http.StatusTooManyRequests || statusCode >= 500
+}